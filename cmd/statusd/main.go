@@ -24,6 +24,14 @@ var (
 	networkID      = flag.Int("networkid", params.RopstenNetworkID, "Network identifier (integer, 1=Homestead, 3=Ropsten, 4=Rinkeby, 777=StatusChain)")
 	whisperEnabled = flag.Bool("shh", false, "SHH protocol enabled")
 	swarmEnabled   = flag.Bool("swarm", false, "Swarm protocol enabled")
+	mailServer     = flag.Bool("mailserver", false, "Archives Whisper envelopes and delivers them on demand (requires -shh)")
+	mailServerPass = flag.String("mailserverpassword", "", "Password the symmetric key of mail server requests is derived from")
+	listenAddr     = flag.String("listenaddr", params.ListenAddr, "P2P listener's IP address and port, port 0 makes the OS choose a random one")
+	listenPorts    = flag.String("listenports", "", "Range of P2P listener ports (e.g. 30303-30310), the first available one is used")
+	natSpec        = flag.String("nat", params.NAT, `NAT port mapping mechanism, one of: "any", "none", "upnp", "pmp", "extip:<IP>"`)
+	syncMode       = flag.String("syncmode", params.SyncMode, `Blockchain sync mode, one of: "light", "fast", and "full"`)
+	peerBlacklist  = flag.String("blacklist", "", "Comma-separated enode URLs, IP addresses and CIDR ranges of peers which are never connected")
+	peerWhitelist  = flag.String("whitelist", "", "Comma-separated enode URLs, IP addresses and CIDR ranges of the only peers which are connected")
 	httpEnabled    = flag.Bool("http", false, "HTTP RPC endpoint enabled (default: false)")
 	httpPort       = flag.Int("httpport", params.HTTPPort, "HTTP RPC server's listening port")
 	ipcEnabled     = flag.Bool("ipc", false, "IPC RPC endpoint enabled")
@@ -88,9 +96,21 @@ func makeNodeConfig() (*params.NodeConfig, error) {
 	}
 
 	nodeConfig.LightEthConfig.Enabled = true
+	nodeConfig.SyncMode = *syncMode
+	nodeConfig.ListenAddr = *listenAddr
+	nodeConfig.ListenPortRange = *listenPorts
+	nodeConfig.NAT = *natSpec
+	if *peerBlacklist != "" {
+		nodeConfig.PeerBlacklist = strings.Split(*peerBlacklist, ",")
+	}
+	if *peerWhitelist != "" {
+		nodeConfig.PeerWhitelist = strings.Split(*peerWhitelist, ",")
+	}
 	nodeConfig.RPCEnabled = *httpEnabled
 	nodeConfig.WhisperConfig.Enabled = *whisperEnabled
 	nodeConfig.SwarmConfig.Enabled = *swarmEnabled
+	nodeConfig.MailServerConfig.Enabled = *mailServer
+	nodeConfig.MailServerConfig.Password = *mailServerPass
 
 	// RPC configuration
 	if !*httpEnabled {
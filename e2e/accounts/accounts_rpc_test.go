@@ -23,8 +23,7 @@ func (s *AccountsTestSuite) TestRPCEthAccounts() {
 	defer s.StopTestBackend()
 
 	// log into test account
-	err := s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
-	s.NoError(err)
+	s.SelectTestAccount1()
 
 	rpcClient := s.Backend.NodeManager().RPCClient()
 	s.NotNil(rpcClient)
@@ -50,8 +49,7 @@ func (s *AccountsTestSuite) TestRPCEthAccountsWithUpstream() {
 	defer s.StopTestBackend()
 
 	// log into test account
-	err = s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
-	s.NoError(err)
+	s.SelectTestAccount1()
 
 	rpcClient := s.Backend.NodeManager().RPCClient()
 	s.NotNil(rpcClient)
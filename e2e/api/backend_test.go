@@ -1,10 +1,13 @@
 package api_test
 
 import (
+	"context"
+	"encoding/json"
 	"math/rand"
 	"testing"
 	"time"
 
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/status-im/status-go/e2e"
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/common"
@@ -12,6 +15,8 @@ import (
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/status-im/status-go/geth/txqueue"
 	. "github.com/status-im/status-go/testing"
 	"github.com/stretchr/testify/suite"
 )
@@ -288,3 +293,71 @@ func (s *APIBackendTestSuite) TestRestartNode() {
 	s.NoError(err)
 	s.Equal(GetHeadHash(), firstHash)
 }
+
+func (s *APIBackendTestSuite) TestNodeStatus() {
+	require := s.Require()
+
+	require.False(s.Backend.NodeManager().NodeStatus().Running)
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	require.NoError(err)
+	nodeStarted, err := s.Backend.StartNode(nodeConfig)
+	require.NoError(err)
+	<-nodeStarted
+	defer s.StopTestBackend()
+
+	var response struct {
+		Result common.NodeStatus `json:"result"`
+	}
+	rawResponse := s.Backend.CallRPC(`{"jsonrpc":"2.0","method":"status_health","params":[],"id":1}`)
+	require.NoError(json.Unmarshal([]byte(rawResponse), &response), rawResponse)
+
+	status := response.Result
+	require.True(status.Running)
+	require.Equal(nodeConfig.NetworkID, status.NetworkID)
+	require.NotEqual(gethcommon.Hash{}, status.LatestBlockHash, "at least genesis block is expected")
+}
+
+func (s *APIBackendTestSuite) TestStopNodeDrainsTransactionQueue() {
+	require := s.Require()
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	require.NoError(err)
+	nodeConfig.ShutdownDrainTimeout = 10
+	nodeStarted, err := s.Backend.StartNode(nodeConfig)
+	require.NoError(err)
+	<-nodeStarted
+
+	queuedTxID := make(chan common.QueuedTxID, 1)
+	signal.SetDefaultNodeNotificationHandler(func(rawSignal string) {
+		var envelope signal.Envelope
+		if err := json.Unmarshal([]byte(rawSignal), &envelope); err != nil || envelope.Type != txqueue.EventTransactionQueued {
+			return
+		}
+		queuedTxID <- common.QueuedTxID(envelope.Event.(map[string]interface{})["id"].(string))
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	sendResult := make(chan error, 1)
+	go func() {
+		_, err := s.Backend.SendTransaction(context.Background(), common.SendTxArgs{
+			From: common.FromAddress(TestConfig.Account1.Address),
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+		sendResult <- err
+	}()
+	txID := <-queuedTxID
+
+	nodeStopped, err := s.Backend.StopNode()
+	require.NoError(err)
+
+	// node keeps running until the queued transaction is discarded
+	time.Sleep(500 * time.Millisecond)
+	require.True(s.Backend.IsNodeRunning())
+
+	require.NoError(s.Backend.DiscardTransaction(txID))
+	require.Equal(txqueue.ErrQueuedTxDiscarded, <-sendResult)
+
+	<-nodeStopped
+	require.False(s.Backend.IsNodeRunning())
+}
@@ -130,7 +130,7 @@ func (s *JailRPCTestSuite) TestContractDeployment() {
 			event := envelope.Event.(map[string]interface{})
 			s.T().Logf("transaction queued and will be completed shortly, id: %v", event["id"])
 
-			s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+			s.SelectTestAccount1()
 
 			txID := event["id"].(string)
 			txHash, err = s.Backend.CompleteTransaction(common.QueuedTxID(txID), TestConfig.Account1.Password)
@@ -195,8 +195,7 @@ func (s *JailRPCTestSuite) TestJailVMPersistence() {
 	EnsureNodeSync(s.Backend.NodeManager())
 
 	// log into account from which transactions will be sent
-	err := s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
-	s.NoError(err, "cannot select account: %v", TestConfig.Account1.Address)
+	s.SelectTestAccount1()
 
 	type testCase struct {
 		command   string
@@ -2,6 +2,10 @@ package node_test
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -9,13 +13,19 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/les"
 	gethnode "github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/rpc"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/e2e"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/signal"
 	. "github.com/status-im/status-go/testing"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -38,9 +48,9 @@ func (s *ManagerTestSuite) TestReferencesWithoutStartedNode() {
 		expectedErr error
 	}{
 		{
-			"non-null manager, no running node, RestartNode()",
+			"non-null manager, no running node, RestartNode(nil)",
 			func() (interface{}, error) {
-				return s.NodeManager.RestartNode()
+				return s.NodeManager.RestartNode(nil)
 			},
 			node.ErrNoRunningNode,
 		},
@@ -332,7 +342,7 @@ func (s *ManagerTestSuite) TestRestartNode() {
 	defer s.StopTestNode()
 
 	s.True(s.NodeManager.IsNodeRunning())
-	nodeReady, err := s.NodeManager.RestartNode()
+	nodeReady, err := s.NodeManager.RestartNode(nil)
 	s.NoError(err)
 	// new node, with previous config should be running
 	<-nodeReady
@@ -344,6 +354,95 @@ func (s *ManagerTestSuite) TestRestartNode() {
 	s.Equal(GetHeadHash(), firstHash)
 }
 
+func (s *ManagerTestSuite) TestRestartNodeWithConfig() {
+	s.StartTestNode()
+	defer s.StopTestNode()
+
+	runningNode, err := s.NodeManager.Node()
+	s.NoError(err)
+	config, err := s.NodeManager.NodeConfig()
+	s.NoError(err)
+
+	// hot-reloadable fields are applied in place
+	newConfig := *config
+	newConfig.LogLevel = "DEBUG"
+	bootClusterConfig := *config.BootClusterConfig
+	bootClusterConfig.BootNodes = append([]string{}, config.BootClusterConfig.BootNodes...)
+	newConfig.BootClusterConfig = &bootClusterConfig
+
+	nodeReady, err := s.NodeManager.RestartNode(&newConfig)
+	s.NoError(err)
+	<-nodeReady
+
+	sameNode, err := s.NodeManager.Node()
+	s.NoError(err)
+	s.True(runningNode == sameNode, "node should not be restarted")
+	reloadedConfig, err := s.NodeManager.NodeConfig()
+	s.NoError(err)
+	s.Equal("DEBUG", reloadedConfig.LogLevel)
+
+	// other fields require restart
+	restartConfig := newConfig
+	restartConfig.MaxPeers++
+
+	nodeReady, err = s.NodeManager.RestartNode(&restartConfig)
+	s.NoError(err)
+	<-nodeReady
+	s.True(s.NodeManager.IsNodeRunning())
+
+	restartedNode, err := s.NodeManager.Node()
+	s.NoError(err)
+	s.False(runningNode == restartedNode, "node should be restarted")
+	restartedConfig, err := s.NodeManager.NodeConfig()
+	s.NoError(err)
+	s.Equal(restartConfig.MaxPeers, restartedConfig.MaxPeers)
+}
+
+func (s *ManagerTestSuite) TestRestartNodeWithLogFile() {
+	s.StartTestNode()
+	defer s.StopTestNode()
+
+	runningNode, err := s.NodeManager.Node()
+	s.NoError(err)
+	config, err := s.NodeManager.NodeConfig()
+	s.NoError(err)
+	logFile := filepath.Join(config.DataDir, "reloaded.log")
+
+	newConfig := *config
+	newConfig.LogLevel = "INFO"
+	newConfig.LogFile = logFile
+	newConfig.PeerBlacklist = []string{"10.0.0.0/8"}
+	nodeReady, err := s.NodeManager.RestartNode(&newConfig)
+	s.NoError(err)
+	<-nodeReady
+	log.Info("logged to file")
+
+	// the running node's config is a copy, which later changes of the caller's config don't affect
+	newConfig.PeerBlacklist[0] = "192.168.0.0/16"
+	newConfig.LogFile = ""
+	reloadedConfig, err := s.NodeManager.NodeConfig()
+	s.NoError(err)
+	s.Equal(logFile, reloadedConfig.LogFile)
+	s.Equal([]string{"10.0.0.0/8"}, reloadedConfig.PeerBlacklist)
+
+	// the node logs to stdout again once the log file is removed from the config
+	stdoutConfig := *reloadedConfig
+	stdoutConfig.LogLevel = config.LogLevel
+	stdoutConfig.LogFile = ""
+	nodeReady, err = s.NodeManager.RestartNode(&stdoutConfig)
+	s.NoError(err)
+	<-nodeReady
+	log.Error("logged to stdout")
+
+	sameNode, err := s.NodeManager.Node()
+	s.NoError(err)
+	s.True(runningNode == sameNode, "node should not be restarted")
+	data, err := ioutil.ReadFile(logFile)
+	s.NoError(err)
+	s.Contains(string(data), "logged to file")
+	s.NotContains(string(data), "logged to stdout")
+}
+
 // TODO(adam): race conditions should be tested with -race flag and unit tests, if possible.
 // TODO(boris): going via https://github.com/status-im/status-go/pull/433#issuecomment-342232645 . Testing should be with -race flag
 // Research if it's possible to do the same with unit tests.
@@ -398,7 +497,7 @@ func (s *ManagerTestSuite) TestRestartNode() {
 //		// },
 //		func(config *params.NodeConfig) {
 //			log.Info("RestartNode()")
-//			_, err := s.NodeManager.RestartNode()
+//			_, err := s.NodeManager.RestartNode(nil)
 //			s.T().Logf("RestartNode(), error: %v", err)
 //			progress <- struct{}{}
 //		},
@@ -523,3 +622,537 @@ func (s *ManagerTestSuite) TestNodeStartCrash() {
 	s.NodeManager.StopNode()           //nolint: errcheck
 	signal.ResetDefaultNodeNotificationHandler()
 }
+
+func (s *ManagerTestSuite) TestMakeNodeWithLESProtocolVersions() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	s.NoError(err)
+	nodeConfig.LightEthConfig.Enabled = true
+
+	nodeConfig.LightEthConfig.ProtocolVersions = []uint{1}
+	_, err = node.MakeNode(nodeConfig, node.LogDeliveryService{})
+	s.NoError(err)
+
+	// les/2 is not implemented by the bundled LES
+	nodeConfig.LightEthConfig.ProtocolVersions = []uint{2}
+	_, err = node.MakeNode(nodeConfig, node.LogDeliveryService{})
+	s.Error(err)
+	s.Contains(err.Error(), node.ErrUnsupportedLESVersion.Error())
+}
+
+func (s *ManagerTestSuite) TestDiscoveryTopics() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	s.Require().NoError(err)
+	s.True(nodeConfig.DiscoveryV5, "discovery v5 is expected to be enabled by default")
+
+	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+	s.Require().NoError(err)
+	<-nodeStarted
+
+	s.NoError(s.NodeManager.RegisterTopic("e2e-topic"))
+	s.NoError(s.NodeManager.RegisterTopic("e2e-topic"), "registering the same topic again should be a no-op")
+	s.NoError(s.NodeManager.UnregisterTopic("e2e-topic"))
+	s.Equal(node.ErrTopicNotRegistered, s.NodeManager.UnregisterTopic("e2e-topic"))
+
+	// there are no other nodes registered under the topic
+	enodes, err := s.NodeManager.SearchTopic("e2e-topic", 1, 100*time.Millisecond)
+	s.NoError(err)
+	s.Empty(enodes)
+
+	_, err = s.NodeManager.SearchTopic("e2e-topic", 0, time.Second)
+	s.Equal(node.ErrInvalidSearchLimit, err)
+
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.Require().NoError(err)
+	<-nodeStopped
+
+	nodeConfig.DiscoveryV5 = false
+	nodeStarted, err = s.NodeManager.StartNode(nodeConfig)
+	s.Require().NoError(err)
+	<-nodeStarted
+
+	s.Equal(node.ErrDiscoveryV5Disabled, s.NodeManager.RegisterTopic("e2e-topic"))
+	_, err = s.NodeManager.SearchTopic("e2e-topic", 1, time.Second)
+	s.Equal(node.ErrDiscoveryV5Disabled, err)
+
+	nodeStopped, err = s.NodeManager.StopNode()
+	s.Require().NoError(err)
+	<-nodeStopped
+}
+
+func (s *ManagerTestSuite) TestFullSyncMode() {
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	s.Require().NoError(err)
+	nodeConfig.SyncMode = params.FullSyncMode
+
+	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+	s.Require().NoError(err)
+	<-nodeStarted
+	defer func() {
+		// filter system of eth service subscribes to chain events asynchronously,
+		// and panics if the chain is closed before that
+		time.Sleep(100 * time.Millisecond)
+		nodeStopped, err := s.NodeManager.StopNode()
+		s.NoError(err)
+		<-nodeStopped
+	}()
+
+	ethService, err := s.NodeManager.EthereumService()
+	s.NoError(err)
+	s.NotNil(ethService)
+
+	_, err = s.NodeManager.LightEthereumService()
+	s.Equal(node.ErrInvalidLightEthereumService, err)
+
+	status := s.NodeManager.NodeStatus()
+	s.True(status.Running)
+	s.Equal(params.FullSyncMode, status.SyncMode)
+	s.NotZero(s.NodeManager.Metrics().LevelDBSize, "chain database is expected to be written")
+}
+
+func (s *ManagerTestSuite) TestSubscribeLifecycleEvents() {
+	events := make(chan common.NodeEvent, 10)
+	sub := s.NodeManager.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	nextEvent := func() common.NodeEvent {
+		for {
+			select {
+			case e := <-events:
+				// sync events depend on peers, and may come at any point
+				if e.Type == common.SyncStarted || e.Type == common.SyncFinished {
+					continue
+				}
+				return e
+			case <-time.After(10 * time.Second):
+				s.FailNow("timed out waiting for node event")
+			}
+		}
+	}
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	s.Require().NoError(err)
+	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+	s.Require().NoError(err)
+	<-nodeStarted
+
+	s.Equal(common.NodeStarting, nextEvent().Type)
+	s.Equal(common.NodeStarted, nextEvent().Type)
+
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.Require().NoError(err)
+	<-nodeStopped
+
+	s.Equal(common.NodeStopped, nextEvent().Type)
+}
+
+func (s *ManagerTestSuite) TestSupervisorRestartsCrashedNode() {
+	events := make(chan common.NodeEvent, 10)
+	sub := s.NodeManager.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	nextEvent := func() common.NodeEvent {
+		for {
+			select {
+			case e := <-events:
+				if e.Type == common.SyncStarted || e.Type == common.SyncFinished {
+					continue
+				}
+				return e
+			case <-time.After(10 * time.Second):
+				s.FailNow("timed out waiting for node event")
+			}
+		}
+	}
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	s.Require().NoError(err)
+	nodeConfig.SupervisorConfig = params.SupervisorConfig{
+		Enabled:        true,
+		MaxRestarts:    1,
+		InitialBackoff: 10,
+		MaxBackoff:     10,
+	}
+
+	nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+	s.Require().NoError(err)
+	<-nodeStarted
+	s.Equal(common.NodeStarting, nextEvent().Type)
+	s.Equal(common.NodeStarted, nextEvent().Type)
+
+	// stopping the underlying node bypassing the manager simulates a crash
+	runningNode, err := s.NodeManager.Node()
+	s.Require().NoError(err)
+	s.Require().NoError(runningNode.Stop())
+
+	crash := nextEvent()
+	s.Equal(common.NodeCrashed, crash.Type)
+	s.Equal(node.ErrNodeStoppedUnexpectedly, crash.Error)
+	restart := nextEvent()
+	s.Equal(common.NodeRestarting, restart.Type)
+	s.Equal(1, restart.Attempt)
+	s.Equal(common.NodeStarting, nextEvent().Type)
+	s.Equal(common.NodeStarted, nextEvent().Type)
+	s.True(s.NodeManager.IsNodeRunning())
+
+	// node stopped with the manager is not restarted
+	nodeStopped, err := s.NodeManager.StopNode()
+	s.Require().NoError(err)
+	<-nodeStopped
+	s.Equal(common.NodeStopped, nextEvent().Type)
+
+	time.Sleep(100 * time.Millisecond)
+	s.False(s.NodeManager.IsNodeRunning())
+}
+
+func (s *ManagerTestSuite) TestSupervisorIgnoresStoppedNode() {
+	events := make(chan common.NodeEvent, 100)
+	sub := s.NodeManager.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	nodeConfig, err := e2e.MakeTestNodeConfig(GetNetworkID())
+	s.Require().NoError(err)
+	nodeConfig.SupervisorConfig = params.SupervisorConfig{
+		Enabled:        true,
+		MaxRestarts:    3,
+		InitialBackoff: 10,
+		MaxBackoff:     10,
+	}
+
+	// nodes stopped or restarted with the manager are not considered crashed
+	for i := 0; i < 3; i++ {
+		nodeStarted, err := s.NodeManager.StartNode(nodeConfig)
+		s.Require().NoError(err)
+		<-nodeStarted
+
+		nodeRestarted, err := s.NodeManager.RestartNode(nil)
+		s.Require().NoError(err)
+		<-nodeRestarted
+		s.True(s.NodeManager.IsNodeRunning())
+
+		nodeStopped, err := s.NodeManager.StopNode()
+		s.Require().NoError(err)
+		<-nodeStopped
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	s.False(s.NodeManager.IsNodeRunning())
+
+	for len(events) > 0 {
+		e := <-events
+		s.NotEqual(common.NodeCrashed, e.Type)
+		s.NotEqual(common.NodeRestarting, e.Type)
+	}
+}
+
+func TestRegistryRunsIndependentNodes(t *testing.T) {
+	registry := node.NewRegistry()
+
+	started := make(chan string, 2)
+	for _, name := range []string{"wallet", "dev"} {
+		nodeManager, err := registry.Create(name)
+		require.NoError(t, err)
+
+		nodeManager.SetSignalHandler(func(envelope signal.Envelope) {
+			if envelope.Type == signal.EventNodeStarted {
+				started <- envelope.Node
+			}
+		})
+
+		env := NewIsolatedTestEnv(t)
+		defer env.Teardown()
+
+		config, err := params.NewNodeConfig(env.DataDir, params.StatusChainNetworkID, true)
+		require.NoError(t, err)
+		env.Configure(config)
+		config.BootClusterConfig.Enabled = false
+
+		nodeStarted, err := nodeManager.StartNode(config)
+		require.NoError(t, err)
+		<-nodeStarted
+		require.True(t, nodeManager.IsNodeRunning())
+	}
+
+	startedNodes := map[string]bool{<-started: true, <-started: true}
+	require.Equal(t, map[string]bool{"wallet": true, "dev": true}, startedNodes)
+
+	wallet, err := registry.Get("wallet")
+	require.NoError(t, err)
+	dev, err := registry.Get("dev")
+	require.NoError(t, err)
+	require.False(t, wallet.RPCClient() == dev.RPCClient())
+
+	for _, nodeManager := range []*node.NodeManager{wallet, dev} {
+		var version string
+		require.NoError(t, nodeManager.RPCClient().Call(&version, "web3_clientVersion"))
+		require.NotEmpty(t, version)
+
+		require.Equal(t, node.ErrNodeExists, registry.Remove(nodeManager.Name()))
+		nodeStopped, err := nodeManager.StopNode()
+		require.NoError(t, err)
+		<-nodeStopped
+		require.NoError(t, registry.Remove(nodeManager.Name()))
+	}
+	require.Empty(t, registry.Names())
+}
+
+func TestPeerManagement(t *testing.T) {
+	local, remote, teardown := startConnectableNodes(t, false)
+	defer teardown()
+
+	remoteNode, err := remote.Node()
+	require.NoError(t, err)
+	remoteURL := remoteNode.Server().NodeInfo().Enode
+
+	require.NoError(t, local.AddTrustedPeer(remoteURL))
+	waitForPeers(t, local, 1)
+
+	localNode, err := local.Node()
+	require.NoError(t, err)
+	trustedNodes := localNode.Server().Config.TrustedNodes
+	require.Len(t, trustedNodes, 1, "trusted peer is added to the running server")
+	require.Equal(t, remoteURL, trustedNodes[0].String())
+
+	// a peer trusted already is not added twice
+	require.NoError(t, local.AddTrustedPeer(remoteURL))
+	require.Len(t, localNode.Server().Config.TrustedNodes, 1)
+
+	require.NoError(t, local.RemovePeer(remoteURL))
+	waitForPeers(t, local, 0)
+
+	nodeRestarted, err := local.RestartNode(nil)
+	require.NoError(t, err)
+	<-nodeRestarted
+	localNode, err = local.Node()
+	require.NoError(t, err)
+	trustedNodes = localNode.Server().Config.TrustedNodes
+	require.Len(t, trustedNodes, 1)
+	require.Equal(t, remoteURL, trustedNodes[0].String())
+}
+
+func TestSetBootNodes(t *testing.T) {
+	local, remote, teardown := startConnectableNodes(t, true)
+	defer teardown()
+
+	remoteNode, err := remote.Node()
+	require.NoError(t, err)
+	remoteURL := remoteNode.Server().NodeInfo().Enode
+
+	require.NoError(t, local.SetBootNodes([]string{remoteURL}))
+	waitForPeers(t, local, 1)
+
+	// invalid list is not applied partially
+	require.Error(t, local.SetBootNodes([]string{"enode://invalid"}))
+	config, err := local.NodeConfig()
+	require.NoError(t, err)
+	require.Equal(t, []string{remoteURL}, config.BootClusterConfig.BootNodes)
+
+	require.NoError(t, local.SetBootNodes(nil))
+	waitForPeers(t, local, 0)
+	config, err = local.NodeConfig()
+	require.NoError(t, err)
+	require.Empty(t, config.BootClusterConfig.BootNodes)
+}
+
+func TestSetBootNodesSeedsDiscovery(t *testing.T) {
+	local, remote, teardown := startConnectableNodes(t, true)
+	defer teardown()
+
+	localNode, err := local.Node()
+	require.NoError(t, err)
+	remoteNode, err := remote.Node()
+	require.NoError(t, err)
+	require.NotNil(t, remoteNode.Server().DiscV5, "discovery v5 is expected to be enabled by default")
+	self := remoteNode.Server().Self()
+	remoteURL := discover.NewNode(self.ID, self.IP, remoteNode.Server().DiscV5.Self().UDP, self.TCP).String()
+
+	// discovery table is seeded with the new boot nodes, rather than with the default ones
+	require.NoError(t, local.SetBootNodes([]string{remoteURL}))
+	for i := 0; ; i++ {
+		nodes := make([]*discv5.Node, 16)
+		nodes = nodes[:localNode.Server().DiscV5.ReadRandomNodes(nodes)]
+		if len(nodes) == 1 && nodes[0].ID == discv5.NodeID(self.ID) {
+			break
+		}
+		if i == 100 {
+			t.Fatalf("discovery table isn't seeded with the boot node, it has %v", nodes)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	local, remote, teardown := startConnectableNodes(t, false)
+	defer teardown()
+
+	remoteNode, err := remote.Node()
+	require.NoError(t, err)
+	require.NoError(t, local.AddPeer(remoteNode.Server().NodeInfo().Enode))
+	waitForPeers(t, local, 1)
+
+	metrics := local.Metrics()
+	require.NotZero(t, metrics.Goroutines)
+	require.NotZero(t, metrics.HeapAlloc)
+	require.NotZero(t, metrics.P2POutboundIngress, "handshake is expected to be received from the dialed peer")
+	require.NotZero(t, metrics.P2POutboundEgress, "handshake is expected to be sent to the dialed peer")
+	require.Equal(t, int64(1), metrics.Services["shh"]["peers"])
+
+	// traffic of accepted connections is not counted
+	remoteMetrics := remote.Metrics()
+	require.Zero(t, remoteMetrics.P2POutboundIngress)
+	require.Equal(t, int64(1), remoteMetrics.Services["shh"]["peers"])
+}
+
+func TestPeerFilter(t *testing.T) {
+	local, remote, teardown := startConnectableNodes(t, false)
+	defer teardown()
+
+	localNode, err := local.Node()
+	require.NoError(t, err)
+	remoteNode, err := remote.Node()
+	require.NoError(t, err)
+	remoteURL := remoteNode.Server().NodeInfo().Enode
+
+	require.NoError(t, local.AddPeer(remoteURL))
+	waitForPeers(t, local, 1)
+
+	// connected peers which are not whitelisted anymore are dropped
+	require.NoError(t, local.SetPeerWhitelist([]string{"10.0.0.0/8"}))
+	waitForPeers(t, local, 0)
+	require.NoError(t, local.SetPeerWhitelist(nil))
+
+	// invalid entries are not applied
+	require.Equal(t, node.ErrInvalidPeerFilter, local.BlacklistPeer("127.0.0.1/33"))
+	require.Equal(t, node.ErrPeerNotBlacklisted, local.UnblacklistPeer(remoteURL))
+
+	require.NoError(t, local.BlacklistPeer(remoteURL))
+	config, err := local.NodeConfig()
+	require.NoError(t, err)
+	require.Equal(t, []string{remoteURL}, config.PeerBlacklist)
+
+	// blacklisted peer connecting to the node is disconnected right after the handshake
+	events := make(chan *p2p.PeerEvent, 10)
+	sub := localNode.Server().SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	require.NoError(t, remote.AddPeer(localNode.Server().NodeInfo().Enode))
+	timeout := time.After(10 * time.Second)
+	for dropped := false; !dropped; {
+		select {
+		case ev := <-events:
+			dropped = ev.Type == p2p.PeerEventTypeDrop && ev.Peer == remoteNode.Server().Self().ID
+		case <-timeout:
+			t.Fatal("timed out waiting for blacklisted peer to be dropped")
+		}
+	}
+
+	require.NoError(t, local.UnblacklistPeer(remoteURL))
+	config, err = local.NodeConfig()
+	require.NoError(t, err)
+	require.Empty(t, config.PeerBlacklist)
+}
+
+func TestKnownPeersAreDialedAfterRestart(t *testing.T) {
+	local, remote, teardown := startConnectableNodes(t, false)
+	defer teardown()
+
+	remoteNode, err := remote.Node()
+	require.NoError(t, err)
+	require.NoError(t, local.AddPeer(remoteNode.Server().NodeInfo().Enode))
+	waitForPeers(t, local, 1)
+
+	// restarted node forgets static peers, the remote one is dialed as a known peer
+	nodeRestarted, err := local.RestartNode(nil)
+	require.NoError(t, err)
+	<-nodeRestarted
+	waitForPeers(t, local, 1)
+}
+
+func TestListenPortRange(t *testing.T) {
+	startNode := func(env *IsolatedTestEnv, portRange string) int {
+		config, err := params.NewNodeConfig(env.DataDir, params.StatusChainNetworkID, true)
+		require.NoError(t, err)
+		env.Configure(config)
+		config.ListenAddr = "127.0.0.1:0"
+		config.ListenPortRange = portRange
+		config.BootClusterConfig.Enabled = false
+
+		nodeManager := node.NewNodeManager()
+		nodeStarted, err := nodeManager.StartNode(config)
+		require.NoError(t, err)
+		<-nodeStarted
+		port := nodeManager.NodeStatus().ListenPort
+
+		nodeStopped, err := nodeManager.StopNode()
+		require.NoError(t, err)
+		<-nodeStopped
+
+		return port
+	}
+
+	env := NewIsolatedTestEnv(t)
+	defer env.Teardown()
+	portRange := fmt.Sprintf("%d-%d", env.ListenPort, env.ListenPort+10)
+
+	// the first port of the range is taken by another listener
+	listener, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", env.ListenPort))
+	require.NoError(t, err)
+	port := startNode(env, portRange)
+	require.True(t, port > env.ListenPort && port <= env.ListenPort+10, "port %d is out of range", port)
+
+	require.NoError(t, listener.Close())
+	require.Equal(t, env.ListenPort, startNode(env, portRange))
+
+	// port 0 makes the OS choose a random one
+	require.NotZero(t, startNode(env, ""))
+}
+
+// startConnectableNodes starts two nodes in isolated environments, which stay
+// connected once one is added as a peer of the other.
+func startConnectableNodes(t *testing.T, bootClusterEnabled bool) (local, remote *node.NodeManager, teardown func()) {
+	nodeManagers := make([]*node.NodeManager, 2)
+	var teardowns []func()
+	teardown = func() {
+		for i := len(teardowns) - 1; i >= 0; i-- {
+			teardowns[i]()
+		}
+	}
+
+	for i := range nodeManagers {
+		env := NewIsolatedTestEnv(t)
+		teardowns = append(teardowns, env.Teardown)
+
+		config, err := params.NewNodeConfig(env.DataDir, params.StatusChainNetworkID, true)
+		require.NoError(t, err)
+		env.Configure(config)
+		// light clients drop each other, whisper keeps peers connected
+		config.LightEthConfig.Enabled = false
+		config.BootClusterConfig.Enabled = bootClusterEnabled
+		config.BootClusterConfig.BootNodes = nil
+
+		nodeManager := node.NewNodeManager()
+		nodeStarted, err := nodeManager.StartNode(config)
+		if err != nil {
+			teardown()
+			t.Fatalf("failed to start node: %v", err)
+		}
+		<-nodeStarted
+		teardowns = append(teardowns, func() {
+			nodeManager.StopNode() //nolint: errcheck
+		})
+		nodeManagers[i] = nodeManager
+	}
+
+	return nodeManagers[0], nodeManagers[1], teardown
+}
+
+func waitForPeers(t *testing.T, nodeManager *node.NodeManager, n int) {
+	for i := 0; i < 100; i++ {
+		peers, err := nodeManager.Peers()
+		require.NoError(t, err)
+		if len(peers) == n {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d peers", n)
+}
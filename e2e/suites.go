@@ -19,6 +19,11 @@ type NodeManagerTestSuite struct {
 
 func init() {
 	for id := range TestNetworkNames {
+		// restore previously synced chaindata unless -freshsync is set
+		if _, err := RestoreSnapshot(id); err != nil {
+			panic(err)
+		}
+
 		nodeConfig, err := MakeTestNodeConfig(id)
 		if err != nil {
 			panic(err)
@@ -61,6 +66,14 @@ func (s *NodeManagerTestSuite) StopTestNode() {
 	s.NoError(err)
 	<-nodeStopped
 	s.False(s.NodeManager.IsNodeRunning())
+	s.NoError(saveSyncedSnapshot())
+}
+
+// TearDownTest stops the node if a test left it running.
+func (s *NodeManagerTestSuite) TearDownTest() {
+	if s.NodeManager != nil && s.NodeManager.IsNodeRunning() {
+		s.StopTestNode()
+	}
 }
 
 // BackendTestSuite is a test suite with api.StatusBackend initialized
@@ -76,8 +89,11 @@ func (s *BackendTestSuite) SetupTest() {
 	s.NotNil(s.Backend)
 }
 
-// TearDownTest cleans up the packages state.
+// TearDownTest stops the node if a test left it running, and cleans up the packages state.
 func (s *BackendTestSuite) TearDownTest() {
+	if s.Backend.IsNodeRunning() {
+		s.StopTestBackend()
+	}
 	signal.ResetDefaultNodeNotificationHandler()
 }
 
@@ -109,6 +125,7 @@ func (s *BackendTestSuite) StopTestBackend() {
 	s.NoError(err)
 	<-backendStopped
 	s.False(s.Backend.IsNodeRunning())
+	s.NoError(saveSyncedSnapshot())
 }
 
 // RestartTestNode restarts a currently running node.
@@ -120,6 +137,35 @@ func (s *BackendTestSuite) RestartTestNode() {
 	s.True(s.Backend.IsNodeRunning())
 }
 
+// ResetChainData removes chain data and waits for the node to start again.
+func (s *BackendTestSuite) ResetChainData() {
+	s.True(s.Backend.IsNodeRunning())
+	nodeReady, err := s.Backend.ResetChainData()
+	s.NoError(err)
+	<-nodeReady
+	s.True(s.Backend.IsNodeRunning())
+}
+
+// SelectAccount logs into an account.
+func (s *BackendTestSuite) SelectAccount(address, password string) {
+	s.NoError(s.Backend.AccountManager().SelectAccount(address, password), "cannot select account: %v", address)
+}
+
+// SelectTestAccount1 logs into the first test account.
+func (s *BackendTestSuite) SelectTestAccount1() {
+	s.SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
+}
+
+// SelectTestAccount2 logs into the second test account.
+func (s *BackendTestSuite) SelectTestAccount2() {
+	s.SelectAccount(TestConfig.Account2.Address, TestConfig.Account2.Password)
+}
+
+// Logout clears the selected account.
+func (s *BackendTestSuite) Logout() {
+	s.NoError(s.Backend.AccountManager().Logout())
+}
+
 // WhisperService returns a reference to the Whisper service.
 func (s *BackendTestSuite) WhisperService() *whisper.Whisper {
 	whisperService, err := s.Backend.NodeManager().WhisperService()
@@ -143,6 +189,16 @@ func (s *BackendTestSuite) TxQueueManager() common.TxQueueManager {
 	return s.Backend.TxQueueManager()
 }
 
+// saveSyncedSnapshot saves chaindata of the test network, unless
+// the chain is served by an external node.
+func saveSyncedSnapshot() error {
+	if ExternalNodeURL() != "" {
+		return nil
+	}
+
+	return SaveSyncedSnapshot(GetNetworkID())
+}
+
 func importTestAccouns(keyStoreDir string) (err error) {
 	log.Debug("Import accounts to", keyStoreDir)
 
@@ -24,8 +24,15 @@ func WithUpstream(url string) TestNodeOption {
 	}
 }
 
+// WithIsolatedEnv returns TestNodeOption which makes node use data dir and ports of a given env.
+func WithIsolatedEnv(env *IsolatedTestEnv) TestNodeOption {
+	return env.Configure
+}
+
 // MakeTestNodeConfig defines a function to return a giving params.NodeConfig
 // where specific network addresses are assigned based on provieded network id.
+// If -nodeurl flag is set, the config uses the external node as upstream.
+// LES protocol versions are restricted with -lesversion flag.
 func MakeTestNodeConfig(networkID int) (*params.NodeConfig, error) {
 	testDir := filepath.Join(TestDataDir, TestNetworkNames[networkID])
 
@@ -52,6 +59,15 @@ func MakeTestNodeConfig(networkID int) (*params.NodeConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	// with -lesversion, only selected LES protocols are advertised
+	nodeConfig.LightEthConfig.ProtocolVersions = LESProtocolVersions()
+
+	// with -nodeurl, chain requests are proxied to the external node
+	if url := ExternalNodeURL(); url != "" {
+		WithUpstream(url)(nodeConfig)
+	}
+
 	return nodeConfig, nil
 }
 
@@ -37,8 +37,7 @@ func (s *TransactionsTestSuite) TestCallRPCSendTransaction() {
 
 	EnsureNodeSync(s.Backend.NodeManager())
 
-	err := s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
-	s.NoError(err)
+	s.SelectTestAccount1()
 
 	transactionCompleted := make(chan struct{})
 
@@ -89,8 +88,7 @@ func (s *TransactionsTestSuite) TestCallRPCSendTransactionUpstream() {
 	s.StartTestBackend(e2e.WithUpstream(addr))
 	defer s.StopTestBackend()
 
-	err = s.Backend.AccountManager().SelectAccount(TestConfig.Account2.Address, TestConfig.Account2.Password)
-	s.NoError(err)
+	s.SelectTestAccount2()
 
 	transactionCompleted := make(chan struct{})
 
@@ -188,7 +186,7 @@ func (s *TransactionsTestSuite) TestSendContractTx() {
 
 			// the third call will work as expected (as we are logged in with correct credentials)
 			log.Info("trying to complete with correct user, this should succeed")
-			s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+			s.SelectTestAccount1()
 			txHash, err = s.Backend.CompleteTransaction(
 				common.QueuedTxID(event["id"].(string)),
 				TestConfig.Account1.Password,
@@ -277,7 +275,7 @@ func (s *TransactionsTestSuite) TestSendEther() {
 
 			// the third call will work as expected (as we are logged in with correct credentials)
 			log.Info("trying to complete with correct user, this should succeed")
-			s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+			s.SelectTestAccount1()
 			txHash, err = s.Backend.CompleteTransaction(
 				common.QueuedTxID(event["id"].(string)),
 				TestConfig.Account1.Password,
@@ -318,8 +316,7 @@ func (s *TransactionsTestSuite) TestSendEtherTxUpstream() {
 	s.StartTestBackend(e2e.WithUpstream(addr))
 	defer s.StopTestBackend()
 
-	err = s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
-	s.NoError(err)
+	s.SelectTestAccount1()
 
 	completeQueuedTransaction := make(chan struct{})
 
@@ -365,6 +362,61 @@ func (s *TransactionsTestSuite) TestSendEtherTxUpstream() {
 	s.Zero(s.Backend.TxQueueManager().TransactionQueue().Count(), "tx queue must be empty at this point")
 }
 
+func (s *TransactionsTestSuite) TestSendEtherFullNode() {
+	s.StartTestBackend(func(config *params.NodeConfig) {
+		config.SyncMode = params.FullSyncMode
+	})
+	defer func() {
+		// filter system of eth service subscribes to chain events asynchronously,
+		// and panics if the chain is closed before that
+		time.Sleep(100 * time.Millisecond)
+		s.StopTestBackend()
+	}()
+
+	ethService, err := s.Backend.NodeManager().EthereumService()
+	s.Require().NoError(err)
+
+	s.SelectTestAccount1()
+
+	completeQueuedTransaction := make(chan struct{})
+
+	// full node has no LES backend, transaction is added to the pool of the eth service
+	var txHash = gethcommon.Hash{}
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) { // nolint: dupl
+		var envelope signal.Envelope
+		err := json.Unmarshal([]byte(jsonEvent), &envelope)
+		s.NoError(err, "cannot unmarshal JSON: %s", jsonEvent)
+
+		if envelope.Type == txqueue.EventTransactionQueued {
+			event := envelope.Event.(map[string]interface{})
+			txHash, err = s.Backend.CompleteTransaction(
+				common.QueuedTxID(event["id"].(string)),
+				TestConfig.Account1.Password,
+			)
+			s.NoError(err, "cannot complete queued transaction[%v]", event["id"])
+
+			close(completeQueuedTransaction)
+		}
+	})
+
+	txHashCheck, err := s.Backend.SendTransaction(context.TODO(), common.SendTxArgs{
+		From:  common.FromAddress(TestConfig.Account1.Address),
+		To:    common.ToAddress(TestConfig.Account2.Address),
+		Value: (*hexutil.Big)(big.NewInt(1000000000000)),
+	})
+	s.NoError(err, "cannot send transaction")
+
+	select {
+	case <-completeQueuedTransaction:
+	case <-time.After(time.Minute):
+		s.FailNow("completing transaction timed out")
+	}
+
+	s.Equal(txHash.Hex(), txHashCheck.Hex(), "transaction hash returned from SendTransaction is invalid")
+	s.NotNil(ethService.TxPool().Get(txHash), "transaction is expected in the pool of the eth service")
+	s.Zero(s.Backend.TxQueueManager().TransactionQueue().Count(), "tx queue must be empty at this point")
+}
+
 func (s *TransactionsTestSuite) TestDoubleCompleteQueuedTransactions() {
 	s.StartTestBackend()
 	defer s.StopTestBackend()
@@ -375,7 +427,7 @@ func (s *TransactionsTestSuite) TestDoubleCompleteQueuedTransactions() {
 	s.NotNil(backend)
 
 	// log into account from which transactions will be sent
-	s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+	s.SelectTestAccount1()
 
 	completeQueuedTransaction := make(chan struct{})
 
@@ -455,7 +507,7 @@ func (s *TransactionsTestSuite) TestDiscardQueuedTransaction() {
 	s.Backend.TxQueueManager().TransactionQueue().Reset()
 
 	// log into account from which transactions will be sent
-	s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+	s.SelectTestAccount1()
 
 	completeQueuedTransaction := make(chan struct{})
 
@@ -530,8 +582,7 @@ func (s *TransactionsTestSuite) TestCompleteMultipleQueuedTransactions() {
 	s.TxQueueManager().TransactionQueue().Reset()
 
 	// log into account from which transactions will be sent
-	err := s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password)
-	s.NoError(err)
+	s.SelectTestAccount1()
 
 	testTxCount := 3
 	txIDs := make(chan common.QueuedTxID, testTxCount)
@@ -565,18 +616,22 @@ func (s *TransactionsTestSuite) TestCompleteMultipleQueuedTransactions() {
 
 	// wait for transactions, and complete them in a single call
 	completeTxs := func(txIDs []common.QueuedTxID) {
-		txIDs = append(txIDs, "invalid-tx-id")
-		results := s.Backend.CompleteTransactions(txIDs, TestConfig.Account1.Password)
+		// none of transactions is sent, if any of them can't be
+		results := s.Backend.CompleteTransactions(append(txIDs, "invalid-tx-id"), TestConfig.Account1.Password)
 		s.Len(results, testTxCount+1)
 		s.EqualError(results["invalid-tx-id"].Error, "transaction hash not found")
+		for _, txID := range txIDs {
+			s.Equal(txqueue.ErrQueuedTxBatchAborted, results[txID].Error, "invalid error for %s", txID)
+			s.True(s.Backend.TxQueueManager().TransactionQueue().Has(txID))
+		}
+
+		results = s.Backend.CompleteTransactions(txIDs, TestConfig.Account1.Password)
+		s.Len(results, testTxCount)
 
 		for txID, txResult := range results {
+			s.NoError(txResult.Error, "invalid error for %s", txID)
 			s.False(
-				txResult.Error != nil && txID != "invalid-tx-id",
-				"invalid error for %s", txID,
-			)
-			s.False(
-				txResult.Hash == (gethcommon.Hash{}) && txID != "invalid-tx-id",
+				txResult.Hash == (gethcommon.Hash{}),
 				"invalid hash (expected non empty hash): %s", txID,
 			)
 			log.Info("transaction complete", "URL", "https://ropsten.etherscan.io/tx/"+txResult.Hash.Hex())
@@ -628,7 +683,7 @@ func (s *TransactionsTestSuite) TestDiscardMultipleQueuedTransactions() {
 	s.Backend.TxQueueManager().TransactionQueue().Reset()
 
 	// log into account from which transactions will be sent
-	s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+	s.SelectTestAccount1()
 
 	testTxCount := 3
 	txIDs := make(chan common.QueuedTxID, testTxCount)
@@ -739,7 +794,7 @@ func (s *TransactionsTestSuite) TestNonExistentQueuedTransactions() {
 	s.NotNil(backend)
 
 	// log into account from which transactions will be sent
-	s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+	s.SelectTestAccount1()
 
 	// replace transaction notification handler
 	signal.SetDefaultNodeNotificationHandler(func(string) {})
@@ -761,7 +816,7 @@ func (s *TransactionsTestSuite) TestEvictionOfQueuedTransactions() {
 	s.Backend.TxQueueManager().TransactionQueue().Reset()
 
 	// log into account from which transactions will be sent
-	s.NoError(s.Backend.AccountManager().SelectAccount(TestConfig.Account1.Address, TestConfig.Account1.Password))
+	s.SelectTestAccount1()
 
 	txQueue := s.Backend.TxQueueManager().TransactionQueue()
 	var i = 0
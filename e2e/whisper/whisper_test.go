@@ -3,6 +3,7 @@ package whisper
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/crypto"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
@@ -10,6 +11,7 @@ import (
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/node"
 	. "github.com/status-im/status-go/testing"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -115,3 +117,36 @@ func (s *WhisperTestSuite) TestLogout() {
 	s.NoError(accountManager.Logout())
 	s.False(whisperService.HasKeyPair(pubKey), "identity not cleared from whisper")
 }
+
+func TestWhisperTestPair(t *testing.T) {
+	pair := NewWhisperTestPair(t)
+	defer pair.Teardown()
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	payload := []byte("hello from sender")
+
+	require.NoError(t, pair.Send(topic, payload))
+	require.NoError(t, pair.Expect(topic, payload, 10*time.Second))
+}
+
+func TestWhisperTestPairWithFaults(t *testing.T) {
+	faults := NewFaultInjector()
+	pair := NewWhisperTestPairWithFaults(t, faults)
+	defer pair.Teardown()
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+
+	// slow link still delivers messages
+	faults.SetLatency(50 * time.Millisecond)
+	require.NoError(t, pair.Send(topic, []byte("slow")))
+	require.NoError(t, pair.Expect(topic, []byte("slow"), 10*time.Second))
+	faults.SetLatency(0)
+
+	// nothing is delivered across a partition
+	receiverNode, err := pair.Receiver.Node()
+	require.NoError(t, err)
+	faults.Partition(receiverNode.Server().Self().ID)
+
+	require.NoError(t, pair.Send(topic, []byte("partitioned")))
+	require.Equal(t, ErrWaitTimeout, pair.Expect(topic, []byte("partitioned"), time.Second))
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/status-im/status-go/extkeys"
@@ -61,6 +62,30 @@ func TestMnemonicPhrase(t *testing.T) {
 	}
 }
 
+func TestMnemonicPhraseStrength(t *testing.T) {
+	mnemonic := extkeys.NewMnemonic(extkeys.Salt)
+
+	for strength, words := range map[extkeys.Language]int{128: 12, 160: 15, 192: 18, 224: 21, 256: 24} {
+		phrase, err := mnemonic.MnemonicPhrase(strength, extkeys.EnglishLanguage)
+		if err != nil {
+			t.Fatalf("could not create mnemonic of %d bits: %v", strength, err)
+		}
+		if n := len(strings.Fields(phrase)); n != words {
+			t.Errorf("mnemonic of %d bits has %d words, expected %d", strength, n, words)
+		}
+	}
+
+	for _, strength := range []extkeys.Language{96, 130, 288} {
+		if _, err := mnemonic.MnemonicPhrase(strength, extkeys.EnglishLanguage); err == nil {
+			t.Errorf("mnemonic of %d bits is expected to be refused", strength)
+		}
+	}
+
+	if _, err := mnemonic.MnemonicPhrase(128, extkeys.Language(len(extkeys.Languages))); err == nil {
+		t.Error("mnemonic of unknown language is expected to be refused")
+	}
+}
+
 func LoadVectorsFile(path string) (*VectorsFile, error) {
 	fp, err := os.Open(path)
 	if err != nil {
@@ -0,0 +1,68 @@
+package abi
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+)
+
+// erc20ABI are ERC20 methods used to transfer tokens and to describe them
+const erc20ABI = `[
+	{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}], "outputs": [{"name": "", "type": "bool"}]},
+	{"type": "function", "name": "symbol", "constant": true, "inputs": [], "outputs": [{"name": "", "type": "string"}]},
+	{"type": "function", "name": "decimals", "constant": true, "inputs": [], "outputs": [{"name": "", "type": "uint8"}]}
+]`
+
+var erc20 = mustParseABI(erc20ABI)
+
+// PackTransfer returns data of an ERC20 transfer of an amount of tokens, in their smallest units, to an address.
+func PackTransfer(to gethcommon.Address, amount *big.Int) ([]byte, error) {
+	return erc20.Pack("transfer", to, amount)
+}
+
+// PackSymbol returns data of an ERC20 symbol call.
+func PackSymbol() []byte {
+	return erc20.Methods["symbol"].Id()
+}
+
+// PackDecimals returns data of an ERC20 decimals call.
+func PackDecimals() []byte {
+	return erc20.Methods["decimals"].Id()
+}
+
+// UnpackSymbol returns a symbol returned by an ERC20 symbol call. Some tokens predating the standard return
+// it as bytes32 rather than a string, so a single word is read as bytes padded with zeros.
+func UnpackSymbol(output []byte) (string, error) {
+	if len(output) == 32 {
+		return string(bytes.TrimRight(output, "\x00")), nil
+	}
+
+	var symbol string
+	if err := erc20.Unpack(&symbol, "symbol", output); err != nil {
+		return "", err
+	}
+
+	return symbol, nil
+}
+
+// UnpackDecimals returns decimals returned by an ERC20 decimals call.
+func UnpackDecimals(output []byte) (uint8, error) {
+	var decimals uint8
+	if err := erc20.Unpack(&decimals, "decimals", output); err != nil {
+		return 0, err
+	}
+
+	return decimals, nil
+}
+
+func mustParseABI(abiJSON string) gethabi.ABI {
+	parsed, err := gethabi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
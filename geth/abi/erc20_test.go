@@ -0,0 +1,52 @@
+package abi
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const symbolABI = `[
+	{"type": "function", "name": "symbol", "inputs": [{"name": "", "type": "string"}]},
+	{"type": "function", "name": "decimals", "inputs": [{"name": "", "type": "uint8"}]}
+]`
+
+func TestPackTransfer(t *testing.T) {
+	data, err := PackTransfer(recipient, big.NewInt(1000))
+	require.NoError(t, err)
+	require.Equal(t, pack(t, wellKnownABI, "transfer", recipient, big.NewInt(1000)), data)
+
+	preview := NewRegistry().DecodeCall(&token, data)
+	require.Equal(t, "transfer", preview.Method)
+	require.Equal(t, "1000", preview.Args[1].Value)
+}
+
+func TestUnpackSymbol(t *testing.T) {
+	require.Equal(t, []byte{0x95, 0xd8, 0x9b, 0x41}, PackSymbol())
+
+	// outputs are encoded as inputs are, without the selector
+	symbol, err := UnpackSymbol(pack(t, symbolABI, "symbol", "SNT")[selectorLength:])
+	require.NoError(t, err)
+	require.Equal(t, "SNT", symbol)
+
+	word := make([]byte, 32)
+	copy(word, "MKR")
+	symbol, err = UnpackSymbol(word)
+	require.NoError(t, err)
+	require.Equal(t, "MKR", symbol)
+
+	_, err = UnpackSymbol([]byte{0x01})
+	require.Error(t, err)
+}
+
+func TestUnpackDecimals(t *testing.T) {
+	require.Equal(t, []byte{0x31, 0x3c, 0xe5, 0x67}, PackDecimals())
+
+	decimals, err := UnpackDecimals(pack(t, symbolABI, "decimals", uint8(18))[selectorLength:])
+	require.NoError(t, err)
+	require.Equal(t, uint8(18), decimals)
+
+	_, err = UnpackDecimals(nil)
+	require.Error(t, err)
+}
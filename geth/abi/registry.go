@@ -0,0 +1,241 @@
+// Package abi decodes contract calls of transactions with ABIs of well-known token standards
+// and ABIs supplied by DApps for their contracts, so that calls can be previewed before approval,
+// and encodes calls of ERC20 tokens made by the transaction queue.
+package abi
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// selectorLength is the length of a method selector preceding arguments of a call
+const selectorLength = 4
+
+// ErrNoMethods is returned when an ABI registered for a contract has no methods.
+var ErrNoMethods = errors.New("ABI has no methods")
+
+// wellKnownABI are methods of ERC20 and ERC721 tokens which change state. ERC721 transferFrom and approve
+// have the same selectors as ERC20 ones, so they are decoded as ERC20 methods unless a contract's ABI is registered.
+const wellKnownABI = `[
+	{"type": "function", "name": "transfer", "inputs": [{"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}]},
+	{"type": "function", "name": "transferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "value", "type": "uint256"}]},
+	{"type": "function", "name": "approve", "inputs": [{"name": "spender", "type": "address"}, {"name": "value", "type": "uint256"}]},
+	{"type": "function", "name": "increaseApproval", "inputs": [{"name": "spender", "type": "address"}, {"name": "addedValue", "type": "uint256"}]},
+	{"type": "function", "name": "decreaseApproval", "inputs": [{"name": "spender", "type": "address"}, {"name": "subtractedValue", "type": "uint256"}]},
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}]},
+	{"type": "function", "name": "safeTransferFrom", "inputs": [{"name": "from", "type": "address"}, {"name": "to", "type": "address"}, {"name": "tokenId", "type": "uint256"}, {"name": "data", "type": "bytes"}]},
+	{"type": "function", "name": "setApprovalForAll", "inputs": [{"name": "operator", "type": "address"}, {"name": "approved", "type": "bool"}]}
+]`
+
+// methods are methods of an ABI by selector
+type methods map[string]gethabi.Method
+
+// Registry decodes contract calls with ABIs registered for contracts, or with well-known token methods
+// for other contracts. It implements common.CallDecoder.
+type Registry struct {
+	wellKnown methods
+
+	mu        sync.RWMutex
+	contracts map[gethcommon.Address]methods // ABIs registered by DApps
+}
+
+// NewRegistry returns a registry which knows methods of ERC20 and ERC721 tokens.
+func NewRegistry() *Registry {
+	wellKnown, err := parseMethods(wellKnownABI)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Registry{
+		wellKnown: wellKnown,
+		contracts: make(map[gethcommon.Address]methods),
+	}
+}
+
+// Register sets a JSON ABI of a contract, which takes precedence over well-known methods when calls of
+// the contract are decoded. A contract registered again gets the new ABI.
+func (r *Registry) Register(contract gethcommon.Address, abiJSON string) error {
+	parsed, err := parseMethods(abiJSON)
+	if err != nil {
+		return err
+	}
+	if len(parsed) == 0 {
+		return ErrNoMethods
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.contracts[contract] = parsed
+
+	return nil
+}
+
+// Unregister forgets an ABI registered for a contract.
+func (r *Registry) Unregister(contract gethcommon.Address) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.contracts, contract)
+}
+
+// DecodeCall returns a call of a contract with given data, or nil if it's not a call of a known method
+// or its arguments can't be decoded, e.g. a contract creation or a plain transfer of ether.
+func (r *Registry) DecodeCall(to *gethcommon.Address, data []byte) *common.CallPreview {
+	if to == nil || len(data) < selectorLength {
+		return nil
+	}
+
+	method, ok := r.method(*to, data[:selectorLength])
+	if !ok {
+		return nil
+	}
+
+	preview, err := decodeCall(method, data[selectorLength:])
+	if err != nil {
+		log.Warn("failed to decode a contract call", "contract", to.Hex(), "method", method.Sig(), "err", err)
+		return nil
+	}
+
+	return preview
+}
+
+// method looks a method up in the contract's ABI first, and among well-known methods then.
+func (r *Registry) method(contract gethcommon.Address, selector []byte) (gethabi.Method, bool) {
+	r.mu.RLock()
+	registered := r.contracts[contract]
+	r.mu.RUnlock()
+
+	if method, ok := registered[string(selector)]; ok {
+		return method, true
+	}
+
+	method, ok := r.wellKnown[string(selector)]
+	return method, ok
+}
+
+// parseMethods parses a JSON ABI into its methods by selector.
+func parseMethods(abiJSON string) (methods, error) {
+	parsed, err := gethabi.JSON(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(methods, len(parsed.Methods))
+	for _, method := range parsed.Methods {
+		result[string(method.Id())] = method
+	}
+
+	return result, nil
+}
+
+// decodeCall decodes arguments of a method call.
+func decodeCall(method gethabi.Method, input []byte) (*common.CallPreview, error) {
+	preview := &common.CallPreview{
+		Method:    method.Name,
+		Signature: method.Sig(),
+		Args:      make([]common.CallArgument, 0, len(method.Inputs)),
+	}
+	if len(method.Inputs) == 0 {
+		return preview, nil
+	}
+
+	values, err := unpackInputs(method, input)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, input := range method.Inputs {
+		if fixed, ok := values[i].([]byte); ok && input.Type.T == gethabi.FixedBytesTy && len(fixed) > input.Type.SliceSize {
+			values[i] = fixed[:input.Type.SliceSize] // unpacked with the padding of the word
+		}
+		preview.Args = append(preview.Args, common.CallArgument{
+			Name:  input.Name,
+			Type:  input.Type.String(),
+			Value: formatValue(reflect.ValueOf(values[i])),
+		})
+	}
+
+	return preview, nil
+}
+
+// unpackInputs unpacks arguments of a call, which are encoded as outputs are, so unpacking of outputs of
+// a method with the inputs as outputs is used. Malformed offsets of dynamic arguments make the unpacking
+// panic, which is returned as an error, as data of a transaction comes from a DApp.
+func unpackInputs(method gethabi.Method, input []byte) (values []interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("malformed arguments: %v", r)
+		}
+	}()
+
+	name := method.Name
+	unpacker := gethabi.ABI{Methods: map[string]gethabi.Method{
+		name: {Name: name, Outputs: method.Inputs},
+	}}
+
+	if len(method.Inputs) == 1 {
+		var value interface{}
+		if err := unpacker.Unpack(&value, name, input); err != nil {
+			return nil, err
+		}
+		return []interface{}{value}, nil
+	}
+
+	if err := unpacker.Unpack(&values, name, input); err != nil {
+		return nil, err
+	}
+	if len(values) != len(method.Inputs) {
+		return nil, fmt.Errorf("unpacked %d arguments of %d", len(values), len(method.Inputs))
+	}
+
+	return values, nil
+}
+
+// formatValue converts an unpacked value to what it looks like in JSON of a call preview.
+func formatValue(value reflect.Value) interface{} {
+	switch v := value.Interface().(type) {
+	case *big.Int:
+		return v.String()
+	case gethcommon.Address:
+		return v.Hex()
+	case gethcommon.Hash:
+		return v.Hex()
+	case []byte:
+		return hexutil.Encode(v)
+	case bool, string:
+		return v
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return big.NewInt(value.Int()).String()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return new(big.Int).SetUint64(value.Uint()).String()
+	case reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			encoded := make([]byte, value.Len())
+			reflect.Copy(reflect.ValueOf(encoded), value)
+			return hexutil.Encode(encoded)
+		}
+		fallthrough
+	case reflect.Slice:
+		list := make([]interface{}, value.Len())
+		for i := range list {
+			list[i] = formatValue(value.Index(i))
+		}
+		return list
+	}
+
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,102 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	gethabi "github.com/ethereum/go-ethereum/accounts/abi"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	token     = gethcommon.HexToAddress("0x744d70fdbe2ba4cf95131626614a1763df805b9e")
+	recipient = gethcommon.HexToAddress("0x0000000000000000000000000000000000000b0b")
+)
+
+const exchangeABI = `[
+	{"type": "function", "name": "trade", "inputs": [{"name": "amounts", "type": "uint256[]"}, {"name": "ref", "type": "bytes32"}, {"name": "memo", "type": "string"}]},
+	{"type": "function", "name": "transfer", "inputs": [{"name": "recipient", "type": "address"}, {"name": "units", "type": "uint256"}]},
+	{"type": "function", "name": "withdraw", "inputs": []}
+]`
+
+func pack(t *testing.T, abiJSON, method string, args ...interface{}) []byte {
+	parsed, err := gethabi.JSON(strings.NewReader(abiJSON))
+	require.NoError(t, err)
+	data, err := parsed.Pack(method, args...)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodeWellKnownCall(t *testing.T) {
+	registry := NewRegistry()
+
+	data := pack(t, wellKnownABI, "transfer", recipient, big.NewInt(1000))
+	preview := registry.DecodeCall(&token, data)
+	require.Equal(t, &common.CallPreview{
+		Method:    "transfer",
+		Signature: "transfer(address,uint256)",
+		Args: []common.CallArgument{
+			{Name: "to", Type: "address", Value: recipient.Hex()},
+			{Name: "value", Type: "uint256", Value: "1000"},
+		},
+	}, preview)
+
+	data = pack(t, wellKnownABI, "setApprovalForAll", recipient, true)
+	preview = registry.DecodeCall(&token, data)
+	require.Equal(t, "setApprovalForAll", preview.Method)
+	require.Equal(t, true, preview.Args[1].Value)
+}
+
+func TestDecodeRegisteredCall(t *testing.T) {
+	registry := NewRegistry()
+	require.NoError(t, registry.Register(token, exchangeABI))
+
+	// the contract's ABI takes precedence over the well-known transfer
+	data := pack(t, exchangeABI, "transfer", recipient, big.NewInt(5))
+	preview := registry.DecodeCall(&token, data)
+	require.Equal(t, "recipient", preview.Args[0].Name)
+	require.Equal(t, "units", preview.Args[1].Name)
+
+	var ref [32]byte
+	ref[0] = 0xab
+	data = pack(t, exchangeABI, "trade", []*big.Int{big.NewInt(1), big.NewInt(2)}, ref, "hi")
+	preview = registry.DecodeCall(&token, data)
+	require.Equal(t, "trade(uint256[],bytes32,string)", preview.Signature)
+	require.Equal(t, []interface{}{"1", "2"}, preview.Args[0].Value)
+	require.Equal(t, gethcommon.ToHex(ref[:]), preview.Args[1].Value)
+	require.Equal(t, "hi", preview.Args[2].Value)
+
+	data = pack(t, exchangeABI, "withdraw")
+	preview = registry.DecodeCall(&token, data)
+	require.Equal(t, "withdraw", preview.Method)
+	require.Empty(t, preview.Args)
+
+	// other contracts are decoded with well-known methods only
+	other := gethcommon.HexToAddress("0x1")
+	require.Nil(t, registry.DecodeCall(&other, data))
+
+	registry.Unregister(token)
+	require.Nil(t, registry.DecodeCall(&token, data))
+}
+
+func TestDecodeUnknownCall(t *testing.T) {
+	registry := NewRegistry()
+
+	require.Nil(t, registry.DecodeCall(&token, nil))
+	require.Nil(t, registry.DecodeCall(nil, pack(t, wellKnownABI, "transfer", recipient, big.NewInt(1))))
+	require.Nil(t, registry.DecodeCall(&token, []byte{0x01, 0x02, 0x03, 0x04}))
+
+	// arguments which are too short are not decoded
+	data := pack(t, wellKnownABI, "transfer", recipient, big.NewInt(1))
+	require.Nil(t, registry.DecodeCall(&token, data[:40]))
+}
+
+func TestRegisterInvalidABI(t *testing.T) {
+	registry := NewRegistry()
+
+	require.Error(t, registry.Register(token, "not an ABI"))
+	require.Equal(t, ErrNoMethods, registry.Register(token, "[]"))
+}
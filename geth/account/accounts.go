@@ -8,6 +8,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -15,7 +17,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/status-go/extkeys"
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
 )
 
 // errors
@@ -26,12 +30,27 @@ var (
 	ErrWhisperClearIdentitiesFailure   = errors.New("failed to clear whisper identities")
 	ErrNoAccountSelected               = errors.New("no account has been selected, please login")
 	ErrInvalidMasterKeyCreated         = errors.New("can not create master extended key")
+	ErrInvalidMnemonicWordCount        = errors.New("mnemonic must have 12, 15, 18, 21 or 24 words")
+	ErrInvalidMnemonic                 = errors.New("mnemonic is not valid for a given word count and language")
+	ErrInvalidDerivationPath           = errors.New("invalid BIP44 derivation path")
+	ErrInvalidDerivationRange          = errors.New("derived accounts must have non-hardened indexes, and their count must be between 1 and 100")
+	ErrInvalidPrivateKey               = errors.New("private key must be 32 hex encoded bytes")
+	ErrSamePassword                    = errors.New("new password must differ from the old one")
+	ErrNoExtendedKey                   = errors.New("account has no extended key, e.g. it was imported as a private key")
 )
 
+// maxDerivedAccounts limits a number of accounts derived at once, as each of them is encrypted with scrypt.
+const maxDerivedAccounts = 100
+
 // Manager represents account manager interface
 type Manager struct {
 	nodeManager     common.NodeManager
-	selectedAccount *common.SelectedExtKey // account that was processed during the last call to SelectAccount()
+	selectedAccount *common.SelectedExtKey   // account that was processed during the last call to SelectAccount()
+	sessions        []*common.SelectedExtKey // unlocked accounts, including the selected one, in the order they were unlocked
+	sessionsMu      sync.RWMutex             // guards selectedAccount and sessions
+	watchOnlyMu     sync.Mutex               // guards the watch-only accounts file
+	metadataMu      sync.Mutex               // guards the account metadata file
+	signals         signal.Sender            // sends account signals, see SetSignalHandler
 }
 
 // NewManager returns new node account manager
@@ -41,20 +60,39 @@ func NewManager(nodeManager common.NodeManager) *Manager {
 	}
 }
 
+// SetSignalHandler sets a handler of signals sent by the account manager, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (m *Manager) SetSignalHandler(handler func(signal.Envelope)) {
+	m.signals.SetHandler(handler)
+}
+
 // CreateAccount creates an internal geth account
 // BIP44-compatible keys are generated: CKD#1 is stored as account key, CKD#2 stored as sub-account root
 // Public key of CKD#1 is returned, with CKD#2 securely encoded into account key file (to be used for
 // sub-account derivations)
+// Mnemonic has 12 English words.
 func (m *Manager) CreateAccount(password string) (address, pubKey, mnemonic string, err error) {
+	return m.CreateAccountWithOptions(password, common.MnemonicOptions{})
+}
+
+// CreateAccountWithOptions creates an internal geth account like CreateAccount, with a mnemonic
+// of a given length and language. If BIP39 passphrase is set, it's required to recover the account
+// along with the mnemonic, instead of the password.
+func (m *Manager) CreateAccountWithOptions(password string, options common.MnemonicOptions) (address, pubKey, mnemonic string, err error) {
+	strength, err := mnemonicStrength(options.WordCount)
+	if err != nil {
+		return "", "", "", err
+	}
+
 	// generate mnemonic phrase
 	mn := extkeys.NewMnemonic(extkeys.Salt)
-	mnemonic, err = mn.MnemonicPhrase(128, extkeys.EnglishLanguage)
+	mnemonic, err = mn.MnemonicPhrase(strength, options.Language)
 	if err != nil {
 		return "", "", "", fmt.Errorf("can not create mnemonic seed: %v", err)
 	}
 
 	// generate extended master key (see BIP32)
-	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, password), []byte(extkeys.Salt))
+	extKey, err := extkeys.NewMaster(mnemonicSeed(mn, mnemonic, password, options.Passphrase), []byte(extkeys.Salt))
 	if err != nil {
 		return "", "", "", fmt.Errorf("can not create master extended key: %v", err)
 	}
@@ -77,8 +115,10 @@ func (m *Manager) CreateChildAccount(parentAddress, password string) (address, p
 		return "", "", err
 	}
 
-	if parentAddress == "" && m.selectedAccount != nil { // derive from selected account by default
-		parentAddress = m.selectedAccount.Address.Hex()
+	if parentAddress == "" { // derive from selected account by default
+		if selectedAccount, err := m.SelectedAccount(); err == nil {
+			parentAddress = selectedAccount.Address.Hex()
+		}
 	}
 
 	if parentAddress == "" {
@@ -117,10 +157,8 @@ func (m *Manager) CreateChildAccount(parentAddress, password string) (address, p
 		return
 	}
 
-	// update in-memory selected account
-	if m.selectedAccount != nil {
-		m.selectedAccount.AccountKey = accountKey
-	}
+	// update in-memory key of the parent account, if it's unlocked
+	m.updateSessionKey(account.Address, accountKey)
 
 	return address, pubKey, nil
 }
@@ -128,9 +166,30 @@ func (m *Manager) CreateChildAccount(parentAddress, password string) (address, p
 // RecoverAccount re-creates master key using given details.
 // Once master key is re-generated, it is inserted into keystore (if not already there).
 func (m *Manager) RecoverAccount(password, mnemonic string) (address, pubKey string, err error) {
+	return m.recoverAccount(password, mnemonic, "")
+}
+
+// RecoverAccountWithOptions re-creates master key of an account created with CreateAccountWithOptions.
+// Unlike RecoverAccount, it checks that the mnemonic consists of words of a given language,
+// and of a given number of words if it's set.
+func (m *Manager) RecoverAccountWithOptions(password, mnemonic string, options common.MnemonicOptions) (address, pubKey string, err error) {
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	if !mn.ValidMnemonic(mnemonic, options.Language) {
+		return "", "", ErrInvalidMnemonic
+	}
+	if options.WordCount != 0 && options.WordCount != len(strings.Fields(mnemonic)) {
+		return "", "", ErrInvalidMnemonic
+	}
+
+	return m.recoverAccount(password, mnemonic, options.Passphrase)
+}
+
+// recoverAccount re-creates master key from a mnemonic and BIP39 passphrase,
+// and inserts it into keystore.
+func (m *Manager) recoverAccount(password, mnemonic, passphrase string) (address, pubKey string, err error) {
 	// re-create extended key (see BIP32)
 	mn := extkeys.NewMnemonic(extkeys.Salt)
-	extKey, err := extkeys.NewMaster(mn.MnemonicSeed(mnemonic, password), []byte(extkeys.Salt))
+	extKey, err := extkeys.NewMaster(mnemonicSeed(mn, mnemonic, password, passphrase), []byte(extkeys.Salt))
 	if err != nil {
 		return "", "", ErrInvalidMasterKeyCreated
 	}
@@ -144,6 +203,290 @@ func (m *Manager) RecoverAccount(password, mnemonic string) (address, pubKey str
 	return address, pubKey, nil
 }
 
+// DeriveAccounts derives count accounts with consecutive indexes starting from start under a BIP44 path
+// (e.g. m/44'/60'/0'/0, which is used if path is empty) from a master key of a mnemonic, and stores them
+// in keystore encrypted with the password. Addresses of the derived accounts are returned.
+// Master key is re-created as in RecoverAccountWithOptions, so index 0 of the default path is the account
+// it returns for the mnemonic and the BIP39 passphrase, which may be empty.
+func (m *Manager) DeriveAccounts(password, mnemonic, passphrase, path string, start, count int) ([]string, error) {
+	if start < 0 || count < 1 || count > maxDerivedAccounts || uint64(start+count) > extkeys.HardenedKeyStart {
+		return nil, ErrInvalidDerivationRange
+	}
+
+	parentPath := accounts.DefaultRootDerivationPath
+	if path != "" {
+		var err error
+		if parentPath, err = accounts.ParseDerivationPath(path); err != nil {
+			return nil, fmt.Errorf("%v: %v", ErrInvalidDerivationPath, err)
+		}
+	}
+
+	mn := extkeys.NewMnemonic(extkeys.Salt)
+	masterKey, err := extkeys.NewMaster(mnemonicSeed(mn, mnemonic, password, passphrase), []byte(extkeys.Salt))
+	if err != nil {
+		return nil, ErrInvalidMasterKeyCreated
+	}
+	parentKey, err := masterKey.Derive(parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	addresses := make([]string, 0, count)
+	for i := start; i < start+count; i++ {
+		childKey, err := parentKey.Child(uint32(i))
+		if err != nil {
+			return nil, err
+		}
+
+		// derived key is not a master key, so it's imported as is
+		address, _, err := m.importExtendedKey(childKey, password)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, nil
+}
+
+// ImportPrivateKey stores a hex encoded private key (with or without 0x prefix) in keystore, encrypted
+// with the password. Unlike keys of CreateAccount, the key is not an extended one, so no sub-accounts
+// can be created for it. If the account is already in keystore, the password must decrypt it.
+func (m *Manager) ImportPrivateKey(hexKey, password string) (address, pubKey string, err error) {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return "", "", err
+	}
+
+	privateKey, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(hexKey), "0x"))
+	if err != nil {
+		return "", "", ErrInvalidPrivateKey
+	}
+
+	account := accounts.Account{Address: crypto.PubkeyToAddress(privateKey.PublicKey)}
+	if !keyStore.HasAddress(account.Address) {
+		if account, err = keyStore.ImportECDSA(privateKey, password); err != nil {
+			return "", "", err
+		}
+	}
+	address = account.Address.Hex()
+
+	// make sure that an already imported key is encrypted with the password
+	if _, _, err = keyStore.AccountDecryptedKey(account, password); err != nil {
+		return "", "", fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+	pubKey = gethcommon.ToHex(crypto.FromECDSAPub(&privateKey.PublicKey))
+
+	return address, pubKey, nil
+}
+
+// ExportAccount returns a private key of an account as V3 keystore JSON encrypted with the password,
+// which must decrypt the key in keystore. Status specific fields (extended key and sub-account index)
+// are not exported, so that the key can be imported into other wallets. Every attempt is logged.
+func (m *Manager) ExportAccount(address, password string) (string, error) {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return "", err
+	}
+
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return "", ErrAddressToAccountMappingFailure
+	}
+
+	_, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	if err != nil {
+		log.Warn("Account export refused", "address", account.Address.Hex(), "err", err)
+		return "", fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	keyJSON, err := keystore.EncryptKey(&keystore.Key{
+		Id:         accountKey.Id,
+		Address:    accountKey.Address,
+		PrivateKey: accountKey.PrivateKey,
+	}, password, keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return "", err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(keyJSON, &fields); err != nil {
+		return "", err
+	}
+	delete(fields, "extendedkey")
+	delete(fields, "subaccountindex")
+	if keyJSON, err = json.Marshal(fields); err != nil {
+		return "", err
+	}
+	log.Warn("Account exported", "address", account.Address.Hex())
+
+	return string(keyJSON), nil
+}
+
+// ExportExtendedPublicKey returns a BIP32 extended public key (xpub) of an account, once the password decrypts
+// the account key, so that addresses can be derived elsewhere without private keys. The path is relative to
+// the extended key of the account, which is the root of its sub-accounts: "m" (or "") exports the root itself,
+// whose non-hardened children are addresses of sub-accounts, and e.g. "m/1'/0" exports a key derived from it.
+func (m *Manager) ExportExtendedPublicKey(address, password, path string) (string, error) {
+	var derivationPath accounts.DerivationPath
+	if path != "" && path != "m" {
+		if !strings.HasPrefix(path, "m/") {
+			return "", ErrInvalidDerivationPath
+		}
+		var err error
+		if derivationPath, err = accounts.ParseDerivationPath(path); err != nil {
+			return "", fmt.Errorf("%v: %v", ErrInvalidDerivationPath, err)
+		}
+	}
+
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return "", err
+	}
+
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return "", ErrAddressToAccountMappingFailure
+	}
+
+	_, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	if err != nil {
+		log.Warn("Extended public key export refused", "address", account.Address.Hex(), "err", err)
+		return "", fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+	if accountKey.ExtendedKey.String() == extkeys.EmptyExtendedKeyString {
+		return "", ErrNoExtendedKey
+	}
+
+	extKey, err := accountKey.ExtendedKey.Derive(derivationPath)
+	if err != nil {
+		return "", err
+	}
+	publicKey, err := extKey.Neuter()
+	if err != nil {
+		return "", err
+	}
+	log.Info("Extended public key exported", "address", account.Address.Hex(), "path", path)
+
+	return publicKey.String(), nil
+}
+
+// ChangeAccountPassword re-encrypts key of an account and keys of its sub-accounts with a new password.
+// All keys are decrypted with the old password before any of them is changed, and keys which have been
+// re-encrypted are restored with the old password if re-encrypting one of the others fails.
+func (m *Manager) ChangeAccountPassword(address, oldPassword, newPassword string) error {
+	if oldPassword == newPassword {
+		return ErrSamePassword
+	}
+
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+
+	account, accountKey, err := keyStore.AccountDecryptedKey(account, oldPassword)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	subAccounts, err := m.findSubAccounts(accountKey.ExtendedKey, accountKey.SubAccountIndex)
+	if err != nil {
+		return err
+	}
+	for _, subAccount := range subAccounts {
+		if _, _, err := keyStore.AccountDecryptedKey(subAccount, oldPassword); err != nil {
+			return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+		}
+	}
+
+	changed := make([]accounts.Account, 0, 1+len(subAccounts))
+	for _, a := range append([]accounts.Account{account}, subAccounts...) {
+		if err := keyStore.Update(a, oldPassword, newPassword); err != nil {
+			for _, restored := range changed {
+				if restoreErr := keyStore.Update(restored, newPassword, oldPassword); restoreErr != nil {
+					log.Error("Failed to restore account password", "address", restored.Address.Hex(), "err", restoreErr)
+				}
+			}
+			return fmt.Errorf("failed to change password of %s: %v", a.Address.Hex(), err)
+		}
+		changed = append(changed, a)
+	}
+	log.Info("Account password changed", "address", account.Address.Hex(), "subAccounts", len(subAccounts))
+
+	return nil
+}
+
+// DeleteAccount removes key of an account and keys of its sub-accounts from keystore, after checking
+// that the password decrypts all of them. Whisper identities of the keys and metadata of the account
+// are removed as well, and the account is logged out if it's selected. EventAccountDeleted is sent
+// once the account is deleted.
+func (m *Manager) DeleteAccount(address, password string) error {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return ErrAddressToAccountMappingFailure
+	}
+
+	account, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	if err != nil {
+		return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	subAccounts, err := m.findSubAccounts(accountKey.ExtendedKey, accountKey.SubAccountIndex)
+	if err != nil {
+		return err
+	}
+	keys := []*keystore.Key{accountKey}
+	for _, subAccount := range subAccounts {
+		_, subAccountKey, err := keyStore.AccountDecryptedKey(subAccount, password)
+		if err != nil {
+			return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+		}
+		keys = append(keys, subAccountKey)
+	}
+
+	// whisper is not necessarily running, e.g. when accounts are removed before a node is configured
+	whisperService, whisperErr := m.nodeManager.WhisperService()
+	if whisperErr != nil {
+		log.Warn("Whisper identities of a deleted account are not removed", "err", whisperErr)
+	}
+
+	event := signal.AccountDeletedEvent{Address: account.Address.Hex(), SubAccounts: []string{}}
+	for i, a := range append([]accounts.Account{account}, subAccounts...) {
+		if err := keyStore.Delete(a, password); err != nil {
+			return err
+		}
+		if whisperService != nil {
+			whisperService.DeleteKeyPair(gethcommon.ToHex(crypto.FromECDSAPub(&keys[i].PrivateKey.PublicKey)))
+		}
+		if i > 0 {
+			event.SubAccounts = append(event.SubAccounts, a.Address.Hex())
+		}
+	}
+
+	m.removeSession(account.Address)
+	if err := m.removeAccountMetadata(account.Address); err != nil {
+		log.Warn("Failed to remove metadata of a deleted account", "address", account.Address.Hex(), "err", err)
+	}
+
+	log.Info("Account deleted", "address", account.Address.Hex(), "subAccounts", len(subAccounts))
+	m.signals.Send(signal.Envelope{
+		Type:  signal.EventAccountDeleted,
+		Event: event,
+	})
+
+	return nil
+}
+
 // VerifyAccountPassword tries to decrypt a given account key file, with a provided password.
 // If no error is returned, then account is considered verified.
 func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error) {
@@ -204,49 +547,31 @@ func (m *Manager) VerifyAccountPassword(keyStoreDir, address, password string) (
 
 // SelectAccount selects current account, by verifying that address has corresponding account which can be decrypted
 // using provided password. Once verification is done, decrypted key is injected into Whisper (as a single identity,
-// all previous identities are removed).
+// all previous identities are removed), and sessions of other accounts are closed.
 func (m *Manager) SelectAccount(address, password string) error {
-	keyStore, err := m.nodeManager.AccountKeyStore()
+	session, err := m.unlockAccount(address, password)
 	if err != nil {
 		return err
 	}
 
-	account, err := common.ParseAccountString(address)
-	if err != nil {
-		return ErrAddressToAccountMappingFailure
-	}
-
-	account, accountKey, err := keyStore.AccountDecryptedKey(account, password)
-	if err != nil {
-		return fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
-	}
-
-	whisperService, err := m.nodeManager.WhisperService()
-	if err != nil {
+	if err := m.replaceIdentities([]*common.SelectedExtKey{session}); err != nil {
 		return err
 	}
 
-	err = whisperService.SelectKeyPair(accountKey.PrivateKey)
-	if err != nil {
-		return ErrWhisperIdentityInjectionFailure
-	}
-
 	// persist account key for easier recovery of currently selected key
-	subAccounts, err := m.findSubAccounts(accountKey.ExtendedKey, accountKey.SubAccountIndex)
-	if err != nil {
-		return err
-	}
-	m.selectedAccount = &common.SelectedExtKey{
-		Address:     account.Address,
-		AccountKey:  accountKey,
-		SubAccounts: subAccounts,
-	}
+	m.sessionsMu.Lock()
+	m.selectedAccount = session
+	m.sessions = []*common.SelectedExtKey{session}
+	m.sessionsMu.Unlock()
 
 	return nil
 }
 
 // SelectedAccount returns currently selected account
 func (m *Manager) SelectedAccount() (*common.SelectedExtKey, error) {
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+
 	if m.selectedAccount == nil {
 		return nil, ErrNoAccountSelected
 	}
@@ -254,39 +579,56 @@ func (m *Manager) SelectedAccount() (*common.SelectedExtKey, error) {
 }
 
 // ReSelectAccount selects previously selected account, often, after node restart.
+// Identities of other open sessions are re-injected into Whisper as well.
 func (m *Manager) ReSelectAccount() error {
-	selectedAccount := m.selectedAccount
-	if selectedAccount == nil {
+	m.sessionsMu.RLock()
+	sessions := m.sessions
+	m.sessionsMu.RUnlock()
+
+	if len(sessions) == 0 {
 		return nil
 	}
 
-	whisperService, err := m.nodeManager.WhisperService()
-	if err != nil {
+	return m.replaceIdentities(sessions)
+}
+
+// Logout clears whisper identities and closes all sessions
+func (m *Manager) Logout() error {
+	if err := m.replaceIdentities(nil); err != nil {
 		return err
 	}
 
-	if err := whisperService.SelectKeyPair(selectedAccount.AccountKey.PrivateKey); err != nil {
-		return ErrWhisperIdentityInjectionFailure
-	}
+	m.sessionsMu.Lock()
+	m.selectedAccount = nil
+	m.sessions = nil
+	m.sessionsMu.Unlock()
 
 	return nil
 }
 
-// Logout clears whisper identities
-func (m *Manager) Logout() error {
-	whisperService, err := m.nodeManager.WhisperService()
-	if err != nil {
-		return err
+// mnemonicStrength returns a number of entropy bits encoded by a mnemonic of a given number of words,
+// 12 words are used if it's 0.
+func mnemonicStrength(wordCount int) (extkeys.Language, error) {
+	switch wordCount {
+	case 0:
+		return 128, nil
+	case 12, 15, 18, 21, 24:
+		// each word encodes 11 bits, a checksum bit is added for every 32 bits of entropy
+		return extkeys.Language(wordCount * 32 / 3), nil
 	}
 
-	err = whisperService.DeleteKeyPairs()
-	if err != nil {
-		return fmt.Errorf("%s: %v", ErrWhisperClearIdentitiesFailure, err)
-	}
+	return 0, ErrInvalidMnemonicWordCount
+}
 
-	m.selectedAccount = nil
+// mnemonicSeed returns a binary seed of a master key. A passphrase, if given, is the BIP39 passphrase,
+// and the account password only encrypts keys. Otherwise the password is used as BIP39 passphrase,
+// so that accounts created without a passphrase are recovered as before.
+func mnemonicSeed(mn *extkeys.Mnemonic, mnemonic, password, passphrase string) []byte {
+	if passphrase != "" {
+		return mn.MnemonicSeed(mnemonic, passphrase)
+	}
 
-	return nil
+	return mn.MnemonicSeed(mnemonic, password)
 }
 
 // importExtendedKey processes incoming extended key, extracts required info and creates corresponding account key.
@@ -314,8 +656,8 @@ func (m *Manager) importExtendedKey(extKey *extkeys.ExtendedKey, password string
 	return
 }
 
-// Accounts returns list of addresses for selected account, including
-// subaccounts.
+// Accounts returns list of addresses of unlocked accounts, including
+// their subaccounts.
 func (m *Manager) Accounts() ([]gethcommon.Address, error) {
 	am, err := m.nodeManager.AccountManager()
 	if err != nil {
@@ -329,24 +671,24 @@ func (m *Manager) Accounts() ([]gethcommon.Address, error) {
 		}
 	}
 
-	if m.selectedAccount == nil {
-		return []gethcommon.Address{}, nil
-	}
+	m.refreshSessions()
 
-	m.refreshSelectedAccount()
+	m.sessionsMu.RLock()
+	unlocked := make(map[gethcommon.Address]bool)
+	for _, session := range m.sessions {
+		// main account
+		unlocked[session.Address] = true
+		// sub accounts
+		for _, subAccount := range session.SubAccounts {
+			unlocked[subAccount.Address] = true
+		}
+	}
+	m.sessionsMu.RUnlock()
 
 	filtered := make([]gethcommon.Address, 0)
 	for _, account := range addresses {
-		// main account
-		if m.selectedAccount.Address.Hex() == account.Hex() {
+		if unlocked[account] {
 			filtered = append(filtered, account)
-		} else {
-			// sub accounts
-			for _, subAccount := range m.selectedAccount.SubAccounts {
-				if subAccount.Address.Hex() == account.Hex() {
-					filtered = append(filtered, account)
-				}
-			}
 		}
 	}
 
@@ -360,29 +702,6 @@ func (m *Manager) AccountsRPCHandler() rpc.Handler {
 	}
 }
 
-// refreshSelectedAccount re-populates list of sub-accounts of the currently selected account (if any)
-func (m *Manager) refreshSelectedAccount() {
-	if m.selectedAccount == nil {
-		return
-	}
-
-	accountKey := m.selectedAccount.AccountKey
-	if accountKey == nil {
-		return
-	}
-
-	// re-populate list of sub-accounts
-	subAccounts, err := m.findSubAccounts(accountKey.ExtendedKey, accountKey.SubAccountIndex)
-	if err != nil {
-		return
-	}
-	m.selectedAccount = &common.SelectedExtKey{
-		Address:     m.selectedAccount.Address,
-		AccountKey:  m.selectedAccount.AccountKey,
-		SubAccounts: subAccounts,
-	}
-}
-
 // findSubAccounts traverses cached accounts and adds as a sub-accounts any
 // that belong to the currently selected account.
 // The extKey is CKD#2 := root of sub-accounts of the main account
@@ -1,18 +1,30 @@
 package account_test
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/extkeys"
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
 	. "github.com/status-im/status-go/testing"
+	"github.com/status-im/status-go/testing/mocks"
 	"github.com/stretchr/testify/require"
 )
 
@@ -109,3 +121,577 @@ func TestVerifyAccountPasswordWithAccountBeforeEIP55(t *testing.T) {
 	_, err = acctManager.VerifyAccountPassword(keyStoreDir, address.Hex(), TestConfig.Account3.Password)
 	require.NoError(t, err)
 }
+
+// newTestAccountManager returns account manager of a running fake node, with a keystore in a temporary directory.
+func newTestAccountManager(t *testing.T) (acctManager *account.Manager, keyStoreDir string, teardown func()) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+
+	nodeManager := mocks.NewNodeManager()
+	nodeManager.SetAccountKeyStore(keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP))
+	config, err := params.NewNodeConfig(keyStoreDir, params.StatusChainNetworkID, true)
+	require.NoError(t, err)
+	_, err = nodeManager.StartNode(config)
+	require.NoError(t, err)
+
+	return account.NewManager(nodeManager), keyStoreDir, func() {
+		os.RemoveAll(keyStoreDir) //nolint: errcheck
+	}
+}
+
+// newTestWhisperAccountManager returns an account manager of a node running Whisper.
+func newTestWhisperAccountManager(t *testing.T) (acctManager *account.Manager, whisperService *whisper.Whisper, keyStoreDir string, teardown func()) {
+	keyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+
+	nodeManager := mocks.NewNodeManager()
+	keyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	nodeManager.SetAccountKeyStore(keyStore)
+	nodeManager.SetAccountManager(accounts.NewManager(keyStore))
+	whisperService = whisper.New(nil)
+	nodeManager.SetWhisperService(whisperService)
+	config, err := params.NewNodeConfig(keyStoreDir, params.StatusChainNetworkID, true)
+	require.NoError(t, err)
+	_, err = nodeManager.StartNode(config)
+	require.NoError(t, err)
+
+	return account.NewManager(nodeManager), whisperService, keyStoreDir, func() {
+		os.RemoveAll(keyStoreDir) //nolint: errcheck
+	}
+}
+
+func TestCreateAndRecoverAccountWithOptions(t *testing.T) {
+	acctManager, _, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+
+	// defaults are the same as of CreateAccount
+	address, _, mnemonic, err := acctManager.CreateAccountWithOptions(password, common.MnemonicOptions{})
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(mnemonic), 12)
+	recovered, _, err := acctManager.RecoverAccount(password, mnemonic)
+	require.NoError(t, err)
+	require.Equal(t, address, recovered)
+
+	options := common.MnemonicOptions{WordCount: 24, Language: extkeys.SpanishLanguage, Passphrase: "25th word"}
+	address, _, mnemonic, err = acctManager.CreateAccountWithOptions(password, options)
+	require.NoError(t, err)
+	require.Len(t, strings.Fields(mnemonic), 24)
+	require.True(t, extkeys.NewMnemonic(extkeys.Salt).ValidMnemonic(mnemonic, extkeys.SpanishLanguage))
+
+	recovered, _, err = acctManager.RecoverAccountWithOptions(password, mnemonic, options)
+	require.NoError(t, err)
+	require.Equal(t, address, recovered)
+
+	// passphrase is required to recover the same account
+	recovered, _, err = acctManager.RecoverAccountWithOptions(password, mnemonic, common.MnemonicOptions{Language: extkeys.SpanishLanguage})
+	require.NoError(t, err)
+	require.NotEqual(t, address, recovered)
+
+	// the passphrase isn't joined with the password
+	options.Passphrase = "word"
+	splitPassphrase, _, err := acctManager.RecoverAccountWithOptions("pass", mnemonic, options)
+	require.NoError(t, err)
+	options.Passphrase = ""
+	joinedPassword, _, err := acctManager.RecoverAccountWithOptions("password", mnemonic, options)
+	require.NoError(t, err)
+	require.NotEqual(t, splitPassphrase, joinedPassword)
+	options.Passphrase = "25th word"
+
+	_, _, err = acctManager.RecoverAccountWithOptions(password, mnemonic, common.MnemonicOptions{Passphrase: "25th word"})
+	require.Equal(t, account.ErrInvalidMnemonic, err)
+	options.WordCount = 12
+	_, _, err = acctManager.RecoverAccountWithOptions(password, mnemonic, options)
+	require.Equal(t, account.ErrInvalidMnemonic, err)
+
+	_, _, _, err = acctManager.CreateAccountWithOptions(password, common.MnemonicOptions{WordCount: 13})
+	require.Equal(t, account.ErrInvalidMnemonicWordCount, err)
+	_, _, _, err = acctManager.CreateAccountWithOptions(password, common.MnemonicOptions{Language: 100})
+	require.Error(t, err)
+}
+
+func TestDeriveAccounts(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	address, _, mnemonic, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+
+	addresses, err := acctManager.DeriveAccounts(password, mnemonic, "", "", 0, 3)
+	require.NoError(t, err)
+	require.Len(t, addresses, 3)
+	require.Equal(t, address, addresses[0], "main account is m/44'/60'/0'/0/0")
+	require.NotEqual(t, addresses[1], addresses[2])
+	for _, derived := range addresses[1:] {
+		// derived accounts are stored in keystore
+		key, err := acctManager.VerifyAccountPassword(keyStoreDir, derived, password)
+		require.NoError(t, err)
+		require.Equal(t, gethcommon.HexToAddress(derived), key.Address)
+	}
+
+	// derivation is deterministic
+	again, err := acctManager.DeriveAccounts(password, mnemonic, "", "m/44'/60'/0'/0", 2, 2)
+	require.NoError(t, err)
+	require.Equal(t, addresses[2], again[0])
+
+	otherPath, err := acctManager.DeriveAccounts(password, mnemonic, "", "m/44'/60'/1'/0", 0, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, addresses[0], otherPath[0])
+
+	// accounts of mnemonics with a BIP39 passphrase are derived with it
+	options := common.MnemonicOptions{Passphrase: "25th word"}
+	address, _, mnemonic, err = acctManager.CreateAccountWithOptions(password, options)
+	require.NoError(t, err)
+	withPassphrase, err := acctManager.DeriveAccounts(password, mnemonic, options.Passphrase, "", 0, 1)
+	require.NoError(t, err)
+	require.Equal(t, address, withPassphrase[0])
+	withoutPassphrase, err := acctManager.DeriveAccounts(password, mnemonic, "", "", 0, 1)
+	require.NoError(t, err)
+	require.NotEqual(t, address, withoutPassphrase[0])
+
+	_, err = acctManager.DeriveAccounts(password, mnemonic, "", "m/44'/x", 0, 1)
+	require.Error(t, err)
+	for _, r := range [][2]int{{-1, 1}, {0, 0}, {0, 101}, {0x7fffffff, 2}} {
+		_, err = acctManager.DeriveAccounts(password, mnemonic, "", "", r[0], r[1])
+		require.Equal(t, account.ErrInvalidDerivationRange, err, "start %d, count %d", r[0], r[1])
+	}
+}
+
+func TestImportPrivateKey(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	privateKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	hexKey := gethcommon.Bytes2Hex(crypto.FromECDSA(privateKey))
+
+	address, pubKey, err := acctManager.ImportPrivateKey("0x"+hexKey, password)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(privateKey.PublicKey).Hex(), address)
+	require.Equal(t, gethcommon.ToHex(crypto.FromECDSAPub(&privateKey.PublicKey)), pubKey)
+
+	key, err := acctManager.VerifyAccountPassword(keyStoreDir, address, password)
+	require.NoError(t, err)
+	require.Equal(t, privateKey.D, key.PrivateKey.D)
+
+	// importing the key again is a no-op, provided the password matches
+	imported, _, err := acctManager.ImportPrivateKey(hexKey, password)
+	require.NoError(t, err)
+	require.Equal(t, address, imported)
+	_, _, err = acctManager.ImportPrivateKey(hexKey, "wrong password")
+	require.Error(t, err)
+
+	for _, invalid := range []string{"", "0x1234", "zz" + hexKey[2:]} {
+		_, _, err = acctManager.ImportPrivateKey(invalid, password)
+		require.Equal(t, account.ErrInvalidPrivateKey, err, invalid)
+	}
+}
+
+func TestExportAccount(t *testing.T) {
+	acctManager, _, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	address, _, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+
+	_, err = acctManager.ExportAccount(address, "wrong password")
+	require.Error(t, err)
+
+	keyJSON, err := acctManager.ExportAccount(address, password)
+	require.NoError(t, err)
+	require.NotContains(t, keyJSON, "extendedkey")
+
+	key, err := keystore.DecryptKey([]byte(keyJSON), password)
+	require.NoError(t, err)
+	require.Equal(t, address, key.Address.Hex())
+
+	// exported key can be imported into another keystore
+	otherKeyStoreDir, err := ioutil.TempDir("", "status-accounts-test")
+	require.NoError(t, err)
+	defer os.RemoveAll(otherKeyStoreDir) //nolint: errcheck
+	otherKeyStore := keystore.NewKeyStore(otherKeyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	imported, err := otherKeyStore.Import([]byte(keyJSON), password, password)
+	require.NoError(t, err)
+	require.Equal(t, address, imported.Address.Hex())
+}
+
+func TestExportExtendedPublicKey(t *testing.T) {
+	acctManager, _, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	address, _, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+
+	_, err = acctManager.ExportExtendedPublicKey(address, "wrong password", "m")
+	require.Error(t, err)
+	_, err = acctManager.ExportExtendedPublicKey(address, password, "0/1")
+	require.Equal(t, account.ErrInvalidDerivationPath, err)
+
+	xpub, err := acctManager.ExportExtendedPublicKey(address, password, "")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(xpub, "xpub"))
+
+	// addresses of sub-accounts are derived from the exported key
+	extKey, err := extkeys.NewKeyFromString(xpub)
+	require.NoError(t, err)
+	require.False(t, extKey.IsPrivate)
+	for i := uint32(0); i < 2; i++ {
+		subAddress, _, err := acctManager.CreateChildAccount(address, password)
+		require.NoError(t, err)
+		childKey, err := extKey.Child(i)
+		require.NoError(t, err)
+		pubKey, err := btcec.ParsePubKey(childKey.KeyData, btcec.S256())
+		require.NoError(t, err)
+		require.Equal(t, subAddress, crypto.PubkeyToAddress(*pubKey.ToECDSA()).Hex())
+	}
+
+	// keys at hardened paths are derived from the private key
+	hardened, err := acctManager.ExportExtendedPublicKey(address, password, "m/1'/0")
+	require.NoError(t, err)
+	require.NotEqual(t, xpub, hardened)
+
+	// imported private keys have no extended key
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	imported, _, err := acctManager.ImportPrivateKey(fmt.Sprintf("%x", crypto.FromECDSA(key)), password)
+	require.NoError(t, err)
+	_, err = acctManager.ExportExtendedPublicKey(imported, password, "m")
+	require.Equal(t, account.ErrNoExtendedKey, err)
+}
+
+func TestWatchOnlyAccounts(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	watched := "0x" + strings.Repeat("ab", 20)
+	address, err := acctManager.AddWatchOnlyAccount(watched)
+	require.NoError(t, err)
+	require.Equal(t, gethcommon.HexToAddress(watched).Hex(), address)
+	require.True(t, acctManager.IsWatchOnlyAccount(gethcommon.HexToAddress(watched)))
+
+	// accounts can be added with an extended public key
+	master, err := extkeys.NewMaster(extkeys.NewMnemonic(extkeys.Salt).MnemonicSeed("test", ""), []byte(extkeys.Salt))
+	require.NoError(t, err)
+	xpub, err := master.Neuter()
+	require.NoError(t, err)
+	xpubAddress, err := acctManager.AddWatchOnlyAccount(xpub.String())
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(master.ToECDSA().PublicKey).Hex(), xpubAddress)
+
+	_, err = acctManager.AddWatchOnlyAccount(watched) // no-op
+	require.NoError(t, err)
+	watchOnly, err := acctManager.WatchOnlyAccounts()
+	require.NoError(t, err)
+	require.Equal(t, []common.WatchOnlyAccount{
+		{Address: address},
+		{Address: xpubAddress, ExtendedKey: xpub.String()},
+	}, watchOnly)
+
+	// list is saved in the node's data directory
+	_, err = os.Stat(filepath.Join(keyStoreDir, "watch-only-accounts.json"))
+	require.NoError(t, err)
+
+	for _, invalid := range []string{"", "0x1234", master.String()} {
+		_, err = acctManager.AddWatchOnlyAccount(invalid)
+		require.Equal(t, account.ErrInvalidWatchOnlyAccount, err, invalid)
+	}
+	keyAddress, _, _, err := acctManager.CreateAccount(TestConfig.Account1.Password)
+	require.NoError(t, err)
+	_, err = acctManager.AddWatchOnlyAccount(keyAddress)
+	require.Equal(t, account.ErrWatchOnlyAccountHasKey, err)
+
+	require.NoError(t, acctManager.RemoveWatchOnlyAccount(watched))
+	require.False(t, acctManager.IsWatchOnlyAccount(gethcommon.HexToAddress(watched)))
+	watchOnly, err = acctManager.WatchOnlyAccounts()
+	require.NoError(t, err)
+	require.Len(t, watchOnly, 1)
+}
+
+func TestAccountMetadata(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	address, _, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+
+	_, err = acctManager.AccountMetadata(address, password)
+	require.Equal(t, account.ErrNoAccountMetadata, err)
+
+	metadata := common.AccountMetadata{Name: "Savings", PhotoPath: "/photos/piggy.png", Flags: map[string]bool{"backedUp": true}}
+	require.NoError(t, acctManager.SetAccountMetadata(address, password, metadata))
+	stored, err := acctManager.AccountMetadata(address, password)
+	require.NoError(t, err)
+	require.Equal(t, "Savings", stored.Name)
+	require.Equal(t, metadata.Flags, stored.Flags)
+	require.NotZero(t, stored.CreatedAt)
+
+	// metadata is encrypted
+	data, err := ioutil.ReadFile(filepath.Join(keyStoreDir, "account-metadata.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "Savings")
+
+	// creation time is kept on update
+	createdAt := stored.CreatedAt
+	metadata.Name = "Spending"
+	require.NoError(t, acctManager.SetAccountMetadata(address, password, metadata))
+	stored, err = acctManager.AccountMetadata(address, password)
+	require.NoError(t, err)
+	require.Equal(t, "Spending", stored.Name)
+	require.Equal(t, createdAt, stored.CreatedAt)
+
+	_, err = acctManager.AccountMetadata(address, "wrong password")
+	require.Error(t, err)
+	require.Error(t, acctManager.SetAccountMetadata(address, "wrong password", metadata))
+
+	require.NoError(t, acctManager.DeleteAccountMetadata(address, password))
+	_, err = acctManager.AccountMetadata(address, password)
+	require.Equal(t, account.ErrNoAccountMetadata, err)
+}
+
+func TestChangeAccountPassword(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	oldPassword, newPassword := TestConfig.Account1.Password, "new password"
+	address, _, _, err := acctManager.CreateAccount(oldPassword)
+	require.NoError(t, err)
+	subAddress, _, err := acctManager.CreateChildAccount(address, oldPassword)
+	require.NoError(t, err)
+
+	require.Equal(t, account.ErrSamePassword, acctManager.ChangeAccountPassword(address, oldPassword, oldPassword))
+	require.Error(t, acctManager.ChangeAccountPassword(address, "wrong password", newPassword))
+
+	require.NoError(t, acctManager.ChangeAccountPassword(address, oldPassword, newPassword))
+	for _, changed := range []string{address, subAddress} {
+		_, err = acctManager.VerifyAccountPassword(keyStoreDir, changed, newPassword)
+		require.NoError(t, err)
+		_, err = acctManager.VerifyAccountPassword(keyStoreDir, changed, oldPassword)
+		require.Error(t, err)
+	}
+
+	// no key is changed if any of them can't be decrypted with the old password
+	subKeyStore := keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP)
+	require.NoError(t, subKeyStore.Update(accounts.Account{Address: gethcommon.HexToAddress(subAddress)}, newPassword, "other password"))
+	require.Error(t, acctManager.ChangeAccountPassword(address, newPassword, oldPassword))
+	_, err = acctManager.VerifyAccountPassword(keyStoreDir, address, newPassword)
+	require.NoError(t, err)
+}
+
+func TestDeleteAccount(t *testing.T) {
+	acctManager, whisperService, keyStoreDir, teardown := newTestWhisperAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	address, pubKey, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+	require.NoError(t, acctManager.SelectAccount(address, password))
+	subAddress, _, err := acctManager.CreateChildAccount("", password)
+	require.NoError(t, err)
+	require.NoError(t, acctManager.SetAccountMetadata(address, password, common.AccountMetadata{Name: "Deleted"}))
+	require.True(t, whisperService.HasKeyPair(pubKey))
+
+	require.Error(t, acctManager.DeleteAccount(address, "wrong password"))
+	_, err = acctManager.VerifyAccountPassword(keyStoreDir, address, password)
+	require.NoError(t, err)
+
+	events := make(chan signal.Envelope, 1)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			signal.Envelope
+			Event signal.AccountDeletedEvent `json:"event"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		envelope.Envelope.Event = envelope.Event
+		events <- envelope.Envelope
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	require.NoError(t, acctManager.DeleteAccount(address, password))
+	envelope := <-events
+	require.Equal(t, signal.EventAccountDeleted, envelope.Type)
+	require.Equal(t, signal.AccountDeletedEvent{Address: address, SubAccounts: []string{subAddress}}, envelope.Event)
+
+	for _, deleted := range []string{address, subAddress} {
+		_, err = acctManager.VerifyAccountPassword(keyStoreDir, deleted, password)
+		require.Error(t, err)
+	}
+	require.False(t, whisperService.HasKeyPair(pubKey))
+	_, err = acctManager.SelectedAccount()
+	require.Equal(t, account.ErrNoAccountSelected, err)
+	data, err := ioutil.ReadFile(filepath.Join(keyStoreDir, "account-metadata.json"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), address)
+}
+
+func TestSessions(t *testing.T) {
+	acctManager, whisperService, _, teardown := newTestWhisperAccountManager(t)
+	defer teardown()
+
+	password := TestConfig.Account1.Password
+	address1, pubKey1, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+	address2, pubKey2, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+	subAddress2, _, err := acctManager.CreateChildAccount(address2, password)
+	require.NoError(t, err)
+
+	require.Error(t, acctManager.OpenSession(address1, "wrong password"))
+	require.Empty(t, acctManager.Sessions())
+
+	// the first session is selected
+	require.NoError(t, acctManager.OpenSession(address1, password))
+	require.NoError(t, acctManager.OpenSession(address2, password))
+	selected, err := acctManager.SelectedAccount()
+	require.NoError(t, err)
+	require.Equal(t, address1, selected.Address.Hex())
+	require.Equal(t, []common.AccountSession{
+		{Address: address1, PubKey: pubKey1, SubAccounts: []string{}, Selected: true},
+		{Address: address2, PubKey: pubKey2, SubAccounts: []string{subAddress2}},
+	}, acctManager.Sessions())
+	require.True(t, whisperService.HasKeyPair(pubKey1))
+	require.True(t, whisperService.HasKeyPair(pubKey2))
+	identities, err := acctManager.Identities()
+	require.NoError(t, err)
+	require.Equal(t, []common.WhisperIdentity{
+		{ID: pubKey1, Address: address1, Selected: true},
+		{ID: pubKey2, Address: address2},
+	}, identities)
+
+	session, err := acctManager.SessionAccount(gethcommon.HexToAddress(address2))
+	require.NoError(t, err)
+	require.Equal(t, address2, session.Address.Hex())
+
+	// sub-accounts of all sessions are unlocked
+	unlocked, err := acctManager.Accounts()
+	require.NoError(t, err)
+	require.Len(t, unlocked, 3)
+	require.Contains(t, unlocked, gethcommon.HexToAddress(subAddress2))
+
+	// the next session is selected once the selected one is closed
+	require.NoError(t, acctManager.CloseSession(address1))
+	require.False(t, whisperService.HasKeyPair(pubKey1))
+	selected, err = acctManager.SelectedAccount()
+	require.NoError(t, err)
+	require.Equal(t, address2, selected.Address.Hex())
+	_, err = acctManager.SessionAccount(gethcommon.HexToAddress(address1))
+	require.Equal(t, account.ErrNoSession, err)
+	require.Equal(t, account.ErrNoSession, acctManager.CloseSession(address1))
+
+	// selecting an account closes other sessions
+	require.NoError(t, acctManager.OpenSession(address1, password))
+	require.NoError(t, acctManager.SelectAccount(address1, password))
+	require.Len(t, acctManager.Sessions(), 1)
+	require.False(t, whisperService.HasKeyPair(pubKey2))
+
+	// selecting another account replaces its identity, keys added directly to Whisper don't outlive logins
+	otherID, err := whisperService.NewKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, acctManager.SelectAccount(address2, password))
+	require.False(t, whisperService.HasKeyPair(pubKey1))
+	require.False(t, whisperService.HasKeyPair(otherID))
+	identities, err = acctManager.Identities()
+	require.NoError(t, err)
+	require.Equal(t, []common.WhisperIdentity{{ID: pubKey2, Address: address2, Selected: true}}, identities)
+
+	require.NoError(t, acctManager.OpenSession(address1, password))
+	require.NoError(t, acctManager.Logout())
+	require.Empty(t, acctManager.Sessions())
+	require.False(t, whisperService.HasKeyPair(pubKey1))
+	require.False(t, whisperService.HasKeyPair(pubKey2))
+	identities, err = acctManager.Identities()
+	require.NoError(t, err)
+	require.Empty(t, identities)
+	_, err = acctManager.SelectedAccount()
+	require.Equal(t, account.ErrNoAccountSelected, err)
+}
+
+func TestBackupAndRestoreKeyStore(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	password, backupPassword := TestConfig.Account1.Password, "backup password"
+	address, _, _, err := acctManager.CreateAccount(password)
+	require.NoError(t, err)
+	subAddress, _, err := acctManager.CreateChildAccount(address, password)
+	require.NoError(t, err)
+	require.NoError(t, acctManager.SetAccountMetadata(address, password, common.AccountMetadata{Name: "Savings"}))
+	_, err = acctManager.AddWatchOnlyAccount("0x00000000000000000000000000000000000000ff")
+	require.NoError(t, err)
+
+	archivePath := filepath.Join(keyStoreDir, "backup.json")
+	require.NoError(t, acctManager.BackupKeyStore(archivePath, backupPassword))
+
+	// neither keys nor metadata are readable in the archive
+	data, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	require.NotContains(t, strings.ToLower(string(data)), strings.ToLower(address[2:]))
+	require.NotContains(t, string(data), "Savings")
+
+	dataDir, err := ioutil.TempDir("", "status-accounts-restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir) //nolint: errcheck
+
+	require.Equal(t, account.ErrInvalidBackupPassword, acctManager.RestoreKeyStore(archivePath, "wrong password", dataDir))
+	_, err = os.Stat(filepath.Join(dataDir, params.KeyStoreDir))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, acctManager.RestoreKeyStore(archivePath, backupPassword, dataDir))
+	restoredKeyStoreDir := filepath.Join(dataDir, params.KeyStoreDir)
+	for _, restored := range []string{address, subAddress} {
+		_, err = acctManager.VerifyAccountPassword(restoredKeyStoreDir, restored, password)
+		require.NoError(t, err)
+	}
+	for _, name := range []string{"account-metadata.json", "watch-only-accounts.json"} {
+		restored, err := ioutil.ReadFile(filepath.Join(dataDir, name))
+		require.NoError(t, err)
+		original, err := ioutil.ReadFile(filepath.Join(keyStoreDir, name))
+		require.NoError(t, err)
+		require.Equal(t, original, restored)
+	}
+
+	// accounts are never overwritten
+	require.Equal(t, account.ErrDataDirNotEmpty, acctManager.RestoreKeyStore(archivePath, backupPassword, dataDir))
+}
+
+func TestRestoreKeyStoreWithCraftedScryptParameters(t *testing.T) {
+	acctManager, keyStoreDir, teardown := newTestAccountManager(t)
+	defer teardown()
+
+	_, _, _, err := acctManager.CreateAccount(TestConfig.Account1.Password)
+	require.NoError(t, err)
+	archivePath := filepath.Join(keyStoreDir, "backup.json")
+	require.NoError(t, acctManager.BackupKeyStore(archivePath, "backup password"))
+
+	data, err := ioutil.ReadFile(archivePath)
+	require.NoError(t, err)
+	var backup map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &backup))
+
+	dataDir, err := ioutil.TempDir("", "status-accounts-restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dataDir) //nolint: errcheck
+
+	// parameters which would take gigabytes of memory or minutes to derive a key with are rejected at once
+	for _, crafted := range []map[string]float64{{"n": 1 << 30}, {"r": 1 << 20}, {"p": 1 << 20}, {"n": 2}} {
+		tampered := make(map[string]interface{}, len(backup))
+		for name, value := range backup {
+			tampered[name] = value
+		}
+		for name, value := range crafted {
+			tampered[name] = value
+		}
+		data, err := json.Marshal(tampered)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(archivePath, data, 0600))
+
+		start := time.Now()
+		require.Equal(t, account.ErrUnsupportedBackup, acctManager.RestoreKeyStore(archivePath, "backup password", dataDir))
+		require.True(t, time.Since(start) < time.Second)
+	}
+}
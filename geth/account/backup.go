@@ -0,0 +1,285 @@
+package account
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/params"
+	"golang.org/x/crypto/scrypt"
+)
+
+// backup errors
+var (
+	ErrInvalidBackupPassword = errors.New("backup can't be decrypted with the password")
+	ErrUnsupportedBackup     = errors.New("backup has an unsupported format")
+	ErrCorruptBackup         = errors.New("backup contains an unexpected file")
+	ErrDataDirNotEmpty       = errors.New("data directory already contains accounts")
+)
+
+const (
+	// backupVersion is a version of the backup format
+	backupVersion = 1
+
+	// scrypt parameters of the backup encryption key, N is between the light and the standard keystore
+	// parameters, to keep memory used for decryption acceptable on mobile devices
+	backupScryptN     = 1 << 15
+	backupScryptR     = 8
+	backupScryptP     = 1
+	backupScryptDKLen = 32
+)
+
+// backupDataFiles are files of the node's data directory stored in a backup along with keystore files.
+var backupDataFiles = []string{accountMetadataFile, watchOnlyAccountsFile}
+
+// encryptedBackup is an AES-GCM encrypted zip archive of keystore and account files,
+// with a key derived from the backup password with scrypt.
+type encryptedBackup struct {
+	Version    int           `json:"version"`
+	ScryptN    int           `json:"n"`
+	ScryptR    int           `json:"r"`
+	ScryptP    int           `json:"p"`
+	Salt       hexutil.Bytes `json:"salt"`
+	Nonce      hexutil.Bytes `json:"nonce"`
+	CipherText hexutil.Bytes `json:"ciphertext"`
+}
+
+// BackupKeyStore writes key files of all accounts in keystore of the running node, along with metadata and watch-only accounts,
+// to a single archive encrypted with the password. Keys remain encrypted with their own passwords in the archive,
+// so it can be moved to another device and restored there with RestoreKeyStore.
+func (m *Manager) BackupKeyStore(archivePath, password string) error {
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return err
+	}
+
+	m.metadataMu.Lock()
+	defer m.metadataMu.Unlock()
+	m.watchOnlyMu.Lock()
+	defer m.watchOnlyMu.Unlock()
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return err
+	}
+	for _, account := range keyStore.Accounts() {
+		name := params.KeyStoreDir + "/" + filepath.Base(account.URL.Path)
+		if err := addBackupFile(writer, name, account.URL.Path); err != nil {
+			return err
+		}
+	}
+	for _, name := range backupDataFiles {
+		err := addBackupFile(writer, name, filepath.Join(config.DataDir, name))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	backup, err := encryptBackup(archive.Bytes(), password)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(archivePath, data, 0600)
+}
+
+// RestoreKeyStore unpacks an archive written by BackupKeyStore into a data directory, which must not contain
+// keystore files or account files yet. The archive is validated before any file is written. The node must be
+// started with the data directory afterwards, using the default keystore directory.
+func (m *Manager) RestoreKeyStore(archivePath, password, dataDir string) error {
+	data, err := ioutil.ReadFile(archivePath)
+	if err != nil {
+		return err
+	}
+	var backup encryptedBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return ErrUnsupportedBackup
+	}
+	archive, err := decryptBackup(backup, password)
+	if err != nil {
+		return err
+	}
+
+	files, err := readBackupFiles(archive)
+	if err != nil {
+		return err
+	}
+
+	keyStoreDir := filepath.Join(dataDir, params.KeyStoreDir)
+	if keyFiles, err := ioutil.ReadDir(keyStoreDir); err == nil && len(keyFiles) > 0 {
+		return ErrDataDirNotEmpty
+	}
+	for _, name := range backupDataFiles {
+		if _, err := os.Stat(filepath.Join(dataDir, name)); err == nil {
+			return ErrDataDirNotEmpty
+		}
+	}
+
+	if err := os.MkdirAll(keyStoreDir, 0700); err != nil {
+		return err
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dataDir, filepath.FromSlash(name)), content, 0600); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addBackupFile copies a file to the archive under a given name.
+func addBackupFile(writer *zip.Writer, name, path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	w, err := writer.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+
+	return err
+}
+
+// readBackupFiles returns contents of archived files by name, checking that there are only keystore files
+// and known account files, so that nothing is written outside of the data directory on restore.
+func readBackupFiles(archive []byte) (map[string][]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		return nil, ErrCorruptBackup
+	}
+
+	files := make(map[string][]byte)
+	for _, file := range reader.File {
+		if !isBackupFileName(file.Name) {
+			return nil, ErrCorruptBackup
+		}
+
+		r, err := file.Open()
+		if err != nil {
+			return nil, ErrCorruptBackup
+		}
+		content, err := ioutil.ReadAll(r)
+		r.Close() // nolint: errcheck
+		if err != nil {
+			return nil, ErrCorruptBackup
+		}
+
+		// keystore files must be keys encrypted by their own passwords
+		if strings.HasPrefix(file.Name, params.KeyStoreDir+"/") {
+			var key struct {
+				Address string          `json:"address"`
+				Crypto  json.RawMessage `json:"crypto"`
+			}
+			if err := json.Unmarshal(content, &key); err != nil || key.Address == "" || len(key.Crypto) == 0 {
+				return nil, ErrCorruptBackup
+			}
+		}
+		files[file.Name] = content
+	}
+
+	return files, nil
+}
+
+// isBackupFileName reports whether a name is of a keystore file or of a known account file.
+func isBackupFileName(name string) bool {
+	for _, dataFile := range backupDataFiles {
+		if name == dataFile {
+			return true
+		}
+	}
+
+	keyFile := strings.TrimPrefix(name, params.KeyStoreDir+"/")
+	return keyFile != name && keyFile != "" && keyFile != "." && keyFile != ".." &&
+		!strings.ContainsAny(keyFile, `/\`)
+}
+
+// backupCipher returns AES-GCM cipher with a key derived from the backup password.
+func backupCipher(password string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(password), salt, n, r, p, backupScryptDKLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptBackup encrypts an archive with a key derived from the password.
+func encryptBackup(archive []byte, password string) (*encryptedBackup, error) {
+	salt := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := backupCipher(password, salt, backupScryptN, backupScryptR, backupScryptP)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return &encryptedBackup{
+		Version:    backupVersion,
+		ScryptN:    backupScryptN,
+		ScryptR:    backupScryptR,
+		ScryptP:    backupScryptP,
+		Salt:       salt,
+		Nonce:      nonce,
+		CipherText: aead.Seal(nil, nonce, archive, nil),
+	}, nil
+}
+
+// decryptBackup decrypts an archive encrypted with encryptBackup. Archives with scrypt parameters other than
+// the ones encryptBackup uses are rejected before a key is derived, as crafted parameters could make
+// derivation use gigabytes of memory or take minutes.
+func decryptBackup(backup encryptedBackup, password string) ([]byte, error) {
+	if backup.Version != backupVersion {
+		return nil, ErrUnsupportedBackup
+	}
+	if backup.ScryptN != backupScryptN || backup.ScryptR != backupScryptR || backup.ScryptP != backupScryptP {
+		return nil, ErrUnsupportedBackup
+	}
+
+	aead, err := backupCipher(password, backup.Salt, backup.ScryptN, backup.ScryptR, backup.ScryptP)
+	if err != nil {
+		return nil, ErrUnsupportedBackup
+	}
+	if len(backup.Nonce) != aead.NonceSize() {
+		return nil, ErrUnsupportedBackup
+	}
+
+	archive, err := aead.Open(nil, backup.Nonce, backup.CipherText, nil)
+	if err != nil {
+		return nil, ErrInvalidBackupPassword
+	}
+
+	return archive, nil
+}
@@ -0,0 +1,91 @@
+package account
+
+import (
+	"fmt"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// Whisper identities of unlocked accounts are installed and removed only here: the key of each session is
+// a Whisper key pair, its public key being the identity ID in Whisper calls. Selecting an account, logging
+// out and re-selecting the account after a node restart replace all key pairs of the node, so that neither
+// identities of previous logins nor keys added with shh_addPrivateKey outlive them.
+
+// Identities returns Whisper identities of accounts unlocked with SelectAccount or OpenSession, which are
+// installed in the running node, in the order the accounts were unlocked.
+func (m *Manager) Identities() ([]common.WhisperIdentity, error) {
+	whisperService, err := m.nodeManager.WhisperService()
+	if err != nil {
+		return nil, err
+	}
+
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+
+	identities := make([]common.WhisperIdentity, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		id := identityID(session)
+		if !whisperService.HasKeyPair(id) {
+			continue
+		}
+		identities = append(identities, common.WhisperIdentity{
+			ID:       id,
+			Address:  session.Address.Hex(),
+			Selected: session == m.selectedAccount,
+		})
+	}
+
+	return identities, nil
+}
+
+// replaceIdentities makes keys of sessions the only Whisper identities of the node.
+func (m *Manager) replaceIdentities(sessions []*common.SelectedExtKey) error {
+	whisperService, err := m.nodeManager.WhisperService()
+	if err != nil {
+		return err
+	}
+
+	if err := whisperService.DeleteKeyPairs(); err != nil {
+		return fmt.Errorf("%s: %v", ErrWhisperClearIdentitiesFailure, err)
+	}
+	for _, session := range sessions {
+		if _, err := whisperService.AddKeyPair(session.AccountKey.PrivateKey); err != nil {
+			return ErrWhisperIdentityInjectionFailure
+		}
+	}
+
+	return nil
+}
+
+// addIdentity installs the key of a session in addition to the node's Whisper identities.
+func (m *Manager) addIdentity(session *common.SelectedExtKey) error {
+	whisperService, err := m.nodeManager.WhisperService()
+	if err != nil {
+		return err
+	}
+
+	if _, err := whisperService.AddKeyPair(session.AccountKey.PrivateKey); err != nil {
+		return ErrWhisperIdentityInjectionFailure
+	}
+
+	return nil
+}
+
+// removeIdentity removes the key of a session from the node's Whisper identities.
+func (m *Manager) removeIdentity(session *common.SelectedExtKey) error {
+	whisperService, err := m.nodeManager.WhisperService()
+	if err != nil {
+		return err
+	}
+
+	whisperService.DeleteKeyPair(identityID(session))
+
+	return nil
+}
+
+// identityID returns the ID of the Whisper identity of a session, its public key.
+func identityID(session *common.SelectedExtKey) string {
+	return gethcommon.ToHex(crypto.FromECDSAPub(&session.AccountKey.PrivateKey.PublicKey))
+}
@@ -0,0 +1,213 @@
+// Package ledger implements signing of transactions on a Ledger hardware wallet running the Ethereum app.
+// The host application provides a transport (USB or BLE) exchanging APDUs with the device, the wire protocol
+// is described in https://github.com/LedgerHQ/blue-app-eth/blob/master/doc/ethapp.asc
+package ledger
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// errors
+var (
+	ErrUnknownAccount   = errors.New("ledger: account has not been derived on the device")
+	ErrInvalidReply     = errors.New("ledger: invalid reply from the device")
+	ErrRejectedByUser   = errors.New("ledger: rejected on the device")
+	ErrAppNotOpen       = errors.New("ledger: Ethereum app is not open on the device")
+	ErrSenderMismatch   = errors.New("ledger: transaction was signed by another account")
+	ErrDeviceStatus     = errors.New("ledger: device returned an error")
+	ErrPathTooLong      = errors.New("ledger: derivation path is longer than 10 components")
+	ErrTransportMissing = errors.New("ledger: transport is not set")
+)
+
+const (
+	claEthereum = 0xe0 // class of Ethereum app instructions
+
+	insGetAddress = 0x02 // returns the public key and address of a BIP32 path
+	insSignTx     = 0x04 // signs a transaction after the user confirms it on the device
+
+	p1FirstTxChunk = 0x00 // first chunk of a transaction to sign
+	p1NextTxChunk  = 0x80 // subsequent chunk of a transaction to sign
+
+	maxChunkSize = 255 // maximum size of APDU data
+	maxPathDepth = 10  // maximum number of derivation path components
+
+	statusOK          = 0x9000
+	statusDenied      = 0x6985 // user rejected the request on the device
+	statusAppNotOpen1 = 0x6d00 // instruction not supported, e.g. in the dashboard
+	statusAppNotOpen2 = 0x6e00 // class not supported
+)
+
+// Transport exchanges APDUs with a device. The host application implements it on top of USB HID or BLE,
+// handling framing of the underlying link. A reply includes the two bytes status word.
+type Transport interface {
+	Exchange(apdu []byte) ([]byte, error)
+}
+
+// Signer signs transactions of accounts derived on a Ledger device, it implements common.TxSigner.
+// Accounts must be derived with Derive before their transactions can be signed.
+type Signer struct {
+	transport Transport
+
+	mu    sync.Mutex                  // serialises exchanges and guards paths
+	paths map[common.Address][]uint32 // derivation paths of derived accounts
+}
+
+// NewSigner returns a signer exchanging APDUs over a given transport.
+func NewSigner(transport Transport) *Signer {
+	return &Signer{
+		transport: transport,
+		paths:     make(map[common.Address][]uint32),
+	}
+}
+
+// Derive returns an address of an account at a derivation path (e.g. m/44'/60'/0'/0/0) on the device,
+// and makes its transactions signable with the signer.
+func (s *Signer) Derive(path accounts.DerivationPath) (common.Address, error) {
+	pathBytes, err := encodePath(path)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reply, err := s.exchange(insGetAddress, 0, 0, pathBytes)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	// reply is public key length, public key, address length and hex encoded address
+	if len(reply) < 1 || len(reply) < 1+int(reply[0]) {
+		return common.Address{}, ErrInvalidReply
+	}
+	reply = reply[1+int(reply[0]):]
+	if len(reply) < 1 || int(reply[0]) != 2*common.AddressLength || len(reply) < 1+int(reply[0]) {
+		return common.Address{}, ErrInvalidReply
+	}
+
+	var address common.Address
+	if _, err := hex.Decode(address[:], reply[1:1+int(reply[0])]); err != nil {
+		return common.Address{}, ErrInvalidReply
+	}
+	s.paths[address] = append([]uint32(nil), path...)
+	log.Info("Ledger account derived", "address", address.Hex(), "path", path.String())
+
+	return address, nil
+}
+
+// HasAccount reports whether an account has been derived with Derive.
+func (s *Signer) HasAccount(address common.Address) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.paths[address]
+	return ok
+}
+
+// SignTx sends a transaction to the device and waits until the user confirms or rejects it.
+func (s *Signer) SignTx(address common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	path, ok := s.paths[address]
+	if !ok {
+		return nil, ErrUnknownAccount
+	}
+	pathBytes, err := encodePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	txRLP, err := rlp.EncodeToBytes([]interface{}{
+		tx.Nonce(), tx.GasPrice(), tx.Gas(), tx.To(), tx.Value(), tx.Data(), chainID, uint(0), uint(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var reply []byte
+	payload := append(pathBytes, txRLP...)
+	for p1 := byte(p1FirstTxChunk); len(payload) > 0; p1 = p1NextTxChunk {
+		chunk := maxChunkSize
+		if chunk > len(payload) {
+			chunk = len(payload)
+		}
+		if reply, err = s.exchange(insSignTx, p1, 0, payload[:chunk]); err != nil {
+			return nil, err
+		}
+		payload = payload[chunk:]
+	}
+
+	// reply is V, R and S, V is EIP155 encoded and truncated to a byte
+	if len(reply) != 65 {
+		return nil, ErrInvalidReply
+	}
+	signature := append(reply[1:], reply[0]-byte(chainID.Uint64()*2+35))
+
+	signer := types.NewEIP155Signer(chainID)
+	signed, err := tx.WithSignature(signer, signature)
+	if err != nil {
+		return nil, err
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != address {
+		return nil, ErrSenderMismatch
+	}
+
+	return signed, nil
+}
+
+// exchange sends an Ethereum app instruction to the device and returns data of the reply.
+func (s *Signer) exchange(ins, p1, p2 byte, data []byte) ([]byte, error) {
+	if s.transport == nil {
+		return nil, ErrTransportMissing
+	}
+
+	apdu := append([]byte{claEthereum, ins, p1, p2, byte(len(data))}, data...)
+	reply, err := s.transport.Exchange(apdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(reply) < 2 {
+		return nil, ErrInvalidReply
+	}
+
+	switch binary.BigEndian.Uint16(reply[len(reply)-2:]) {
+	case statusOK:
+		return reply[:len(reply)-2], nil
+	case statusDenied:
+		return nil, ErrRejectedByUser
+	case statusAppNotOpen1, statusAppNotOpen2:
+		return nil, ErrAppNotOpen
+	default:
+		return nil, ErrDeviceStatus
+	}
+}
+
+// encodePath serialises a derivation path as a number of components followed by big endian components.
+func encodePath(path []uint32) ([]byte, error) {
+	if len(path) > maxPathDepth {
+		return nil, ErrPathTooLong
+	}
+
+	encoded := make([]byte, 1+4*len(path))
+	encoded[0] = byte(len(path))
+	for i, component := range path {
+		binary.BigEndian.PutUint32(encoded[1+4*i:], component)
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,116 @@
+package ledger
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDevice emulates the Ethereum app with a single key, regardless of a derivation path.
+type fakeDevice struct {
+	key     *ecdsa.PrivateKey
+	deny    bool
+	payload []byte
+	apdus   int
+}
+
+func (d *fakeDevice) Exchange(apdu []byte) ([]byte, error) {
+	d.apdus++
+	if len(apdu) < 5 || apdu[0] != claEthereum || int(apdu[4]) != len(apdu)-5 {
+		return nil, errors.New("malformed APDU")
+	}
+	data := apdu[5:]
+
+	switch apdu[1] {
+	case insGetAddress:
+		pubKey := crypto.FromECDSAPub(&d.key.PublicKey)
+		address := []byte(hex.EncodeToString(crypto.PubkeyToAddress(d.key.PublicKey).Bytes()))
+		reply := append([]byte{byte(len(pubKey))}, pubKey...)
+		reply = append(reply, byte(len(address)))
+		reply = append(reply, address...)
+		return append(reply, 0x90, 0x00), nil
+	case insSignTx:
+		if apdu[2] == p1FirstTxChunk {
+			d.payload = nil
+		}
+		d.payload = append(d.payload, data...)
+		if len(data) == maxChunkSize {
+			return []byte{0x90, 0x00}, nil // wait for more chunks
+		}
+		if d.deny {
+			return []byte{0x69, 0x85}, nil
+		}
+
+		txRLP := d.payload[1+4*int(d.payload[0]):]
+		var fields []interface{}
+		if err := rlp.DecodeBytes(txRLP, &fields); err != nil {
+			return nil, err
+		}
+		chainID := new(big.Int).SetBytes(fields[6].([]byte))
+		sig, err := crypto.Sign(crypto.Keccak256(txRLP), d.key)
+		if err != nil {
+			return nil, err
+		}
+		v := byte(chainID.Uint64()*2+35) + sig[64]
+		reply := append([]byte{v}, sig[:64]...)
+		return append(reply, 0x90, 0x00), nil
+	}
+
+	return []byte{0x6d, 0x00}, nil
+}
+
+func TestSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	device := &fakeDevice{key: key}
+	signer := NewSigner(device)
+
+	path, err := accounts.ParseDerivationPath("m/44'/60'/0'/0/0")
+	require.NoError(t, err)
+	address, err := signer.Derive(path)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), address)
+	require.True(t, signer.HasAccount(address))
+	require.False(t, signer.HasAccount(common.Address{1}))
+
+	// transaction data doesn't fit into a single APDU
+	tx := types.NewTransaction(1, common.Address{2}, big.NewInt(10), big.NewInt(21000), big.NewInt(1), make([]byte, 600))
+	chainID := big.NewInt(777) // EIP155 V doesn't fit into a byte
+	device.apdus = 0
+	signed, err := signer.SignTx(address, tx, chainID)
+	require.NoError(t, err)
+	require.Equal(t, 3, device.apdus)
+	sender, err := types.Sender(types.NewEIP155Signer(chainID), signed)
+	require.NoError(t, err)
+	require.Equal(t, address, sender)
+
+	_, err = signer.SignTx(common.Address{1}, tx, chainID)
+	require.Equal(t, ErrUnknownAccount, err)
+
+	device.deny = true
+	_, err = signer.SignTx(address, tx, chainID)
+	require.Equal(t, ErrRejectedByUser, err)
+}
+
+func TestSignerErrors(t *testing.T) {
+	_, err := NewSigner(nil).Derive(accounts.DefaultBaseDerivationPath)
+	require.Equal(t, ErrTransportMissing, err)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := NewSigner(&fakeDevice{key: key})
+	_, err = signer.Derive(make(accounts.DerivationPath, 11))
+	require.Equal(t, ErrPathTooLong, err)
+
+	_, err = signer.exchange(0x06, 0, 0, nil)
+	require.Equal(t, ErrAppNotOpen, err)
+}
@@ -0,0 +1,252 @@
+package account
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// metadata errors
+var (
+	ErrNoAccountMetadata      = errors.New("account has no metadata")
+	ErrCorruptAccountMetadata = errors.New("account metadata can't be decrypted")
+)
+
+// accountMetadataFile is a file in the node's data directory with encrypted metadata of accounts.
+const accountMetadataFile = "account-metadata.json"
+
+// metadataKeySalt makes a metadata encryption key differ from any other key derived from an account key.
+var metadataKeySalt = []byte("status-account-metadata")
+
+// encryptedMetadata is AES-GCM encrypted JSON of common.AccountMetadata.
+type encryptedMetadata struct {
+	Nonce      hexutil.Bytes `json:"nonce"`
+	CipherText hexutil.Bytes `json:"ciphertext"`
+}
+
+// SetAccountMetadata stores metadata of an account, replacing the previous one. Metadata is encrypted
+// with a key derived from the account's private key, so the password must decrypt the account key in keystore.
+// Creation time is set to the current time, unless it's given or the account already has metadata.
+func (m *Manager) SetAccountMetadata(address, password string, metadata common.AccountMetadata) error {
+	privateKey, err := m.decryptedPrivateKey(address, password)
+	if err != nil {
+		return err
+	}
+
+	m.metadataMu.Lock()
+	defer m.metadataMu.Unlock()
+
+	path, err := m.accountMetadataPath()
+	if err != nil {
+		return err
+	}
+	store, err := loadAccountMetadata(path)
+	if err != nil {
+		return err
+	}
+
+	key := crypto.PubkeyToAddress(privateKey.PublicKey).Hex()
+	if metadata.CreatedAt == 0 {
+		metadata.CreatedAt = time.Now().Unix()
+		if encrypted, ok := store[key]; ok {
+			if previous, err := decryptMetadata(encrypted, privateKey); err == nil {
+				metadata.CreatedAt = previous.CreatedAt
+			}
+		}
+	}
+
+	encrypted, err := encryptMetadata(metadata, privateKey)
+	if err != nil {
+		return err
+	}
+	store[key] = encrypted
+
+	return saveAccountMetadata(path, store)
+}
+
+// AccountMetadata returns metadata of an account stored with SetAccountMetadata.
+func (m *Manager) AccountMetadata(address, password string) (*common.AccountMetadata, error) {
+	privateKey, err := m.decryptedPrivateKey(address, password)
+	if err != nil {
+		return nil, err
+	}
+
+	m.metadataMu.Lock()
+	defer m.metadataMu.Unlock()
+
+	path, err := m.accountMetadataPath()
+	if err != nil {
+		return nil, err
+	}
+	store, err := loadAccountMetadata(path)
+	if err != nil {
+		return nil, err
+	}
+
+	encrypted, ok := store[crypto.PubkeyToAddress(privateKey.PublicKey).Hex()]
+	if !ok {
+		return nil, ErrNoAccountMetadata
+	}
+
+	return decryptMetadata(encrypted, privateKey)
+}
+
+// DeleteAccountMetadata removes metadata of an account. It's not an error if the account has none.
+func (m *Manager) DeleteAccountMetadata(address, password string) error {
+	privateKey, err := m.decryptedPrivateKey(address, password)
+	if err != nil {
+		return err
+	}
+
+	return m.removeAccountMetadata(crypto.PubkeyToAddress(privateKey.PublicKey))
+}
+
+// removeAccountMetadata removes metadata of an account with a given address.
+func (m *Manager) removeAccountMetadata(address gethcommon.Address) error {
+	m.metadataMu.Lock()
+	defer m.metadataMu.Unlock()
+
+	path, err := m.accountMetadataPath()
+	if err != nil {
+		return err
+	}
+	store, err := loadAccountMetadata(path)
+	if err != nil {
+		return err
+	}
+	if _, ok := store[address.Hex()]; !ok {
+		return nil
+	}
+
+	delete(store, address.Hex())
+
+	return saveAccountMetadata(path, store)
+}
+
+// decryptedPrivateKey returns a private key of an account in keystore decrypted with the password.
+func (m *Manager) decryptedPrivateKey(address, password string) (*ecdsa.PrivateKey, error) {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return nil, ErrAddressToAccountMappingFailure
+	}
+
+	_, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	return accountKey.PrivateKey, nil
+}
+
+// accountMetadataPath returns a path of the account metadata file of the running node.
+func (m *Manager) accountMetadataPath() (string, error) {
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(config.DataDir, accountMetadataFile), nil
+}
+
+// metadataCipher returns AES-GCM cipher with a key derived from an account's private key.
+func metadataCipher(privateKey *ecdsa.PrivateKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(crypto.Keccak256(metadataKeySalt, crypto.FromECDSA(privateKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// encryptMetadata encrypts metadata with a key derived from an account's private key.
+func encryptMetadata(metadata common.AccountMetadata, privateKey *ecdsa.PrivateKey) (encryptedMetadata, error) {
+	plainText, err := json.Marshal(metadata)
+	if err != nil {
+		return encryptedMetadata{}, err
+	}
+
+	aead, err := metadataCipher(privateKey)
+	if err != nil {
+		return encryptedMetadata{}, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return encryptedMetadata{}, err
+	}
+
+	return encryptedMetadata{
+		Nonce:      nonce,
+		CipherText: aead.Seal(nil, nonce, plainText, nil),
+	}, nil
+}
+
+// decryptMetadata decrypts metadata encrypted with encryptMetadata.
+func decryptMetadata(encrypted encryptedMetadata, privateKey *ecdsa.PrivateKey) (*common.AccountMetadata, error) {
+	aead, err := metadataCipher(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != aead.NonceSize() {
+		return nil, ErrCorruptAccountMetadata
+	}
+
+	plainText, err := aead.Open(nil, encrypted.Nonce, encrypted.CipherText, nil)
+	if err != nil {
+		return nil, ErrCorruptAccountMetadata
+	}
+
+	var metadata common.AccountMetadata
+	if err := json.Unmarshal(plainText, &metadata); err != nil {
+		return nil, ErrCorruptAccountMetadata
+	}
+
+	return &metadata, nil
+}
+
+// loadAccountMetadata reads encrypted metadata by account address from a file, a missing file means there is none.
+func loadAccountMetadata(path string) (map[string]encryptedMetadata, error) {
+	store := make(map[string]encryptedMetadata)
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// saveAccountMetadata replaces the account metadata file.
+func saveAccountMetadata(path string, store map[string]encryptedMetadata) error {
+	data, err := json.Marshal(store)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,214 @@
+package account
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// session errors
+var (
+	ErrNoSession = errors.New("account has no open session")
+)
+
+// OpenSession unlocks an account in addition to already unlocked ones, so that several accounts can be used
+// at the same time. Key of the account is added to Whisper identities, its public key being the identity ID
+// in Whisper calls, its transactions can be completed, and it's returned by eth_accounts with its sub-accounts.
+// The account becomes the selected one if none is selected.
+func (m *Manager) OpenSession(address, password string) error {
+	session, err := m.unlockAccount(address, password)
+	if err != nil {
+		return err
+	}
+
+	if err := m.addIdentity(session); err != nil {
+		return err
+	}
+
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	m.sessions = append(withoutSession(m.sessions, session.Address), session)
+	if m.selectedAccount == nil || m.selectedAccount.Address == session.Address {
+		m.selectedAccount = session
+	}
+
+	return nil
+}
+
+// CloseSession locks an account unlocked with SelectAccount or OpenSession, removing its Whisper identity.
+// If the account is the selected one, the earliest opened of the remaining sessions becomes selected.
+func (m *Manager) CloseSession(address string) error {
+	if !gethcommon.IsHexAddress(address) {
+		return ErrAddressToAccountMappingFailure
+	}
+
+	session, err := m.SessionAccount(gethcommon.HexToAddress(address))
+	if err != nil {
+		return err
+	}
+
+	if err := m.removeIdentity(session); err != nil {
+		return err
+	}
+
+	m.removeSession(session.Address)
+
+	return nil
+}
+
+// SessionAccount returns an account unlocked with SelectAccount or OpenSession.
+func (m *Manager) SessionAccount(address gethcommon.Address) (*common.SelectedExtKey, error) {
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+
+	for _, session := range m.sessions {
+		if session.Address == address {
+			return session, nil
+		}
+	}
+
+	return nil, ErrNoSession
+}
+
+// Sessions returns accounts unlocked with SelectAccount or OpenSession, in the order they were unlocked.
+func (m *Manager) Sessions() []common.AccountSession {
+	m.refreshSessions()
+
+	m.sessionsMu.RLock()
+	defer m.sessionsMu.RUnlock()
+
+	sessions := make([]common.AccountSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		subAccounts := make([]string, 0, len(session.SubAccounts))
+		for _, subAccount := range session.SubAccounts {
+			subAccounts = append(subAccounts, subAccount.Address.Hex())
+		}
+		sessions = append(sessions, common.AccountSession{
+			Address:     session.Address.Hex(),
+			PubKey:      identityID(session),
+			SubAccounts: subAccounts,
+			Selected:    session == m.selectedAccount,
+		})
+	}
+
+	return sessions
+}
+
+// unlockAccount decrypts key of an account and finds its sub-accounts.
+func (m *Manager) unlockAccount(address, password string) (*common.SelectedExtKey, error) {
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	account, err := common.ParseAccountString(address)
+	if err != nil {
+		return nil, ErrAddressToAccountMappingFailure
+	}
+
+	account, accountKey, err := keyStore.AccountDecryptedKey(account, password)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", ErrAccountToKeyMappingFailure.Error(), err)
+	}
+
+	subAccounts, err := m.findSubAccounts(accountKey.ExtendedKey, accountKey.SubAccountIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return &common.SelectedExtKey{
+		Address:     account.Address,
+		AccountKey:  accountKey,
+		SubAccounts: subAccounts,
+	}, nil
+}
+
+// removeSession forgets a session of an account, if any. If the account is the selected one,
+// the earliest opened of the remaining sessions becomes selected.
+func (m *Manager) removeSession(address gethcommon.Address) {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	m.sessions = withoutSession(m.sessions, address)
+	if m.selectedAccount != nil && m.selectedAccount.Address == address {
+		m.selectedAccount = nil
+		if len(m.sessions) > 0 {
+			m.selectedAccount = m.sessions[0]
+		}
+	}
+}
+
+// updateSessionKey replaces a key of an unlocked account, e.g. once its sub-account index is increased.
+func (m *Manager) updateSessionKey(address gethcommon.Address, accountKey *keystore.Key) {
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	updated := make([]*common.SelectedExtKey, len(m.sessions))
+	for i, session := range m.sessions {
+		updated[i] = session
+		if session.Address != address {
+			continue
+		}
+		updated[i] = &common.SelectedExtKey{
+			Address:     session.Address,
+			AccountKey:  accountKey,
+			SubAccounts: session.SubAccounts,
+		}
+		if session == m.selectedAccount {
+			m.selectedAccount = updated[i]
+		}
+	}
+	m.sessions = updated
+}
+
+// refreshSessions re-populates lists of sub-accounts of unlocked accounts.
+func (m *Manager) refreshSessions() {
+	m.sessionsMu.RLock()
+	sessions := m.sessions
+	m.sessionsMu.RUnlock()
+
+	refreshed := make(map[*common.SelectedExtKey]*common.SelectedExtKey, len(sessions))
+	for _, session := range sessions {
+		subAccounts, err := m.findSubAccounts(session.AccountKey.ExtendedKey, session.AccountKey.SubAccountIndex)
+		if err != nil {
+			continue
+		}
+		refreshed[session] = &common.SelectedExtKey{
+			Address:     session.Address,
+			AccountKey:  session.AccountKey,
+			SubAccounts: subAccounts,
+		}
+	}
+
+	m.sessionsMu.Lock()
+	defer m.sessionsMu.Unlock()
+
+	// sessions might have changed meanwhile, only those which are still open are updated
+	updated := make([]*common.SelectedExtKey, len(m.sessions))
+	for i, session := range m.sessions {
+		updated[i] = session
+		if refreshedSession, ok := refreshed[session]; ok {
+			updated[i] = refreshedSession
+		}
+		if session == m.selectedAccount {
+			m.selectedAccount = updated[i]
+		}
+	}
+	m.sessions = updated
+}
+
+// withoutSession returns a copy of sessions without a session of a given account.
+func withoutSession(sessions []*common.SelectedExtKey, address gethcommon.Address) []*common.SelectedExtKey {
+	kept := make([]*common.SelectedExtKey, 0, len(sessions))
+	for _, session := range sessions {
+		if session.Address != address {
+			kept = append(kept, session)
+		}
+	}
+
+	return kept
+}
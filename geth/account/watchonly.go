@@ -0,0 +1,187 @@
+package account
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/status-im/status-go/extkeys"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// watch-only account errors
+var (
+	ErrInvalidWatchOnlyAccount = errors.New("watch-only account must be an address or an extended public key")
+	ErrWatchOnlyAccountHasKey  = errors.New("account has a private key in keystore")
+)
+
+// watchOnlyAccountsFile is a file in the node's data directory listing watch-only accounts.
+const watchOnlyAccountsFile = "watch-only-accounts.json"
+
+// AddWatchOnlyAccount adds an account without a private key, given its address or an extended public key (xpub),
+// in which case the account is the address of the key itself. Watch-only accounts are not returned by eth_accounts,
+// so that dapps don't try to sign with them, and transactions from them are rejected by the transaction queue.
+// The list is saved in the node's data directory, so the node must be running. Address of the account is returned.
+func (m *Manager) AddWatchOnlyAccount(addressOrXPub string) (string, error) {
+	account, err := parseWatchOnlyAccount(strings.TrimSpace(addressOrXPub))
+	if err != nil {
+		return "", err
+	}
+
+	keyStore, err := m.nodeManager.AccountKeyStore()
+	if err != nil {
+		return "", err
+	}
+	if keyStore.HasAddress(gethcommon.HexToAddress(account.Address)) {
+		return "", ErrWatchOnlyAccountHasKey
+	}
+
+	m.watchOnlyMu.Lock()
+	defer m.watchOnlyMu.Unlock()
+
+	path, err := m.watchOnlyAccountsPath()
+	if err != nil {
+		return "", err
+	}
+	watchOnly, err := loadWatchOnlyAccounts(path)
+	if err != nil {
+		return "", err
+	}
+
+	for _, added := range watchOnly {
+		if added.Address == account.Address {
+			return account.Address, nil
+		}
+	}
+
+	if err := saveWatchOnlyAccounts(path, append(watchOnly, account)); err != nil {
+		return "", err
+	}
+
+	return account.Address, nil
+}
+
+// RemoveWatchOnlyAccount removes a watch-only account with a given address. Unknown addresses are ignored.
+func (m *Manager) RemoveWatchOnlyAccount(address string) error {
+	if !gethcommon.IsHexAddress(address) {
+		return ErrInvalidWatchOnlyAccount
+	}
+	removed := gethcommon.HexToAddress(address).Hex()
+
+	m.watchOnlyMu.Lock()
+	defer m.watchOnlyMu.Unlock()
+
+	path, err := m.watchOnlyAccountsPath()
+	if err != nil {
+		return err
+	}
+	watchOnly, err := loadWatchOnlyAccounts(path)
+	if err != nil {
+		return err
+	}
+
+	kept := watchOnly[:0]
+	for _, account := range watchOnly {
+		if account.Address != removed {
+			kept = append(kept, account)
+		}
+	}
+
+	return saveWatchOnlyAccounts(path, kept)
+}
+
+// WatchOnlyAccounts returns watch-only accounts in the order they were added.
+func (m *Manager) WatchOnlyAccounts() ([]common.WatchOnlyAccount, error) {
+	m.watchOnlyMu.Lock()
+	defer m.watchOnlyMu.Unlock()
+
+	path, err := m.watchOnlyAccountsPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return loadWatchOnlyAccounts(path)
+}
+
+// IsWatchOnlyAccount reports whether an address was added with AddWatchOnlyAccount.
+// It's false if the node is not running.
+func (m *Manager) IsWatchOnlyAccount(address gethcommon.Address) bool {
+	watchOnly, err := m.WatchOnlyAccounts()
+	if err != nil {
+		return false
+	}
+
+	for _, account := range watchOnly {
+		if account.Address == address.Hex() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watchOnlyAccountsPath returns a path of the watch-only accounts file of the running node.
+func (m *Manager) watchOnlyAccountsPath() (string, error) {
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(config.DataDir, watchOnlyAccountsFile), nil
+}
+
+// parseWatchOnlyAccount returns a watch-only account of a hex address or a base58 encoded extended public key.
+// Extended private keys are rejected, as they would be stored unencrypted.
+func parseWatchOnlyAccount(addressOrXPub string) (common.WatchOnlyAccount, error) {
+	if gethcommon.IsHexAddress(addressOrXPub) {
+		return common.WatchOnlyAccount{Address: gethcommon.HexToAddress(addressOrXPub).Hex()}, nil
+	}
+
+	extKey, err := extkeys.NewKeyFromString(addressOrXPub)
+	if err != nil || extKey.IsPrivate || len(extKey.KeyData) == 0 {
+		return common.WatchOnlyAccount{}, ErrInvalidWatchOnlyAccount
+	}
+	pubKey, err := btcec.ParsePubKey(extKey.KeyData, btcec.S256())
+	if err != nil {
+		return common.WatchOnlyAccount{}, ErrInvalidWatchOnlyAccount
+	}
+
+	return common.WatchOnlyAccount{
+		Address:     crypto.PubkeyToAddress(*pubKey.ToECDSA()).Hex(),
+		ExtendedKey: addressOrXPub,
+	}, nil
+}
+
+// loadWatchOnlyAccounts reads watch-only accounts from a file, a missing file means there are none.
+func loadWatchOnlyAccounts(path string) ([]common.WatchOnlyAccount, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []common.WatchOnlyAccount{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var watchOnly []common.WatchOnlyAccount
+	if err := json.Unmarshal(data, &watchOnly); err != nil {
+		return nil, err
+	}
+
+	return watchOnly, nil
+}
+
+// saveWatchOnlyAccounts replaces the watch-only accounts file.
+func saveWatchOnlyAccounts(path string, watchOnly []common.WatchOnlyAccount) error {
+	data, err := json.Marshal(watchOnly)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
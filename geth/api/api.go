@@ -2,12 +2,17 @@ package api
 
 import (
 	"context"
+	"time"
 
 	"github.com/NaySoftware/go-fcm"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/p2p"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/msgstore"
 	"github.com/status-im/status-go/geth/params"
 )
 
@@ -18,8 +23,13 @@ type StatusAPI struct {
 
 // NewStatusAPI create a new StatusAPI instance
 func NewStatusAPI() *StatusAPI {
+	return NewStatusAPIWithBackend(NewStatusBackend())
+}
+
+// NewStatusAPIWithBackend creates a new StatusAPI instance using a given backend
+func NewStatusAPIWithBackend(b *StatusBackend) *StatusAPI {
 	return &StatusAPI{
-		b: NewStatusBackend(),
+		b: b,
 	}
 }
 
@@ -85,11 +95,102 @@ func (api *StatusAPI) RestartNode() error {
 	return nil
 }
 
+// RestartNodeWithConfig applies a new config to running Status node, restarting it only if required
+func (api *StatusAPI) RestartNodeWithConfig(config *params.NodeConfig) error {
+	nodeStarted, err := api.b.RestartNodeWithConfig(config)
+	if err != nil {
+		return err
+	}
+	<-nodeStarted // do not return up until backend is ready
+	return nil
+}
+
 // RestartNodeAsync restart running Status node, in async manner
 func (api *StatusAPI) RestartNodeAsync() (<-chan struct{}, error) {
 	return api.b.RestartNode()
 }
 
+// AddPeer adds a static peer and connects to it
+func (api *StatusAPI) AddPeer(url string) error {
+	return api.b.NodeManager().AddPeer(url)
+}
+
+// RemovePeer disconnects a peer and removes it from static peers
+func (api *StatusAPI) RemovePeer(url string) error {
+	return api.b.NodeManager().RemovePeer(url)
+}
+
+// AddTrustedPeer adds a peer which is allowed to connect even if the peers limit is reached
+func (api *StatusAPI) AddTrustedPeer(url string) error {
+	return api.b.NodeManager().AddTrustedPeer(url)
+}
+
+// Peers returns information about connected peers
+func (api *StatusAPI) Peers() ([]*p2p.PeerInfo, error) {
+	return api.b.NodeManager().Peers()
+}
+
+// NodeStatus returns running state, peer count, sync progress and latest block of the node
+func (api *StatusAPI) NodeStatus() common.NodeStatus {
+	return api.b.NodeManager().NodeStatus()
+}
+
+// Metrics returns resource usage of the process and its running node. P2P traffic is counted
+// for connections to peers dialed by the node only, not for connections accepted from peers.
+func (api *StatusAPI) Metrics() common.NodeMetrics {
+	return api.b.NodeManager().Metrics()
+}
+
+// SetBootNodes replaces boot nodes of a running node without restarting it
+func (api *StatusAPI) SetBootNodes(enodes []string) error {
+	return api.b.NodeManager().SetBootNodes(enodes)
+}
+
+// BlacklistPeer prevents connections to a peer given by enode URL, IP address or CIDR range
+func (api *StatusAPI) BlacklistPeer(peer string) error {
+	return api.b.NodeManager().BlacklistPeer(peer)
+}
+
+// UnblacklistPeer removes an entry from the peer blacklist
+func (api *StatusAPI) UnblacklistPeer(peer string) error {
+	return api.b.NodeManager().UnblacklistPeer(peer)
+}
+
+// SetPeerWhitelist restricts connections to given peers, empty whitelist allows all peers
+func (api *StatusAPI) SetPeerWhitelist(peers []string) error {
+	return api.b.NodeManager().SetPeerWhitelist(peers)
+}
+
+// SetNetworkCondition adapts sync and Whisper relaying to a kind of network the device is connected to
+func (api *StatusAPI) SetNetworkCondition(condition common.NetworkCondition) error {
+	return api.b.NodeManager().SetNetworkCondition(condition)
+}
+
+// Suspend quiesces the node and jail timers while the application is in background
+func (api *StatusAPI) Suspend() {
+	api.b.Suspend()
+}
+
+// Resume restarts the activity stopped with Suspend
+func (api *StatusAPI) Resume() {
+	api.b.Resume()
+}
+
+// RegisterTopic advertises the node under a discovery v5 topic
+func (api *StatusAPI) RegisterTopic(topic string) error {
+	return api.b.NodeManager().RegisterTopic(topic)
+}
+
+// UnregisterTopic stops advertising the node under a discovery v5 topic
+func (api *StatusAPI) UnregisterTopic(topic string) error {
+	return api.b.NodeManager().UnregisterTopic(topic)
+}
+
+// SearchTopic returns enode URLs of up to limit nodes registered under a discovery v5 topic
+func (api *StatusAPI) SearchTopic(topic string, limit int, timeout time.Duration) ([]string, error) {
+	return api.b.NodeManager().SearchTopic(topic, limit, timeout)
+}
+
 // ResetChainData remove chain data from data directory.
 // Node is stopped, and new node is started, with clean data directory.
 func (api *StatusAPI) ResetChainData() error {
@@ -119,6 +220,12 @@ func (api *StatusAPI) CreateAccount(password string) (address, pubKey, mnemonic
 	return api.b.AccountManager().CreateAccount(password)
 }
 
+// CreateAccountWithOptions creates an internal geth account with a mnemonic of a given length and language,
+// and an optional BIP39 passphrase
+func (api *StatusAPI) CreateAccountWithOptions(password string, options common.MnemonicOptions) (address, pubKey, mnemonic string, err error) {
+	return api.b.AccountManager().CreateAccountWithOptions(password, options)
+}
+
 // CreateChildAccount creates sub-account for an account identified by parent address.
 // CKD#2 is used as root for master accounts (when parentAddress is "").
 // Otherwise (when parentAddress != ""), child is derived directly from parent.
@@ -132,6 +239,82 @@ func (api *StatusAPI) RecoverAccount(password, mnemonic string) (address, pubKey
 	return api.b.AccountManager().RecoverAccount(password, mnemonic)
 }
 
+// RecoverAccountWithOptions re-creates master key of an account created with CreateAccountWithOptions
+func (api *StatusAPI) RecoverAccountWithOptions(password, mnemonic string, options common.MnemonicOptions) (address, pubKey string, err error) {
+	return api.b.AccountManager().RecoverAccountWithOptions(password, mnemonic, options)
+}
+
+// DeriveAccounts derives accounts with consecutive indexes under a BIP44 path from a mnemonic
+// and an optional BIP39 passphrase, and stores them in keystore encrypted with the password
+func (api *StatusAPI) DeriveAccounts(password, mnemonic, passphrase, path string, start, count int) ([]string, error) {
+	return api.b.AccountManager().DeriveAccounts(password, mnemonic, passphrase, path, start, count)
+}
+
+// ImportPrivateKey stores a hex encoded private key in keystore encrypted with the password
+func (api *StatusAPI) ImportPrivateKey(hexKey, password string) (address, pubKey string, err error) {
+	return api.b.AccountManager().ImportPrivateKey(hexKey, password)
+}
+
+// ExportAccount returns a key of an account as V3 keystore JSON encrypted with the password
+func (api *StatusAPI) ExportAccount(address, password string) (string, error) {
+	return api.b.AccountManager().ExportAccount(address, password)
+}
+
+// ExportExtendedPublicKey returns an extended public key (xpub) of an account at a path relative to its extended key
+func (api *StatusAPI) ExportExtendedPublicKey(address, password, path string) (string, error) {
+	return api.b.AccountManager().ExportExtendedPublicKey(address, password, path)
+}
+
+// AddWatchOnlyAccount adds an account without a private key, given its address or an extended public key
+func (api *StatusAPI) AddWatchOnlyAccount(addressOrXPub string) (string, error) {
+	return api.b.AccountManager().AddWatchOnlyAccount(addressOrXPub)
+}
+
+// RemoveWatchOnlyAccount removes a watch-only account with a given address
+func (api *StatusAPI) RemoveWatchOnlyAccount(address string) error {
+	return api.b.AccountManager().RemoveWatchOnlyAccount(address)
+}
+
+// WatchOnlyAccounts returns watch-only accounts in the order they were added
+func (api *StatusAPI) WatchOnlyAccounts() ([]common.WatchOnlyAccount, error) {
+	return api.b.AccountManager().WatchOnlyAccounts()
+}
+
+// SetAccountMetadata stores metadata of an account, encrypted with a key derived from the account's key
+func (api *StatusAPI) SetAccountMetadata(address, password string, metadata common.AccountMetadata) error {
+	return api.b.AccountManager().SetAccountMetadata(address, password, metadata)
+}
+
+// AccountMetadata returns metadata of an account stored with SetAccountMetadata
+func (api *StatusAPI) AccountMetadata(address, password string) (*common.AccountMetadata, error) {
+	return api.b.AccountManager().AccountMetadata(address, password)
+}
+
+// DeleteAccountMetadata removes metadata of an account
+func (api *StatusAPI) DeleteAccountMetadata(address, password string) error {
+	return api.b.AccountManager().DeleteAccountMetadata(address, password)
+}
+
+// ChangeAccountPassword re-encrypts key of an account and keys of its sub-accounts with a new password
+func (api *StatusAPI) ChangeAccountPassword(address, oldPassword, newPassword string) error {
+	return api.b.AccountManager().ChangeAccountPassword(address, oldPassword, newPassword)
+}
+
+// DeleteAccount removes keys of an account and its sub-accounts along with their Whisper identities
+func (api *StatusAPI) DeleteAccount(address, password string) error {
+	return api.b.AccountManager().DeleteAccount(address, password)
+}
+
+// BackupKeyStore writes keystore files, account metadata and watch-only accounts to an archive encrypted with the password
+func (api *StatusAPI) BackupKeyStore(archivePath, password string) error {
+	return api.b.AccountManager().BackupKeyStore(archivePath, password)
+}
+
+// RestoreKeyStore unpacks an archive written by BackupKeyStore into a data directory without accounts
+func (api *StatusAPI) RestoreKeyStore(archivePath, password, dataDir string) error {
+	return api.b.AccountManager().RestoreKeyStore(archivePath, password, dataDir)
+}
+
 // VerifyAccountPassword tries to decrypt a given account key file, with a provided password.
 // If no error is returned, then account is considered verified.
 func (api *StatusAPI) VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error) {
@@ -145,15 +328,39 @@ func (api *StatusAPI) SelectAccount(address, password string) error {
 	// FIXME(oleg-raev): This method doesn't make stop, it rather resets its cells to an initial state
 	// and should be properly renamed, for example: ResetCells
 	api.b.jailManager.Stop()
+	defer api.b.pruneIdentities()
 	return api.b.AccountManager().SelectAccount(address, password)
 }
 
-// Logout clears whisper identities
+// Logout clears whisper identities and closes all sessions
 func (api *StatusAPI) Logout() error {
 	api.b.jailManager.Stop()
+	defer api.b.pruneIdentities()
 	return api.b.AccountManager().Logout()
 }
 
+// OpenSession unlocks an account in addition to already unlocked ones, so that several accounts
+// can complete transactions and use Whisper at the same time.
+func (api *StatusAPI) OpenSession(address, password string) error {
+	return api.b.AccountManager().OpenSession(address, password)
+}
+
+// CloseSession locks an account unlocked with SelectAccount or OpenSession.
+func (api *StatusAPI) CloseSession(address string) error {
+	defer api.b.pruneIdentities()
+	return api.b.AccountManager().CloseSession(address)
+}
+
+// Sessions returns accounts unlocked with SelectAccount or OpenSession.
+func (api *StatusAPI) Sessions() []common.AccountSession {
+	return api.b.AccountManager().Sessions()
+}
+
+// WhisperIdentities returns Whisper identities of accounts unlocked with SelectAccount or OpenSession.
+func (api *StatusAPI) WhisperIdentities() ([]common.WhisperIdentity, error) {
+	return api.b.AccountManager().Identities()
+}
+
 // SendTransaction creates a new transaction and waits until it's complete.
 func (api *StatusAPI) SendTransaction(ctx context.Context, args common.SendTxArgs) (gethcommon.Hash, error) {
 	return api.b.SendTransaction(ctx, args)
@@ -164,11 +371,104 @@ func (api *StatusAPI) CompleteTransaction(id common.QueuedTxID, password string)
 	return api.b.txQueueManager.CompleteTransaction(id, password)
 }
 
-// CompleteTransactions instructs backend to complete sending of multiple transactions
+// SetTxSigner routes transactions of accounts held by a signer, e.g. a hardware wallet, to it instead of the keystore
+func (api *StatusAPI) SetTxSigner(signer common.TxSigner) {
+	api.b.TxQueueManager().SetTxSigner(signer)
+}
+
+// ResetNonce forgets nonces of transactions of an account sent by the transaction queue, so that nonce of its next
+// transaction is requested from the node, e.g. after transactions were sent from the account elsewhere
+func (api *StatusAPI) ResetNonce(address string) error {
+	if !gethcommon.IsHexAddress(address) {
+		return ErrInvalidAddress
+	}
+
+	api.b.TxQueueManager().ResetNonce(gethcommon.HexToAddress(address))
+
+	return nil
+}
+
+// RegisterContractABI sets a JSON ABI of a contract, so that calls of its methods are decoded in the transaction
+// queued signal, in addition to calls of well-known token methods
+func (api *StatusAPI) RegisterContractABI(address, abiJSON string) error {
+	if !gethcommon.IsHexAddress(address) {
+		return ErrInvalidAddress
+	}
+
+	return api.b.abiRegistry.Register(gethcommon.HexToAddress(address), abiJSON)
+}
+
+// SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price, given as
+// a hex encoded amount of wei. It blocks until the replacement is approved and sent, and returns its hash.
+func (api *StatusAPI) SpeedUpTransaction(ctx context.Context, hash, gasPrice string) (gethcommon.Hash, error) {
+	txHash, err := parseTxHash(hash)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+	price, err := hexutil.DecodeBig(gasPrice)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	return api.b.TxQueueManager().SpeedUpTransaction(ctx, txHash, price)
+}
+
+// CancelTransaction replaces a pending transaction with a transfer of nothing to its sender. It blocks until
+// the replacement is approved and sent, and returns its hash.
+func (api *StatusAPI) CancelTransaction(ctx context.Context, hash string) (gethcommon.Hash, error) {
+	txHash, err := parseTxHash(hash)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	return api.b.TxQueueManager().CancelTransaction(ctx, txHash)
+}
+
+// SignTransaction signs a transaction with the key of its sender decrypted with the password without sending it,
+// and returns the signed transaction and its hash
+func (api *StatusAPI) SignTransaction(args common.SendTxArgs, password string) (hexutil.Bytes, gethcommon.Hash, error) {
+	return api.b.TxQueueManager().SignTransaction(args, password)
+}
+
+// BroadcastRawTransaction sends a hex encoded signed transaction, e.g. one signed with SignTransaction, to the node
+func (api *StatusAPI) BroadcastRawTransaction(ctx context.Context, rawTx string) (gethcommon.Hash, error) {
+	raw, err := hexutil.Decode(rawTx)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	return api.b.TxQueueManager().BroadcastRawTransaction(ctx, raw)
+}
+
+// QueueTokenTransfer queues a transfer of a hex encoded amount of ERC20 tokens, in their smallest units, from
+// the selected account. It blocks until the transfer is approved and sent, and returns its hash.
+func (api *StatusAPI) QueueTokenTransfer(ctx context.Context, token, to, amount string) (gethcommon.Hash, error) {
+	if !gethcommon.IsHexAddress(token) || !gethcommon.IsHexAddress(to) {
+		return gethcommon.Hash{}, ErrInvalidAddress
+	}
+	value, err := hexutil.DecodeBig(amount)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	return api.b.TxQueueManager().QueueTokenTransfer(ctx, gethcommon.HexToAddress(token), gethcommon.HexToAddress(to), value)
+}
+
+// CompleteTransactions sends transactions approved together, none of them unless all can be sent
 func (api *StatusAPI) CompleteTransactions(ids []common.QueuedTxID, password string) map[common.QueuedTxID]common.RawCompleteTransactionResult {
 	return api.b.txQueueManager.CompleteTransactions(ids, password)
 }
 
+// CompleteMessage signs a message queued by personal_sign or eth_signTypedData with the key of its account decrypted with the password
+func (api *StatusAPI) CompleteMessage(id common.QueuedMessageID, password string) (hexutil.Bytes, error) {
+	return api.b.CompleteMessage(id, password)
+}
+
+// DiscardMessage discards a message queued by personal_sign or eth_signTypedData
+func (api *StatusAPI) DiscardMessage(id common.QueuedMessageID) error {
+	return api.b.DiscardMessage(id)
+}
+
 // DiscardTransaction discards a given transaction from transaction queue
 func (api *StatusAPI) DiscardTransaction(id common.QueuedTxID) error {
 	return api.b.txQueueManager.DiscardTransaction(id)
@@ -179,6 +479,16 @@ func (api *StatusAPI) DiscardTransactions(ids []common.QueuedTxID) map[common.Qu
 	return api.b.txQueueManager.DiscardTransactions(ids)
 }
 
+// DiscardAllTransactions discards all queued transactions except for ones being sent, e.g. on logout
+func (api *StatusAPI) DiscardAllTransactions() map[common.QueuedTxID]common.RawDiscardTransactionResult {
+	return api.b.txQueueManager.DiscardAllTransactions()
+}
+
+// PendingTransactions returns queued transactions waiting for approval, in the order they were queued
+func (api *StatusAPI) PendingTransactions() []common.PendingTransaction {
+	return api.b.txQueueManager.PendingTransactions()
+}
+
 // CreateAndInitCell creates a new jail cell context, with the given chatID as identifier.
 // New context executes provided JavaScript code, right after the initialization.
 func (api *StatusAPI) CreateAndInitCell(chatID, js string) string {
@@ -195,11 +505,117 @@ func (api *StatusAPI) JailExecute(chatID, code string) string {
 	return api.b.jailManager.Execute(chatID, code)
 }
 
+// StopJailCell stops a jail cell and removes it.
+func (api *StatusAPI) StopJailCell(chatID string) error {
+	return api.b.jailManager.StopCell(chatID)
+}
+
+// RestartJailCell stops a jail cell and creates it again with the JavaScript code it was created with.
+func (api *StatusAPI) RestartJailCell(chatID string) string {
+	return api.b.jailManager.RestartCell(chatID)
+}
+
+// InterruptJailCell aborts JavaScript code being executed in a jail cell, e.g. a script stuck in an infinite loop.
+func (api *StatusAPI) InterruptJailCell(chatID string) error {
+	return api.b.jailManager.InterruptCell(chatID)
+}
+
+// SnapshotJailCell returns a snapshot of a jail cell, which can be restored with RestoreJailCell after a restart.
+func (api *StatusAPI) SnapshotJailCell(chatID string) (*common.JailCellSnapshot, error) {
+	return api.b.jailManager.SnapshotCell(chatID)
+}
+
+// RestoreJailCell creates a jail cell from a snapshot, replaying its initialization.
+func (api *StatusAPI) RestoreJailCell(snapshot *common.JailCellSnapshot) string {
+	return api.b.jailManager.RestoreCell(snapshot)
+}
+
+// JailCells returns statuses of running jail cells.
+func (api *StatusAPI) JailCells() []common.JailCellStatus {
+	return api.b.jailManager.Cells()
+}
+
+// RegisterJailModule adds a CommonJS module jail cells can require, if the SHA-256 hash of its code is the given hash
+func (api *StatusAPI) RegisterJailModule(name, code, hash string) error {
+	return api.b.jailManager.RegisterModule(name, code, hash)
+}
+
+// UnregisterJailModule removes a module jail cells can require
+func (api *StatusAPI) UnregisterJailModule(name string) {
+	api.b.jailManager.UnregisterModule(name)
+}
+
+// SetJailConsoleCapture enables or disables sending messages written with console of jail cells as signals
+func (api *StatusAPI) SetJailConsoleCapture(enabled bool) {
+	api.b.jailManager.SetConsoleCapture(enabled)
+}
+
+// InspectJailCell returns global variables, pending timers and the last error of a jail cell
+func (api *StatusAPI) InspectJailCell(chatID string) (*common.JailCellInspection, error) {
+	return api.b.jailManager.InspectCell(chatID)
+}
+
+// EvaluateInJailCell evaluates a JS expression in a jail cell, if JailDebug is enabled in the node's config
+func (api *StatusAPI) EvaluateInJailCell(chatID, expr string) (string, error) {
+	return api.b.jailManager.EvaluateInCell(chatID, expr)
+}
+
+// SetJailFetchWhitelist sets hosts a jail cell can fetch from, in addition to hosts allowed by the node's config
+func (api *StatusAPI) SetJailFetchWhitelist(chatID string, hosts []string) {
+	api.b.jailManager.SetFetchWhitelist(chatID, hosts)
+}
+
+// PurgeJailStorage removes all items from localStorage of a jail cell, e.g. when a DApp is removed
+func (api *StatusAPI) PurgeJailStorage(chatID string) error {
+	return api.b.jailManager.PurgeStorage(chatID)
+}
+
 // SetJailBaseJS allows to setup initial JavaScript to be loaded on each jail.CreateAndInitCell().
 func (api *StatusAPI) SetJailBaseJS(js string) {
 	api.b.jailManager.SetBaseJS(js)
 }
 
+// OpenMessageStore starts storing Whisper messages received by an identity, which is an ID of a key pair in Whisper
+func (api *StatusAPI) OpenMessageStore(identity string) error {
+	store, err := api.b.messageStore()
+	if err != nil {
+		return err
+	}
+
+	return store.Open(identity)
+}
+
+// CloseMessageStore stops storing Whisper messages received by an identity, keeping stored ones
+func (api *StatusAPI) CloseMessageStore(identity string) error {
+	store, err := api.b.messageStore()
+	if err != nil {
+		return err
+	}
+	store.Close(identity)
+
+	return nil
+}
+
+// QueryMessages returns a page of stored Whisper messages of an identity selected by a query
+func (api *StatusAPI) QueryMessages(identity string, query msgstore.Query) (*msgstore.QueryResult, error) {
+	store, err := api.b.messageStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Query(identity, query)
+}
+
+// DeleteMessages removes stored Whisper messages of an identity of a topic, or all of them if the topic is empty
+func (api *StatusAPI) DeleteMessages(identity string, topic whisper.TopicType) error {
+	store, err := api.b.messageStore()
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(identity, topic)
+}
+
 // Notify sends a push notification to the device with the given token.
 // @deprecated
 func (api *StatusAPI) Notify(token string) string {
@@ -227,3 +643,13 @@ func (api *StatusAPI) NotifyUsers(message string, payload fcm.NotificationPayloa
 
 	return err
 }
+
+// parseTxHash decodes a hex encoded transaction hash.
+func parseTxHash(hash string) (gethcommon.Hash, error) {
+	data, err := hexutil.Decode(hash)
+	if err != nil || len(data) != gethcommon.HashLength {
+		return gethcommon.Hash{}, ErrInvalidTxHash
+	}
+
+	return gethcommon.BytesToHash(data), nil
+}
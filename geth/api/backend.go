@@ -2,21 +2,56 @@ package api
 
 import (
 	"context"
+	"errors"
+	"path/filepath"
 	"sync"
+	"time"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/abi"
 	"github.com/status-im/status-go/geth/account"
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/delivery"
+	"github.com/status-im/status-go/geth/gasprice"
+	"github.com/status-im/status-go/geth/groups"
 	"github.com/status-im/status-go/geth/jail"
 	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/msgqueue"
+	"github.com/status-im/status-go/geth/msgstore"
 	"github.com/status-im/status-go/geth/node"
 	"github.com/status-im/status-go/geth/notification/fcm"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/push"
+	"github.com/status-im/status-go/geth/receipts"
+	"github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/geth/signal"
+	"github.com/status-im/status-go/geth/topics"
 	"github.com/status-im/status-go/geth/txqueue"
+	"github.com/status-im/status-go/geth/txwatcher"
+)
+
+var (
+	// ErrInvalidPeerURL is returned when peer management RPC method is called without an enode URL.
+	ErrInvalidPeerURL = errors.New("enode URL of a peer is expected")
+
+	// ErrInvalidBootNodes is returned when status_setBootNodes is called without a list of enode URLs.
+	ErrInvalidBootNodes = errors.New("list of boot nodes enode URLs is expected")
+
+	// ErrInvalidPeerWhitelist is returned when status_setPeerWhitelist is called without a list of peers.
+	ErrInvalidPeerWhitelist = errors.New("list of whitelisted enode URLs and IP ranges is expected")
+
+	// ErrInvalidAddress is returned when an account address is not hex encoded.
+	ErrInvalidAddress = errors.New("hex encoded account address is expected")
+
+	// ErrInvalidTxHash is returned when a transaction hash is not hex encoded.
+	ErrInvalidTxHash = errors.New("hex encoded transaction hash is expected")
 )
 
 const (
+	// drainCheckInterval is how often stopping node checks whether Whisper envelopes sent by it are relayed
+	drainCheckInterval = 100 * time.Millisecond
+
 	//todo(jeka): should be removed
 	fcmServerKey = "AAAAxwa-r08:APA91bFtMIToDVKGAmVCm76iEXtA4dn9MPvLdYKIZqAlNpLJbd12EgdBI9DSDSXKdqvIAgLodepmRhGVaWvhxnXJzVpE6MoIRuKedDV3kfHSVBhWFqsyoLTwXY4xeufL9Sdzb581U-lx"
 )
@@ -25,21 +60,48 @@ const (
 type StatusBackend struct {
 	sync.Mutex
 	nodeReady       chan struct{} // channel to wait for when node is fully ready
+	nodeStopping    chan struct{} // channel to wait for when node is being stopped
 	nodeManager     common.NodeManager
 	accountManager  common.AccountManager
 	txQueueManager  common.TxQueueManager
+	txWatcher       *txwatcher.Watcher
+	abiRegistry     *abi.Registry // decodes contract calls of queued transactions
+	msgQueueManager common.MessageQueueManager
 	jailManager     common.JailManager
 	newNotification common.NotificationConstructor
+	clock           *common.SuspendableClock // clock of jail timers, transaction and message timeouts, suspended with Suspend
 }
 
 // NewStatusBackend create a new NewStatusBackend instance
 func NewStatusBackend() *StatusBackend {
+	return NewStatusBackendWithNodeManager(node.NewNodeManager())
+}
+
+// NewStatusBackendWithNodeManager create a new StatusBackend instance on top of a given node manager,
+// e.g. one of several node managers of a node.Registry. Signals of the backend's services are sent
+// with the node manager, so that they're marked with the name of the node.
+func NewStatusBackendWithNodeManager(nodeManager common.NodeManager) *StatusBackend {
 	defer log.Info("Status backend initialized")
 
-	nodeManager := node.NewNodeManager()
+	clock := common.NewSuspendableClock(common.SystemClock)
 	accountManager := account.NewManager(nodeManager)
+	accountManager.SetSignalHandler(nodeManager.SendSignal)
 	txQueueManager := txqueue.NewManager(nodeManager, accountManager)
+	txQueueManager.SetSignalHandler(nodeManager.SendSignal)
+	txQueueManager.SetClock(clock)
+	txQueueManager.SetGasPriceOracle(gasprice.NewOracle(nodeManager))
+	txWatcher := txwatcher.NewWatcher(nodeManager)
+	txWatcher.SetSignalHandler(nodeManager.SendSignal)
+	txWatcher.SetClock(clock)
+	txQueueManager.SetTxWatcher(txWatcher)
+	abiRegistry := abi.NewRegistry()
+	txQueueManager.SetCallDecoder(abiRegistry)
+	msgQueueManager := msgqueue.NewManager(accountManager)
+	msgQueueManager.SetSignalHandler(nodeManager.SendSignal)
+	msgQueueManager.SetClock(clock)
 	jailManager := jail.New(nodeManager)
+	jailManager.SetSignalHandler(nodeManager.SendSignal)
+	jailManager.SetClock(clock)
 	notificationManager := fcm.NewNotification(fcmServerKey)
 
 	return &StatusBackend{
@@ -47,7 +109,11 @@ func NewStatusBackend() *StatusBackend {
 		accountManager:  accountManager,
 		jailManager:     jailManager,
 		txQueueManager:  txQueueManager,
+		txWatcher:       txWatcher,
+		abiRegistry:     abiRegistry,
+		msgQueueManager: msgQueueManager,
 		newNotification: notificationManager,
+		clock:           clock,
 	}
 }
 
@@ -71,6 +137,11 @@ func (m *StatusBackend) TxQueueManager() common.TxQueueManager {
 	return m.txQueueManager
 }
 
+// MessageQueueManager returns reference to the queue of messages signed with personal_sign or eth_signTypedData
+func (m *StatusBackend) MessageQueueManager() common.MessageQueueManager {
+	return m.msgQueueManager
+}
+
 // IsNodeRunning confirm that node is running
 func (m *StatusBackend) IsNodeRunning() bool {
 	return m.nodeManager.IsNodeRunning()
@@ -90,7 +161,15 @@ func (m *StatusBackend) StartNode(config *params.NodeConfig) (<-chan struct{}, e
 		return nil, err
 	}
 
+	m.txQueueManager.Configure(config.TxQueueConfig)
+	m.jailManager.ConfigureFetch(config.JailFetchConfig)
+	m.jailManager.ConfigureLimits(config.JailLimitsConfig)
+	m.jailManager.SetDebug(config.JailDebug)
+	if config.DataDir != "" {
+		m.jailManager.SetStorageDir(filepath.Join(config.DataDir, jail.StorageDir))
+	}
 	m.txQueueManager.Start()
+	m.txWatcher.Start(config.TxWatcherConfig)
 
 	m.nodeReady = make(chan struct{}, 1)
 	go m.onNodeStart(nodeStarted, m.nodeReady) // waits on nodeStarted, writes to backendReady
@@ -111,13 +190,31 @@ func (m *StatusBackend) onNodeStart(nodeStarted <-chan struct{}, backendReady ch
 	}
 	log.Info("Account reselected")
 
+	if err := m.restoreTransactionQueue(); err != nil {
+		log.Error("Restoring queued transactions failed", "err", err)
+	}
+
 	close(backendReady)
-	signal.Send(signal.Envelope{
+	m.nodeManager.SendSignal(signal.Envelope{
 		Type:  signal.EventNodeReady,
 		Event: struct{}{},
 	})
 }
 
+// restoreTransactionQueue restores transactions queued before the application was restarted,
+// and makes the queue save transactions to the node's data directory.
+func (m *StatusBackend) restoreTransactionQueue() error {
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return err
+	}
+	if config.DataDir == "" {
+		return nil
+	}
+
+	return m.txQueueManager.RestoreQueue(filepath.Join(config.DataDir, txqueue.QueuedTransactionsFile))
+}
+
 // StopNode stop Status node. Stopped node cannot be resumed.
 func (m *StatusBackend) StopNode() (<-chan struct{}, error) {
 	m.Lock()
@@ -128,19 +225,34 @@ func (m *StatusBackend) StopNode() (<-chan struct{}, error) {
 	}
 	<-m.nodeReady
 
-	m.txQueueManager.Stop()
-	m.jailManager.Stop()
+	if m.nodeStopping != nil {
+		return m.nodeStopping, nil
+	}
 
-	nodeStopped, err := m.nodeManager.StopNode()
+	config, err := m.nodeManager.NodeConfig()
 	if err != nil {
 		return nil, err
 	}
 
+	// no new work is accepted while in-flight one is drained
+	m.jailManager.Stop()
+
 	backendStopped := make(chan struct{}, 1)
+	m.nodeStopping = backendStopped
 	go func() {
-		<-nodeStopped
+		m.drain(time.Duration(config.ShutdownDrainTimeout) * time.Second)
+		m.txQueueManager.Stop()
+		m.txWatcher.Stop()
+
+		if nodeStopped, err := m.nodeManager.StopNode(); err != nil {
+			log.Error("Failed to stop node", "error", err)
+		} else {
+			<-nodeStopped
+		}
+
 		m.Lock()
 		m.nodeReady = nil
+		m.nodeStopping = nil
 		m.Unlock()
 		close(backendStopped)
 	}()
@@ -148,17 +260,119 @@ func (m *StatusBackend) StopNode() (<-chan struct{}, error) {
 	return backendStopped, nil
 }
 
+// drain waits up to a given timeout until queued transactions are completed or discarded,
+// and Whisper envelopes sent by the node are written to at least one peer, as counted by the delivery tracker.
+func (m *StatusBackend) drain(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := m.txQueueManager.Drain(ctx); err != nil {
+		log.Warn("Transaction queue is not drained before shutdown",
+			"queued", m.txQueueManager.TransactionQueue().Count(), "error", err)
+	}
+
+	// envelopes can't be relayed without peers
+	runningNode, err := m.nodeManager.Node()
+	if err != nil || runningNode.Server().PeerCount() == 0 {
+		return
+	}
+	var tracker *delivery.Tracker
+	if err := runningNode.Service(&tracker); err != nil {
+		return
+	}
+
+	ticker := time.NewTicker(drainCheckInterval)
+	defer ticker.Stop()
+
+	for tracker.Unrelayed() > 0 {
+		select {
+		case <-ctx.Done():
+			log.Warn("Whisper envelopes are not relayed before shutdown", "unrelayed", tracker.Unrelayed())
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// messageStore returns the store of Whisper messages of the running node.
+func (m *StatusBackend) messageStore() (*msgstore.Store, error) {
+	runningNode, err := m.nodeManager.Node()
+	if err != nil {
+		return nil, err
+	}
+
+	var store *msgstore.Store
+	if err := runningNode.Service(&store); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// pruneIdentities drops state services of the running node keep for Whisper identities removed from Whisper,
+// so that it doesn't outlive their logins.
+func (m *StatusBackend) pruneIdentities() {
+	runningNode, err := m.nodeManager.Node()
+	if err != nil {
+		return
+	}
+
+	var (
+		topicsManager   *topics.Manager
+		groupsManager   *groups.Manager
+		store           *msgstore.Store
+		receiptsService *receipts.Service
+		pushClient      *push.Client
+	)
+	if err := runningNode.Service(&topicsManager); err == nil {
+		topicsManager.PruneIdentities()
+	}
+	if err := runningNode.Service(&groupsManager); err == nil {
+		groupsManager.PruneIdentities()
+	}
+	if err := runningNode.Service(&store); err == nil {
+		store.PruneIdentities()
+	}
+	if err := runningNode.Service(&receiptsService); err == nil {
+		receiptsService.PruneIdentities()
+	}
+	if err := runningNode.Service(&pushClient); err == nil {
+		pushClient.PruneIdentities()
+	}
+}
+
+// Suspend quiesces the node and stops jail timers while the application is in background.
+// Queued transactions stay in the queue and don't time out until Resume is called.
+// It may be called whether the node is running or not.
+func (m *StatusBackend) Suspend() {
+	m.nodeManager.Suspend()
+	m.clock.Suspend()
+}
+
+// Resume restarts the activity stopped with Suspend.
+func (m *StatusBackend) Resume() {
+	m.clock.Resume()
+	m.nodeManager.Resume()
+}
+
 // RestartNode restart running Status node, fails if node is not running
 func (m *StatusBackend) RestartNode() (<-chan struct{}, error) {
+	return m.RestartNodeWithConfig(nil)
+}
+
+// RestartNodeWithConfig applies a new config to running Status node,
+// restarting it only if changed fields can't be applied in place.
+// Node is restarted with its current config if a given one is nil.
+func (m *StatusBackend) RestartNodeWithConfig(config *params.NodeConfig) (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
-	if m.nodeReady == nil {
+	if m.nodeReady == nil || m.nodeStopping != nil {
 		return nil, node.ErrNoRunningNode
 	}
 	<-m.nodeReady
 
-	nodeRestarted, err := m.nodeManager.RestartNode()
+	nodeRestarted, err := m.nodeManager.RestartNode(config)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +389,7 @@ func (m *StatusBackend) ResetChainData() (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
-	if m.nodeReady == nil {
+	if m.nodeReady == nil || m.nodeStopping != nil {
 		return nil, node.ErrNoRunningNode
 	}
 	<-m.nodeReady
@@ -221,7 +435,7 @@ func (m *StatusBackend) CompleteTransaction(id common.QueuedTxID, password strin
 	return m.txQueueManager.CompleteTransaction(id, password)
 }
 
-// CompleteTransactions instructs backend to complete sending of multiple transactions
+// CompleteTransactions sends transactions approved together, none of them unless all can be sent
 func (m *StatusBackend) CompleteTransactions(ids []common.QueuedTxID, password string) map[common.QueuedTxID]common.RawCompleteTransactionResult {
 	return m.txQueueManager.CompleteTransactions(ids, password)
 }
@@ -236,6 +450,16 @@ func (m *StatusBackend) DiscardTransactions(ids []common.QueuedTxID) map[common.
 	return m.txQueueManager.DiscardTransactions(ids)
 }
 
+// CompleteMessage signs a message queued by personal_sign or eth_signTypedData with the key of its account decrypted with the password
+func (m *StatusBackend) CompleteMessage(id common.QueuedMessageID, password string) (hexutil.Bytes, error) {
+	return m.msgQueueManager.CompleteMessage(id, password)
+}
+
+// DiscardMessage discards a message queued by personal_sign or eth_signTypedData
+func (m *StatusBackend) DiscardMessage(id common.QueuedMessageID) error {
+	return m.msgQueueManager.DiscardMessage(id)
+}
+
 // registerHandlers attaches Status callback handlers to running node
 func (m *StatusBackend) registerHandlers() error {
 	rpcClient := m.NodeManager().RPCClient()
@@ -245,6 +469,21 @@ func (m *StatusBackend) registerHandlers() error {
 
 	rpcClient.RegisterHandler("eth_accounts", m.accountManager.AccountsRPCHandler())
 	rpcClient.RegisterHandler("eth_sendTransaction", m.txQueueManager.SendTransactionRPCHandler)
+	rpcClient.RegisterHandler("personal_sign", m.msgQueueManager.PersonalSignRPCHandler)
+	rpcClient.RegisterHandler("eth_signTypedData", m.msgQueueManager.SignTypedDataRPCHandler)
+	rpcClient.RegisterHandler("status_addPeer", peerRPCHandler(m.nodeManager.AddPeer))
+	rpcClient.RegisterHandler("status_removePeer", peerRPCHandler(m.nodeManager.RemovePeer))
+	rpcClient.RegisterHandler("status_addTrustedPeer", peerRPCHandler(m.nodeManager.AddTrustedPeer))
+	rpcClient.RegisterHandler("status_peers", func(context.Context, ...interface{}) (interface{}, error) {
+		return m.nodeManager.Peers()
+	})
+	rpcClient.RegisterHandler("status_setBootNodes", m.setBootNodesRPCHandler)
+	rpcClient.RegisterHandler("status_blacklistPeer", peerRPCHandler(m.nodeManager.BlacklistPeer))
+	rpcClient.RegisterHandler("status_unblacklistPeer", peerRPCHandler(m.nodeManager.UnblacklistPeer))
+	rpcClient.RegisterHandler("status_setPeerWhitelist", m.setPeerWhitelistRPCHandler)
+	rpcClient.RegisterHandler("status_health", func(context.Context, ...interface{}) (interface{}, error) {
+		return m.nodeManager.NodeStatus(), nil
+	})
 
 	m.txQueueManager.SetTransactionQueueHandler(m.txQueueManager.TransactionQueueHandler())
 	log.Info("Registered handler", "fn", "TransactionQueueHandler")
@@ -254,3 +493,74 @@ func (m *StatusBackend) registerHandlers() error {
 
 	return nil
 }
+
+// setBootNodesRPCHandler replaces boot nodes with enode URLs passed as the only param.
+func (m *StatusBackend) setBootNodesRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, ErrInvalidBootNodes
+	}
+
+	urls, ok := args[0].([]interface{})
+	if !ok {
+		return nil, ErrInvalidBootNodes
+	}
+
+	enodes := make([]string, len(urls))
+	for i, url := range urls {
+		if enodes[i], ok = url.(string); !ok {
+			return nil, ErrInvalidBootNodes
+		}
+	}
+
+	if err := m.nodeManager.SetBootNodes(enodes); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// setPeerWhitelistRPCHandler restricts peers to enode URLs and IP ranges passed as the only param.
+func (m *StatusBackend) setPeerWhitelistRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) != 1 {
+		return nil, ErrInvalidPeerWhitelist
+	}
+
+	entries, ok := args[0].([]interface{})
+	if !ok {
+		return nil, ErrInvalidPeerWhitelist
+	}
+
+	peers := make([]string, len(entries))
+	for i, entry := range entries {
+		if peers[i], ok = entry.(string); !ok {
+			return nil, ErrInvalidPeerWhitelist
+		}
+	}
+
+	if err := m.nodeManager.SetPeerWhitelist(peers); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// peerRPCHandler returns RPC handler which calls a given peer management
+// method with enode URL passed as the only param.
+func peerRPCHandler(fn func(url string) error) rpc.Handler {
+	return func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, ErrInvalidPeerURL
+		}
+
+		url, ok := args[0].(string)
+		if !ok {
+			return nil, ErrInvalidPeerURL
+		}
+
+		if err := fn(url); err != nil {
+			return nil, err
+		}
+
+		return true, nil
+	}
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/jail"
+	"github.com/status-im/status-go/geth/node"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/status-im/status-go/geth/txqueue"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackendSignalsOfNodes(t *testing.T) {
+	registry := node.NewRegistry()
+
+	var mu sync.Mutex
+	received := make(map[string][]signal.Envelope) // by the node whose handler received them
+	backends := make(map[string]*StatusBackend)
+	for _, name := range []string{"wallet", "dev"} {
+		nodeManager, err := registry.Create(name)
+		require.NoError(t, err)
+
+		name := name
+		nodeManager.SetSignalHandler(func(envelope signal.Envelope) {
+			mu.Lock()
+			defer mu.Unlock()
+			received[name] = append(received[name], envelope)
+		})
+		backends[name] = NewStatusBackendWithNodeManager(nodeManager)
+	}
+
+	// signals of a jail cell are sent by the node of its backend
+	backends["wallet"].JailManager().CreateAndInitCell("chat", `statusSignals.sendSignal("wallet")`)
+
+	// so are transaction queue signals, e.g. of a DApp over its rate limit
+	txQueueManager := backends["dev"].txQueueManager.(*txqueue.Manager)
+	txQueueManager.Configure(params.TxQueueConfig{RateLimit: 0.001, RateBurst: 1})
+	ctx := context.WithValue(context.Background(), common.ChatIDKey, "dapp")
+	require.NoError(t, txQueueManager.QueueTransaction(txQueueManager.CreateTransaction(ctx, common.SendTxArgs{})))
+	require.Equal(t, txqueue.ErrTxRateLimited, txQueueManager.QueueTransaction(txQueueManager.CreateTransaction(ctx, common.SendTxArgs{})))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, received["wallet"], 1)
+	require.Equal(t, jail.EventSignal, received["wallet"][0].Type)
+	require.Equal(t, "wallet", received["wallet"][0].Node)
+	require.Len(t, received["dev"], 1)
+	require.Equal(t, txqueue.EventTransactionsThrottled, received["dev"][0].Type)
+	require.Equal(t, "dev", received["dev"][0].Node)
+}
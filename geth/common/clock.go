@@ -0,0 +1,196 @@
+package common
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstracts wall-clock time, so that time-dependent components
+// (transaction expiry, jail timers) can be driven by a fake clock in tests.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// AfterFunc waits for the duration to elapse and then calls f in its own goroutine.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a single event scheduled with Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the timer from firing. It returns false if the timer has already fired or been stopped.
+	Stop() bool
+
+	// Reset changes the timer to expire after duration d. It returns true if the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+// SystemClock is Clock backed by the time package.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+// SuspendableClock is Clock whose timers can be suspended, e.g. while the application is in background.
+// Suspended timers don't fire. Once the clock is resumed, they fire after the time which was left
+// when the clock was suspended, so that time spent suspended doesn't count. Now is not affected.
+type SuspendableClock struct {
+	clock Clock
+
+	mu        sync.Mutex
+	suspended bool
+	timers    map[*suspendableTimer]struct{} // timers which haven't fired or been stopped yet
+}
+
+// NewSuspendableClock returns SuspendableClock scheduling timers with a given clock.
+func NewSuspendableClock(clock Clock) *SuspendableClock {
+	return &SuspendableClock{
+		clock:  clock,
+		timers: make(map[*suspendableTimer]struct{}),
+	}
+}
+
+// Now returns the current time of the underlying clock.
+func (c *SuspendableClock) Now() time.Time {
+	return c.clock.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel.
+func (c *SuspendableClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.AfterFunc(d, func() {
+		ch <- c.Now()
+	})
+
+	return ch
+}
+
+// AfterFunc waits for the duration to elapse and then calls f in its own goroutine.
+func (c *SuspendableClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &suspendableTimer{clock: c, f: f}
+	t.schedule(d)
+
+	return t
+}
+
+// Suspend stops all timers until Resume is called.
+func (c *SuspendableClock) Suspend() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.suspended {
+		return
+	}
+	c.suspended = true
+
+	now := c.clock.Now()
+	for t := range c.timers {
+		t.unschedule()
+		t.remaining = t.deadline.Sub(now)
+		if t.remaining < 0 {
+			t.remaining = 0
+		}
+	}
+}
+
+// Resume restarts timers stopped with Suspend.
+func (c *SuspendableClock) Resume() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.suspended {
+		return
+	}
+	c.suspended = false
+
+	for t := range c.timers {
+		t.schedule(t.remaining)
+	}
+}
+
+// suspendableTimer is Timer of SuspendableClock. Its fields are guarded by the clock's mutex.
+type suspendableTimer struct {
+	clock      *SuspendableClock
+	f          func()
+	timer      Timer         // timer of the underlying clock, nil while suspended
+	generation int           // incremented whenever timer is replaced, so that a stale one doesn't fire
+	deadline   time.Time     // time when the timer fires, unless suspended
+	remaining  time.Duration // time left when the timer was suspended
+}
+
+// schedule activates the timer to fire after d, or once the clock is resumed and d elapses.
+func (t *suspendableTimer) schedule(d time.Duration) {
+	t.clock.timers[t] = struct{}{}
+	t.unschedule()
+
+	if t.clock.suspended {
+		t.remaining = d
+		return
+	}
+
+	generation := t.generation
+	t.deadline = t.clock.clock.Now().Add(d)
+	t.timer = t.clock.clock.AfterFunc(d, func() {
+		t.fire(generation)
+	})
+}
+
+// unschedule stops the underlying timer, leaving the timer active.
+func (t *suspendableTimer) unschedule() {
+	t.generation++
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *suspendableTimer) fire(generation int) {
+	t.clock.mu.Lock()
+	if _, active := t.clock.timers[t]; !active || generation != t.generation {
+		t.clock.mu.Unlock()
+		return
+	}
+	delete(t.clock.timers, t)
+	t.clock.mu.Unlock()
+
+	t.f()
+}
+
+// Stop implements Timer.
+func (t *suspendableTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	_, active := t.clock.timers[t]
+	delete(t.clock.timers, t)
+	t.unschedule()
+
+	return active
+}
+
+// Reset implements Timer.
+func (t *suspendableTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	_, active := t.clock.timers[t]
+	t.schedule(d)
+
+	return active
+}
@@ -0,0 +1,106 @@
+package common_test
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+	. "github.com/status-im/status-go/testing"
+	"github.com/stretchr/testify/require"
+)
+
+type firedCounter struct {
+	fired int32
+	done  chan struct{}
+}
+
+func newFiredCounter() *firedCounter {
+	return &firedCounter{done: make(chan struct{}, 10)}
+}
+
+func (c *firedCounter) fire() {
+	atomic.AddInt32(&c.fired, 1)
+	c.done <- struct{}{}
+}
+
+func (c *firedCounter) count() int {
+	return int(atomic.LoadInt32(&c.fired))
+}
+
+func (c *firedCounter) wait(t *testing.T) {
+	select {
+	case <-c.done:
+	case <-time.After(time.Second):
+		t.Fatal("timer did not fire")
+	}
+}
+
+func TestSuspendableClock(t *testing.T) {
+	fake := NewFakeClock(time.Now())
+	clock := common.NewSuspendableClock(fake)
+	require.Equal(t, fake.Now(), clock.Now())
+
+	counter := newFiredCounter()
+	timer := clock.AfterFunc(time.Minute, counter.fire)
+	after := clock.After(2 * time.Minute)
+
+	fake.Advance(30 * time.Second)
+	clock.Suspend()
+	clock.Suspend() // no-op
+
+	// time spent suspended doesn't count
+	fake.Advance(time.Hour)
+	require.Equal(t, 0, counter.count())
+	select {
+	case <-after:
+		t.Fatal("After fired while suspended")
+	default:
+	}
+
+	clock.Resume()
+	fake.Advance(29 * time.Second)
+	require.Equal(t, 0, counter.count())
+	fake.Advance(time.Second)
+	counter.wait(t)
+	require.False(t, timer.Stop())
+
+	fake.Advance(time.Minute)
+	select {
+	case <-after:
+	case <-time.After(time.Second):
+		t.Fatal("After did not fire")
+	}
+	require.Equal(t, 1, counter.count())
+}
+
+func TestSuspendableTimerStopAndReset(t *testing.T) {
+	fake := NewFakeClock(time.Now())
+	clock := common.NewSuspendableClock(fake)
+
+	counter := newFiredCounter()
+	stopped := clock.AfterFunc(time.Second, counter.fire)
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+
+	timer := clock.AfterFunc(time.Second, counter.fire)
+	clock.Suspend()
+
+	// timers stopped and reset while suspended don't fire until resumed
+	require.False(t, stopped.Reset(time.Minute))
+	require.True(t, stopped.Stop())
+	require.True(t, timer.Reset(2*time.Second))
+	fake.Advance(time.Minute)
+	require.Equal(t, 0, counter.count())
+
+	clock.Resume()
+	fake.Advance(2 * time.Second)
+	counter.wait(t)
+	require.Equal(t, 1, counter.count())
+
+	// fired timer is rescheduled with Reset, like an interval of a jail timer
+	require.False(t, timer.Reset(time.Second))
+	fake.Advance(time.Second)
+	counter.wait(t)
+	require.Equal(t, 2, counter.count())
+}
@@ -134,6 +134,27 @@ func (r RPCCall) ParseGasPrice() *hexutil.Big {
 	return (*hexutil.Big)(parsedValue)
 }
 
+// ParseChainID returns the hex big chain ID associated with the call.
+// nolint: dupl
+func (r RPCCall) ParseChainID() *hexutil.Big {
+	params, ok := r.Params[0].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	inputValue, ok := params["chainId"].(string)
+	if !ok {
+		return nil
+	}
+
+	parsedValue, err := hexutil.DecodeBig(inputValue)
+	if err != nil {
+		return nil
+	}
+
+	return (*hexutil.Big)(parsedValue)
+}
+
 // ToSendTxArgs converts RPCCall to SendTxArgs.
 func (r RPCCall) ToSendTxArgs() SendTxArgs {
 	var err error
@@ -156,5 +177,6 @@ func (r RPCCall) ToSendTxArgs() SendTxArgs {
 		Data:     r.ParseData(),
 		Gas:      r.ParseGas(),
 		GasPrice: r.ParseGasPrice(),
+		ChainID:  r.ParseChainID(),
 	}
 }
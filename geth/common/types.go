@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"strings"
 	"time"
@@ -14,12 +15,18 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/extkeys"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
 	"github.com/status-im/status-go/static"
 )
 
@@ -72,6 +79,47 @@ type MessageState struct {
 	Source whisper.NewMessage `json:"source,omitempty"`
 }
 
+// NodeEventType is a kind of node lifecycle event
+type NodeEventType string
+
+// node lifecycle events, in order they normally occur
+const (
+	NodeStarting   NodeEventType = "starting"
+	NodeStarted    NodeEventType = "started"
+	SyncStarted    NodeEventType = "sync.started"
+	SyncFinished   NodeEventType = "sync.finished"
+	NodeStopped    NodeEventType = "stopped"
+	NodeCrashed    NodeEventType = "crashed"
+	NodeRestarting NodeEventType = "restarting"
+)
+
+// NodeEvent is a node lifecycle event. Error is set when the node
+// has crashed, or when synchronization has finished with a failure.
+type NodeEvent struct {
+	Type  NodeEventType
+	Error error
+
+	// Attempt is a number of consecutive restart of a crashed node, set for NodeRestarting
+	Attempt int
+}
+
+// ChainHead is a new head of the canonical chain
+type ChainHead struct {
+	Number    uint64      `json:"number"`
+	Hash      common.Hash `json:"hash"`
+	Timestamp uint64      `json:"timestamp"` // block time, in seconds since the epoch
+}
+
+// NetworkCondition is a kind of network the device is connected to
+type NetworkCondition string
+
+// network conditions reported by the application
+const (
+	NetworkWiFi     NetworkCondition = "wifi"
+	NetworkCellular NetworkCondition = "cellular"
+	NetworkOffline  NetworkCondition = "offline"
+)
+
 // NodeManager defines expected methods for managing Status node
 type NodeManager interface {
 	// StartNode start Status node, fails if node is already started
@@ -81,8 +129,9 @@ type NodeManager interface {
 	// Stopped node cannot be resumed, one starts a new node instead.
 	StopNode() (<-chan struct{}, error)
 
-	// RestartNode restart running Status node, fails if node is not running
-	RestartNode() (<-chan struct{}, error)
+	// RestartNode restart running Status node, fails if node is not running.
+	// If newConfig is not nil, node is reconfigured, restarting it only if required.
+	RestartNode(newConfig *params.NodeConfig) (<-chan struct{}, error)
 
 	// ResetChainData remove chain data from data directory.
 	// Node is stopped, and new node is started, with clean data directory.
@@ -103,9 +152,63 @@ type NodeManager interface {
 	// AddPeer adds URL of static peer
 	AddPeer(url string) error
 
+	// RemovePeer disconnects a peer and removes it from static peers
+	RemovePeer(url string) error
+
+	// AddTrustedPeer adds URL of a peer which is allowed to connect even if the peers limit is reached
+	AddTrustedPeer(url string) error
+
+	// Peers returns information about connected peers
+	Peers() ([]*p2p.PeerInfo, error)
+
+	// SetBootNodes replaces boot nodes of a running node without restarting it
+	SetBootNodes(enodes []string) error
+
+	// BlacklistPeer prevents connections to a peer given by enode URL, IP address or CIDR range
+	BlacklistPeer(peer string) error
+
+	// UnblacklistPeer removes an entry from the peer blacklist
+	UnblacklistPeer(peer string) error
+
+	// SetPeerWhitelist restricts connections to given peers, empty whitelist allows all peers
+	SetPeerWhitelist(peers []string) error
+
+	// SetNetworkCondition adapts sync and Whisper relaying to a kind of network the device is connected to
+	SetNetworkCondition(condition NetworkCondition) error
+
+	// Suspend quiesces sync, dialing and discovery while the application is in background
+	Suspend()
+
+	// Resume lifts restrictions of Suspend
+	Resume()
+
+	// RegisterTopic advertises the node under a discovery v5 topic
+	RegisterTopic(topic string) error
+
+	// UnregisterTopic stops advertising the node under a discovery v5 topic
+	UnregisterTopic(topic string) error
+
+	// SearchTopic returns enode URLs of up to limit nodes registered under a discovery v5 topic
+	SearchTopic(topic string, limit int, timeout time.Duration) ([]string, error)
+
+	// NodeStatus returns running state, peer count, sync progress and latest block of the node
+	NodeStatus() NodeStatus
+
+	// Metrics returns resource usage of the process and its running node
+	Metrics() NodeMetrics
+
+	// Subscribe delivers node lifecycle events to a given channel until unsubscribed
+	Subscribe(events chan NodeEvent) event.Subscription
+
+	// SubscribeChainHeads delivers new heads of the canonical chain to a given channel until unsubscribed
+	SubscribeChainHeads(heads chan ChainHead) event.Subscription
+
 	// LightEthereumService exposes reference to LES service running on top of the node
 	LightEthereumService() (*les.LightEthereum, error)
 
+	// EthereumService exposes reference to eth service of a full node (fast or full sync mode)
+	EthereumService() (*eth.Ethereum, error)
+
 	// WhisperService returns reference to running Whisper service
 	WhisperService() (*whisper.Whisper, error)
 
@@ -117,6 +220,9 @@ type NodeManager interface {
 
 	// RPCClient exposes reference to RPC client connected to the running node
 	RPCClient() *rpc.Client
+
+	// SendSignal sends a signal of a service running on the node, marked with the node's name
+	SendSignal(envelope signal.Envelope)
 }
 
 // AccountManager defines expected methods for managing Status accounts
@@ -136,6 +242,67 @@ type AccountManager interface {
 	// Once master key is re-generated, it is inserted into keystore (if not already there).
 	RecoverAccount(password, mnemonic string) (address, pubKey string, err error)
 
+	// CreateAccountWithOptions creates an internal geth account with a mnemonic of a given length and language,
+	// and an optional BIP39 passphrase, which is required to recover the account along with the mnemonic.
+	CreateAccountWithOptions(password string, options MnemonicOptions) (address, pubKey, mnemonic string, err error)
+
+	// RecoverAccountWithOptions re-creates master key of an account created with CreateAccountWithOptions.
+	// The mnemonic is validated against a word list of a given language.
+	RecoverAccountWithOptions(password, mnemonic string, options MnemonicOptions) (address, pubKey string, err error)
+
+	// DeriveAccounts derives count accounts with consecutive indexes starting from start under a BIP44 path
+	// from a mnemonic and an optional BIP39 passphrase, and stores them in keystore encrypted with the password.
+	DeriveAccounts(password, mnemonic, passphrase, path string, start, count int) ([]string, error)
+
+	// ImportPrivateKey stores a hex encoded private key in keystore encrypted with the password.
+	// Such an account has no sub-accounts.
+	ImportPrivateKey(hexKey, password string) (address, pubKey string, err error)
+
+	// ExportAccount returns a key of an account as V3 keystore JSON encrypted with the password,
+	// which must decrypt the key in keystore.
+	ExportAccount(address, password string) (string, error)
+
+	// ExportExtendedPublicKey returns an extended public key (xpub) of an account at a path relative
+	// to its extended key, the password must decrypt the account key in keystore.
+	ExportExtendedPublicKey(address, password, path string) (string, error)
+
+	// AddWatchOnlyAccount adds an account without a private key, given its address or an extended public key.
+	AddWatchOnlyAccount(addressOrXPub string) (string, error)
+
+	// RemoveWatchOnlyAccount removes a watch-only account with a given address.
+	RemoveWatchOnlyAccount(address string) error
+
+	// WatchOnlyAccounts returns watch-only accounts in the order they were added.
+	WatchOnlyAccounts() ([]WatchOnlyAccount, error)
+
+	// IsWatchOnlyAccount reports whether an address was added with AddWatchOnlyAccount.
+	IsWatchOnlyAccount(address common.Address) bool
+
+	// SetAccountMetadata stores metadata of an account encrypted with a key derived from the account's key,
+	// the password must decrypt the account key in keystore.
+	SetAccountMetadata(address, password string, metadata AccountMetadata) error
+
+	// AccountMetadata returns metadata of an account stored with SetAccountMetadata.
+	AccountMetadata(address, password string) (*AccountMetadata, error)
+
+	// DeleteAccountMetadata removes metadata of an account.
+	DeleteAccountMetadata(address, password string) error
+
+	// ChangeAccountPassword re-encrypts key of an account and keys of its sub-accounts with a new password,
+	// keys are restored with the old password if any of them fails.
+	ChangeAccountPassword(address, oldPassword, newPassword string) error
+
+	// DeleteAccount removes keys of an account and its sub-accounts from keystore along with their
+	// Whisper identities, after checking the password.
+	DeleteAccount(address, password string) error
+
+	// BackupKeyStore writes keystore files and account files of the running node to an archive
+	// encrypted with the password.
+	BackupKeyStore(archivePath, password string) error
+
+	// RestoreKeyStore unpacks an archive written by BackupKeyStore into a data directory without accounts.
+	RestoreKeyStore(archivePath, password, dataDir string) error
+
 	// VerifyAccountPassword tries to decrypt a given account key file, with a provided password.
 	// If no error is returned, then account is considered verified.
 	VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error)
@@ -151,9 +318,24 @@ type AccountManager interface {
 	// SelectedAccount returns currently selected account
 	SelectedAccount() (*SelectedExtKey, error)
 
-	// Logout clears whisper identities
+	// Logout clears whisper identities and closes all sessions
 	Logout() error
 
+	// OpenSession unlocks an account in addition to already unlocked ones, adding its key to Whisper identities.
+	OpenSession(address, password string) error
+
+	// CloseSession locks an account unlocked with SelectAccount or OpenSession, removing its Whisper identity.
+	CloseSession(address string) error
+
+	// SessionAccount returns an account unlocked with SelectAccount or OpenSession.
+	SessionAccount(address common.Address) (*SelectedExtKey, error)
+
+	// Sessions returns accounts unlocked with SelectAccount or OpenSession, in the order they were unlocked.
+	Sessions() []AccountSession
+
+	// Identities returns Whisper identities of unlocked accounts, which are installed in the running node.
+	Identities() ([]WhisperIdentity, error)
+
 	// Accounts returns handler to process account list request
 	Accounts() ([]common.Address, error)
 
@@ -186,7 +368,15 @@ type QueuedTx struct {
 	Hash       common.Hash
 	Context    context.Context
 	Args       SendTxArgs
-	InProgress bool // true if transaction is being sent
+	ChatID     string              // ID of a chat of a DApp which requested the transaction from its jail cell, if any
+	Token      *TokenTransfer      // transfer of tokens made by the transaction, if it was queued as one
+	InProgress bool                // true if transaction is being sent
+	Replaces   common.Hash         // hash of a pending transaction replaced by the transaction, if any
+	QueuedAt   time.Time           // when the transaction was queued
+	Deadline   time.Time           // when the transaction times out, if it's not completed or discarded
+	Restored   bool                // true if transaction was queued before a restart
+	GasPrices  *GasPriceSuggestion // gas prices suggested before the transaction was enqueued, if an oracle is set
+	Estimate   *GasEstimate        // gas and fee estimated before the transaction was enqueued, if the node was available
 	Done       chan struct{}
 	Discard    chan struct{}
 	Err        error
@@ -201,6 +391,7 @@ type SendTxArgs struct {
 	Value    *hexutil.Big    `json:"value"`
 	Data     hexutil.Bytes   `json:"data"`
 	Nonce    *hexutil.Uint64 `json:"nonce"`
+	ChainID  *hexutil.Big    `json:"chainId"` // network the transaction is meant for, if given
 }
 
 // EnqueuedTxHandler is a function that receives queued/pending transactions, when they get queued
@@ -232,6 +423,13 @@ type TxQueueManager interface {
 	// Stop stops accepting new transactions in the queue.
 	Stop()
 
+	// Drain stops accepting new transactions and waits until queued ones are
+	// completed or discarded, or a given context is done.
+	Drain(ctx context.Context) error
+
+	// Configure applies limits of the queue.
+	Configure(config params.TxQueueConfig)
+
 	// TransactionQueue returns a transaction queue.
 	TransactionQueue() TxQueue
 
@@ -264,7 +462,7 @@ type TxQueueManager interface {
 	// CompleteTransaction instructs backend to complete sending of a given transaction
 	CompleteTransaction(id QueuedTxID, password string) (common.Hash, error)
 
-	// CompleteTransactions instructs backend to complete sending of multiple transactions
+	// CompleteTransactions sends transactions approved together, none of them unless all can be sent
 	CompleteTransactions(ids []QueuedTxID, password string) map[QueuedTxID]RawCompleteTransactionResult
 
 	// DiscardTransaction discards a given transaction from transaction queue
@@ -272,6 +470,159 @@ type TxQueueManager interface {
 
 	// DiscardTransactions discards given multiple transactions from transaction queue
 	DiscardTransactions(ids []QueuedTxID) map[QueuedTxID]RawDiscardTransactionResult
+
+	// DiscardAllTransactions discards all queued transactions except for ones being sent
+	DiscardAllTransactions() map[QueuedTxID]RawDiscardTransactionResult
+
+	// PendingTransactions returns queued transactions waiting for approval, in the order they were queued
+	PendingTransactions() []PendingTransaction
+
+	// SetTxSigner routes transactions of accounts held by a signer to it, instead of the keystore.
+	SetTxSigner(signer TxSigner)
+
+	// ResetNonce forgets nonces of transactions of an account sent by the queue, so that the next one
+	// is requested from the node.
+	ResetNonce(address common.Address)
+
+	// SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price.
+	SpeedUpTransaction(ctx context.Context, hash common.Hash, gasPrice *big.Int) (common.Hash, error)
+
+	// CancelTransaction replaces a pending transaction with a transfer of nothing to the sender.
+	CancelTransaction(ctx context.Context, hash common.Hash) (common.Hash, error)
+
+	// RestoreQueue restores transactions queued before a restart from a file, and saves queued transactions to it.
+	RestoreQueue(path string) error
+
+	// SignTransaction signs a transaction without sending it, and returns the signed transaction and its hash.
+	SignTransaction(args SendTxArgs, password string) (hexutil.Bytes, common.Hash, error)
+
+	// BroadcastRawTransaction sends a signed transaction to the node.
+	BroadcastRawTransaction(ctx context.Context, raw hexutil.Bytes) (common.Hash, error)
+
+	// QueueTokenTransfer queues an ERC20 transfer from the selected account and waits until it's sent.
+	QueueTokenTransfer(ctx context.Context, token, to common.Address, amount *big.Int) (common.Hash, error)
+}
+
+// TxSigner signs transactions of accounts it holds keys of outside of the keystore, e.g. on a hardware wallet
+type TxSigner interface {
+	// HasAccount reports whether the signer can sign transactions of an account.
+	HasAccount(address common.Address) bool
+
+	// SignTx signs a transaction of an account with EIP155 signer of a given chain.
+	SignTx(address common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// GasPriceSuggestion is gas price in wei of a transaction to be mined slowly, normally or fast
+type GasPriceSuggestion struct {
+	Slow   *hexutil.Big `json:"slow"`
+	Normal *hexutil.Big `json:"normal"`
+	Fast   *hexutil.Big `json:"fast"`
+}
+
+// GasEstimate is gas, gas price and the highest fee of a queued transaction, estimated as it's queued,
+// so that the user knows what a transaction costs before approving it
+type GasEstimate struct {
+	Gas      *hexutil.Big `json:"gas,omitempty"`       // given gas, or gas estimated by the node with a margin
+	GasPrice *hexutil.Big `json:"gas_price,omitempty"` // given gas price, or the suggested one
+	Fee      *hexutil.Big `json:"fee,omitempty"`       // gas times gas price, the highest fee paid
+	Error    string       `json:"error,omitempty"`     // why gas couldn't be estimated, e.g. the transaction would fail
+}
+
+// CallPreview is a contract call of a queued transaction decoded with an ABI of the called method,
+// so that the user sees what a transaction does before approving it
+type CallPreview struct {
+	Method    string         `json:"method"`
+	Signature string         `json:"signature"` // canonical signature, e.g. transfer(address,uint256)
+	Args      []CallArgument `json:"args"`
+}
+
+// CallArgument is a decoded argument of a contract call. Numbers, addresses and bytes are
+// given as decimal, checksummed and hex encoded strings, arrays as lists of such values.
+type CallArgument struct {
+	Name  string      `json:"name,omitempty"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// CallDecoder decodes contract calls of queued transactions
+type CallDecoder interface {
+	// DecodeCall returns a call of a contract with given data, or nil if the method isn't known.
+	DecodeCall(to *common.Address, data []byte) *CallPreview
+}
+
+// PendingTransaction is a queued transaction waiting for approval, as listed by TxQueueManager.PendingTransactions
+type PendingTransaction struct {
+	ID         QueuedTxID     `json:"id"`
+	Args       SendTxArgs     `json:"args"`
+	MessageID  string         `json:"message_id"`        // identifies the chat the transaction was requested from
+	ChatID     string         `json:"chat_id,omitempty"` // ID of a chat of a DApp which requested the transaction
+	QueuedAt   time.Time      `json:"queued_at"`
+	Deadline   time.Time      `json:"deadline"`
+	InProgress bool           `json:"in_progress"`
+	Replaces   *common.Hash   `json:"replaces,omitempty"`
+	Restored   bool           `json:"restored,omitempty"`
+	Estimate   *GasEstimate   `json:"estimate,omitempty"` // estimated when the transaction was queued, if the node was available
+	Call       *CallPreview   `json:"call,omitempty"`     // decoded contract call, if the method is known
+	Token      *TokenTransfer `json:"token,omitempty"`    // transfer of tokens, if the transaction was queued as one
+}
+
+// TokenTransfer is a transfer of ERC20 tokens queued with TxQueueManager.QueueTokenTransfer, previewed
+// with the token's symbol and the amount adjusted by the token's decimals, if the token provides them
+type TokenTransfer struct {
+	Token     common.Address `json:"token"`
+	To        common.Address `json:"to"`
+	Amount    *hexutil.Big   `json:"amount"` // in the token's smallest units
+	Symbol    string         `json:"symbol,omitempty"`
+	Decimals  *uint8         `json:"decimals,omitempty"`
+	Formatted string         `json:"formatted_amount,omitempty"` // amount in whole tokens, e.g. 1.5
+}
+
+// GasPriceOracle suggests gas price of transactions
+type GasPriceOracle interface {
+	// SuggestGasPrices returns gas prices based on recent blocks, or defaults of the network if they can't be fetched.
+	SuggestGasPrices(ctx context.Context) GasPriceSuggestion
+}
+
+// TxWatcher follows sent transactions until they are confirmed, dropped or replaced
+type TxWatcher interface {
+	// Watch starts following a transaction sent from an account.
+	Watch(hash common.Hash, from common.Address)
+}
+
+// QueuedMessageID queued message identifier
+type QueuedMessageID string
+
+// QueuedMessage is a message waiting to be signed with personal_sign or eth_signTypedData,
+// once the user approves it with the password.
+type QueuedMessage struct {
+	ID         QueuedMessageID
+	Context    context.Context
+	Address    common.Address
+	Data       hexutil.Bytes
+	Hash       hexutil.Bytes // hash of data signed once the message is approved
+	Signature  hexutil.Bytes
+	InProgress bool // true if message is being signed
+	Done       chan struct{}
+	Err        error
+}
+
+// MessageQueueManager holds messages signed with personal_sign or eth_signTypedData until they are approved
+// with the password
+type MessageQueueManager interface {
+	// SignMessage queues a message of an account and blocks until it's signed, discarded or times out.
+	SignMessage(ctx context.Context, address common.Address, data []byte) (hexutil.Bytes, error)
+
+	// CompleteMessage signs a queued message with the key of its account decrypted with the password.
+	CompleteMessage(id QueuedMessageID, password string) (hexutil.Bytes, error)
+
+	// DiscardMessage discards a queued message, SignMessage returns an error.
+	DiscardMessage(id QueuedMessageID) error
+
+	// PersonalSignRPCHandler is a handler of personal_sign method.
+	PersonalSignRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error)
+
+	// SignTypedDataRPCHandler is a handler of eth_signTypedData method.
+	SignTypedDataRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error)
 }
 
 // JailCell represents single jail cell, which is basically a JavaScript VM.
@@ -289,6 +640,39 @@ type JailCell interface {
 	Stop() error
 }
 
+// JailCellStatus describes a running jail cell.
+type JailCellStatus struct {
+	ChatID        string `json:"chat_id"`
+	Uptime        int64  `json:"uptime"` // seconds since the cell was created
+	PendingTimers int    `json:"pending_timers"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// JailTimer describes a timer of a jail cell waiting to fire.
+type JailTimer struct {
+	ID       int64 `json:"id"`
+	Delay    int64 `json:"delay"` // milliseconds
+	Interval bool  `json:"interval"`
+}
+
+// JailCellInspection describes the state of a jail cell for debugging.
+type JailCellInspection struct {
+	ChatID    string      `json:"chat_id"`
+	Globals   []string    `json:"globals"` // names of global variables defined by JS code, not built in
+	Timers    []JailTimer `json:"timers"`
+	LastError string      `json:"last_error,omitempty"`
+	LastStack string      `json:"last_stack,omitempty"` // stack trace of the last error
+}
+
+// JailCellSnapshot is what a jail cell is restored from after a restart: the user code it was initialized with,
+// which is run again, its localStorage if it's kept in memory, and its Whisper filters, which are created again.
+type JailCellSnapshot struct {
+	ChatID  string                 `json:"chat_id"`
+	Code    []string               `json:"code"`
+	Storage map[string]string      `json:"storage,omitempty"` // items of localStorage kept in memory
+	Filters map[string]interface{} `json:"filters,omitempty"` // criteria of Whisper message filters by filter ID
+}
+
 // JailManager defines methods for managing jailed environments
 type JailManager interface {
 	// Call executes given JavaScript function w/i a jail cell context identified by the chatID.
@@ -307,9 +691,60 @@ type JailManager interface {
 	// Execute allows to run arbitrary JS code within a cell.
 	Execute(chatID, code string) string
 
+	// StopCell stops a jail cell and removes it.
+	StopCell(chatID string) error
+
+	// RestartCell stops a jail cell and creates it again with the code it was initialized with.
+	RestartCell(chatID string) string
+
+	// InterruptCell aborts JS code being executed in a jail cell, if any.
+	InterruptCell(chatID string) error
+
+	// Cells returns statuses of running jail cells.
+	Cells() []JailCellStatus
+
+	// InspectCell returns global variables, pending timers and the last error of a jail cell.
+	InspectCell(chatID string) (*JailCellInspection, error)
+
+	// EvaluateInCell evaluates a JS expression in a jail cell, if debugging is enabled.
+	EvaluateInCell(chatID, expr string) (string, error)
+
+	// SnapshotCell returns a snapshot of a jail cell, which can be restored with RestoreCell, e.g. after a restart.
+	SnapshotCell(chatID string) (*JailCellSnapshot, error)
+
+	// RestoreCell creates a jail cell from a snapshot, replaying its initialization.
+	RestoreCell(snapshot *JailCellSnapshot) string
+
+	// SetDebug enables or disables EvaluateInCell.
+	SetDebug(enabled bool)
+
 	// SetBaseJS allows to setup initial JavaScript to be loaded on each jail.CreateAndInitCell().
 	SetBaseJS(js string)
 
+	// ConfigureFetch sets limits of HTTP requests made with fetch from jail cells.
+	ConfigureFetch(config params.JailFetchConfig)
+
+	// SetFetchWhitelist sets hosts a jail cell can fetch from, in addition to hosts all cells can fetch from.
+	SetFetchWhitelist(chatID string, hosts []string)
+
+	// ConfigureLimits sets limits of a single execution of JS code in a jail cell.
+	ConfigureLimits(config params.JailLimitsConfig)
+
+	// RegisterModule adds a CommonJS module jail cells can require, if its code matches the hash.
+	RegisterModule(name, code, hash string) error
+
+	// UnregisterModule removes a module jail cells can require.
+	UnregisterModule(name string)
+
+	// SetConsoleCapture enables or disables sending messages written with console of jail cells as signals.
+	SetConsoleCapture(enabled bool)
+
+	// SetStorageDir sets a directory where localStorage of jail cells is kept.
+	SetStorageDir(dir string)
+
+	// PurgeStorage removes all items from localStorage of a chat.
+	PurgeStorage(chatID string) error
+
 	// Stop stops all background activity of jail
 	Stop()
 }
@@ -369,6 +804,50 @@ func (e APIError) Error() string {
 	return fmt.Sprintf("message=%s", e.Message)
 }
 
+// PeersResponse represents connected peers, or an error if they can't be retrieved
+type PeersResponse struct {
+	Peers []*p2p.PeerInfo `json:"peers"`
+	Error string          `json:"error"`
+}
+
+// TopicSearchResponse represents nodes found under a discovery topic, or an error if the search failed
+type TopicSearchResponse struct {
+	Enodes []string `json:"enodes"`
+	Error  string   `json:"error"`
+}
+
+// NodeStatus is a snapshot of node's health, zero valued except Running if the node is not running
+type NodeStatus struct {
+	Running           bool        `json:"running"`
+	NetworkID         uint64      `json:"networkId"`
+	SyncMode          string      `json:"syncMode"`
+	Peers             int         `json:"peers"`
+	ListenPort        int         `json:"listenPort"` // port of the p2p listener, 0 if it's not listening
+	Syncing           bool        `json:"syncing"`
+	StartingBlock     uint64      `json:"startingBlock"`
+	CurrentBlock      uint64      `json:"currentBlock"`
+	HighestBlock      uint64      `json:"highestBlock"`
+	LatestBlockNumber uint64      `json:"latestBlockNumber"`
+	LatestBlockHash   common.Hash `json:"latestBlockHash"`
+	Uptime            uint64      `json:"uptime"` // seconds since the node was started
+}
+
+// ServiceCounters are named counters of a service, e.g. a number of its peers
+type ServiceCounters map[string]int64
+
+// NodeMetrics is a snapshot of resource usage of the process and its running node.
+// P2P traffic is counted for outbound connections, i.e. of peers dialed by the node, only.
+type NodeMetrics struct {
+	Goroutines         int                        `json:"goroutines"`
+	HeapAlloc          uint64                     `json:"heapAlloc"`          // bytes of allocated heap objects
+	HeapSys            uint64                     `json:"heapSys"`            // bytes of heap memory obtained from the OS
+	OpenFiles          int                        `json:"openFiles"`          // -1 if it can't be determined on the platform
+	LevelDBSize        int64                      `json:"levelDbSize"`        // bytes of node's databases on disk
+	P2POutboundIngress uint64                     `json:"p2pOutboundIngress"` // bytes received from dialed peers
+	P2POutboundEgress  uint64                     `json:"p2pOutboundEgress"`  // bytes sent to dialed peers
+	Services           map[string]ServiceCounters `json:"services"`           // counters by protocol name
+}
+
 // AccountInfo represents account's info
 type AccountInfo struct {
 	Address  string `json:"address"`
@@ -377,6 +856,115 @@ type AccountInfo struct {
 	Error    string `json:"error"`
 }
 
+// DerivedAccountsResponse represents addresses of accounts derived from a mnemonic, or an error if derivation failed
+type DerivedAccountsResponse struct {
+	Addresses []string `json:"addresses"`
+	Error     string   `json:"error"`
+}
+
+// ExportedAccountResponse represents V3 keystore JSON of an exported account, or an error if export failed
+type ExportedAccountResponse struct {
+	Keystore string `json:"keystore"`
+	Error    string `json:"error"`
+}
+
+// SignTransactionResult is a JSON returned from transaction sign function (used in exposed method)
+type SignTransactionResult struct {
+	Raw   string `json:"raw"`
+	Hash  string `json:"hash"`
+	Error string `json:"error"`
+}
+
+// BroadcastTransactionResult is a JSON returned from raw transaction broadcast function (used in exposed method)
+type BroadcastTransactionResult struct {
+	Hash  string `json:"hash"`
+	Error string `json:"error"`
+}
+
+// TokenTransferResult is a JSON returned from token transfer function (used in exposed method)
+type TokenTransferResult struct {
+	Hash  string `json:"hash"`
+	Error string `json:"error"`
+}
+
+// JailEvaluationResult is a JSON returned from evaluation of a JS expression in a jail cell (used in exposed method)
+type JailEvaluationResult struct {
+	Result string `json:"result"`
+	Error  string `json:"error"`
+}
+
+// ReplaceTransactionResult is a JSON returned from transaction speed-up and cancel functions
+type ReplaceTransactionResult struct {
+	OldHash string `json:"old_hash"`
+	Hash    string `json:"hash"`
+	Error   string `json:"error"`
+}
+
+// ExportedExtendedPublicKeyResponse represents an exported extended public key, or an error if export failed
+type ExportedExtendedPublicKeyResponse struct {
+	XPub  string `json:"xpub"`
+	Error string `json:"error"`
+}
+
+// AccountSession is an unlocked account, its public key is the ID of its Whisper identity
+type AccountSession struct {
+	Address     string   `json:"address"`
+	PubKey      string   `json:"pubkey"`
+	SubAccounts []string `json:"subAccounts"`
+	Selected    bool     `json:"selected"`
+}
+
+// WhisperIdentity is a Whisper identity of an unlocked account, installed in the running node
+type WhisperIdentity struct {
+	ID       string `json:"id"` // public key of the account, used as the identity ID in Whisper calls
+	Address  string `json:"address"`
+	Selected bool   `json:"selected"`
+}
+
+// WhisperIdentitiesResponse represents Whisper identities of unlocked accounts, or an error if they can't be listed
+type WhisperIdentitiesResponse struct {
+	Identities []WhisperIdentity `json:"identities"`
+	Error      string            `json:"error"`
+}
+
+// AccountSessionsResponse represents accounts unlocked with Login or OpenSession
+type AccountSessionsResponse struct {
+	Sessions []AccountSession `json:"sessions"`
+}
+
+// WatchOnlyAccount is an account without a private key, which can't sign transactions
+type WatchOnlyAccount struct {
+	Address     string `json:"address"`
+	ExtendedKey string `json:"xpub,omitempty"` // extended public key the account was added with, if any
+}
+
+// WatchOnlyAccountsResponse represents watch-only accounts, or an error if they can't be read
+type WatchOnlyAccountsResponse struct {
+	Accounts []WatchOnlyAccount `json:"accounts"`
+	Error    string             `json:"error"`
+}
+
+// AccountMetadata is information about an account kept by the client, e.g. to show it in a list of accounts
+type AccountMetadata struct {
+	Name      string          `json:"name"`
+	PhotoPath string          `json:"photoPath"`
+	CreatedAt int64           `json:"createdAt"` // unix time, in seconds
+	Flags     map[string]bool `json:"flags,omitempty"`
+}
+
+// AccountMetadataResponse represents metadata of an account, or an error if it can't be read
+type AccountMetadataResponse struct {
+	Metadata *AccountMetadata `json:"metadata"`
+	Error    string           `json:"error"`
+}
+
+// MnemonicOptions are parameters of BIP39 mnemonic an account is created or recovered with
+type MnemonicOptions struct {
+	WordCount  int              `json:"wordCount"`  // 12, 15, 18, 21 or 24, 12 if not set
+	Language   extkeys.Language `json:"language"`   // word list language, English if not set
+	Passphrase string           `json:"passphrase"` // optional BIP39 passphrase ("25th word")
+}
+
 // StopRPCCallError defines a error type specific for killing a execution process.
 type StopRPCCallError struct {
 	Err error
@@ -394,6 +982,13 @@ type CompleteTransactionResult struct {
 	Error string `json:"error"`
 }
 
+// CompleteMessageResult is a JSON returned from message complete function (used in exposed method)
+type CompleteMessageResult struct {
+	ID        string `json:"id"`
+	Signature string `json:"signature"`
+	Error     string `json:"error"`
+}
+
 // CompleteTransactionsResult is list of results from CompleteTransactions() (used in exposed method)
 type CompleteTransactionsResult struct {
 	Results map[string]CompleteTransactionResult `json:"results"`
@@ -410,6 +1005,11 @@ type DiscardTransactionsResult struct {
 	Results map[string]DiscardTransactionResult `json:"results"`
 }
 
+// PendingTransactionsResponse is a JSON returned from PendingTransactions()
+type PendingTransactionsResponse struct {
+	Transactions []PendingTransaction `json:"transactions"`
+}
+
 type account struct {
 	Address  string
 	Password string
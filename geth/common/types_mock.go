@@ -9,14 +9,22 @@ import (
 	accounts "github.com/ethereum/go-ethereum/accounts"
 	keystore "github.com/ethereum/go-ethereum/accounts/keystore"
 	common "github.com/ethereum/go-ethereum/common"
+	hexutil "github.com/ethereum/go-ethereum/common/hexutil"
+	types "github.com/ethereum/go-ethereum/core/types"
+	eth "github.com/ethereum/go-ethereum/eth"
+	event "github.com/ethereum/go-ethereum/event"
 	les "github.com/ethereum/go-ethereum/les"
 	node "github.com/ethereum/go-ethereum/node"
+	p2p "github.com/ethereum/go-ethereum/p2p"
 	whisperv5 "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	gomock "github.com/golang/mock/gomock"
 	otto "github.com/robertkrimen/otto"
 	params "github.com/status-im/status-go/geth/params"
 	rpc "github.com/status-im/status-go/geth/rpc"
+	signal "github.com/status-im/status-go/geth/signal"
+	big "math/big"
 	reflect "reflect"
+	time "time"
 )
 
 // MockNodeManager is a mock of NodeManager interface
@@ -69,16 +77,16 @@ func (mr *MockNodeManagerMockRecorder) StopNode() *gomock.Call {
 }
 
 // RestartNode mocks base method
-func (m *MockNodeManager) RestartNode() (<-chan struct{}, error) {
-	ret := m.ctrl.Call(m, "RestartNode")
+func (m *MockNodeManager) RestartNode(newConfig *params.NodeConfig) (<-chan struct{}, error) {
+	ret := m.ctrl.Call(m, "RestartNode", newConfig)
 	ret0, _ := ret[0].(<-chan struct{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // RestartNode indicates an expected call of RestartNode
-func (mr *MockNodeManagerMockRecorder) RestartNode() *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartNode", reflect.TypeOf((*MockNodeManager)(nil).RestartNode))
+func (mr *MockNodeManagerMockRecorder) RestartNode(newConfig interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartNode", reflect.TypeOf((*MockNodeManager)(nil).RestartNode), newConfig)
 }
 
 // ResetChainData mocks base method
@@ -156,6 +164,208 @@ func (mr *MockNodeManagerMockRecorder) AddPeer(url interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPeer", reflect.TypeOf((*MockNodeManager)(nil).AddPeer), url)
 }
 
+// RemovePeer mocks base method
+func (m *MockNodeManager) RemovePeer(url string) error {
+	ret := m.ctrl.Call(m, "RemovePeer", url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemovePeer indicates an expected call of RemovePeer
+func (mr *MockNodeManagerMockRecorder) RemovePeer(url interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemovePeer", reflect.TypeOf((*MockNodeManager)(nil).RemovePeer), url)
+}
+
+// AddTrustedPeer mocks base method
+func (m *MockNodeManager) AddTrustedPeer(url string) error {
+	ret := m.ctrl.Call(m, "AddTrustedPeer", url)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddTrustedPeer indicates an expected call of AddTrustedPeer
+func (mr *MockNodeManagerMockRecorder) AddTrustedPeer(url interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTrustedPeer", reflect.TypeOf((*MockNodeManager)(nil).AddTrustedPeer), url)
+}
+
+// Peers mocks base method
+func (m *MockNodeManager) Peers() ([]*p2p.PeerInfo, error) {
+	ret := m.ctrl.Call(m, "Peers")
+	ret0, _ := ret[0].([]*p2p.PeerInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Peers indicates an expected call of Peers
+func (mr *MockNodeManagerMockRecorder) Peers() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Peers", reflect.TypeOf((*MockNodeManager)(nil).Peers))
+}
+
+// NodeStatus mocks base method
+func (m *MockNodeManager) NodeStatus() NodeStatus {
+	ret := m.ctrl.Call(m, "NodeStatus")
+	ret0, _ := ret[0].(NodeStatus)
+	return ret0
+}
+
+// NodeStatus indicates an expected call of NodeStatus
+func (mr *MockNodeManagerMockRecorder) NodeStatus() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NodeStatus", reflect.TypeOf((*MockNodeManager)(nil).NodeStatus))
+}
+
+// Subscribe mocks base method
+func (m *MockNodeManager) Subscribe(events chan NodeEvent) event.Subscription {
+	ret := m.ctrl.Call(m, "Subscribe", events)
+	ret0, _ := ret[0].(event.Subscription)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe
+func (mr *MockNodeManagerMockRecorder) Subscribe(events interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockNodeManager)(nil).Subscribe), events)
+}
+
+// SetBootNodes mocks base method
+func (m *MockNodeManager) SetBootNodes(enodes []string) error {
+	ret := m.ctrl.Call(m, "SetBootNodes", enodes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetBootNodes indicates an expected call of SetBootNodes
+func (mr *MockNodeManagerMockRecorder) SetBootNodes(enodes interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBootNodes", reflect.TypeOf((*MockNodeManager)(nil).SetBootNodes), enodes)
+}
+
+// BlacklistPeer mocks base method
+func (m *MockNodeManager) BlacklistPeer(peer string) error {
+	ret := m.ctrl.Call(m, "BlacklistPeer", peer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BlacklistPeer indicates an expected call of BlacklistPeer
+func (mr *MockNodeManagerMockRecorder) BlacklistPeer(peer interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlacklistPeer", reflect.TypeOf((*MockNodeManager)(nil).BlacklistPeer), peer)
+}
+
+// UnblacklistPeer mocks base method
+func (m *MockNodeManager) UnblacklistPeer(peer string) error {
+	ret := m.ctrl.Call(m, "UnblacklistPeer", peer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnblacklistPeer indicates an expected call of UnblacklistPeer
+func (mr *MockNodeManagerMockRecorder) UnblacklistPeer(peer interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnblacklistPeer", reflect.TypeOf((*MockNodeManager)(nil).UnblacklistPeer), peer)
+}
+
+// SetPeerWhitelist mocks base method
+func (m *MockNodeManager) SetPeerWhitelist(peers []string) error {
+	ret := m.ctrl.Call(m, "SetPeerWhitelist", peers)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPeerWhitelist indicates an expected call of SetPeerWhitelist
+func (mr *MockNodeManagerMockRecorder) SetPeerWhitelist(peers interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPeerWhitelist", reflect.TypeOf((*MockNodeManager)(nil).SetPeerWhitelist), peers)
+}
+
+// SetNetworkCondition mocks base method
+func (m *MockNodeManager) SetNetworkCondition(condition NetworkCondition) error {
+	ret := m.ctrl.Call(m, "SetNetworkCondition", condition)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNetworkCondition indicates an expected call of SetNetworkCondition
+func (mr *MockNodeManagerMockRecorder) SetNetworkCondition(condition interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNetworkCondition", reflect.TypeOf((*MockNodeManager)(nil).SetNetworkCondition), condition)
+}
+
+// Suspend mocks base method
+func (m *MockNodeManager) Suspend() {
+	m.ctrl.Call(m, "Suspend")
+}
+
+// Suspend indicates an expected call of Suspend
+func (mr *MockNodeManagerMockRecorder) Suspend() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suspend", reflect.TypeOf((*MockNodeManager)(nil).Suspend))
+}
+
+// Resume mocks base method
+func (m *MockNodeManager) Resume() {
+	m.ctrl.Call(m, "Resume")
+}
+
+// Resume indicates an expected call of Resume
+func (mr *MockNodeManagerMockRecorder) Resume() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resume", reflect.TypeOf((*MockNodeManager)(nil).Resume))
+}
+
+// RegisterTopic mocks base method
+func (m *MockNodeManager) RegisterTopic(topic string) error {
+	ret := m.ctrl.Call(m, "RegisterTopic", topic)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterTopic indicates an expected call of RegisterTopic
+func (mr *MockNodeManagerMockRecorder) RegisterTopic(topic interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterTopic", reflect.TypeOf((*MockNodeManager)(nil).RegisterTopic), topic)
+}
+
+// UnregisterTopic mocks base method
+func (m *MockNodeManager) UnregisterTopic(topic string) error {
+	ret := m.ctrl.Call(m, "UnregisterTopic", topic)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnregisterTopic indicates an expected call of UnregisterTopic
+func (mr *MockNodeManagerMockRecorder) UnregisterTopic(topic interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterTopic", reflect.TypeOf((*MockNodeManager)(nil).UnregisterTopic), topic)
+}
+
+// SearchTopic mocks base method
+func (m *MockNodeManager) SearchTopic(topic string, limit int, timeout time.Duration) ([]string, error) {
+	ret := m.ctrl.Call(m, "SearchTopic", topic, limit, timeout)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchTopic indicates an expected call of SearchTopic
+func (mr *MockNodeManagerMockRecorder) SearchTopic(topic, limit, timeout interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchTopic", reflect.TypeOf((*MockNodeManager)(nil).SearchTopic), topic, limit, timeout)
+}
+
+// SubscribeChainHeads mocks base method
+func (m *MockNodeManager) SubscribeChainHeads(heads chan ChainHead) event.Subscription {
+	ret := m.ctrl.Call(m, "SubscribeChainHeads", heads)
+	ret0, _ := ret[0].(event.Subscription)
+	return ret0
+}
+
+// SubscribeChainHeads indicates an expected call of SubscribeChainHeads
+func (mr *MockNodeManagerMockRecorder) SubscribeChainHeads(heads interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubscribeChainHeads", reflect.TypeOf((*MockNodeManager)(nil).SubscribeChainHeads), heads)
+}
+
+// Metrics mocks base method
+func (m *MockNodeManager) Metrics() NodeMetrics {
+	ret := m.ctrl.Call(m, "Metrics")
+	ret0, _ := ret[0].(NodeMetrics)
+	return ret0
+}
+
+// Metrics indicates an expected call of Metrics
+func (mr *MockNodeManagerMockRecorder) Metrics() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Metrics", reflect.TypeOf((*MockNodeManager)(nil).Metrics))
+}
+
 // LightEthereumService mocks base method
 func (m *MockNodeManager) LightEthereumService() (*les.LightEthereum, error) {
 	ret := m.ctrl.Call(m, "LightEthereumService")
@@ -169,6 +379,19 @@ func (mr *MockNodeManagerMockRecorder) LightEthereumService() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LightEthereumService", reflect.TypeOf((*MockNodeManager)(nil).LightEthereumService))
 }
 
+// EthereumService mocks base method
+func (m *MockNodeManager) EthereumService() (*eth.Ethereum, error) {
+	ret := m.ctrl.Call(m, "EthereumService")
+	ret0, _ := ret[0].(*eth.Ethereum)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EthereumService indicates an expected call of EthereumService
+func (mr *MockNodeManagerMockRecorder) EthereumService() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EthereumService", reflect.TypeOf((*MockNodeManager)(nil).EthereumService))
+}
+
 // WhisperService mocks base method
 func (m *MockNodeManager) WhisperService() (*whisperv5.Whisper, error) {
 	ret := m.ctrl.Call(m, "WhisperService")
@@ -220,6 +443,16 @@ func (mr *MockNodeManagerMockRecorder) RPCClient() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RPCClient", reflect.TypeOf((*MockNodeManager)(nil).RPCClient))
 }
 
+// SendSignal mocks base method
+func (m *MockNodeManager) SendSignal(envelope signal.Envelope) {
+	m.ctrl.Call(m, "SendSignal", envelope)
+}
+
+// SendSignal indicates an expected call of SendSignal
+func (mr *MockNodeManagerMockRecorder) SendSignal(envelope interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendSignal", reflect.TypeOf((*MockNodeManager)(nil).SendSignal), envelope)
+}
+
 // MockAccountManager is a mock of AccountManager interface
 type MockAccountManager struct {
 	ctrl     *gomock.Controller
@@ -286,6 +519,223 @@ func (mr *MockAccountManagerMockRecorder) RecoverAccount(password, mnemonic inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverAccount", reflect.TypeOf((*MockAccountManager)(nil).RecoverAccount), password, mnemonic)
 }
 
+// CreateAccountWithOptions mocks base method
+func (m *MockAccountManager) CreateAccountWithOptions(password string, options MnemonicOptions) (string, string, string, error) {
+	ret := m.ctrl.Call(m, "CreateAccountWithOptions", password, options)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(string)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// CreateAccountWithOptions indicates an expected call of CreateAccountWithOptions
+func (mr *MockAccountManagerMockRecorder) CreateAccountWithOptions(password, options interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccountWithOptions", reflect.TypeOf((*MockAccountManager)(nil).CreateAccountWithOptions), password, options)
+}
+
+// RecoverAccountWithOptions mocks base method
+func (m *MockAccountManager) RecoverAccountWithOptions(password, mnemonic string, options MnemonicOptions) (string, string, error) {
+	ret := m.ctrl.Call(m, "RecoverAccountWithOptions", password, mnemonic, options)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// RecoverAccountWithOptions indicates an expected call of RecoverAccountWithOptions
+func (mr *MockAccountManagerMockRecorder) RecoverAccountWithOptions(password, mnemonic, options interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecoverAccountWithOptions", reflect.TypeOf((*MockAccountManager)(nil).RecoverAccountWithOptions), password, mnemonic, options)
+}
+
+// DeriveAccounts mocks base method
+func (m *MockAccountManager) DeriveAccounts(password, mnemonic, passphrase, path string, start, count int) ([]string, error) {
+	ret := m.ctrl.Call(m, "DeriveAccounts", password, mnemonic, passphrase, path, start, count)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeriveAccounts indicates an expected call of DeriveAccounts
+func (mr *MockAccountManagerMockRecorder) DeriveAccounts(password, mnemonic, passphrase, path, start, count interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeriveAccounts", reflect.TypeOf((*MockAccountManager)(nil).DeriveAccounts), password, mnemonic, passphrase, path, start, count)
+}
+
+// ImportPrivateKey mocks base method
+func (m *MockAccountManager) ImportPrivateKey(hexKey, password string) (string, string, error) {
+	ret := m.ctrl.Call(m, "ImportPrivateKey", hexKey, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ImportPrivateKey indicates an expected call of ImportPrivateKey
+func (mr *MockAccountManagerMockRecorder) ImportPrivateKey(hexKey, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportPrivateKey", reflect.TypeOf((*MockAccountManager)(nil).ImportPrivateKey), hexKey, password)
+}
+
+// ExportAccount mocks base method
+func (m *MockAccountManager) ExportAccount(address, password string) (string, error) {
+	ret := m.ctrl.Call(m, "ExportAccount", address, password)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportAccount indicates an expected call of ExportAccount
+func (mr *MockAccountManagerMockRecorder) ExportAccount(address, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportAccount", reflect.TypeOf((*MockAccountManager)(nil).ExportAccount), address, password)
+}
+
+// ExportExtendedPublicKey mocks base method
+func (m *MockAccountManager) ExportExtendedPublicKey(address, password, path string) (string, error) {
+	ret := m.ctrl.Call(m, "ExportExtendedPublicKey", address, password, path)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportExtendedPublicKey indicates an expected call of ExportExtendedPublicKey
+func (mr *MockAccountManagerMockRecorder) ExportExtendedPublicKey(address, password, path interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportExtendedPublicKey", reflect.TypeOf((*MockAccountManager)(nil).ExportExtendedPublicKey), address, password, path)
+}
+
+// AddWatchOnlyAccount mocks base method
+func (m *MockAccountManager) AddWatchOnlyAccount(addressOrXPub string) (string, error) {
+	ret := m.ctrl.Call(m, "AddWatchOnlyAccount", addressOrXPub)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWatchOnlyAccount indicates an expected call of AddWatchOnlyAccount
+func (mr *MockAccountManagerMockRecorder) AddWatchOnlyAccount(addressOrXPub interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWatchOnlyAccount", reflect.TypeOf((*MockAccountManager)(nil).AddWatchOnlyAccount), addressOrXPub)
+}
+
+// RemoveWatchOnlyAccount mocks base method
+func (m *MockAccountManager) RemoveWatchOnlyAccount(address string) error {
+	ret := m.ctrl.Call(m, "RemoveWatchOnlyAccount", address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveWatchOnlyAccount indicates an expected call of RemoveWatchOnlyAccount
+func (mr *MockAccountManagerMockRecorder) RemoveWatchOnlyAccount(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveWatchOnlyAccount", reflect.TypeOf((*MockAccountManager)(nil).RemoveWatchOnlyAccount), address)
+}
+
+// WatchOnlyAccounts mocks base method
+func (m *MockAccountManager) WatchOnlyAccounts() ([]WatchOnlyAccount, error) {
+	ret := m.ctrl.Call(m, "WatchOnlyAccounts")
+	ret0, _ := ret[0].([]WatchOnlyAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchOnlyAccounts indicates an expected call of WatchOnlyAccounts
+func (mr *MockAccountManagerMockRecorder) WatchOnlyAccounts() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchOnlyAccounts", reflect.TypeOf((*MockAccountManager)(nil).WatchOnlyAccounts))
+}
+
+// IsWatchOnlyAccount mocks base method
+func (m *MockAccountManager) IsWatchOnlyAccount(address common.Address) bool {
+	ret := m.ctrl.Call(m, "IsWatchOnlyAccount", address)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsWatchOnlyAccount indicates an expected call of IsWatchOnlyAccount
+func (mr *MockAccountManagerMockRecorder) IsWatchOnlyAccount(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsWatchOnlyAccount", reflect.TypeOf((*MockAccountManager)(nil).IsWatchOnlyAccount), address)
+}
+
+// SetAccountMetadata mocks base method
+func (m *MockAccountManager) SetAccountMetadata(address, password string, metadata AccountMetadata) error {
+	ret := m.ctrl.Call(m, "SetAccountMetadata", address, password, metadata)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAccountMetadata indicates an expected call of SetAccountMetadata
+func (mr *MockAccountManagerMockRecorder) SetAccountMetadata(address, password, metadata interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAccountMetadata", reflect.TypeOf((*MockAccountManager)(nil).SetAccountMetadata), address, password, metadata)
+}
+
+// AccountMetadata mocks base method
+func (m *MockAccountManager) AccountMetadata(address, password string) (*AccountMetadata, error) {
+	ret := m.ctrl.Call(m, "AccountMetadata", address, password)
+	ret0, _ := ret[0].(*AccountMetadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountMetadata indicates an expected call of AccountMetadata
+func (mr *MockAccountManagerMockRecorder) AccountMetadata(address, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountMetadata", reflect.TypeOf((*MockAccountManager)(nil).AccountMetadata), address, password)
+}
+
+// DeleteAccountMetadata mocks base method
+func (m *MockAccountManager) DeleteAccountMetadata(address, password string) error {
+	ret := m.ctrl.Call(m, "DeleteAccountMetadata", address, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccountMetadata indicates an expected call of DeleteAccountMetadata
+func (mr *MockAccountManagerMockRecorder) DeleteAccountMetadata(address, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccountMetadata", reflect.TypeOf((*MockAccountManager)(nil).DeleteAccountMetadata), address, password)
+}
+
+// ChangeAccountPassword mocks base method
+func (m *MockAccountManager) ChangeAccountPassword(address, oldPassword, newPassword string) error {
+	ret := m.ctrl.Call(m, "ChangeAccountPassword", address, oldPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangeAccountPassword indicates an expected call of ChangeAccountPassword
+func (mr *MockAccountManagerMockRecorder) ChangeAccountPassword(address, oldPassword, newPassword interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeAccountPassword", reflect.TypeOf((*MockAccountManager)(nil).ChangeAccountPassword), address, oldPassword, newPassword)
+}
+
+// DeleteAccount mocks base method
+func (m *MockAccountManager) DeleteAccount(address, password string) error {
+	ret := m.ctrl.Call(m, "DeleteAccount", address, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount
+func (mr *MockAccountManagerMockRecorder) DeleteAccount(address, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockAccountManager)(nil).DeleteAccount), address, password)
+}
+
+// BackupKeyStore mocks base method
+func (m *MockAccountManager) BackupKeyStore(archivePath, password string) error {
+	ret := m.ctrl.Call(m, "BackupKeyStore", archivePath, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// BackupKeyStore indicates an expected call of BackupKeyStore
+func (mr *MockAccountManagerMockRecorder) BackupKeyStore(archivePath, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackupKeyStore", reflect.TypeOf((*MockAccountManager)(nil).BackupKeyStore), archivePath, password)
+}
+
+// RestoreKeyStore mocks base method
+func (m *MockAccountManager) RestoreKeyStore(archivePath, password, dataDir string) error {
+	ret := m.ctrl.Call(m, "RestoreKeyStore", archivePath, password, dataDir)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreKeyStore indicates an expected call of RestoreKeyStore
+func (mr *MockAccountManagerMockRecorder) RestoreKeyStore(archivePath, password, dataDir interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreKeyStore", reflect.TypeOf((*MockAccountManager)(nil).RestoreKeyStore), archivePath, password, dataDir)
+}
+
 // VerifyAccountPassword mocks base method
 func (m *MockAccountManager) VerifyAccountPassword(keyStoreDir, address, password string) (*keystore.Key, error) {
 	ret := m.ctrl.Call(m, "VerifyAccountPassword", keyStoreDir, address, password)
@@ -348,6 +798,68 @@ func (mr *MockAccountManagerMockRecorder) Logout() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockAccountManager)(nil).Logout))
 }
 
+// OpenSession mocks base method
+func (m *MockAccountManager) OpenSession(address, password string) error {
+	ret := m.ctrl.Call(m, "OpenSession", address, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OpenSession indicates an expected call of OpenSession
+func (mr *MockAccountManagerMockRecorder) OpenSession(address, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenSession", reflect.TypeOf((*MockAccountManager)(nil).OpenSession), address, password)
+}
+
+// CloseSession mocks base method
+func (m *MockAccountManager) CloseSession(address string) error {
+	ret := m.ctrl.Call(m, "CloseSession", address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseSession indicates an expected call of CloseSession
+func (mr *MockAccountManagerMockRecorder) CloseSession(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseSession", reflect.TypeOf((*MockAccountManager)(nil).CloseSession), address)
+}
+
+// SessionAccount mocks base method
+func (m *MockAccountManager) SessionAccount(address common.Address) (*SelectedExtKey, error) {
+	ret := m.ctrl.Call(m, "SessionAccount", address)
+	ret0, _ := ret[0].(*SelectedExtKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SessionAccount indicates an expected call of SessionAccount
+func (mr *MockAccountManagerMockRecorder) SessionAccount(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SessionAccount", reflect.TypeOf((*MockAccountManager)(nil).SessionAccount), address)
+}
+
+// Sessions mocks base method
+func (m *MockAccountManager) Sessions() []AccountSession {
+	ret := m.ctrl.Call(m, "Sessions")
+	ret0, _ := ret[0].([]AccountSession)
+	return ret0
+}
+
+// Sessions indicates an expected call of Sessions
+func (mr *MockAccountManagerMockRecorder) Sessions() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sessions", reflect.TypeOf((*MockAccountManager)(nil).Sessions))
+}
+
+// Identities mocks base method
+func (m *MockAccountManager) Identities() ([]WhisperIdentity, error) {
+	ret := m.ctrl.Call(m, "Identities")
+	ret0, _ := ret[0].([]WhisperIdentity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Identities indicates an expected call of Identities
+func (mr *MockAccountManagerMockRecorder) Identities() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Identities", reflect.TypeOf((*MockAccountManager)(nil).Identities))
+}
+
 // Accounts mocks base method
 func (m *MockAccountManager) Accounts() ([]common.Address, error) {
 	ret := m.ctrl.Call(m, "Accounts")
@@ -497,6 +1009,28 @@ func (mr *MockTxQueueManagerMockRecorder) Stop() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stop", reflect.TypeOf((*MockTxQueueManager)(nil).Stop))
 }
 
+// Drain mocks base method
+func (m *MockTxQueueManager) Drain(ctx context.Context) error {
+	ret := m.ctrl.Call(m, "Drain", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Drain indicates an expected call of Drain
+func (mr *MockTxQueueManagerMockRecorder) Drain(ctx interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Drain", reflect.TypeOf((*MockTxQueueManager)(nil).Drain), ctx)
+}
+
+// Configure mocks base method
+func (m *MockTxQueueManager) Configure(config params.TxQueueConfig) {
+	m.ctrl.Call(m, "Configure", config)
+}
+
+// Configure indicates an expected call of Configure
+func (mr *MockTxQueueManagerMockRecorder) Configure(config interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Configure", reflect.TypeOf((*MockTxQueueManager)(nil).Configure), config)
+}
+
 // TransactionQueue mocks base method
 func (m *MockTxQueueManager) TransactionQueue() TxQueue {
 	ret := m.ctrl.Call(m, "TransactionQueue")
@@ -666,6 +1200,376 @@ func (mr *MockTxQueueManagerMockRecorder) DiscardTransactions(ids interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardTransactions", reflect.TypeOf((*MockTxQueueManager)(nil).DiscardTransactions), ids)
 }
 
+// DiscardAllTransactions mocks base method
+func (m *MockTxQueueManager) DiscardAllTransactions() map[QueuedTxID]RawDiscardTransactionResult {
+	ret := m.ctrl.Call(m, "DiscardAllTransactions")
+	ret0, _ := ret[0].(map[QueuedTxID]RawDiscardTransactionResult)
+	return ret0
+}
+
+// DiscardAllTransactions indicates an expected call of DiscardAllTransactions
+func (mr *MockTxQueueManagerMockRecorder) DiscardAllTransactions() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardAllTransactions", reflect.TypeOf((*MockTxQueueManager)(nil).DiscardAllTransactions))
+}
+
+// PendingTransactions mocks base method
+func (m *MockTxQueueManager) PendingTransactions() []PendingTransaction {
+	ret := m.ctrl.Call(m, "PendingTransactions")
+	ret0, _ := ret[0].([]PendingTransaction)
+	return ret0
+}
+
+// PendingTransactions indicates an expected call of PendingTransactions
+func (mr *MockTxQueueManagerMockRecorder) PendingTransactions() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingTransactions", reflect.TypeOf((*MockTxQueueManager)(nil).PendingTransactions))
+}
+
+// SetTxSigner mocks base method
+func (m *MockTxQueueManager) SetTxSigner(signer TxSigner) {
+	m.ctrl.Call(m, "SetTxSigner", signer)
+}
+
+// SetTxSigner indicates an expected call of SetTxSigner
+func (mr *MockTxQueueManagerMockRecorder) SetTxSigner(signer interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTxSigner", reflect.TypeOf((*MockTxQueueManager)(nil).SetTxSigner), signer)
+}
+
+// ResetNonce mocks base method
+func (m *MockTxQueueManager) ResetNonce(address common.Address) {
+	m.ctrl.Call(m, "ResetNonce", address)
+}
+
+// ResetNonce indicates an expected call of ResetNonce
+func (mr *MockTxQueueManagerMockRecorder) ResetNonce(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetNonce", reflect.TypeOf((*MockTxQueueManager)(nil).ResetNonce), address)
+}
+
+// SpeedUpTransaction mocks base method
+func (m *MockTxQueueManager) SpeedUpTransaction(ctx context.Context, hash common.Hash, gasPrice *big.Int) (common.Hash, error) {
+	ret := m.ctrl.Call(m, "SpeedUpTransaction", ctx, hash, gasPrice)
+	ret0, _ := ret[0].(common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SpeedUpTransaction indicates an expected call of SpeedUpTransaction
+func (mr *MockTxQueueManagerMockRecorder) SpeedUpTransaction(ctx, hash, gasPrice interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpeedUpTransaction", reflect.TypeOf((*MockTxQueueManager)(nil).SpeedUpTransaction), ctx, hash, gasPrice)
+}
+
+// CancelTransaction mocks base method
+func (m *MockTxQueueManager) CancelTransaction(ctx context.Context, hash common.Hash) (common.Hash, error) {
+	ret := m.ctrl.Call(m, "CancelTransaction", ctx, hash)
+	ret0, _ := ret[0].(common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelTransaction indicates an expected call of CancelTransaction
+func (mr *MockTxQueueManagerMockRecorder) CancelTransaction(ctx, hash interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelTransaction", reflect.TypeOf((*MockTxQueueManager)(nil).CancelTransaction), ctx, hash)
+}
+
+// RestoreQueue mocks base method
+func (m *MockTxQueueManager) RestoreQueue(path string) error {
+	ret := m.ctrl.Call(m, "RestoreQueue", path)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RestoreQueue indicates an expected call of RestoreQueue
+func (mr *MockTxQueueManagerMockRecorder) RestoreQueue(path interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreQueue", reflect.TypeOf((*MockTxQueueManager)(nil).RestoreQueue), path)
+}
+
+// SignTransaction mocks base method
+func (m *MockTxQueueManager) SignTransaction(args SendTxArgs, password string) (hexutil.Bytes, common.Hash, error) {
+	ret := m.ctrl.Call(m, "SignTransaction", args, password)
+	ret0, _ := ret[0].(hexutil.Bytes)
+	ret1, _ := ret[1].(common.Hash)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SignTransaction indicates an expected call of SignTransaction
+func (mr *MockTxQueueManagerMockRecorder) SignTransaction(args, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignTransaction", reflect.TypeOf((*MockTxQueueManager)(nil).SignTransaction), args, password)
+}
+
+// BroadcastRawTransaction mocks base method
+func (m *MockTxQueueManager) BroadcastRawTransaction(ctx context.Context, raw hexutil.Bytes) (common.Hash, error) {
+	ret := m.ctrl.Call(m, "BroadcastRawTransaction", ctx, raw)
+	ret0, _ := ret[0].(common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BroadcastRawTransaction indicates an expected call of BroadcastRawTransaction
+func (mr *MockTxQueueManagerMockRecorder) BroadcastRawTransaction(ctx, raw interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BroadcastRawTransaction", reflect.TypeOf((*MockTxQueueManager)(nil).BroadcastRawTransaction), ctx, raw)
+}
+
+// QueueTokenTransfer mocks base method
+func (m *MockTxQueueManager) QueueTokenTransfer(ctx context.Context, token, to common.Address, amount *big.Int) (common.Hash, error) {
+	ret := m.ctrl.Call(m, "QueueTokenTransfer", ctx, token, to, amount)
+	ret0, _ := ret[0].(common.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueueTokenTransfer indicates an expected call of QueueTokenTransfer
+func (mr *MockTxQueueManagerMockRecorder) QueueTokenTransfer(ctx, token, to, amount interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueueTokenTransfer", reflect.TypeOf((*MockTxQueueManager)(nil).QueueTokenTransfer), ctx, token, to, amount)
+}
+
+// MockTxSigner is a mock of TxSigner interface
+type MockTxSigner struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxSignerMockRecorder
+}
+
+// MockTxSignerMockRecorder is the mock recorder for MockTxSigner
+type MockTxSignerMockRecorder struct {
+	mock *MockTxSigner
+}
+
+// NewMockTxSigner creates a new mock instance
+func NewMockTxSigner(ctrl *gomock.Controller) *MockTxSigner {
+	mock := &MockTxSigner{ctrl: ctrl}
+	mock.recorder = &MockTxSignerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTxSigner) EXPECT() *MockTxSignerMockRecorder {
+	return m.recorder
+}
+
+// HasAccount mocks base method
+func (m *MockTxSigner) HasAccount(address common.Address) bool {
+	ret := m.ctrl.Call(m, "HasAccount", address)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// HasAccount indicates an expected call of HasAccount
+func (mr *MockTxSignerMockRecorder) HasAccount(address interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasAccount", reflect.TypeOf((*MockTxSigner)(nil).HasAccount), address)
+}
+
+// SignTx mocks base method
+func (m *MockTxSigner) SignTx(address common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	ret := m.ctrl.Call(m, "SignTx", address, tx, chainID)
+	ret0, _ := ret[0].(*types.Transaction)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignTx indicates an expected call of SignTx
+func (mr *MockTxSignerMockRecorder) SignTx(address, tx, chainID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignTx", reflect.TypeOf((*MockTxSigner)(nil).SignTx), address, tx, chainID)
+}
+
+// MockCallDecoder is a mock of CallDecoder interface
+type MockCallDecoder struct {
+	ctrl     *gomock.Controller
+	recorder *MockCallDecoderMockRecorder
+}
+
+// MockCallDecoderMockRecorder is the mock recorder for MockCallDecoder
+type MockCallDecoderMockRecorder struct {
+	mock *MockCallDecoder
+}
+
+// NewMockCallDecoder creates a new mock instance
+func NewMockCallDecoder(ctrl *gomock.Controller) *MockCallDecoder {
+	mock := &MockCallDecoder{ctrl: ctrl}
+	mock.recorder = &MockCallDecoderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCallDecoder) EXPECT() *MockCallDecoderMockRecorder {
+	return m.recorder
+}
+
+// DecodeCall mocks base method
+func (m *MockCallDecoder) DecodeCall(to *common.Address, data []byte) *CallPreview {
+	ret := m.ctrl.Call(m, "DecodeCall", to, data)
+	ret0, _ := ret[0].(*CallPreview)
+	return ret0
+}
+
+// DecodeCall indicates an expected call of DecodeCall
+func (mr *MockCallDecoderMockRecorder) DecodeCall(to, data interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecodeCall", reflect.TypeOf((*MockCallDecoder)(nil).DecodeCall), to, data)
+}
+
+// MockGasPriceOracle is a mock of GasPriceOracle interface
+type MockGasPriceOracle struct {
+	ctrl     *gomock.Controller
+	recorder *MockGasPriceOracleMockRecorder
+}
+
+// MockGasPriceOracleMockRecorder is the mock recorder for MockGasPriceOracle
+type MockGasPriceOracleMockRecorder struct {
+	mock *MockGasPriceOracle
+}
+
+// NewMockGasPriceOracle creates a new mock instance
+func NewMockGasPriceOracle(ctrl *gomock.Controller) *MockGasPriceOracle {
+	mock := &MockGasPriceOracle{ctrl: ctrl}
+	mock.recorder = &MockGasPriceOracleMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockGasPriceOracle) EXPECT() *MockGasPriceOracleMockRecorder {
+	return m.recorder
+}
+
+// SuggestGasPrices mocks base method
+func (m *MockGasPriceOracle) SuggestGasPrices(ctx context.Context) GasPriceSuggestion {
+	ret := m.ctrl.Call(m, "SuggestGasPrices", ctx)
+	ret0, _ := ret[0].(GasPriceSuggestion)
+	return ret0
+}
+
+// SuggestGasPrices indicates an expected call of SuggestGasPrices
+func (mr *MockGasPriceOracleMockRecorder) SuggestGasPrices(ctx interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestGasPrices", reflect.TypeOf((*MockGasPriceOracle)(nil).SuggestGasPrices), ctx)
+}
+
+// MockTxWatcher is a mock of TxWatcher interface
+type MockTxWatcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockTxWatcherMockRecorder
+}
+
+// MockTxWatcherMockRecorder is the mock recorder for MockTxWatcher
+type MockTxWatcherMockRecorder struct {
+	mock *MockTxWatcher
+}
+
+// NewMockTxWatcher creates a new mock instance
+func NewMockTxWatcher(ctrl *gomock.Controller) *MockTxWatcher {
+	mock := &MockTxWatcher{ctrl: ctrl}
+	mock.recorder = &MockTxWatcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTxWatcher) EXPECT() *MockTxWatcherMockRecorder {
+	return m.recorder
+}
+
+// Watch mocks base method
+func (m *MockTxWatcher) Watch(hash common.Hash, from common.Address) {
+	m.ctrl.Call(m, "Watch", hash, from)
+}
+
+// Watch indicates an expected call of Watch
+func (mr *MockTxWatcherMockRecorder) Watch(hash, from interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockTxWatcher)(nil).Watch), hash, from)
+}
+
+// MockMessageQueueManager is a mock of MessageQueueManager interface
+type MockMessageQueueManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageQueueManagerMockRecorder
+}
+
+// MockMessageQueueManagerMockRecorder is the mock recorder for MockMessageQueueManager
+type MockMessageQueueManagerMockRecorder struct {
+	mock *MockMessageQueueManager
+}
+
+// NewMockMessageQueueManager creates a new mock instance
+func NewMockMessageQueueManager(ctrl *gomock.Controller) *MockMessageQueueManager {
+	mock := &MockMessageQueueManager{ctrl: ctrl}
+	mock.recorder = &MockMessageQueueManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockMessageQueueManager) EXPECT() *MockMessageQueueManagerMockRecorder {
+	return m.recorder
+}
+
+// SignMessage mocks base method
+func (m *MockMessageQueueManager) SignMessage(ctx context.Context, address common.Address, data []byte) (hexutil.Bytes, error) {
+	ret := m.ctrl.Call(m, "SignMessage", ctx, address, data)
+	ret0, _ := ret[0].(hexutil.Bytes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignMessage indicates an expected call of SignMessage
+func (mr *MockMessageQueueManagerMockRecorder) SignMessage(ctx, address, data interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignMessage", reflect.TypeOf((*MockMessageQueueManager)(nil).SignMessage), ctx, address, data)
+}
+
+// CompleteMessage mocks base method
+func (m *MockMessageQueueManager) CompleteMessage(id QueuedMessageID, password string) (hexutil.Bytes, error) {
+	ret := m.ctrl.Call(m, "CompleteMessage", id, password)
+	ret0, _ := ret[0].(hexutil.Bytes)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteMessage indicates an expected call of CompleteMessage
+func (mr *MockMessageQueueManagerMockRecorder) CompleteMessage(id, password interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteMessage", reflect.TypeOf((*MockMessageQueueManager)(nil).CompleteMessage), id, password)
+}
+
+// DiscardMessage mocks base method
+func (m *MockMessageQueueManager) DiscardMessage(id QueuedMessageID) error {
+	ret := m.ctrl.Call(m, "DiscardMessage", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DiscardMessage indicates an expected call of DiscardMessage
+func (mr *MockMessageQueueManagerMockRecorder) DiscardMessage(id interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DiscardMessage", reflect.TypeOf((*MockMessageQueueManager)(nil).DiscardMessage), id)
+}
+
+// PersonalSignRPCHandler mocks base method
+func (m *MockMessageQueueManager) PersonalSignRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	varargs := []interface{}{ctx}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PersonalSignRPCHandler", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PersonalSignRPCHandler indicates an expected call of PersonalSignRPCHandler
+func (mr *MockMessageQueueManagerMockRecorder) PersonalSignRPCHandler(ctx interface{}, args ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{ctx}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PersonalSignRPCHandler", reflect.TypeOf((*MockMessageQueueManager)(nil).PersonalSignRPCHandler), varargs...)
+}
+
+// SignTypedDataRPCHandler mocks base method
+func (m *MockMessageQueueManager) SignTypedDataRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	varargs := []interface{}{ctx}
+	for _, a := range args {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SignTypedDataRPCHandler", varargs...)
+	ret0, _ := ret[0].(interface{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignTypedDataRPCHandler indicates an expected call of SignTypedDataRPCHandler
+func (mr *MockMessageQueueManagerMockRecorder) SignTypedDataRPCHandler(ctx interface{}, args ...interface{}) *gomock.Call {
+	varargs := append([]interface{}{ctx}, args...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignTypedDataRPCHandler", reflect.TypeOf((*MockMessageQueueManager)(nil).SignTypedDataRPCHandler), varargs...)
+}
+
 // MockJailCell is a mock of JailCell interface
 type MockJailCell struct {
 	ctrl     *gomock.Controller
@@ -828,6 +1732,115 @@ func (mr *MockJailManagerMockRecorder) Cell(chatID interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cell", reflect.TypeOf((*MockJailManager)(nil).Cell), chatID)
 }
 
+// StopCell mocks base method
+func (m *MockJailManager) StopCell(chatID string) error {
+	ret := m.ctrl.Call(m, "StopCell", chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StopCell indicates an expected call of StopCell
+func (mr *MockJailManagerMockRecorder) StopCell(chatID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StopCell", reflect.TypeOf((*MockJailManager)(nil).StopCell), chatID)
+}
+
+// RestartCell mocks base method
+func (m *MockJailManager) RestartCell(chatID string) string {
+	ret := m.ctrl.Call(m, "RestartCell", chatID)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RestartCell indicates an expected call of RestartCell
+func (mr *MockJailManagerMockRecorder) RestartCell(chatID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestartCell", reflect.TypeOf((*MockJailManager)(nil).RestartCell), chatID)
+}
+
+// Cells mocks base method
+func (m *MockJailManager) Cells() []JailCellStatus {
+	ret := m.ctrl.Call(m, "Cells")
+	ret0, _ := ret[0].([]JailCellStatus)
+	return ret0
+}
+
+// Cells indicates an expected call of Cells
+func (mr *MockJailManagerMockRecorder) Cells() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cells", reflect.TypeOf((*MockJailManager)(nil).Cells))
+}
+
+// InspectCell mocks base method
+func (m *MockJailManager) InspectCell(chatID string) (*JailCellInspection, error) {
+	ret := m.ctrl.Call(m, "InspectCell", chatID)
+	ret0, _ := ret[0].(*JailCellInspection)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InspectCell indicates an expected call of InspectCell
+func (mr *MockJailManagerMockRecorder) InspectCell(chatID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InspectCell", reflect.TypeOf((*MockJailManager)(nil).InspectCell), chatID)
+}
+
+// EvaluateInCell mocks base method
+func (m *MockJailManager) EvaluateInCell(chatID, expr string) (string, error) {
+	ret := m.ctrl.Call(m, "EvaluateInCell", chatID, expr)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EvaluateInCell indicates an expected call of EvaluateInCell
+func (mr *MockJailManagerMockRecorder) EvaluateInCell(chatID, expr interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EvaluateInCell", reflect.TypeOf((*MockJailManager)(nil).EvaluateInCell), chatID, expr)
+}
+
+// InterruptCell mocks base method
+func (m *MockJailManager) InterruptCell(chatID string) error {
+	ret := m.ctrl.Call(m, "InterruptCell", chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// InterruptCell indicates an expected call of InterruptCell
+func (mr *MockJailManagerMockRecorder) InterruptCell(chatID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InterruptCell", reflect.TypeOf((*MockJailManager)(nil).InterruptCell), chatID)
+}
+
+// SnapshotCell mocks base method
+func (m *MockJailManager) SnapshotCell(chatID string) (*JailCellSnapshot, error) {
+	ret := m.ctrl.Call(m, "SnapshotCell", chatID)
+	ret0, _ := ret[0].(*JailCellSnapshot)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SnapshotCell indicates an expected call of SnapshotCell
+func (mr *MockJailManagerMockRecorder) SnapshotCell(chatID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotCell", reflect.TypeOf((*MockJailManager)(nil).SnapshotCell), chatID)
+}
+
+// RestoreCell mocks base method
+func (m *MockJailManager) RestoreCell(snapshot *JailCellSnapshot) string {
+	ret := m.ctrl.Call(m, "RestoreCell", snapshot)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// RestoreCell indicates an expected call of RestoreCell
+func (mr *MockJailManagerMockRecorder) RestoreCell(snapshot interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreCell", reflect.TypeOf((*MockJailManager)(nil).RestoreCell), snapshot)
+}
+
+// SetDebug mocks base method
+func (m *MockJailManager) SetDebug(enabled bool) {
+	m.ctrl.Call(m, "SetDebug", enabled)
+}
+
+// SetDebug indicates an expected call of SetDebug
+func (mr *MockJailManagerMockRecorder) SetDebug(enabled interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDebug", reflect.TypeOf((*MockJailManager)(nil).SetDebug), enabled)
+}
+
 // BaseJS mocks base method
 func (m *MockJailManager) BaseJS(js string) {
 	m.ctrl.Call(m, "BaseJS", js)
@@ -838,6 +1851,90 @@ func (mr *MockJailManagerMockRecorder) BaseJS(js interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BaseJS", reflect.TypeOf((*MockJailManager)(nil).BaseJS), js)
 }
 
+// ConfigureFetch mocks base method
+func (m *MockJailManager) ConfigureFetch(config params.JailFetchConfig) {
+	m.ctrl.Call(m, "ConfigureFetch", config)
+}
+
+// ConfigureFetch indicates an expected call of ConfigureFetch
+func (mr *MockJailManagerMockRecorder) ConfigureFetch(config interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureFetch", reflect.TypeOf((*MockJailManager)(nil).ConfigureFetch), config)
+}
+
+// ConfigureLimits mocks base method
+func (m *MockJailManager) ConfigureLimits(config params.JailLimitsConfig) {
+	m.ctrl.Call(m, "ConfigureLimits", config)
+}
+
+// ConfigureLimits indicates an expected call of ConfigureLimits
+func (mr *MockJailManagerMockRecorder) ConfigureLimits(config interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigureLimits", reflect.TypeOf((*MockJailManager)(nil).ConfigureLimits), config)
+}
+
+// RegisterModule mocks base method
+func (m *MockJailManager) RegisterModule(name, code, hash string) error {
+	ret := m.ctrl.Call(m, "RegisterModule", name, code, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterModule indicates an expected call of RegisterModule
+func (mr *MockJailManagerMockRecorder) RegisterModule(name, code, hash interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterModule", reflect.TypeOf((*MockJailManager)(nil).RegisterModule), name, code, hash)
+}
+
+// UnregisterModule mocks base method
+func (m *MockJailManager) UnregisterModule(name string) {
+	m.ctrl.Call(m, "UnregisterModule", name)
+}
+
+// UnregisterModule indicates an expected call of UnregisterModule
+func (mr *MockJailManagerMockRecorder) UnregisterModule(name interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterModule", reflect.TypeOf((*MockJailManager)(nil).UnregisterModule), name)
+}
+
+// SetConsoleCapture mocks base method
+func (m *MockJailManager) SetConsoleCapture(enabled bool) {
+	m.ctrl.Call(m, "SetConsoleCapture", enabled)
+}
+
+// SetConsoleCapture indicates an expected call of SetConsoleCapture
+func (mr *MockJailManagerMockRecorder) SetConsoleCapture(enabled interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetConsoleCapture", reflect.TypeOf((*MockJailManager)(nil).SetConsoleCapture), enabled)
+}
+
+// SetFetchWhitelist mocks base method
+func (m *MockJailManager) SetFetchWhitelist(chatID string, hosts []string) {
+	m.ctrl.Call(m, "SetFetchWhitelist", chatID, hosts)
+}
+
+// SetFetchWhitelist indicates an expected call of SetFetchWhitelist
+func (mr *MockJailManagerMockRecorder) SetFetchWhitelist(chatID, hosts interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFetchWhitelist", reflect.TypeOf((*MockJailManager)(nil).SetFetchWhitelist), chatID, hosts)
+}
+
+// SetStorageDir mocks base method
+func (m *MockJailManager) SetStorageDir(dir string) {
+	m.ctrl.Call(m, "SetStorageDir", dir)
+}
+
+// SetStorageDir indicates an expected call of SetStorageDir
+func (mr *MockJailManagerMockRecorder) SetStorageDir(dir interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStorageDir", reflect.TypeOf((*MockJailManager)(nil).SetStorageDir), dir)
+}
+
+// PurgeStorage mocks base method
+func (m *MockJailManager) PurgeStorage(chatID string) error {
+	ret := m.ctrl.Call(m, "PurgeStorage", chatID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PurgeStorage indicates an expected call of PurgeStorage
+func (mr *MockJailManagerMockRecorder) PurgeStorage(chatID interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeStorage", reflect.TypeOf((*MockJailManager)(nil).PurgeStorage), chatID)
+}
+
 // Stop mocks base method
 func (m *MockJailManager) Stop() {
 	m.ctrl.Call(m, "Stop")
@@ -24,6 +24,10 @@ const (
 	// MessageIDKey is a key for message ID
 	// This ID is required to track from which chat a given send transaction request is coming.
 	MessageIDKey = contextKey("message_id")
+
+	// ChatIDKey is a key for ID of a chat, which is ID of its jail cell as well.
+	// It tracks which DApp a request sent through web3 provider of a jail cell comes from.
+	ChatIDKey = contextKey("chat_id")
 )
 
 type contextKey string // in order to make sure that our context key does not collide with keys from other packages
@@ -116,6 +120,18 @@ func MessageIDFromContext(ctx context.Context) string {
 	return ""
 }
 
+// ChatIDFromContext returns ID of a chat a request comes from (if exists)
+func ChatIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if chatID, ok := ctx.Value(ChatIDKey).(string); ok {
+		return chatID
+	}
+
+	return ""
+}
+
 // ParseJSONArray parses JSON array into Go array of string
 func ParseJSONArray(items string) ([]string, error) {
 	var parsedItems []string
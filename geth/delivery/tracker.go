@@ -0,0 +1,273 @@
+// Package delivery tracks Whisper envelopes sent by the node from the moment they're posted until they
+// expire, counting peers they're written to, so that clients can tell whether messages propagate.
+package delivery
+
+import (
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventEnvelopeState is triggered when a state of an envelope sent by the node changes
+const EventEnvelopeState = "envelope.state"
+
+// checkInterval is how often expired envelopes are forgotten and envelopes relayed to more peers are signalled
+const checkInterval = time.Second
+
+// State is a state of an envelope sent by the node.
+type State string
+
+// States of envelopes sent by the node.
+const (
+	StatePosted  State = "posted"  // added to the node's pool, not relayed to any peer yet
+	StateRelayed State = "relayed" // relayed to at least one peer
+	StateExpired State = "expired" // removed from the pool when its TTL passed
+)
+
+// EnvelopeStateEvent is a signal sent when a state of an envelope sent by the node changes,
+// or when it's relayed to more peers.
+type EnvelopeStateEvent struct {
+	Hash  string `json:"hash"`
+	State State  `json:"state"`
+	Peers int    `json:"peers"` // how many peers the envelope was relayed to
+}
+
+// trackedEnvelope is an envelope sent by the node which hasn't expired yet
+type trackedEnvelope struct {
+	expiry    time.Time
+	state     State
+	peers     map[discover.NodeID]struct{} // peers the envelope was written to
+	signalled int                          // how many peers the envelope was relayed to when it was last signalled
+}
+
+// Tracker records states of envelopes sent by the node, and counts how many were posted, relayed and
+// expired. It's a Whisper delivery server, which passes states of messages on to the delivery server
+// it wraps, and a node service forgetting expired envelopes. Peers envelopes are written to are reported
+// with Relayed, e.g. by shhlimit.Whisper.
+type Tracker struct {
+	delivery whisper.DeliveryServer // wrapped delivery server, if any
+	signals  bool                   // whether EventEnvelopeState is signalled
+
+	mu        sync.Mutex
+	envelopes map[gethcommon.Hash]*trackedEnvelope
+	counters  common.ServiceCounters
+	quit      chan struct{}
+	stopping  sync.WaitGroup
+
+	sender signal.Sender // sends EventEnvelopeState, see SetSignalHandler
+}
+
+// New returns a tracker wrapping a delivery server, which may be nil. If signals are enabled,
+// changes of states of envelopes are signalled with EventEnvelopeState.
+func New(delivery whisper.DeliveryServer, signals bool) *Tracker {
+	return &Tracker{
+		delivery:  delivery,
+		signals:   signals,
+		envelopes: make(map[gethcommon.Hash]*trackedEnvelope),
+		counters:  make(common.ServiceCounters),
+	}
+}
+
+// SetSignalHandler sets a handler of signals sent by the tracker, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (t *Tracker) SetSignalHandler(handler func(signal.Envelope)) {
+	t.sender.SetHandler(handler)
+}
+
+// Protocols implements node.Service, the tracker has no protocols.
+func (t *Tracker) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, the tracker has no APIs.
+func (t *Tracker) APIs() []rpc.API {
+	return nil
+}
+
+// Start implements node.Service, checking tracked envelopes every checkInterval.
+func (t *Tracker) Start(*p2p.Server) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.quit = make(chan struct{})
+	t.stopping.Add(1)
+	go t.checkLoop(t.quit)
+
+	return nil
+}
+
+// Stop implements node.Service. Envelopes which haven't expired yet are forgotten.
+func (t *Tracker) Stop() error {
+	t.mu.Lock()
+	if t.quit == nil {
+		t.mu.Unlock()
+		return nil
+	}
+	close(t.quit)
+	t.quit = nil
+	t.mu.Unlock()
+
+	t.stopping.Wait()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.envelopes = make(map[gethcommon.Hash]*trackedEnvelope)
+
+	return nil
+}
+
+// SendState implements whisper.DeliveryServer, tracking envelopes sent by the node once they're posted.
+func (t *Tracker) SendState(state whisper.MessageState) {
+	if state.Direction == gethmessage.OutgoingMessage && state.Status == gethmessage.SentStatus {
+		t.track(&state.Envelope)
+	}
+
+	if t.delivery != nil {
+		t.delivery.SendState(state)
+	}
+}
+
+// Counters returns how many envelopes were posted, relayed to at least one peer and expired,
+// and how many are tracked now.
+func (t *Tracker) Counters() common.ServiceCounters {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counters := make(common.ServiceCounters, len(t.counters)+1)
+	for name, value := range t.counters {
+		counters[name] = value
+	}
+	counters["tracked"] = int64(len(t.envelopes))
+
+	return counters
+}
+
+// State returns a state of an envelope sent by the node and how many peers it was relayed to.
+// Envelopes which aren't tracked, e.g. expired ones, are reported as not found.
+func (t *Tracker) State(hash gethcommon.Hash) (state State, peers int, found bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	envelope, ok := t.envelopes[hash]
+	if !ok {
+		return "", 0, false
+	}
+
+	return envelope.state, len(envelope.peers), true
+}
+
+// Unrelayed returns how many envelopes sent by the node haven't been relayed to any peer yet.
+func (t *Tracker) Unrelayed() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	unrelayed := 0
+	for _, envelope := range t.envelopes {
+		if envelope.state == StatePosted {
+			unrelayed++
+		}
+	}
+
+	return unrelayed
+}
+
+// Relayed records that an envelope was written to a peer. An envelope sent by the node becomes relayed once
+// it's written to a peer, and is signalled as relayed to more peers once every checkInterval.
+func (t *Tracker) Relayed(peer discover.NodeID, hash gethcommon.Hash) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	envelope, ok := t.envelopes[hash]
+	if !ok {
+		return
+	}
+	envelope.peers[peer] = struct{}{}
+	if envelope.state == StatePosted {
+		envelope.state = StateRelayed
+		t.counters[string(StateRelayed)]++
+	}
+}
+
+// track starts tracking a posted envelope. An envelope posted again is tracked once.
+func (t *Tracker) track(envelope *whisper.Envelope) {
+	hash := envelope.Hash()
+
+	t.mu.Lock()
+	if _, ok := t.envelopes[hash]; ok {
+		t.mu.Unlock()
+		return
+	}
+	t.envelopes[hash] = &trackedEnvelope{
+		expiry: time.Unix(int64(envelope.Expiry), 0),
+		state:  StatePosted,
+		peers:  make(map[discover.NodeID]struct{}),
+	}
+	t.counters[string(StatePosted)]++
+	t.mu.Unlock()
+
+	t.signal(EnvelopeStateEvent{Hash: hash.Hex(), State: StatePosted})
+}
+
+// checkLoop checks tracked envelopes, until quit is closed.
+func (t *Tracker) checkLoop(quit <-chan struct{}) {
+	defer t.stopping.Done()
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			t.check(now)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// check stops tracking envelopes expired at a time, and signals envelopes relayed to more peers
+// since they were last signalled.
+func (t *Tracker) check(now time.Time) {
+	var events []EnvelopeStateEvent
+
+	t.mu.Lock()
+	for hash, envelope := range t.envelopes {
+		if !now.Before(envelope.expiry) {
+			delete(t.envelopes, hash)
+			t.counters[string(StateExpired)]++
+			events = append(events, EnvelopeStateEvent{Hash: hash.Hex(), State: StateExpired, Peers: len(envelope.peers)})
+			continue
+		}
+
+		if len(envelope.peers) == envelope.signalled {
+			continue
+		}
+		envelope.signalled = len(envelope.peers)
+		events = append(events, EnvelopeStateEvent{Hash: hash.Hex(), State: StateRelayed, Peers: len(envelope.peers)})
+	}
+	t.mu.Unlock()
+
+	for _, event := range events {
+		t.signal(event)
+	}
+}
+
+// signal sends EventEnvelopeState, if signals are enabled.
+func (t *Tracker) signal(event EnvelopeStateEvent) {
+	if !t.signals {
+		return
+	}
+
+	t.sender.Send(signal.Envelope{
+		Type:  EventEnvelopeState,
+		Event: event,
+	})
+}
@@ -0,0 +1,130 @@
+package delivery
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDelivery records states of messages passed to it
+type recordingDelivery struct {
+	states chan whisper.MessageState
+}
+
+func (d recordingDelivery) SendState(state whisper.MessageState) {
+	d.states <- state
+}
+
+func TestTrackEnvelope(t *testing.T) {
+	var events []EnvelopeStateEvent
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event EnvelopeStateEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventEnvelopeState {
+			events = append(events, envelope.Event)
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	delivery := recordingDelivery{states: make(chan whisper.MessageState, 2)}
+	tracker := New(delivery, true)
+
+	posted := time.Unix(1000, 0)
+	envelope := whisper.Envelope{Version: []byte{0}, Expiry: 1060, TTL: 60, Data: []byte{0x01}}
+	hash := envelope.Hash()
+
+	// only envelopes posted by the node are tracked, all states are passed on
+	tracker.SendState(whisper.MessageState{Direction: gethmessage.IncomingMessage, Status: gethmessage.SentStatus, Envelope: envelope})
+	<-delivery.states
+	_, _, found := tracker.State(hash)
+	require.False(t, found)
+
+	for i := 0; i < 2; i++ {
+		tracker.SendState(whisper.MessageState{
+			Direction: gethmessage.OutgoingMessage,
+			Status:    gethmessage.SentStatus,
+			Envelope:  envelope,
+			Timestamp: posted,
+		})
+	}
+	<-delivery.states
+
+	state, peers, found := tracker.State(hash)
+	require.True(t, found)
+	require.Equal(t, StatePosted, state)
+	require.Equal(t, 0, peers)
+	require.Equal(t, 1, tracker.Unrelayed())
+
+	// connected peers aren't relays, only peers the envelope is written to are, once each
+	peerA, peerB, peerC := discover.NodeID{0x0a}, discover.NodeID{0x0b}, discover.NodeID{0x0c}
+	tracker.check(posted.Add(checkInterval))
+	state, _, _ = tracker.State(hash)
+	require.Equal(t, StatePosted, state)
+
+	tracker.Relayed(peerA, hash)
+	tracker.Relayed(peerB, hash)
+	tracker.Relayed(peerA, hash)
+	tracker.Relayed(peerA, (&whisper.Envelope{Version: []byte{0}, Expiry: 1060}).Hash()) // not sent by the node
+	state, peers, _ = tracker.State(hash)
+	require.Equal(t, StateRelayed, state)
+	require.Equal(t, 2, peers)
+	require.Equal(t, 0, tracker.Unrelayed())
+
+	// envelopes relayed to more peers are signalled once every check
+	tracker.check(posted.Add(2 * checkInterval))
+	tracker.check(posted.Add(3 * checkInterval))
+	tracker.Relayed(peerC, hash)
+	tracker.check(posted.Add(4 * checkInterval))
+	_, peers, _ = tracker.State(hash)
+	require.Equal(t, 3, peers)
+
+	tracker.check(time.Unix(1060, 0))
+	_, _, found = tracker.State(hash)
+	require.False(t, found)
+
+	require.Equal(t, common.ServiceCounters{
+		"posted":  1,
+		"relayed": 1,
+		"expired": 1,
+		"tracked": 0,
+	}, tracker.Counters())
+
+	require.Equal(t, []EnvelopeStateEvent{
+		{Hash: hash.Hex(), State: StatePosted},
+		{Hash: hash.Hex(), State: StateRelayed, Peers: 2},
+		{Hash: hash.Hex(), State: StateRelayed, Peers: 3},
+		{Hash: hash.Hex(), State: StateExpired, Peers: 3},
+	}, events)
+}
+
+func TestTrackWithoutSignals(t *testing.T) {
+	signalled := false
+	signal.SetDefaultNodeNotificationHandler(func(string) {
+		signalled = true
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	tracker := New(nil, false)
+	envelope := whisper.Envelope{Version: []byte{0}, Expiry: 1060, TTL: 60}
+	tracker.SendState(whisper.MessageState{
+		Direction: gethmessage.OutgoingMessage,
+		Status:    gethmessage.SentStatus,
+		Envelope:  envelope,
+		Timestamp: time.Unix(1000, 0),
+	})
+	tracker.Relayed(discover.NodeID{0x0a}, envelope.Hash())
+	tracker.check(time.Unix(1001, 0))
+
+	require.False(t, signalled)
+	require.Equal(t, int64(1), tracker.Counters()["relayed"])
+}
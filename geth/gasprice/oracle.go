@@ -0,0 +1,192 @@
+// Package gasprice suggests gas price of transactions from prices paid by transactions of recent blocks,
+// which are requested over RPC, so from the upstream node if it's enabled.
+package gasprice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	statusparams "github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// ErrNoRPCClient is returned when the node has no RPC client, e.g. it's not running.
+var ErrNoRPCClient = errors.New("RPC client is not available")
+
+const (
+	// DefaultBlocks is how many recent blocks are analysed.
+	DefaultBlocks = 20
+
+	// percentiles of gas prices of recent transactions suggested as slow, normal and fast gas prices
+	slowPercentile   = 30
+	normalPercentile = 60
+	fastPercentile   = 90
+)
+
+// fallbackGasPrices are suggested when gas prices of recent blocks can't be fetched, by network ID
+var fallbackGasPrices = map[uint64]common.GasPriceSuggestion{
+	statusparams.MainNetworkID:    suggestion(2, 4, 20),
+	statusparams.RopstenNetworkID: suggestion(1, 2, 10),
+	statusparams.RinkebyNetworkID: suggestion(1, 2, 5),
+}
+
+// defaultFallbackGasPrices are suggested on networks without fallback gas prices
+var defaultFallbackGasPrices = suggestion(1, 2, 5)
+
+// rpcBlock is a block with gas prices of its transactions
+type rpcBlock struct {
+	Transactions []struct {
+		GasPrice *hexutil.Big `json:"gasPrice"`
+	} `json:"transactions"`
+}
+
+// Oracle suggests gas prices from percentiles of gas prices of transactions of recent blocks.
+// It implements common.GasPriceOracle.
+type Oracle struct {
+	nodeManager common.NodeManager
+	blocks      int
+
+	mu     sync.Mutex            // serialises suggestions and guards prices
+	prices map[uint64][]*big.Int // gas prices of transactions by number of analysed blocks
+}
+
+// NewOracle returns an oracle requesting blocks with the RPC client of a node.
+func NewOracle(nodeManager common.NodeManager) *Oracle {
+	return &Oracle{
+		nodeManager: nodeManager,
+		blocks:      DefaultBlocks,
+		prices:      make(map[uint64][]*big.Int),
+	}
+}
+
+// SuggestGasPrices returns slow, normal and fast gas prices from recent blocks. Gas prices of a block are
+// requested only once, so only new blocks are fetched on subsequent calls. Fallback gas prices of the network
+// are returned if blocks can't be fetched or they have no transactions.
+func (o *Oracle) SuggestGasPrices(ctx context.Context) common.GasPriceSuggestion {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	prices, err := o.recentPrices(ctx)
+	if err != nil {
+		log.Warn("failed to fetch gas prices of recent blocks", "err", err)
+	}
+	if len(prices) == 0 {
+		return o.fallback()
+	}
+
+	return common.GasPriceSuggestion{
+		Slow:   percentile(prices, slowPercentile),
+		Normal: percentile(prices, normalPercentile),
+		Fast:   percentile(prices, fastPercentile),
+	}
+}
+
+// recentPrices returns sorted gas prices of recent blocks, fetching blocks which were not analysed yet.
+// Prices of blocks fetched before an error are returned along with the error.
+func (o *Oracle) recentPrices(ctx context.Context) ([]*big.Int, error) {
+	client := o.nodeManager.RPCClient()
+	if client == nil {
+		return nil, ErrNoRPCClient
+	}
+
+	var head hexutil.Uint64
+	if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		return nil, err
+	}
+
+	first := uint64(0)
+	if uint64(head) >= uint64(o.blocks) {
+		first = uint64(head) - uint64(o.blocks) + 1
+	}
+	for number := range o.prices {
+		if number < first || number > uint64(head) {
+			delete(o.prices, number) // too old, or replaced by a reorg
+		}
+	}
+
+	var err error
+	for number := uint64(head); number >= first && err == nil; number-- {
+		if _, ok := o.prices[number]; !ok {
+			err = o.fetchBlockPrices(ctx, client, number)
+		}
+		if number == 0 {
+			break
+		}
+	}
+
+	var prices []*big.Int
+	for _, blockPrices := range o.prices {
+		prices = append(prices, blockPrices...)
+	}
+	sort.Sort(bigIntSlice(prices))
+
+	return prices, err
+}
+
+// fetchBlockPrices requests a block with its transactions and stores their gas prices.
+func (o *Oracle) fetchBlockPrices(ctx context.Context, client *rpc.Client, number uint64) error {
+	var raw json.RawMessage
+	if err := client.CallContext(ctx, &raw, "eth_getBlockByNumber", hexutil.Uint64(number), true); err != nil {
+		return err
+	}
+
+	var block *rpcBlock
+	if err := json.Unmarshal(raw, &block); err != nil {
+		return err
+	}
+	if block == nil {
+		return nil // not available yet, e.g. on a lagging upstream node
+	}
+
+	prices := make([]*big.Int, 0, len(block.Transactions))
+	for _, tx := range block.Transactions {
+		if tx.GasPrice != nil && tx.GasPrice.ToInt().Sign() > 0 {
+			prices = append(prices, tx.GasPrice.ToInt())
+		}
+	}
+	o.prices[number] = prices
+
+	return nil
+}
+
+// fallback returns fallback gas prices of the node's network.
+func (o *Oracle) fallback() common.GasPriceSuggestion {
+	config, err := o.nodeManager.NodeConfig()
+	if err != nil {
+		return defaultFallbackGasPrices
+	}
+	if prices, ok := fallbackGasPrices[config.NetworkID]; ok {
+		return prices
+	}
+
+	return defaultFallbackGasPrices
+}
+
+// percentile returns a percentile of sorted prices.
+func percentile(prices []*big.Int, p int) *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int).Set(prices[(len(prices)-1)*p/100]))
+}
+
+// suggestion returns gas prices given in gwei.
+func suggestion(slow, normal, fast int64) common.GasPriceSuggestion {
+	gwei := func(n int64) *hexutil.Big {
+		return (*hexutil.Big)(new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Shannon)))
+	}
+
+	return common.GasPriceSuggestion{Slow: gwei(slow), Normal: gwei(normal), Fast: gwei(fast)}
+}
+
+// bigIntSlice sorts big integers in increasing order.
+type bigIntSlice []*big.Int
+
+func (s bigIntSlice) Len() int           { return len(s) }
+func (s bigIntSlice) Less(i, j int) bool { return s[i].Cmp(s[j]) < 0 }
+func (s bigIntSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
@@ -0,0 +1,101 @@
+package gasprice
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/params"
+	statusparams "github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/testing/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChain serves blocks whose transactions pay gas prices given in gwei.
+type fakeChain struct {
+	blocks  map[uint64][]int64
+	fetched []uint64
+}
+
+func (c *fakeChain) register(nodeManager *mocks.NodeManager, head uint64) {
+	nodeManager.SetRPCResponse("eth_blockNumber", hexutil.Uint64(head), nil)
+	nodeManager.RPCClient().RegisterHandler("eth_getBlockByNumber", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		number := uint64(args[0].(hexutil.Uint64))
+		c.fetched = append(c.fetched, number)
+
+		prices, ok := c.blocks[number]
+		if !ok {
+			return nil, nil
+		}
+		txs := make([]map[string]interface{}, len(prices))
+		for i, price := range prices {
+			txs[i] = map[string]interface{}{"gasPrice": gwei(price)}
+		}
+		return map[string]interface{}{"transactions": txs}, nil
+	})
+}
+
+func gwei(n int64) *hexutil.Big {
+	return (*hexutil.Big)(new(big.Int).Mul(big.NewInt(n), big.NewInt(params.Shannon)))
+}
+
+func newTestOracle(t *testing.T, networkID uint64) (*Oracle, *mocks.NodeManager) {
+	nodeManager := mocks.NewNodeManager()
+	config, err := statusparams.NewNodeConfig(t.Name(), networkID, true)
+	require.NoError(t, err)
+	_, err = nodeManager.StartNode(config)
+	require.NoError(t, err)
+
+	return NewOracle(nodeManager), nodeManager
+}
+
+func TestSuggestGasPrices(t *testing.T) {
+	oracle, nodeManager := newTestOracle(t, statusparams.MainNetworkID)
+	oracle.blocks = 3
+
+	chain := &fakeChain{blocks: map[uint64][]int64{
+		8:  {100}, // too old to be analysed
+		9:  {1, 2, 3},
+		10: {4, 5, 0}, // zero gas price is ignored
+		11: {6, 7, 8, 9, 10},
+	}}
+	chain.register(nodeManager, 11)
+
+	suggestion := oracle.SuggestGasPrices(context.Background())
+	require.Equal(t, gwei(3), suggestion.Slow)
+	require.Equal(t, gwei(6), suggestion.Normal)
+	require.Equal(t, gwei(9), suggestion.Fast)
+	require.Equal(t, []uint64{11, 10, 9}, chain.fetched)
+
+	// only a new block is fetched, and the oldest one is not analysed anymore
+	chain.blocks[12] = []int64{20, 20}
+	chain.fetched = nil
+	chain.register(nodeManager, 12)
+
+	suggestion = oracle.SuggestGasPrices(context.Background())
+	require.Equal(t, []uint64{12}, chain.fetched)
+	require.Equal(t, gwei(6), suggestion.Slow)
+	require.Equal(t, gwei(8), suggestion.Normal)
+	require.Equal(t, gwei(20), suggestion.Fast)
+}
+
+func TestSuggestGasPricesFallback(t *testing.T) {
+	oracle, nodeManager := newTestOracle(t, statusparams.RopstenNetworkID)
+
+	// upstream is not available
+	nodeManager.SetRPCResponse("eth_blockNumber", nil, errors.New("connection refused"))
+	require.Equal(t, fallbackGasPrices[statusparams.RopstenNetworkID], oracle.SuggestGasPrices(context.Background()))
+
+	// recent blocks have no transactions
+	chain := &fakeChain{blocks: map[uint64][]int64{0: {}, 1: {}}}
+	chain.register(nodeManager, 1)
+	require.Equal(t, fallbackGasPrices[statusparams.RopstenNetworkID], oracle.SuggestGasPrices(context.Background()))
+	require.Equal(t, []uint64{1, 0}, chain.fetched)
+
+	// networks without their own fallback gas prices
+	oracle, nodeManager = newTestOracle(t, statusparams.StatusChainNetworkID)
+	nodeManager.SetRPCResponse("eth_blockNumber", nil, errors.New("connection refused"))
+	require.Equal(t, defaultFallbackGasPrices, oracle.SuggestGasPrices(context.Background()))
+}
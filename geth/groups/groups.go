@@ -0,0 +1,139 @@
+// Package groups implements membership of group chats on top of Whisper. Members of a group share a secret
+// its topic and symmetric key are derived from. Admins change membership with signed updates, which are sent
+// to each member over one-to-one chats. A new secret is shared when admins remove members, and admins rekey
+// a group after a member leaves, so that former members can't read messages of the group anymore.
+package groups
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+// UpdateType is a type of a change of membership of a group.
+type UpdateType string
+
+// Types of changes of membership of groups.
+const (
+	UpdateCreate UpdateType = "create" // a group is created with its first members
+	UpdateAdd    UpdateType = "add"    // members are added by an admin
+	UpdateRemove UpdateType = "remove" // members are removed by an admin
+	UpdateLeave  UpdateType = "leave"  // a member leaves
+	UpdateRekey  UpdateType = "rekey"  // a new secret is shared by an admin
+)
+
+// secretLength is the length of a secret of a group, in bytes
+const secretLength = 32
+
+var (
+	// ErrInvalidSignature is returned when an update isn't signed by the member it's sent from.
+	ErrInvalidSignature = errors.New("invalid signature of a membership update")
+
+	// ErrInvalidSecret is returned when a secret of an update doesn't match the hash it's signed with.
+	ErrInvalidSecret = errors.New("secret doesn't match the membership update")
+
+	// ErrInvalidUpdate is returned when an update doesn't follow rules of its type.
+	ErrInvalidUpdate = errors.New("invalid membership update")
+)
+
+var (
+	// topicInfo and keyInfo make a topic and a key of a group differ when derived from the same secret
+	topicInfo = []byte("status-group-topic")
+	keyInfo   = []byte("status-group-key")
+)
+
+// MembershipUpdate is a signed change of membership of a group, which carries the whole membership after it,
+// so that new members learn it. The secret of the group after the update isn't signed, only its hash, so that
+// an update can be sent without the secret to removed members.
+type MembershipUpdate struct {
+	GroupID    hexutil.Bytes   `json:"groupID"`
+	Type       UpdateType      `json:"type"`
+	Version    uint64          `json:"version"` // increased with every update of the group
+	Name       string          `json:"name"`
+	Admins     []hexutil.Bytes `json:"admins"`
+	Members    []hexutil.Bytes `json:"members"` // public keys of members after the update, including admins
+	Changed    []hexutil.Bytes `json:"changed"` // public keys of members added, removed or leaving
+	SecretHash hexutil.Bytes   `json:"secretHash"`
+	From       hexutil.Bytes   `json:"from"` // public key of the member who signed the update
+	Signature  hexutil.Bytes   `json:"signature"`
+	Secret     hexutil.Bytes   `json:"secret,omitempty"` // secret of the group after the update, for its members
+}
+
+// sign signs an update, carrying a secret of the group, with the key of a member.
+func (u *MembershipUpdate) sign(key *ecdsa.PrivateKey) error {
+	u.From = crypto.FromECDSAPub(&key.PublicKey)
+	u.SecretHash = crypto.Keccak256(u.Secret)
+
+	hash, err := u.hash()
+	if err != nil {
+		return err
+	}
+	u.Signature, err = crypto.Sign(hash, key)
+
+	return err
+}
+
+// Verify checks that an update is signed by the member it's sent from, and that its secret, if any,
+// matches the signed hash.
+func (u *MembershipUpdate) Verify() error {
+	hash, err := u.hash()
+	if err != nil {
+		return err
+	}
+	signer, err := crypto.SigToPub(hash, u.Signature)
+	if err != nil || !bytes.Equal(crypto.FromECDSAPub(signer), u.From) {
+		return ErrInvalidSignature
+	}
+
+	if len(u.Secret) > 0 && !bytes.Equal(crypto.Keccak256(u.Secret), u.SecretHash) {
+		return ErrInvalidSecret
+	}
+
+	return nil
+}
+
+// WithoutSecret returns a copy of an update without the secret, to be sent to removed members.
+func (u *MembershipUpdate) WithoutSecret() *MembershipUpdate {
+	c := *u
+	c.Secret = nil
+	return &c
+}
+
+// hash returns a hash of an update without its signature and secret.
+func (u *MembershipUpdate) hash() ([]byte, error) {
+	c := *u
+	c.Signature = nil
+	c.Secret = nil
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Keccak256(data), nil
+}
+
+// isMember reports whether a public key is in a list.
+func isMember(list []hexutil.Bytes, key []byte) bool {
+	for _, member := range list {
+		if bytes.Equal(member, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// DeriveTopic returns a topic of messages of a group with a secret.
+func DeriveTopic(secret []byte) whisper.TopicType {
+	return whisper.BytesToTopic(crypto.Keccak256(topicInfo, secret))
+}
+
+// DeriveKey returns a symmetric key messages of a group with a secret are encrypted with.
+func DeriveKey(secret []byte) []byte {
+	return crypto.Keccak256(keyInfo, secret)
+}
@@ -0,0 +1,613 @@
+package groups
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventGroupMembership is triggered when an identity receives a membership update of a group
+const EventGroupMembership = "group.membership"
+
+// groupIDLength is the length of a random ID of a group, in bytes
+const groupIDLength = 32
+
+var (
+	// ErrGroupNotFound is returned when a group the identity isn't a member of is changed.
+	ErrGroupNotFound = errors.New("group not found")
+
+	// ErrNotAdmin is returned when membership of a group is changed by a member who isn't its admin.
+	ErrNotAdmin = errors.New("only admins can change membership of a group")
+
+	// ErrStaleUpdate is returned when an update isn't newer than the last one applied to a group.
+	ErrStaleUpdate = errors.New("membership update is older than the group")
+
+	// ErrInvalidMemberKey is returned when a public key of a member is malformed.
+	ErrInvalidMemberKey = errors.New("invalid public key of a member")
+)
+
+// Group is a group chat an identity is a member of.
+type Group struct {
+	ID       hexutil.Bytes     `json:"id"`
+	Name     string            `json:"name"`
+	Version  uint64            `json:"version"` // version of the last membership update applied
+	Admins   []hexutil.Bytes   `json:"admins"`
+	Members  []hexutil.Bytes   `json:"members"`
+	Topic    whisper.TopicType `json:"topic"`    // topic messages of the group are sent on
+	SymKeyID string            `json:"symKeyID"` // ID of the symmetric key messages of the group are encrypted with
+	FilterID string            `json:"filterID"` // ID of the filter receiving messages of the group
+
+	secret []byte
+}
+
+// MembershipEvent is a signal sent when an identity receives a membership update of a group.
+type MembershipEvent struct {
+	Identity string          `json:"identity"` // ID of the key pair of the identity
+	GroupID  hexutil.Bytes   `json:"groupID"`
+	Name     string          `json:"name"`
+	Type     UpdateType      `json:"type"`
+	From     hexutil.Bytes   `json:"from"`    // public key of the member who changed membership
+	Changed  []hexutil.Bytes `json:"changed"` // public keys of members added, removed or leaving
+	Members  []hexutil.Bytes `json:"members"`
+	Removed  bool            `json:"removed"` // whether the identity isn't a member of the group anymore
+}
+
+// groupKey identifies a group of an identity
+type groupKey struct {
+	identity string
+	id       string
+}
+
+// Manager keeps groups of Whisper identities, registering filters of their topics, and creates and applies
+// membership updates. Updates created by identities are returned to be sent to members of groups, updates
+// received from members are signalled with EventGroupMembership. It's a node service adding group methods
+// to the shh namespace.
+type Manager struct {
+	shh *whisper.Whisper
+
+	mu     sync.Mutex
+	groups map[groupKey]*Group
+
+	signals signal.Sender // sends group signals, see SetSignalHandler
+}
+
+// New returns a manager of groups of identities known to a Whisper service.
+func New(shh *whisper.Whisper) *Manager {
+	return &Manager{
+		shh:    shh,
+		groups: make(map[groupKey]*Group),
+	}
+}
+
+// SetSignalHandler sets a handler of signals sent by the manager, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (m *Manager) SetSignalHandler(handler func(signal.Envelope)) {
+	m.signals.SetHandler(handler)
+}
+
+// Protocols implements node.Service, the manager has no protocols.
+func (m *Manager) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, adding group methods to the shh namespace.
+func (m *Manager) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "shh",
+			Version:   "1.0",
+			Service:   &PublicAPI{manager: m},
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service.
+func (m *Manager) Start(*p2p.Server) error {
+	return nil
+}
+
+// Stop implements node.Service, removing filters and symmetric keys of all groups.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, group := range m.groups {
+		m.removeGroup(key, group)
+	}
+
+	return nil
+}
+
+// PruneIdentities forgets groups of identities whose key pairs were removed from Whisper, e.g. once their
+// accounts are logged out, removing filters and symmetric keys of the groups.
+func (m *Manager) PruneIdentities() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, group := range m.groups {
+		if !m.shh.HasKeyPair(key.identity) {
+			m.removeGroup(key, group)
+		}
+	}
+}
+
+// Groups returns groups an identity is a member of.
+func (m *Manager) Groups(identity string) []Group {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	groups := []Group{}
+	for key, group := range m.groups {
+		if key.identity == identity {
+			groups = append(groups, *group)
+		}
+	}
+
+	return groups
+}
+
+// CreateGroup creates a group of an identity, its admin, with members, returning the update to be sent to them.
+func (m *Manager) CreateGroup(identity, name string, members [][]byte) (*MembershipUpdate, error) {
+	identityKey, err := m.shh.GetPrivateKey(identity)
+	if err != nil {
+		return nil, err
+	}
+	self := hexutil.Bytes(crypto.FromECDSAPub(&identityKey.PublicKey))
+
+	added, err := newMembers(members, []hexutil.Bytes{self})
+	if err != nil {
+		return nil, err
+	}
+	id, err := randomBytes(groupIDLength)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomBytes(secretLength)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &MembershipUpdate{
+		GroupID: id,
+		Type:    UpdateCreate,
+		Version: 1,
+		Name:    name,
+		Admins:  []hexutil.Bytes{self},
+		Members: append([]hexutil.Bytes{self}, added...),
+		Changed: added,
+		Secret:  secret,
+	}
+	if err := update.sign(identityKey); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.apply(identity, identityKey, update); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// AddMembers adds members to a group an identity is an admin of, returning the update to be sent to all members.
+func (m *Manager) AddMembers(identity string, groupID []byte, members [][]byte) (*MembershipUpdate, error) {
+	return m.change(identity, groupID, UpdateAdd, func(update *MembershipUpdate, self hexutil.Bytes) error {
+		added, err := newMembers(members, update.Members)
+		if err != nil {
+			return err
+		}
+		if len(added) == 0 {
+			return ErrInvalidUpdate
+		}
+
+		update.Members = append(update.Members, added...)
+		update.Changed = added
+
+		return nil
+	})
+}
+
+// RemoveMembers removes members from a group an identity is an admin of, sharing a new secret. The update is
+// returned to be sent to remaining members, and without the secret to removed ones.
+func (m *Manager) RemoveMembers(identity string, groupID []byte, members [][]byte) (*MembershipUpdate, error) {
+	return m.change(identity, groupID, UpdateRemove, func(update *MembershipUpdate, self hexutil.Bytes) error {
+		if len(members) == 0 {
+			return ErrInvalidUpdate
+		}
+		for _, member := range members {
+			if !isMember(update.Members, member) || bytes.Equal(member, self) {
+				return ErrInvalidUpdate
+			}
+			update.Changed = append(update.Changed, member)
+		}
+		update.Members = without(update.Members, update.Changed)
+		update.Admins = without(update.Admins, update.Changed)
+
+		return rekey(update)
+	})
+}
+
+// LeaveGroup removes an identity from a group, returning the update to be sent to remaining members.
+// Admins should rekey the group once they receive it.
+func (m *Manager) LeaveGroup(identity string, groupID []byte) (*MembershipUpdate, error) {
+	return m.change(identity, groupID, UpdateLeave, func(update *MembershipUpdate, self hexutil.Bytes) error {
+		update.Changed = []hexutil.Bytes{self}
+		update.Members = without(update.Members, update.Changed)
+		update.Admins = without(update.Admins, update.Changed)
+
+		return nil
+	})
+}
+
+// RekeyGroup shares a new secret of a group an identity is an admin of, e.g. after a member leaves,
+// returning the update to be sent to all members.
+func (m *Manager) RekeyGroup(identity string, groupID []byte) (*MembershipUpdate, error) {
+	return m.change(identity, groupID, UpdateRekey, func(update *MembershipUpdate, self hexutil.Bytes) error {
+		return rekey(update)
+	})
+}
+
+// ProcessUpdate applies a membership update received by an identity from a member of a group, and signals it
+// with EventGroupMembership. A group the identity learns of with an update is joined, a group the identity is
+// removed from is left.
+func (m *Manager) ProcessUpdate(identity string, update *MembershipUpdate) (*Group, error) {
+	identityKey, err := m.shh.GetPrivateKey(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	group, err := m.apply(identity, identityKey, update)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.signals.Send(signal.Envelope{
+		Type: EventGroupMembership,
+		Event: MembershipEvent{
+			Identity: identity,
+			GroupID:  update.GroupID,
+			Name:     update.Name,
+			Type:     update.Type,
+			From:     update.From,
+			Changed:  update.Changed,
+			Members:  update.Members,
+			Removed:  group.FilterID == "",
+		},
+	})
+
+	return group, nil
+}
+
+// change creates, signs and applies an update of a group of an identity, changed by a function.
+func (m *Manager) change(identity string, groupID []byte, updateType UpdateType,
+	fn func(update *MembershipUpdate, self hexutil.Bytes) error) (*MembershipUpdate, error) {
+	identityKey, err := m.shh.GetPrivateKey(identity)
+	if err != nil {
+		return nil, err
+	}
+	self := hexutil.Bytes(crypto.FromECDSAPub(&identityKey.PublicKey))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	group, ok := m.groups[groupKey{identity: identity, id: hexutil.Encode(groupID)}]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	if updateType != UpdateLeave && !isMember(group.Admins, self) {
+		return nil, ErrNotAdmin
+	}
+
+	update := &MembershipUpdate{
+		GroupID: group.ID,
+		Type:    updateType,
+		Version: group.Version + 1,
+		Name:    group.Name,
+		Admins:  append([]hexutil.Bytes(nil), group.Admins...),
+		Members: append([]hexutil.Bytes(nil), group.Members...),
+		Secret:  group.secret,
+	}
+	if err := fn(update, self); err != nil {
+		return nil, err
+	}
+	if err := update.sign(identityKey); err != nil {
+		return nil, err
+	}
+
+	if _, err := m.apply(identity, identityKey, update); err != nil {
+		return nil, err
+	}
+
+	return update, nil
+}
+
+// apply checks an update of a group of an identity and applies it, registering a filter of a new secret.
+// The returned group has no filter if the identity isn't its member anymore.
+func (m *Manager) apply(identity string, identityKey *ecdsa.PrivateKey, update *MembershipUpdate) (*Group, error) {
+	if err := update.Verify(); err != nil {
+		return nil, err
+	}
+
+	key := groupKey{identity: identity, id: hexutil.Encode(update.GroupID)}
+	current := m.groups[key]
+	if err := validate(current, update); err != nil {
+		return nil, err
+	}
+
+	group := &Group{
+		ID:      update.GroupID,
+		Name:    update.Name,
+		Version: update.Version,
+		Admins:  update.Admins,
+		Members: update.Members,
+	}
+
+	if !isMember(update.Members, crypto.FromECDSAPub(&identityKey.PublicKey)) {
+		if current == nil {
+			return nil, ErrGroupNotFound
+		}
+		m.removeGroup(key, current)
+		log.Info("left a group", "id", key.id)
+
+		return group, nil
+	}
+
+	group.secret = update.Secret
+	if len(group.secret) == 0 {
+		if current == nil || !bytes.Equal(crypto.Keccak256(current.secret), update.SecretHash) {
+			return nil, ErrInvalidSecret
+		}
+		group.secret = current.secret
+	}
+
+	if current != nil && bytes.Equal(current.secret, group.secret) {
+		group.Topic, group.SymKeyID, group.FilterID = current.Topic, current.SymKeyID, current.FilterID
+		m.groups[key] = group
+		return group, nil
+	}
+
+	if err := m.subscribe(group); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		m.removeGroup(key, current)
+	}
+	m.groups[key] = group
+
+	log.Info("joined a group", "id", key.id, "version", group.Version, "topic", group.Topic)
+
+	return group, nil
+}
+
+// subscribe registers a filter of messages of a group with its secret.
+func (m *Manager) subscribe(group *Group) error {
+	symKey := DeriveKey(group.secret)
+	symKeyID, err := m.shh.AddSymKeyDirect(symKey)
+	if err != nil {
+		return err
+	}
+
+	topic := DeriveTopic(group.secret)
+	filterID, err := m.shh.Subscribe(&whisper.Filter{
+		KeySym:   symKey,
+		Topics:   [][]byte{topic[:]},
+		AllowP2P: true, // messages of the group delivered by a mail server
+	})
+	if err != nil {
+		m.shh.DeleteSymKey(symKeyID)
+		return err
+	}
+
+	group.Topic, group.SymKeyID, group.FilterID = topic, symKeyID, filterID
+
+	return nil
+}
+
+// removeGroup removes the filter and the symmetric key of a group.
+func (m *Manager) removeGroup(key groupKey, group *Group) {
+	if err := m.shh.Unsubscribe(group.FilterID); err != nil {
+		log.Debug("failed to remove a filter", "filterID", group.FilterID, "err", err)
+	}
+	m.shh.DeleteSymKey(group.SymKeyID)
+	delete(m.groups, key)
+}
+
+// validate checks that an update follows rules of its type and changes the current state of a group, if it's
+// known. The first update of a group an identity receives is trusted to carry the right admins.
+func validate(current *Group, update *MembershipUpdate) error {
+	if len(update.GroupID) != groupIDLength || hasDuplicates(update.Members) || !isSubset(update.Admins, update.Members) {
+		return ErrInvalidUpdate
+	}
+	if current == nil {
+		if update.Type == UpdateCreate && update.Version != 1 {
+			return ErrInvalidUpdate
+		}
+		if update.Type != UpdateLeave && !isMember(update.Admins, update.From) {
+			return ErrNotAdmin
+		}
+		return nil
+	}
+
+	if update.Version <= current.Version {
+		return ErrStaleUpdate
+	}
+	if update.Name != current.Name {
+		return ErrInvalidUpdate
+	}
+	if update.Type != UpdateLeave && !isMember(current.Admins, update.From) {
+		return ErrNotAdmin
+	}
+
+	var members, admins []hexutil.Bytes
+	switch update.Type {
+	case UpdateAdd:
+		for _, member := range update.Changed {
+			if isMember(current.Members, member) {
+				return ErrInvalidUpdate
+			}
+		}
+		members, admins = append(append([]hexutil.Bytes(nil), current.Members...), update.Changed...), current.Admins
+	case UpdateRemove:
+		if !isSubset(update.Changed, current.Members) {
+			return ErrInvalidUpdate
+		}
+		members, admins = without(current.Members, update.Changed), without(current.Admins, update.Changed)
+	case UpdateLeave:
+		if len(update.Changed) != 1 || !bytes.Equal(update.Changed[0], update.From) || !isMember(current.Members, update.From) {
+			return ErrInvalidUpdate
+		}
+		members, admins = without(current.Members, update.Changed), without(current.Admins, update.Changed)
+	case UpdateRekey:
+		if len(update.Changed) > 0 {
+			return ErrInvalidUpdate
+		}
+		members, admins = current.Members, current.Admins
+	default:
+		return ErrInvalidUpdate
+	}
+
+	if !sameSet(members, update.Members) || !sameSet(admins, update.Admins) {
+		return ErrInvalidUpdate
+	}
+
+	return nil
+}
+
+// newMembers returns valid public keys of members which aren't in a list already, without duplicates.
+func newMembers(keys [][]byte, existing []hexutil.Bytes) ([]hexutil.Bytes, error) {
+	var added []hexutil.Bytes
+	for _, key := range keys {
+		if !whisper.ValidatePublicKey(crypto.ToECDSAPub(key)) {
+			return nil, ErrInvalidMemberKey
+		}
+		if isMember(existing, key) || isMember(added, key) {
+			continue
+		}
+		added = append(added, key)
+	}
+
+	return added, nil
+}
+
+// rekey sets a new random secret of an update.
+func rekey(update *MembershipUpdate) error {
+	secret, err := randomBytes(secretLength)
+	if err != nil {
+		return err
+	}
+	update.Secret = secret
+
+	return nil
+}
+
+// without returns a list without public keys in another list.
+func without(list, removed []hexutil.Bytes) []hexutil.Bytes {
+	var out []hexutil.Bytes
+	for _, key := range list {
+		if !isMember(removed, key) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
+
+// isSubset reports whether all public keys of a list are in another list.
+func isSubset(list, of []hexutil.Bytes) bool {
+	for _, key := range list {
+		if !isMember(of, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameSet reports whether two lists have the same public keys, in any order.
+func sameSet(a, b []hexutil.Bytes) bool {
+	return len(a) == len(b) && isSubset(a, b) && isSubset(b, a)
+}
+
+// hasDuplicates reports whether a public key is in a list more than once.
+func hasDuplicates(list []hexutil.Bytes) bool {
+	seen := make(map[string]struct{}, len(list))
+	for _, key := range list {
+		if _, ok := seen[string(key)]; ok {
+			return true
+		}
+		seen[string(key)] = struct{}{}
+	}
+	return false
+}
+
+// randomBytes returns random bytes of a length.
+func randomBytes(length int) ([]byte, error) {
+	b := make([]byte, length)
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// PublicAPI is the shh API of the manager.
+type PublicAPI struct {
+	manager *Manager
+}
+
+// Groups returns groups an identity is a member of, see Manager.Groups.
+func (api *PublicAPI) Groups(identity string) []Group {
+	return api.manager.Groups(identity)
+}
+
+// CreateGroup creates a group of an identity with members, see Manager.CreateGroup.
+func (api *PublicAPI) CreateGroup(identity, name string, members []hexutil.Bytes) (*MembershipUpdate, error) {
+	return api.manager.CreateGroup(identity, name, toKeys(members))
+}
+
+// AddGroupMembers adds members to a group, see Manager.AddMembers.
+func (api *PublicAPI) AddGroupMembers(identity string, groupID hexutil.Bytes, members []hexutil.Bytes) (*MembershipUpdate, error) {
+	return api.manager.AddMembers(identity, groupID, toKeys(members))
+}
+
+// RemoveGroupMembers removes members from a group, see Manager.RemoveMembers.
+func (api *PublicAPI) RemoveGroupMembers(identity string, groupID hexutil.Bytes, members []hexutil.Bytes) (*MembershipUpdate, error) {
+	return api.manager.RemoveMembers(identity, groupID, toKeys(members))
+}
+
+// LeaveGroup removes an identity from a group, see Manager.LeaveGroup.
+func (api *PublicAPI) LeaveGroup(identity string, groupID hexutil.Bytes) (*MembershipUpdate, error) {
+	return api.manager.LeaveGroup(identity, groupID)
+}
+
+// RekeyGroup shares a new secret of a group, see Manager.RekeyGroup.
+func (api *PublicAPI) RekeyGroup(identity string, groupID hexutil.Bytes) (*MembershipUpdate, error) {
+	return api.manager.RekeyGroup(identity, groupID)
+}
+
+// ProcessMembershipUpdate applies an update received from a member of a group, see Manager.ProcessUpdate.
+func (api *PublicAPI) ProcessMembershipUpdate(identity string, update *MembershipUpdate) (*Group, error) {
+	return api.manager.ProcessUpdate(identity, update)
+}
+
+// toKeys converts hex encoded public keys.
+func toKeys(keys []hexutil.Bytes) [][]byte {
+	out := make([][]byte, len(keys))
+	for i, key := range keys {
+		out[i] = key
+	}
+	return out
+}
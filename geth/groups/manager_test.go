@@ -0,0 +1,131 @@
+package groups
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+// member is an identity with its own manager
+type member struct {
+	manager    *Manager
+	identityID string
+	publicKey  []byte
+}
+
+func newMember(t *testing.T) *member {
+	shh := whisper.New(nil)
+	identityID, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	key, err := shh.GetPrivateKey(identityID)
+	require.NoError(t, err)
+
+	return &member{manager: New(shh), identityID: identityID, publicKey: crypto.FromECDSAPub(&key.PublicKey)}
+}
+
+func (m *member) process(t *testing.T, update *MembershipUpdate) *Group {
+	group, err := m.manager.ProcessUpdate(m.identityID, update)
+	require.NoError(t, err)
+	return group
+}
+
+func TestGroupMembership(t *testing.T) {
+	var events []MembershipEvent
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event MembershipEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventGroupMembership {
+			events = append(events, envelope.Event)
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	alice, bob, carol := newMember(t), newMember(t), newMember(t)
+
+	created, err := alice.manager.CreateGroup(alice.identityID, "friends", [][]byte{bob.publicKey, bob.publicKey})
+	require.NoError(t, err)
+	require.Len(t, created.Members, 2)
+	require.Empty(t, events, "updates created by the identity aren't signalled")
+
+	bobGroup := bob.process(t, created)
+	aliceGroup := alice.manager.Groups(alice.identityID)[0]
+	require.Equal(t, aliceGroup.Topic, bobGroup.Topic)
+	require.NotEmpty(t, bobGroup.FilterID)
+	require.Len(t, events, 1)
+	require.Equal(t, UpdateCreate, events[0].Type)
+	require.Equal(t, hexutil.Bytes(alice.publicKey), events[0].From)
+
+	// only admins change membership
+	_, err = bob.manager.AddMembers(bob.identityID, created.GroupID, [][]byte{carol.publicKey})
+	require.Equal(t, ErrNotAdmin, err)
+
+	added, err := alice.manager.AddMembers(alice.identityID, created.GroupID, [][]byte{carol.publicKey})
+	require.NoError(t, err)
+	require.Equal(t, bobGroup.Topic, bob.process(t, added).Topic, "the secret is kept when members are added")
+	require.Equal(t, bobGroup.Topic, carol.process(t, added).Topic)
+
+	_, err = bob.manager.ProcessUpdate(bob.identityID, added)
+	require.Equal(t, ErrStaleUpdate, err)
+
+	// removed members don't learn the new secret
+	removed, err := alice.manager.RemoveMembers(alice.identityID, created.GroupID, [][]byte{carol.publicKey})
+	require.NoError(t, err)
+	rekeyed := bob.process(t, removed)
+	require.NotEqual(t, bobGroup.Topic, rekeyed.Topic)
+	require.Empty(t, carol.process(t, removed.WithoutSecret()).FilterID)
+	require.Empty(t, carol.manager.Groups(carol.identityID))
+	require.True(t, events[len(events)-1].Removed)
+
+	left, err := bob.manager.LeaveGroup(bob.identityID, created.GroupID)
+	require.NoError(t, err)
+	require.Empty(t, bob.manager.Groups(bob.identityID))
+	require.Equal(t, rekeyed.Topic, alice.process(t, left.WithoutSecret()).Topic)
+
+	rekey, err := alice.manager.RekeyGroup(alice.identityID, created.GroupID)
+	require.NoError(t, err)
+	require.Len(t, rekey.Members, 1)
+	require.NotEqual(t, rekeyed.Topic, alice.manager.Groups(alice.identityID)[0].Topic)
+}
+
+func TestInvalidMembershipUpdates(t *testing.T) {
+	alice, bob, carol := newMember(t), newMember(t), newMember(t)
+
+	created, err := alice.manager.CreateGroup(alice.identityID, "friends", [][]byte{bob.publicKey})
+	require.NoError(t, err)
+
+	tampered := *created
+	tampered.Members = append(tampered.Members, carol.publicKey)
+	_, err = bob.manager.ProcessUpdate(bob.identityID, &tampered)
+	require.Equal(t, ErrInvalidSignature, err)
+
+	tampered = *created
+	tampered.Secret = []byte("another secret")
+	_, err = bob.manager.ProcessUpdate(bob.identityID, &tampered)
+	require.Equal(t, ErrInvalidSecret, err)
+
+	// an update needs the secret to join a group
+	_, err = bob.manager.ProcessUpdate(bob.identityID, created.WithoutSecret())
+	require.Equal(t, ErrInvalidSecret, err)
+
+	// an update of a group the identity isn't a member of isn't applied
+	_, err = carol.manager.ProcessUpdate(carol.identityID, created)
+	require.Equal(t, ErrGroupNotFound, err)
+
+	_, err = alice.manager.CreateGroup(alice.identityID, "invalid", [][]byte{[]byte("not a key")})
+	require.Equal(t, ErrInvalidMemberKey, err)
+	_, err = alice.manager.RemoveMembers(alice.identityID, created.GroupID, [][]byte{carol.publicKey})
+	require.Equal(t, ErrInvalidUpdate, err)
+	_, err = alice.manager.RekeyGroup(alice.identityID, []byte("unknown"))
+	require.Equal(t, ErrGroupNotFound, err)
+
+	require.NoError(t, alice.manager.Stop())
+	require.Empty(t, alice.manager.Groups(alice.identityID))
+}
@@ -3,12 +3,17 @@ package jail
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
 	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/jail/internal/crypto"
 	"github.com/status-im/status-go/geth/jail/internal/fetch"
+	"github.com/status-im/status-go/geth/jail/internal/localstorage"
 	"github.com/status-im/status-go/geth/jail/internal/loop"
 	"github.com/status-im/status-go/geth/jail/internal/loop/looptask"
+	"github.com/status-im/status-go/geth/jail/internal/polyfill"
 	"github.com/status-im/status-go/geth/jail/internal/timers"
 	"github.com/status-im/status-go/geth/jail/internal/vm"
 )
@@ -22,15 +27,39 @@ type Cell struct {
 	loop        *loop.Loop
 	loopStopped chan struct{}
 	loopErr     error
+
+	storage *localstorage.Store // localStorage of a cell created by a Jail
+	code    []string            // user code a cell created by a Jail was initialized with
+
+	clock   common.Clock
+	started time.Time
+
+	watchMx  sync.Mutex
+	watchers map[string]otto.Value // callbacks of watched Whisper filters, by filter ID
+
+	errMx     sync.Mutex
+	lastErr   error  // the last error of JS code executed in a cell
+	lastStack string // stack trace of the last error, if it's a JS error
 }
 
 // NewCell encapsulates what we need to create a new jailCell from the
 // provided vm and eventloop instance.
 func NewCell(id string) (*Cell, error) {
+	return NewCellWithClock(id, common.SystemClock)
+}
+
+// NewCellWithClock creates a new jailCell whose JS timers are scheduled with a given clock.
+// Its fetch can make any requests, unlike fetch of cells created by a Jail.
+func NewCellWithClock(id string, clock common.Clock) (*Cell, error) {
+	return newCell(id, clock, nil)
+}
+
+// newCell creates a new jailCell whose requests made with fetch are restricted by a policy, if it's given.
+func newCell(id string, clock common.Clock, fetchPolicy func() *fetch.Policy) (*Cell, error) {
 	vm := vm.New()
 	lo := loop.New(vm)
 
-	err := registerVMHandlers(vm, lo)
+	err := registerVMHandlers(vm, lo, clock, fetchPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -43,7 +72,10 @@ func NewCell(id string) (*Cell, error) {
 		cancel:      cancel,
 		loop:        lo,
 		loopStopped: loopStopped,
+		clock:       clock,
+		started:     clock.Now(),
 	}
+	lo.SetErrorHandler(cell.setLastError)
 
 	// Start event loop in the background.
 	go func() {
@@ -60,14 +92,30 @@ func NewCell(id string) (*Cell, error) {
 
 // registerHandlers register variuous functions and handlers
 // to the Otto VM, such as Fetch API callbacks or promises.
-func registerVMHandlers(vm *vm.VM, lo *loop.Loop) error {
+func registerVMHandlers(vm *vm.VM, lo *loop.Loop, clock common.Clock, fetchPolicy func() *fetch.Policy) error {
 	// setTimeout/setInterval functions
-	if err := timers.Define(vm, lo); err != nil {
+	if err := timers.Define(vm, lo, clock); err != nil {
 		return err
 	}
 
 	// FetchAPI functions
-	return fetch.Define(vm, lo)
+	var err error
+	if fetchPolicy != nil {
+		err = fetch.DefineWithPolicy(vm, lo, fetchPolicy)
+	} else {
+		err = fetch.Define(vm, lo)
+	}
+	if err != nil {
+		return err
+	}
+
+	// ES6 methods, after Promise is defined with FetchAPI
+	if err := polyfill.Define(vm); err != nil {
+		return err
+	}
+
+	// status.crypto functions
+	return crypto.Define(vm)
 }
 
 // Stop halts event loop associated with cell.
@@ -82,6 +130,155 @@ func (c *Cell) Stop() error {
 	}
 }
 
+// Run evaluates JS source, which may be string or otto.Script variable.
+// It's executed in the event loop of the cell, see execute.
+func (c *Cell) Run(src interface{}) (otto.Value, error) {
+	value, err := c.execute(func() (otto.Value, error) {
+		return c.VM.Run(src)
+	})
+	c.setLastError(err)
+	return value, err
+}
+
+// Call calls a JS function by name with given args.
+// It's executed in the event loop of the cell, see execute.
+func (c *Cell) Call(item string, this interface{}, args ...interface{}) (otto.Value, error) {
+	value, err := c.execute(func() (otto.Value, error) {
+		return c.VM.Call(item, this, args...)
+	})
+	c.setLastError(err)
+	return value, err
+}
+
+// execute queues a function executing JS code in the event loop of the cell and waits for its result,
+// so that executions requested by callers are run one by one in the goroutine of the cell, in order,
+// along with callbacks of timers and asynchronous calls. Once the loop is stopped, the function
+// is executed by the caller, so that a stopped cell can still be inspected.
+func (c *Cell) execute(fn func() (otto.Value, error)) (otto.Value, error) {
+	task := newExecuteTask(fn)
+	c.loop.Add(task)
+	c.loop.Ready(task)
+
+	select {
+	case result := <-task.done:
+		return result.value, result.err
+	case <-c.loopStopped:
+	}
+
+	// the task could be executed right before the loop stopped
+	select {
+	case result := <-task.done:
+		return result.value, result.err
+	default:
+		return fn()
+	}
+}
+
+// executeResult is a result of an executeTask.
+type executeResult struct {
+	value otto.Value
+	err   error
+}
+
+// executeTask is a task executing JS code on behalf of a caller of Run or Call of a cell.
+type executeTask struct {
+	id   int64
+	fn   func() (otto.Value, error)
+	done chan executeResult
+}
+
+func newExecuteTask(fn func() (otto.Value, error)) *executeTask {
+	return &executeTask{
+		fn:   fn,
+		done: make(chan executeResult, 1),
+	}
+}
+
+// SetID sets the ID of an executeTask.
+func (t *executeTask) SetID(id int64) { t.id = id }
+
+// GetID gets the ID of an executeTask.
+func (t *executeTask) GetID() int64 { return t.id }
+
+// Cancel does nothing, as the caller waiting for a cancelled task executes it once the loop is stopped.
+func (t *executeTask) Cancel() {}
+
+// Execute executes the function and passes its result to the caller, which handles errors,
+// so they are not returned to the loop.
+// nolint: unparam
+func (t *executeTask) Execute(vm *vm.VM, l *loop.Loop) error {
+	value, err := t.fn()
+	t.done <- executeResult{value, err}
+	return nil
+}
+
+// Status returns how long a cell is running, how many timers are pending in it, and its last error.
+func (c *Cell) Status() common.JailCellStatus {
+	status := common.JailCellStatus{
+		ChatID:        c.id,
+		Uptime:        int64(c.clock.Now().Sub(c.started) / time.Second),
+		PendingTimers: timers.Pending(c.loop),
+	}
+
+	c.errMx.Lock()
+	if c.lastErr != nil {
+		status.LastError = c.lastErr.Error()
+	}
+	c.errMx.Unlock()
+
+	return status
+}
+
+// watchFilter starts watching a Whisper filter, whose messages are passed to the callback.
+// A filter watched already is watched with the new callback instead.
+func (c *Cell) watchFilter(filterID string, callback otto.Value) {
+	c.watchMx.Lock()
+	defer c.watchMx.Unlock()
+
+	if c.watchers == nil {
+		c.watchers = make(map[string]otto.Value)
+	}
+	c.watchers[filterID] = callback
+}
+
+// unwatchFilter stops watching a Whisper filter, if it's watched.
+func (c *Cell) unwatchFilter(filterID string) {
+	c.watchMx.Lock()
+	defer c.watchMx.Unlock()
+
+	delete(c.watchers, filterID)
+}
+
+// watchedFilters returns callbacks of watched Whisper filters, by filter ID.
+func (c *Cell) watchedFilters() map[string]otto.Value {
+	c.watchMx.Lock()
+	defer c.watchMx.Unlock()
+
+	watched := make(map[string]otto.Value, len(c.watchers))
+	for filterID, callback := range c.watchers {
+		watched[filterID] = callback
+	}
+
+	return watched
+}
+
+// setLastError remembers an error of JS code executed in a cell, if any.
+func (c *Cell) setLastError(err error) {
+	if err == nil {
+		return
+	}
+
+	var stack string
+	if jsErr, ok := err.(*otto.Error); ok {
+		stack = jsErr.String()
+	}
+
+	c.errMx.Lock()
+	c.lastErr = err
+	c.lastStack = stack
+	c.errMx.Unlock()
+}
+
 // CallAsync puts otto's function with given args into
 // event queue loop and schedules for immediate execution.
 // Intended to be used by any cell user that want's to run
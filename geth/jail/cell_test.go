@@ -40,6 +40,30 @@ func (s *CellTestSuite) TestCellRegisteredHandlers() {
 	s.NoError(err)
 }
 
+func (s *CellTestSuite) TestCellStopCancelsTimers() {
+	cell, err := NewCell("testCell2")
+	s.NoError(err)
+
+	called := make(chan struct{}, 1)
+	err = cell.Set("__shouldNeverRun", func() {
+		called <- struct{}{}
+	})
+	s.NoError(err)
+
+	_, err = cell.Run(`
+		setTimeout(__shouldNeverRun, 50);
+		setInterval(__shouldNeverRun, 50);
+	`)
+	s.NoError(err)
+	s.NoError(cell.Stop())
+
+	select {
+	case <-called:
+		s.Fail("timer of a stopped cell was called")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
 // TestJailLoopRace tests multiple setTimeout callbacks,
 // supposed to be run with '-race' flag.
 func (s *CellTestSuite) TestCellLoopRace() {
@@ -0,0 +1,88 @@
+package jail
+
+import (
+	"sync/atomic"
+
+	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/jail/console"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventConsole is triggered when a message is written with console of a cell while capturing is enabled.
+const EventConsole = "jail.console"
+
+// consoleLevels are methods of console whose messages are captured
+var consoleLevels = []string{"log", "info", "debug", "warn", "error"}
+
+// ConsoleEvent is a signal sent when a message is written with console of a cell.
+type ConsoleEvent struct {
+	ChatID    string `json:"chat_id"`
+	Level     string `json:"level"` // "log", "info", "debug", "warn" or "error"
+	Message   string `json:"message"`
+	Timestamp int64  `json:"timestamp"` // milliseconds since the epoch
+}
+
+// SetConsoleCapture enables or disables capturing of messages written with console of cells, including
+// existing ones. Captured messages are sent as EventConsole signals instead of being printed to stdout.
+func (j *Jail) SetConsoleCapture(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&j.consoleCapture, value)
+}
+
+// defineConsole replaces methods of console of a cell with ones which send their messages as signals
+// while capturing is enabled, and call the original methods otherwise.
+func (j *Jail) defineConsole(cell *Cell) error {
+	_, err := cell.VM.Do(func() (otto.Value, error) {
+		consoleValue, err := cell.VM.UnsafeVM().Get("console")
+		if err != nil {
+			return otto.UndefinedValue(), err
+		}
+		consoleObject := consoleValue.Object()
+
+		for _, level := range consoleLevels {
+			original, err := consoleObject.Get(level)
+			if err != nil {
+				return otto.UndefinedValue(), err
+			}
+
+			if err := consoleObject.Set(level, j.createConsoleHandler(cell, level, original)); err != nil {
+				return otto.UndefinedValue(), err
+			}
+		}
+
+		return otto.UndefinedValue(), nil
+	})
+
+	return err
+}
+
+// createConsoleHandler returns a handler of a console method of a cell.
+func (j *Jail) createConsoleHandler(cell *Cell, level string, original otto.Value) func(call otto.FunctionCall) otto.Value {
+	return func(call otto.FunctionCall) otto.Value {
+		if atomic.LoadInt32(&j.consoleCapture) == 0 {
+			arguments := make([]interface{}, len(call.ArgumentList))
+			for i, argument := range call.ArgumentList {
+				arguments[i] = argument
+			}
+			if _, err := original.Call(call.This, arguments...); err != nil {
+				throwJSError(err)
+			}
+			return otto.UndefinedValue()
+		}
+
+		j.signals.Send(signal.Envelope{
+			Type: EventConsole,
+			Event: ConsoleEvent{
+				ChatID:    cell.id,
+				Level:     level,
+				Message:   console.Format(call),
+				Timestamp: cell.clock.Now().UnixNano() / 1e6,
+			},
+		})
+
+		return otto.UndefinedValue()
+	}
+}
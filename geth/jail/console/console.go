@@ -10,9 +10,9 @@ import (
 )
 
 // Write provides the base function to write data to the underline writer
-// for the underline otto vm.
-func Write(fn otto.FunctionCall, w io.Writer, consoleEventName string) otto.Value {
-	signal.Send(signal.Envelope{
+// for the underline otto vm, signalling the data with a given function, e.g. signal.Send.
+func Write(fn otto.FunctionCall, w io.Writer, consoleEventName string, send func(signal.Envelope)) otto.Value {
+	send(signal.Envelope{
 		Type:  consoleEventName,
 		Event: convertArgs(fn.ArgumentList),
 	})
@@ -23,6 +23,12 @@ func Write(fn otto.FunctionCall, w io.Writer, consoleEventName string) otto.Valu
 	return otto.UndefinedValue()
 }
 
+// Format returns a message written by a console method called with given arguments,
+// formatted as otto's console formats it.
+func Format(fn otto.FunctionCall) string {
+	return formatForConsole(fn.ArgumentList)
+}
+
 // formatForConsole handles conversion of giving otto.Values into
 // string counter part.
 func formatForConsole(argumentList []otto.Value) string {
@@ -41,7 +41,7 @@ func (s *ConsoleTestSuite) TestConsoleLog() {
 
 	err := s.vm.Set("console", map[string]interface{}{
 		"log": func(fn otto.FunctionCall) otto.Value {
-			return console.Write(fn, &customWriter, "vm.console")
+			return console.Write(fn, &customWriter, "vm.console", signal.Send)
 		},
 	})
 	require.NoError(err)
@@ -80,7 +80,7 @@ func (s *ConsoleTestSuite) TestObjectLogging() {
 
 	err := s.vm.Set("console", map[string]interface{}{
 		"log": func(fn otto.FunctionCall) otto.Value {
-			return console.Write(fn, &customWriter, "vm.console")
+			return console.Write(fn, &customWriter, "vm.console", signal.Send)
 		},
 	})
 	require.NoError(err)
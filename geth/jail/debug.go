@@ -0,0 +1,134 @@
+package jail
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/jail/internal/timers"
+)
+
+// ErrDebugDisabled is returned when a JS expression is evaluated in a cell while debugging is disabled.
+var ErrDebugDisabled = errors.New("debugging of jail cells is disabled")
+
+// globalNamesCode returns names of properties of the global object as JSON
+const globalNamesCode = `JSON.stringify(Object.getOwnPropertyNames(this))`
+
+var (
+	builtinGlobalsOnce sync.Once
+	builtinGlobals     map[string]bool // names of global variables built into otto
+)
+
+// SetDebug enables or disables evaluation of JS expressions in cells with EvaluateInCell.
+func (j *Jail) SetDebug(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&j.debug, value)
+}
+
+// InspectCell returns names of global variables which are not built into otto, timers waiting to fire,
+// and the last error of a cell with its stack trace.
+func (j *Jail) InspectCell(chatID string) (*common.JailCellInspection, error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := cell.VM.Run(globalNamesCode)
+	if err != nil {
+		return nil, err
+	}
+	names, err := parseNames(value)
+	if err != nil {
+		return nil, err
+	}
+
+	builtinGlobalsOnce.Do(loadBuiltinGlobals)
+	globals := make([]string, 0, len(names))
+	for _, name := range names {
+		if !builtinGlobals[name] {
+			globals = append(globals, name)
+		}
+	}
+	sort.Strings(globals)
+
+	inspection := &common.JailCellInspection{
+		ChatID:  chatID,
+		Globals: globals,
+		Timers:  []common.JailTimer{},
+	}
+	for _, timer := range timers.List(cell.loop) {
+		inspection.Timers = append(inspection.Timers, common.JailTimer{
+			ID:       timer.ID,
+			Delay:    int64(timer.Delay / time.Millisecond),
+			Interval: timer.Interval,
+		})
+	}
+
+	cell.errMx.Lock()
+	if cell.lastErr != nil {
+		inspection.LastError = cell.lastErr.Error()
+		inspection.LastStack = cell.lastStack
+	}
+	cell.errMx.Unlock()
+
+	return inspection, nil
+}
+
+// EvaluateInCell evaluates a JS expression in a cell and returns its value, objects as JSON.
+// It returns ErrDebugDisabled unless debugging is enabled with SetDebug.
+func (j *Jail) EvaluateInCell(chatID, expr string) (string, error) {
+	if atomic.LoadInt32(&j.debug) == 0 {
+		return "", ErrDebugDisabled
+	}
+
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return "", err
+	}
+
+	value, err := cell.Run(expr)
+	if err != nil {
+		return "", err
+	}
+
+	if value.IsObject() && value.Class() != "Function" {
+		encoded, err := cell.VM.Call("JSON.stringify", nil, value)
+		if err == nil && encoded.IsString() {
+			return encoded.String(), nil
+		}
+	}
+
+	return value.String(), nil
+}
+
+// loadBuiltinGlobals loads names of global variables of a new otto VM.
+func loadBuiltinGlobals() {
+	builtinGlobals = make(map[string]bool)
+
+	value, err := otto.New().Run(globalNamesCode)
+	if err != nil {
+		return
+	}
+	names, err := parseNames(value)
+	if err != nil {
+		return
+	}
+
+	for _, name := range names {
+		builtinGlobals[name] = true
+	}
+}
+
+func parseNames(value otto.Value) ([]string, error) {
+	var names []string
+	err := json.Unmarshal([]byte(value.String()), &names)
+	return names, err
+}
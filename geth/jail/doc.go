@@ -37,6 +37,10 @@ Call and Run
 wrappers arount Otto VM functions of the same name. Run accepts raw JS strings for execution,
 Call takes a JS function name (defined in VM) and parameters.
 
+Cells don't share locks while executing JS code, so a cell busy with a slow command doesn't stall
+other chats. Cell.Run and Cell.Call are queued to the event loop of the cell and executed in its goroutine
+one by one, in order, along with callbacks of timers and asynchronous calls.
+
 Timeouts and intervals support
 
 Default Otto VM interpreter doesn't support setTimeout()/setInterval() JS functions,
@@ -46,6 +50,8 @@ We add support for them using own implementation of Event Loop, heavily based on
 Each cell starts a new loop in a separate goroutine, registers functions for setTimeout/setInterval
 calls and associate them with this loop. All JS code executed as callback to setTimeout/setInterval
 will be handled by this loop.
+A cell can have at most 500 timers scheduled at once, scheduling more throws a RangeError.
+Timers which haven't fired yet are cancelled when the cell is stopped.
 
 For example, following code:
 
@@ -66,6 +72,10 @@ Fetch support
 
 Fetch API is implemented in a similar way using the same loop. When Cell is created, corresponding handlers are registered within VM and associated event loop.
 
+Cells created by Jail can only fetch from hosts allowed by JailFetchConfig of the node's config
+and hosts allowed for the cell with SetFetchWhitelist, over HTTPS unless plain HTTP is allowed.
+Requests taking longer than the timeout and responses larger than the limit of the config fail.
+
 Due to asynchronous nature of Fetch API, the following code will return immediately:
 
 	cell.Run(`fetch('http://example.com/').then(function(data) { ... })`)
@@ -82,6 +92,86 @@ it's user responsibility to register a corresponding callback function before:
 		__captureSuccess(data)
 	}))
 
+localStorage support
+
+Cells created by Jail have localStorage, which is kept in the node's data directory in a file per chat,
+so that it persists when the cell is created again. Its keys and values can take 5MB, setting more items
+throws a QuotaExceededError. Jail.PurgeStorage removes all items of a chat.
+
+Modules
+
+Cells created by Jail can require CommonJS modules registered by the host app with Jail.RegisterModule,
+which adds a module only if the hex-encoded SHA-256 hash of its code matches the given one. Each cell
+evaluates a module once, when it's first required, and caches its exports. Requiring a module which is
+not registered throws an Error. Modules bundled with web3.js, e.g. require('web3'), are required as before.
+
+ES6 support
+
+Otto supports ES5 only, so cells define methods of built-in objects added by ES6 and later, e.g.
+Object.assign, Array.from, Array.prototype.find and includes, String.prototype.startsWith and padStart,
+Number.isInteger or Promise.prototype.finally. Syntax added by ES6, e.g. let, const, arrow functions
+or classes, can't be polyfilled, so DApps using it must transpile their code to ES5.
+
+Asynchronous RPC calls
+
+The web3 provider of a cell makes RPC calls with callbacks, e.g. web3.eth.getBalance(address, callback),
+asynchronously: the script continues while the call is made, and the callback is executed in the loop.
+At most 16 asynchronous calls are made at once by all cells, further calls wait for one of them to complete.
+Calls without callbacks block the cell until they complete.
+
+Whisper filters
+
+Cells can have messages of Whisper filters they created pushed to callbacks instead of polling them:
+statusWhisper.watch(filterId, callback) calls callback(error, messages) in the loop of the cell when
+messages matching the filter arrive, and statusWhisper.unwatch(filterId) stops it. Messages of filters
+which are not watched are left for shh_getFilterMessages, as before.
+
+Crypto
+
+Cells have status.crypto with cryptographic functions implemented in Go, so DApps don't need to ship
+pure-JS crypto libraries, which are large and slow in Otto: keccak256(data), ecrecover(hash, signature),
+verify(addressOrPublicKey, hash, signature), randomBytes(length), utf8ToHex(string) and hexToUtf8(hex).
+Binary data is passed as 0x-prefixed hex strings, and invalid arguments throw an Error. status.crypto
+is added to a status object defined by the user code as well.
+
+Console capture
+
+console.log, info, debug, warn and error of cells created by Jail print to stdout by default.
+Once Jail.SetConsoleCapture enables capturing, their messages are sent as "jail.console" signals instead,
+with the chat ID, the level, the message and a timestamp in milliseconds.
+
+Cell lifecycle
+
+Jail.StopCell stops a cell and removes it, Jail.RestartCell creates it again with the user code it was
+initialized with, and Jail.Cells returns how long each cell is running, how many timers are pending in it,
+and its last error, e.g. an exception thrown by a callback of a timer.
+
+Snapshots
+
+Cells lose their state when the app restarts. Jail.SnapshotCell returns what a cell can be restored from
+with Jail.RestoreCell on the next start: the user code it was initialized with, its localStorage if it's
+kept in memory, and criteria of Whisper filters it created. The memory of the VM isn't kept: RestoreCell
+sets the items of localStorage, creates the filters again and replays the initialization of the cell.
+statusWhisper.restoredFilters maps IDs of filters in the snapshot to IDs of filters created again.
+
+Debugging
+
+Jail.InspectCell returns names of global variables of a cell which are not built into otto, its timers
+waiting to fire, and its last error with the stack trace. Jail.EvaluateInCell evaluates a JS expression
+in a cell and returns its value, objects as JSON. It's enabled by JailDebug of the node's config only,
+which should be turned off in production.
+
+Resource limits
+
+Cells created by Jail have limits of a single execution of JS code, e.g. of a script or a callback of a timer:
+its duration, excluding time spent waiting for RPC calls, how many operations it evaluates, and approximately
+how much memory it allocates. They are set by JailLimitsConfig of the node. A cell exceeding a limit is stopped
+and removed, and a "jail.cell.halted" signal is sent with the chat ID and the limit.
+
+Jail.InterruptCell aborts JS code being executed in a cell, e.g. a script stuck in an infinite loop, with
+Otto's interrupt channel, which is checked before each statement. The interrupted execution fails, but the
+cell keeps running. An execution exceeding the duration limit is interrupted the same way by a watchdog.
+
 */
 package jail
 
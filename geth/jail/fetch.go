@@ -0,0 +1,47 @@
+package jail
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/geth/jail/internal/fetch"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// ConfigureFetch sets limits of HTTP requests made with fetch from cells, including hosts all cells can
+// fetch from. The limits apply to requests made afterwards, including ones of existing cells.
+func (j *Jail) ConfigureFetch(config params.JailFetchConfig) {
+	j.fetchMx.Lock()
+	defer j.fetchMx.Unlock()
+
+	j.fetchConfig = config
+}
+
+// SetFetchWhitelist sets hosts a cell can fetch from in addition to hosts all cells can fetch from,
+// e.g. a backend of a DApp. Hosts can be set before the cell is created, and are kept when it's stopped.
+func (j *Jail) SetFetchWhitelist(chatID string, hosts []string) {
+	j.fetchMx.Lock()
+	defer j.fetchMx.Unlock()
+
+	if len(hosts) == 0 {
+		delete(j.fetchHosts, chatID)
+		return
+	}
+	j.fetchHosts[chatID] = append([]string(nil), hosts...)
+}
+
+// fetchPolicy returns a policy of requests made with fetch from a cell.
+func (j *Jail) fetchPolicy(chatID string) *fetch.Policy {
+	j.fetchMx.RLock()
+	defer j.fetchMx.RUnlock()
+
+	hosts := make([]string, 0, len(j.fetchConfig.AllowedHosts)+len(j.fetchHosts[chatID]))
+	hosts = append(hosts, j.fetchConfig.AllowedHosts...)
+	hosts = append(hosts, j.fetchHosts[chatID]...)
+
+	return &fetch.Policy{
+		AllowedHosts:    hosts,
+		AllowInsecure:   j.fetchConfig.AllowInsecure,
+		Timeout:         time.Duration(j.fetchConfig.Timeout) * time.Second,
+		MaxResponseSize: j.fetchConfig.MaxResponseSize,
+	}
+}
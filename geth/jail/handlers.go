@@ -21,7 +21,7 @@ func registerWeb3Provider(jail *Jail, cell *Cell) error {
 	jeth := map[string]interface{}{
 		"console": map[string]interface{}{
 			"log": func(fn otto.FunctionCall) otto.Value {
-				return console.Write(fn, os.Stdout, eventConsoleLog)
+				return console.Write(fn, os.Stdout, eventConsoleLog, jail.signals.Send)
 			},
 		},
 		"send":        createSendHandler(jail, cell),
@@ -34,9 +34,9 @@ func registerWeb3Provider(jail *Jail, cell *Cell) error {
 
 // registerStatusSignals creates an object called "statusSignals".
 // TODO(adam): describe what it is and when it's used.
-func registerStatusSignals(cell *Cell) error {
+func registerStatusSignals(jail *Jail, cell *Cell) error {
 	statusSignals := map[string]interface{}{
-		"sendSignal": createSendSignalHandler(cell),
+		"sendSignal": createSendSignalHandler(jail, cell),
 	}
 
 	return cell.Set("statusSignals", statusSignals)
@@ -55,7 +55,12 @@ func createSendHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall) otto
 			throwJSError(err)
 		}
 
-		response, err := jail.sendRPCCall(request.String())
+		// waiting for a response, e.g. for the user to approve a transaction,
+		// doesn't count towards the execution time limit of the cell
+		var response interface{}
+		cell.VM.Idle(func() {
+			response, err = jail.sendRPCCall(cell.id, request.String())
+		})
 		if err != nil {
 			throwJSError(err)
 		}
@@ -82,24 +87,21 @@ func createSendAsyncHandler(jail *Jail, cell *Cell) func(call otto.FunctionCall)
 			throwJSError(err)
 		}
 
-		go func() {
-			// As it's an async call, it's not called from a thread-safe context,
-			// thus using a thread-safe vm.VM.
-			vm := cell.VM
-			callback := call.Argument(1)
-			response, err := jail.sendRPCCall(request.String())
-
+		callback := call.Argument(1)
+		jail.sendRPCCallAsync(cell.id, request.String(), func(response interface{}, err error) {
 			// If provided callback argument is not a function, don't call it.
 			if callback.Class() != "Function" {
 				return
 			}
 
+			// As it's an async call, it's not called from a thread-safe context,
+			// thus using a thread-safe vm.VM.
 			if err != nil {
-				cell.CallAsync(callback, vm.MakeCustomError("Error", err.Error()))
+				cell.CallAsync(callback, cell.VM.MakeCustomError("Error", err.Error()))
 			} else {
 				cell.CallAsync(callback, nil, response)
 			}
-		}()
+		})
 
 		return otto.UndefinedValue()
 	}
@@ -127,11 +129,11 @@ func createIsConnectedHandler(jail RPCClientProvider) func(call otto.FunctionCal
 	}
 }
 
-func createSendSignalHandler(cell *Cell) func(otto.FunctionCall) otto.Value {
+func createSendSignalHandler(jail *Jail, cell *Cell) func(otto.FunctionCall) otto.Value {
 	return func(call otto.FunctionCall) otto.Value {
 		message := call.Argument(0).String()
 
-		signal.Send(signal.Envelope{
+		jail.signals.Send(signal.Envelope{
 			Type: EventSignal,
 			Event: struct {
 				ChatID string `json:"chat_id"`
@@ -1,15 +1,18 @@
 package jail
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/robertkrimen/otto"
 
 	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/geth/signal"
@@ -62,6 +65,41 @@ func (s *HandlersTestSuite) TestWeb3SendHandlerSuccess() {
 	s.True(result)
 }
 
+func (s *HandlersTestSuite) TestWeb3SendHandlerChatID() {
+	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	chatIDs := make(chan string, 2)
+	client.RegisterHandler("eth_sendTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		chatIDs <- common.ChatIDFromContext(ctx)
+		return "0x01", nil
+	})
+
+	jail := New(&testRPCClientProvider{client})
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+
+	expectChatID := func() {
+		select {
+		case chatID := <-chatIDs:
+			s.Equal("cell1", chatID)
+		case <-time.After(time.Second):
+			s.Fail("transaction was not sent")
+		}
+	}
+
+	// transactions sent by a DApp are attributed to its cell, both with sync and async calls
+	tx := `{from: "0x0000000000000000000000000000000000000001", to: "0x0000000000000000000000000000000000000002"}`
+	_, err = cell.Run(`web3.eth.sendTransaction(` + tx + `)`)
+	s.NoError(err)
+	expectChatID()
+
+	_, err = cell.Run(`web3.eth.sendTransaction(` + tx + `, function() {})`)
+	s.NoError(err)
+	expectChatID()
+}
+
 func (s *HandlersTestSuite) TestWeb3SendHandlerFailure() {
 	jail := New(nil)
 
@@ -97,6 +135,62 @@ func (s *HandlersTestSuite) TestWeb3SendAsyncHandlerSuccess() {
 	s.Equal(`true`, <-resultc)
 }
 
+func (s *HandlersTestSuite) TestWeb3SendAsyncHandlerConcurrency() {
+	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	var calls, maxCalls int32
+	release := make(chan struct{})
+	client.RegisterHandler("eth_blockNumber", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		current := atomic.AddInt32(&calls, 1)
+		defer atomic.AddInt32(&calls, -1)
+		for {
+			max := atomic.LoadInt32(&maxCalls)
+			if current <= max || atomic.CompareAndSwapInt32(&maxCalls, max, current) {
+				break
+			}
+		}
+
+		<-release
+		return "0x10", nil
+	})
+
+	jail := New(&testRPCClientProvider{client})
+	defer jail.Stop()
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+
+	results := make(chan string, 2*RPCWorkers)
+	err = cell.Set("__blockNumberCallback", func(call otto.FunctionCall) otto.Value {
+		results <- call.Argument(1).String()
+		return otto.UndefinedValue()
+	})
+	s.NoError(err)
+
+	// the script is not blocked by calls which don't complete
+	value, err := cell.Run(fmt.Sprintf(`
+		for (var i = 0; i < %d; i++) { web3.eth.getBlockNumber(__blockNumberCallback) }
+		"scheduled"
+	`, 2*RPCWorkers))
+	s.NoError(err)
+	s.Equal("scheduled", value.String())
+
+	time.Sleep(100 * time.Millisecond)
+	s.EqualValues(RPCWorkers, atomic.LoadInt32(&maxCalls))
+
+	close(release)
+	for i := 0; i < 2*RPCWorkers; i++ {
+		select {
+		case result := <-results:
+			s.Equal("16", result)
+		case <-time.After(time.Second):
+			s.FailNow("callback was not called")
+		}
+	}
+	s.EqualValues(RPCWorkers, atomic.LoadInt32(&maxCalls))
+}
+
 func (s *HandlersTestSuite) TestWeb3SendAsyncHandlerWithoutCallbackSuccess() {
 	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
 	s.NoError(err)
@@ -184,3 +278,153 @@ func (s *HandlersTestSuite) TestSendSignalHandler() {
 	s.NoError(err)
 	s.True(resultBool)
 }
+
+func (s *HandlersTestSuite) TestWhisperWatch() {
+	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	client.RegisterHandler("shh_newMessageFilter", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "filter1", nil
+	})
+	messages := make(chan []map[string]interface{}, 1)
+	messages <- []map[string]interface{}{{"payload": "0x01"}}
+	client.RegisterHandler("shh_getFilterMessages", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		select {
+		case m := <-messages:
+			return m, nil
+		default:
+			return []map[string]interface{}{}, nil
+		}
+	})
+
+	jail := New(&testRPCClientProvider{client})
+	defer jail.Stop()
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+	other, err := jail.createAndInitCell("cell2")
+	s.NoError(err)
+
+	received := make(chan string, 1)
+	err = cell.Set("__received", func(call otto.FunctionCall) otto.Value {
+		received <- call.Argument(1).String()
+		return otto.UndefinedValue()
+	})
+	s.NoError(err)
+
+	_, err = cell.Run(`
+		var filterId = jeth.send({jsonrpc: "2.0", id: 7, method: "shh_newMessageFilter", params: [{}]}).result;
+		statusWhisper.watch(filterId, function (err, messages) {
+			__received(err, JSON.stringify(messages));
+		});
+	`)
+	s.NoError(err)
+
+	select {
+	case m := <-received:
+		s.Equal(`[{"payload":"0x01"}]`, m)
+	case <-time.After(time.Second):
+		s.Fail("messages were not pushed")
+	}
+
+	// cells can't watch filters of other cells
+	_, err = other.Run(`statusWhisper.watch("filter1", function () {})`)
+	s.EqualError(err, "filter filter1 was not created by the cell")
+
+	_, err = cell.Run(`statusWhisper.unwatch(filterId)`)
+	s.NoError(err)
+	messages <- []map[string]interface{}{{"payload": "0x02"}}
+	select {
+	case <-received:
+		s.Fail("unwatched filter is pushed")
+	case <-time.After(2 * whisperPollInterval):
+	}
+}
+
+func (s *HandlersTestSuite) TestStopCellRemovesFilters() {
+	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	client.RegisterHandler("shh_newMessageFilter", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		return "filter1", nil
+	})
+	var polls int32
+	client.RegisterHandler("shh_getFilterMessages", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		atomic.AddInt32(&polls, 1)
+		return []map[string]interface{}{}, nil
+	})
+	deleted := make(chan string, 1)
+	client.RegisterHandler("shh_deleteMessageFilter", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		deleted <- fmt.Sprint(args[0])
+		return true, nil
+	})
+
+	jail := New(&testRPCClientProvider{client})
+	defer jail.Stop()
+
+	cell, err := jail.createAndInitCell("cell1")
+	s.NoError(err)
+	_, err = cell.Run(`
+		var filterId = jeth.send({jsonrpc: "2.0", id: 1, method: "shh_newMessageFilter", params: [{}]}).result;
+		statusWhisper.watch(filterId, function () {});
+	`)
+	s.NoError(err)
+	time.Sleep(2 * whisperPollInterval)
+	s.NotZero(atomic.LoadInt32(&polls))
+
+	// filters of a stopped cell are deleted and not polled anymore
+	s.NoError(jail.StopCell("cell1"))
+	s.Equal("filter1", <-deleted)
+	s.Empty(jail.whisperFilters.criteriaOf("cell1"))
+
+	time.Sleep(2 * whisperPollInterval)
+	stopped := atomic.LoadInt32(&polls)
+	time.Sleep(2 * whisperPollInterval)
+	s.Equal(stopped, atomic.LoadInt32(&polls))
+
+	jail.watchMx.Lock()
+	defer jail.watchMx.Unlock()
+	s.False(jail.watching, "polling loop is running without watched filters")
+}
+
+func (s *HandlersTestSuite) TestSnapshotCell() {
+	client, err := rpc.NewClient(s.client, params.UpstreamRPCConfig{})
+	s.NoError(err)
+
+	var created int32
+	criteria := make(chan string, 1)
+	client.RegisterHandler("shh_newMessageFilter", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		criteria <- fmt.Sprint(args[0])
+		return fmt.Sprintf("filter%d", atomic.AddInt32(&created, 1)), nil
+	})
+
+	jail := New(&testRPCClientProvider{client})
+	code := `var _status_catalog = {}; var visits = Number(localStorage.getItem('visits')) + 1; localStorage.setItem('visits', visits)`
+	cell, err := jail.createAndInitCell("cell1", code)
+	s.NoError(err)
+	_, err = cell.Run(`jeth.send({jsonrpc: "2.0", id: 1, method: "shh_newMessageFilter", params: [{topics: ["0x01"]}]})`)
+	s.NoError(err)
+	s.Equal("map[topics:[0x01]]", <-criteria)
+
+	snapshot, err := jail.SnapshotCell("cell1")
+	s.NoError(err)
+	s.Equal([]string{code}, snapshot.Code)
+	s.Equal(map[string]string{"visits": "1"}, snapshot.Storage)
+	s.Len(snapshot.Filters, 1)
+	jail.Stop()
+
+	// the cell is restored as the app restarts
+	jail = New(&testRPCClientProvider{client})
+	defer jail.Stop()
+
+	s.Equal(`{"result": {}}`, jail.RestoreCell(snapshot))
+	s.Equal("map[topics:[0x01]]", <-criteria)
+
+	cell, err = jail.cell("cell1")
+	s.NoError(err)
+	value, err := cell.Run(`visits + ' ' + statusWhisper.restoredFilters.filter1`)
+	s.NoError(err)
+	s.Equal("2 filter2", value.String())
+
+	s.Contains(jail.RestoreCell(snapshot), "cell with id 'cell1' already exists")
+}
@@ -0,0 +1,176 @@
+// Package crypto defines status.crypto in a VM, which provides cryptographic primitives implemented in Go,
+// so that DApps don't need pure-JS crypto libraries, which are large and slow in otto. Binary data is passed
+// as 0x-prefixed hex strings, as in web3.
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/robertkrimen/otto"
+
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+// MaxRandomBytes is how many random bytes can be generated at once.
+const MaxRandomBytes = 1024
+
+const (
+	hashLength      = 32
+	signatureLength = 65 // R, S and V
+)
+
+var (
+	errHashLength      = fmt.Errorf("hash must be %d bytes long", hashLength)
+	errSignatureLength = fmt.Errorf("signature must be %d bytes long", signatureLength)
+	errSignatureValues = errors.New("signature values are invalid")
+	errInvalidUTF8     = errors.New("data is not valid UTF-8")
+)
+
+// Define status.crypto with the following functions, which throw an Error on invalid arguments:
+//
+//	keccak256(data)                  - Keccak-256 hash of hex data
+//	ecrecover(hash, signature)       - address of the signer of a 32-byte hash
+//	verify(signer, hash, signature)  - whether a hash is signed by a signer, given as an address or a public key
+//	randomBytes(length)              - hex of cryptographically secure random bytes, at most MaxRandomBytes
+//	utf8ToHex(string), hexToUtf8(hex) - conversions between strings and hex of their UTF-8 bytes
+//
+// Signatures are 65 bytes long, R, S and V, with V being either 0/1 or 27/28. The status object is created
+// if it's not defined yet, and it's left alone if it's defined as something other than an object.
+func Define(vm *vm.VM) error {
+	status, err := vm.Get("status")
+	if err != nil {
+		return err
+	}
+	if status.IsUndefined() {
+		if status, err = vm.Run(`status = {}`); err != nil {
+			return err
+		}
+	}
+	if !status.IsObject() {
+		return nil
+	}
+
+	return status.Object().Set("crypto", map[string]interface{}{
+		"keccak256":   keccak256,
+		"ecrecover":   ecrecover,
+		"verify":      verify,
+		"randomBytes": randomBytes,
+		"utf8ToHex":   utf8ToHex,
+		"hexToUtf8":   hexToUtf8,
+	})
+}
+
+func keccak256(call otto.FunctionCall) otto.Value {
+	data := decodeArgument(call, 0)
+	return toValue(call, hexutil.Encode(gethcrypto.Keccak256(data)))
+}
+
+func ecrecover(call otto.FunctionCall) otto.Value {
+	hash := decodeArgument(call, 0)
+	signature := decodeArgument(call, 1)
+
+	signer, err := recoverSigner(hash, signature)
+	if err != nil {
+		throw(call, err)
+	}
+
+	return toValue(call, gethcrypto.PubkeyToAddress(*signer).Hex())
+}
+
+func verify(call otto.FunctionCall) otto.Value {
+	expected := decodeArgument(call, 0)
+	hash := decodeArgument(call, 1)
+	signature := decodeArgument(call, 2)
+
+	signer, err := recoverSigner(hash, signature)
+	if err != nil {
+		return otto.FalseValue()
+	}
+
+	if len(expected) == gethcommon.AddressLength {
+		return toValue(call, bytes.Equal(expected, gethcrypto.PubkeyToAddress(*signer).Bytes()))
+	}
+	return toValue(call, bytes.Equal(expected, gethcrypto.FromECDSAPub(signer)))
+}
+
+func randomBytes(call otto.FunctionCall) otto.Value {
+	length, err := call.Argument(0).ToInteger()
+	if err != nil || length < 0 || length > MaxRandomBytes {
+		throw(call, fmt.Errorf("length must be between 0 and %d", MaxRandomBytes))
+	}
+
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		throw(call, err)
+	}
+
+	return toValue(call, hexutil.Encode(data))
+}
+
+func utf8ToHex(call otto.FunctionCall) otto.Value {
+	return toValue(call, hexutil.Encode([]byte(call.Argument(0).String())))
+}
+
+func hexToUtf8(call otto.FunctionCall) otto.Value {
+	data := decodeArgument(call, 0)
+	if !utf8.Valid(data) {
+		throw(call, errInvalidUTF8)
+	}
+
+	return toValue(call, string(data))
+}
+
+// recoverSigner returns a public key which signed a hash, rejecting malleable signatures.
+func recoverSigner(hash, signature []byte) (*ecdsa.PublicKey, error) {
+	if len(hash) != hashLength {
+		return nil, errHashLength
+	}
+	if len(signature) != signatureLength {
+		return nil, errSignatureLength
+	}
+
+	sig := make([]byte, signatureLength)
+	copy(sig, signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	r := new(big.Int).SetBytes(sig[:32])
+	s := new(big.Int).SetBytes(sig[32:64])
+	if !gethcrypto.ValidateSignatureValues(sig[64], r, s, true) {
+		return nil, errSignatureValues
+	}
+
+	return gethcrypto.SigToPub(hash, sig)
+}
+
+// decodeArgument decodes a hex argument of a call, throwing an Error if it's not 0x-prefixed hex.
+func decodeArgument(call otto.FunctionCall, i int) []byte {
+	data, err := hexutil.Decode(call.Argument(i).String())
+	if err != nil {
+		throw(call, fmt.Errorf("argument %d: %v", i, err))
+	}
+
+	return data
+}
+
+func throw(call otto.FunctionCall, err error) {
+	panic(call.Otto.MakeCustomError("Error", err.Error()))
+}
+
+func toValue(call otto.FunctionCall, value interface{}) otto.Value {
+	v, err := call.Otto.ToValue(value)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
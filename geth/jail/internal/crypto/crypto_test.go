@@ -0,0 +1,87 @@
+package crypto_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/geth/jail/internal/crypto"
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+func TestCrypto(t *testing.T) {
+	o := vm.New()
+	require.NoError(t, crypto.Define(o))
+
+	key, err := gethcrypto.GenerateKey()
+	require.NoError(t, err)
+	address := gethcrypto.PubkeyToAddress(key.PublicKey).Hex()
+	hash := gethcrypto.Keccak256([]byte("status"))
+	signature, err := gethcrypto.Sign(hash, key)
+	require.NoError(t, err)
+	signature[64] += 27 // as signed by web3
+
+	require.NoError(t, o.Set("hash", hexutil.Encode(hash)))
+	require.NoError(t, o.Set("signature", hexutil.Encode(signature)))
+	require.NoError(t, o.Set("publicKey", hexutil.Encode(gethcrypto.FromECDSAPub(&key.PublicKey))))
+
+	testCases := []struct {
+		code     string
+		expected string
+	}{
+		{`status.crypto.keccak256(status.crypto.utf8ToHex('status'))`, hexutil.Encode(hash)},
+		{`status.crypto.keccak256('0x')`, hexutil.Encode(gethcrypto.Keccak256(nil))},
+		{`status.crypto.ecrecover(hash, signature)`, address},
+		{fmt.Sprintf(`status.crypto.verify('%s', hash, signature)`, address), `true`},
+		{`status.crypto.verify(publicKey, hash, signature)`, `true`},
+		{`status.crypto.verify('0x0000000000000000000000000000000000000001', hash, signature)`, `false`},
+		{`status.crypto.verify(publicKey, status.crypto.keccak256('0x01'), signature)`, `false`},
+		{`status.crypto.hexToUtf8(status.crypto.utf8ToHex('статус ✓'))`, `статус ✓`},
+		{`status.crypto.randomBytes(16).length`, `34`},
+		{`status.crypto.randomBytes(0)`, `0x`},
+	}
+
+	for _, tc := range testCases {
+		value, err := o.Run(tc.code)
+		require.NoError(t, err, tc.code)
+		require.Equal(t, tc.expected, value.String(), tc.code)
+	}
+
+	errorCases := []string{
+		`status.crypto.keccak256('status')`,
+		`status.crypto.ecrecover(hash, '0x01')`,
+		`status.crypto.ecrecover('0x01', signature)`,
+		`status.crypto.randomBytes(1025)`,
+		`status.crypto.randomBytes(-1)`,
+		`status.crypto.hexToUtf8('0xff')`,
+	}
+
+	for _, code := range errorCases {
+		_, err := o.Run(code)
+		require.Error(t, err, code)
+	}
+}
+
+func TestDefineOnExistingStatus(t *testing.T) {
+	o := vm.New()
+	_, err := o.Run(`var status = {name: 'bot'}`)
+	require.NoError(t, err)
+	require.NoError(t, crypto.Define(o))
+
+	value, err := o.Run(`status.name + ' ' + typeof status.crypto.keccak256`)
+	require.NoError(t, err)
+	require.Equal(t, "bot function", value.String())
+
+	// status defined as something else is left alone
+	o = vm.New()
+	_, err = o.Run(`var status = 'ok'`)
+	require.NoError(t, err)
+	require.NoError(t, crypto.Define(o))
+
+	value, err = o.Run(`status`)
+	require.NoError(t, err)
+	require.Equal(t, "ok", value.String())
+}
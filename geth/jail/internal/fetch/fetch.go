@@ -4,7 +4,6 @@ package fetch
 
 import (
 	"io"
-	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -53,9 +52,14 @@ func (t *fetchTask) Execute(vm *vm.VM, l *loop.Loop) error {
 	// We're locking on VM here because underlying otto's VM
 	// is not concurrently safe, and this function indirectly
 	// access vm's functions in cb.Call/h.Set.
-	vm.Lock()
-	defer vm.Unlock()
+	_, err := vm.Do(func() (otto.Value, error) {
+		return otto.UndefinedValue(), t.respond(arguments)
+	})
+	return err
+}
 
+// respond fills the response in and calls the callback with it
+func (t *fetchTask) respond(arguments []interface{}) error {
 	err := t.jsRes.Set("status", t.status)
 	if err != nil {
 		return err
@@ -93,6 +97,16 @@ func Define(vm *vm.VM, l *loop.Loop) error {
 
 //DefineWithHandler fetch with handler
 func DefineWithHandler(vm *vm.VM, l *loop.Loop, h http.Handler) error {
+	return define(vm, l, h, nil)
+}
+
+//DefineWithPolicy fetch restricted by a policy, which is requested for each request,
+//so that the policy can be changed after fetch is defined
+func DefineWithPolicy(vm *vm.VM, l *loop.Loop, policy func() *Policy) error {
+	return define(vm, l, nil, policy)
+}
+
+func define(vm *vm.VM, l *loop.Loop, h http.Handler, policy func() *Policy) error {
 	if err := promise.Define(vm, l); err != nil {
 		return err
 	}
@@ -150,13 +164,23 @@ func DefineWithHandler(vm *vm.VM, l *loop.Loop, h http.Handler) error {
 				t.headers = res.Header()
 				t.body = res.Body.Bytes()
 			} else {
-				res, e := http.DefaultClient.Do(req)
+				var p *Policy
+				if policy != nil {
+					p = policy()
+				}
+				if e := p.check(req.URL); e != nil {
+					t.err = e
+					return
+				}
+
+				res, e := p.client().Do(req)
 				if e != nil {
 					t.err = e
 					return
 				}
+				defer res.Body.Close() //nolint: errcheck
 
-				d, e := ioutil.ReadAll(res.Body)
+				d, e := p.readBody(res.Body)
 				if e != nil {
 					t.err = e
 					return
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -193,6 +194,61 @@ loop:
 	s.Equal(5, count)
 }
 
+func (s *FetchSuite) TestFetchWithPolicy() {
+	s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello")) //nolint: errcheck
+	})
+	s.mux.HandleFunc("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello, world")) //nolint: errcheck
+	})
+	s.mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost:1/", http.StatusFound)
+	})
+
+	policy := &fetch.Policy{
+		AllowedHosts:    []string{"127.0.0.1"},
+		AllowInsecure:   true,
+		MaxResponseSize: 5,
+	}
+	err := fetch.DefineWithPolicy(s.vm, s.loop, func() *fetch.Policy { return policy })
+	s.NoError(err)
+
+	results := make(chan string)
+	err = s.vm.Set("__capture", func(str string) {
+		results <- str
+	})
+	s.NoError(err)
+
+	fetchURL := func(url string) string {
+		err := s.loop.Eval(`fetch('` + url + `').then(function(r) {
+			return r.text();
+		}).then(__capture).catch(function(e) {
+			__capture(e.message);
+		})`)
+		s.NoError(err)
+
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(1 * time.Second):
+			s.Fail("test timed out")
+			return ""
+		}
+	}
+
+	s.Equal("hello", fetchURL(s.srv.URL))
+	s.Equal("response is larger than 5 bytes", fetchURL(s.srv.URL+"/large"))
+	s.Contains(fetchURL(s.srv.URL+"/redirect"), "requests to localhost are not allowed")
+	s.Equal("requests to localhost are not allowed", fetchURL(strings.Replace(s.srv.URL, "127.0.0.1", "localhost", 1)))
+
+	// the policy is requested for each request
+	policy = &fetch.Policy{AllowedHosts: []string{"127.0.0.1"}}
+	s.Equal(fetch.ErrInsecureRequest.Error(), fetchURL(s.srv.URL))
+
+	policy = &fetch.Policy{AllowedHosts: []string{"*.example.com"}, AllowInsecure: true}
+	s.Equal("requests to 127.0.0.1 are not allowed", fetchURL(s.srv.URL))
+}
+
 type FetchSuite struct {
 	suite.Suite
 
@@ -0,0 +1,108 @@
+package fetch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInsecureRequest is returned when a request over plain HTTP is made and the policy doesn't allow it.
+	ErrInsecureRequest = errors.New("only HTTPS requests are allowed")
+
+	// ErrTooManyRedirects is returned when a request is redirected too many times.
+	ErrTooManyRedirects = errors.New("too many redirects")
+)
+
+// maxRedirects is how many times a request is redirected at most, as the default client does
+const maxRedirects = 10
+
+// Policy restricts requests made with fetch. A nil policy allows any request.
+type Policy struct {
+	// AllowedHosts are hosts requests can be made to, e.g. "api.example.com", or "*.example.com" for
+	// its subdomains. Requests to other hosts fail.
+	AllowedHosts []string
+
+	// AllowInsecure lets requests be made over plain HTTP, only HTTPS requests are allowed otherwise.
+	AllowInsecure bool
+
+	// Timeout limits how long a request can take, including reading the response, if it's not zero.
+	Timeout time.Duration
+
+	// MaxResponseSize limits how large a response body can be, in bytes, if it's not zero.
+	MaxResponseSize int64
+}
+
+// check returns an error if a request to the URL is not allowed.
+func (p *Policy) check(u *url.URL) error {
+	if p == nil {
+		return nil
+	}
+
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !p.AllowInsecure {
+			return ErrInsecureRequest
+		}
+	default:
+		return fmt.Errorf("unsupported protocol %q", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range p.AllowedHosts {
+		if hostMatches(host, strings.ToLower(allowed)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("requests to %s are not allowed", host)
+}
+
+// hostMatches reports whether a host is an allowed one, or its subdomain if a wildcard is allowed.
+func hostMatches(host, allowed string) bool {
+	if strings.HasPrefix(allowed, "*.") {
+		return strings.HasSuffix(host, allowed[1:])
+	}
+
+	return host == allowed
+}
+
+// client returns an HTTP client making requests allowed by the policy, including redirects.
+func (p *Policy) client() *http.Client {
+	if p == nil {
+		return http.DefaultClient
+	}
+
+	return &http.Client{
+		Timeout: p.Timeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return ErrTooManyRedirects
+			}
+			return p.check(req.URL)
+		},
+	}
+}
+
+// readBody reads a response body, which fails if it's larger than the policy allows.
+func (p *Policy) readBody(body io.Reader) ([]byte, error) {
+	if p == nil || p.MaxResponseSize <= 0 {
+		return ioutil.ReadAll(body)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(body, p.MaxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > p.MaxResponseSize {
+		return nil, fmt.Errorf("response is larger than %d bytes", p.MaxResponseSize)
+	}
+
+	return data, nil
+}
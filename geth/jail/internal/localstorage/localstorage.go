@@ -0,0 +1,257 @@
+// Package localstorage implements localStorage of a VM, kept in a file so that it persists across restarts.
+package localstorage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+// ErrQuotaExceeded is returned when an item doesn't fit into the quota of a store.
+var ErrQuotaExceeded = errors.New("storage quota has been exceeded")
+
+// Store is a key/value storage kept in a file, or in memory if it has no file. Items are written
+// to the file as soon as they are changed.
+type Store struct {
+	mu    sync.RWMutex
+	path  string
+	quota int // bytes of keys and values
+	size  int
+	items map[string]string
+}
+
+// Open loads a store from a file, which is created once an item is set, if it doesn't exist.
+// A store with an empty path is kept in memory. Keys and values of items can take a quota of bytes.
+func Open(path string, quota int) (*Store, error) {
+	s := &Store{
+		path:  path,
+		quota: quota,
+		items: make(map[string]string),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.items); err != nil {
+		return nil, fmt.Errorf("failed to load storage %s: %v", path, err)
+	}
+	for key, value := range s.items {
+		s.size += len(key) + len(value)
+	}
+
+	return s, nil
+}
+
+// GetItem returns a value of an item, and whether the item exists.
+func (s *Store) GetItem(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.items[key]
+	return value, ok
+}
+
+// SetItem sets a value of an item, unless the store would exceed its quota.
+func (s *Store) SetItem(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	size := s.size + len(key) + len(value)
+	if old, ok := s.items[key]; ok {
+		size -= len(key) + len(old)
+	}
+	if size > s.quota {
+		return ErrQuotaExceeded
+	}
+
+	s.items[key] = value
+	s.size = size
+
+	return s.save()
+}
+
+// RemoveItem removes an item, if it exists.
+func (s *Store) RemoveItem(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.items[key]
+	if !ok {
+		return nil
+	}
+
+	delete(s.items, key)
+	s.size -= len(key) + len(value)
+
+	return s.save()
+}
+
+// Clear removes all items, along with the file of the store.
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.items = make(map[string]string)
+	s.size = 0
+
+	if s.path == "" {
+		return nil
+	}
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// Items returns a copy of items.
+func (s *Store) Items() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := make(map[string]string, len(s.items))
+	for key, value := range s.items {
+		items[key] = value
+	}
+
+	return items
+}
+
+// InMemory reports whether a store is kept in memory only, having no file.
+func (s *Store) InMemory() bool {
+	return s.path == ""
+}
+
+// Keys returns keys of items in order.
+func (s *Store) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.items))
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// save writes items to the file, if any. The file is replaced at once, so that it's not left
+// truncated if the application is killed while it's being written.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(s.items)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// localStorageCode wraps functions of a store into an object with methods of the Web Storage API
+const localStorageCode = `(function(getItem, setItem, removeItem, clear, key, length) {
+	var storage = {
+		getItem: function(k) { return getItem(String(k)); },
+		setItem: function(k, v) { setItem(String(k), String(v)); },
+		removeItem: function(k) { removeItem(String(k)); },
+		clear: function() { clear(); },
+		key: function(i) { return key(Number(i)); }
+	};
+	Object.defineProperty(storage, 'length', {get: function() { return length(); }});
+	return storage;
+})`
+
+// Define localStorage backed by a store. Failures to write the store are thrown,
+// exceeding its quota as a QuotaExceededError, as browsers do.
+func Define(vm *vm.VM, store *Store) error {
+	throw := func(call otto.FunctionCall, err error) {
+		if err == ErrQuotaExceeded {
+			panic(call.Otto.MakeCustomError("QuotaExceededError", err.Error()))
+		}
+		panic(call.Otto.MakeCustomError("Error", err.Error()))
+	}
+
+	getItem := func(call otto.FunctionCall) otto.Value {
+		value, ok := store.GetItem(call.Argument(0).String())
+		if !ok {
+			return otto.NullValue()
+		}
+		return toValue(call, value)
+	}
+
+	setItem := func(call otto.FunctionCall) otto.Value {
+		if err := store.SetItem(call.Argument(0).String(), call.Argument(1).String()); err != nil {
+			throw(call, err)
+		}
+		return otto.UndefinedValue()
+	}
+
+	removeItem := func(call otto.FunctionCall) otto.Value {
+		if err := store.RemoveItem(call.Argument(0).String()); err != nil {
+			throw(call, err)
+		}
+		return otto.UndefinedValue()
+	}
+
+	clear := func(call otto.FunctionCall) otto.Value {
+		if err := store.Clear(); err != nil {
+			throw(call, err)
+		}
+		return otto.UndefinedValue()
+	}
+
+	key := func(call otto.FunctionCall) otto.Value {
+		index, err := call.Argument(0).ToInteger()
+		keys := store.Keys()
+		if err != nil || index < 0 || index >= int64(len(keys)) {
+			return otto.NullValue()
+		}
+		return toValue(call, keys[index])
+	}
+
+	length := func(call otto.FunctionCall) otto.Value {
+		return toValue(call, len(store.Keys()))
+	}
+
+	storage, err := vm.Call(localStorageCode, nil, getItem, setItem, removeItem, clear, key, length)
+	if err != nil {
+		return err
+	}
+
+	return vm.Set("localStorage", storage)
+}
+
+func toValue(call otto.FunctionCall, value interface{}) otto.Value {
+	v, err := call.Otto.ToValue(value)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
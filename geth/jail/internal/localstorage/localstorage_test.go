@@ -0,0 +1,95 @@
+package localstorage_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/status-im/status-go/geth/jail/internal/localstorage"
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+	"github.com/stretchr/testify/suite"
+)
+
+func (s *LocalStorageSuite) TestItems() {
+	_, err := s.vm.Run(`
+		localStorage.setItem('a', 1);
+		localStorage.setItem('b', 'two');
+		localStorage.removeItem('missing');
+	`)
+	s.NoError(err)
+
+	s.run(`localStorage.getItem('a')`, "1")
+	s.run(`localStorage.getItem('missing')`, "null")
+	s.run(`localStorage.length`, "2")
+	s.run(`localStorage.key(1)`, "b")
+	s.run(`localStorage.key(2)`, "null")
+
+	// items persist in the file
+	store, err := localstorage.Open(s.path, 100)
+	s.NoError(err)
+	value, ok := store.GetItem("b")
+	s.True(ok)
+	s.Equal("two", value)
+
+	s.run(`localStorage.removeItem('a'); localStorage.length`, "1")
+	s.run(`localStorage.clear(); localStorage.length`, "0")
+	_, err = os.Stat(s.path)
+	s.True(os.IsNotExist(err))
+}
+
+func (s *LocalStorageSuite) TestQuota() {
+	// keys and values take 100 bytes at most
+	s.run(`localStorage.setItem('key', new Array(98).join('x')); localStorage.length`, "1")
+
+	_, err := s.vm.Run(`localStorage.setItem('other', 'x')`)
+	s.Error(err)
+	s.Contains(err.Error(), "QuotaExceededError")
+
+	// replacing an item counts its new size only
+	s.run(`localStorage.setItem('key', 'x'); localStorage.setItem('other', 'x'); localStorage.length`, "2")
+}
+
+func (s *LocalStorageSuite) TestMemoryStore() {
+	store, err := localstorage.Open("", 100)
+	s.NoError(err)
+	s.NoError(store.SetItem("a", "1"))
+	s.Equal([]string{"a"}, store.Keys())
+	s.NoError(store.Clear())
+	s.Empty(store.Keys())
+}
+
+func (s *LocalStorageSuite) run(code, expected string) {
+	value, err := s.vm.Run(code)
+	s.NoError(err)
+	s.Equal(expected, value.String())
+}
+
+type LocalStorageSuite struct {
+	suite.Suite
+
+	dir  string
+	path string
+	vm   *vm.VM
+}
+
+func (s *LocalStorageSuite) SetupTest() {
+	dir, err := ioutil.TempDir("", "localstorage")
+	s.NoError(err)
+	s.dir = dir
+	s.path = filepath.Join(dir, "storage", "cell.json")
+
+	store, err := localstorage.Open(s.path, 100)
+	s.NoError(err)
+
+	s.vm = vm.New()
+	s.NoError(localstorage.Define(s.vm, store))
+}
+
+func (s *LocalStorageSuite) TearDownTest() {
+	os.RemoveAll(s.dir) //nolint: errcheck
+}
+
+func TestLocalStorageSuite(t *testing.T) {
+	suite.Run(t, new(LocalStorageSuite))
+}
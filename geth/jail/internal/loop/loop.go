@@ -40,6 +40,11 @@ type Loop struct {
 	lock  sync.RWMutex
 	tasks map[int64]Task
 	ready chan Task
+
+	// closed when the loop stops running, so that tasks becoming ready afterwards don't block
+	stopped chan struct{}
+
+	onError func(err error) // called with errors of tasks, which don't stop the loop
 }
 
 // New creates a new Loop with an unbuffered ready queue on a specific VM.
@@ -51,9 +56,10 @@ func New(vm *vm.VM) *Loop {
 // queue, the capacity of which being specified by the backlog argument.
 func NewWithBacklog(vm *vm.VM, backlog int) *Loop {
 	return &Loop{
-		vm:    vm,
-		tasks: make(map[int64]Task),
-		ready: make(chan Task, backlog),
+		vm:      vm,
+		tasks:   make(map[int64]Task),
+		ready:   make(chan Task, backlog),
+		stopped: make(chan struct{}),
 	}
 }
 
@@ -62,6 +68,25 @@ func (l *Loop) VM() *vm.VM {
 	return l.vm
 }
 
+// SetErrorHandler sets a function called with errors returned by tasks, e.g. exceptions thrown by
+// callbacks of timers. It must be set before the loop is run.
+func (l *Loop) SetErrorHandler(fn func(err error)) {
+	l.onError = fn
+}
+
+// Tasks returns tasks which are in the loop, waiting to become ready.
+func (l *Loop) Tasks() []Task {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	tasks := make([]Task, 0, len(l.tasks))
+	for _, t := range l.tasks {
+		tasks = append(tasks, t)
+	}
+
+	return tasks
+}
+
 // Add puts a task into the loop. This signals to the loop that this task is
 // doing something outside of the JavaScript environment, and that at some
 // point, it will become ready for finalising.
@@ -100,9 +125,13 @@ func (l *Loop) removeAll() {
 }
 
 // Ready signals to the loop that a task is ready to be finalised. This might
-// block if the "ready channel" in the loop is at capacity.
+// block if the "ready channel" in the loop is at capacity. Tasks becoming ready
+// after the loop stopped, e.g. timers firing as the loop is stopped, are dropped.
 func (l *Loop) Ready(t Task) {
-	l.ready <- t
+	select {
+	case l.ready <- t:
+	case <-l.stopped:
+	}
 }
 
 // Eval executes some code in the VM associated with the loop and returns an
@@ -129,8 +158,11 @@ func (l *Loop) processTask(t Task) error {
 }
 
 // Run handles the task scheduling and finalisation.
-// It runs infinitely waiting for new tasks.
+// It runs infinitely waiting for new tasks, until the context is cancelled,
+// cancelling tasks which are not finalised yet. A loop can't be run again.
 func (l *Loop) Run(ctx context.Context) error {
+	defer close(l.stopped)
+
 	for {
 		select {
 		case t := <-l.ready:
@@ -145,10 +177,11 @@ func (l *Loop) Run(ctx context.Context) error {
 
 			err := l.processTask(t)
 			if err != nil {
-				// TODO(divan): do we need to report
-				// errors up to the caller?
-				// Ignoring for now, as loop
-				// should keep running.
+				// errors are reported to the handler,
+				// as the loop should keep running.
+				if l.onError != nil {
+					l.onError(err)
+				}
 				continue
 			}
 		case <-ctx.Done():
@@ -120,9 +120,9 @@ func (c CallTask) Execute(vm *vm.VM, l *loop.Loop) error {
 	// vm is not used directly here, but underlying
 	// FunctionCall in CallTask likely does use it,
 	// so we must to guard it here
-	vm.Lock()
-	v, err := c.Function.Call(otto.NullValue(), c.Args...)
-	vm.Unlock()
+	v, err := vm.Do(func() (otto.Value, error) {
+		return c.Function.Call(otto.NullValue(), c.Args...)
+	})
 
 	c.Value <- v
 	c.Error <- err
@@ -0,0 +1,119 @@
+// Package modules implements require of CommonJS modules registered by the host app, so that DApps can
+// split their code into modules. Only registered modules can be required, and only if their code matches
+// hashes supplied by the host app.
+package modules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+// ErrHashMismatch is returned when code of a module being registered doesn't match its hash.
+var ErrHashMismatch = errors.New("module code doesn't match its hash")
+
+// ErrNoName is returned when a module being registered has no name.
+var ErrNoName = errors.New("module name is empty")
+
+// Registry keeps code of modules which can be required, by name.
+type Registry struct {
+	mu      sync.RWMutex
+	modules map[string]string
+}
+
+// NewRegistry returns a registry without modules.
+func NewRegistry() *Registry {
+	return &Registry{modules: make(map[string]string)}
+}
+
+// Register adds a module, if the hex-encoded SHA-256 hash of its code is the given hash. A module registered
+// again gets the new code, which VMs which required the module already don't see until they're created again.
+func (r *Registry) Register(name, code, hash string) error {
+	if name == "" {
+		return ErrNoName
+	}
+
+	sum := sha256.Sum256([]byte(code))
+	if hex.EncodeToString(sum[:]) != strings.ToLower(strings.TrimPrefix(hash, "0x")) {
+		return ErrHashMismatch
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.modules[name] = code
+
+	return nil
+}
+
+// Unregister removes a module.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.modules, name)
+}
+
+// code returns code of a module.
+func (r *Registry) code(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	code, ok := r.modules[name]
+	return code, ok
+}
+
+// requireCode wraps a function returning code of a module into require, which evaluates a module once
+// and caches its exports. A module is cached before it's evaluated, so that cyclic requires get exports
+// the module has defined so far, as in Node.js.
+const requireCode = `(function(code) {
+	var cache = {};
+	function require(name) {
+		name = String(name);
+		if (Object.prototype.hasOwnProperty.call(cache, name)) {
+			return cache[name].exports;
+		}
+		var factory = new Function('module', 'exports', 'require', code(name));
+		var module = {id: name, exports: {}};
+		cache[name] = module;
+		try {
+			factory.call(module.exports, module, module.exports, require);
+		} catch (e) {
+			delete cache[name];
+			throw e;
+		}
+		return module.exports;
+	}
+	return require;
+})`
+
+// Define require of modules of a registry. Exports of modules are cached in the VM, so modules
+// are evaluated once per VM. Requiring a module which is not registered throws an Error.
+func Define(vm *vm.VM, registry *Registry) error {
+	code := func(call otto.FunctionCall) otto.Value {
+		name := call.Argument(0).String()
+		code, ok := registry.code(name)
+		if !ok {
+			panic(call.Otto.MakeCustomError("Error", "Cannot find module '"+name+"'"))
+		}
+
+		value, err := call.Otto.ToValue(code)
+		if err != nil {
+			panic(err)
+		}
+		return value
+	}
+
+	require, err := vm.Call(requireCode, nil, code)
+	if err != nil {
+		return err
+	}
+
+	return vm.Set("require", require)
+}
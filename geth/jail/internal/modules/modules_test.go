@@ -0,0 +1,68 @@
+package modules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+func hash(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func register(t *testing.T, registry *Registry, name, code string) {
+	require.NoError(t, registry.Register(name, code, hash(code)))
+}
+
+func TestRegister(t *testing.T) {
+	registry := NewRegistry()
+
+	code := `module.exports = 42`
+	require.Equal(t, ErrHashMismatch, registry.Register("answer", code, hash("tampered")))
+	require.Equal(t, ErrNoName, registry.Register("", code, hash(code)))
+	require.NoError(t, registry.Register("answer", code, "0x"+hash(code)))
+
+	_, ok := registry.code("answer")
+	require.True(t, ok)
+	registry.Unregister("answer")
+	_, ok = registry.code("answer")
+	require.False(t, ok)
+}
+
+func TestRequire(t *testing.T) {
+	registry := NewRegistry()
+	register(t, registry, "counter", `var count = 0; exports.next = function () { return ++count }`)
+	register(t, registry, "a", `exports.name = 'a'; exports.b = require('b').name`)
+	register(t, registry, "b", `exports.name = 'b'; exports.a = require('a').name`)
+	register(t, registry, "broken", `throw new Error('broken module')`)
+
+	o := vm.New()
+	require.NoError(t, Define(o, registry))
+
+	// modules are evaluated once per VM
+	value, err := o.Run(`require('counter').next(); require('counter').next()`)
+	require.NoError(t, err)
+	require.Equal(t, "2", value.String())
+
+	other := vm.New()
+	require.NoError(t, Define(other, registry))
+	value, err = other.Run(`require('counter').next()`)
+	require.NoError(t, err)
+	require.Equal(t, "1", value.String())
+
+	// a cyclic require gets exports defined so far
+	value, err = o.Run(`var a = require('a'); a.b + require('b').a`)
+	require.NoError(t, err)
+	require.Equal(t, "ba", value.String())
+
+	_, err = o.Run(`require('missing')`)
+	require.EqualError(t, err, "Error: Cannot find module 'missing'")
+
+	_, err = o.Run(`require('broken')`)
+	require.EqualError(t, err, "Error: broken module")
+}
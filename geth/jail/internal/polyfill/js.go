@@ -0,0 +1,266 @@
+package polyfill
+
+const src = `(function (global) {
+  'use strict';
+
+  // methods are defined as non-enumerable, so that they don't show up in for-in loops
+  function define(object, name, value) {
+    if (object[name] === undefined) {
+      Object.defineProperty(object, name, {
+        value: value,
+        configurable: true,
+        enumerable: false,
+        writable: true
+      });
+    }
+  }
+
+  function toInteger(value) {
+    var number = Number(value);
+    if (isNaN(number)) {
+      return 0;
+    }
+    if (number === 0 || !isFinite(number)) {
+      return number;
+    }
+    return (number > 0 ? 1 : -1) * Math.floor(Math.abs(number));
+  }
+
+  function toLength(value) {
+    var length = toInteger(value);
+    if (length <= 0) {
+      return 0;
+    }
+    return Math.min(length, 9007199254740991);
+  }
+
+  function toObject(value) {
+    if (value === null || value === undefined) {
+      throw new TypeError('Cannot convert undefined or null to object');
+    }
+    return Object(value);
+  }
+
+  // Object
+
+  define(Object, 'assign', function assign(target) {
+    var to = toObject(target);
+    for (var i = 1; i < arguments.length; i++) {
+      var source = arguments[i];
+      if (source === null || source === undefined) {
+        continue;
+      }
+      for (var key in source) {
+        if (Object.prototype.hasOwnProperty.call(source, key)) {
+          to[key] = source[key];
+        }
+      }
+    }
+    return to;
+  });
+
+  define(Object, 'is', function is(x, y) {
+    if (x === y) {
+      return x !== 0 || 1 / x === 1 / y;
+    }
+    return x !== x && y !== y;
+  });
+
+  define(Object, 'values', function values(object) {
+    var o = toObject(object);
+    return Object.keys(o).map(function (key) {
+      return o[key];
+    });
+  });
+
+  define(Object, 'entries', function entries(object) {
+    var o = toObject(object);
+    return Object.keys(o).map(function (key) {
+      return [key, o[key]];
+    });
+  });
+
+  // Array
+
+  define(Array, 'from', function from(arrayLike, mapFn, thisArg) {
+    if (mapFn !== undefined && typeof mapFn !== 'function') {
+      throw new TypeError('Array.from: when provided, the second argument must be a function');
+    }
+    var items = toObject(arrayLike);
+    if (typeof items === 'string' || items instanceof String) {
+      items = String(items).split('');
+    }
+    var length = toLength(items.length);
+    var result = new Array(length);
+    for (var i = 0; i < length; i++) {
+      result[i] = mapFn ? mapFn.call(thisArg, items[i], i) : items[i];
+    }
+    return result;
+  });
+
+  define(Array, 'of', function of() {
+    return Array.prototype.slice.call(arguments);
+  });
+
+  define(Array.prototype, 'find', function find(predicate, thisArg) {
+    var index = this.findIndex(predicate, thisArg);
+    return index === -1 ? undefined : this[index];
+  });
+
+  define(Array.prototype, 'findIndex', function findIndex(predicate, thisArg) {
+    if (typeof predicate !== 'function') {
+      throw new TypeError('predicate must be a function');
+    }
+    var o = toObject(this);
+    var length = toLength(o.length);
+    for (var i = 0; i < length; i++) {
+      if (predicate.call(thisArg, o[i], i, o)) {
+        return i;
+      }
+    }
+    return -1;
+  });
+
+  define(Array.prototype, 'includes', function includes(searchElement, fromIndex) {
+    var o = toObject(this);
+    var length = toLength(o.length);
+    var start = toInteger(fromIndex);
+    for (var i = start >= 0 ? start : Math.max(length + start, 0); i < length; i++) {
+      if (o[i] === searchElement || (searchElement !== searchElement && o[i] !== o[i])) {
+        return true;
+      }
+    }
+    return false;
+  });
+
+  define(Array.prototype, 'fill', function fill(value, start, end) {
+    var o = toObject(this);
+    var length = toLength(o.length);
+    var from = toInteger(start);
+    var to = end === undefined ? length : toInteger(end);
+    from = from < 0 ? Math.max(length + from, 0) : Math.min(from, length);
+    to = to < 0 ? Math.max(length + to, 0) : Math.min(to, length);
+    for (var i = from; i < to; i++) {
+      o[i] = value;
+    }
+    return o;
+  });
+
+  // String
+
+  define(String.prototype, 'startsWith', function startsWith(search, position) {
+    var s = String(toObject(this));
+    var start = Math.max(toInteger(position), 0);
+    return s.substr(start, String(search).length) === String(search);
+  });
+
+  define(String.prototype, 'endsWith', function endsWith(search, position) {
+    var s = String(toObject(this));
+    var end = position === undefined ? s.length : Math.min(Math.max(toInteger(position), 0), s.length);
+    var searchString = String(search);
+    var start = end - searchString.length;
+    return start >= 0 && s.slice(start, end) === searchString;
+  });
+
+  define(String.prototype, 'includes', function includes(search, position) {
+    return String(toObject(this)).indexOf(String(search), position) !== -1;
+  });
+
+  define(String.prototype, 'repeat', function repeat(count) {
+    var s = String(toObject(this));
+    var n = toInteger(count);
+    if (n < 0 || n === Infinity) {
+      throw new RangeError('Invalid count value');
+    }
+    var result = '';
+    for (var i = 0; i < n; i++) {
+      result += s;
+    }
+    return result;
+  });
+
+  function pad(s, maxLength, fillString) {
+    var length = toLength(maxLength);
+    var filler = fillString === undefined ? ' ' : String(fillString);
+    if (length <= s.length || filler === '') {
+      return '';
+    }
+    var padding = filler.repeat(Math.ceil((length - s.length) / filler.length));
+    return padding.slice(0, length - s.length);
+  }
+
+  define(String.prototype, 'padStart', function padStart(maxLength, fillString) {
+    var s = String(toObject(this));
+    return pad(s, maxLength, fillString) + s;
+  });
+
+  define(String.prototype, 'padEnd', function padEnd(maxLength, fillString) {
+    var s = String(toObject(this));
+    return s + pad(s, maxLength, fillString);
+  });
+
+  // Number and Math
+
+  define(Number, 'EPSILON', Math.pow(2, -52));
+  define(Number, 'MAX_SAFE_INTEGER', 9007199254740991);
+  define(Number, 'MIN_SAFE_INTEGER', -9007199254740991);
+  define(Number, 'parseFloat', parseFloat);
+  define(Number, 'parseInt', parseInt);
+
+  define(Number, 'isFinite', function isFinite(value) {
+    return typeof value === 'number' && global.isFinite(value);
+  });
+
+  define(Number, 'isNaN', function isNaN(value) {
+    return typeof value === 'number' && value !== value;
+  });
+
+  define(Number, 'isInteger', function isInteger(value) {
+    return Number.isFinite(value) && Math.floor(value) === value;
+  });
+
+  define(Number, 'isSafeInteger', function isSafeInteger(value) {
+    return Number.isInteger(value) && Math.abs(value) <= Number.MAX_SAFE_INTEGER;
+  });
+
+  define(Math, 'sign', function sign(x) {
+    var number = Number(x);
+    if (number === 0 || number !== number) {
+      return number;
+    }
+    return number > 0 ? 1 : -1;
+  });
+
+  define(Math, 'trunc', function trunc(x) {
+    var number = Number(x);
+    return number < 0 ? Math.ceil(number) : Math.floor(number);
+  });
+
+  define(Math, 'log2', function log2(x) {
+    return Math.log(x) / Math.LN2;
+  });
+
+  define(Math, 'log10', function log10(x) {
+    return Math.log(x) / Math.LN10;
+  });
+
+  // Promise, if it's defined
+
+  if (typeof global.Promise === 'function') {
+    define(global.Promise.prototype, 'finally', function (onFinally) {
+      if (typeof onFinally !== 'function') {
+        return this.then(onFinally, onFinally);
+      }
+      return this.then(function (value) {
+        return global.Promise.resolve(onFinally()).then(function () {
+          return value;
+        });
+      }, function (reason) {
+        return global.Promise.resolve(onFinally()).then(function () {
+          throw reason;
+        });
+      });
+    });
+  }
+})(this);
+`
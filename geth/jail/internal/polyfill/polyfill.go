@@ -0,0 +1,21 @@
+// Package polyfill defines methods of built-in objects added by ES6 and later, e.g. Object.assign,
+// Array.prototype.find or String.prototype.startsWith, which otto, supporting ES5 only, lacks.
+// Syntax added by ES6, e.g. let, const, arrow functions or classes, can't be polyfilled, so code
+// using it must be transpiled to ES5.
+package polyfill
+
+import (
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+// Define defines ES6 methods of built-in objects which are not defined in a VM yet.
+// Promise.prototype.finally is defined only if Promise is defined before.
+func Define(vm *vm.VM) error {
+	s, err := vm.Compile("polyfill.js", src)
+	if err != nil {
+		return err
+	}
+
+	_, err = vm.Run(s)
+	return err
+}
@@ -0,0 +1,82 @@
+package polyfill_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/status-im/status-go/geth/jail/internal/loop"
+	"github.com/status-im/status-go/geth/jail/internal/polyfill"
+	"github.com/status-im/status-go/geth/jail/internal/promise"
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+)
+
+func TestPolyfill(t *testing.T) {
+	o := vm.New()
+	require.NoError(t, polyfill.Define(o))
+
+	testCases := []struct {
+		code     string
+		expected string
+	}{
+		{`JSON.stringify(Object.assign({a: 1}, null, {b: 2}, {a: 3}))`, `{"a":3,"b":2}`},
+		{`JSON.stringify(Object.entries({a: 1, b: 'x'}))`, `[["a",1],["b","x"]]`},
+		{`JSON.stringify(Object.values({a: 1, b: 'x'}))`, `[1,"x"]`},
+		{`Object.is(NaN, NaN) && !Object.is(0, -0)`, `true`},
+		{`JSON.stringify(Array.from('abc'))`, `["a","b","c"]`},
+		{`JSON.stringify(Array.from({length: 3}, function (v, i) { return i * 2 }))`, `[0,2,4]`},
+		{`JSON.stringify(Array.of(7, 8))`, `[7,8]`},
+		{`[1, 5, 10].find(function (x) { return x > 3 })`, `5`},
+		{`[1, 5, 10].findIndex(function (x) { return x > 30 })`, `-1`},
+		{`[1, NaN].includes(NaN) && ![1, 2].includes(1, 1)`, `true`},
+		{`JSON.stringify([1, 2, 3, 4].fill(0, 1, -1))`, `[1,0,0,4]`},
+		{`'status'.startsWith('tat', 1) && 'status'.endsWith('tat', 4) && 'status'.includes('tu')`, `true`},
+		{`'ab'.repeat(3)`, `ababab`},
+		{`'5'.padStart(3, '0') + '|' + 'x'.padEnd(4, 'ab')`, `005|xaba`},
+		{`Number.isInteger(5) && !Number.isInteger(5.5) && !Number.isNaN('NaN') && Number.isSafeInteger(Math.pow(2, 53) - 1)`, `true`},
+		{`Math.sign(-3) + Math.trunc(-4.7) + Math.round(Math.log10(1000) + Math.log2(8))`, `1`},
+	}
+
+	for _, tc := range testCases {
+		value, err := o.Run(tc.code)
+		require.NoError(t, err, tc.code)
+		require.Equal(t, tc.expected, value.String(), tc.code)
+	}
+
+	// polyfilled methods are not enumerable
+	value, err := o.Run(`var keys = []; for (var key in [1, 2]) { keys.push(key) }; keys.join(',')`)
+	require.NoError(t, err)
+	require.Equal(t, "0,1", value.String())
+}
+
+func TestPromiseFinally(t *testing.T) {
+	o := vm.New()
+	l := loop.New(o)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go l.Run(ctx) //nolint: errcheck
+
+	require.NoError(t, promise.Define(o, l))
+	require.NoError(t, polyfill.Define(o))
+
+	done := make(chan string, 1)
+	require.NoError(t, o.Set("__done", func(result string) {
+		done <- result
+	}))
+
+	require.NoError(t, l.Eval(`
+		var cleaned = false;
+		Promise.reject('bad')
+			.finally(function () { cleaned = true })
+			.catch(function (err) { __done(err + ':' + cleaned) });
+	`))
+
+	select {
+	case result := <-done:
+		require.Equal(t, "bad:true", result)
+	case <-time.After(time.Second):
+		require.Fail(t, "promise was not settled")
+	}
+}
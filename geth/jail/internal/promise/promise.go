@@ -1,6 +1,7 @@
 package promise
 
 import (
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/jail/internal/loop"
 	"github.com/status-im/status-go/geth/jail/internal/timers"
 	"github.com/status-im/status-go/geth/jail/internal/vm"
@@ -14,7 +15,8 @@ func Define(vm *vm.VM, l *loop.Loop) error {
 		return nil
 	}
 
-	if err := timers.Define(vm, l); err != nil {
+	// no-op if timers have been already defined with another clock
+	if err := timers.Define(vm, l, common.SystemClock); err != nil {
 		return err
 	}
 
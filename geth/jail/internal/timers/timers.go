@@ -1,27 +1,101 @@
 package timers
 
 import (
+	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/robertkrimen/otto"
 
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/jail/internal/loop"
 	"github.com/status-im/status-go/geth/jail/internal/vm"
 )
 
+// MaxTimers is how many timeouts, intervals and immediates can be scheduled in a VM at once,
+// so that a runaway script can't exhaust the memory. Scheduling more throws a RangeError.
+const MaxTimers = 500
+
 var minDelay = map[bool]int64{
 	true:  10,
 	false: 4,
 }
 
-//Define jail timers
-func Define(vm *vm.VM, l *loop.Loop) error {
+// activeTimers are timers scheduled in a VM which haven't completed, been cleared or cancelled yet
+type activeTimers struct {
+	mu     sync.Mutex
+	timers map[*timerTask]struct{}
+}
+
+// add reports whether a timer is added, which it's not once MaxTimers are active.
+func (a *activeTimers) add(t *timerTask) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.timers) >= MaxTimers {
+		return false
+	}
+	a.timers[t] = struct{}{}
+
+	return true
+}
+
+func (a *activeTimers) remove(t *timerTask) {
+	a.mu.Lock()
+	delete(a.timers, t)
+	a.mu.Unlock()
+}
+
+// Timer describes a timer waiting to fire.
+type Timer struct {
+	ID       int64
+	Delay    time.Duration
+	Interval bool
+}
+
+// Pending returns how many timers scheduled in a loop are waiting to fire.
+func Pending(l *loop.Loop) int {
+	return len(List(l))
+}
+
+// List returns timers scheduled in a loop which are waiting to fire, sorted by ID.
+func List(l *loop.Loop) []Timer {
+	var list []Timer
+	for _, t := range l.Tasks() {
+		if timer, ok := t.(*timerTask); ok {
+			list = append(list, Timer{
+				ID:       timer.GetID(),
+				Delay:    timer.duration,
+				Interval: timer.interval,
+			})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+
+	return list
+}
+
+//Define jail timers, scheduled with a given clock
+func Define(vm *vm.VM, l *loop.Loop, clock common.Clock) error {
 	if v, err := vm.Get("setTimeout"); err != nil {
 		return err
 	} else if !v.IsUndefined() {
 		return nil
 	}
 
+	active := &activeTimers{timers: make(map[*timerTask]struct{})}
+
+	// schedule panics with a RangeError thrown by the JS function, if too many timers are active
+	schedule := func(t *timerTask) {
+		if !active.add(t) {
+			panic(t.call.Otto.MakeRangeError(fmt.Sprintf("too many timers, at most %d can be scheduled at once", MaxTimers)))
+		}
+		l.Add(t)
+	}
+
 	newTimer := func(interval bool) func(call otto.FunctionCall) otto.Value {
 		return func(call otto.FunctionCall) otto.Value {
 			delay, _ := call.Argument(1).ToInteger()
@@ -33,10 +107,11 @@ func Define(vm *vm.VM, l *loop.Loop) error {
 				duration: time.Duration(delay) * time.Millisecond,
 				call:     call,
 				interval: interval,
+				active:   active,
 			}
-			l.Add(t)
+			schedule(t)
 
-			t.timer = time.AfterFunc(t.duration, func() {
+			t.timer = clock.AfterFunc(t.duration, func() {
 				l.Ready(t)
 			})
 
@@ -63,10 +138,11 @@ func Define(vm *vm.VM, l *loop.Loop) error {
 		t := &timerTask{
 			duration: time.Millisecond,
 			call:     call,
+			active:   active,
 		}
-		l.Add(t)
+		schedule(t)
 
-		t.timer = time.AfterFunc(t.duration, func() {
+		t.timer = clock.AfterFunc(t.duration, func() {
 			l.Ready(t)
 		})
 
@@ -87,6 +163,7 @@ func Define(vm *vm.VM, l *loop.Loop) error {
 			t.stopped = true
 			t.timer.Stop()
 			l.Remove(t)
+			active.remove(t)
 		}
 
 		return otto.UndefinedValue()
@@ -107,11 +184,12 @@ func Define(vm *vm.VM, l *loop.Loop) error {
 
 type timerTask struct {
 	id       int64
-	timer    *time.Timer
+	timer    common.Timer
 	duration time.Duration
 	interval bool
 	call     otto.FunctionCall
 	stopped  bool
+	active   *activeTimers
 }
 
 func (t *timerTask) SetID(id int64) { t.id = id }
@@ -134,12 +212,15 @@ func (t *timerTask) Execute(vm *vm.VM, l *loop.Loop) error {
 	arguments[0] = t.call.ArgumentList[0]
 
 	if _, err := vm.Call(`Function.call.call`, nil, arguments...); err != nil {
+		t.active.remove(t)
 		return err
 	}
 
 	if t.interval && !t.stopped {
 		t.timer.Reset(t.duration)
 		l.Add(t)
+	} else {
+		t.active.remove(t)
 	}
 
 	return nil
@@ -147,4 +228,5 @@ func (t *timerTask) Execute(vm *vm.VM, l *loop.Loop) error {
 
 func (t *timerTask) Cancel() {
 	t.timer.Stop()
+	t.active.remove(t)
 }
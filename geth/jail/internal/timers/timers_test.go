@@ -2,9 +2,11 @@ package timers_test
 
 import (
 	"context"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/jail/internal/loop"
 	"github.com/status-im/status-go/geth/jail/internal/timers"
 	"github.com/status-im/status-go/geth/jail/internal/vm"
@@ -91,6 +93,28 @@ func (s *TimersSuite) TestClearIntervalImmediately() {
 	<-time.After(100 * time.Millisecond)
 }
 
+func (s *TimersSuite) TestTimersLimit() {
+	err := s.loop.Eval(`
+		var timers = [];
+		for (var i = 0; i < ` + strconv.Itoa(timers.MaxTimers) + `; i++) {
+			timers.push(i % 2 ? setTimeout(function() {}, 10000) : setInterval(function() {}, 10000));
+		}
+	`)
+	s.NoError(err)
+
+	err = s.loop.Eval(`setTimeout(function() {}, 10000);`)
+	s.Error(err)
+	s.Contains(err.Error(), "RangeError: too many timers")
+
+	// cleared timers don't count
+	err = s.loop.Eval(`
+		clearInterval(timers.shift());
+		timers.push(setTimeout(function() {}, 10000));
+		timers.forEach(clearTimeout);
+	`)
+	s.NoError(err)
+}
+
 type TimersSuite struct {
 	suite.Suite
 
@@ -106,7 +130,7 @@ func (s *TimersSuite) SetupTest() {
 
 	go s.loop.Run(context.Background()) //nolint: errcheck
 
-	err := timers.Define(s.vm, s.loop)
+	err := timers.Define(s.vm, s.loop, common.SystemClock)
 	s.NoError(err)
 
 	s.ch = make(chan struct{})
@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInterrupted is returned when an execution of JS code is aborted with Interrupt.
+var ErrInterrupted = errors.New("execution of JS code has been interrupted")
+
+// reasons of an interruption of an execution
+const (
+	interruptNone int32 = iota
+	interruptRequested
+	interruptDuration
+)
+
+// execution is an execution of JS code in progress, which is interrupted from other goroutines:
+// by Interrupt, or by its watchdog once it exceeds the duration limit. The interpreter checks
+// for an interruption before each statement and expression is evaluated.
+type execution struct {
+	interrupt int32 // accessed atomically, the reason of an interruption, if any
+	deadline  int64 // accessed atomically, in Unix nanoseconds
+	done      chan struct{}
+}
+
+func (e *execution) interruption() int32 {
+	return atomic.LoadInt32(&e.interrupt)
+}
+
+func (e *execution) interruptWith(reason int32) {
+	atomic.CompareAndSwapInt32(&e.interrupt, interruptNone, reason)
+}
+
+// extendDeadline moves the deadline of the execution, e.g. by time spent in Idle.
+func (e *execution) extendDeadline(d time.Duration) {
+	atomic.AddInt64(&e.deadline, int64(d))
+}
+
+// watch interrupts the execution once its deadline passes, until it's done.
+func (e *execution) watch() {
+	for {
+		remaining := time.Until(time.Unix(0, atomic.LoadInt64(&e.deadline)))
+		if remaining <= 0 {
+			e.interruptWith(interruptDuration)
+			return
+		}
+
+		select {
+		case <-time.After(remaining):
+		case <-e.done:
+			return
+		}
+	}
+}
+
+// Interrupt aborts the execution of JS code in progress, if any, e.g. a script stuck in an infinite loop,
+// which returns ErrInterrupted. Unlike exceeding limits, it doesn't halt the VM. Go functions called
+// by the execution are not interrupted, it's aborted once they return.
+func (vm *VM) Interrupt() {
+	l := &vm.limiter
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.current != nil {
+		l.current.interruptWith(interruptRequested)
+	}
+}
+
+// start begins an execution, watched if the duration of executions is limited. It's called with the VM locked.
+func (l *limiter) start() {
+	exec := &execution{done: make(chan struct{})}
+	if l.limits.MaxDuration > 0 {
+		exec.deadline = time.Now().Add(l.limits.MaxDuration).UnixNano()
+		go exec.watch()
+	}
+
+	l.mu.Lock()
+	l.current = exec
+	l.mu.Unlock()
+}
+
+// finish ends the execution in progress. It's called with the VM locked.
+func (l *limiter) finish() {
+	l.mu.Lock()
+	exec := l.current
+	l.current = nil
+	l.mu.Unlock()
+
+	close(exec.done)
+}
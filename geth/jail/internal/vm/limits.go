@@ -0,0 +1,182 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
+)
+
+// memoryCheckInterval is how many operations are evaluated between checks of the memory limit,
+// which are rare as reading memory statistics stops the world
+const memoryCheckInterval = 1 << 16
+
+// ErrHalted is returned when JS code is run in a VM halted after an execution exceeded its limits.
+var ErrHalted = errors.New("VM has been halted after exceeding its limits")
+
+// Limit names a limit of an execution of JS code.
+type Limit string
+
+// Limits of executions of JS code.
+const (
+	LimitDuration Limit = "duration"
+	LimitOps      Limit = "ops"
+	LimitMemory   Limit = "memory"
+)
+
+// LimitError is returned when an execution of JS code exceeds a limit of its VM.
+type LimitError struct {
+	Limit Limit
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("execution of JS code has exceeded the %s limit", e.Limit)
+}
+
+// Limits of a single execution of JS code in a VM, e.g. of a script or a callback of a timer.
+// Zero values don't limit executions.
+type Limits struct {
+	// MaxDuration is how long an execution can take, except for time spent in Idle.
+	MaxDuration time.Duration
+
+	// MaxOps is how many statements and expressions an execution can evaluate.
+	MaxOps uint64
+
+	// MaxMemory is how many bytes the heap can grow by during an execution. The heap is shared by the process,
+	// so it's an approximation of memory allocated by the execution, checked every memoryCheckInterval operations.
+	MaxMemory uint64
+}
+
+// limiter tracks an execution of JS code in a VM against its limits
+type limiter struct {
+	limits     Limits
+	onExceeded func(err *LimitError)
+	halted     bool
+
+	executing bool
+	ops       uint64
+	heapBase  uint64 // heap size at the first memory check of the execution
+
+	mu      sync.Mutex
+	current *execution // execution in progress, if any
+}
+
+// SetLimits sets limits of executions of JS code started afterwards. An execution exceeding them is aborted
+// with a LimitError, and the VM is halted: JS code run in it fails with ErrHalted from now on. The callback,
+// if any, is called in a separate goroutine once the VM is halted.
+func (vm *VM) SetLimits(limits Limits, onExceeded func(err *LimitError)) {
+	vm.Lock()
+	defer vm.Unlock()
+
+	vm.limiter.limits = limits
+	vm.limiter.onExceeded = onExceeded
+}
+
+// Idle calls a function, e.g. waiting for a network request, during an execution of JS code without
+// counting its duration towards the duration limit. It must be called from the execution, e.g. from
+// a Go function called by JS code.
+func (vm *VM) Idle(fn func()) {
+	started := time.Now()
+	defer func() {
+		if exec := vm.limiter.current; exec != nil {
+			exec.extendDeadline(time.Since(started))
+		}
+	}()
+
+	fn()
+}
+
+// Do runs a function with the VM locked as an execution of JS code, limited by limits of the VM.
+// JS code must be executed with Do, or with Run or Call, which use it, so that it's not run concurrently
+// and an execution exceeding limits is aborted.
+func (vm *VM) Do(fn func() (otto.Value, error)) (value otto.Value, err error) {
+	vm.Lock()
+	defer vm.Unlock()
+
+	l := &vm.limiter
+	if l.halted {
+		return otto.UndefinedValue(), ErrHalted
+	}
+
+	l.executing = true
+	l.ops = 0
+	l.heapBase = 0
+	l.start()
+
+	defer func() {
+		l.executing = false
+		l.finish()
+
+		caught := recover()
+		if caught == nil {
+			return
+		}
+		if caught == ErrInterrupted {
+			value, err = otto.UndefinedValue(), ErrInterrupted
+			return
+		}
+		limitErr, ok := caught.(*LimitError)
+		if !ok {
+			panic(caught)
+		}
+
+		l.halted = true
+		if l.onExceeded != nil {
+			go l.onExceeded(limitErr)
+		}
+		value, err = otto.UndefinedValue(), limitErr
+	}()
+
+	return fn()
+}
+
+// checkLimits aborts an execution which is interrupted or exceeds limits of the VM. It's called
+// by the interpreter before each statement and expression is evaluated.
+func (vm *VM) checkLimits() {
+	if exec := vm.limiter.current; exec != nil {
+		switch exec.interruption() {
+		case interruptRequested:
+			vm.vm.Interrupt <- vm.checkLimits
+			panic(ErrInterrupted)
+		case interruptDuration:
+			panic(&LimitError{Limit: LimitDuration})
+		}
+	}
+
+	if err := vm.limiter.exceeded(); err != nil {
+		panic(err)
+	}
+
+	vm.vm.Interrupt <- vm.checkLimits
+}
+
+// exceeded counts an operation of an execution and returns an error if a limit is exceeded.
+// Operations evaluated outside of executions, e.g. by a conversion of a value to a string, are not limited.
+func (l *limiter) exceeded() *LimitError {
+	if !l.executing {
+		return nil
+	}
+
+	l.ops++
+	limits := l.limits
+
+	if limits.MaxOps > 0 && l.ops > limits.MaxOps {
+		return &LimitError{Limit: LimitOps}
+	}
+
+	if limits.MaxMemory > 0 && l.ops%memoryCheckInterval == 0 {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+
+		if l.heapBase == 0 || stats.HeapAlloc < l.heapBase {
+			l.heapBase = stats.HeapAlloc
+		} else if stats.HeapAlloc-l.heapBase > limits.MaxMemory {
+			return &LimitError{Limit: LimitMemory}
+		}
+	}
+
+	return nil
+}
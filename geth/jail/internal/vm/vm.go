@@ -11,14 +11,22 @@ import (
 type VM struct {
 	sync.Mutex
 
-	vm *otto.Otto
+	vm      *otto.Otto
+	limiter limiter
 }
 
 // New creates new instance of VM.
 func New() *VM {
-	return &VM{
+	vm := &VM{
 		vm: otto.New(),
 	}
+
+	// every statement and expression evaluated takes the check of interruptions and limits
+	// from the channel, and the check puts itself back
+	vm.vm.Interrupt = make(chan func(), 1)
+	vm.vm.Interrupt <- vm.checkLimits
+
+	return vm
 }
 
 // UnsafeVM returns a thread-unsafe JavaScript VM.
@@ -45,18 +53,16 @@ func (vm *VM) Get(key string) (otto.Value, error) {
 // Call attempts to call the internal call function for the giving response associated with the
 // proper values.
 func (vm *VM) Call(item string, this interface{}, args ...interface{}) (otto.Value, error) {
-	vm.Lock()
-	defer vm.Unlock()
-
-	return vm.vm.Call(item, this, args...)
+	return vm.Do(func() (otto.Value, error) {
+		return vm.vm.Call(item, this, args...)
+	})
 }
 
 // Run evaluates JS source, which may be string or otto.Script variable.
 func (vm *VM) Run(src interface{}) (otto.Value, error) {
-	vm.Lock()
-	defer vm.Unlock()
-
-	return vm.vm.Run(src)
+	return vm.Do(func() (otto.Value, error) {
+		return vm.vm.Run(src)
+	})
 }
 
 // Compile parses given source and returns otto.Script.
@@ -1,15 +1,23 @@
 package jail
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/robertkrimen/otto"
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/jail/internal/crypto"
+	"github.com/status-im/status-go/geth/jail/internal/fetch"
+	"github.com/status-im/status-go/geth/jail/internal/modules"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
 	"github.com/status-im/status-go/static"
 )
 
@@ -24,6 +32,10 @@ const (
 	`
 )
 
+// RPCWorkers is how many asynchronous RPC calls of cells are made at once. Further calls wait
+// for one of them to complete, so that DApps flooding the node with calls can't spawn unbounded requests.
+const RPCWorkers = 16
+
 var (
 	web3Code = string(static.MustAsset("scripts/web3.js"))
 	// ErrNoRPCClient is returned when an RPC client is required but it's nil.
@@ -46,6 +58,23 @@ type Jail struct {
 	baseJS            string
 	cellsMx           sync.RWMutex
 	cells             map[string]*Cell
+	clock             common.Clock
+	storageDir        string // where localStorage of cells is kept, in memory if empty
+	limitsConfig      params.JailLimitsConfig
+	consoleCapture    int32         // accessed atomically, non-zero if messages written with console are captured
+	debug             int32         // accessed atomically, non-zero if JS expressions can be evaluated in cells
+	rpcWorkers        chan struct{} // slots of asynchronous RPC calls being made
+	modules           *modules.Registry
+	whisperFilters    *whisperFilters
+
+	watchMx  sync.Mutex
+	watching bool // true while the loop polling filters watched by cells runs
+
+	fetchMx     sync.RWMutex
+	fetchConfig params.JailFetchConfig
+	fetchHosts  map[string][]string // hosts each cell can fetch from, by chat ID
+
+	signals signal.Sender // sends signals of cells, see SetSignalHandler
 }
 
 // New returns a new Jail.
@@ -59,46 +88,111 @@ func NewWithBaseJS(provider RPCClientProvider, code string) *Jail {
 		rpcClientProvider: provider,
 		baseJS:            code,
 		cells:             make(map[string]*Cell),
+		clock:             common.SystemClock,
+		rpcWorkers:        make(chan struct{}, RPCWorkers),
+		modules:           modules.NewRegistry(),
+		whisperFilters:    newWhisperFilters(),
+		fetchConfig: params.JailFetchConfig{
+			Timeout:         params.JailFetchTimeout,
+			MaxResponseSize: params.JailFetchMaxResponseSize,
+		},
+		fetchHosts: make(map[string][]string),
+		limitsConfig: params.JailLimitsConfig{
+			MaxExecutionTime: params.JailMaxExecutionTime,
+			MaxMemory:        params.JailMaxMemory,
+		},
 	}
 }
 
+// SetSignalHandler sets a handler of signals sent by cells, e.g. the signal handler of the node
+// the jail runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (j *Jail) SetSignalHandler(handler func(signal.Envelope)) {
+	j.signals.SetHandler(handler)
+}
+
+// SetClock replaces a clock used by JS timers of cells created afterwards,
+// e.g. with a suspendable clock or a fake one in tests.
+func (j *Jail) SetClock(clock common.Clock) {
+	j.clock = clock
+}
+
 // SetBaseJS sets initial JavaScript code loaded to each new cell.
 func (j *Jail) SetBaseJS(js string) {
 	j.baseJS = js
 }
 
-// Stop stops jail and all assosiacted cells.
+// Stop stops jail and all assosiacted cells. Cells are stopped at once, outside of the lock of cells,
+// so that cells busy executing JS code don't block the jail meanwhile.
 func (j *Jail) Stop() {
 	j.cellsMx.Lock()
-	defer j.cellsMx.Unlock()
-
-	for _, cell := range j.cells {
-		cell.Stop() //nolint: errcheck
-	}
-
+	cells := j.cells
 	// TODO(tiabc): Move this initialisation to a proper place.
 	j.cells = make(map[string]*Cell)
+	j.cellsMx.Unlock()
+
+	var wg sync.WaitGroup
+	for _, cell := range cells {
+		wg.Add(1)
+		go func(cell *Cell) {
+			defer wg.Done()
+			cell.Stop() //nolint: errcheck
+			j.removeFilters(cell.id)
+		}(cell)
+	}
+	wg.Wait()
 }
 
-// createCell creates a new cell if it does not exists.
+// createCell creates a new cell if it does not exists. The cell is set up outside of the lock of cells,
+// as it runs JS code and loads localStorage, so that other cells can be looked up meanwhile.
 func (j *Jail) createCell(chatID string) (*Cell, error) {
-	j.cellsMx.Lock()
-	defer j.cellsMx.Unlock()
-
-	if cell, ok := j.cells[chatID]; ok {
-		return cell, fmt.Errorf("cell with id '%s' already exists", chatID)
+	j.cellsMx.RLock()
+	existing, ok := j.cells[chatID]
+	storagePath := j.storagePath(chatID)
+	j.cellsMx.RUnlock()
+	if ok {
+		return existing, fmt.Errorf("cell with id '%s' already exists", chatID)
 	}
 
-	cell, err := NewCell(chatID)
+	cell, err := newCell(chatID, j.clock, func() *fetch.Policy {
+		return j.fetchPolicy(chatID)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if err := j.setupCell(cell, storagePath); err != nil {
+		cell.Stop() //nolint: errcheck
+		return nil, err
+	}
+
+	j.cellsMx.Lock()
+	defer j.cellsMx.Unlock()
+
+	// the same cell could be created concurrently
+	if existing, ok := j.cells[chatID]; ok {
+		cell.Stop() //nolint: errcheck
+		return existing, fmt.Errorf("cell with id '%s' already exists", chatID)
+	}
+
+	j.setLimits(cell, j.limitsConfig)
 	j.cells[chatID] = cell
 
 	return cell, nil
 }
 
+// setupCell defines localStorage kept in a file, console and require in a new cell.
+func (j *Jail) setupCell(cell *Cell, storagePath string) error {
+	if err := j.defineStorage(cell, storagePath); err != nil {
+		return err
+	}
+
+	if err := j.defineConsole(cell); err != nil {
+		return err
+	}
+
+	return modules.Define(cell.VM, j.modules)
+}
+
 // CreateCell creates a new cell. It returns an error
 // if a cell with a given ID already exists.
 func (j *Jail) CreateCell(chatID string) (common.JailCell, error) {
@@ -112,7 +206,11 @@ func (j *Jail) initCell(cell *Cell) error {
 		return err
 	}
 
-	if err := registerStatusSignals(cell); err != nil {
+	if err := registerStatusSignals(j, cell); err != nil {
+		return err
+	}
+
+	if err := registerWhisperWatcher(j, cell); err != nil {
 		return err
 	}
 
@@ -138,15 +236,28 @@ func (j *Jail) createAndInitCell(chatID string, code ...string) (*Cell, error) {
 		return nil, err
 	}
 
-	// Run custom user code
+	if err := runUserCode(cell, code); err != nil {
+		return nil, err
+	}
+
+	return cell, nil
+}
+
+// runUserCode runs custom user code in an initialized cell, remembering it, so that the cell
+// can be restarted or restored from a snapshot.
+func runUserCode(cell *Cell, code []string) error {
+	cell.code = code
+
 	for _, js := range code {
 		_, err := cell.Run(js)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
-	return cell, nil
+	// The code may define its own status object, e.g. status.js of bots does,
+	// so status.crypto is defined on it again.
+	return crypto.Define(cell.VM)
 }
 
 // CreateAndInitCell creates and initializes new Cell. Additionally,
@@ -197,6 +308,77 @@ func (j *Jail) Cell(chatID string) (common.JailCell, error) {
 	return j.cell(chatID)
 }
 
+// StopCell stops a cell and removes it with Whisper filters it created. Its localStorage is kept.
+func (j *Jail) StopCell(chatID string) error {
+	cell, err := j.removeCell(chatID)
+	if err != nil {
+		return err
+	}
+
+	err = cell.Stop()
+	j.removeFilters(chatID)
+
+	return err
+}
+
+// RestartCell stops a cell and creates it again with the user code it was initialized with,
+// e.g. to recover a cell wedged by its timers. It returns the response as CreateAndInitCell does.
+func (j *Jail) RestartCell(chatID string) string {
+	cell, err := j.removeCell(chatID)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	if err := cell.Stop(); err != nil {
+		log.Warn("failed to stop a restarted jail cell", "chatID", chatID, "err", err)
+	}
+	j.removeFilters(chatID)
+
+	return j.CreateAndInitCell(chatID, cell.code...)
+}
+
+// Cells returns statuses of cells, sorted by chat ID.
+func (j *Jail) Cells() []common.JailCellStatus {
+	cells := j.cellList()
+
+	statuses := make([]common.JailCellStatus, 0, len(cells))
+	for _, cell := range cells {
+		statuses = append(statuses, cell.Status())
+	}
+	sort.Slice(statuses, func(i, k int) bool {
+		return statuses[i].ChatID < statuses[k].ChatID
+	})
+
+	return statuses
+}
+
+// cellList returns cells, so that they can be iterated over without holding the lock of cells.
+func (j *Jail) cellList() []*Cell {
+	j.cellsMx.RLock()
+	defer j.cellsMx.RUnlock()
+
+	cells := make([]*Cell, 0, len(j.cells))
+	for _, cell := range j.cells {
+		cells = append(cells, cell)
+	}
+
+	return cells
+}
+
+// removeCell removes a cell without stopping it.
+func (j *Jail) removeCell(chatID string) (*Cell, error) {
+	j.cellsMx.Lock()
+	defer j.cellsMx.Unlock()
+
+	cell, ok := j.cells[chatID]
+	if !ok {
+		return nil, fmt.Errorf("cell '%s' not found", chatID)
+	}
+	delete(j.cells, chatID)
+
+	return cell, nil
+}
+
 // Execute allows to run arbitrary JS code within a cell.
 func (j *Jail) Execute(chatID, code string) string {
 	cell, err := j.cell(chatID)
@@ -243,23 +425,39 @@ func (j *Jail) RPCClient() *rpc.Client {
 	return j.rpcClientProvider.RPCClient()
 }
 
-// sendRPCCall executes a raw JSON-RPC request.
-func (j *Jail) sendRPCCall(request string) (interface{}, error) {
+// sendRPCCall executes a raw JSON-RPC request of a cell, which is identified as the origin
+// of the request, e.g. of a queued transaction.
+func (j *Jail) sendRPCCall(cellID, request string) (interface{}, error) {
 	client := j.RPCClient()
 	if client == nil {
 		return nil, ErrNoRPCClient
 	}
 
-	rawResponse := client.CallRaw(request)
+	ctx := context.WithValue(context.Background(), common.ChatIDKey, cellID)
+	rawResponse := client.CallRawContext(ctx, request)
 
 	var response interface{}
 	if err := json.Unmarshal([]byte(rawResponse), &response); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %s", err)
 	}
+	j.whisperFilters.track(cellID, request, response)
 
 	return response, nil
 }
 
+// sendRPCCallAsync executes a raw JSON-RPC request of a cell in the background, once one of RPCWorkers
+// is free, and passes the response to a function, which is called outside of the worker, so that
+// a cell busy executing JS code doesn't hold the worker.
+func (j *Jail) sendRPCCallAsync(cellID, request string, done func(response interface{}, err error)) {
+	go func() {
+		j.rpcWorkers <- struct{}{}
+		response, err := j.sendRPCCall(cellID, request)
+		<-j.rpcWorkers
+
+		done(response, err)
+	}()
+}
+
 // newJailErrorResponse returns an error.
 func newJailErrorResponse(err error) string {
 	response := struct {
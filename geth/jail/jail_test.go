@@ -1,10 +1,23 @@
 package jail
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -116,6 +129,38 @@ func (s *JailTestSuite) TestMakeCatalogVariable() {
 	s.Equal(`{"result": {"test":true}}`, response)
 }
 
+func (s *JailTestSuite) TestCellLifecycle() {
+	defer s.Jail.Stop()
+
+	_, err := s.Jail.createAndInitCell("cell1", `var _status_catalog = {}; var counter = 1`, `setTimeout(function () {}, 60000)`)
+	s.NoError(err)
+	_, err = s.Jail.CreateCell("cell2")
+	s.NoError(err)
+
+	s.Contains(s.Jail.Execute("cell1", `undefinedFunction()`), "ReferenceError")
+
+	cells := s.Jail.Cells()
+	s.Len(cells, 2)
+	s.Equal("cell1", cells[0].ChatID)
+	s.Equal(1, cells[0].PendingTimers)
+	s.Contains(cells[0].LastError, "ReferenceError")
+	s.Equal("cell2", cells[1].ChatID)
+	s.Empty(cells[1].LastError)
+
+	// a restarted cell runs its code again, from scratch
+	s.Equal("1", s.Jail.Execute("cell1", `counter++`))
+	s.NotContains(s.Jail.RestartCell("cell1"), "error")
+	s.Equal("1", s.Jail.Execute("cell1", `counter`))
+	cells = s.Jail.Cells()
+	s.Equal(1, cells[0].PendingTimers)
+	s.Empty(cells[0].LastError)
+
+	s.NoError(s.Jail.StopCell("cell2"))
+	s.Len(s.Jail.Cells(), 1)
+	s.EqualError(s.Jail.StopCell("cell2"), "cell 'cell2' not found")
+	s.Contains(s.Jail.RestartCell("cell2"), "cell 'cell2' not found")
+}
+
 func (s *JailTestSuite) TestCreateAndInitCell() {
 	cell, err := s.Jail.createAndInitCell(
 		"cell1",
@@ -154,3 +199,410 @@ func (s *JailTestSuite) TestExecute() {
 	`)
 	s.Equal(`{"test":true}`, response)
 }
+
+func (s *JailTestSuite) TestFetchWhitelist() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello")) //nolint: errcheck
+	}))
+	defer server.Close()
+	defer s.Jail.Stop()
+
+	s.Jail.ConfigureFetch(params.JailFetchConfig{
+		AllowedHosts:    []string{"api.example.com"},
+		AllowInsecure:   true,
+		Timeout:         1,
+		MaxResponseSize: 1024,
+	})
+	s.Jail.SetFetchWhitelist("cell1", []string{"127.0.0.1"})
+
+	fetch := func(chatID string) string {
+		cell, err := s.Jail.createCell(chatID)
+		s.NoError(err)
+
+		results := make(chan string, 1)
+		err = cell.Set("__capture", func(str string) { results <- str })
+		s.NoError(err)
+		_, err = cell.Run(`fetch('` + server.URL + `').then(function(r) {
+			return r.text();
+		}).then(__capture).catch(function(e) {
+			__capture(e.message);
+		})`)
+		s.NoError(err)
+
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(time.Second):
+			s.Fail("test timed out")
+			return ""
+		}
+	}
+
+	// hosts allowed for a cell are allowed in addition to ones allowed for all cells
+	s.Equal("hello", fetch("cell1"))
+	s.Equal("requests to 127.0.0.1 are not allowed", fetch("cell2"))
+	s.Equal([]string{"api.example.com", "127.0.0.1"}, s.Jail.fetchPolicy("cell1").AllowedHosts)
+	s.Equal([]string{"api.example.com"}, s.Jail.fetchPolicy("cell2").AllowedHosts)
+
+	s.Jail.SetFetchWhitelist("cell1", nil)
+	s.Equal([]string{"api.example.com"}, s.Jail.fetchPolicy("cell1").AllowedHosts)
+}
+
+func (s *JailTestSuite) TestStorage() {
+	dir, err := ioutil.TempDir("", "jail-storage")
+	s.NoError(err)
+	defer os.RemoveAll(dir) //nolint: errcheck
+	defer s.Jail.Stop()
+
+	s.Jail.SetStorageDir(dir)
+
+	cell, err := s.Jail.createCell("cell1")
+	s.NoError(err)
+	_, err = cell.Run(`localStorage.setItem('key', 'value')`)
+	s.NoError(err)
+
+	// items persist across cells of a chat, and are not shared between chats
+	s.Jail.Stop()
+	cell, err = s.Jail.createCell("cell1")
+	s.NoError(err)
+	value, err := cell.Run(`localStorage.getItem('key')`)
+	s.NoError(err)
+	s.Equal("value", value.String())
+
+	other, err := s.Jail.createCell("cell2")
+	s.NoError(err)
+	value, err = other.Run(`localStorage.getItem('key')`)
+	s.NoError(err)
+	s.True(value.IsNull())
+
+	s.NoError(s.Jail.PurgeStorage("cell1"))
+	value, err = cell.Run(`localStorage.length`)
+	s.NoError(err)
+	s.Equal("0", value.String())
+
+	// storage of a chat without a cell is purged too
+	_, err = cell.Run(`localStorage.setItem('key', 'value')`)
+	s.NoError(err)
+	s.Jail.Stop()
+	s.NoError(s.Jail.PurgeStorage("cell1"))
+	files, err := ioutil.ReadDir(dir)
+	s.NoError(err)
+	s.Empty(files)
+}
+
+func (s *JailTestSuite) TestLimits() {
+	defer s.Jail.Stop()
+
+	halted := make(chan string, 1)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event HaltCellEvent
+		}
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventCellHalted {
+			halted <- envelope.Event.ChatID + ":" + envelope.Event.Limit
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	s.Jail.ConfigureLimits(params.JailLimitsConfig{MaxOps: 1000})
+	cell, err := s.Jail.createCell("cell1")
+	s.NoError(err)
+
+	// executions within limits are not affected
+	value, err := cell.Run(`var sum = 0; for (var i = 0; i < 10; i++) { sum += i }; sum`)
+	s.NoError(err)
+	s.Equal("45", value.String())
+
+	_, err = cell.Run(`while (true) {}`)
+	s.Equal(&vm.LimitError{Limit: vm.LimitOps}, err)
+
+	select {
+	case event := <-halted:
+		s.Equal("cell1:ops", event)
+	case <-time.After(time.Second):
+		s.Fail("halted cell was not signalled")
+	}
+
+	// the halted cell is removed and can't run code anymore
+	_, err = s.Jail.Cell("cell1")
+	s.Error(err)
+	_, err = cell.Run(`1 + 1`)
+	s.Equal(vm.ErrHalted, err)
+
+	// callbacks of timers are limited too
+	s.Jail.ConfigureLimits(params.JailLimitsConfig{MaxExecutionTime: 50})
+	cell, err = s.Jail.createCell("cell2")
+	s.NoError(err)
+	_, err = cell.Run(`setTimeout(function () { while (true) {} }, 0)`)
+	s.NoError(err)
+
+	select {
+	case event := <-halted:
+		s.Equal("cell2:duration", event)
+	case <-time.After(time.Second):
+		s.Fail("halted cell was not signalled")
+	}
+}
+
+func (s *JailTestSuite) TestInterruptCell() {
+	defer s.Jail.Stop()
+
+	s.Jail.ConfigureLimits(params.JailLimitsConfig{})
+	cell, err := s.Jail.createCell("cell1")
+	s.NoError(err)
+
+	started := make(chan struct{})
+	s.NoError(cell.Set("__started", func(call otto.FunctionCall) otto.Value {
+		close(started)
+		return otto.UndefinedValue()
+	}))
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := cell.Run(`__started(); while (true) {}`)
+		errc <- err
+	}()
+	<-started
+
+	s.NoError(s.Jail.InterruptCell("cell1"))
+	select {
+	case err := <-errc:
+		s.Equal(vm.ErrInterrupted, err)
+	case <-time.After(time.Second):
+		s.Fail("script was not interrupted")
+	}
+
+	// the interrupted cell keeps running
+	value, err := cell.Run(`1 + 1`)
+	s.NoError(err)
+	s.Equal("2", value.String())
+
+	s.EqualError(s.Jail.InterruptCell("cell2"), "cell 'cell2' not found")
+}
+
+func (s *JailTestSuite) TestConsoleCapture() {
+	defer s.Jail.Stop()
+
+	events := make(chan ConsoleEvent, 10)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event ConsoleEvent
+		}
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventConsole {
+			events <- envelope.Event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	cell, err := s.Jail.createCell("cell1")
+	s.NoError(err)
+
+	// messages are not captured until capturing is enabled
+	_, err = cell.Run(`console.log("not captured")`)
+	s.NoError(err)
+
+	s.Jail.SetConsoleCapture(true)
+	_, err = cell.Run(`console.log("answer:", 42); console.error("failed")`)
+	s.NoError(err)
+
+	s.Jail.SetConsoleCapture(false)
+	_, err = cell.Run(`console.warn("not captured")`)
+	s.NoError(err)
+
+	s.Len(events, 2)
+	event := <-events
+	s.Equal("cell1", event.ChatID)
+	s.Equal("log", event.Level)
+	s.Equal("answer: 42", event.Message)
+	s.NotZero(event.Timestamp)
+	event = <-events
+	s.Equal("error", event.Level)
+	s.Equal("failed", event.Message)
+}
+
+func (s *JailTestSuite) TestRequireModules() {
+	defer s.Jail.Stop()
+
+	code := `exports.greet = function (name) { return 'hello ' + name }`
+	sum := sha256.Sum256([]byte(code))
+	s.NoError(s.Jail.RegisterModule("greeter", code, hex.EncodeToString(sum[:])))
+
+	cell, err := s.Jail.createAndInitCell("cell1")
+	s.NoError(err)
+
+	// registered modules can be required along with modules bundled with web3
+	value, err := cell.Run(`require('greeter').greet(typeof require('web3'))`)
+	s.NoError(err)
+	s.Equal("hello function", value.String())
+
+	s.Jail.UnregisterModule("greeter")
+	other, err := s.Jail.createCell("cell2")
+	s.NoError(err)
+	_, err = other.Run(`require('greeter')`)
+	s.EqualError(err, "Error: Cannot find module 'greeter'")
+}
+
+func (s *JailTestSuite) TestCryptoInCell() {
+	defer s.Jail.Stop()
+
+	// status defined by the user code gets status.crypto too
+	cell, err := s.Jail.createAndInitCell("cell1", `var status = {bot: true}`)
+	s.NoError(err)
+
+	value, err := cell.Run(`status.bot && status.crypto.keccak256('0x')`)
+	s.NoError(err)
+	s.Equal("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", value.String())
+}
+
+func (s *JailTestSuite) TestInspectCell() {
+	defer s.Jail.Stop()
+
+	cell, err := s.Jail.createCell("cell1")
+	s.NoError(err)
+	_, err = cell.Run(`
+		var answer = 42;
+		function fail() { return undefinedVariable }
+		setInterval(function () {}, 60000);
+	`)
+	s.NoError(err)
+	_, err = cell.Run(`fail()`)
+	s.Error(err)
+
+	inspection, err := s.Jail.InspectCell("cell1")
+	s.NoError(err)
+	s.Equal("cell1", inspection.ChatID)
+	s.Contains(inspection.Globals, "answer")
+	s.Contains(inspection.Globals, "fail")
+	s.Contains(inspection.Globals, "setTimeout")
+	s.NotContains(inspection.Globals, "JSON")
+	s.Len(inspection.Timers, 1)
+	s.EqualValues(60000, inspection.Timers[0].Delay)
+	s.True(inspection.Timers[0].Interval)
+	s.Equal("ReferenceError: 'undefinedVariable' is not defined", inspection.LastError)
+	s.Contains(inspection.LastStack, "at fail (")
+
+	_, err = s.Jail.InspectCell("cell2")
+	s.EqualError(err, "cell 'cell2' not found")
+}
+
+func (s *JailTestSuite) TestEvaluateInCell() {
+	defer s.Jail.Stop()
+
+	cell, err := s.Jail.createCell("cell1")
+	s.NoError(err)
+	_, err = cell.Run(`var state = {count: 1, items: ['a']}`)
+	s.NoError(err)
+
+	_, err = s.Jail.EvaluateInCell("cell1", `state.count`)
+	s.Equal(ErrDebugDisabled, err)
+
+	s.Jail.SetDebug(true)
+	result, err := s.Jail.EvaluateInCell("cell1", `state.count + 1`)
+	s.NoError(err)
+	s.Equal("2", result)
+	result, err = s.Jail.EvaluateInCell("cell1", `state`)
+	s.NoError(err)
+	s.Equal(`{"count":1,"items":["a"]}`, result)
+	_, err = s.Jail.EvaluateInCell("cell1", `state.missing.count`)
+	s.Error(err)
+
+	s.Jail.SetDebug(false)
+	_, err = s.Jail.EvaluateInCell("cell1", `state`)
+	s.Equal(ErrDebugDisabled, err)
+}
+
+func (s *JailTestSuite) TestConcurrentCells() {
+	defer s.Jail.Stop()
+
+	// cell1 is blocked in a call until it's released
+	blocked, err := s.Jail.CreateCell("cell1")
+	s.NoError(err)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	s.NoError(blocked.Set("call", func(call otto.FunctionCall) otto.Value {
+		close(entered)
+		<-release
+		return otto.UndefinedValue()
+	}))
+
+	done := make(chan string, 1)
+	go func() {
+		done <- s.Jail.Call("cell1", `[]`, ``)
+	}()
+	<-entered
+
+	// other cells are created and called meanwhile
+	cell, err := s.Jail.CreateCell("cell2")
+	s.NoError(err)
+	_, err = cell.Run(`function call(path, args) { return args.length }`)
+	s.NoError(err)
+	s.Equal(`{"result": 2}`, s.Jail.Call("cell2", `[]`, `hi`))
+	s.Len(s.Jail.Cells(), 2)
+
+	// calls of the blocked cell are queued after the blocking one
+	queued := make(chan string, 1)
+	go func() {
+		value, err := blocked.Run(`'next'`)
+		s.NoError(err)
+		queued <- value.String()
+	}()
+
+	select {
+	case <-done:
+		s.Fail("blocked call completed before it was released")
+	case <-queued:
+		s.Fail("queued call completed before the blocking one")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	s.Equal(`{"result": undefined}`, <-done)
+	s.Equal("next", <-queued)
+}
+
+// benchmarkCall calls a function doing some work in a number of cells, from a goroutine per cell.
+func benchmarkCall(b *testing.B, cells int) {
+	jail := New(nil)
+	defer jail.Stop()
+
+	ids := make([]string, cells)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("cell%d", i)
+		cell, err := jail.CreateCell(ids[i])
+		if err != nil {
+			b.Fatal(err)
+		}
+		_, err = cell.Run(`function call(path, args) { var sum = 0; for (var i = 0; i < 100; i++) { sum += i }; return sum }`)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ResetTimer()
+
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for i := 0; i < b.N; i++ {
+				jail.Call(id, `[]`, ``)
+			}
+		}(id)
+	}
+	wg.Wait()
+}
+
+func BenchmarkCallOneCell(b *testing.B) {
+	benchmarkCall(b, 1)
+}
+
+// BenchmarkCallFourCells makes four times as many calls as BenchmarkCallOneCell. With four CPUs, they take
+// about as long as calls of one cell do, as cells execute JS code in parallel.
+func BenchmarkCallFourCells(b *testing.B) {
+	benchmarkCall(b, 4)
+}
@@ -0,0 +1,85 @@
+package jail
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/geth/jail/internal/vm"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventCellHalted is triggered when a cell is halted because JS code executed in it exceeded a limit.
+const EventCellHalted = "jail.cell.halted"
+
+// HaltCellEvent is a signal sent when a cell is halted because JS code executed in it exceeded a limit.
+type HaltCellEvent struct {
+	ChatID string `json:"chat_id"`
+	Limit  string `json:"limit"` // "duration", "ops" or "memory"
+	Error  string `json:"error"`
+}
+
+// ConfigureLimits sets limits of a single execution of JS code in a cell, including in existing cells.
+// A cell exceeding them is stopped and removed, and EventCellHalted is signalled.
+func (j *Jail) ConfigureLimits(config params.JailLimitsConfig) {
+	j.cellsMx.Lock()
+	j.limitsConfig = config
+	j.cellsMx.Unlock()
+
+	// limits of a cell are set once its current execution completes, so existing cells
+	// are configured outside of the lock of cells
+	for _, cell := range j.cellList() {
+		j.setLimits(cell, config)
+	}
+}
+
+// InterruptCell aborts JS code being executed in a cell, if any, e.g. a script stuck in an infinite loop,
+// which fails with vm.ErrInterrupted. Unlike a cell exceeding a limit, the cell keeps running.
+func (j *Jail) InterruptCell(chatID string) error {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return err
+	}
+
+	cell.VM.Interrupt()
+
+	return nil
+}
+
+// setLimits sets limits of executions of JS code in a cell.
+func (j *Jail) setLimits(cell *Cell, config params.JailLimitsConfig) {
+	limits := vm.Limits{
+		MaxDuration: time.Duration(config.MaxExecutionTime) * time.Millisecond,
+		MaxOps:      config.MaxOps,
+		MaxMemory:   config.MaxMemory,
+	}
+
+	cell.VM.SetLimits(limits, func(err *vm.LimitError) {
+		j.haltCell(cell, err)
+	})
+}
+
+// haltCell stops and removes a cell which exceeded a limit.
+func (j *Jail) haltCell(cell *Cell, limitErr *vm.LimitError) {
+	log.Warn("jail cell exceeded a limit, halting it", "chatID", cell.id, "limit", limitErr.Limit)
+
+	j.cellsMx.Lock()
+	if j.cells[cell.id] == cell {
+		delete(j.cells, cell.id)
+	}
+	j.cellsMx.Unlock()
+
+	if err := cell.Stop(); err != nil && err != limitErr {
+		log.Warn("failed to stop a halted jail cell", "chatID", cell.id, "err", err)
+	}
+	j.removeFilters(cell.id)
+
+	j.signals.Send(signal.Envelope{
+		Type: EventCellHalted,
+		Event: HaltCellEvent{
+			ChatID: cell.id,
+			Limit:  string(limitErr.Limit),
+			Error:  limitErr.Error(),
+		},
+	})
+}
@@ -0,0 +1,13 @@
+package jail
+
+// RegisterModule adds a CommonJS module which cells can require by name, if the hex-encoded SHA-256 hash
+// of its code is the given hash. Cells cache exports of modules, so a module registered again is seen by
+// cells which haven't required it yet only.
+func (j *Jail) RegisterModule(name, code, hash string) error {
+	return j.modules.Register(name, code, hash)
+}
+
+// UnregisterModule removes a module, so that cells which haven't required it yet can't require it.
+func (j *Jail) UnregisterModule(name string) {
+	j.modules.Unregister(name)
+}
@@ -0,0 +1,120 @@
+package jail
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// SnapshotCell returns what a cell can be restored from with RestoreCell, e.g. after the app restarts:
+// the user code it was initialized with, its localStorage if it's kept in memory rather than in a file,
+// and criteria of Whisper filters it created. Neither the memory of the VM nor timers are kept.
+func (j *Jail) SnapshotCell(chatID string) (*common.JailCellSnapshot, error) {
+	cell, err := j.cell(chatID)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &common.JailCellSnapshot{
+		ChatID:  chatID,
+		Code:    cell.code,
+		Filters: j.whisperFilters.criteriaOf(chatID),
+	}
+	if cell.storage != nil && cell.storage.InMemory() {
+		snapshot.Storage = cell.storage.Items()
+	}
+
+	return snapshot, nil
+}
+
+// RestoreCell creates a cell from a snapshot taken with SnapshotCell. Items of localStorage in the snapshot
+// are set and Whisper filters are created again with their criteria before the initialization of the cell
+// is replayed. New filters have new IDs, so statusWhisper.restoredFilters maps IDs of filters in the snapshot
+// to IDs of filters created again. It returns the response as CreateAndInitCell does.
+func (j *Jail) RestoreCell(snapshot *common.JailCellSnapshot) string {
+	cell, err := j.restoreCell(snapshot)
+	if err != nil {
+		return newJailErrorResponse(err)
+	}
+
+	return j.makeCatalogVariable(cell)
+}
+
+func (j *Jail) restoreCell(snapshot *common.JailCellSnapshot) (*Cell, error) {
+	cell, err := j.createCell(snapshot.ChatID)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range snapshot.Storage {
+		if err := cell.storage.SetItem(key, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := j.initCell(cell); err != nil {
+		return nil, err
+	}
+
+	if err := j.restoreFilters(cell, snapshot.Filters); err != nil {
+		return nil, err
+	}
+
+	if err := runUserCode(cell, snapshot.Code); err != nil {
+		return nil, err
+	}
+
+	return cell, nil
+}
+
+// restoreFilters creates Whisper filters of a cell again and sets statusWhisper.restoredFilters.
+// Filters which can't be created, e.g. as Whisper is not available, are skipped.
+func (j *Jail) restoreFilters(cell *Cell, filters map[string]interface{}) error {
+	restored := make(map[string]interface{}, len(filters))
+	for oldID, criteria := range filters {
+		newID, err := j.createFilter(cell.id, criteria)
+		if err != nil {
+			log.Warn("failed to restore a Whisper filter of a jail cell", "chatID", cell.id, "filterID", oldID, "err", err)
+			continue
+		}
+		restored[oldID] = newID
+	}
+
+	_, err := cell.VM.Do(func() (otto.Value, error) {
+		statusWhisper, err := cell.VM.UnsafeVM().Get("statusWhisper")
+		if err != nil {
+			return otto.UndefinedValue(), err
+		}
+
+		return otto.UndefinedValue(), statusWhisper.Object().Set("restoredFilters", restored)
+	})
+	return err
+}
+
+// createFilter creates a Whisper filter on behalf of a cell and returns its ID.
+func (j *Jail) createFilter(cellID string, criteria interface{}) (string, error) {
+	request, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "shh_newMessageFilter",
+		"params":  []interface{}{criteria},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	response, err := j.sendRPCCall(cellID, string(request))
+	if err != nil {
+		return "", err
+	}
+
+	filterID, ok := resultOf(response).(string)
+	if !ok || filterID == "" {
+		return "", fmt.Errorf("unexpected response: %v", response)
+	}
+
+	return filterID, nil
+}
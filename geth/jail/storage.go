@@ -0,0 +1,69 @@
+package jail
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+
+	"github.com/status-im/status-go/geth/jail/internal/localstorage"
+)
+
+const (
+	// StorageDir is a directory in the node's data directory where localStorage of cells is kept.
+	StorageDir = "jail-storage"
+
+	// StorageQuota is how many bytes keys and values in localStorage of a cell can take.
+	StorageQuota = 5 << 20
+)
+
+// SetStorageDir sets a directory where localStorage of cells created afterwards is kept, in a file per chat.
+// Until it's set, localStorage is kept in memory.
+func (j *Jail) SetStorageDir(dir string) {
+	j.cellsMx.Lock()
+	defer j.cellsMx.Unlock()
+
+	j.storageDir = dir
+}
+
+// PurgeStorage removes all items from localStorage of a chat, whether its cell exists or not.
+func (j *Jail) PurgeStorage(chatID string) error {
+	j.cellsMx.RLock()
+	defer j.cellsMx.RUnlock()
+
+	if cell, ok := j.cells[chatID]; ok {
+		return cell.storage.Clear()
+	}
+
+	path := j.storagePath(chatID)
+	if path == "" {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// defineStorage defines localStorage of a cell kept in a file, loading items it stored before.
+func (j *Jail) defineStorage(cell *Cell, path string) error {
+	store, err := localstorage.Open(path, StorageQuota)
+	if err != nil {
+		return err
+	}
+	cell.storage = store
+
+	return localstorage.Define(cell.VM, store)
+}
+
+// storagePath returns a file of localStorage of a chat, named by a hash of the chat ID, so that any ID
+// makes a valid file name. It's empty if localStorage is kept in memory. It must be called with cellsMx locked.
+func (j *Jail) storagePath(chatID string) string {
+	if j.storageDir == "" {
+		return ""
+	}
+
+	hash := sha256.Sum256([]byte(chatID))
+	return filepath.Join(j.storageDir, hex.EncodeToString(hash[:])+".json")
+}
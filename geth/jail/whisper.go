@@ -0,0 +1,244 @@
+package jail
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// whisperPollInterval is how often messages of watched Whisper filters are retrieved
+const whisperPollInterval = 250 * time.Millisecond
+
+// whisperFilter is a Whisper filter created by a cell
+type whisperFilter struct {
+	owner    string      // chat ID of the cell
+	criteria interface{} // params of shh_newMessageFilter the filter was created with
+}
+
+// whisperFilters are Whisper filters created by cells, so that a cell can watch its own filters only,
+// and filters of a cell can be created again when it's restored from a snapshot
+type whisperFilters struct {
+	mu      sync.Mutex
+	filters map[string]whisperFilter // by filter ID
+}
+
+func newWhisperFilters() *whisperFilters {
+	return &whisperFilters{filters: make(map[string]whisperFilter)}
+}
+
+func (f *whisperFilters) owner(filterID string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.filters[filterID].owner
+}
+
+// forget forgets filters created by a cell and returns their IDs.
+func (f *whisperFilters) forget(cellID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var filterIDs []string
+	for filterID, filter := range f.filters {
+		if filter.owner == cellID {
+			filterIDs = append(filterIDs, filterID)
+			delete(f.filters, filterID)
+		}
+	}
+
+	return filterIDs
+}
+
+// criteriaOf returns criteria of filters created by a cell, by filter ID.
+func (f *whisperFilters) criteriaOf(cellID string) map[string]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	criteria := make(map[string]interface{})
+	for filterID, filter := range f.filters {
+		if filter.owner == cellID {
+			criteria[filterID] = filter.criteria
+		}
+	}
+
+	return criteria
+}
+
+// track records filters created, and forgets filters deleted, by a raw JSON-RPC request of a cell,
+// which may be a batch.
+func (f *whisperFilters) track(cellID, request string, response interface{}) {
+	if !strings.Contains(request, "MessageFilter") {
+		return
+	}
+
+	var calls []rpcCall
+	if err := json.Unmarshal([]byte(request), &calls); err != nil {
+		var call rpcCall
+		if err := json.Unmarshal([]byte(request), &call); err != nil {
+			return
+		}
+		calls = []rpcCall{call}
+	}
+
+	responses, ok := response.([]interface{})
+	if !ok {
+		responses = []interface{}{response}
+	}
+	results := make(map[string]interface{}, len(responses))
+	for _, r := range responses {
+		if object, ok := r.(map[string]interface{}); ok {
+			results[fmt.Sprint(object["id"])] = object["result"]
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, call := range calls {
+		switch call.Method {
+		case "shh_newMessageFilter":
+			if filterID, ok := results[fmt.Sprint(call.ID)].(string); ok && filterID != "" {
+				filter := whisperFilter{owner: cellID}
+				if len(call.Params) > 0 {
+					filter.criteria = call.Params[0]
+				}
+				f.filters[filterID] = filter
+			}
+		case "shh_deleteMessageFilter":
+			if len(call.Params) > 0 {
+				if filterID, ok := call.Params[0].(string); ok && f.filters[filterID].owner == cellID {
+					delete(f.filters, filterID)
+				}
+			}
+		}
+	}
+}
+
+// rpcCall is a JSON-RPC request, as much as it's needed to track Whisper filters
+type rpcCall struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// resultOf returns a result of a JSON-RPC response, or nil if it has none.
+func resultOf(response interface{}) interface{} {
+	if object, ok := response.(map[string]interface{}); ok {
+		return object["result"]
+	}
+	return nil
+}
+
+// registerWhisperWatcher creates an object called "statusWhisper", which pushes messages of Whisper filters
+// to callbacks: statusWhisper.watch(filterId, callback) calls callback(error, messages) in the loop of a cell
+// when messages matching a filter created by the cell arrive, and statusWhisper.unwatch(filterId) stops it.
+// Messages of filters which are not watched are left for DApps to poll with shh_getFilterMessages.
+func registerWhisperWatcher(jail *Jail, cell *Cell) error {
+	statusWhisper := map[string]interface{}{
+		"watch": func(call otto.FunctionCall) otto.Value {
+			filterID := call.Argument(0).String()
+			callback := call.Argument(1)
+			if callback.Class() != "Function" {
+				throwJSError(fmt.Errorf("callback of filter %s is not a function", filterID))
+			}
+			if jail.whisperFilters.owner(filterID) != cell.id {
+				throwJSError(fmt.Errorf("filter %s was not created by the cell", filterID))
+			}
+
+			cell.watchFilter(filterID, callback)
+			jail.startWatching()
+			return otto.UndefinedValue()
+		},
+		"unwatch": func(call otto.FunctionCall) otto.Value {
+			cell.unwatchFilter(call.Argument(0).String())
+			return otto.UndefinedValue()
+		},
+	}
+
+	return cell.Set("statusWhisper", statusWhisper)
+}
+
+// startWatching starts the loop polling filters watched by cells, unless it's running already.
+func (j *Jail) startWatching() {
+	j.watchMx.Lock()
+	defer j.watchMx.Unlock()
+
+	if j.watching {
+		return
+	}
+	j.watching = true
+
+	go j.watchFilters()
+}
+
+// watchFilters polls messages of filters watched by all cells in a single loop, which stops
+// once no filter is watched anymore, e.g. as cells watching them are stopped.
+func (j *Jail) watchFilters() {
+	for {
+		<-j.clock.After(whisperPollInterval)
+
+		if j.stopWatchingIfIdle() {
+			return
+		}
+
+		for _, cell := range j.cellList() {
+			for filterID, callback := range cell.watchedFilters() {
+				j.pollFilter(cell, filterID, callback)
+			}
+		}
+	}
+}
+
+// stopWatchingIfIdle marks the loop polling filters as stopped if no cell watches a filter.
+// Filters are watched before startWatching is called, so none is missed.
+func (j *Jail) stopWatchingIfIdle() bool {
+	j.watchMx.Lock()
+	defer j.watchMx.Unlock()
+
+	for _, cell := range j.cellList() {
+		if len(cell.watchedFilters()) > 0 {
+			return false
+		}
+	}
+	j.watching = false
+
+	return true
+}
+
+// pollFilter retrieves messages of a watched filter and passes them to the callback. A failure to retrieve
+// messages, e.g. as the filter has been deleted, is passed to the callback and stops watching the filter.
+func (j *Jail) pollFilter(cell *Cell, filterID string, callback otto.Value) {
+	request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"shh_getFilterMessages","params":[%q]}`, filterID)
+
+	response, err := j.sendRPCCall(cell.id, request)
+	if err == nil {
+		if object, ok := response.(map[string]interface{}); ok && object["error"] != nil {
+			err = fmt.Errorf("failed to get messages of filter %s: %v", filterID, object["error"])
+		}
+	}
+	if err != nil {
+		cell.unwatchFilter(filterID)
+		cell.CallAsync(callback, cell.VM.MakeCustomError("Error", err.Error()))
+		return
+	}
+
+	if messages, ok := resultOf(response).([]interface{}); ok && len(messages) > 0 {
+		cell.CallAsync(callback, nil, messages)
+	}
+}
+
+// removeFilters forgets Whisper filters created by a cell, which is stopped, and deletes them,
+// so that they don't keep collecting messages.
+func (j *Jail) removeFilters(cellID string) {
+	for _, filterID := range j.whisperFilters.forget(cellID) {
+		request := fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"shh_deleteMessageFilter","params":[%q]}`, filterID)
+		if _, err := j.sendRPCCall(cellID, request); err != nil {
+			log.Debug("failed to delete a Whisper filter of a stopped jail cell", "chatID", cellID, "filterID", filterID, "err", err)
+		}
+	}
+}
@@ -75,9 +75,15 @@ func SetLevel(level string) {
 	setHandler(lvl, logger.handler)
 }
 
-// SetLogFile configures logger to write output into file.
+// SetLogFile configures logger to write output into file, or to stdout again if filename is empty.
 // This call preserves current logging level.
 func SetLogFile(filename string) error {
+	if filename == "" {
+		logger.handler = log.StreamHandler(os.Stdout, log.TerminalFormat(true))
+		setHandler(logger.level, logger.handler)
+		return nil
+	}
+
 	handler, err := log.FileHandler(filename, log.TerminalFormat(false))
 	if err != nil {
 		return err
@@ -0,0 +1,406 @@
+// Package mailclient requests Whisper messages sent while the node was offline from a mail server,
+// a Whisper node which archives envelopes and delivers them to trusted peers on demand.
+package mailclient
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventRequestCompleted is triggered when a mail server stops delivering envelopes requested from it
+const EventRequestCompleted = "mailserver.request.completed"
+
+const (
+	// defaultRequestPeriod is how far back messages are requested from if a request has no time range
+	defaultRequestPeriod = 24 * time.Hour
+
+	// quietPeriod is how long a request is pending after the last envelope delivered by the mail server,
+	// as the mail server doesn't tell when it has delivered all envelopes
+	quietPeriod = 3 * time.Second
+
+	// requestTimeout is how long a request is pending at most
+	requestTimeout = time.Minute
+
+	// requestTTL is a TTL of request envelopes, in seconds
+	requestTTL = 60
+
+	// requestWorkTime is how long a PoW of request envelopes is computed at most, in seconds
+	requestWorkTime = 5
+)
+
+var (
+	// ErrNotStarted is returned when messages are requested before the node is started.
+	ErrNotStarted = errors.New("mail server client is not started")
+
+	// ErrInvalidTimeRange is returned when a request starts after it ends.
+	ErrInvalidTimeRange = errors.New("time range of a request is invalid")
+
+	// ErrNoMailServers is returned when a client is configured without mail servers.
+	ErrNoMailServers = errors.New("no mail server enodes are configured")
+)
+
+// CompleteRequestEvent is a signal sent when a mail server stops delivering envelopes requested from it.
+// Envelopes are delivered to Whisper filters which allow peer-to-peer messages.
+type CompleteRequestEvent struct {
+	RequestID string `json:"request_id"`
+	Envelopes int    `json:"envelopes"` // how many envelopes the mail server delivered while the request was pending
+	TimedOut  bool   `json:"timed_out"` // whether the mail server was still delivering envelopes after requestTimeout
+}
+
+// MessagesRequest is a request of messages sent on topics within a time range.
+type MessagesRequest struct {
+	From   uint32              `json:"from"` // unix time, in seconds, defaultRequestPeriod before To if zero
+	To     uint32              `json:"to"`   // unix time, in seconds, now if zero
+	Topics []whisper.TopicType `json:"topics"`
+}
+
+// pendingRequest is a request waiting for a mail server to deliver envelopes
+type pendingRequest struct {
+	id        string
+	sent      time.Time
+	last      time.Time // when the last envelope was delivered, or when the request was sent
+	envelopes int
+	server    *mailServer // mail server the request was sent to
+}
+
+// Client keeps the node connected to a mail server and requests messages from it. Of the mail servers
+// configured, it selects the most responsive one, and fails over to another one with EventMailServerChanged
+// when the selected one becomes unavailable. It's a node service exposing shh_requestMessages, and a Whisper
+// delivery server counting envelopes delivered by the mail server, which passes states of messages on to
+// the delivery server it wraps.
+type Client struct {
+	mailServers []*mailServer // in the order they're configured
+	password    string
+	pow         float64
+	delivery    whisper.DeliveryServer // wrapped delivery server, if any
+
+	quietPeriod    time.Duration
+	requestTimeout time.Duration
+	probeInterval  time.Duration
+	connectTimeout time.Duration
+	probe          func(node *discover.Node) (time.Duration, error)
+
+	mu            sync.Mutex
+	shh           *whisper.Whisper
+	server        *p2p.Server
+	peers         peerManager
+	selected      *mailServer
+	lastConnected time.Time // when the node was last known to be connected to the selected mail server
+	symKey        []byte
+	pending       map[string]*pendingRequest
+	quit          chan struct{}
+	stopping      sync.WaitGroup
+
+	signals signal.Sender // sends mail server signals, see SetSignalHandler
+}
+
+// New returns a client of mail servers configured by a Whisper config, wrapping a delivery server, which may be nil.
+// MailServerEnode, if set, is the first of the mail servers.
+func New(config *params.WhisperConfig, delivery whisper.DeliveryServer) (*Client, error) {
+	enodes := config.MailServerEnodes
+	if config.MailServerEnode != "" {
+		enodes = append([]string{config.MailServerEnode}, enodes...)
+	}
+
+	var mailServers []*mailServer
+	known := make(map[discover.NodeID]bool)
+	for _, enode := range enodes {
+		node, err := discover.ParseNode(enode)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mail server enode: %v", err)
+		}
+		if known[node.ID] {
+			continue
+		}
+		known[node.ID] = true
+		mailServers = append(mailServers, &mailServer{node: node})
+	}
+	if len(mailServers) == 0 {
+		return nil, ErrNoMailServers
+	}
+
+	return &Client{
+		mailServers:    mailServers,
+		password:       config.MailServerPassword,
+		pow:            requestPoW(config.MinimumPoW),
+		delivery:       delivery,
+		quietPeriod:    quietPeriod,
+		requestTimeout: requestTimeout,
+		probeInterval:  probeInterval,
+		connectTimeout: connectTimeout,
+		probe:          probeTCP,
+		selected:       mailServers[0],
+		pending:        make(map[string]*pendingRequest),
+	}, nil
+}
+
+// SetSignalHandler sets a handler of signals sent by the client, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (c *Client) SetSignalHandler(handler func(signal.Envelope)) {
+	c.signals.SetHandler(handler)
+}
+
+// Init sets the Whisper service messages are requested with, deriving the symmetric key of requests
+// from the mail server's password, as the mail server does.
+func (c *Client) Init(shh *whisper.Whisper) error {
+	keyID, err := shh.AddSymKeyFromPassword(c.password)
+	if err != nil {
+		return err
+	}
+	symKey, err := shh.GetSymKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.shh = shh
+	c.symKey = symKey
+
+	return nil
+}
+
+// Protocols implements node.Service, the client has no protocols.
+func (c *Client) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, adding requestMessages and mailServers to the shh namespace.
+func (c *Client) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "shh",
+			Version:   "1.0",
+			Service:   &PublicAPI{client: c},
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service, connecting to the selected mail server, which is redialed as a static peer,
+// and probing mail servers every probeInterval.
+func (c *Client) Start(server *p2p.Server) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.server = server
+	c.peers = server
+	c.lastConnected = time.Now()
+	c.quit = make(chan struct{})
+	server.AddPeer(c.selected.node)
+
+	c.stopping.Add(1)
+	go c.monitorLoop(c.quit)
+
+	return nil
+}
+
+// Stop implements node.Service, disconnecting from the selected mail server. Pending requests are not signalled.
+func (c *Client) Stop() error {
+	c.mu.Lock()
+	peers := c.peers
+	if peers == nil {
+		c.mu.Unlock()
+		return nil
+	}
+	c.server = nil
+	c.peers = nil
+	close(c.quit)
+	selected := c.selected
+	c.mu.Unlock()
+
+	peers.RemovePeer(selected.node)
+	c.stopping.Wait()
+
+	return nil
+}
+
+// RequestMessages requests messages sent on topics within a time range from the selected mail server,
+// with a request per topic, or a single request of all topics if none are given. It returns
+// an ID of the request, which EventRequestCompleted is signalled with. If the request can't be sent,
+// the client fails over to another mail server.
+func (c *Client) RequestMessages(request MessagesRequest) (string, error) {
+	c.mu.Lock()
+	shh, server, selected, symKey, quit := c.shh, c.server, c.selected, c.symKey, c.quit
+	c.mu.Unlock()
+
+	if server == nil || shh == nil {
+		return "", ErrNotStarted
+	}
+
+	now := time.Now()
+	if request.To == 0 {
+		request.To = uint32(now.Unix())
+	}
+	if request.From == 0 {
+		request.From = request.To - uint32(defaultRequestPeriod/time.Second)
+	}
+	if request.From > request.To {
+		return "", ErrInvalidTimeRange
+	}
+
+	topics := request.Topics
+	if len(topics) == 0 {
+		topics = []whisper.TopicType{{}}
+	}
+
+	var requestID string
+	for _, topic := range topics {
+		envelope, err := makeRequestEnvelope(symKey, server.PrivateKey, c.pow, request.From, request.To, topic)
+		if err != nil {
+			return "", err
+		}
+		if err := shh.RequestHistoricMessages(selected.node.ID[:], envelope); err != nil {
+			c.fail(selected, ReasonRequestFailed)
+			return "", fmt.Errorf("failed to request messages from the mail server: %v", err)
+		}
+		if requestID == "" {
+			requestID = envelope.Hash().Hex()
+		}
+	}
+
+	pending := &pendingRequest{id: requestID, sent: now, last: now, server: selected}
+	c.mu.Lock()
+	if c.server == nil {
+		c.mu.Unlock()
+		return "", ErrNotStarted
+	}
+	c.pending[requestID] = pending
+	c.stopping.Add(1)
+	c.mu.Unlock()
+
+	go c.awaitCompletion(pending, quit)
+
+	log.Info("requested messages from the mail server", "requestID", requestID, "enode", selected.node.String(), "from", request.From, "to", request.To, "topics", len(request.Topics))
+
+	return requestID, nil
+}
+
+// SendState implements whisper.DeliveryServer, counting envelopes delivered directly by a trusted peer,
+// which is the mail server, towards pending requests.
+func (c *Client) SendState(state whisper.MessageState) {
+	if state.IsP2P && state.Direction == gethmessage.IncomingMessage && state.Status == gethmessage.SentStatus {
+		c.mu.Lock()
+		for _, pending := range c.pending {
+			pending.envelopes++
+			pending.last = state.Timestamp
+		}
+		c.mu.Unlock()
+	}
+
+	if c.delivery != nil {
+		c.delivery.SendState(state)
+	}
+}
+
+// awaitCompletion signals EventRequestCompleted once the mail server stops delivering envelopes,
+// or the request times out. If the mail server disconnected without delivering envelopes, the client
+// fails over to another mail server.
+func (c *Client) awaitCompletion(pending *pendingRequest, quit <-chan struct{}) {
+	defer c.stopping.Done()
+
+	wait := c.quietPeriod
+	for {
+		select {
+		case <-time.After(wait):
+		case <-quit:
+			return
+		}
+
+		c.mu.Lock()
+		now := time.Now()
+		idle := now.Sub(pending.last)
+		timedOut := now.Sub(pending.sent) >= c.requestTimeout
+		if idle < c.quietPeriod && !timedOut {
+			c.mu.Unlock()
+			wait = c.quietPeriod - idle
+			continue
+		}
+		delete(c.pending, pending.id)
+		envelopes := pending.envelopes
+		disconnected := envelopes == 0 && pending.server != nil && c.peers != nil && !c.connected(pending.server)
+		c.mu.Unlock()
+
+		if disconnected {
+			c.fail(pending.server, ReasonDisconnected)
+		}
+
+		c.signals.Send(signal.Envelope{
+			Type: EventRequestCompleted,
+			Event: CompleteRequestEvent{
+				RequestID: pending.id,
+				Envelopes: envelopes,
+				TimedOut:  timedOut && idle < c.quietPeriod,
+			},
+		})
+		return
+	}
+}
+
+// requestPoW returns a PoW of request envelopes, which is at least whisper.DefaultMinimumPoW,
+// so that mail servers with the default minimum PoW accept requests.
+func requestPoW(minimumPoW float64) float64 {
+	if minimumPoW < whisper.DefaultMinimumPoW {
+		return whisper.DefaultMinimumPoW
+	}
+	return minimumPoW
+}
+
+// makeRequestEnvelope returns an envelope of a request of messages sent on a topic within a time range,
+// or on any topic if it's empty. It's encrypted with the mail server's symmetric key and signed with
+// the node key, which the mail server checks against the ID of the peer the request comes from.
+func makeRequestEnvelope(symKey []byte, nodeKey *ecdsa.PrivateKey, pow float64, from, to uint32, topic whisper.TopicType) (*whisper.Envelope, error) {
+	payload := make([]byte, 8, 8+whisper.TopicLength)
+	binary.BigEndian.PutUint32(payload, from)
+	binary.BigEndian.PutUint32(payload[4:], to)
+	if topic != (whisper.TopicType{}) {
+		payload = append(payload, topic[:]...)
+	}
+
+	params := &whisper.MessageParams{
+		TTL:      requestTTL,
+		Src:      nodeKey,
+		KeySym:   symKey,
+		Topic:    topic,
+		WorkTime: requestWorkTime,
+		PoW:      pow,
+		Payload:  payload,
+	}
+
+	message, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return message.Wrap(params)
+}
+
+// PublicAPI is the shh API of the client.
+type PublicAPI struct {
+	client *Client
+}
+
+// RequestMessages requests messages sent while the node was offline from the mail server,
+// see Client.RequestMessages.
+func (api *PublicAPI) RequestMessages(request MessagesRequest) (string, error) {
+	return api.client.RequestMessages(request)
+}
+
+// MailServers returns mail servers messages can be requested from, see Client.MailServers.
+func (api *PublicAPI) MailServers() []MailServerInfo {
+	return api.client.MailServers()
+}
@@ -0,0 +1,240 @@
+package mailclient
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	mailServerEnode  = "enode://3f04db09bedc8d85a198de94c84da73aa7782fafc61b28c525ec5cca5a6cc16be7ebbb5cd001780f71d8408d35a2f6326faa1e524d9d8875294172ebec988743@127.0.0.1:30303"
+	mailServerEnode2 = "enode://a6a2a9b3a7cbb0a15da74301537ebba549c990e3325ae78e1272a19a3ace150d03c184b8ac86cc33f1f2f63691e467d49308f02d613277754c4dccd6773b95e8@127.0.0.1:30304"
+	mailServerEnode3 = "enode://e860f4a2c3d5f9ad06e1a14ec5cb8aa7e1c4ba2de15ac8ba99e4d4e0e1ab2e5bd3fb0fa4b1e4fb8eeb44b4d3b2fbf6e2c8b5bf6a1ff28d2c0e8d9a1da4f0e5f4@127.0.0.1:30305"
+)
+
+// fakePeers records peers added to it, which are connected at once
+type fakePeers struct {
+	mu    sync.Mutex
+	peers map[discover.NodeID]bool
+}
+
+func (p *fakePeers) AddPeer(node *discover.Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.peers[node.ID] = true
+}
+
+func (p *fakePeers) RemovePeer(node *discover.Node) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.peers, node.ID)
+}
+
+func (p *fakePeers) PeersInfo() []*p2p.PeerInfo {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var infos []*p2p.PeerInfo
+	for id := range p.peers {
+		infos = append(infos, &p2p.PeerInfo{ID: id.String()})
+	}
+	return infos
+}
+
+// recordingDelivery records states of messages passed to it
+type recordingDelivery struct {
+	states chan whisper.MessageState
+}
+
+func (d recordingDelivery) SendState(state whisper.MessageState) {
+	d.states <- state
+}
+
+func TestMakeRequestEnvelope(t *testing.T) {
+	nodeKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	symKey := make([]byte, 32)
+	symKey[0] = 1
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	envelope, err := makeRequestEnvelope(symKey, nodeKey, whisper.DefaultMinimumPoW, 100, 200, topic)
+	require.NoError(t, err)
+	require.True(t, envelope.PoW() >= whisper.DefaultMinimumPoW)
+
+	// the request is opened as the mail server does
+	message := envelope.Open(&whisper.Filter{KeySym: symKey})
+	require.NotNil(t, message)
+	require.Equal(t, crypto.FromECDSAPub(&nodeKey.PublicKey), crypto.FromECDSAPub(message.Src))
+	require.Len(t, message.Payload, 8+whisper.TopicLength)
+	require.Equal(t, uint32(100), binary.BigEndian.Uint32(message.Payload[:4]))
+	require.Equal(t, uint32(200), binary.BigEndian.Uint32(message.Payload[4:8]))
+	require.Equal(t, topic[:], message.Payload[8:])
+
+	// requests of any topic have a time range only
+	envelope, err = makeRequestEnvelope(symKey, nodeKey, whisper.DefaultMinimumPoW, 100, 200, whisper.TopicType{})
+	require.NoError(t, err)
+	message = envelope.Open(&whisper.Filter{KeySym: symKey})
+	require.NotNil(t, message)
+	require.Len(t, message.Payload, 8)
+}
+
+func TestRequestCompleted(t *testing.T) {
+	completed := make(chan CompleteRequestEvent, 1)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event CompleteRequestEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventRequestCompleted {
+			completed <- envelope.Event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	delivery := recordingDelivery{states: make(chan whisper.MessageState, 3)}
+	client, err := New(&params.WhisperConfig{MailServerEnode: mailServerEnode}, delivery)
+	require.NoError(t, err)
+	client.quietPeriod = 50 * time.Millisecond
+
+	now := time.Now()
+	pending := &pendingRequest{id: "0x01", sent: now, last: now}
+	client.pending[pending.id] = pending
+	client.stopping.Add(1)
+	go client.awaitCompletion(pending, make(chan struct{}))
+
+	// envelopes delivered directly by the mail server are counted, other states are passed on only
+	for _, state := range []whisper.MessageState{
+		{IsP2P: true, Direction: gethmessage.IncomingMessage, Status: gethmessage.SentStatus},
+		{IsP2P: true, Direction: gethmessage.IncomingMessage, Status: gethmessage.SentStatus},
+		{IsP2P: false, Direction: gethmessage.IncomingMessage, Status: gethmessage.SentStatus},
+	} {
+		state.Timestamp = time.Now()
+		client.SendState(state)
+		<-delivery.states
+	}
+
+	select {
+	case event := <-completed:
+		require.Equal(t, CompleteRequestEvent{RequestID: "0x01", Envelopes: 2}, event)
+	case <-time.After(time.Second):
+		t.Fatal("request was not completed")
+	}
+	require.Empty(t, client.pending)
+}
+
+func TestRequestMessagesNotStarted(t *testing.T) {
+	_, err := New(&params.WhisperConfig{MailServerEnode: "enode://invalid"}, nil)
+	require.Error(t, err)
+
+	client, err := New(&params.WhisperConfig{MailServerEnode: mailServerEnode}, nil)
+	require.NoError(t, err)
+
+	_, err = client.RequestMessages(MessagesRequest{})
+	require.Equal(t, ErrNotStarted, err)
+}
+
+func TestNewMailServers(t *testing.T) {
+	_, err := New(&params.WhisperConfig{}, nil)
+	require.Equal(t, ErrNoMailServers, err)
+
+	_, err = New(&params.WhisperConfig{MailServerEnodes: []string{mailServerEnode, "enode://invalid"}}, nil)
+	require.Error(t, err)
+
+	// MailServerEnode is the first of mail servers, duplicates are dropped
+	client, err := New(&params.WhisperConfig{
+		MailServerEnode:  mailServerEnode2,
+		MailServerEnodes: []string{mailServerEnode, mailServerEnode2},
+	}, nil)
+	require.NoError(t, err)
+	servers := client.MailServers()
+	require.Len(t, servers, 2)
+	require.Equal(t, MailServerInfo{Enode: mailServerEnode2, RTT: -1, Selected: true}, servers[0])
+	require.Equal(t, MailServerInfo{Enode: mailServerEnode, RTT: -1}, servers[1])
+}
+
+func TestMailServerFailover(t *testing.T) {
+	changes := make(chan ChangeMailServerEvent, 10)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event ChangeMailServerEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventMailServerChanged {
+			changes <- envelope.Event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	client, err := New(&params.WhisperConfig{MailServerEnodes: []string{mailServerEnode, mailServerEnode2, mailServerEnode3}}, nil)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	rtts := map[string]time.Duration{
+		mailServerEnode:  30 * time.Millisecond,
+		mailServerEnode2: 10 * time.Millisecond,
+		mailServerEnode3: 20 * time.Millisecond,
+	}
+	client.probe = func(node *discover.Node) (time.Duration, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if rtt, ok := rtts[node.String()]; ok {
+			return rtt, nil
+		}
+		return 0, errors.New("unreachable")
+	}
+	setUnreachable := func(enode string) {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(rtts, enode)
+	}
+	expectChange := func(expected ChangeMailServerEvent) {
+		select {
+		case event := <-changes:
+			require.Equal(t, expected, event)
+		default:
+			t.Fatal("mail server change was not signalled")
+		}
+	}
+
+	peers := &fakePeers{peers: make(map[discover.NodeID]bool)}
+	peers.AddPeer(client.selected.node)
+	client.peers = peers
+	now := time.Now()
+
+	// the most responsive mail server is selected first
+	client.check(now, true)
+	expectChange(ChangeMailServerEvent{Enode: mailServerEnode2, Previous: mailServerEnode, Reason: ReasonSelected})
+	require.Equal(t, []*p2p.PeerInfo{{ID: client.mailServers[1].node.ID.String()}}, peers.PeersInfo())
+	require.Equal(t, int64(10), client.MailServers()[1].RTT)
+
+	// the next most responsive one replaces the selected one once it's unreachable
+	setUnreachable(mailServerEnode2)
+	client.check(now.Add(time.Minute), false)
+	expectChange(ChangeMailServerEvent{Enode: mailServerEnode3, Previous: mailServerEnode2, Reason: ReasonUnreachable})
+	require.True(t, client.MailServers()[1].Failed)
+
+	// or once it stays disconnected for connectTimeout
+	peers.RemovePeer(client.selected.node)
+	client.check(now.Add(time.Minute+time.Second), false)
+	require.Len(t, changes, 0)
+	client.check(now.Add(time.Minute+client.connectTimeout), false)
+	expectChange(ChangeMailServerEvent{Enode: mailServerEnode, Previous: mailServerEnode3, Reason: ReasonDisconnected})
+
+	// and when requests to it fail
+	client.fail(client.selected, ReasonRequestFailed)
+	expectChange(ChangeMailServerEvent{Enode: mailServerEnode2, Previous: mailServerEnode, Reason: ReasonRequestFailed})
+	require.Len(t, changes, 0)
+}
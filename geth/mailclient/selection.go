@@ -0,0 +1,262 @@
+package mailclient
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventMailServerChanged is triggered when the client switches to another mail server
+const EventMailServerChanged = "mailserver.changed"
+
+const (
+	// probeInterval is how often latencies of mail servers are measured, and the selected one is checked
+	probeInterval = time.Minute
+
+	// probeTimeout is how long a mail server is waited for to accept a connection when probed
+	probeTimeout = 5 * time.Second
+
+	// connectTimeout is how long the selected mail server may stay disconnected before the client fails over
+	connectTimeout = 30 * time.Second
+
+	// failedPeriod is how long a mail server which failed isn't selected again, unless none is available
+	failedPeriod = 10 * time.Minute
+)
+
+// Reasons of switching to another mail server.
+const (
+	ReasonSelected      = "selected"       // a more responsive mail server is selected once the node starts
+	ReasonUnreachable   = "unreachable"    // the selected mail server didn't accept a connection when probed
+	ReasonDisconnected  = "disconnected"   // the selected mail server stayed disconnected, or disconnected during a request
+	ReasonRequestFailed = "request_failed" // a request couldn't be sent to the selected mail server
+)
+
+// ChangeMailServerEvent is a signal sent when the client switches to another mail server.
+type ChangeMailServerEvent struct {
+	Enode    string `json:"enode"`
+	Previous string `json:"previous"`
+	Reason   string `json:"reason"`
+}
+
+// MailServerInfo describes a mail server messages can be requested from.
+type MailServerInfo struct {
+	Enode    string `json:"enode"`
+	RTT      int64  `json:"rtt"` // latency of the last probe, in milliseconds, -1 if it failed or wasn't made yet
+	Selected bool   `json:"selected"`
+	Failed   bool   `json:"failed"` // whether the mail server failed within failedPeriod
+}
+
+// peerManager is the part of p2p.Server the client keeps the node connected to the selected mail server with
+type peerManager interface {
+	AddPeer(node *discover.Node)
+	RemovePeer(node *discover.Node)
+	PeersInfo() []*p2p.PeerInfo
+}
+
+// mailServer is what the client knows of a mail server
+type mailServer struct {
+	node   *discover.Node
+	rtt    time.Duration // latency of the last probe, zero if it failed or wasn't made yet
+	failed time.Time     // when the mail server failed last
+}
+
+// MailServers returns mail servers messages can be requested from, in the order they're configured.
+func (c *Client) MailServers() []MailServerInfo {
+	now := time.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	infos := make([]MailServerInfo, 0, len(c.mailServers))
+	for _, state := range c.mailServers {
+		rtt := int64(-1)
+		if state.rtt > 0 {
+			rtt = int64(state.rtt / time.Millisecond)
+		}
+		infos = append(infos, MailServerInfo{
+			Enode:    state.node.String(),
+			RTT:      rtt,
+			Selected: state == c.selected,
+			Failed:   c.failedRecently(state, now),
+		})
+	}
+
+	return infos
+}
+
+// monitorLoop probes mail servers every probeInterval, selecting the most responsive one first and failing
+// over when the selected one becomes unavailable, until quit is closed.
+func (c *Client) monitorLoop(quit <-chan struct{}) {
+	defer c.stopping.Done()
+
+	c.check(time.Now(), true)
+
+	ticker := time.NewTicker(c.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			c.check(now, false)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// check records latencies of mail servers measured at a time, and switches to the most responsive one if it's
+// the first check, or if the selected mail server is unreachable or stayed disconnected for connectTimeout.
+func (c *Client) check(now time.Time, first bool) {
+	rtts := c.probeAll()
+
+	c.mu.Lock()
+	if c.peers == nil {
+		c.mu.Unlock()
+		return
+	}
+	for i, state := range c.mailServers {
+		state.rtt = rtts[i]
+	}
+	if c.connected(c.selected) {
+		c.lastConnected = now
+	}
+
+	var reason string
+	switch {
+	case first:
+		reason = ReasonSelected
+	case c.selected.rtt == 0:
+		reason = ReasonUnreachable
+	case now.Sub(c.lastConnected) >= c.connectTimeout:
+		reason = ReasonDisconnected
+	}
+
+	var event *ChangeMailServerEvent
+	if reason != "" {
+		if !first {
+			c.selected.failed = now
+		}
+		event = c.selectBest(now, reason)
+	}
+	c.mu.Unlock()
+
+	c.signalChange(event)
+}
+
+// fail records that a mail server failed, switching to another one if it's the selected one.
+func (c *Client) fail(state *mailServer, reason string) {
+	now := time.Now()
+
+	c.mu.Lock()
+	state.failed = now
+	var event *ChangeMailServerEvent
+	if c.peers != nil && state == c.selected {
+		event = c.selectBest(now, reason)
+	}
+	c.mu.Unlock()
+
+	c.signalChange(event)
+}
+
+// selectBest switches to the reachable mail server with the lowest latency which didn't fail recently, or to
+// the next configured one if none is known to be available. It returns an event of the change, if any.
+func (c *Client) selectBest(now time.Time, reason string) *ChangeMailServerEvent {
+	var next *mailServer
+	for _, state := range c.mailServers {
+		if state.rtt == 0 || c.failedRecently(state, now) {
+			continue
+		}
+		if next == nil || state.rtt < next.rtt {
+			next = state
+		}
+	}
+	if next == nil {
+		next = c.mailServers[0]
+		for i, state := range c.mailServers {
+			if state == c.selected {
+				next = c.mailServers[(i+1)%len(c.mailServers)]
+			}
+		}
+	}
+	if next == c.selected {
+		return nil
+	}
+
+	previous := c.selected
+	c.peers.RemovePeer(previous.node)
+	c.peers.AddPeer(next.node)
+	c.selected, c.lastConnected = next, now
+	log.Info("switched to another mail server", "enode", next.node.String(), "reason", reason)
+
+	return &ChangeMailServerEvent{Enode: next.node.String(), Previous: previous.node.String(), Reason: reason}
+}
+
+// connected reports whether the node is connected to a mail server.
+func (c *Client) connected(state *mailServer) bool {
+	id := state.node.ID.String()
+	for _, peer := range c.peers.PeersInfo() {
+		if peer.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failedRecently reports whether a mail server failed within failedPeriod before a time.
+func (c *Client) failedRecently(state *mailServer, now time.Time) bool {
+	return !state.failed.IsZero() && now.Sub(state.failed) < failedPeriod
+}
+
+// probeAll measures latencies of all mail servers at once, zero ones are of unreachable mail servers.
+func (c *Client) probeAll() []time.Duration {
+	rtts := make([]time.Duration, len(c.mailServers))
+
+	var wg sync.WaitGroup
+	for i, state := range c.mailServers {
+		wg.Add(1)
+		go func(i int, node *discover.Node) {
+			defer wg.Done()
+			rtt, err := c.probe(node)
+			if err != nil {
+				log.Debug("mail server is unreachable", "enode", node.String(), "err", err)
+				return
+			}
+			rtts[i] = rtt
+		}(i, state.node)
+	}
+	wg.Wait()
+
+	return rtts
+}
+
+// signalChange sends EventMailServerChanged, if there's a change.
+func (c *Client) signalChange(event *ChangeMailServerEvent) {
+	if event == nil {
+		return
+	}
+
+	c.signals.Send(signal.Envelope{
+		Type:  EventMailServerChanged,
+		Event: event,
+	})
+}
+
+// probeTCP returns how long a mail server takes to accept a TCP connection.
+func probeTCP(node *discover.Node) (time.Duration, error) {
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(node.IP.String(), strconv.Itoa(int(node.TCP))), probeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	conn.Close() // nolint: errcheck
+
+	return rtt, nil
+}
@@ -0,0 +1,271 @@
+// Package mailserver archives Whisper envelopes passing through the node and delivers them on demand
+// to peers which request messages sent while they were offline, e.g. with the mailclient package.
+package mailserver
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// pruneInterval is how often envelopes older than the retention period are removed
+const pruneInterval = time.Hour
+
+var (
+	// ErrNoPassword is returned when a mail server is initialized without a password.
+	ErrNoPassword = errors.New("mail server password is not set")
+
+	// ErrInvalidRequest is returned when a request can't be decrypted or is malformed.
+	ErrInvalidRequest = errors.New("invalid mail server request")
+
+	// ErrInsufficientPoW is returned when a request has a PoW lower than the mail server's minimum.
+	ErrInsufficientPoW = errors.New("insufficient PoW of a mail server request")
+
+	// ErrWrongSigner is returned when a request isn't signed by the peer it comes from.
+	ErrWrongSigner = errors.New("mail server request is not signed by the peer")
+)
+
+// request is a request of envelopes sent on a topic within a time range, or on any topic if it's empty
+type request struct {
+	lower, upper uint32
+	topic        whisper.TopicType
+}
+
+// MailServer archives envelopes received by Whisper and delivers them to peers requesting them.
+// Requests are encrypted with a symmetric key derived from a password, so that only peers knowing
+// the password are served, and signed with the requester's node key. It implements whisper.MailServer,
+// and node.Service, removing envelopes older than the retention period while the node runs.
+type MailServer struct {
+	config *params.MailServerConfig
+
+	mu      sync.RWMutex
+	shh     *whisper.Whisper
+	storage Storage
+	symKey  []byte
+	quit    chan struct{}
+	pruning sync.WaitGroup
+}
+
+// New returns a mail server configured by a mail server config.
+func New(config *params.MailServerConfig) *MailServer {
+	return &MailServer{config: config}
+}
+
+// Init sets the Whisper service envelopes are delivered with and the storage they're archived in,
+// deriving the symmetric key of requests from the password. The mail server closes the storage when stopped.
+func (s *MailServer) Init(shh *whisper.Whisper, storage Storage) error {
+	if s.config.Password == "" {
+		return ErrNoPassword
+	}
+
+	keyID, err := shh.AddSymKeyFromPassword(s.config.Password)
+	if err != nil {
+		return err
+	}
+	symKey, err := shh.GetSymKey(keyID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shh = shh
+	s.storage = storage
+	s.symKey = symKey
+
+	return nil
+}
+
+// Protocols implements node.Service, the mail server has no protocols.
+func (s *MailServer) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, the mail server has no APIs.
+func (s *MailServer) APIs() []rpc.API {
+	return nil
+}
+
+// Start implements node.Service, removing envelopes older than the retention period periodically.
+func (s *MailServer) Start(*p2p.Server) error {
+	if s.config.Retention <= 0 {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quit = make(chan struct{})
+	s.pruning.Add(1)
+	go s.pruneLoop(s.quit)
+
+	return nil
+}
+
+// Stop implements node.Service, closing the storage. Envelopes are neither archived nor delivered afterwards.
+func (s *MailServer) Stop() error {
+	s.mu.Lock()
+	if s.quit != nil {
+		close(s.quit)
+		s.quit = nil
+	}
+	s.mu.Unlock()
+
+	s.pruning.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.storage == nil {
+		return nil
+	}
+	err := s.storage.Close()
+	s.storage = nil
+
+	return err
+}
+
+// Archive implements whisper.MailServer, storing an envelope received by Whisper.
+func (s *MailServer) Archive(envelope *whisper.Envelope) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.storage == nil {
+		return
+	}
+
+	if err := s.storage.Put(envelope); err != nil {
+		log.Error("failed to archive an envelope", "hash", envelope.Hash().Hex(), "err", err)
+	}
+}
+
+// DeliverMail implements whisper.MailServer, sending envelopes requested by a peer directly to it.
+func (s *MailServer) DeliverMail(peer *whisper.Peer, envelope *whisper.Envelope) {
+	if peer == nil {
+		log.Error("mail server request without a peer")
+		return
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.storage == nil {
+		return
+	}
+
+	req, err := s.validateRequest(peer.ID(), envelope)
+	if err != nil {
+		log.Warn("rejected a mail server request", "hash", envelope.Hash().Hex(), "err", err)
+		return
+	}
+
+	delivered, err := s.query(req, func(archived *whisper.Envelope) error {
+		return s.shh.SendP2PDirect(peer, archived)
+	})
+	if err != nil {
+		log.Error("failed to deliver envelopes to a peer", "hash", envelope.Hash().Hex(), "delivered", delivered, "err", err)
+		return
+	}
+
+	log.Info("delivered envelopes to a peer", "hash", envelope.Hash().Hex(), "from", req.lower, "to", req.upper, "delivered", delivered)
+}
+
+// query calls fn with archived envelopes matching a request and returns how many matched.
+func (s *MailServer) query(req request, fn func(*whisper.Envelope) error) (int, error) {
+	matched := 0
+	err := s.storage.Query(req.lower, req.upper, func(envelope *whisper.Envelope) error {
+		if req.topic != (whisper.TopicType{}) && envelope.Topic != req.topic {
+			return nil
+		}
+		matched++
+		return fn(envelope)
+	})
+
+	return matched, err
+}
+
+// validateRequest decrypts a request with the symmetric key, checks it's signed by the peer it comes from,
+// and parses its payload, which is a time range of two big-endian timestamps and an optional topic.
+func (s *MailServer) validateRequest(peerID []byte, envelope *whisper.Envelope) (request, error) {
+	if s.config.MinimumPoW > 0 && envelope.PoW() < s.config.MinimumPoW {
+		return request{}, ErrInsufficientPoW
+	}
+
+	message := envelope.Open(&whisper.Filter{KeySym: s.symKey})
+	if message == nil || len(message.Payload) < 8 {
+		return request{}, ErrInvalidRequest
+	}
+
+	if message.Src == nil {
+		return request{}, ErrWrongSigner
+	}
+	src := crypto.FromECDSAPub(message.Src)
+	if len(src)-len(peerID) == 1 {
+		src = src[1:] // the peer ID omits the prefix of an uncompressed key
+	}
+	if !bytes.Equal(peerID, src) {
+		return request{}, ErrWrongSigner
+	}
+
+	req := request{
+		lower: binary.BigEndian.Uint32(message.Payload[:4]),
+		upper: binary.BigEndian.Uint32(message.Payload[4:8]),
+	}
+	if len(message.Payload) >= 8+whisper.TopicLength {
+		req.topic = whisper.BytesToTopic(message.Payload[8:])
+	}
+
+	return req, nil
+}
+
+// pruneLoop removes envelopes older than the retention period, on start and every pruneInterval.
+func (s *MailServer) pruneLoop(quit <-chan struct{}) {
+	defer s.pruning.Done()
+
+	ticker := time.NewTicker(pruneInterval)
+	defer ticker.Stop()
+
+	for {
+		s.prune(time.Now())
+
+		select {
+		case <-ticker.C:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// prune removes envelopes sent before the retention period preceding a time.
+func (s *MailServer) prune(now time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.storage == nil {
+		return
+	}
+
+	before := now.Add(-time.Duration(s.config.Retention) * time.Hour).Unix()
+	if before <= 0 {
+		return
+	}
+
+	removed, err := s.storage.Prune(uint32(before))
+	if err != nil {
+		log.Error("failed to remove expired envelopes", "err", err)
+		return
+	}
+	if removed > 0 {
+		log.Info("removed expired envelopes", "removed", removed)
+	}
+}
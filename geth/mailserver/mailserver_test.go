@@ -0,0 +1,144 @@
+package mailserver
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+const password = "mail server password"
+
+// makeRequest returns a request envelope as mail server clients send it
+func makeRequest(t *testing.T, symKey []byte, key *ecdsa.PrivateKey, lower, upper uint32, topic whisper.TopicType) *whisper.Envelope {
+	payload := make([]byte, 8, 8+whisper.TopicLength)
+	binary.BigEndian.PutUint32(payload, lower)
+	binary.BigEndian.PutUint32(payload[4:], upper)
+	if topic != (whisper.TopicType{}) {
+		payload = append(payload, topic[:]...)
+	}
+
+	messageParams := &whisper.MessageParams{
+		TTL:      60,
+		Src:      key,
+		KeySym:   symKey,
+		Topic:    topic,
+		WorkTime: 1,
+		PoW:      whisper.DefaultMinimumPoW,
+		Payload:  payload,
+	}
+	message, err := whisper.NewSentMessage(messageParams)
+	require.NoError(t, err)
+	envelope, err := message.Wrap(messageParams)
+	require.NoError(t, err)
+
+	return envelope
+}
+
+func newTestMailServer(t *testing.T, config *params.MailServerConfig) (*MailServer, []byte, string) {
+	storage, dir := newTestStorage(t)
+
+	shh := whisper.New(nil)
+	server := New(config)
+	require.NoError(t, server.Init(shh, storage))
+
+	keyID, err := shh.AddSymKeyFromPassword(password)
+	require.NoError(t, err)
+	symKey, err := shh.GetSymKey(keyID)
+	require.NoError(t, err)
+
+	return server, symKey, dir
+}
+
+func TestInitWithoutPassword(t *testing.T) {
+	server := New(&params.MailServerConfig{})
+	require.Equal(t, ErrNoPassword, server.Init(whisper.New(nil), nil))
+}
+
+func TestValidateRequest(t *testing.T) {
+	config := &params.MailServerConfig{Password: password, MinimumPoW: whisper.DefaultMinimumPoW}
+	server, symKey, dir := newTestMailServer(t, config)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	defer server.Stop()     // nolint: errcheck
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	peerID := crypto.FromECDSAPub(&key.PublicKey)[1:]
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	req, err := server.validateRequest(peerID, makeRequest(t, symKey, key, 100, 200, topic))
+	require.NoError(t, err)
+	require.Equal(t, request{lower: 100, upper: 200, topic: topic}, req)
+
+	req, err = server.validateRequest(peerID, makeRequest(t, symKey, key, 100, 200, whisper.TopicType{}))
+	require.NoError(t, err)
+	require.Equal(t, request{lower: 100, upper: 200}, req)
+
+	// requests signed by another peer are rejected
+	other, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	_, err = server.validateRequest(peerID, makeRequest(t, symKey, other, 100, 200, topic))
+	require.Equal(t, ErrWrongSigner, err)
+
+	// requests encrypted without the password are rejected
+	wrongKey := make([]byte, len(symKey))
+	copy(wrongKey, symKey)
+	wrongKey[0]++
+	_, err = server.validateRequest(peerID, makeRequest(t, wrongKey, key, 100, 200, topic))
+	require.Equal(t, ErrInvalidRequest, err)
+
+	config.MinimumPoW = 1000
+	_, err = server.validateRequest(peerID, makeRequest(t, symKey, key, 100, 200, topic))
+	require.Equal(t, ErrInsufficientPoW, err)
+}
+
+func TestQueryArchived(t *testing.T) {
+	server, _, dir := newTestMailServer(t, &params.MailServerConfig{Password: password})
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	server.Archive(makeEnvelope(100, topic))
+	server.Archive(makeEnvelope(150, whisper.TopicType{0x05}))
+	server.Archive(makeEnvelope(200, topic))
+
+	var sent []uint32
+	collect := func(envelope *whisper.Envelope) error {
+		sent = append(sent, envelope.Expiry-envelope.TTL)
+		return nil
+	}
+
+	matched, err := server.query(request{lower: 100, upper: 200, topic: topic}, collect)
+	require.NoError(t, err)
+	require.Equal(t, 2, matched)
+	require.Equal(t, []uint32{100, 200}, sent)
+
+	sent = nil
+	matched, err = server.query(request{lower: 120, upper: 200}, collect)
+	require.NoError(t, err)
+	require.Equal(t, 2, matched)
+	require.Equal(t, []uint32{150, 200}, sent)
+
+	// envelopes are neither archived nor delivered once the mail server is stopped
+	require.NoError(t, server.Stop())
+	server.Archive(makeEnvelope(300, topic))
+	server.DeliverMail(nil, makeEnvelope(300, topic))
+}
+
+func TestPruneRetention(t *testing.T) {
+	server, _, dir := newTestMailServer(t, &params.MailServerConfig{Password: password, Retention: 1})
+	defer os.RemoveAll(dir) // nolint: errcheck
+	defer server.Stop()     // nolint: errcheck
+
+	now := time.Unix(100000, 0)
+	server.Archive(makeEnvelope(uint32(now.Add(-2*time.Hour).Unix()), whisper.TopicType{}))
+	server.Archive(makeEnvelope(uint32(now.Add(-time.Minute).Unix()), whisper.TopicType{}))
+
+	server.prune(now)
+	require.Equal(t, []uint32{uint32(now.Add(-time.Minute).Unix())}, queryAll(t, server.storage, 0, uint32(now.Unix())))
+}
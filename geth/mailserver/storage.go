@@ -0,0 +1,129 @@
+package mailserver
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// keyLength is the length of keys of archived envelopes, a timestamp followed by a hash
+const keyLength = 4 + common.HashLength
+
+// Storage archives envelopes by the time they were sent at.
+type Storage interface {
+	// Put archives an envelope, an envelope archived again is overwritten.
+	Put(envelope *whisper.Envelope) error
+
+	// Query calls fn with envelopes sent within a time range, inclusive, in order of the time
+	// they were sent at. It stops at the first error returned by fn and returns it.
+	Query(lower, upper uint32, fn func(*whisper.Envelope) error) error
+
+	// Prune removes envelopes sent before a time and returns how many were removed.
+	Prune(before uint32) (int, error)
+
+	// Close releases the storage, it can't be used afterwards.
+	Close() error
+}
+
+// LevelDBStorage is a storage of envelopes in LevelDB, keyed by the time an envelope was sent at
+// and its hash, so that envelopes sent within a time range are read with a single iterator.
+type LevelDBStorage struct {
+	db *leveldb.DB
+}
+
+// NewLevelDBStorage opens or creates a LevelDB database of envelopes in a directory.
+func NewLevelDBStorage(path string) (*LevelDBStorage, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LevelDBStorage{db: db}, nil
+}
+
+// Put implements Storage.
+func (s *LevelDBStorage) Put(envelope *whisper.Envelope) error {
+	data, err := rlp.EncodeToBytes(envelope)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Put(envelopeKey(envelope.Expiry-envelope.TTL, envelope.Hash()), data, nil)
+}
+
+// Query implements Storage.
+func (s *LevelDBStorage) Query(lower, upper uint32, fn func(*whisper.Envelope) error) error {
+	if lower > upper {
+		return nil
+	}
+
+	it := s.db.NewIterator(timeRange(lower, upper), nil)
+	defer it.Release()
+
+	for it.Next() {
+		var envelope whisper.Envelope
+		if err := rlp.DecodeBytes(it.Value(), &envelope); err != nil {
+			return err
+		}
+		if err := fn(&envelope); err != nil {
+			return err
+		}
+	}
+
+	return it.Error()
+}
+
+// Prune implements Storage.
+func (s *LevelDBStorage) Prune(before uint32) (int, error) {
+	if before == 0 {
+		return 0, nil
+	}
+
+	it := s.db.NewIterator(timeRange(0, before-1), nil)
+	defer it.Release()
+
+	batch := new(leveldb.Batch)
+	for it.Next() {
+		batch.Delete(it.Key())
+	}
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+	if batch.Len() == 0 {
+		return 0, nil
+	}
+
+	if err := s.db.Write(batch, nil); err != nil {
+		return 0, err
+	}
+
+	return batch.Len(), nil
+}
+
+// Close implements Storage.
+func (s *LevelDBStorage) Close() error {
+	return s.db.Close()
+}
+
+// envelopeKey returns a key of an envelope sent at a time, in seconds.
+func envelopeKey(timestamp uint32, hash common.Hash) []byte {
+	key := make([]byte, keyLength)
+	binary.BigEndian.PutUint32(key, timestamp)
+	copy(key[4:], hash[:])
+	return key
+}
+
+// timeRange returns a range of keys of envelopes sent within a time range, inclusive.
+func timeRange(lower, upper uint32) *util.Range {
+	limit := []byte(nil) // up to the last key
+	if upper < math.MaxUint32 {
+		limit = envelopeKey(upper+1, common.Hash{})
+	}
+
+	return &util.Range{Start: envelopeKey(lower, common.Hash{}), Limit: limit}
+}
@@ -0,0 +1,70 @@
+package mailserver
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+// makeEnvelope returns an envelope sent at a time on a topic
+func makeEnvelope(sent uint32, topic whisper.TopicType) *whisper.Envelope {
+	return &whisper.Envelope{
+		Version:  []byte{0},
+		Expiry:   sent + 50,
+		TTL:      50,
+		Topic:    topic,
+		AESNonce: []byte{0x01},
+		Data:     []byte{byte(sent)},
+	}
+}
+
+func newTestStorage(t *testing.T) (*LevelDBStorage, string) {
+	dir, err := ioutil.TempDir("", "mailserver")
+	require.NoError(t, err)
+
+	storage, err := NewLevelDBStorage(dir)
+	require.NoError(t, err)
+
+	return storage, dir
+}
+
+func queryAll(t *testing.T, storage Storage, lower, upper uint32) []uint32 {
+	sent := []uint32{}
+	err := storage.Query(lower, upper, func(envelope *whisper.Envelope) error {
+		sent = append(sent, envelope.Expiry-envelope.TTL)
+		return nil
+	})
+	require.NoError(t, err)
+	return sent
+}
+
+func TestLevelDBStorage(t *testing.T) {
+	storage, dir := newTestStorage(t)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	for _, sent := range []uint32{300, 100, 200, math.MaxUint32 - 50} {
+		require.NoError(t, storage.Put(makeEnvelope(sent, topic)))
+	}
+
+	// time ranges are inclusive, envelopes are ordered by the time they were sent at
+	require.Equal(t, []uint32{100, 200}, queryAll(t, storage, 100, 200))
+	require.Equal(t, []uint32{300, math.MaxUint32 - 50}, queryAll(t, storage, 201, math.MaxUint32))
+	require.Empty(t, queryAll(t, storage, 200, 100))
+
+	removed, err := storage.Prune(200)
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	require.Equal(t, []uint32{200, 300}, queryAll(t, storage, 0, 1000))
+
+	// envelopes are kept when the storage is reopened
+	require.NoError(t, storage.Close())
+	storage, err = NewLevelDBStorage(dir)
+	require.NoError(t, err)
+	defer storage.Close() // nolint: errcheck
+	require.Equal(t, []uint32{200, 300}, queryAll(t, storage, 0, 1000))
+}
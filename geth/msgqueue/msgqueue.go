@@ -0,0 +1,385 @@
+// Package msgqueue holds messages signed with personal_sign (EIP-191) or eth_signTypedData (EIP-712)
+// until the user approves them with the password, in the same way as the transaction queue holds transactions.
+package msgqueue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/pborman/uuid"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+const (
+	// EventMessageQueued is triggered when a personal_sign request is queued
+	EventMessageQueued = "message.queued"
+
+	// EventMessageFailed is triggered when a personal_sign request fails
+	EventMessageFailed = "message.failed"
+
+	// DefaultMessageQueueCap defines how many messages can be queued.
+	DefaultMessageQueueCap = 35
+
+	// PersonalSignMethod is a method signing a message prefixed as defined by EIP-191
+	PersonalSignMethod = "personal_sign"
+
+	// SignTypedDataMethod is a method signing typed data as defined by EIP-712
+	SignTypedDataMethod = "eth_signTypedData"
+
+	// DefaultMessageSignTimeout defines how many seconds to wait for the user to approve a message.
+	DefaultMessageSignTimeout = 300
+)
+
+// Sign message response codes
+const (
+	SignMessageNoErrorCode        = "0"
+	SignMessageDefaultErrorCode   = "1"
+	SignMessagePasswordErrorCode  = "2"
+	SignMessageTimeoutErrorCode   = "3"
+	SignMessageDiscardedErrorCode = "4"
+)
+
+var (
+	//ErrQueuedMessageIDNotFound - error message id not found
+	ErrQueuedMessageIDNotFound = errors.New("message id not found")
+	//ErrQueuedMessageTimedOut - error message signing timed out
+	ErrQueuedMessageTimedOut = errors.New("message signing timed out")
+	//ErrQueuedMessageDiscarded - error message discarded
+	ErrQueuedMessageDiscarded = errors.New("message has been discarded")
+	//ErrQueuedMessageInProgress - error message is being signed
+	ErrQueuedMessageInProgress = errors.New("message is being signed")
+	//ErrMessageQueueFull - error too many messages are waiting for approval
+	ErrMessageQueueFull = errors.New("too many messages are waiting for approval")
+	//ErrWatchOnlyAccount - error message of a watch-only account
+	ErrWatchOnlyAccount = errors.New("message can't be signed by a watch-only account")
+	//ErrInvalidPersonalSignParams - error personal_sign called with invalid params
+	ErrInvalidPersonalSignParams = errors.New("personal_sign expects hex encoded data and an address")
+)
+
+var messageReturnCodes = map[error]string{
+	nil:                       SignMessageNoErrorCode,
+	keystore.ErrDecrypt:       SignMessagePasswordErrorCode,
+	ErrQueuedMessageTimedOut:  SignMessageTimeoutErrorCode,
+	ErrQueuedMessageDiscarded: SignMessageDiscardedErrorCode,
+}
+
+// Manager holds messages until they are signed or discarded.
+type Manager struct {
+	accountManager common.AccountManager
+	clock          common.Clock
+
+	mu       sync.Mutex // guards messages
+	messages map[common.QueuedMessageID]*common.QueuedMessage
+
+	signals signal.Sender // sends message signals, see SetSignalHandler
+}
+
+// NewManager returns a new Manager.
+func NewManager(accountManager common.AccountManager) *Manager {
+	return &Manager{
+		accountManager: accountManager,
+		clock:          common.SystemClock,
+		messages:       make(map[common.QueuedMessageID]*common.QueuedMessage),
+	}
+}
+
+// SetSignalHandler sets a handler of signals sent by the message queue, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (m *Manager) SetSignalHandler(handler func(signal.Envelope)) {
+	m.signals.SetHandler(handler)
+}
+
+// SetClock replaces a clock used to time out queued messages,
+// e.g. with a suspendable clock or a fake one in tests.
+func (m *Manager) SetClock(clock common.Clock) {
+	m.clock = clock
+}
+
+// SignMessage queues a message of an account and blocks until it's signed with CompleteMessage,
+// discarded with DiscardMessage, the context is done or it times out. EventMessageQueued is sent
+// with the message, so that the application can show it to the user.
+func (m *Manager) SignMessage(ctx context.Context, address gethcommon.Address, data []byte) (hexutil.Bytes, error) {
+	log.Info("queue a new message", "address", address.Hex(), "size", len(data))
+
+	msg := &common.QueuedMessage{
+		ID:      common.QueuedMessageID(uuid.New()),
+		Context: ctx,
+		Address: address,
+		Data:    data,
+		Hash:    SignHash(data),
+		Done:    make(chan struct{}),
+	}
+
+	return m.queue(msg, SignMessageEvent{
+		ID:        string(msg.ID),
+		Method:    PersonalSignMethod,
+		Address:   address.Hex(),
+		Data:      msg.Data,
+		Text:      messageText(data),
+		MessageID: common.MessageIDFromContext(ctx),
+	})
+}
+
+// SignTypedData queues typed data of an account in the same way as SignMessage. The application is sent
+// a preview of the domain and the message, and the hash defined by EIP-712 is signed once it's approved.
+func (m *Manager) SignTypedData(ctx context.Context, address gethcommon.Address, typedData *TypedData) (hexutil.Bytes, error) {
+	log.Info("queue new typed data", "address", address.Hex(), "type", typedData.PrimaryType)
+
+	hash, err := typedData.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &common.QueuedMessage{
+		ID:      common.QueuedMessageID(uuid.New()),
+		Context: ctx,
+		Address: address,
+		Hash:    hash,
+		Done:    make(chan struct{}),
+	}
+
+	return m.queue(msg, SignMessageEvent{
+		ID:        string(msg.ID),
+		Method:    SignTypedDataMethod,
+		Address:   address.Hex(),
+		TypedData: typedData.Preview(),
+		MessageID: common.MessageIDFromContext(ctx),
+	})
+}
+
+// queue adds a message to the queue, signals it to the application and waits until it's done.
+func (m *Manager) queue(msg *common.QueuedMessage, event SignMessageEvent) (hexutil.Bytes, error) {
+	if m.accountManager.IsWatchOnlyAccount(msg.Address) {
+		log.Warn("message of a watch-only account rejected", "address", msg.Address.Hex())
+		return nil, ErrWatchOnlyAccount
+	}
+
+	m.mu.Lock()
+	if len(m.messages) >= DefaultMessageQueueCap {
+		m.mu.Unlock()
+		return nil, ErrMessageQueueFull
+	}
+	m.messages[msg.ID] = msg
+	m.mu.Unlock()
+
+	m.signals.Send(signal.Envelope{
+		Type:  EventMessageQueued,
+		Event: event,
+	})
+
+	var err error
+	select {
+	case <-msg.Done:
+		err = msg.Err
+	case <-msg.Context.Done():
+		err = msg.Context.Err()
+	case <-m.clock.After(DefaultMessageSignTimeout * time.Second):
+		err = ErrQueuedMessageTimedOut
+	}
+
+	if err != nil {
+		m.remove(msg.ID)
+		m.messageFailed(msg, err)
+		return nil, err
+	}
+
+	return msg.Signature, nil
+}
+
+// CompleteMessage signs a queued message with the key of its account decrypted with the password.
+// With a wrong password, the message stays queued, so that the user can try again.
+func (m *Manager) CompleteMessage(id common.QueuedMessageID, password string) (hexutil.Bytes, error) {
+	log.Info("complete message", "id", id)
+
+	m.mu.Lock()
+	msg, ok := m.messages[id]
+	if !ok {
+		m.mu.Unlock()
+		return nil, ErrQueuedMessageIDNotFound
+	}
+	if msg.InProgress {
+		m.mu.Unlock()
+		return nil, ErrQueuedMessageInProgress
+	}
+	msg.InProgress = true
+	m.mu.Unlock()
+
+	_, accountKey, err := m.accountManager.AddressToDecryptedAccount(msg.Address.Hex(), password)
+	if err != nil {
+		log.Warn("failed to decrypt a key of a message", "id", id, "err", err)
+		m.mu.Lock()
+		msg.InProgress = false
+		m.mu.Unlock()
+		m.messageFailed(msg, err)
+		return nil, err
+	}
+
+	signature, err := crypto.Sign(msg.Hash, accountKey.PrivateKey)
+	if err == nil {
+		signature[64] += 27 // V is 27 or 28, as in personal_sign of go-ethereum
+	}
+
+	if !m.remove(id) {
+		return nil, ErrQueuedMessageIDNotFound // discarded or timed out meanwhile
+	}
+	msg.Signature = signature
+	msg.Err = err
+	close(msg.Done)
+
+	return signature, err
+}
+
+// DiscardMessage discards a queued message, SignMessage returns ErrQueuedMessageDiscarded.
+func (m *Manager) DiscardMessage(id common.QueuedMessageID) error {
+	m.mu.Lock()
+	msg, ok := m.messages[id]
+	delete(m.messages, id)
+	m.mu.Unlock()
+
+	if !ok {
+		return ErrQueuedMessageIDNotFound
+	}
+
+	msg.Err = ErrQueuedMessageDiscarded
+	close(msg.Done)
+
+	return nil
+}
+
+// PersonalSignRPCHandler is a handler for personal_sign method. It accepts hex encoded data and an address,
+// and returns a signature once the message is approved. A password param is ignored, as messages are only
+// signed with the password given by the user to CompleteMessage.
+func (m *Manager) PersonalSignRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, ErrInvalidPersonalSignParams
+	}
+
+	encoded, ok := args[0].(string)
+	if !ok {
+		return nil, ErrInvalidPersonalSignParams
+	}
+	data, err := hexutil.Decode(encoded)
+	if err != nil {
+		return nil, ErrInvalidPersonalSignParams
+	}
+
+	address, ok := args[1].(string)
+	if !ok || !gethcommon.IsHexAddress(address) {
+		return nil, ErrInvalidPersonalSignParams
+	}
+
+	return m.SignMessage(ctx, gethcommon.HexToAddress(address), data)
+}
+
+// SignTypedDataRPCHandler is a handler for eth_signTypedData method. It accepts an address and typed data,
+// either as JSON or as an encoded JSON string, and returns a signature once the typed data is approved.
+// Typed data can only be signed by the selected account.
+func (m *Manager) SignTypedDataRPCHandler(ctx context.Context, args ...interface{}) (interface{}, error) {
+	if len(args) < 2 {
+		return nil, ErrInvalidSignTypedDataArgs
+	}
+
+	address, ok := args[0].(string)
+	if !ok || !gethcommon.IsHexAddress(address) {
+		return nil, ErrInvalidSignTypedDataArgs
+	}
+
+	encoded, ok := args[1].(string)
+	if !ok {
+		data, err := json.Marshal(args[1])
+		if err != nil {
+			return nil, ErrInvalidSignTypedDataArgs
+		}
+		encoded = string(data)
+	}
+	typedData, err := ParseTypedData([]byte(encoded))
+	if err != nil {
+		return nil, err
+	}
+
+	selectedAccount, err := m.accountManager.SelectedAccount()
+	if err != nil {
+		return nil, err
+	}
+	if selectedAccount.Address != gethcommon.HexToAddress(address) {
+		return nil, ErrTypedDataSenderMismatch
+	}
+
+	return m.SignTypedData(ctx, selectedAccount.Address, typedData)
+}
+
+// remove removes a message from the queue, reporting whether it was queued.
+func (m *Manager) remove(id common.QueuedMessageID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.messages[id]
+	delete(m.messages, id)
+
+	return ok
+}
+
+// messageFailed signals an error of a message up to the application.
+func (m *Manager) messageFailed(msg *common.QueuedMessage, err error) {
+	code, ok := messageReturnCodes[err]
+	if !ok {
+		code = SignMessageDefaultErrorCode
+	}
+
+	m.signals.Send(signal.Envelope{
+		Type: EventMessageFailed,
+		Event: ReturnSignMessageEvent{
+			ID:           string(msg.ID),
+			Address:      msg.Address.Hex(),
+			MessageID:    common.MessageIDFromContext(msg.Context),
+			ErrorMessage: err.Error(),
+			ErrorCode:    code,
+		},
+	})
+}
+
+// SignMessageEvent is a signal sent on a personal_sign or eth_signTypedData request
+type SignMessageEvent struct {
+	ID        string            `json:"id"`
+	Method    string            `json:"method"`
+	Address   string            `json:"address"`
+	Data      hexutil.Bytes     `json:"data,omitempty"`
+	Text      string            `json:"text,omitempty"` // data as text, empty if it's not valid UTF-8
+	TypedData *TypedDataPreview `json:"typed_data,omitempty"`
+	MessageID string            `json:"message_id"`
+}
+
+// ReturnSignMessageEvent is a signal sent when a personal_sign request fails
+type ReturnSignMessageEvent struct {
+	ID           string `json:"id"`
+	Address      string `json:"address"`
+	MessageID    string `json:"message_id"`
+	ErrorMessage string `json:"error_message"`
+	ErrorCode    string `json:"error_code"`
+}
+
+// SignHash returns a hash of a message signed with personal_sign, which is prefixed
+// as defined by EIP-191, so that it can't be a hash of a transaction.
+func SignHash(data []byte) []byte {
+	return crypto.Keccak256([]byte(fmt.Sprintf("\x19Ethereum Signed Message:\n%d%s", len(data), data)))
+}
+
+// messageText returns data as text to be shown to the user, if it's valid UTF-8.
+func messageText(data []byte) string {
+	if !utf8.Valid(data) {
+		return ""
+	}
+
+	return string(data)
+}
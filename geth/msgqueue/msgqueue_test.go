@@ -0,0 +1,203 @@
+package msgqueue
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/golang/mock/gomock"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/signal"
+	. "github.com/status-im/status-go/testing"
+	"github.com/stretchr/testify/require"
+)
+
+const testPassword = "password"
+
+type signResult struct {
+	signature hexutil.Bytes
+	err       error
+}
+
+// envelope is a signal of the queue as it's received by the application
+type envelope struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// newTestManager returns a manager signing messages of a single account, and a channel of its signals.
+func newTestManager(t *testing.T) (*Manager, *keystore.Key, chan envelope, func()) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	accountKey := &keystore.Key{Address: crypto.PubkeyToAddress(key.PublicKey), PrivateKey: key}
+
+	ctrl := gomock.NewController(t)
+	accountManager := common.NewMockAccountManager(ctrl)
+	accountManager.EXPECT().IsWatchOnlyAccount(gomock.Any()).Return(false).AnyTimes()
+	accountManager.EXPECT().AddressToDecryptedAccount(accountKey.Address.Hex(), testPassword).
+		Return(accounts.Account{Address: accountKey.Address}, accountKey, nil).AnyTimes()
+	accountManager.EXPECT().AddressToDecryptedAccount(accountKey.Address.Hex(), gomock.Not(testPassword)).
+		Return(accounts.Account{}, nil, keystore.ErrDecrypt).AnyTimes()
+
+	signals := make(chan envelope, 10)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var e envelope
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &e))
+		signals <- e
+	})
+
+	return NewManager(accountManager), accountKey, signals, func() {
+		signal.ResetDefaultNodeNotificationHandler()
+		ctrl.Finish()
+	}
+}
+
+// signAsync calls SignMessage in a goroutine and returns the queued message signal.
+func signAsync(t *testing.T, m *Manager, key *keystore.Key, data []byte, signals chan envelope) (SignMessageEvent, chan signResult) {
+	results := make(chan signResult, 1)
+	go func() {
+		signature, err := m.SignMessage(context.Background(), key.Address, data)
+		results <- signResult{signature, err}
+	}()
+
+	e := <-signals
+	require.Equal(t, EventMessageQueued, e.Type)
+	var event SignMessageEvent
+	require.NoError(t, json.Unmarshal(e.Event, &event))
+
+	return event, results
+}
+
+func TestSignMessage(t *testing.T) {
+	m, key, signals, teardown := newTestManager(t)
+	defer teardown()
+
+	event, results := signAsync(t, m, key, []byte("Hello, Status!"), signals)
+	require.Equal(t, key.Address.Hex(), event.Address)
+	require.Equal(t, "Hello, Status!", event.Text)
+
+	// wrong password keeps the message queued
+	_, err := m.CompleteMessage(common.QueuedMessageID(event.ID), "wrong password")
+	require.Equal(t, keystore.ErrDecrypt, err)
+	e := <-signals
+	require.Equal(t, EventMessageFailed, e.Type)
+	var failed ReturnSignMessageEvent
+	require.NoError(t, json.Unmarshal(e.Event, &failed))
+	require.Equal(t, SignMessagePasswordErrorCode, failed.ErrorCode)
+
+	signature, err := m.CompleteMessage(common.QueuedMessageID(event.ID), testPassword)
+	require.NoError(t, err)
+	result := <-results
+	require.NoError(t, result.err)
+	require.Equal(t, signature, result.signature)
+
+	// signature is recoverable as of personal_sign
+	require.Len(t, signature, 65)
+	require.Contains(t, []byte{27, 28}, signature[64])
+	sig := append([]byte{}, signature...)
+	sig[64] -= 27
+	pubKey, err := crypto.SigToPub(SignHash([]byte("Hello, Status!")), sig)
+	require.NoError(t, err)
+	require.Equal(t, key.Address, crypto.PubkeyToAddress(*pubKey))
+
+	_, err = m.CompleteMessage(common.QueuedMessageID(event.ID), testPassword)
+	require.Equal(t, ErrQueuedMessageIDNotFound, err)
+}
+
+func TestDiscardMessage(t *testing.T) {
+	m, key, signals, teardown := newTestManager(t)
+	defer teardown()
+
+	// binary data isn't shown as text
+	event, results := signAsync(t, m, key, []byte{0xff, 0xfe}, signals)
+	require.Empty(t, event.Text)
+	require.Equal(t, hexutil.Bytes{0xff, 0xfe}, event.Data)
+
+	require.NoError(t, m.DiscardMessage(common.QueuedMessageID(event.ID)))
+	require.Equal(t, ErrQueuedMessageDiscarded, (<-results).err)
+	require.Equal(t, EventMessageFailed, (<-signals).Type)
+	require.Equal(t, ErrQueuedMessageIDNotFound, m.DiscardMessage(common.QueuedMessageID(event.ID)))
+}
+
+func TestMessageTimeout(t *testing.T) {
+	m, key, signals, teardown := newTestManager(t)
+	defer teardown()
+	clock := NewFakeClock(time.Now())
+	m.SetClock(clock)
+
+	event, results := signAsync(t, m, key, []byte("timeout"), signals)
+	clock.BlockUntil(1)
+	clock.Advance(DefaultMessageSignTimeout * time.Second)
+	require.Equal(t, ErrQueuedMessageTimedOut, (<-results).err)
+
+	_, err := m.CompleteMessage(common.QueuedMessageID(event.ID), testPassword)
+	require.Equal(t, ErrQueuedMessageIDNotFound, err)
+}
+
+func TestPersonalSignRPCHandlerParams(t *testing.T) {
+	m, _, _, teardown := newTestManager(t)
+	defer teardown()
+
+	for _, args := range [][]interface{}{
+		{},
+		{"0x01"},
+		{"not hex", "0x0000000000000000000000000000000000000001"},
+		{"0x01", "not an address"},
+		{1, "0x0000000000000000000000000000000000000001"},
+	} {
+		_, err := m.PersonalSignRPCHandler(context.Background(), args...)
+		require.Equal(t, ErrInvalidPersonalSignParams, err, "args: %v", args)
+	}
+}
+
+func TestSignTypedData(t *testing.T) {
+	m, key, signals, teardown := newTestManager(t)
+	defer teardown()
+	m.accountManager.(*common.MockAccountManager).EXPECT().SelectedAccount().
+		Return(&common.SelectedExtKey{Address: key.Address, AccountKey: key}, nil).AnyTimes()
+
+	// only the selected account signs typed data
+	_, err := m.SignTypedDataRPCHandler(context.Background(), "0x0000000000000000000000000000000000000001", mailTypedData)
+	require.Equal(t, ErrTypedDataSenderMismatch, err)
+
+	// typed data is accepted as decoded JSON too
+	var decoded interface{}
+	require.NoError(t, json.Unmarshal([]byte(mailTypedData), &decoded))
+
+	results := make(chan signResult, 1)
+	go func() {
+		signature, err := m.SignTypedDataRPCHandler(context.Background(), key.Address.Hex(), decoded)
+		signatureBytes, _ := signature.(hexutil.Bytes)
+		results <- signResult{signatureBytes, err}
+	}()
+
+	e := <-signals
+	require.Equal(t, EventMessageQueued, e.Type)
+	var event SignMessageEvent
+	require.NoError(t, json.Unmarshal(e.Event, &event))
+	require.Equal(t, SignTypedDataMethod, event.Method)
+	require.Empty(t, event.Data)
+	require.NotNil(t, event.TypedData)
+	require.Equal(t, "Mail", event.TypedData.PrimaryType)
+	require.Len(t, event.TypedData.Message, 3)
+
+	_, err = m.CompleteMessage(common.QueuedMessageID(event.ID), testPassword)
+	require.NoError(t, err)
+	result := <-results
+	require.NoError(t, result.err)
+
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+	hash, err := typedData.Hash()
+	require.NoError(t, err)
+	sig := append([]byte{}, result.signature...)
+	sig[64] -= 27
+	pubKey, err := crypto.SigToPub(hash, sig)
+	require.NoError(t, err)
+	require.Equal(t, key.Address, crypto.PubkeyToAddress(*pubKey))
+}
@@ -0,0 +1,493 @@
+package msgqueue
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// domainType is a name of the type of the EIP-712 domain separator
+const domainType = "EIP712Domain"
+
+// typed data errors
+var (
+	ErrTypedDataNoDomainType    = errors.New("typed data has no EIP712Domain type")
+	ErrTypedDataNoPrimaryType   = errors.New("typed data primary type is not defined")
+	ErrTypedDataInvalidType     = errors.New("typed data has an invalid type")
+	ErrTypedDataInvalidDomain   = errors.New("typed data domain has an unknown field")
+	ErrTypedDataRecursiveType   = errors.New("typed data type references itself")
+	ErrTypedDataMissingValue    = errors.New("typed data value is missing")
+	ErrTypedDataInvalidValue    = errors.New("typed data value doesn't match its type")
+	ErrInvalidSignTypedDataArgs = errors.New("eth_signTypedData expects an address and typed data")
+	ErrTypedDataSenderMismatch  = errors.New("typed data can only be signed by the selected account")
+)
+
+// domainFields are types of fields allowed in the EIP-712 domain separator
+var domainFields = map[string]string{
+	"name":              "string",
+	"version":           "string",
+	"chainId":           "uint256",
+	"verifyingContract": "address",
+	"salt":              "bytes32",
+}
+
+var (
+	identifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	arrayRegexp      = regexp.MustCompile(`^(.+)\[([0-9]*)\]$`)
+)
+
+// TypedDataField is a field of a struct type of typed data.
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedData is structured data signed with eth_signTypedData, as defined by EIP-712.
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType"`
+	Domain      map[string]interface{}      `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// ParseTypedData decodes typed data from JSON, keeping numbers precise, and validates it.
+func ParseTypedData(data []byte) (*TypedData, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var typedData TypedData
+	if err := decoder.Decode(&typedData); err != nil {
+		return nil, err
+	}
+	if err := typedData.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &typedData, nil
+}
+
+// Validate checks that types are well formed and that the domain and the message match them.
+func (t *TypedData) Validate() error {
+	if _, ok := t.Types[domainType]; !ok {
+		return ErrTypedDataNoDomainType
+	}
+	if _, ok := t.Types[t.PrimaryType]; !ok {
+		return ErrTypedDataNoPrimaryType
+	}
+
+	for name, fields := range t.Types {
+		if !identifierRegexp.MatchString(name) || isAtomicType(name) || isDynamicType(name) {
+			return fmt.Errorf("%v: %s", ErrTypedDataInvalidType, name)
+		}
+		for _, field := range fields {
+			if !identifierRegexp.MatchString(field.Name) || !t.isValidType(field.Type) {
+				return fmt.Errorf("%v: %s %s", ErrTypedDataInvalidType, field.Type, field.Name)
+			}
+		}
+		if _, err := t.dependencies(name, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range t.Types[domainType] {
+		if domainFields[field.Name] != field.Type {
+			return fmt.Errorf("%v: %s %s", ErrTypedDataInvalidDomain, field.Type, field.Name)
+		}
+	}
+
+	if _, err := t.HashStruct(domainType, t.Domain); err != nil {
+		return err
+	}
+	_, err := t.HashStruct(t.PrimaryType, t.Message)
+
+	return err
+}
+
+// Hash returns a hash of typed data to be signed, as defined by EIP-712:
+// keccak256("\x19\x01" ‖ domainSeparator ‖ hashStruct(message)).
+func (t *TypedData) Hash() ([]byte, error) {
+	domainSeparator, err := t.HashStruct(domainType, t.Domain)
+	if err != nil {
+		return nil, err
+	}
+	messageHash, err := t.HashStruct(t.PrimaryType, t.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Keccak256([]byte{0x19, 0x01}, domainSeparator, messageHash), nil
+}
+
+// HashStruct returns keccak256(typeHash ‖ encodeData(data)) of a value of a struct type.
+func (t *TypedData) HashStruct(typeName string, data map[string]interface{}) ([]byte, error) {
+	encoded, err := t.EncodeData(typeName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Keccak256(encoded), nil
+}
+
+// TypeHash returns keccak256 of the encoded type.
+func (t *TypedData) TypeHash(typeName string) ([]byte, error) {
+	encoded, err := t.EncodeType(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	return crypto.Keccak256([]byte(encoded)), nil
+}
+
+// EncodeType returns a type as "Name(type1 name1,type2 name2)", followed by struct types it references
+// sorted by name, e.g. "Mail(Person from,Person to,string contents)Person(string name,address wallet)".
+func (t *TypedData) EncodeType(typeName string) (string, error) {
+	deps, err := t.dependencies(typeName, nil)
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(deps[1:])
+
+	var buffer bytes.Buffer
+	for _, dep := range deps {
+		fields := make([]string, len(t.Types[dep]))
+		for i, field := range t.Types[dep] {
+			fields[i] = field.Type + " " + field.Name
+		}
+		fmt.Fprintf(&buffer, "%s(%s)", dep, strings.Join(fields, ","))
+	}
+
+	return buffer.String(), nil
+}
+
+// EncodeData returns typeHash followed by 32 bytes encoded values of fields of a struct type.
+func (t *TypedData) EncodeData(typeName string, data map[string]interface{}) ([]byte, error) {
+	typeHash, err := t.TypeHash(typeName)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := [][]byte{typeHash}
+	for _, field := range t.Types[typeName] {
+		value, ok := data[field.Name]
+		if !ok {
+			return nil, fmt.Errorf("%v: %s.%s", ErrTypedDataMissingValue, typeName, field.Name)
+		}
+		encodedValue, err := t.encodeValue(field.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", typeName, field.Name, err)
+		}
+		encoded = append(encoded, encodedValue)
+	}
+
+	return bytes.Join(encoded, nil), nil
+}
+
+// encodeValue encodes a value of any type to 32 bytes. Struct types are encoded with hashStruct, dynamic types
+// and arrays with keccak256 of their contents, and atomic types are padded.
+func (t *TypedData) encodeValue(typeName string, value interface{}) ([]byte, error) {
+	if elemType, length, ok := parseArrayType(typeName); ok {
+		values, ok := value.([]interface{})
+		if !ok || (length >= 0 && len(values) != length) {
+			return nil, fmt.Errorf("%v: %s", ErrTypedDataInvalidValue, typeName)
+		}
+		encoded := make([][]byte, len(values))
+		for i, elem := range values {
+			encodedElem, err := t.encodeValue(elemType, elem)
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = encodedElem
+		}
+		return crypto.Keccak256(encoded...), nil
+	}
+
+	if _, ok := t.Types[typeName]; ok {
+		data, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%v: %s", ErrTypedDataInvalidValue, typeName)
+		}
+		return t.HashStruct(typeName, data)
+	}
+
+	switch typeName {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("%v: %s", ErrTypedDataInvalidValue, typeName)
+		}
+		return crypto.Keccak256([]byte(s)), nil
+	case "bytes":
+		b, err := decodeBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Keccak256(b), nil
+	}
+
+	return encodeAtomicValue(typeName, value)
+}
+
+// dependencies returns a type followed by struct types it references, directly or not.
+func (t *TypedData) dependencies(typeName string, found []string) ([]string, error) {
+	for _, dep := range found {
+		if dep == typeName {
+			return found, nil
+		}
+	}
+	found = append(found, typeName)
+
+	for _, field := range t.Types[typeName] {
+		fieldType := baseType(field.Type)
+		if _, ok := t.Types[fieldType]; !ok {
+			continue
+		}
+		if fieldType == found[0] {
+			return nil, fmt.Errorf("%v: %s", ErrTypedDataRecursiveType, fieldType)
+		}
+		var err error
+		if found, err = t.dependencies(fieldType, found); err != nil {
+			return nil, err
+		}
+	}
+
+	return found, nil
+}
+
+// isValidType reports whether a type is atomic, dynamic, defined or an array of those.
+func (t *TypedData) isValidType(typeName string) bool {
+	if elemType, _, ok := parseArrayType(typeName); ok {
+		return t.isValidType(elemType)
+	}
+	if _, ok := t.Types[typeName]; ok {
+		return typeName != domainType
+	}
+
+	return isAtomicType(typeName) || isDynamicType(typeName)
+}
+
+// parseArrayType splits "type[n]" or "type[]" into the element type and a length, -1 for dynamic arrays.
+func parseArrayType(typeName string) (string, int, bool) {
+	match := arrayRegexp.FindStringSubmatch(typeName)
+	if match == nil {
+		return "", 0, false
+	}
+	if match[2] == "" {
+		return match[1], -1, true
+	}
+	length, err := strconv.Atoi(match[2])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return match[1], length, true
+}
+
+// baseType strips all array suffixes of a type.
+func baseType(typeName string) string {
+	for {
+		elemType, _, ok := parseArrayType(typeName)
+		if !ok {
+			return typeName
+		}
+		typeName = elemType
+	}
+}
+
+func isDynamicType(typeName string) bool {
+	return typeName == "string" || typeName == "bytes"
+}
+
+func isAtomicType(typeName string) bool {
+	switch typeName {
+	case "address", "bool":
+		return true
+	}
+	if size, ok := typeSize(typeName, "bytes"); ok {
+		return size >= 1 && size <= 32
+	}
+	for _, prefix := range []string{"uint", "int"} {
+		if size, ok := typeSize(typeName, prefix); ok {
+			return size >= 8 && size <= 256 && size%8 == 0
+		}
+	}
+
+	return false
+}
+
+// typeSize returns N of a type like uintN, intN or bytesN. Missing N is 256 for integers.
+func typeSize(typeName, prefix string) (int, bool) {
+	if !strings.HasPrefix(typeName, prefix) {
+		return 0, false
+	}
+	suffix := strings.TrimPrefix(typeName, prefix)
+	if suffix == "" && prefix != "bytes" {
+		return 256, true
+	}
+	size, err := strconv.Atoi(suffix)
+	if err != nil || strconv.Itoa(size) != suffix {
+		return 0, false
+	}
+
+	return size, true
+}
+
+// encodeAtomicValue encodes a value of an atomic type as 32 bytes.
+func encodeAtomicValue(typeName string, value interface{}) ([]byte, error) {
+	invalid := fmt.Errorf("%v: %s", ErrTypedDataInvalidValue, typeName)
+
+	switch typeName {
+	case "address":
+		s, ok := value.(string)
+		if !ok || !gethcommon.IsHexAddress(s) {
+			return nil, invalid
+		}
+		return gethcommon.LeftPadBytes(gethcommon.HexToAddress(s).Bytes(), 32), nil
+	case "bool":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, invalid
+		}
+		encoded := make([]byte, 32)
+		if b {
+			encoded[31] = 1
+		}
+		return encoded, nil
+	}
+
+	if size, ok := typeSize(typeName, "bytes"); ok {
+		b, err := decodeBytes(value)
+		if err != nil || len(b) > size {
+			return nil, invalid
+		}
+		return gethcommon.RightPadBytes(b, 32), nil
+	}
+
+	n, err := decodeInteger(value)
+	if err != nil {
+		return nil, invalid
+	}
+	if size, ok := typeSize(typeName, "uint"); ok {
+		if n.Sign() < 0 || n.BitLen() > size {
+			return nil, invalid
+		}
+		return math.PaddedBigBytes(n, 32), nil
+	}
+	if size, ok := typeSize(typeName, "int"); ok {
+		limit := new(big.Int).Lsh(big.NewInt(1), uint(size-1))
+		if n.Cmp(limit) >= 0 || n.Cmp(new(big.Int).Neg(limit)) < 0 {
+			return nil, invalid
+		}
+		return math.PaddedBigBytes(math.U256(new(big.Int).Set(n)), 32), nil
+	}
+
+	return nil, invalid
+}
+
+// decodeBytes decodes a hex encoded bytes value.
+func decodeBytes(value interface{}) ([]byte, error) {
+	s, ok := value.(string)
+	if !ok {
+		return nil, ErrTypedDataInvalidValue
+	}
+	b, err := hexutil.Decode(s)
+	if err != nil {
+		return nil, ErrTypedDataInvalidValue
+	}
+
+	return b, nil
+}
+
+// decodeInteger decodes an integer given as a JSON number, or a decimal or hex string.
+func decodeInteger(value interface{}) (*big.Int, error) {
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	case float64:
+		if v != float64(int64(v)) {
+			return nil, ErrTypedDataInvalidValue
+		}
+		return big.NewInt(int64(v)), nil
+	default:
+		return nil, ErrTypedDataInvalidValue
+	}
+
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		return nil, ErrTypedDataInvalidValue
+	}
+
+	return n, nil
+}
+
+// TypedDataValue is a field of typed data shown to the user for approval. Value of a struct field is
+// a list of its fields, and value of an array is a list of its elements.
+type TypedDataValue struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// TypedDataPreview is typed data with its fields in the order they are defined by their types.
+type TypedDataPreview struct {
+	PrimaryType string           `json:"primary_type"`
+	Domain      []TypedDataValue `json:"domain"`
+	Message     []TypedDataValue `json:"message"`
+}
+
+// Preview returns validated typed data to be shown to the user.
+func (t *TypedData) Preview() *TypedDataPreview {
+	return &TypedDataPreview{
+		PrimaryType: t.PrimaryType,
+		Domain:      t.previewStruct(domainType, t.Domain),
+		Message:     t.previewStruct(t.PrimaryType, t.Message),
+	}
+}
+
+func (t *TypedData) previewStruct(typeName string, data map[string]interface{}) []TypedDataValue {
+	fields := make([]TypedDataValue, len(t.Types[typeName]))
+	for i, field := range t.Types[typeName] {
+		fields[i] = TypedDataValue{
+			Name:  field.Name,
+			Type:  field.Type,
+			Value: t.previewValue(field.Type, data[field.Name]),
+		}
+	}
+
+	return fields
+}
+
+func (t *TypedData) previewValue(typeName string, value interface{}) interface{} {
+	if elemType, _, ok := parseArrayType(typeName); ok {
+		values, _ := value.([]interface{})
+		elems := make([]interface{}, len(values))
+		for i, elem := range values {
+			elems[i] = t.previewValue(elemType, elem)
+		}
+		return elems
+	}
+	if _, ok := t.Types[typeName]; ok {
+		data, _ := value.(map[string]interface{})
+		return t.previewStruct(typeName, data)
+	}
+	if _, ok := typeSize(strings.TrimPrefix(typeName, "u"), "int"); ok {
+		if n, err := decodeInteger(value); err == nil {
+			return n.String() // integers are shown in decimal, as they may not fit in a JavaScript number
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,159 @@
+package msgqueue
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// mailTypedData is the example of EIP-712
+const mailTypedData = `{
+	"types": {
+		"EIP712Domain": [
+			{"name": "name", "type": "string"},
+			{"name": "version", "type": "string"},
+			{"name": "chainId", "type": "uint256"},
+			{"name": "verifyingContract", "type": "address"}
+		],
+		"Person": [
+			{"name": "name", "type": "string"},
+			{"name": "wallet", "type": "address"}
+		],
+		"Mail": [
+			{"name": "from", "type": "Person"},
+			{"name": "to", "type": "Person"},
+			{"name": "contents", "type": "string"}
+		]
+	},
+	"primaryType": "Mail",
+	"domain": {
+		"name": "Ether Mail",
+		"version": "1",
+		"chainId": 1,
+		"verifyingContract": "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"
+	},
+	"message": {
+		"from": {"name": "Cow", "wallet": "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		"contents": "Hello, Bob!"
+	}
+}`
+
+func TestTypedDataHash(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+
+	encodedType, err := typedData.EncodeType("Mail")
+	require.NoError(t, err)
+	require.Equal(t, "Mail(Person from,Person to,string contents)Person(string name,address wallet)", encodedType)
+
+	typeHash, err := typedData.TypeHash("Mail")
+	require.NoError(t, err)
+	require.Equal(t, "0xa0cedeb2dc280ba39b857546d74f5549c3a1d7bdc2dd96bf881f76108e23dac2", hexutil.Encode(typeHash))
+
+	domainSeparator, err := typedData.HashStruct(domainType, typedData.Domain)
+	require.NoError(t, err)
+	require.Equal(t, "0xf2cee375fa42b42143804025fc449deafd50cc031ca257e0b194a650a912090f", hexutil.Encode(domainSeparator))
+
+	messageHash, err := typedData.HashStruct("Mail", typedData.Message)
+	require.NoError(t, err)
+	require.Equal(t, "0xc52c0ee5d84264471806290a3f2c4cecfc5490626bf912d01f240d7a274b371e", hexutil.Encode(messageHash))
+
+	hash, err := typedData.Hash()
+	require.NoError(t, err)
+	require.Equal(t, "0xbe609aee343fb3c4b28e1df9e632fca64fcfaede20f02e86244efddf30957bd2", hexutil.Encode(hash))
+
+	// signature of the example, with the key of Cow
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte("cow")))
+	require.NoError(t, err)
+	signature, err := crypto.Sign(hash, key)
+	require.NoError(t, err)
+	require.Equal(t, "0x4355c47d63924e8a72e509b65029052eb6c299d53a04e167c5775fd466751c9d"+
+		"07299936d304c153f6443dfa05f40ff007d72911b6f72307f996231605b91562"+"01", hexutil.Encode(signature))
+}
+
+func TestTypedDataEncodeValues(t *testing.T) {
+	typedData := &TypedData{
+		Types: map[string][]TypedDataField{
+			"Values": {
+				{Name: "flag", Type: "bool"},
+				{Name: "small", Type: "int8"},
+				{Name: "id", Type: "bytes4"},
+				{Name: "amounts", Type: "uint256[]"},
+			},
+		},
+	}
+
+	encoded, err := typedData.EncodeData("Values", map[string]interface{}{
+		"flag":    true,
+		"small":   "-1",
+		"id":      "0x01020304",
+		"amounts": []interface{}{"0x01", float64(2)},
+	})
+	require.NoError(t, err)
+	require.Len(t, encoded, 5*32)
+	require.Equal(t, byte(1), encoded[2*32-1])
+	require.Equal(t, strings.Repeat("ff", 32), hexutil.Encode(encoded[2*32 : 3*32])[2:])
+	require.Equal(t, "0x01020304"+strings.Repeat("00", 28), hexutil.Encode(encoded[3*32:4*32]))
+	one, two := make([]byte, 32), make([]byte, 32)
+	one[31], two[31] = 1, 2
+	require.Equal(t, crypto.Keccak256(one, two), encoded[4*32:])
+}
+
+func TestTypedDataValidation(t *testing.T) {
+	testCases := []struct {
+		name    string
+		replace []string
+		err     error
+	}{
+		{"no domain type", []string{`"EIP712Domain"`, `"Domain"`}, ErrTypedDataNoDomainType},
+		{"undefined primary type", []string{`"primaryType": "Mail"`, `"primaryType": "Letter"`}, ErrTypedDataNoPrimaryType},
+		{"undefined field type", []string{`"type": "Person"}`, `"type": "Human"}`}, ErrTypedDataInvalidType},
+		{"invalid integer size", []string{`"uint256"`, `"uint7"`}, ErrTypedDataInvalidType},
+		{"unknown domain field", []string{`"name": "version"`, `"name": "release"`}, ErrTypedDataInvalidDomain},
+		{"recursive type", []string{`{"name": "wallet", "type": "address"}`, `{"name": "friend", "type": "Person[]"}`}, ErrTypedDataRecursiveType},
+		{"missing value", []string{`"contents": "Hello, Bob!"`, `"text": "Hello, Bob!"`}, ErrTypedDataMissingValue},
+		{"invalid address", []string{`"0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"`, `"Bob"`}, ErrTypedDataInvalidValue},
+		{"negative unsigned integer", []string{`"chainId": 1`, `"chainId": -1`}, ErrTypedDataInvalidValue},
+		{"struct value of a string", []string{`"to": {"name": "Bob", "wallet": "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"}`, `"to": "Bob"`}, ErrTypedDataInvalidValue},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			data := strings.Replace(mailTypedData, testCase.replace[0], testCase.replace[1], -1)
+			require.NotEqual(t, mailTypedData, data)
+
+			_, err := ParseTypedData([]byte(data))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), testCase.err.Error())
+		})
+	}
+}
+
+func TestTypedDataPreview(t *testing.T) {
+	typedData, err := ParseTypedData([]byte(mailTypedData))
+	require.NoError(t, err)
+
+	preview := typedData.Preview()
+	require.Equal(t, "Mail", preview.PrimaryType)
+	require.Equal(t, []TypedDataValue{
+		{Name: "name", Type: "string", Value: "Ether Mail"},
+		{Name: "version", Type: "string", Value: "1"},
+		{Name: "chainId", Type: "uint256", Value: "1"},
+		{Name: "verifyingContract", Type: "address", Value: "0xCcCCccccCCCCcCCCCCCcCcCccCcCCCcCcccccccC"},
+	}, preview.Domain)
+	require.Equal(t, []TypedDataValue{
+		{Name: "from", Type: "Person", Value: []TypedDataValue{
+			{Name: "name", Type: "string", Value: "Cow"},
+			{Name: "wallet", Type: "address", Value: "0xCD2a3d9F938E13CD947Ec05AbC7FE734Df8DD826"},
+		}},
+		{Name: "to", Type: "Person", Value: []TypedDataValue{
+			{Name: "name", Type: "string", Value: "Bob"},
+			{Name: "wallet", Type: "address", Value: "0xbBbBBBBbbBBBbbbBbbBbbbbBBbBbbbbBbBbbBBbB"},
+		}},
+		{Name: "contents", Type: "string", Value: "Hello, Bob!"},
+	}, preview.Message)
+}
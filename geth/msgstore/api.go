@@ -0,0 +1,30 @@
+package msgstore
+
+import (
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+// PublicAPI is the status API of the store.
+type PublicAPI struct {
+	store *Store
+}
+
+// OpenMessageStore starts storing messages received by an identity, see Store.Open.
+func (api *PublicAPI) OpenMessageStore(identity string) error {
+	return api.store.Open(identity)
+}
+
+// CloseMessageStore stops storing messages received by an identity, see Store.Close.
+func (api *PublicAPI) CloseMessageStore(identity string) {
+	api.store.Close(identity)
+}
+
+// QueryMessages returns stored messages of an identity, see Store.Query.
+func (api *PublicAPI) QueryMessages(identity string, query Query) (*QueryResult, error) {
+	return api.store.Query(identity, query)
+}
+
+// DeleteMessages removes stored messages of an identity of a topic, or all of them, see Store.Delete.
+func (api *PublicAPI) DeleteMessages(identity string, topic whisper.TopicType) error {
+	return api.store.Delete(identity, topic)
+}
@@ -0,0 +1,482 @@
+// Package msgstore persists Whisper messages received by identities, so that they can be read again after
+// they're consumed from filters or the node restarts. Messages of each identity are encrypted with a key
+// derived from the identity's private key, and indexed by the time they were sent at and their topic.
+package msgstore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DefaultQueryLimit is how many messages a query returns if it has no limit
+	DefaultQueryLimit = 100
+
+	// MaxQueryLimit is how many messages a query returns at most
+	MaxQueryLimit = 1000
+)
+
+// prefixes of keys of messages and of the topic index, following the identity's tag
+const (
+	messagePrefix = 'm'
+	topicPrefix   = 't'
+)
+
+// DataDir is the directory messages are stored in, relative to the node's instance directory
+const DataDir = "messages"
+
+const (
+	tagLength    = 8
+	cursorLength = 4 + gethcommon.HashLength // time a message was sent at and its envelope hash
+)
+
+var (
+	// ErrNotInitialized is returned when an identity is opened before the store is initialized.
+	ErrNotInitialized = errors.New("message store is not initialized")
+
+	// ErrIdentityNotOpen is returned when messages of an identity which isn't open are accessed.
+	ErrIdentityNotOpen = errors.New("message store of the identity is not open")
+
+	// ErrInvalidCursor is returned when a query has a cursor which wasn't returned by a previous query.
+	ErrInvalidCursor = errors.New("invalid cursor of a query")
+
+	// ErrCorruptMessage is returned when a stored message can't be decrypted.
+	ErrCorruptMessage = errors.New("stored message can't be decrypted")
+)
+
+// storeKeySalt makes a message encryption key differ from any other key derived from an identity key.
+var storeKeySalt = []byte("status-message-store")
+
+// Message is a Whisper message received by an identity.
+type Message struct {
+	Hash      gethcommon.Hash   `json:"hash"` // hash of the envelope of the message
+	Topic     whisper.TopicType `json:"topic"`
+	Payload   hexutil.Bytes     `json:"payload"`
+	Sig       hexutil.Bytes     `json:"sig,omitempty"`                // public key of the sender, if the message is signed
+	Recipient hexutil.Bytes     `json:"recipientPublicKey,omitempty"` // public key of the identity, if the message is encrypted with it
+	Timestamp uint32            `json:"timestamp"`                    // unix time the message was sent at, in seconds
+	TTL       uint32            `json:"ttl"`
+}
+
+// Query selects messages of a topic, e.g. of a chat, or of any topic if it's empty, sent within a time range,
+// in order of the time they were sent at. Results are paginated, the next page is queried with the cursor
+// returned with the previous one.
+type Query struct {
+	Topic  whisper.TopicType `json:"topic"`
+	From   uint32            `json:"from"`   // unix time, in seconds, inclusive
+	To     uint32            `json:"to"`     // unix time, in seconds, inclusive, no limit if zero
+	Limit  int               `json:"limit"`  // DefaultQueryLimit if zero, at most MaxQueryLimit
+	Cursor hexutil.Bytes     `json:"cursor"` // cursor of the page, the first one if empty
+}
+
+// QueryResult is a page of messages selected by a query.
+type QueryResult struct {
+	Messages []Message     `json:"messages"`
+	Cursor   hexutil.Bytes `json:"cursor"` // cursor of the next page, empty if it's the last one
+}
+
+// identity is an identity open in the store
+type identity struct {
+	publicKey []byte      // public key messages sent to the identity are encrypted with
+	tag       []byte      // prefix of keys of the identity's messages
+	aead      cipher.AEAD // cipher of the identity's messages
+}
+
+// Store stores Whisper messages received by open identities in LevelDB. Messages encrypted with
+// an identity's public key are stored for it, and messages encrypted with symmetric keys are stored for
+// every open identity. It's a Whisper delivery server, which passes states of messages on to the delivery
+// server it wraps, and a node service exposing the status namespace.
+type Store struct {
+	delivery whisper.DeliveryServer // wrapped delivery server, if any
+
+	mu         sync.RWMutex
+	shh        *whisper.Whisper
+	db         *leveldb.DB
+	identities map[string]*identity // open identities by ID
+}
+
+// New returns a store of messages wrapping a delivery server, which may be nil.
+// Messages are stored once the store is initialized.
+func New(delivery whisper.DeliveryServer) *Store {
+	return &Store{
+		delivery:   delivery,
+		identities: make(map[string]*identity),
+	}
+}
+
+// OpenDB opens a LevelDB database of messages at a path, or in memory if the path is empty.
+func OpenDB(path string) (*leveldb.DB, error) {
+	if path == "" {
+		return leveldb.Open(storage.NewMemStorage(), nil)
+	}
+
+	return leveldb.OpenFile(path, nil)
+}
+
+// Init sets the Whisper service keys of identities are looked up in, and the database messages are stored in.
+func (s *Store) Init(shh *whisper.Whisper, db *leveldb.DB) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shh = shh
+	s.db = db
+}
+
+// Protocols implements node.Service, the store has no protocols.
+func (s *Store) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, exposing the status namespace.
+func (s *Store) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "status",
+			Version:   "1.0",
+			Service:   &PublicAPI{store: s},
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service.
+func (s *Store) Start(*p2p.Server) error {
+	return nil
+}
+
+// Stop implements node.Service, closing identities and the database.
+func (s *Store) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.identities = make(map[string]*identity)
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+
+	return err
+}
+
+// Open starts storing messages received by an identity, which is an ID of a key pair in Whisper.
+func (s *Store) Open(identityID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.shh == nil || s.db == nil {
+		return ErrNotInitialized
+	}
+	privateKey, err := s.shh.GetPrivateKey(identityID)
+	if err != nil {
+		return err
+	}
+	opened, err := newIdentity(privateKey)
+	if err != nil {
+		return err
+	}
+	s.identities[identityID] = opened
+
+	return nil
+}
+
+// Close stops storing messages received by an identity. Stored messages are kept.
+func (s *Store) Close(identityID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.identities, identityID)
+}
+
+// PruneIdentities closes identities whose key pairs were removed from Whisper, e.g. once their accounts
+// are logged out. Their messages stay stored.
+func (s *Store) PruneIdentities() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for identityID := range s.identities {
+		if s.shh == nil || !s.shh.HasKeyPair(identityID) {
+			delete(s.identities, identityID)
+		}
+	}
+}
+
+// SendState implements whisper.DeliveryServer, storing messages delivered to filters.
+func (s *Store) SendState(state whisper.MessageState) {
+	if state.Direction == gethmessage.IncomingMessage && state.Status == gethmessage.DeliveredStatus {
+		s.store(&state.Received)
+	}
+
+	if s.delivery != nil {
+		s.delivery.SendState(state)
+	}
+}
+
+// Query returns messages of an open identity selected by a query.
+func (s *Store) Query(identityID string, query Query) (*QueryResult, error) {
+	opened, db, err := s.identity(identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	if limit > MaxQueryLimit {
+		limit = MaxQueryLimit
+	}
+
+	prefix := opened.prefix(query.Topic)
+	start := appendTime(prefix, query.From)
+	if len(query.Cursor) > 0 {
+		if len(query.Cursor) != cursorLength || binary.BigEndian.Uint32(query.Cursor) < query.From {
+			return nil, ErrInvalidCursor
+		}
+		start = append(copyBytes(prefix), query.Cursor...)
+	}
+	var limitKey []byte
+	if query.To > 0 && query.To < math.MaxUint32 {
+		limitKey = appendTime(prefix, query.To+1)
+	} else {
+		limitKey = util.BytesPrefix(prefix).Limit
+	}
+
+	it := db.NewIterator(&util.Range{Start: start, Limit: limitKey}, nil)
+	defer it.Release()
+
+	result := &QueryResult{Messages: []Message{}}
+	for it.Next() {
+		if len(result.Messages) == limit {
+			result.Cursor = copyBytes(it.Key()[len(prefix):])
+			break
+		}
+
+		value := it.Value()
+		if query.Topic != (whisper.TopicType{}) {
+			// the topic index refers to messages by their keys
+			if value, err = db.Get(append(opened.prefix(whisper.TopicType{}), it.Key()[len(prefix):]...), nil); err != nil {
+				return nil, err
+			}
+		}
+
+		message, err := opened.decrypt(value)
+		if err != nil {
+			return nil, err
+		}
+		result.Messages = append(result.Messages, *message)
+	}
+
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Delete removes messages of an open identity of a topic, e.g. of a chat, or all its messages if it's empty.
+func (s *Store) Delete(identityID string, topic whisper.TopicType) error {
+	opened, db, err := s.identity(identityID)
+	if err != nil {
+		return err
+	}
+
+	batch := new(leveldb.Batch)
+	it := db.NewIterator(util.BytesPrefix(opened.prefix(topic)), nil)
+	defer it.Release()
+
+	for it.Next() {
+		batch.Delete(copyBytes(it.Key()))
+		if topic == (whisper.TopicType{}) {
+			continue
+		}
+
+		// messages of the topic are removed along with their index entries
+		messageKey := append(opened.prefix(whisper.TopicType{}), it.Key()[len(opened.prefix(topic)):]...)
+		batch.Delete(messageKey)
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	if topic == (whisper.TopicType{}) {
+		// entries of the topic index of the identity are removed as well
+		indexes := db.NewIterator(util.BytesPrefix(append(copyBytes(opened.tag), topicPrefix)), nil)
+		defer indexes.Release()
+		for indexes.Next() {
+			batch.Delete(copyBytes(indexes.Key()))
+		}
+		if err := indexes.Error(); err != nil {
+			return err
+		}
+	}
+
+	return db.Write(batch, nil)
+}
+
+// identity returns an open identity and the database its messages are stored in.
+func (s *Store) identity(identityID string) (*identity, *leveldb.DB, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	opened, ok := s.identities[identityID]
+	if !ok {
+		return nil, nil, ErrIdentityNotOpen
+	}
+
+	return opened, s.db, nil
+}
+
+// store stores a received message for identities it's meant for. Identities whose key pairs were removed
+// from Whisper, e.g. on logout, are closed.
+func (s *Store) store(received *whisper.ReceivedMessage) {
+	message := Message{
+		Hash:      received.EnvelopeHash,
+		Topic:     received.Topic,
+		Payload:   received.Payload,
+		Timestamp: received.Sent,
+		TTL:       received.TTL,
+	}
+	if received.Src != nil {
+		message.Sig = crypto.FromECDSAPub(received.Src)
+	}
+	if received.Dst != nil {
+		message.Recipient = crypto.FromECDSAPub(received.Dst)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for identityID, opened := range s.identities {
+		if s.shh == nil || !s.shh.HasKeyPair(identityID) {
+			delete(s.identities, identityID)
+			continue
+		}
+		if received.Dst != nil && !bytes.Equal(opened.publicKey, message.Recipient) {
+			continue
+		}
+
+		if err := s.put(opened, &message); err != nil {
+			log.Error("failed to store a message", "hash", message.Hash.Hex(), "err", err)
+		}
+	}
+}
+
+// put writes an encrypted message of an identity and its entry in the topic index.
+func (s *Store) put(opened *identity, message *Message) error {
+	value, err := opened.encrypt(message)
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, cursorLength)
+	binary.BigEndian.PutUint32(suffix, message.Timestamp)
+	copy(suffix[4:], message.Hash[:])
+
+	batch := new(leveldb.Batch)
+	batch.Put(append(opened.prefix(whisper.TopicType{}), suffix...), value)
+	batch.Put(append(opened.prefix(message.Topic), suffix...), nil)
+
+	return s.db.Write(batch, nil)
+}
+
+// newIdentity returns an identity with a tag and a cipher derived from its private key.
+func newIdentity(privateKey *ecdsa.PrivateKey) (*identity, error) {
+	key := crypto.Keccak256(storeKeySalt, crypto.FromECDSA(privateKey))
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// the tag is derived from the key, so that keys of the database don't reveal public keys of identities
+	return &identity{
+		publicKey: crypto.FromECDSAPub(&privateKey.PublicKey),
+		tag:       crypto.Keccak256(key, []byte("tag"))[:tagLength],
+		aead:      aead,
+	}, nil
+}
+
+// prefix returns a prefix of keys of messages of a topic in the topic index,
+// or of keys of all messages if the topic is empty.
+func (i *identity) prefix(topic whisper.TopicType) []byte {
+	if topic == (whisper.TopicType{}) {
+		return append(copyBytes(i.tag), messagePrefix)
+	}
+
+	// topics are hashed with the tag, so that keys of the database don't reveal them
+	prefix := append(copyBytes(i.tag), topicPrefix)
+	return append(prefix, crypto.Keccak256(i.tag, topic[:])[:tagLength]...)
+}
+
+// encrypt returns a message encrypted with the identity's cipher, preceded by the nonce.
+func (i *identity) encrypt(message *Message) ([]byte, error) {
+	plainText, err := json.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, i.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return i.aead.Seal(nonce, nonce, plainText, nil), nil
+}
+
+// decrypt returns a message encrypted with the identity's cipher.
+func (i *identity) decrypt(value []byte) (*Message, error) {
+	nonceSize := i.aead.NonceSize()
+	if len(value) < nonceSize {
+		return nil, ErrCorruptMessage
+	}
+
+	plainText, err := i.aead.Open(nil, value[:nonceSize], value[nonceSize:], nil)
+	if err != nil {
+		return nil, ErrCorruptMessage
+	}
+
+	var message Message
+	if err := json.Unmarshal(plainText, &message); err != nil {
+		return nil, err
+	}
+
+	return &message, nil
+}
+
+// appendTime returns a prefix followed by a big-endian time.
+func appendTime(prefix []byte, time uint32) []byte {
+	key := make([]byte, len(prefix)+4)
+	copy(key, prefix)
+	binary.BigEndian.PutUint32(key[len(prefix):], time)
+	return key
+}
+
+// copyBytes returns a copy of a slice, which can be appended to without changing the original.
+func copyBytes(b []byte) []byte {
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,218 @@
+package msgstore
+
+import (
+	"bytes"
+	"testing"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingDelivery records states of messages passed to it
+type recordingDelivery struct {
+	states []whisper.MessageState
+}
+
+func (d *recordingDelivery) SendState(state whisper.MessageState) {
+	d.states = append(d.states, state)
+}
+
+// newTestStore returns a store in memory and Whisper with an identity open in it
+func newTestStore(t *testing.T, delivery whisper.DeliveryServer) (*Store, *whisper.Whisper, string) {
+	shh := whisper.New(nil)
+	db, err := OpenDB("")
+	require.NoError(t, err)
+
+	store := New(delivery)
+	store.Init(shh, db)
+
+	identityID, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, store.Open(identityID))
+
+	return store, shh, identityID
+}
+
+// receive passes a message received on a topic at a time to the store
+func receive(store *Store, topic whisper.TopicType, sent uint32, payload string, dst *whisper.Whisper, dstID string) gethcommon.Hash {
+	hash := gethcommon.BytesToHash(append([]byte(payload), byte(sent)))
+	received := whisper.ReceivedMessage{
+		Payload:      []byte(payload),
+		Sent:         sent,
+		TTL:          60,
+		Topic:        topic,
+		EnvelopeHash: hash,
+	}
+	if dst != nil {
+		privateKey, _ := dst.GetPrivateKey(dstID)
+		received.Dst = &privateKey.PublicKey
+	}
+
+	store.SendState(whisper.MessageState{
+		Direction: gethmessage.IncomingMessage,
+		Status:    gethmessage.DeliveredStatus,
+		Received:  received,
+	})
+
+	return hash
+}
+
+func payloads(result *QueryResult) []string {
+	var out []string
+	for _, message := range result.Messages {
+		out = append(out, string(message.Payload))
+	}
+	return out
+}
+
+func TestStoreReceivedMessages(t *testing.T) {
+	delivery := &recordingDelivery{}
+	store, shh, identityID := newTestStore(t, delivery)
+	defer store.Stop() // nolint: errcheck
+
+	otherID, err := shh.NewKeyPair()
+	require.NoError(t, err)
+
+	chat := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	other := whisper.TopicType{0x05, 0x06, 0x07, 0x08}
+
+	receive(store, chat, 1020, "third", nil, "")
+	receive(store, chat, 1000, "first", shh, identityID)
+	receive(store, other, 1010, "second", nil, "")
+	receive(store, chat, 1005, "to another identity", shh, otherID)
+
+	// messages sent by the node aren't stored, all states are passed on
+	store.SendState(whisper.MessageState{
+		Direction: gethmessage.OutgoingMessage,
+		Status:    gethmessage.SentStatus,
+		Received:  whisper.ReceivedMessage{Payload: []byte("sent"), Sent: 1001, Topic: chat},
+	})
+	require.Len(t, delivery.states, 5)
+
+	result, err := store.Query(identityID, Query{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second", "third"}, payloads(result))
+	require.Empty(t, result.Cursor)
+	require.Equal(t, chat, result.Messages[0].Topic)
+	require.Equal(t, uint32(1000), result.Messages[0].Timestamp)
+	require.NotEmpty(t, result.Messages[0].Recipient)
+
+	result, err = store.Query(identityID, Query{Topic: chat})
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "third"}, payloads(result))
+
+	result, err = store.Query(identityID, Query{From: 1001, To: 1010})
+	require.NoError(t, err)
+	require.Equal(t, []string{"second"}, payloads(result))
+
+	// the same envelope delivered to several filters is stored once
+	receive(store, chat, 1000, "first", shh, identityID)
+	result, err = store.Query(identityID, Query{Topic: chat})
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "third"}, payloads(result))
+}
+
+func TestQueryPages(t *testing.T) {
+	store, _, identityID := newTestStore(t, nil)
+	defer store.Stop() // nolint: errcheck
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	for i, payload := range []string{"a", "b", "c", "d", "e"} {
+		receive(store, topic, uint32(1000+i), payload, nil, "")
+	}
+
+	var pages [][]string
+	query := Query{Topic: topic, From: 1001, Limit: 2}
+	for {
+		result, err := store.Query(identityID, query)
+		require.NoError(t, err)
+		pages = append(pages, payloads(result))
+		if len(result.Cursor) == 0 {
+			break
+		}
+		query.Cursor = result.Cursor
+	}
+	require.Equal(t, [][]string{{"b", "c"}, {"d", "e"}}, pages)
+
+	_, err := store.Query(identityID, Query{Cursor: []byte{0x01}})
+	require.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestDeleteMessages(t *testing.T) {
+	store, _, identityID := newTestStore(t, nil)
+	defer store.Stop() // nolint: errcheck
+
+	chat := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	other := whisper.TopicType{0x05, 0x06, 0x07, 0x08}
+	receive(store, chat, 1000, "chat", nil, "")
+	receive(store, other, 1001, "other", nil, "")
+
+	require.NoError(t, store.Delete(identityID, chat))
+	result, err := store.Query(identityID, Query{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"other"}, payloads(result))
+	result, err = store.Query(identityID, Query{Topic: chat})
+	require.NoError(t, err)
+	require.Empty(t, result.Messages)
+
+	require.NoError(t, store.Delete(identityID, whisper.TopicType{}))
+	result, err = store.Query(identityID, Query{Topic: other})
+	require.NoError(t, err)
+	require.Empty(t, result.Messages)
+
+	it := store.db.NewIterator(nil, nil)
+	defer it.Release()
+	require.False(t, it.Next(), "no keys are left in the database")
+}
+
+func TestMessagesAreEncryptedPerIdentity(t *testing.T) {
+	store, shh, identityID := newTestStore(t, nil)
+	defer store.Stop() // nolint: errcheck
+
+	topic := whisper.TopicType{0x01, 0x02, 0x03, 0x04}
+	receive(store, topic, 1000, "secret payload", nil, "")
+
+	it := store.db.NewIterator(nil, nil)
+	for it.Next() {
+		require.False(t, bytes.Contains(it.Value(), []byte("secret payload")))
+		require.False(t, bytes.Contains(it.Key(), topic[:]))
+	}
+	it.Release()
+
+	// identities opened later don't see messages received before
+	otherID, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	require.NoError(t, store.Open(otherID))
+	result, err := store.Query(otherID, Query{})
+	require.NoError(t, err)
+	require.Empty(t, result.Messages)
+
+	// stored messages are kept when an identity is closed, and read when it's open again
+	store.Close(identityID)
+	_, err = store.Query(identityID, Query{})
+	require.Equal(t, ErrIdentityNotOpen, err)
+	require.NoError(t, store.Open(identityID))
+	result, err = store.Query(identityID, Query{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"secret payload"}, payloads(result))
+}
+
+func TestIdentityClosedWhenKeyPairRemoved(t *testing.T) {
+	store, shh, identityID := newTestStore(t, nil)
+	defer store.Stop() // nolint: errcheck
+
+	require.True(t, shh.DeleteKeyPair(identityID))
+	receive(store, whisper.TopicType{0x01}, 1000, "after logout", nil, "")
+
+	_, err := store.Query(identityID, Query{})
+	require.Equal(t, ErrIdentityNotOpen, err)
+	require.Error(t, store.Open(identityID))
+}
+
+func TestOpenBeforeInit(t *testing.T) {
+	store := New(nil)
+	require.Equal(t, ErrNotInitialized, store.Open("identity"))
+	require.NoError(t, store.Stop())
+}
@@ -0,0 +1,79 @@
+package node
+
+import (
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/les"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// chainHeadChanSize is a size of the channel receiving new chain heads from the chain,
+// the chain blocks on sending if it's full.
+const chainHeadChanSize = 10
+
+// chainHeadSource is a chain which reports new heads, either LES light chain or full node's blockchain.
+type chainHeadSource interface {
+	SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription
+}
+
+// SubscribeChainHeads delivers new heads of the canonical chain to a given channel until unsubscribed.
+// Heads are reported by LES or full node's chain, so nothing is delivered when upstream RPC is used.
+// Like Subscribe, delivery is synchronous: use a buffered channel which is received from promptly.
+func (m *NodeManager) SubscribeChainHeads(heads chan common.ChainHead) event.Subscription {
+	return m.chainHeads.Subscribe(heads)
+}
+
+// chainHeadSourceOf returns a chain of LES or eth service of a given node, or nil if it runs neither.
+func chainHeadSourceOf(stack *node.Node) chainHeadSource {
+	var lesService *les.LightEthereum
+	if err := stack.Service(&lesService); err == nil && lesService != nil {
+		return lesService.BlockChain()
+	}
+
+	var ethService *eth.Ethereum
+	if err := stack.Service(&ethService); err == nil && ethService != nil {
+		return ethService.BlockChain()
+	}
+
+	return nil
+}
+
+// watchChainHeads forwards new chain heads to subscribers and as signals until the node is stopped.
+func (m *NodeManager) watchChainHeads(chain chainHeadSource, nodeStopped <-chan struct{}) {
+	events := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	sub := chain.SubscribeChainHeadEvent(events)
+	if sub == nil {
+		// chain is closed already
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			header := ev.Block.Header()
+			head := common.ChainHead{
+				Number:    header.Number.Uint64(),
+				Hash:      header.Hash(),
+				Timestamp: header.Time.Uint64(),
+			}
+
+			m.chainHeads.Send(head)
+			m.SendSignal(signal.Envelope{
+				Type: signal.EventChainHead,
+				Event: signal.ChainHeadEvent{
+					Number:    head.Number,
+					Hash:      head.Hash.Hex(),
+					Timestamp: head.Timestamp,
+				},
+			})
+		case <-sub.Err():
+			return
+		case <-nodeStopped:
+			return
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeChain struct {
+	feed       event.Feed
+	subscribed chan struct{} // closed once chain heads are subscribed to
+}
+
+func (c *fakeChain) SubscribeChainHeadEvent(ch chan<- core.ChainHeadEvent) event.Subscription {
+	sub := c.feed.Subscribe(ch)
+	close(c.subscribed)
+	return sub
+}
+
+func TestWatchChainHeads(t *testing.T) {
+	signals := make(chan signal.Envelope, 1)
+	m := NewNodeManager()
+	m.SetSignalHandler(func(envelope signal.Envelope) {
+		signals <- envelope
+	})
+
+	heads := make(chan common.ChainHead, 1)
+	sub := m.SubscribeChainHeads(heads)
+	defer sub.Unsubscribe()
+
+	chain := &fakeChain{subscribed: make(chan struct{})}
+	nodeStopped := make(chan struct{})
+	watchStopped := make(chan struct{})
+	go func() {
+		m.watchChainHeads(chain, nodeStopped)
+		close(watchStopped)
+	}()
+
+	block := types.NewBlockWithHeader(&types.Header{
+		Number: big.NewInt(42),
+		Time:   big.NewInt(1510000000),
+	})
+	// heads are sent once the watcher subscribes to the chain, not concurrently with it
+	<-chain.subscribed
+	require.Equal(t, 1, chain.feed.Send(core.ChainHeadEvent{Block: block}))
+
+	expected := common.ChainHead{Number: 42, Hash: block.Hash(), Timestamp: 1510000000}
+	require.Equal(t, expected, <-heads)
+	require.Equal(t, signal.Envelope{
+		Type: signal.EventChainHead,
+		Event: signal.ChainHeadEvent{
+			Number:    42,
+			Hash:      block.Hash().Hex(),
+			Timestamp: 1510000000,
+		},
+	}, <-signals)
+
+	close(nodeStopped)
+	select {
+	case <-watchStopped:
+	case <-time.After(time.Second):
+		t.Fatal("chain heads are still watched after the node is stopped")
+	}
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discv5"
+)
+
+// discovery-related errors
+var (
+	ErrDiscoveryV5Disabled  = errors.New("discovery v5 is disabled")
+	ErrTopicNotRegistered   = errors.New("discovery topic is not registered")
+	ErrInvalidSearchLimit   = errors.New("limit of searched nodes must be positive")
+	ErrInvalidSearchTimeout = errors.New("timeout of topic search must be positive")
+)
+
+// topicSearchPeriod is how often nodes registered under a searched topic are looked up.
+const topicSearchPeriod = time.Second
+
+// RegisterTopic advertises the node under a discovery v5 topic, so that it's found
+// by peers searching for the topic. Registration lasts until UnregisterTopic is called
+// or the node is stopped.
+func (m *NodeManager) RegisterTopic(topic string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	server, err := m.discoveryServer()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := m.topics[topic]; ok {
+		return nil
+	}
+
+	// suspended node is registered once it's resumed
+	if m.isSuspended() {
+		m.topics[topic] = nil
+		return nil
+	}
+
+	stop := make(chan struct{})
+	m.topics[topic] = stop
+	go server.DiscV5.RegisterTopic(discv5.Topic(topic), stop)
+
+	return nil
+}
+
+// UnregisterTopic stops advertising the node under a discovery v5 topic.
+func (m *NodeManager) UnregisterTopic(topic string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, err := m.discoveryServer(); err != nil {
+		return err
+	}
+
+	stop, ok := m.topics[topic]
+	if !ok {
+		return ErrTopicNotRegistered
+	}
+	if stop != nil {
+		close(stop)
+	}
+	delete(m.topics, topic)
+
+	return nil
+}
+
+// SearchTopic looks up nodes registered under a discovery v5 topic and returns
+// enode URLs of up to limit nodes found within a timeout.
+// Discovery runs a single search per topic, so topics searched by the node's services
+// (e.g. LES servers topic) can't be searched with this method.
+func (m *NodeManager) SearchTopic(topic string, limit int, timeout time.Duration) ([]string, error) {
+	if limit <= 0 {
+		return nil, ErrInvalidSearchLimit
+	}
+	if timeout <= 0 {
+		return nil, ErrInvalidSearchTimeout
+	}
+
+	m.RLock()
+	server, err := m.discoveryServer()
+	m.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(chan *discv5.Node, limit)
+	setPeriod := make(chan time.Duration, 1)
+	setPeriod <- topicSearchPeriod
+	go server.DiscV5.SearchTopic(discv5.Topic(topic), setPeriod, found, nil)
+	defer close(setPeriod) // stops the search
+
+	var (
+		enodes   []string
+		seen     = make(map[discv5.NodeID]bool)
+		deadline = time.After(timeout)
+	)
+	for len(enodes) < limit {
+		select {
+		case node := <-found:
+			if !seen[node.ID] {
+				seen[node.ID] = true
+				enodes = append(enodes, node.String())
+			}
+		case <-deadline:
+			return enodes, nil
+		}
+	}
+
+	return enodes, nil
+}
+
+// discoveryServer returns p2p server of the running node which has discovery v5 enabled.
+func (m *NodeManager) discoveryServer() (*p2p.Server, error) {
+	if err := m.isNodeAvailable(); err != nil {
+		return nil, err
+	}
+
+	<-m.nodeStarted
+
+	server := m.node.Server()
+	if server == nil {
+		return nil, ErrNoRunningNode
+	}
+	if server.DiscV5 == nil {
+		return nil, ErrDiscoveryV5Disabled
+	}
+
+	return server, nil
+}
@@ -0,0 +1,116 @@
+package node
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// eventSubscriber is a channel node lifecycle events are delivered to
+type eventSubscriber struct {
+	events chan common.NodeEvent
+}
+
+// Subscribe delivers node lifecycle events to a given channel until unsubscribed.
+// Events are delivered without blocking the node manager, those which the channel has no room for
+// are dropped: use a buffered channel.
+func (m *NodeManager) Subscribe(events chan common.NodeEvent) event.Subscription {
+	subscriber := &eventSubscriber{events: events}
+
+	m.subscribersMu.Lock()
+	if m.subscribers == nil {
+		m.subscribers = make(map[*eventSubscriber]struct{})
+	}
+	m.subscribers[subscriber] = struct{}{}
+	m.subscribersMu.Unlock()
+
+	return event.NewSubscription(func(unsubscribed <-chan struct{}) error {
+		<-unsubscribed
+
+		m.subscribersMu.Lock()
+		delete(m.subscribers, subscriber)
+		m.subscribersMu.Unlock()
+
+		return nil
+	})
+}
+
+// emit sends a lifecycle event to subscribers and its signal to the application.
+func (m *NodeManager) emit(e common.NodeEvent) {
+	m.subscribersMu.Lock()
+	for subscriber := range m.subscribers {
+		select {
+		case subscriber.events <- e:
+		default:
+			log.Warn("Node event dropped, subscriber is not receiving", "type", e.Type)
+		}
+	}
+	m.subscribersMu.Unlock()
+
+	m.SendSignal(nodeEventSignal(e))
+}
+
+// nodeEventSignal serializes a lifecycle event as a signal.
+func nodeEventSignal(e common.NodeEvent) signal.Envelope {
+	switch e.Type {
+	case common.NodeStarting:
+		return signal.Envelope{Type: signal.EventNodeStarting, Event: struct{}{}}
+	case common.NodeStarted:
+		return signal.Envelope{Type: signal.EventNodeStarted, Event: struct{}{}}
+	case common.SyncStarted:
+		return signal.Envelope{Type: signal.EventSyncStarted, Event: struct{}{}}
+	case common.SyncFinished:
+		var event signal.SyncFinishedEvent
+		if e.Error != nil {
+			event.Error = e.Error.Error()
+		}
+		return signal.Envelope{Type: signal.EventSyncFinished, Event: event}
+	case common.NodeStopped:
+		return signal.Envelope{Type: signal.EventNodeStopped, Event: struct{}{}}
+	case common.NodeRestarting:
+		return signal.Envelope{Type: signal.EventNodeRestarting, Event: signal.NodeRestartingEvent{Attempt: e.Attempt}}
+	default:
+		var event signal.NodeCrashEvent
+		if e.Error != nil {
+			event.Error = e.Error.Error()
+		}
+		return signal.Envelope{Type: signal.EventNodeCrashed, Event: event}
+	}
+}
+
+// watchSync emits sync events posted by the downloader until the node is stopped.
+// Synchronisations which are not allowed on the current network or while the node is suspended
+// are cancelled with syncer, if it's set.
+func (m *NodeManager) watchSync(sub *event.TypeMuxSubscription, syncer syncCanceler, nodeStopped <-chan struct{}) {
+	defer sub.Unsubscribe()
+
+	var lastSync time.Time
+
+	for {
+		select {
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+
+			switch data := ev.Data.(type) {
+			case downloader.StartEvent:
+				m.emit(common.NodeEvent{Type: common.SyncStarted})
+				if syncer != nil && !m.syncAllowed(&lastSync) {
+					log.Info("Sync cancelled", "condition", m.currentNetworkCondition(), "suspended", m.isSuspended())
+					syncer.Cancel()
+				}
+			case downloader.DoneEvent:
+				m.emit(common.NodeEvent{Type: common.SyncFinished})
+			case downloader.FailedEvent:
+				m.emit(common.NodeEvent{Type: common.SyncFinished, Error: data.Err})
+			}
+		case <-nodeStopped:
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeEventSignal(t *testing.T) {
+	errSync := errors.New("sync failed")
+
+	testCases := []struct {
+		event    common.NodeEvent
+		expected signal.Envelope
+	}{
+		{
+			common.NodeEvent{Type: common.NodeStarting},
+			signal.Envelope{Type: signal.EventNodeStarting, Event: struct{}{}},
+		},
+		{
+			common.NodeEvent{Type: common.NodeStarted},
+			signal.Envelope{Type: signal.EventNodeStarted, Event: struct{}{}},
+		},
+		{
+			common.NodeEvent{Type: common.SyncStarted},
+			signal.Envelope{Type: signal.EventSyncStarted, Event: struct{}{}},
+		},
+		{
+			common.NodeEvent{Type: common.SyncFinished},
+			signal.Envelope{Type: signal.EventSyncFinished, Event: signal.SyncFinishedEvent{}},
+		},
+		{
+			common.NodeEvent{Type: common.SyncFinished, Error: errSync},
+			signal.Envelope{Type: signal.EventSyncFinished, Event: signal.SyncFinishedEvent{Error: errSync.Error()}},
+		},
+		{
+			common.NodeEvent{Type: common.NodeStopped},
+			signal.Envelope{Type: signal.EventNodeStopped, Event: struct{}{}},
+		},
+		{
+			common.NodeEvent{Type: common.NodeRestarting, Attempt: 2},
+			signal.Envelope{Type: signal.EventNodeRestarting, Event: signal.NodeRestartingEvent{Attempt: 2}},
+		},
+		{
+			common.NodeEvent{Type: common.NodeCrashed},
+			signal.Envelope{Type: signal.EventNodeCrashed, Event: signal.NodeCrashEvent{}},
+		},
+		{
+			common.NodeEvent{Type: common.NodeCrashed, Error: ErrRPCClient},
+			signal.Envelope{Type: signal.EventNodeCrashed, Event: signal.NodeCrashEvent{Error: ErrRPCClient.Error()}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.event.Type), func(t *testing.T) {
+			require.Equal(t, tc.expected, nodeEventSignal(tc.event))
+		})
+	}
+}
+
+func TestEmitDoesNotBlock(t *testing.T) {
+	m := NewNodeManager()
+	m.SetSignalHandler(func(signal.Envelope) {})
+
+	buffered := make(chan common.NodeEvent, 1)
+	defer m.Subscribe(buffered).Unsubscribe()
+	stalled := make(chan common.NodeEvent) // never received from
+	defer m.Subscribe(stalled).Unsubscribe()
+	unsubscribed := make(chan common.NodeEvent, 1)
+	m.Subscribe(unsubscribed).Unsubscribe()
+
+	// events which a subscriber has no room for are dropped
+	emitted := make(chan struct{})
+	go func() {
+		m.emit(common.NodeEvent{Type: common.NodeStarting})
+		m.emit(common.NodeEvent{Type: common.NodeStarted})
+		close(emitted)
+	}()
+	select {
+	case <-emitted:
+	case <-time.After(time.Second):
+		t.Fatal("emitting events is blocked by a subscriber")
+	}
+
+	require.Equal(t, common.NodeEvent{Type: common.NodeStarting}, <-buffered)
+	require.Len(t, buffered, 0)
+	require.Len(t, unsubscribed, 0)
+}
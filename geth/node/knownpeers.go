@@ -0,0 +1,269 @@
+package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// knownPeersFile is a file in the node's instance directory listing peers
+// which the node has connected to, it is kept across restarts.
+const knownPeersFile = "known-peers.json"
+
+const (
+	// maxKnownPeers is a number of peers kept in the store, those with the lowest scores are forgotten
+	maxKnownPeers = 100
+
+	// minKnownPeerScore is a score below which a peer is forgotten
+	minKnownPeerScore = -3
+
+	// shortPeerConnection is a connection time below which a dropped peer is considered unreliable
+	shortPeerConnection = time.Minute
+
+	// shortPeerConnectionPenalty is subtracted from a score of a peer dropped shortly after connecting,
+	// it outweighs the connection, so that score of a peer which keeps dropping decreases
+	shortPeerConnectionPenalty = 2
+
+	// dialedPeerTTL is how long a dialed node is remembered while waiting for the handshake to complete
+	dialedPeerTTL = time.Minute
+)
+
+// knownPeer is a peer the node has connected to.
+type knownPeer struct {
+	Enode         string `json:"enode"`
+	Score         int    `json:"score"`
+	LastConnected int64  `json:"lastConnected"` // unix time, in seconds
+}
+
+// dialedPeer is a node which has been dialed, but hasn't completed the handshake yet.
+type dialedPeer struct {
+	node     *discover.Node
+	dialedAt time.Time
+}
+
+// knownPeers is a store of peers the node has dialed and connected to, with quality scores:
+// a score is increased on every connection and decreased more when a peer is dropped shortly after connecting.
+// Only dialed peers are stored, as listening addresses of inbound peers are not known.
+// The store is saved to a file, so that the best peers are dialed first after a restart.
+type knownPeers struct {
+	mu          sync.Mutex
+	path        string
+	peers       map[discover.NodeID]*knownPeer
+	dialed      map[discover.NodeID]dialedPeer
+	connectedAt map[discover.NodeID]time.Time
+}
+
+// newKnownPeers creates a store saved to a given file, loading peers saved in it previously.
+// A missing or corrupted file results in an empty store.
+func newKnownPeers(path string) *knownPeers {
+	store := &knownPeers{
+		path:        path,
+		peers:       make(map[discover.NodeID]*knownPeer),
+		dialed:      make(map[discover.NodeID]dialedPeer),
+		connectedAt: make(map[discover.NodeID]time.Time),
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warn("Failed to read known peers", "error", err)
+		}
+		return store
+	}
+
+	var peers []*knownPeer
+	if err := json.Unmarshal(data, &peers); err != nil {
+		log.Warn("Failed to parse known peers", "error", err)
+		return store
+	}
+	for _, peer := range peers {
+		node, err := discover.ParseNode(peer.Enode)
+		if err != nil {
+			continue
+		}
+		store.peers[node.ID] = peer
+	}
+
+	return store
+}
+
+// best returns enode URLs of up to limit peers with the highest scores, the most recently connected first.
+func (s *knownPeers) best(limit int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var enodes []string
+	for _, peer := range s.sorted() {
+		if len(enodes) == limit {
+			break
+		}
+		enodes = append(enodes, peer.Enode)
+	}
+
+	return enodes
+}
+
+// sorted returns known peers ordered by score and recency.
+func (s *knownPeers) sorted() []*knownPeer {
+	peers := make([]*knownPeer, 0, len(s.peers))
+	for _, peer := range s.peers {
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		if peers[i].Score != peers[j].Score {
+			return peers[i].Score > peers[j].Score
+		}
+		return peers[i].LastConnected > peers[j].LastConnected
+	})
+
+	return peers
+}
+
+// dialing remembers a node dialed by the p2p server, so that it's stored once the handshake completes.
+func (s *knownPeers) dialing(node *discover.Node) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, peer := range s.dialed {
+		if now.Sub(peer.dialedAt) > dialedPeerTTL {
+			delete(s.dialed, id)
+		}
+	}
+	s.dialed[node.ID] = dialedPeer{node: node, dialedAt: now}
+}
+
+// connected increases a score of a connected peer, storing it if it has been dialed.
+func (s *knownPeers) connected(id discover.NodeID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	peer, ok := s.peers[id]
+	if dialed, isDialed := s.dialed[id]; isDialed {
+		delete(s.dialed, id)
+		if !ok {
+			peer = &knownPeer{}
+			s.peers[id] = peer
+		}
+		// the address might have changed since the peer was stored
+		peer.Enode = dialed.node.String()
+	} else if !ok {
+		return
+	}
+
+	peer.Score++
+	peer.LastConnected = now.Unix()
+	s.connectedAt[id] = now
+	s.save()
+}
+
+// dropped decreases a score of a peer which was dropped shortly after connecting.
+func (s *knownPeers) dropped(id discover.NodeID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	connectedAt, ok := s.connectedAt[id]
+	delete(s.connectedAt, id)
+	peer, known := s.peers[id]
+	if !ok || !known || time.Since(connectedAt) >= shortPeerConnection {
+		return
+	}
+
+	peer.Score -= shortPeerConnectionPenalty
+	if peer.Score < minKnownPeerScore {
+		delete(s.peers, id)
+	}
+	s.save()
+}
+
+// save writes the best known peers to the store's file, forgetting the others.
+func (s *knownPeers) save() {
+	peers := s.sorted()
+	if len(peers) > maxKnownPeers {
+		for _, peer := range peers[maxKnownPeers:] {
+			node, err := discover.ParseNode(peer.Enode)
+			if err == nil {
+				delete(s.peers, node.ID)
+			}
+		}
+		peers = peers[:maxKnownPeers]
+	}
+
+	data, err := json.MarshalIndent(peers, "", "    ")
+	if err != nil {
+		log.Error("Failed to encode known peers", "error", err)
+		return
+	}
+	if err := ioutil.WriteFile(s.path, data, 0600); err != nil {
+		log.Error("Failed to save known peers", "error", err)
+	}
+}
+
+// dialer returns p2p.NodeDialer which remembers nodes it dials.
+func (s *knownPeers) dialer(dialer p2p.NodeDialer) p2p.NodeDialer {
+	return &knownPeersDialer{store: s, dialer: dialer}
+}
+
+// watch updates scores of connected and dropped peers until the node is stopped.
+func (s *knownPeers) watch(server *p2p.Server, nodeStopped <-chan struct{}) {
+	events := make(chan *p2p.PeerEvent, 10)
+	sub := server.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			switch ev.Type {
+			case p2p.PeerEventTypeAdd:
+				s.connected(ev.Peer)
+			case p2p.PeerEventTypeDrop:
+				s.dropped(ev.Peer)
+			}
+		case <-sub.Err():
+			return
+		case <-nodeStopped:
+			return
+		}
+	}
+}
+
+// knownPeersDialer is p2p.NodeDialer which reports dialed nodes to its store.
+type knownPeersDialer struct {
+	store  *knownPeers
+	dialer p2p.NodeDialer
+}
+
+// Dial implements p2p.NodeDialer.
+func (d *knownPeersDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	conn, err := d.dialer.Dial(dest)
+	if err == nil {
+		d.store.dialing(dest)
+	}
+
+	return conn, err
+}
+
+// dialKnownPeers adds the best known peers as static peers of the running node.
+func (m *NodeManager) dialKnownPeers() {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil || m.knownPeers == nil {
+		return
+	}
+
+	for _, enode := range m.knownPeers.best(m.config.PeerMaintenanceConfig.KnownPeers) {
+		if err := m.addPeer(enode); err != nil {
+			log.Warn("Known peer addition failed", "enode", enode, "error", err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package node
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnownPeers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "known-peers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, knownPeersFile)
+
+	store := newKnownPeers(path)
+	require.Empty(t, store.best(10))
+
+	local, remote := net.Pipe()
+	defer local.Close()  // nolint: errcheck
+	defer remote.Close() // nolint: errcheck
+	dialer := store.dialer(pipeDialer{local})
+
+	reliable := discover.NewNode(discover.NodeID{1}, net.ParseIP("10.0.0.1"), 30303, 30303)
+	flaky := discover.NewNode(discover.NodeID{2}, net.ParseIP("10.0.0.2"), 30303, 30303)
+	for _, node := range []*discover.Node{reliable, flaky} {
+		_, err = dialer.Dial(node)
+		require.NoError(t, err)
+		store.connected(node.ID)
+	}
+
+	// inbound peers are not stored
+	store.connected(discover.NodeID{3})
+	require.Len(t, store.best(10), 2)
+
+	// peers dropped shortly after connecting lose score
+	store.dropped(flaky.ID)
+	require.Equal(t, []string{reliable.String(), flaky.String()}, store.best(10))
+	require.Equal(t, []string{reliable.String()}, store.best(1))
+
+	// scores are kept across restarts
+	store = newKnownPeers(path)
+	require.Equal(t, []string{reliable.String(), flaky.String()}, store.best(10))
+
+	// peers which keep dropping are forgotten
+	for i := 0; i < 4; i++ {
+		store.connected(flaky.ID)
+		store.dropped(flaky.ID)
+	}
+	require.Nil(t, store.peers[flaky.ID])
+	require.Equal(t, []string{reliable.String()}, newKnownPeers(path).best(10))
+}
+
+func TestKnownPeersCorruptedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "known-peers")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, knownPeersFile)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	require.Empty(t, newKnownPeers(path).best(10))
+}
@@ -1,21 +1,31 @@
 package node
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/les"
 	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/shhlimit"
 	"github.com/status-im/status-go/geth/signal"
 )
 
@@ -26,11 +36,17 @@ var (
 	ErrInvalidNodeManager          = errors.New("node manager is not properly initialized")
 	ErrInvalidWhisperService       = errors.New("whisper service is unavailable")
 	ErrInvalidLightEthereumService = errors.New("LES service is unavailable")
+	ErrInvalidEthereumService      = errors.New("eth service is unavailable")
 	ErrInvalidAccountManager       = errors.New("could not retrieve account manager")
 	ErrAccountKeyStoreMissing      = errors.New("account key store is not set")
 	ErrRPCClient                   = errors.New("failed to init RPC client")
+	ErrNodeStoppedUnexpectedly     = errors.New("node has stopped unexpectedly")
 )
 
+// trustedNodesFile is a file in the node's instance directory listing trusted peers,
+// it is loaded by the p2p server on start.
+const trustedNodesFile = "trusted-nodes.json"
+
 // NodeManager manages Status node (which abstracts contained geth node)
 // nolint: golint
 // should be fixed at https://github.com/status-im/status-go/issues/200
@@ -42,22 +58,93 @@ type NodeManager struct {
 	nodeStopped    chan struct{}      // channel to wait for termination notifications
 	whisperService *whisper.Whisper   // reference to Whisper service
 	lesService     *les.LightEthereum // reference to LES service
+	ethService     *eth.Ethereum      // reference to full node's eth service
 	rpcClient      *rpc.Client        // reference to RPC client
+	dialer         p2p.NodeDialer     // custom dialer of outbound peer connections
+	traffic        *trafficMeter      // meter of the running node's outbound peer connections
+	peerFilter     *peerFilter        // blacklist and whitelist of the running node's peers
+	keyStore       *keystore.KeyStore // keystore with custom scrypt parameters, nil if the node's one is used
+	knownPeers     *knownPeers        // store of peers the running node has connected to, nil if disabled
+	name           string             // name of the manager in a Registry, empty otherwise
+	startedAt      time.Time          // time when the running node was started
+	stopping       bool               // whether the running node is being stopped by the manager
+
+	topics map[string]chan struct{} // discovery topics registered by the node, with channels stopping registration, nil while suspended
+
+	restartAttempts int         // number of consecutive restarts of a crashed node
+	restartTimer    *time.Timer // timer of a pending restart of a crashed node
+
+	networkMu        sync.RWMutex
+	networkCondition common.NetworkCondition // kind of network the device is connected to, set by the application
+	suspended        bool                    // whether the application is in background, see Suspend
+
+	signalMu      sync.RWMutex
+	signalHandler func(signal.Envelope) // handler of node signals, signal.Send if nil
+	chainHeads    event.Feed            // new heads of the canonical chain
+
+	subscribersMu sync.Mutex
+	subscribers   map[*eventSubscriber]struct{} // channels node lifecycle events are delivered to
 }
 
 // NewNodeManager makes new instance of node manager
 func NewNodeManager() *NodeManager {
-	m := &NodeManager{}
+	return newNodeManager("")
+}
+
+// newNodeManager makes new instance of node manager with a given name
+func newNodeManager(name string) *NodeManager {
+	m := &NodeManager{name: name}
 	go HaltOnInterruptSignal(m) // allow interrupting running nodes
 
 	return m
 }
 
+// Name returns name of the node manager in a Registry, or empty string
+// if it was created with NewNodeManager.
+func (m *NodeManager) Name() string {
+	return m.name
+}
+
+// SetSignalHandler sets a handler of signals sent by this node manager, e.g. node.started,
+// and by services running on the node, see SendSignal. Signals are sent to the application
+// with signal.Send by default. Signals of named node managers are marked with their name.
+func (m *NodeManager) SetSignalHandler(handler func(signal.Envelope)) {
+	m.signalMu.Lock()
+	defer m.signalMu.Unlock()
+
+	m.signalHandler = handler
+}
+
+// SendSignal sends a signal of this node manager, or of a service running on its node,
+// to the handler of this node manager.
+func (m *NodeManager) SendSignal(envelope signal.Envelope) {
+	m.signalMu.RLock()
+	handler := m.signalHandler
+	m.signalMu.RUnlock()
+
+	envelope.Node = m.name
+	if handler == nil {
+		handler = signal.Send
+	}
+	handler(envelope)
+}
+
+// SetDialer sets a dialer used by nodes started afterwards to connect to peers.
+// It is intended to be used in tests, e.g. to inject network faults.
+func (m *NodeManager) SetDialer(dialer p2p.NodeDialer) {
+	m.Lock()
+	defer m.Unlock()
+
+	m.dialer = dialer
+}
+
 // StartNode start Status node, fails if node is already started
 func (m *NodeManager) StartNode(config *params.NodeConfig) (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
+	m.cancelRestart()
+
 	return m.startNode(config)
 }
 
@@ -69,28 +156,49 @@ func (m *NodeManager) startNode(config *params.NodeConfig) (<-chan struct{}, err
 
 	m.initLog(config)
 
-	ethNode, err := MakeNode(config, LogDeliveryService{})
+	filter, err := newPeerFilter(config.PeerBlacklist, config.PeerWhitelist)
 	if err != nil {
 		return nil, err
 	}
+	filter.limitWhisperPeers(whisperPeersLimit(m.currentNetworkCondition()))
+	filter.suspendDials(m.isSuspended())
+	traffic := newTrafficMeter(m.dialer)
+	dialer := p2p.NodeDialer(traffic)
+	var known *knownPeers
+	if config.PeerMaintenanceConfig.KnownPeers > 0 {
+		known = newKnownPeers(filepath.Join(config.DataDir, config.Name, knownPeersFile))
+		dialer = known.dialer(traffic)
+	}
+	ethNode, err := makeNode(config, LogDeliveryService{}, filter.dialer(dialer), m.SendSignal)
+	if err != nil {
+		return nil, err
+	}
+	m.traffic = traffic
+	m.peerFilter = filter
+	m.knownPeers = known
 
 	m.nodeStarted = make(chan struct{}, 1)
 
 	go func() {
 		defer HaltOnPanic()
 
+		m.emit(common.NodeEvent{Type: common.NodeStarting})
+
+		// subscribe before services are started, not to miss the first sync
+		syncSub := ethNode.EventMux().Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+
 		// start underlying node
 		if startErr := ethNode.Start(); startErr != nil {
+			syncSub.Unsubscribe()
 			close(m.nodeStarted)
 			m.Lock()
 			m.nodeStarted = nil
 			m.Unlock()
-			signal.Send(signal.Envelope{
-				Type: signal.EventNodeCrashed,
-				Event: signal.NodeCrashEvent{
-					Error: fmt.Errorf("%v: %v", ErrNodeStartFailure, startErr).Error(),
-				},
+			m.emit(common.NodeEvent{
+				Type:  common.NodeCrashed,
+				Error: fmt.Errorf("%v: %v", ErrNodeStartFailure, startErr),
 			})
+			m.superviseCrash(config)
 			return
 		}
 
@@ -98,6 +206,14 @@ func (m *NodeManager) startNode(config *params.NodeConfig) (<-chan struct{}, err
 		m.node = ethNode
 		m.nodeStopped = make(chan struct{}, 1)
 		m.config = config
+		m.startedAt = time.Now()
+		m.restartAttempts = 0
+		m.topics = make(map[string]chan struct{})
+		if keyStoreConfig := config.KeyStoreConfig; keyStoreConfig.ScryptN > 0 || keyStoreConfig.ScryptP > 0 {
+			// geth only supports presets, keys are encrypted by another keystore in the same directory
+			scryptN, scryptP := keyStoreConfig.ScryptParams()
+			m.keyStore = keystore.NewKeyStore(config.KeyStoreDir, scryptN, scryptP)
+		}
 
 		// init RPC client for this node
 		localRPCClient, errRPC := m.node.Attach()
@@ -109,47 +225,76 @@ func (m *NodeManager) startNode(config *params.NodeConfig) (<-chan struct{}, err
 			log.Error("Failed to create an RPC client", "error", errRPC)
 
 			m.Unlock()
-			signal.Send(signal.Envelope{
-				Type: signal.EventNodeCrashed,
-				Event: signal.NodeCrashEvent{
-					Error: ErrRPCClient.Error(),
-				},
+			syncSub.Unsubscribe()
+			m.emit(common.NodeEvent{
+				Type:  common.NodeCrashed,
+				Error: ErrRPCClient,
 			})
 			return
 		}
 
+		nodeStopped := m.nodeStopped
 		m.Unlock()
 
+		meterWhisperRelay(ethNode, m.currentNetworkCondition())
+
+		go m.watchSync(syncSub, syncCancelerOf(ethNode), nodeStopped)
+		go filter.watch(ethNode.Server(), nodeStopped)
+		if known != nil {
+			go known.watch(ethNode.Server(), nodeStopped)
+		}
+		if config.PeerMaintenanceConfig.Enabled {
+			go m.maintainPeers(ethNode.Server(), config.PeerMaintenanceConfig, nodeStopped)
+		}
+		if chain := chainHeadSourceOf(ethNode); chain != nil {
+			go m.watchChainHeads(chain, nodeStopped)
+		}
+
 		// underlying node is started, every method can use it, we use it immediately
 		go func() {
 			if err := m.PopulateStaticPeers(); err != nil {
 				log.Error("Static peers population", "error", err)
 			}
+			m.dialKnownPeers()
 		}()
 
 		// notify all subscribers that Status node is started
 		close(m.nodeStarted)
-		signal.Send(signal.Envelope{
-			Type:  signal.EventNodeStarted,
-			Event: struct{}{},
-		})
+		m.emit(common.NodeEvent{Type: common.NodeStarted})
 
 		// wait up until underlying node is stopped
-		m.node.Wait()
+		ethNode.Wait()
+
+		// node stopped otherwise than with stopNode is considered crashed. It must be checked
+		// before stopNode is notified, as it resets the node and the stopping flag then.
+		m.Lock()
+		crashed := !m.stopping
+		if crashed {
+			m.resetNode()
+		}
+		m.Unlock()
 
 		// notify m.Stop() that node has been stopped
-		close(m.nodeStopped)
+		close(nodeStopped)
 		log.Info("Node is stopped")
+
+		if crashed {
+			m.emit(common.NodeEvent{Type: common.NodeCrashed, Error: ErrNodeStoppedUnexpectedly})
+			m.superviseCrash(config)
+		}
 	}()
 
 	return m.nodeStarted, nil
 }
 
 // StopNode stop Status node. Stopped node cannot be resumed.
+// Pending restart of a crashed node is cancelled.
 func (m *NodeManager) StopNode() (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
+	m.cancelRestart()
+
 	if err := m.isNodeAvailable(); err != nil {
 		return nil, err
 	}
@@ -165,7 +310,9 @@ func (m *NodeManager) StopNode() (<-chan struct{}, error) {
 // stopNode stop Status node. Stopped node cannot be resumed.
 func (m *NodeManager) stopNode() (<-chan struct{}, error) {
 	// now attempt to stop
+	m.stopping = true
 	if err := m.node.Stop(); err != nil {
+		m.stopping = false
 		return nil, err
 	}
 
@@ -176,28 +323,37 @@ func (m *NodeManager) stopNode() (<-chan struct{}, error) {
 
 		// reset node params
 		m.Lock()
-		m.config = nil
-		m.lesService = nil
-		m.whisperService = nil
-		m.rpcClient = nil
-		m.nodeStarted = nil
-		m.node = nil
+		m.resetNode()
 		m.Unlock()
 
 		close(nodeStopped) // Status node is stopped, and we can create another
 		log.Info("Node manager resets node params")
 
 		// notify application that it can send more requests now
-		signal.Send(signal.Envelope{
-			Type:  signal.EventNodeStopped,
-			Event: struct{}{},
-		})
+		m.emit(common.NodeEvent{Type: common.NodeStopped})
 		log.Info("Node manager notifed app, that node has stopped")
 	}()
 
 	return nodeStopped, nil
 }
 
+// resetNode forgets the stopped node, so that another one can be started.
+func (m *NodeManager) resetNode() {
+	m.config = nil
+	m.lesService = nil
+	m.ethService = nil
+	m.whisperService = nil
+	m.rpcClient = nil
+	m.topics = nil
+	m.traffic = nil
+	m.peerFilter = nil
+	m.knownPeers = nil
+	m.keyStore = nil
+	m.nodeStarted = nil
+	m.node = nil
+	m.stopping = false
+}
+
 // IsNodeRunning confirm that node is running
 func (m *NodeManager) IsNodeRunning() bool {
 	m.RLock()
@@ -290,6 +446,120 @@ func (m *NodeManager) addPeer(url string) error {
 	return nil
 }
 
+// RemovePeer disconnects a peer and removes it from static peers
+func (m *NodeManager) RemovePeer(url string) error {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	server := m.node.Server()
+	if server == nil {
+		return ErrNoRunningNode
+	}
+
+	parsedNode, err := discover.ParseNode(url)
+	if err != nil {
+		return err
+	}
+	server.RemovePeer(parsedNode)
+
+	return nil
+}
+
+// AddTrustedPeer adds a peer which is allowed to connect even if the peers limit is reached.
+// The peer is connected as a static peer and added to trusted nodes of the running p2p server,
+// and it's saved to the node's trusted nodes file, to be trusted since the next start as well.
+func (m *NodeManager) AddTrustedPeer(url string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	if _, err := discover.ParseNode(url); err != nil {
+		return err
+	}
+
+	trustedNodesPath := m.node.ResolvePath(trustedNodesFile)
+
+	var trustedNodes []string
+	if data, err := ioutil.ReadFile(trustedNodesPath); err == nil {
+		if err := json.Unmarshal(data, &trustedNodes); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, trustedNode := range trustedNodes {
+		if trustedNode == url {
+			return m.addTrustedPeer(url)
+		}
+	}
+
+	data, err := json.MarshalIndent(append(trustedNodes, url), "", "    ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(trustedNodesPath, data, 0600); err != nil {
+		return err
+	}
+	log.Info("Trusted peer added", "enode", url)
+
+	return m.addTrustedPeer(url)
+}
+
+// addTrustedPeer connects a peer as a static one and adds it to trusted nodes of the running p2p server.
+// The server makes a set of trusted nodes when it starts, so the peer is admitted over the peers limit
+// as it's dialed as a static peer until the next start.
+func (m *NodeManager) addTrustedPeer(url string) error {
+	if err := m.addPeer(url); err != nil {
+		return err
+	}
+
+	// the server has received the static peer, so it's done reading trusted nodes on start
+	server := m.node.Server()
+	parsedNode, err := discover.ParseNode(url)
+	if err != nil {
+		return err
+	}
+	for _, trustedNode := range server.TrustedNodes {
+		if trustedNode.ID == parsedNode.ID {
+			return nil
+		}
+	}
+	server.TrustedNodes = append(server.TrustedNodes, parsedNode)
+
+	return nil
+}
+
+// Peers returns information about connected peers
+func (m *NodeManager) Peers() ([]*p2p.PeerInfo, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return nil, err
+	}
+
+	<-m.nodeStarted
+
+	server := m.node.Server()
+	if server == nil {
+		return nil, ErrNoRunningNode
+	}
+
+	return server.PeersInfo(), nil
+}
+
 // ResetChainData remove chain data from data directory.
 // Node is stopped, and new node is started, with clean data directory.
 func (m *NodeManager) ResetChainData() (<-chan struct{}, error) {
@@ -326,7 +596,7 @@ func (m *NodeManager) resetChainData() (<-chan struct{}, error) {
 		return nil, err
 	}
 	// send signal up to native app
-	signal.Send(signal.Envelope{
+	m.SendSignal(signal.Envelope{
 		Type:  signal.EventChainDataRemoved,
 		Event: struct{}{},
 	})
@@ -335,8 +605,22 @@ func (m *NodeManager) resetChainData() (<-chan struct{}, error) {
 	return m.startNode(&prevConfig)
 }
 
-// RestartNode restart running Status node, fails if node is not running
-func (m *NodeManager) RestartNode() (<-chan struct{}, error) {
+// hotReloadableConfigFields are NodeConfig fields which can be changed without restarting the node,
+// they're copied to the running node's config by reloadedConfig.
+var hotReloadableConfigFields = map[string]bool{
+	"LogLevel":                    true,
+	"LogFile":                     true,
+	"BootClusterConfig.BootNodes": true,
+	"PeerBlacklist":               true,
+	"PeerWhitelist":               true,
+}
+
+// RestartNode restart running Status node, fails if node is not running.
+// If newConfig is nil, node is restarted with its current configuration.
+// Otherwise, the configs are compared: if only hot-reloadable fields differ,
+// they are applied to the running node in place, without dropping its peers
+// and subscriptions, and a full restart is performed only if it's required.
+func (m *NodeManager) RestartNode(newConfig *params.NodeConfig) (<-chan struct{}, error) {
 	m.Lock()
 	defer m.Unlock()
 
@@ -346,12 +630,31 @@ func (m *NodeManager) RestartNode() (<-chan struct{}, error) {
 
 	<-m.nodeStarted
 
-	return m.restartNode()
+	if newConfig == nil {
+		return m.restartNode(*m.config)
+	}
+
+	diff, err := m.config.Diff(newConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, field := range diff {
+		if !hotReloadableConfigFields[field] {
+			log.Info("Config change requires node restart", "field", field)
+			return m.restartNode(*newConfig)
+		}
+	}
+
+	if err := m.reloadConfig(newConfig, diff); err != nil {
+		return nil, err
+	}
+
+	return m.nodeStarted, nil
 }
 
-// restartNode restart running Status node, fails if node is not running
-func (m *NodeManager) restartNode() (<-chan struct{}, error) {
-	prevConfig := *m.config
+// restartNode restart running Status node with a given config, fails if node is not running
+func (m *NodeManager) restartNode(config params.NodeConfig) (<-chan struct{}, error) {
 	nodeStopped, err := m.stopNode()
 	if err != nil {
 		return nil, err
@@ -361,7 +664,141 @@ func (m *NodeManager) restartNode() (<-chan struct{}, error) {
 	<-nodeStopped
 	m.Lock()
 
-	return m.startNode(&prevConfig)
+	return m.startNode(&config)
+}
+
+// reloadConfig applies changes of hot-reloadable fields to the running node.
+func (m *NodeManager) reloadConfig(newConfig *params.NodeConfig, changedFields []string) error {
+	changed := make(map[string]bool, len(changedFields))
+	for _, field := range changedFields {
+		changed[field] = true
+	}
+
+	if changed["PeerBlacklist"] || changed["PeerWhitelist"] {
+		if err := m.reloadPeerFilter(newConfig.PeerBlacklist, newConfig.PeerWhitelist); err != nil {
+			return err
+		}
+	}
+
+	if changed["BootClusterConfig.BootNodes"] {
+		if err := m.reloadBootNodes(m.config.BootClusterConfig.BootNodes, newConfig.BootClusterConfig.BootNodes); err != nil {
+			return err
+		}
+	}
+
+	if changed["LogLevel"] {
+		log.SetLevel(newConfig.LogLevel)
+	}
+
+	// the node logs to stdout again if its log file is removed from the config
+	if changed["LogFile"] {
+		if err := log.SetLogFile(newConfig.LogFile); err != nil {
+			return err
+		}
+	}
+
+	log.Info("Config reloaded without restart", "fields", strings.Join(changedFields, ","))
+	m.config = m.reloadedConfig(newConfig)
+
+	return nil
+}
+
+// reloadedConfig returns a copy of the running node's config with hot-reloadable fields of a new config,
+// so that the running node doesn't share the new config, nor its slices, with the caller.
+func (m *NodeManager) reloadedConfig(newConfig *params.NodeConfig) *params.NodeConfig {
+	config := *m.config
+	config.LogLevel = newConfig.LogLevel
+	config.LogFile = newConfig.LogFile
+	config.PeerBlacklist = append([]string(nil), newConfig.PeerBlacklist...)
+	config.PeerWhitelist = append([]string(nil), newConfig.PeerWhitelist...)
+
+	bootClusterConfig := *m.config.BootClusterConfig
+	bootClusterConfig.BootNodes = append([]string(nil), newConfig.BootClusterConfig.BootNodes...)
+	config.BootClusterConfig = &bootClusterConfig
+
+	return &config
+}
+
+// SetBootNodes replaces boot nodes of a running node: removed boot nodes are
+// disconnected, added ones are connected and discovery is re-seeded.
+// Nothing is changed if any of the enode URLs is invalid.
+func (m *NodeManager) SetBootNodes(enodes []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	// running node's config may be referenced elsewhere, so it's copied instead of being modified
+	newConfig := *m.config
+	bootClusterConfig := *m.config.BootClusterConfig
+	bootClusterConfig.BootNodes = append([]string(nil), enodes...)
+	newConfig.BootClusterConfig = &bootClusterConfig
+
+	return m.reloadConfig(&newConfig, []string{"BootClusterConfig.BootNodes"})
+}
+
+// reloadBootNodes disconnects removed boot nodes and connects added ones.
+func (m *NodeManager) reloadBootNodes(prevBootNodes, bootNodes []string) error {
+	server := m.node.Server()
+	if server == nil {
+		return ErrNoRunningNode
+	}
+
+	if !m.config.BootClusterConfig.Enabled {
+		return nil
+	}
+
+	prev := make(map[string]bool, len(prevBootNodes))
+	for _, enode := range prevBootNodes {
+		prev[enode] = true
+	}
+	current := make(map[string]bool, len(bootNodes))
+	for _, enode := range bootNodes {
+		current[enode] = true
+	}
+
+	// parse all boot nodes first, so that invalid config is not applied partially
+	var removed, added []*discover.Node
+	for _, enode := range prevBootNodes {
+		if !current[enode] {
+			parsedNode, err := discover.ParseNode(enode)
+			if err != nil {
+				return err
+			}
+			removed = append(removed, parsedNode)
+		}
+	}
+	for _, enode := range bootNodes {
+		if !prev[enode] {
+			parsedNode, err := discover.ParseNode(enode)
+			if err != nil {
+				return err
+			}
+			added = append(added, parsedNode)
+		}
+	}
+
+	for _, bootNode := range removed {
+		server.RemovePeer(bootNode)
+		log.Info("Boot node removed", "enode", bootNode.String())
+	}
+	for _, bootNode := range added {
+		server.AddPeer(bootNode)
+		log.Info("Boot node added", "enode", bootNode.String())
+	}
+
+	// refresh discovery table from the new boot nodes, so that peers lost together with removed ones are replaced
+	if server.DiscV5 != nil {
+		if err := server.DiscV5.SetFallbackNodes(makeBootNodesV5(bootNodes)); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // NodeConfig exposes reference to running node's configuration
@@ -403,6 +840,31 @@ func (m *NodeManager) LightEthereumService() (*les.LightEthereum, error) {
 	return m.lesService, nil
 }
 
+// EthereumService exposes reference to eth service of a full node (fast or full sync mode)
+func (m *NodeManager) EthereumService() (*eth.Ethereum, error) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return nil, err
+	}
+
+	<-m.nodeStarted
+
+	if m.ethService == nil {
+		if err := m.node.Service(&m.ethService); err != nil {
+			log.Warn("Cannot obtain eth service", "error", err)
+			return nil, ErrInvalidEthereumService
+		}
+	}
+
+	if m.ethService == nil {
+		return nil, ErrInvalidEthereumService
+	}
+
+	return m.ethService, nil
+}
+
 // WhisperService exposes reference to Whisper service running on top of the node
 func (m *NodeManager) WhisperService() (*whisper.Whisper, error) {
 	m.RLock()
@@ -415,10 +877,12 @@ func (m *NodeManager) WhisperService() (*whisper.Whisper, error) {
 	<-m.nodeStarted
 
 	if m.whisperService == nil {
-		if err := m.node.Service(&m.whisperService); err != nil {
+		var shhService *shhlimit.Whisper
+		if err := m.node.Service(&shhService); err != nil {
 			log.Warn("Cannot obtain whisper service", "error", err)
 			return nil, ErrInvalidWhisperService
 		}
+		m.whisperService = shhService.Whisper
 	}
 
 	if m.whisperService == nil {
@@ -458,6 +922,10 @@ func (m *NodeManager) AccountKeyStore() (*keystore.KeyStore, error) {
 
 	<-m.nodeStarted
 
+	if m.keyStore != nil {
+		return m.keyStore, nil
+	}
+
 	accountManager := m.node.AccountManager()
 	if accountManager == nil {
 		return nil, ErrInvalidAccountManager
@@ -485,7 +953,8 @@ func (m *NodeManager) RPCClient() *rpc.Client {
 }
 
 // initLog initializes global logger parameters based on
-// provided node configurations.
+// provided node configurations. The logger is shared by all nodes
+// of a process, so the configuration of the last node started applies.
 func (m *NodeManager) initLog(config *params.NodeConfig) {
 	log.SetLevel(config.LogLevel)
 
@@ -0,0 +1,175 @@
+package node
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/delivery"
+	"github.com/status-im/status-go/geth/shhlimit"
+)
+
+// dialTimeout is a timeout of outbound peer connections, the same as p2p server's default.
+const dialTimeout = 15 * time.Second
+
+// levelDBDirs are LevelDB databases kept in the node's instance directory.
+var levelDBDirs = []string{"chaindata", "lightchaindata", "nodes"}
+
+// Metrics returns resource usage of the process and, if the node is running,
+// its databases size, p2p traffic of outbound connections and per-service counters.
+func (m *NodeManager) Metrics() common.NodeMetrics {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	metrics := common.NodeMetrics{
+		Goroutines: runtime.NumGoroutine(),
+		HeapAlloc:  memStats.HeapAlloc,
+		HeapSys:    memStats.HeapSys,
+		OpenFiles:  openFiles(),
+	}
+
+	m.RLock()
+	defer m.RUnlock()
+
+	if m.isNodeAvailable() != nil || m.node.Server() == nil {
+		return metrics
+	}
+
+	for _, dir := range levelDBDirs {
+		metrics.LevelDBSize += dirSize(m.node.ResolvePath(dir))
+	}
+	if m.traffic != nil {
+		metrics.P2POutboundIngress, metrics.P2POutboundEgress = m.traffic.bytes()
+	}
+
+	metrics.Services = make(map[string]common.ServiceCounters)
+	for _, peer := range m.node.Server().PeersInfo() {
+		for protocol := range peer.Protocols {
+			counters, ok := metrics.Services[protocol]
+			if !ok {
+				counters = make(common.ServiceCounters)
+				metrics.Services[protocol] = counters
+			}
+			counters["peers"]++
+		}
+	}
+
+	var shhService *shhlimit.Whisper
+	if err := m.node.Service(&shhService); err == nil && shhService != nil {
+		counters, ok := metrics.Services["shh"]
+		if !ok {
+			counters = make(common.ServiceCounters)
+			metrics.Services["shh"] = counters
+		}
+		counters["envelopes"] = int64(len(shhService.Envelopes()))
+		for name, value := range shhService.Limiter().Counters() {
+			counters[name] = value
+		}
+
+		var tracker *delivery.Tracker
+		if err := m.node.Service(&tracker); err == nil && tracker != nil {
+			for name, value := range tracker.Counters() {
+				counters[name] = value
+			}
+		}
+	}
+
+	return metrics
+}
+
+// openFiles returns a number of file descriptors open by the process,
+// or -1 if they can't be listed on the platform.
+func openFiles() int {
+	for _, dir := range []string{"/proc/self/fd", "/dev/fd"} {
+		f, err := os.Open(dir)
+		if err != nil {
+			continue
+		}
+		names, err := f.Readdirnames(-1)
+		f.Close() // nolint: errcheck
+		if err != nil {
+			continue
+		}
+
+		// descriptor of the listed directory is not counted
+		return len(names) - 1
+	}
+
+	return -1
+}
+
+// dirSize returns a total size of files in a directory, 0 if it doesn't exist.
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error { // nolint: errcheck
+		if err != nil {
+			return nil
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size
+}
+
+// trafficMeter is p2p.NodeDialer which counts bytes read from and written to
+// outbound peer connections. Inbound connections are accepted by the p2p server
+// directly, so their traffic is not counted.
+type trafficMeter struct {
+	// counters are accessed atomically, so they're kept first to be 64-bit aligned on 32-bit platforms
+	ingress uint64
+	egress  uint64
+
+	dialer p2p.NodeDialer
+}
+
+// newTrafficMeter returns trafficMeter which dials peers with a given dialer, or TCP dialer if it's nil.
+func newTrafficMeter(dialer p2p.NodeDialer) *trafficMeter {
+	if dialer == nil {
+		dialer = p2p.TCPDialer{Dialer: &net.Dialer{Timeout: dialTimeout}}
+	}
+
+	return &trafficMeter{dialer: dialer}
+}
+
+// Dial implements p2p.NodeDialer.
+func (t *trafficMeter) Dial(dest *discover.Node) (net.Conn, error) {
+	conn, err := t.dialer.Dial(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &meteredConn{Conn: conn, meter: t}, nil
+}
+
+// bytes returns a number of bytes received and sent.
+func (t *trafficMeter) bytes() (ingress, egress uint64) {
+	return atomic.LoadUint64(&t.ingress), atomic.LoadUint64(&t.egress)
+}
+
+// meteredConn is a peer connection which traffic is counted by its meter.
+type meteredConn struct {
+	net.Conn
+
+	meter *trafficMeter
+}
+
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(&c.meter.ingress, uint64(n))
+	return n, err
+}
+
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(&c.meter.egress, uint64(n))
+	return n, err
+}
@@ -0,0 +1,60 @@
+package node
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/stretchr/testify/require"
+)
+
+// pipeDialer dials peers by returning one end of a pipe.
+type pipeDialer struct {
+	conn net.Conn
+}
+
+func (d pipeDialer) Dial(*discover.Node) (net.Conn, error) {
+	return d.conn, nil
+}
+
+func TestTrafficMeter(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close() // nolint: errcheck
+
+	meter := newTrafficMeter(pipeDialer{local})
+	conn, err := meter.Dial(&discover.Node{})
+	require.NoError(t, err)
+	defer conn.Close() // nolint: errcheck
+
+	go func() {
+		buf := make([]byte, 3)
+		remote.Read(buf)                // nolint: errcheck
+		remote.Write([]byte("hello!!")) // nolint: errcheck
+	}()
+
+	_, err = conn.Write([]byte("hey"))
+	require.NoError(t, err)
+	buf := make([]byte, 7)
+	_, err = conn.Read(buf)
+	require.NoError(t, err)
+
+	ingress, egress := meter.bytes()
+	require.Equal(t, uint64(7), ingress)
+	require.Equal(t, uint64(3), egress)
+}
+
+func TestDirSize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dir-size")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "sub"), os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "a"), make([]byte, 10), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "sub", "b"), make([]byte, 5), 0644))
+
+	require.Equal(t, int64(15), dirSize(dir))
+	require.Equal(t, int64(0), dirSize(filepath.Join(dir, "missing")))
+}
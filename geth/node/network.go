@@ -0,0 +1,139 @@
+package node
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/les"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/shhlimit"
+)
+
+// ErrUnknownNetworkCondition is returned when network condition is not one of common.NetworkCondition values.
+var ErrUnknownNetworkCondition = errors.New(`network condition must be one of "wifi", "cellular" and "offline"`)
+
+const (
+	// cellularSyncInterval is a minimum time between chain synchronisations on a cellular network
+	cellularSyncInterval = 5 * time.Minute
+
+	// meteredWhisperPeers is a number of Whisper peers kept on a cellular network or offline
+	meteredWhisperPeers = 1
+)
+
+// syncCanceler cancels ongoing chain synchronisation, e.g. downloader.Downloader.
+type syncCanceler interface {
+	Cancel()
+}
+
+// SetNetworkCondition adapts the node to a kind of network the device is connected to.
+// On a cellular network, chain synchronisation is started at most once in cellularSyncInterval
+// and Whisper relaying is reduced by disconnecting Whisper peers which don't serve LES,
+// leaving a single Whisper peer if the node isn't connected to any LES server. Whisper runs
+// in light mode, sending peers only envelopes posted by the node.
+// Offline, synchronisation is not started at all. WiFi lifts the restrictions, disconnected peers
+// are replaced by discovery. Cancelled synchronisations are reported as failed.
+// The condition applies to nodes started afterwards as well.
+func (m *NodeManager) SetNetworkCondition(condition common.NetworkCondition) error {
+	switch condition {
+	case common.NetworkWiFi, common.NetworkCellular, common.NetworkOffline:
+	default:
+		return ErrUnknownNetworkCondition
+	}
+
+	m.networkMu.Lock()
+	m.networkCondition = condition
+	m.networkMu.Unlock()
+	log.Info("Network condition changed", "condition", condition)
+
+	m.RLock()
+	defer m.RUnlock()
+
+	if m.peerFilter != nil {
+		m.peerFilter.limitWhisperPeers(whisperPeersLimit(condition))
+	}
+	if m.node == nil {
+		return nil
+	}
+
+	meterWhisperRelay(m.node, condition)
+	if server := m.node.Server(); server != nil && m.peerFilter != nil {
+		m.peerFilter.disconnectDisallowed(server)
+	}
+	if condition == common.NetworkOffline {
+		if syncer := syncCancelerOf(m.node); syncer != nil {
+			syncer.Cancel()
+		}
+	}
+
+	return nil
+}
+
+// currentNetworkCondition returns a condition set with SetNetworkCondition, WiFi by default.
+func (m *NodeManager) currentNetworkCondition() common.NetworkCondition {
+	m.networkMu.RLock()
+	defer m.networkMu.RUnlock()
+
+	if m.networkCondition == "" {
+		return common.NetworkWiFi
+	}
+
+	return m.networkCondition
+}
+
+// whisperPeersLimit returns a maximum number of Whisper peers on a network, 0 if it's unlimited.
+func whisperPeersLimit(condition common.NetworkCondition) int {
+	if condition == common.NetworkWiFi {
+		return 0
+	}
+
+	return meteredWhisperPeers
+}
+
+// syncAllowed reports whether chain synchronisation may start on the current network and isn't
+// suspended, given when the last allowed one was started. The start time is updated if it's allowed.
+func (m *NodeManager) syncAllowed(lastSync *time.Time) bool {
+	if m.isSuspended() {
+		return false
+	}
+
+	now := time.Now()
+	switch m.currentNetworkCondition() {
+	case common.NetworkOffline:
+		return false
+	case common.NetworkCellular:
+		if !lastSync.IsZero() && now.Sub(*lastSync) < cellularSyncInterval {
+			return false
+		}
+	}
+
+	*lastSync = now
+	return true
+}
+
+// meterWhisperRelay enables light mode of Whisper of a given node on a metered network, see shhlimit.Relay.
+func meterWhisperRelay(stack *node.Node, condition common.NetworkCondition) {
+	var shhService *shhlimit.Whisper
+	if err := stack.Service(&shhService); err != nil || shhService == nil {
+		return
+	}
+
+	shhService.Relay().SetMetered(condition != common.NetworkWiFi)
+}
+
+// syncCancelerOf returns a downloader of LES or eth service of a given node, or nil if it runs neither.
+func syncCancelerOf(stack *node.Node) syncCanceler {
+	var lesService *les.LightEthereum
+	if err := stack.Service(&lesService); err == nil && lesService != nil {
+		return lesService.Downloader()
+	}
+
+	var ethService *eth.Ethereum
+	if err := stack.Service(&ethService); err == nil && ethService != nil {
+		return ethService.Downloader()
+	}
+
+	return nil
+}
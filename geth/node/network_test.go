@@ -0,0 +1,79 @@
+package node
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSyncCanceler struct {
+	cancelled int32
+}
+
+func (c *fakeSyncCanceler) Cancel() {
+	atomic.AddInt32(&c.cancelled, 1)
+}
+
+func (c *fakeSyncCanceler) cancellations() int {
+	return int(atomic.LoadInt32(&c.cancelled))
+}
+
+func TestSetNetworkCondition(t *testing.T) {
+	m := NewNodeManager()
+	require.Equal(t, common.NetworkWiFi, m.currentNetworkCondition())
+
+	require.Equal(t, ErrUnknownNetworkCondition, m.SetNetworkCondition("satellite"))
+	require.Equal(t, common.NetworkWiFi, m.currentNetworkCondition())
+
+	// condition can be set before the node is started
+	require.NoError(t, m.SetNetworkCondition(common.NetworkCellular))
+	require.Equal(t, common.NetworkCellular, m.currentNetworkCondition())
+}
+
+func TestSyncAllowed(t *testing.T) {
+	m := NewNodeManager()
+	var lastSync time.Time
+
+	require.True(t, m.syncAllowed(&lastSync))
+	require.True(t, m.syncAllowed(&lastSync))
+
+	require.NoError(t, m.SetNetworkCondition(common.NetworkCellular))
+	require.False(t, m.syncAllowed(&lastSync))
+	lastSync = lastSync.Add(-cellularSyncInterval)
+	require.True(t, m.syncAllowed(&lastSync))
+	require.False(t, m.syncAllowed(&lastSync))
+
+	require.NoError(t, m.SetNetworkCondition(common.NetworkOffline))
+	lastSync = time.Time{}
+	require.False(t, m.syncAllowed(&lastSync))
+
+	require.NoError(t, m.SetNetworkCondition(common.NetworkWiFi))
+	require.True(t, m.syncAllowed(&lastSync))
+}
+
+func TestWatchSyncCancelsSyncOffline(t *testing.T) {
+	m := NewNodeManager()
+	require.NoError(t, m.SetNetworkCondition(common.NetworkOffline))
+
+	mux := new(event.TypeMux)
+	sub := mux.Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+	syncer := &fakeSyncCanceler{}
+	nodeStopped := make(chan struct{})
+	defer close(nodeStopped)
+	go m.watchSync(sub, syncer, nodeStopped)
+
+	// Post returns once the event is received, so the second one waits for the first to be handled
+	require.NoError(t, mux.Post(downloader.StartEvent{}))
+	require.NoError(t, mux.Post(downloader.DoneEvent{}))
+	require.Equal(t, 1, syncer.cancellations())
+
+	require.NoError(t, m.SetNetworkCondition(common.NetworkWiFi))
+	require.NoError(t, mux.Post(downloader.StartEvent{}))
+	require.NoError(t, mux.Post(downloader.DoneEvent{}))
+	require.Equal(t, 1, syncer.cancellations())
+}
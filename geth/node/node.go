@@ -4,10 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
@@ -21,12 +25,23 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/discv5"
 	"github.com/ethereum/go-ethereum/p2p/nat"
-	gethparams "github.com/ethereum/go-ethereum/params"
-	"github.com/ethereum/go-ethereum/whisper/mailserver"
+	gethmailserver "github.com/ethereum/go-ethereum/whisper/mailserver"
 	"github.com/ethereum/go-ethereum/whisper/notifications"
 	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/delivery"
+	"github.com/status-im/status-go/geth/groups"
 	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/mailclient"
+	"github.com/status-im/status-go/geth/mailserver"
+	"github.com/status-im/status-go/geth/msgstore"
+	"github.com/status-im/status-go/geth/notification/fcm"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/pfs"
+	"github.com/status-im/status-go/geth/push"
+	"github.com/status-im/status-go/geth/receipts"
+	"github.com/status-im/status-go/geth/shhlimit"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/status-im/status-go/geth/topics"
 )
 
 // node-related errors
@@ -34,13 +49,29 @@ var (
 	ErrEthServiceRegistrationFailure     = errors.New("failed to register the Ethereum service")
 	ErrWhisperServiceRegistrationFailure = errors.New("failed to register the Whisper service")
 	ErrLightEthRegistrationFailure       = errors.New("failed to register the LES service")
+	ErrFullEthRegistrationFailure        = errors.New("failed to register the full node's eth service")
 	ErrNodeMakeFailure                   = errors.New("error creating p2p node")
 	ErrNodeRunFailure                    = errors.New("error running p2p node")
 	ErrNodeStartFailure                  = errors.New("error starting p2p node")
+	ErrUnsupportedLESVersion             = errors.New("unsupported LES protocol version")
+	ErrUnsupportedSyncMode               = errors.New("unsupported sync mode")
+	ErrInvalidNAT                        = errors.New("invalid NAT mechanism")
+	ErrNoFreeListenPort                  = errors.New("no port of listen port range is available")
 )
 
+// lesProtocolsMu guards les.ProtocolVersions and les.ProtocolLengths,
+// which are read by LES when the service is created.
+var lesProtocolsMu sync.Mutex
+
 // MakeNode create a geth node entity
 func MakeNode(config *params.NodeConfig, deliveryServer whisper.DeliveryServer) (*node.Node, error) {
+	return makeNode(config, deliveryServer, nil, signal.Send)
+}
+
+// makeNode creates a geth node entity which connects to peers with a given dialer, and whose
+// services send signals with a given function. Default TCP dialer is used if it is nil.
+func makeNode(config *params.NodeConfig, deliveryServer whisper.DeliveryServer, dialer p2p.NodeDialer,
+	sendSignal func(signal.Envelope)) (*node.Node, error) {
 	// make sure data directory exists
 	if err := os.MkdirAll(filepath.Join(config.DataDir), os.ModePerm); err != nil {
 		return nil, err
@@ -65,6 +96,24 @@ func MakeNode(config *params.NodeConfig, deliveryServer whisper.DeliveryServer)
 		stackConfig.P2P.PrivateKey = pk
 	}
 
+	natm, err := nat.Parse(config.NAT)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", ErrInvalidNAT, err)
+	}
+	stackConfig.P2P.NAT = natm
+
+	if config.ListenPortRange != "" {
+		listenAddr, err := freeListenAddr(config.ListenAddr, config.ListenPortRange)
+		if err != nil {
+			return nil, err
+		}
+		stackConfig.P2P.ListenAddr = listenAddr
+	}
+
+	if dialer != nil {
+		stackConfig.P2P.Dialer = dialer
+	}
+
 	stack, err := node.New(stackConfig)
 	if err != nil {
 		return nil, ErrNodeMakeFailure
@@ -78,32 +127,60 @@ func MakeNode(config *params.NodeConfig, deliveryServer whisper.DeliveryServer)
 	}
 
 	// start Whisper service
-	if err := activateShhService(stack, config, deliveryServer); err != nil {
+	if err := activateShhService(stack, config, deliveryServer, sendSignal); err != nil {
 		return nil, fmt.Errorf("%v: %v", ErrWhisperServiceRegistrationFailure, err)
 	}
 
 	return stack, nil
 }
 
+// freeListenAddr returns an address with the host of listenAddr and the first port of a range
+// which is available for listening.
+func freeListenAddr(listenAddr, portRange string) (string, error) {
+	from, to, err := params.ParsePortRange(portRange)
+	if err != nil {
+		return "", err
+	}
+
+	var host string
+	if listenAddr != "" {
+		if host, _, err = net.SplitHostPort(listenAddr); err != nil {
+			return "", err
+		}
+	}
+
+	for port := from; port <= to; port++ {
+		addr := net.JoinHostPort(host, strconv.Itoa(port))
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			continue
+		}
+		if err := listener.Close(); err != nil {
+			return "", err
+		}
+		return addr, nil
+	}
+
+	return "", ErrNoFreeListenPort
+}
+
 // defaultEmbeddedNodeConfig returns default stack configuration for mobile client node
 func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 	nc := &node.Config{
 		DataDir:           config.DataDir,
 		KeyStoreDir:       config.KeyStoreDir,
-		UseLightweightKDF: true,
+		UseLightweightKDF: config.KeyStoreConfig.KDF != params.StandardKDF,
 		NoUSB:             true,
 		Name:              config.Name,
 		Version:           config.Version,
 		P2P: p2p.Config{
-			NoDiscovery:      true,
-			DiscoveryV5:      true,
-			DiscoveryV5Addr:  ":0",
-			BootstrapNodes:   makeBootstrapNodes(),
-			BootstrapNodesV5: makeBootstrapNodesV5(),
-			ListenAddr:       ":0",
-			NAT:              nat.Any(),
-			MaxPeers:         config.MaxPeers,
-			MaxPendingPeers:  config.MaxPendingPeers,
+			NoDiscovery:     true,
+			DiscoveryV5:     config.DiscoveryV5,
+			DiscoveryV5Addr: ":0",
+			BootstrapNodes:  makeBootstrapNodes(),
+			ListenAddr:      config.ListenAddr,
+			MaxPeers:        config.MaxPeers,
+			MaxPendingPeers: config.MaxPendingPeers,
 		},
 		IPCPath:     makeIPCPath(config),
 		HTTPCors:    []string{"*"},
@@ -114,6 +191,11 @@ func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 		WSModules:   strings.Split(config.APIModules, ","),
 	}
 
+	// discovery v5 is seeded with the boot cluster, as it is when boot nodes are replaced or re-dialed
+	if config.BootClusterConfig.Enabled {
+		nc.P2P.BootstrapNodesV5 = makeBootNodesV5(config.BootClusterConfig.BootNodes)
+	}
+
 	if config.RPCEnabled {
 		nc.HTTPHost = config.HTTPHost
 		nc.HTTPPort = config.HTTPPort
@@ -122,12 +204,9 @@ func defaultEmbeddedNodeConfig(config *params.NodeConfig) *node.Config {
 	return nc
 }
 
-// updateCHT changes trusted canonical hash trie root
+// updateCHT changes trusted canonical hash trie root, so that light client
+// starts syncing from the checkpoint instead of genesis
 func updateCHT(eth *les.LightEthereum, config *params.NodeConfig) {
-	if !config.BootClusterConfig.Enabled {
-		return
-	}
-
 	if config.BootClusterConfig.RootNumber == 0 {
 		return
 	}
@@ -145,12 +224,18 @@ func updateCHT(eth *les.LightEthereum, config *params.NodeConfig) {
 }
 
 // activateEthService configures and registers the eth.Ethereum service with a given node.
+// LES service is registered in light sync mode, and full node's eth service otherwise.
 func activateEthService(stack *node.Node, config *params.NodeConfig) error {
 	if !config.LightEthConfig.Enabled {
 		log.Info("LES protocol is disabled")
 		return nil
 	}
 
+	syncMode, err := makeSyncMode(config.SyncMode)
+	if err != nil {
+		return err
+	}
+
 	var genesis *core.Genesis
 	if config.LightEthConfig.Genesis != "" {
 		genesis = new(core.Genesis)
@@ -161,12 +246,29 @@ func activateEthService(stack *node.Node, config *params.NodeConfig) error {
 
 	ethConf := eth.DefaultConfig
 	ethConf.Genesis = genesis
-	ethConf.SyncMode = downloader.LightSync
+	ethConf.SyncMode = syncMode
 	ethConf.NetworkId = config.NetworkID
 	ethConf.DatabaseCache = config.LightEthConfig.DatabaseCache
 
+	if syncMode != downloader.LightSync {
+		log.Info("Starting full node", "mode", config.SyncMode)
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return eth.New(ctx, &ethConf)
+		}); err != nil {
+			return fmt.Errorf("%v: %v", ErrFullEthRegistrationFailure, err)
+		}
+
+		return nil
+	}
+
+	// fail early, before the node is started
+	protocolVersions := config.LightEthConfig.ProtocolVersions
+	if err := validateLESProtocolVersions(protocolVersions); err != nil {
+		return err
+	}
+
 	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-		lightEth, err := les.New(ctx, &ethConf)
+		lightEth, err := newLightEthereum(ctx, &ethConf, protocolVersions)
 		if err == nil {
 			updateCHT(lightEth, config)
 		}
@@ -179,13 +281,127 @@ func activateEthService(stack *node.Node, config *params.NodeConfig) error {
 	return nil
 }
 
-// activateShhService configures Whisper and adds it to the given node.
-func activateShhService(stack *node.Node, config *params.NodeConfig, deliveryServer whisper.DeliveryServer) error {
+// makeSyncMode converts sync mode name to downloader's mode.
+// Light sync is used if the mode is not set.
+func makeSyncMode(mode string) (downloader.SyncMode, error) {
+	switch mode {
+	case params.LightSyncMode, "":
+		return downloader.LightSync, nil
+	case params.FastSyncMode:
+		return downloader.FastSync, nil
+	case params.FullSyncMode:
+		return downloader.FullSync, nil
+	}
+
+	return 0, ErrUnsupportedSyncMode
+}
+
+// validateLESProtocolVersions checks that given LES protocol versions are supported.
+func validateLESProtocolVersions(versions []uint) error {
+	lesProtocolsMu.Lock()
+	defer lesProtocolsMu.Unlock()
+
+	_, err := selectLESProtocols(versions)
+	return err
+}
+
+// newLightEthereum creates LES service which advertises given protocol versions,
+// or all supported versions if none is given.
+func newLightEthereum(ctx *node.ServiceContext, ethConf *eth.Config, versions []uint) (*les.LightEthereum, error) {
+	if len(versions) == 0 {
+		return les.New(ctx, ethConf)
+	}
+
+	lesProtocolsMu.Lock()
+	defer lesProtocolsMu.Unlock()
+
+	lengths, err := selectLESProtocols(versions)
+	if err != nil {
+		return nil, err
+	}
+
+	// LES reads supported protocols from package variables, so they
+	// are replaced only for the time the service is being created
+	defaultVersions, defaultLengths := les.ProtocolVersions, les.ProtocolLengths
+	defer func() {
+		les.ProtocolVersions, les.ProtocolLengths = defaultVersions, defaultLengths
+	}()
+	les.ProtocolVersions, les.ProtocolLengths = versions, lengths
+
+	return les.New(ctx, ethConf)
+}
+
+// selectLESProtocols returns message counts of given LES protocol versions.
+// lesProtocolsMu must be held.
+func selectLESProtocols(versions []uint) ([]uint64, error) {
+	lengths := make([]uint64, len(versions))
+
+	for i, version := range versions {
+		supported := false
+		for j, supportedVersion := range les.ProtocolVersions {
+			if version == supportedVersion {
+				lengths[i] = les.ProtocolLengths[j]
+				supported = true
+				break
+			}
+		}
+
+		if !supported {
+			return nil, fmt.Errorf("%v: %d", ErrUnsupportedLESVersion, version)
+		}
+	}
+
+	return lengths, nil
+}
+
+// activateShhService configures Whisper and adds it to the given node, with services sending signals
+// with a given function.
+func activateShhService(stack *node.Node, config *params.NodeConfig, deliveryServer whisper.DeliveryServer,
+	sendSignal func(signal.Envelope)) error {
 	if !config.WhisperConfig.Enabled {
 		log.Info("SHH protocol is disabled")
 		return nil
 	}
 
+	// the tracker records states of envelopes sent by the node, passing states of messages on
+	tracker := delivery.New(deliveryServer, config.WhisperConfig.DeliverySignals)
+	tracker.SetSignalHandler(sendSignal)
+	deliveryServer = tracker
+
+	// the relay records envelopes posted by the node, which are the only ones sent to peers in light mode
+	relay := shhlimit.NewRelay(deliveryServer, config.WhisperConfig.LightClient)
+	deliveryServer = relay
+
+	// the message store keeps messages received by open identities, passing states of messages on
+	messageStore := msgstore.New(deliveryServer)
+	deliveryServer = messageStore
+
+	// the receipts service acknowledges messages received by identities and matches receipts of messages
+	// sent by the node, passing states of messages on
+	var receiptsService *receipts.Service
+	if config.WhisperConfig.DeliveryReceipts {
+		receiptsService = receipts.New(deliveryServer)
+		receiptsService.SetSignalHandler(sendSignal)
+		deliveryServer = receiptsService
+	}
+
+	// the mail server client counts envelopes delivered by the mail server, passing states of messages on
+	var mailClient *mailclient.Client
+	if config.WhisperConfig.MailServerEnode != "" || len(config.WhisperConfig.MailServerEnodes) > 0 {
+		var err error
+		if mailClient, err = mailclient.New(config.WhisperConfig, deliveryServer); err != nil {
+			return err
+		}
+		mailClient.SetSignalHandler(sendSignal)
+		deliveryServer = mailClient
+	}
+
+	// the mail server archives envelopes received by Whisper and delivers them on demand
+	var mailServer *mailserver.MailServer
+	if config.MailServerConfig.Enabled {
+		mailServer = mailserver.New(&config.MailServerConfig)
+	}
+
 	serviceConstructor := func(*node.ServiceContext) (node.Service, error) {
 		whisperConfig := config.WhisperConfig
 		whisperService := whisper.New(nil)
@@ -195,15 +411,17 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, deliverySer
 		}
 
 		// enable mail service
-		if whisperConfig.MailServerNode {
+		if mailServer != nil {
+			whisperService.RegisterServer(mailServer)
+		} else if whisperConfig.MailServerNode {
 			password, err := whisperConfig.ReadPasswordFile()
 			if err != nil {
 				return nil, err
 			}
 
-			var mailServer mailserver.WMailServer
-			whisperService.RegisterServer(&mailServer)
-			mailServer.Init(whisperService, whisperConfig.DataDir, string(password), whisperConfig.MinimumPoW)
+			var wMailServer gethmailserver.WMailServer
+			whisperService.RegisterServer(&wMailServer)
+			wMailServer.Init(whisperService, whisperConfig.DataDir, string(password), whisperConfig.MinimumPoW)
 		}
 
 		// enable notification service
@@ -214,10 +432,205 @@ func activateShhService(stack *node.Node, config *params.NodeConfig, deliverySer
 			notificationServer.Init(whisperService, whisperConfig)
 		}
 
-		return whisperService, nil
+		// limit envelopes each peer can send
+		limiter := shhlimit.NewLimiter(whisperConfig.PeerEnvelopeRate, whisperConfig.PeerByteRate,
+			time.Duration(whisperConfig.PeerRatePenalty)*time.Second)
+
+		shhService := shhlimit.New(whisperService, limiter, relay)
+		shhService.SetWrittenHandler(tracker.Relayed) // the tracker counts peers envelopes are written to
+
+		return shhService, nil
+	}
+
+	if err := stack.Register(serviceConstructor); err != nil {
+		return err
+	}
+
+	// enable tracking of envelopes sent by the node
+	if err := stack.Register(func(*node.ServiceContext) (node.Service, error) {
+		return tracker, nil
+	}); err != nil {
+		return err
+	}
+
+	// enable storage of messages received by identities
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := whisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		db, err := msgstore.OpenDB(ctx.ResolvePath(msgstore.DataDir))
+		if err != nil {
+			return nil, err
+		}
+		messageStore.Init(whisperService, db)
+
+		return messageStore, nil
+	}); err != nil {
+		return err
 	}
 
-	return stack.Register(serviceConstructor)
+	// enable negotiation of topics of one-to-one chats
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := whisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return topics.New(whisperService), nil
+	}); err != nil {
+		return err
+	}
+
+	// enable membership of group chats
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := whisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		groupsManager := groups.New(whisperService)
+		groupsManager.SetSignalHandler(sendSignal)
+
+		return groupsManager, nil
+	}); err != nil {
+		return err
+	}
+
+	// enable sessions with perfect forward secrecy of one-to-one chats
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := whisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return pfs.New(whisperService, ctx.ResolvePath(pfs.DataDir))
+	}); err != nil {
+		return err
+	}
+
+	// enable acknowledgments of messages
+	if receiptsService != nil {
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			whisperService, err := limitedWhisperOf(ctx)
+			if err != nil {
+				return nil, err
+			}
+			receiptsService.Init(whisperService)
+
+			return receiptsService, nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	// enable requests of push notifications of identities
+	if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := limitedWhisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		pushClient := push.NewClient(whisperService)
+		pushClient.SetSignalHandler(sendSignal)
+
+		return pushClient, nil
+	}); err != nil {
+		return err
+	}
+
+	if config.PushServerConfig.Enabled {
+		if err := activatePushServer(stack, config); err != nil {
+			return err
+		}
+	}
+
+	if mailServer != nil {
+		if err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			whisperService, err := whisperOf(ctx)
+			if err != nil {
+				return nil, err
+			}
+			storage, err := mailserver.NewLevelDBStorage(config.MailServerConfig.DataDir)
+			if err != nil {
+				return nil, err
+			}
+			if err := mailServer.Init(whisperService, storage); err != nil {
+				storage.Close() // nolint: errcheck
+				return nil, err
+			}
+
+			return mailServer, nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	if mailClient == nil {
+		return nil
+	}
+
+	// enable requests of messages sent while the node was offline from the mail server
+	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := whisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err := mailClient.Init(whisperService); err != nil {
+			return nil, err
+		}
+
+		return mailClient, nil
+	})
+}
+
+// activatePushServer registers a push server, which sends notifications to devices registered over Whisper
+// with the FCM authorization key of the Whisper config.
+func activatePushServer(stack *node.Node, config *params.NodeConfig) error {
+	key, err := config.PushServerConfig.ReadIdentityFile()
+	if err != nil {
+		return err
+	}
+	authorizationKey, err := config.WhisperConfig.FirebaseConfig.ReadAuthorizationKeyFile()
+	if err != nil {
+		return err
+	}
+	pushServer := push.NewServer(fcm.NewNotification(string(authorizationKey))())
+
+	return stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		whisperService, err := limitedWhisperOf(ctx)
+		if err != nil {
+			return nil, err
+		}
+		db, err := push.OpenDB(config.PushServerConfig.DataDir)
+		if err != nil {
+			return nil, err
+		}
+		pushServer.Init(whisperService, key, db)
+
+		return pushServer, nil
+	})
+}
+
+// whisperOf returns the Whisper service of a node, which is registered with limits of its peers.
+func whisperOf(ctx *node.ServiceContext) (*whisper.Whisper, error) {
+	shhService, err := limitedWhisperOf(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return shhService.Whisper, nil
+}
+
+// limitedWhisperOf returns the Whisper service of a node as registered, for services sending envelopes
+// other than with shh_post, so that they're recorded as posted by the node.
+func limitedWhisperOf(ctx *node.ServiceContext) (*shhlimit.Whisper, error) {
+	var shhService *shhlimit.Whisper
+	if err := ctx.Service(&shhService); err != nil {
+		return nil, err
+	}
+
+	return shhService, nil
 }
 
 // makeIPCPath returns IPC-RPC filename
@@ -252,14 +665,20 @@ func makeBootstrapNodes() []*discover.Node {
 	return bootstrapNodes
 }
 
-// makeBootstrapNodesV5 returns default (hence bootstrap) list of peers
-func makeBootstrapNodesV5() []*discv5.Node {
-	enodes := gethparams.DiscoveryV5Bootnodes
-
-	var bootstrapNodes []*discv5.Node
+// makeBootNodesV5 returns boot nodes as discovery v5 nodes, which discovery is seeded with. Boot nodes
+// without a discovery port are only dialed, invalid ones are skipped.
+func makeBootNodesV5(enodes []string) []*discv5.Node {
+	bootNodes := make([]*discv5.Node, 0, len(enodes))
 	for _, enode := range enodes {
-		bootstrapNodes = append(bootstrapNodes, discv5.MustParseNode(enode))
+		bootNode, err := discv5.ParseNode(enode)
+		if err != nil {
+			log.Warn("Invalid boot node is not used for discovery", "enode", enode, "error", err)
+			continue
+		}
+		if bootNode.UDP != 0 {
+			bootNodes = append(bootNodes, bootNode)
+		}
 	}
 
-	return bootstrapNodes
+	return bootNodes
 }
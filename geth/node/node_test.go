@@ -0,0 +1,54 @@
+package node
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMakeSyncMode(t *testing.T) {
+	testCases := []struct {
+		mode     string
+		expected downloader.SyncMode
+		err      error
+	}{
+		{"", downloader.LightSync, nil},
+		{params.LightSyncMode, downloader.LightSync, nil},
+		{params.FastSyncMode, downloader.FastSync, nil},
+		{params.FullSyncMode, downloader.FullSync, nil},
+		{"archive", 0, ErrUnsupportedSyncMode},
+	}
+
+	for _, tc := range testCases {
+		mode, err := makeSyncMode(tc.mode)
+		require.Equal(t, tc.err, err, "mode %q", tc.mode)
+		require.Equal(t, tc.expected, mode, "mode %q", tc.mode)
+	}
+}
+
+func TestFreeListenAddr(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close() // nolint: errcheck
+	busyPort := listener.Addr().(*net.TCPAddr).Port
+
+	_, err = freeListenAddr("127.0.0.1:0", strconv.Itoa(busyPort))
+	require.Equal(t, ErrNoFreeListenPort, err)
+
+	addr, err := freeListenAddr("127.0.0.1:30303", fmt.Sprintf("%d-%d", busyPort, busyPort+10))
+	require.NoError(t, err)
+	host, port, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", host)
+	number, err := strconv.Atoi(port)
+	require.NoError(t, err)
+	require.True(t, number > busyPort && number <= busyPort+10, "port %d is out of range", number)
+
+	_, err = freeListenAddr(":0", "10-1")
+	require.Equal(t, params.ErrInvalidPortRange, err)
+}
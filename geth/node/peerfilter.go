@@ -0,0 +1,392 @@
+package node
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// lesProtocolName is a name of LES protocol, which is not exported by les package
+const lesProtocolName = "les"
+
+// peer filter errors
+var (
+	ErrPeerNotAllowed     = errors.New("peer is blacklisted or not whitelisted")
+	ErrInvalidPeerFilter  = errors.New("peer filter entry must be an enode URL, an IP address or a CIDR range")
+	ErrPeerNotBlacklisted = errors.New("peer is not blacklisted")
+	ErrDialsSuspended     = errors.New("node is suspended, peers are not dialed")
+)
+
+// BlacklistPeer prevents the running node from connecting to a peer given by enode URL,
+// or to peers from an IP address or CIDR range, and disconnects matching peers.
+// Like SetBootNodes, it changes the running node's config, so the blacklist survives restarts.
+func (m *NodeManager) BlacklistPeer(peer string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	for _, entry := range m.config.PeerBlacklist {
+		if entry == peer {
+			return nil
+		}
+	}
+
+	newConfig := *m.config
+	newConfig.PeerBlacklist = append(append([]string(nil), m.config.PeerBlacklist...), peer)
+
+	return m.reloadConfig(&newConfig, []string{"PeerBlacklist"})
+}
+
+// UnblacklistPeer removes an entry added with BlacklistPeer or listed in PeerBlacklist config.
+func (m *NodeManager) UnblacklistPeer(peer string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	var blacklist []string
+	for _, entry := range m.config.PeerBlacklist {
+		if entry != peer {
+			blacklist = append(blacklist, entry)
+		}
+	}
+	if len(blacklist) == len(m.config.PeerBlacklist) {
+		return ErrPeerNotBlacklisted
+	}
+
+	newConfig := *m.config
+	newConfig.PeerBlacklist = blacklist
+
+	return m.reloadConfig(&newConfig, []string{"PeerBlacklist"})
+}
+
+// SetPeerWhitelist restricts connections of the running node to given peers and disconnects
+// the others. Empty whitelist lifts the restriction. Nothing is changed if any entry is invalid.
+func (m *NodeManager) SetPeerWhitelist(peers []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.isNodeAvailable(); err != nil {
+		return err
+	}
+
+	<-m.nodeStarted
+
+	newConfig := *m.config
+	newConfig.PeerWhitelist = append([]string(nil), peers...)
+
+	return m.reloadConfig(&newConfig, []string{"PeerWhitelist"})
+}
+
+// reloadPeerFilter replaces lists of the running node's peer filter and disconnects peers
+// which are not allowed anymore.
+func (m *NodeManager) reloadPeerFilter(blacklist, whitelist []string) error {
+	server := m.node.Server()
+	if server == nil || m.peerFilter == nil {
+		return ErrNoRunningNode
+	}
+
+	if err := m.peerFilter.set(blacklist, whitelist); err != nil {
+		return err
+	}
+	m.peerFilter.disconnectDisallowed(server)
+
+	return nil
+}
+
+// peerList matches peers by node ID and IP ranges.
+type peerList struct {
+	ids  map[discover.NodeID]bool
+	nets []*net.IPNet
+}
+
+// newPeerList parses enode URLs, IP addresses and CIDR ranges.
+func newPeerList(entries []string) (*peerList, error) {
+	list := &peerList{ids: make(map[discover.NodeID]bool)}
+	for _, entry := range entries {
+		if err := list.add(entry); err != nil {
+			return nil, err
+		}
+	}
+
+	return list, nil
+}
+
+func (l *peerList) add(entry string) error {
+	id, ipNet, err := parsePeerFilterEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	if ipNet != nil {
+		l.nets = append(l.nets, ipNet)
+	} else {
+		l.ids[id] = true
+	}
+
+	return nil
+}
+
+func (l *peerList) contains(id discover.NodeID, ip net.IP) bool {
+	if l.ids[id] {
+		return true
+	}
+
+	for _, n := range l.nets {
+		if ip != nil && n.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *peerList) empty() bool {
+	return len(l.ids) == 0 && len(l.nets) == 0
+}
+
+// parsePeerFilterEntry parses an enode URL to a node ID, or an IP address or CIDR range to a network.
+func parsePeerFilterEntry(entry string) (discover.NodeID, *net.IPNet, error) {
+	if strings.HasPrefix(entry, "enode://") {
+		node, err := discover.ParseNode(entry)
+		if err != nil {
+			return discover.NodeID{}, nil, ErrInvalidPeerFilter
+		}
+		return node.ID, nil, nil
+	}
+
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return discover.NodeID{}, nil, ErrInvalidPeerFilter
+		}
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 8*net.IPv4len
+		}
+		return discover.NodeID{}, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(entry)
+	if err != nil {
+		return discover.NodeID{}, nil, ErrInvalidPeerFilter
+	}
+
+	return discover.NodeID{}, ipNet, nil
+}
+
+// peerFilter decides which peers the node is allowed to be connected to:
+// blacklisted peers never are, and if a whitelist is set only whitelisted peers are.
+// Dials of disallowed peers are refused, and disallowed peers which connect
+// to the node are disconnected right after the handshake.
+// A number of Whisper peers can be limited as well, to reduce traffic of relayed envelopes:
+// peers which serve LES count towards the limit, but only those which don't are disconnected.
+// No peers are dialed while dials are suspended.
+type peerFilter struct {
+	mu                sync.RWMutex
+	blacklist         *peerList
+	whitelist         *peerList
+	whisperPeersLimit int  // 0 if Whisper peers are not limited
+	dialsSuspended    bool // whether the node is suspended
+}
+
+// newPeerFilter creates peerFilter with given blacklist and whitelist entries.
+func newPeerFilter(blacklist, whitelist []string) (*peerFilter, error) {
+	black, err := newPeerList(blacklist)
+	if err != nil {
+		return nil, err
+	}
+	white, err := newPeerList(whitelist)
+	if err != nil {
+		return nil, err
+	}
+
+	return &peerFilter{blacklist: black, whitelist: white}, nil
+}
+
+// allowed reports whether a peer with a given ID and IP may be connected.
+func (f *peerFilter) allowed(id discover.NodeID, ip net.IP) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.blacklist.contains(id, ip) {
+		return false
+	}
+
+	return f.whitelist.empty() || f.whitelist.contains(id, ip)
+}
+
+// set replaces both lists, nothing is changed if any of the entries is invalid.
+func (f *peerFilter) set(blacklist, whitelist []string) error {
+	filter, err := newPeerFilter(blacklist, whitelist)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blacklist, f.whitelist = filter.blacklist, filter.whitelist
+	return nil
+}
+
+// limitWhisperPeers sets a maximum number of Whisper peers, 0 removes the limit.
+func (f *peerFilter) limitWhisperPeers(limit int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.whisperPeersLimit = limit
+}
+
+// suspendDials makes the filter refuse all dials, or allows them again.
+func (f *peerFilter) suspendDials(suspended bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.dialsSuspended = suspended
+}
+
+// dialable reports whether a peer with a given ID and IP may be dialed.
+func (f *peerFilter) dialable(id discover.NodeID, ip net.IP) error {
+	f.mu.RLock()
+	suspended := f.dialsSuspended
+	f.mu.RUnlock()
+
+	if suspended {
+		return ErrDialsSuspended
+	}
+	if !f.allowed(id, ip) {
+		return ErrPeerNotAllowed
+	}
+
+	return nil
+}
+
+// dialer returns p2p.NodeDialer which refuses to dial disallowed peers and any peers while dials are suspended.
+func (f *peerFilter) dialer(dialer p2p.NodeDialer) p2p.NodeDialer {
+	return &filteredDialer{filter: f, dialer: dialer}
+}
+
+// disconnectDisallowed disconnects connected peers which are not allowed anymore,
+// and Whisper peers above the limit.
+func (f *peerFilter) disconnectDisallowed(server *p2p.Server) {
+	var allowed []*p2p.Peer
+	for _, peer := range server.Peers() {
+		if !f.allowed(peer.ID(), peerIP(peer)) {
+			log.Info("Disconnecting peer rejected by peer filter", "peer", peer.ID())
+			peer.Disconnect(p2p.DiscUselessPeer)
+			continue
+		}
+		allowed = append(allowed, peer)
+	}
+
+	f.mu.RLock()
+	limit := f.whisperPeersLimit
+	f.mu.RUnlock()
+
+	for _, peer := range excessWhisperPeers(allowed, limit) {
+		log.Info("Disconnecting Whisper peer above the limit", "peer", peer.ID(), "limit", limit)
+		peer.Disconnect(p2p.DiscTooManyPeers)
+	}
+}
+
+// excessWhisperPeers returns Whisper peers which don't serve LES and exceed a limit
+// together with those which do. Nothing is returned if the limit is 0.
+func excessWhisperPeers(peers []*p2p.Peer, limit int) []*p2p.Peer {
+	if limit == 0 {
+		return nil
+	}
+
+	var (
+		whisperPeers int
+		relayPeers   []*p2p.Peer
+	)
+	for _, peer := range peers {
+		switch {
+		case !runsProtocol(peer, whisper.ProtocolName):
+		case runsProtocol(peer, lesProtocolName):
+			whisperPeers++
+		default:
+			relayPeers = append(relayPeers, peer)
+		}
+	}
+
+	if free := limit - whisperPeers; free > 0 {
+		if free >= len(relayPeers) {
+			return nil
+		}
+		return relayPeers[free:]
+	}
+
+	return relayPeers
+}
+
+// runsProtocol reports whether a peer supports a protocol of a given name.
+func runsProtocol(peer *p2p.Peer, name string) bool {
+	for _, capability := range peer.Caps() {
+		if capability.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// watch disconnects disallowed peers as soon as they connect, until the node is stopped.
+func (f *peerFilter) watch(server *p2p.Server, nodeStopped <-chan struct{}) {
+	events := make(chan *p2p.PeerEvent, 10)
+	sub := server.SubscribeEvents(events)
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == p2p.PeerEventTypeAdd {
+				f.disconnectDisallowed(server)
+			}
+		case <-sub.Err():
+			return
+		case <-nodeStopped:
+			return
+		}
+	}
+}
+
+// peerIP returns IP address of a connected peer, or nil if it's not connected over TCP.
+func peerIP(peer *p2p.Peer) net.IP {
+	if addr, ok := peer.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP
+	}
+
+	return nil
+}
+
+// filteredDialer is p2p.NodeDialer which dials peers only if its filter allows it.
+type filteredDialer struct {
+	filter *peerFilter
+	dialer p2p.NodeDialer
+}
+
+// Dial implements p2p.NodeDialer.
+func (d *filteredDialer) Dial(dest *discover.Node) (net.Conn, error) {
+	if err := d.filter.dialable(dest.ID, dest.IP); err != nil {
+		return nil, err
+	}
+
+	return d.dialer.Dial(dest)
+}
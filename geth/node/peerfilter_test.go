@@ -0,0 +1,100 @@
+package node
+
+import (
+	"net"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+const filteredEnode = "enode://3fa7d4b33e1a6eac0a2c2a35a6a47f5a7ae02c7e50ef5b2c21b1ed1e3b90de4a1aac5b89a55ac38ca26f1df07ae80ff9b5fbd1c4ae0b3f8ae25a2ae1c5e0ad2a@127.0.0.1:30303"
+
+func TestPeerFilterAllowed(t *testing.T) {
+	node, err := discover.ParseNode(filteredEnode)
+	require.NoError(t, err)
+	otherID := discover.NodeID{1}
+
+	testCases := []struct {
+		name      string
+		blacklist []string
+		whitelist []string
+		id        discover.NodeID
+		ip        string
+		allowed   bool
+	}{
+		{"empty lists", nil, nil, otherID, "10.0.0.1", true},
+		{"blacklisted enode", []string{filteredEnode}, nil, node.ID, "10.0.0.1", false},
+		{"blacklisted IP", []string{"10.0.0.1"}, nil, otherID, "10.0.0.1", false},
+		{"blacklisted IP range", []string{"10.0.0.0/8"}, nil, otherID, "10.1.2.3", false},
+		{"IP out of blacklisted range", []string{"10.0.0.0/8"}, nil, otherID, "11.0.0.1", true},
+		{"blacklisted IPv6 range", []string{"fd00::/8"}, nil, otherID, "fd00::1", false},
+		{"whitelisted enode", nil, []string{filteredEnode}, node.ID, "10.0.0.1", true},
+		{"whitelisted IP range", nil, []string{"10.0.0.0/8"}, otherID, "10.1.2.3", true},
+		{"not whitelisted", nil, []string{"10.0.0.0/8", filteredEnode}, otherID, "11.0.0.1", false},
+		{"blacklisted and whitelisted", []string{filteredEnode}, []string{"10.0.0.0/8"}, node.ID, "10.0.0.1", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := newPeerFilter(tc.blacklist, tc.whitelist)
+			require.NoError(t, err)
+			require.Equal(t, tc.allowed, filter.allowed(tc.id, net.ParseIP(tc.ip)))
+		})
+	}
+}
+
+func TestPeerFilterInvalidEntries(t *testing.T) {
+	for _, entry := range []string{"", "10.0.0", "10.0.0.0/33", "enode://abc@127.0.0.1:30303"} {
+		_, err := newPeerFilter([]string{entry}, nil)
+		require.Equal(t, ErrInvalidPeerFilter, err, entry)
+	}
+
+	filter, err := newPeerFilter([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+
+	// invalid lists are not applied partially
+	require.Equal(t, ErrInvalidPeerFilter, filter.set(nil, []string{"11.0.0.0/8", "invalid"}))
+	require.False(t, filter.allowed(discover.NodeID{}, net.ParseIP("10.0.0.1")))
+	require.True(t, filter.allowed(discover.NodeID{}, net.ParseIP("12.0.0.1")))
+
+	require.NoError(t, filter.set(nil, []string{"11.0.0.0/8"}))
+	require.True(t, filter.allowed(discover.NodeID{}, net.ParseIP("11.0.0.1")))
+	require.False(t, filter.allowed(discover.NodeID{}, net.ParseIP("12.0.0.1")))
+}
+
+func TestFilteredDialer(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()  // nolint: errcheck
+	defer remote.Close() // nolint: errcheck
+
+	filter, err := newPeerFilter([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+	dialer := filter.dialer(pipeDialer{local})
+
+	_, err = dialer.Dial(&discover.Node{IP: net.ParseIP("10.0.0.1")})
+	require.Equal(t, ErrPeerNotAllowed, err)
+
+	conn, err := dialer.Dial(&discover.Node{IP: net.ParseIP("11.0.0.1")})
+	require.NoError(t, err)
+	require.Equal(t, local, conn)
+}
+
+func TestExcessWhisperPeers(t *testing.T) {
+	shh := p2p.Cap{Name: whisper.ProtocolName, Version: 5}
+	les := p2p.Cap{Name: lesProtocolName, Version: 2}
+	lesServer := p2p.NewPeer(discover.NodeID{1}, "les", []p2p.Cap{les, shh})
+	relay1 := p2p.NewPeer(discover.NodeID{2}, "relay1", []p2p.Cap{shh})
+	relay2 := p2p.NewPeer(discover.NodeID{3}, "relay2", []p2p.Cap{shh})
+	other := p2p.NewPeer(discover.NodeID{4}, "other", []p2p.Cap{{Name: "bzz", Version: 0}})
+	peers := []*p2p.Peer{lesServer, relay1, relay2, other}
+
+	require.Empty(t, excessWhisperPeers(peers, 0))
+	require.Empty(t, excessWhisperPeers(peers, 3))
+	require.Equal(t, []*p2p.Peer{relay2}, excessWhisperPeers(peers, 2))
+	// LES servers are kept even if they exceed the limit
+	require.Equal(t, []*p2p.Peer{relay1, relay2}, excessWhisperPeers(peers, 1))
+	require.Equal(t, []*p2p.Peer{relay2}, excessWhisperPeers([]*p2p.Peer{relay1, relay2, other}, 1))
+}
@@ -0,0 +1,105 @@
+package node
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// peerCounter reports a number of connected peers, e.g. p2p.Server.
+type peerCounter interface {
+	PeerCount() int
+}
+
+// maintainPeers checks the peer count periodically until the node is stopped.
+// While there are fewer peers than the target, boot nodes and known peers are re-dialed and discovery is re-seeded,
+// so that the node recovers after losing network connectivity. If the node has no peers for
+// longer than PeerlessTimeout, peers.lost signal is sent, once for every period without peers.
+// Peers are not checked while the node is suspended.
+func (m *NodeManager) maintainPeers(server peerCounter, config params.PeerMaintenanceConfig, nodeStopped <-chan struct{}) {
+	checkInterval := time.Duration(config.CheckInterval) * time.Millisecond
+	if checkInterval <= 0 {
+		checkInterval = params.PeerCheckInterval * time.Millisecond
+	}
+	peerlessTimeout := time.Duration(config.PeerlessTimeout) * time.Millisecond
+
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	var (
+		peerlessSince time.Time
+		reported      bool
+	)
+	for {
+		select {
+		case <-ticker.C:
+			if m.isSuspended() {
+				peerlessSince, reported = time.Time{}, false
+				continue
+			}
+
+			count := server.PeerCount()
+			// there is no point in dialing peers while the device is offline
+			if count < config.TargetPeers && m.currentNetworkCondition() != common.NetworkOffline {
+				log.Info("Peer count is below target, re-dialing peers", "peers", count, "target", config.TargetPeers)
+				m.redialPeers(nodeStopped)
+			}
+
+			if count > 0 {
+				peerlessSince, reported = time.Time{}, false
+				continue
+			}
+			if peerlessSince.IsZero() {
+				peerlessSince = time.Now()
+			}
+			if !reported && time.Since(peerlessSince) >= peerlessTimeout {
+				log.Warn("Node has no peers", "since", peerlessSince)
+				m.SendSignal(signal.Envelope{
+					Type:  signal.EventPeersLost,
+					Event: signal.PeersLostEvent{Since: peerlessSince.Unix()},
+				})
+				reported = true
+			}
+		case <-nodeStopped:
+			return
+		}
+	}
+}
+
+// redialPeers adds boot nodes and the best known peers as static peers again, which makes the p2p server resolve
+// their addresses anew, and re-seeds discovery v5 with the boot nodes.
+// Nothing is done if the node which is maintained has been stopped.
+func (m *NodeManager) redialPeers(nodeStopped <-chan struct{}) {
+	m.RLock()
+	defer m.RUnlock()
+
+	if m.node == nil || m.nodeStopped != nodeStopped {
+		return
+	}
+
+	if m.config.BootClusterConfig.Enabled {
+		for _, enode := range m.config.BootClusterConfig.BootNodes {
+			if err := m.addPeer(enode); err != nil {
+				log.Warn("Boot node re-dialing failed", "enode", enode, "error", err)
+			}
+		}
+	}
+
+	if m.knownPeers != nil {
+		for _, enode := range m.knownPeers.best(m.config.PeerMaintenanceConfig.KnownPeers) {
+			if err := m.addPeer(enode); err != nil {
+				log.Warn("Known peer re-dialing failed", "enode", enode, "error", err)
+			}
+		}
+	}
+
+	server := m.node.Server()
+	if server != nil && server.DiscV5 != nil && m.config.BootClusterConfig.Enabled {
+		if err := server.DiscV5.SetFallbackNodes(makeBootNodesV5(m.config.BootClusterConfig.BootNodes)); err != nil {
+			log.Error("Discovery re-seeding failed", "error", err)
+		}
+	}
+}
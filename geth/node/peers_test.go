@@ -0,0 +1,73 @@
+package node
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+type fakePeerCounter struct {
+	count int32
+}
+
+func (c *fakePeerCounter) PeerCount() int {
+	return int(atomic.LoadInt32(&c.count))
+}
+
+func (c *fakePeerCounter) setPeerCount(count int) {
+	atomic.StoreInt32(&c.count, int32(count))
+}
+
+func TestMaintainPeersReportsPeerlessNode(t *testing.T) {
+	signals := make(chan signal.Envelope, 10)
+	m := NewNodeManager()
+	m.SetSignalHandler(func(envelope signal.Envelope) {
+		signals <- envelope
+	})
+
+	server := &fakePeerCounter{}
+	server.setPeerCount(1)
+	config := params.PeerMaintenanceConfig{
+		Enabled:         true,
+		TargetPeers:     1,
+		CheckInterval:   10,
+		PeerlessTimeout: 50,
+	}
+	nodeStopped := make(chan struct{})
+	maintenanceStopped := make(chan struct{})
+	go func() {
+		m.maintainPeers(server, config, nodeStopped)
+		close(maintenanceStopped)
+	}()
+
+	select {
+	case envelope := <-signals:
+		t.Fatalf("unexpected signal while node has peers: %v", envelope)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// peerless node is reported once
+	lostAt := time.Now().Unix()
+	server.setPeerCount(0)
+	envelope := <-signals
+	require.Equal(t, signal.EventPeersLost, envelope.Type)
+	require.InDelta(t, lostAt, envelope.Event.(signal.PeersLostEvent).Since, 1)
+	select {
+	case envelope := <-signals:
+		t.Fatalf("peerless node is expected to be reported once, got %v", envelope)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// node is reported again after it loses recovered peers
+	server.setPeerCount(1)
+	time.Sleep(50 * time.Millisecond)
+	server.setPeerCount(0)
+	require.Equal(t, signal.EventPeersLost, (<-signals).Type)
+
+	close(nodeStopped)
+	<-maintenanceStopped
+}
@@ -0,0 +1,101 @@
+package node
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// registry errors
+var (
+	ErrNodeManagerExists   = errors.New("node manager with this name already exists")
+	ErrNodeManagerNotFound = errors.New("node manager with this name is not found")
+	ErrNodeManagerName     = errors.New("node manager name must not be empty")
+)
+
+// Registry keeps named node managers, so that several independent nodes
+// (e.g. Mainnet wallet and Ropsten dev node) can run in one process.
+// Each node must be configured with its own data dir and ports.
+//
+// Each registered node has its own RPC client, and signals sent by its node manager and
+// by services running on it, such as the Whisper services and the transaction queue, jail,
+// account and message queue of an api.StatusBackend made with the node manager, are sent
+// with NodeManager.SendSignal and marked with its name. Logging is configured process-wide
+// by the last node started. The registry is a Go API for applications embedding status-go,
+// the library bindings manage a single node.
+type Registry struct {
+	mu       sync.RWMutex
+	managers map[string]*NodeManager
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		managers: make(map[string]*NodeManager),
+	}
+}
+
+// Create makes a new node manager registered with a given name.
+func (r *Registry) Create(name string) (*NodeManager, error) {
+	if name == "" {
+		return nil, ErrNodeManagerName
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.managers[name]; ok {
+		return nil, ErrNodeManagerExists
+	}
+
+	m := newNodeManager(name)
+	r.managers[name] = m
+
+	return m, nil
+}
+
+// Get returns a node manager registered with a given name.
+func (r *Registry) Get(name string) (*NodeManager, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.managers[name]
+	if !ok {
+		return nil, ErrNodeManagerNotFound
+	}
+
+	return m, nil
+}
+
+// Remove unregisters a node manager with a given name.
+// Its node must be stopped first, otherwise ErrNodeExists is returned.
+func (r *Registry) Remove(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.managers[name]
+	if !ok {
+		return ErrNodeManagerNotFound
+	}
+	if m.IsNodeRunning() {
+		return ErrNodeExists
+	}
+
+	delete(r.managers, name)
+
+	return nil
+}
+
+// Names returns sorted names of registered node managers.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.managers))
+	for name := range r.managers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
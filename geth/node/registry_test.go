@@ -0,0 +1,50 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry()
+
+	wallet, err := registry.Create("wallet")
+	require.NoError(t, err)
+	require.Equal(t, "wallet", wallet.Name())
+
+	dev, err := registry.Create("dev")
+	require.NoError(t, err)
+	require.False(t, wallet == dev)
+
+	_, err = registry.Create("wallet")
+	require.Equal(t, ErrNodeManagerExists, err)
+	_, err = registry.Create("")
+	require.Equal(t, ErrNodeManagerName, err)
+
+	require.Equal(t, []string{"dev", "wallet"}, registry.Names())
+
+	m, err := registry.Get("wallet")
+	require.NoError(t, err)
+	require.True(t, m == wallet)
+
+	require.NoError(t, registry.Remove("wallet"))
+	_, err = registry.Get("wallet")
+	require.Equal(t, ErrNodeManagerNotFound, err)
+	require.Equal(t, ErrNodeManagerNotFound, registry.Remove("wallet"))
+}
+
+func TestNodeManagerSignalHandler(t *testing.T) {
+	registry := NewRegistry()
+	m, err := registry.Create("wallet")
+	require.NoError(t, err)
+
+	var received []signal.Envelope
+	m.SetSignalHandler(func(envelope signal.Envelope) {
+		received = append(received, envelope)
+	})
+
+	m.SendSignal(signal.Envelope{Type: signal.EventNodeStarted})
+	require.Equal(t, []signal.Envelope{{Type: signal.EventNodeStarted, Node: "wallet"}}, received)
+}
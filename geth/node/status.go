@@ -0,0 +1,78 @@
+package node
+
+import (
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/les"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// NodeStatus returns running state, peer count, sync progress and latest block of the node.
+// Sync progress and latest block are reported by LES service in light sync mode,
+// or by eth service of a full node in fast and full sync modes.
+func (m *NodeManager) NodeStatus() common.NodeStatus {
+	m.RLock()
+	defer m.RUnlock()
+
+	var status common.NodeStatus
+	if m.isNodeAvailable() != nil || m.node.Server() == nil {
+		return status
+	}
+
+	status.Running = true
+	status.NetworkID = m.config.NetworkID
+	status.Peers = m.node.Server().PeerCount()
+	status.ListenPort = listenPort(m.node.Server())
+	status.Uptime = uint64(time.Since(m.startedAt) / time.Second)
+
+	var (
+		dl         *downloader.Downloader
+		head       *types.Header
+		lesService *les.LightEthereum
+		ethService *eth.Ethereum
+	)
+	if err := m.node.Service(&lesService); err == nil && lesService != nil {
+		dl = lesService.Downloader()
+		head = lesService.BlockChain().CurrentHeader()
+	} else if err := m.node.Service(&ethService); err == nil && ethService != nil {
+		dl = ethService.Downloader()
+		head = ethService.BlockChain().CurrentHeader()
+	}
+
+	if dl != nil {
+		status.SyncMode = m.config.SyncMode
+		progress := dl.Progress()
+		status.Syncing = dl.Synchronising()
+		status.StartingBlock = progress.StartingBlock
+		status.CurrentBlock = progress.CurrentBlock
+		status.HighestBlock = progress.HighestBlock
+	}
+	if head != nil {
+		status.LatestBlockNumber = head.Number.Uint64()
+		status.LatestBlockHash = head.Hash()
+	}
+
+	return status
+}
+
+// listenPort returns a port the p2p server listens on, which is chosen by the OS
+// if the node is configured to listen on port 0. It returns 0 if the server doesn't listen.
+func listenPort(server *p2p.Server) int {
+	_, port, err := net.SplitHostPort(server.ListenAddr)
+	if err != nil {
+		return 0
+	}
+
+	number, err := strconv.Atoi(port)
+	if err != nil {
+		return 0
+	}
+
+	return number
+}
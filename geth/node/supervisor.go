@@ -0,0 +1,82 @@
+package node
+
+import (
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// superviseCrash schedules a restart of a crashed node if it's enabled in the node's config.
+// Consecutive restarts are delayed exponentially, until restart attempts are exhausted.
+// Panics are not supervised, as HaltOnPanic exits the process.
+func (m *NodeManager) superviseCrash(config *params.NodeConfig) {
+	supervisorConfig := config.SupervisorConfig
+	if !supervisorConfig.Enabled {
+		return
+	}
+
+	m.Lock()
+	if m.restartAttempts >= supervisorConfig.MaxRestarts {
+		m.Unlock()
+		log.Error("Crashed node is not restarted, restart attempts exhausted", "attempts", supervisorConfig.MaxRestarts)
+		return
+	}
+	m.restartAttempts++
+	attempt := m.restartAttempts
+	m.Unlock()
+
+	delay := restartBackoff(supervisorConfig, attempt)
+	log.Warn("Restarting crashed node", "attempt", attempt, "delay", delay)
+	m.emit(common.NodeEvent{Type: common.NodeRestarting, Attempt: attempt})
+
+	m.Lock()
+	defer m.Unlock()
+
+	// restart could be cancelled while subscribers were notified
+	if m.restartAttempts != attempt {
+		return
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		m.Lock()
+		defer m.Unlock()
+
+		// timer could fire just before being cancelled
+		if m.restartTimer != timer {
+			return
+		}
+		m.restartTimer = nil
+
+		if _, err := m.startNode(config); err != nil {
+			log.Error("Failed to restart crashed node", "attempt", attempt, "error", err)
+		}
+	})
+	m.restartTimer = timer
+}
+
+// cancelRestart stops a pending restart of a crashed node, if any.
+func (m *NodeManager) cancelRestart() {
+	if m.restartTimer != nil {
+		m.restartTimer.Stop()
+		m.restartTimer = nil
+	}
+	m.restartAttempts = 0
+}
+
+// restartBackoff returns a delay before a given restart attempt.
+func restartBackoff(config params.SupervisorConfig, attempt int) time.Duration {
+	delay := time.Duration(config.InitialBackoff) * time.Millisecond
+	maxDelay := time.Duration(config.MaxBackoff) * time.Millisecond
+
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,21 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartBackoff(t *testing.T) {
+	config := params.SupervisorConfig{
+		InitialBackoff: 1000,
+		MaxBackoff:     5000,
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, delay := range expected {
+		require.Equal(t, delay, restartBackoff(config, i+1), "attempt %d", i+1)
+	}
+}
@@ -0,0 +1,77 @@
+package node
+
+import (
+	"github.com/ethereum/go-ethereum/p2p/discv5"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// Suspend quiesces the node while the application is in background: ongoing chain synchronisation
+// is cancelled and new ones are cancelled as soon as they start, no peers are dialed, peer maintenance
+// doesn't re-dial peers and the node is not advertised under registered discovery topics.
+// Connected peers are kept, so that Whisper messages are still delivered, and accounts are not touched.
+// Lookups of nodes run by the p2p server itself can't be stopped, without new dials they are rare though.
+// Like a network condition, suspension applies to nodes started afterwards as well, until Resume is called.
+func (m *NodeManager) Suspend() {
+	m.networkMu.Lock()
+	m.suspended = true
+	m.networkMu.Unlock()
+	log.Info("Node is suspended")
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.peerFilter != nil {
+		m.peerFilter.suspendDials(true)
+	}
+	if m.node == nil {
+		return
+	}
+
+	if syncer := syncCancelerOf(m.node); syncer != nil {
+		syncer.Cancel()
+	}
+	for topic, stop := range m.topics {
+		if stop != nil {
+			close(stop)
+			m.topics[topic] = nil
+		}
+	}
+}
+
+// Resume lifts restrictions of Suspend. Synchronisation starts with a next announced block.
+func (m *NodeManager) Resume() {
+	m.networkMu.Lock()
+	m.suspended = false
+	m.networkMu.Unlock()
+	log.Info("Node is resumed")
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.peerFilter != nil {
+		m.peerFilter.suspendDials(false)
+	}
+	if m.node == nil {
+		return
+	}
+
+	server := m.node.Server()
+	if server == nil || server.DiscV5 == nil {
+		return
+	}
+	for topic, stop := range m.topics {
+		if stop == nil {
+			stop = make(chan struct{})
+			m.topics[topic] = stop
+			go server.DiscV5.RegisterTopic(discv5.Topic(topic), stop)
+		}
+	}
+}
+
+// isSuspended reports whether the node is suspended with Suspend.
+func (m *NodeManager) isSuspended() bool {
+	m.networkMu.RLock()
+	defer m.networkMu.RUnlock()
+
+	return m.suspended
+}
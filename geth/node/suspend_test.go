@@ -0,0 +1,100 @@
+package node
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuspendStopsSync(t *testing.T) {
+	m := NewNodeManager()
+	var lastSync time.Time
+
+	// node can be suspended before it's started
+	m.Suspend()
+	require.False(t, m.syncAllowed(&lastSync))
+
+	mux := new(event.TypeMux)
+	sub := mux.Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
+	syncer := &fakeSyncCanceler{}
+	nodeStopped := make(chan struct{})
+	defer close(nodeStopped)
+	go m.watchSync(sub, syncer, nodeStopped)
+
+	require.NoError(t, mux.Post(downloader.StartEvent{}))
+	require.NoError(t, mux.Post(downloader.DoneEvent{}))
+	require.Equal(t, 1, syncer.cancellations())
+
+	m.Resume()
+	require.True(t, m.syncAllowed(&lastSync))
+	require.NoError(t, mux.Post(downloader.StartEvent{}))
+	require.NoError(t, mux.Post(downloader.DoneEvent{}))
+	require.Equal(t, 1, syncer.cancellations())
+}
+
+func TestSuspendedDialer(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()  // nolint: errcheck
+	defer remote.Close() // nolint: errcheck
+
+	filter, err := newPeerFilter([]string{"10.0.0.0/8"}, nil)
+	require.NoError(t, err)
+	dialer := filter.dialer(pipeDialer{local})
+
+	filter.suspendDials(true)
+	_, err = dialer.Dial(&discover.Node{IP: net.ParseIP("11.0.0.1")})
+	require.Equal(t, ErrDialsSuspended, err)
+
+	filter.suspendDials(false)
+	_, err = dialer.Dial(&discover.Node{IP: net.ParseIP("10.0.0.1")})
+	require.Equal(t, ErrPeerNotAllowed, err)
+	conn, err := dialer.Dial(&discover.Node{IP: net.ParseIP("11.0.0.1")})
+	require.NoError(t, err)
+	require.Equal(t, local, conn)
+}
+
+func TestMaintainPeersPausedWhileSuspended(t *testing.T) {
+	signals := make(chan signal.Envelope, 10)
+	m := NewNodeManager()
+	m.SetSignalHandler(func(envelope signal.Envelope) {
+		signals <- envelope
+	})
+	m.Suspend()
+
+	server := &fakePeerCounter{}
+	config := params.PeerMaintenanceConfig{
+		Enabled:         true,
+		TargetPeers:     1,
+		CheckInterval:   10,
+		PeerlessTimeout: 50,
+	}
+	nodeStopped := make(chan struct{})
+	maintenanceStopped := make(chan struct{})
+	go func() {
+		m.maintainPeers(server, config, nodeStopped)
+		close(maintenanceStopped)
+	}()
+
+	select {
+	case envelope := <-signals:
+		t.Fatalf("unexpected signal while node is suspended: %v", envelope)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// time spent suspended doesn't count towards the peerless timeout
+	resumedAt := time.Now().Unix()
+	m.Resume()
+	envelope := <-signals
+	require.Equal(t, signal.EventPeersLost, envelope.Type)
+	require.InDelta(t, resumedAt, envelope.Event.(signal.PeersLostEvent).Since, 1)
+
+	close(nodeStopped)
+	<-maintenanceStopped
+}
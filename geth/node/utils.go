@@ -33,11 +33,11 @@ func HaltOnInterruptSignal(nodeManager *NodeManager) {
 	osSignal.Notify(sigc, os.Interrupt)
 	defer osSignal.Stop(sigc)
 	<-sigc
-	if nodeManager.node == nil {
+	if !nodeManager.IsNodeRunning() {
 		return
 	}
 	log.Info("Got interrupt, shutting down...")
-	go nodeManager.node.Stop() // nolint: errcheck
+	go nodeManager.StopNode() // nolint: errcheck
 	for i := 3; i > 0; i-- {
 		<-sigc
 		if i > 1 {
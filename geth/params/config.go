@@ -9,8 +9,12 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/status-im/status-go/geth/log"
@@ -39,6 +43,10 @@ var (
 	ErrEmptyIdentityFile          = errors.New("identity file cannot be empty")
 	ErrEmptyAuthorizationKeyFile  = errors.New("authorization key file cannot be empty")
 	ErrAuthorizationKeyFileNotSet = errors.New("authorization key file is not set")
+	ErrUnknownGenesis             = errors.New("no genesis block is defined for a given network")
+	ErrInvalidPortRange           = errors.New("port range must be in from-to form, with ports between 1 and 65535")
+	ErrNoMailServerPassword       = errors.New("mail server password is not set")
+	ErrNoPushServerIdentity       = errors.New("push server identity file is not set")
 )
 
 // LightEthConfig holds LES-related configuration
@@ -52,6 +60,10 @@ type LightEthConfig struct {
 
 	// DatabaseCache is memory (in MBs) allocated to internal caching (min 16MB / database forced)
 	DatabaseCache int
+
+	// ProtocolVersions restricts LES protocol versions advertised by the node.
+	// All versions supported by the LES implementation are advertised if empty.
+	ProtocolVersions []uint
 }
 
 // FirebaseConfig holds FCM-related configuration
@@ -107,9 +119,24 @@ type WhisperConfig struct {
 	// MailServerNode is mode when node is capable of delivering expired messages on demand
 	MailServerNode bool
 
+	// MailServerEnode is an enode URL of a mail server the node stays connected to, so that messages sent
+	// while the node was offline can be requested from it with shh_requestMessages
+	MailServerEnode string
+
+	// MailServerEnodes are enode URLs of further mail servers sharing the password. The most responsive one
+	// is selected, and the node fails over to another one when the selected one becomes unavailable.
+	MailServerEnodes []string
+
+	// MailServerPassword is a password the symmetric key of requests to mail servers is derived from
+	MailServerPassword string
+
 	// NotificationServerNode is mode when node is capable of sending Push (and probably other kinds) Notifications
 	NotificationServerNode bool
 
+	// DeliverySignals specifies whether changes of states of envelopes sent by the node are signalled,
+	// e.g. for sent and delivered indicators of messages
+	DeliverySignals bool
+
 	// DataDir is the file system folder Whisper should use for any data storage needs.
 	DataDir string
 
@@ -122,6 +149,23 @@ type WhisperConfig struct {
 	// TTL time to live for messages, in seconds
 	TTL int
 
+	// PeerEnvelopeRate is how many envelopes per second a peer can send on average, zero doesn't limit peers
+	PeerEnvelopeRate float64 `validate:"min=0"`
+
+	// PeerByteRate is how many bytes of envelopes per second a peer can send on average, zero doesn't limit peers
+	PeerByteRate float64 `validate:"min=0"`
+
+	// PeerRatePenalty is how long all envelopes of a peer over its rates are dropped, in seconds
+	PeerRatePenalty int `validate:"min=0"`
+
+	// LightClient specifies whether the node only sends its own envelopes to peers, not relaying envelopes
+	// of others. Light mode is enabled on metered networks regardless, see SetNetworkCondition.
+	LightClient bool
+
+	// DeliveryReceipts specifies whether the node acknowledges signed one-to-one messages its identities receive,
+	// and signals messages sent by the node once their recipients acknowledge them
+	DeliveryReceipts bool
+
 	// FirebaseConfig extra configuration for Firebase Cloud Messaging
 	FirebaseConfig *FirebaseConfig `json:"FirebaseConfig,"`
 }
@@ -188,12 +232,18 @@ type BootClusterConfig struct {
 	// Enabled flag specifies whether feature is enabled
 	Enabled bool
 
-	// RootNumber CHT root number
+	// RootNumber CHT root number. If set, it's kept unless the boot cluster config
+	// file provides a more recent checkpoint for the network.
 	RootNumber int
 
 	// RootHash is hash of CHT root for a given root number
 	RootHash string
 
+	// ConfigFile is a path to JSON file with boot clusters and CHT checkpoints per network,
+	// in the format of static/config/cht.json. If empty, the bundled one is used.
+	// Allows to ship updated checkpoints without rebuilding the library.
+	ConfigFile string
+
 	// BootNodes list of bootstrap nodes for a given network (Ropsten, Rinkeby, Homestead),
 	// for a given mode (production vs development)
 	BootNodes []string
@@ -219,6 +269,196 @@ type UpstreamRPCConfig struct {
 
 //=====================================================================================
 
+// SupervisorConfig stores configuration of automatic restarts of a crashed node.
+type SupervisorConfig struct {
+	// Enabled flag specifies whether crashed node is restarted
+	Enabled bool
+
+	// MaxRestarts is a number of consecutive restart attempts, after which a crashed node is left stopped
+	MaxRestarts int
+
+	// InitialBackoff is a delay before the first restart attempt, in milliseconds.
+	// The delay is doubled after each unsuccessful attempt.
+	InitialBackoff int
+
+	// MaxBackoff is a maximum delay between restart attempts, in milliseconds
+	MaxBackoff int
+}
+
+//=====================================================================================
+
+// PeerMaintenanceConfig stores configuration of keeping the node connected to peers.
+type PeerMaintenanceConfig struct {
+	// Enabled flag specifies whether the peer count is monitored
+	Enabled bool
+
+	// TargetPeers is a number of peers the node tries to keep. While it has fewer,
+	// boot nodes are re-dialed and discovery is re-seeded.
+	TargetPeers int `validate:"min=0"`
+
+	// CheckInterval is how often the peer count is checked, in milliseconds
+	CheckInterval int `validate:"min=0"`
+
+	// PeerlessTimeout is how long the node may have no peers before peers.lost signal is sent, in milliseconds
+	PeerlessTimeout int `validate:"min=0"`
+
+	// KnownPeers is a number of the best peers, which the node has connected to before and remembers
+	// across restarts, to be dialed on start and while there are fewer peers than the target.
+	// Zero disables the known peers store.
+	KnownPeers int `validate:"min=0"`
+}
+
+//=====================================================================================
+
+// KeyStoreConfig stores parameters of scrypt KDF, which account keys are encrypted with.
+// Higher cost makes a stolen keystore harder to brute-force, at the expense of slower unlocking.
+// Keys are decrypted with parameters they were encrypted with, so changes apply to new keys
+// and keys re-encrypted by a password change only.
+type KeyStoreConfig struct {
+	// KDF is a preset of scrypt parameters: "light" takes a fraction of a second on low-end devices,
+	// "standard" is go-ethereum's default, which may take several seconds there.
+	KDF string `validate:"eq=light|eq=standard"`
+
+	// ScryptN is scrypt CPU/memory cost, a power of 2. It overrides the preset if set.
+	ScryptN int `validate:"min=0,pow2"`
+
+	// ScryptP is scrypt parallelization. It overrides the preset if set.
+	// Block size (r) is always 8, as the keystore format doesn't support other values.
+	ScryptP int `validate:"min=0"`
+}
+
+// ScryptParams returns scrypt N and P, which account keys are encrypted with.
+func (c KeyStoreConfig) ScryptParams() (n, p int) {
+	n, p = keystore.LightScryptN, keystore.LightScryptP
+	if c.KDF == StandardKDF {
+		n, p = keystore.StandardScryptN, keystore.StandardScryptP
+	}
+
+	if c.ScryptN > 0 {
+		n = c.ScryptN
+	}
+	if c.ScryptP > 0 {
+		p = c.ScryptP
+	}
+
+	return n, p
+}
+
+//=====================================================================================
+
+// TxQueueConfig stores limits of the queue of transactions waiting for approval.
+type TxQueueConfig struct {
+	// Capacity is how many transactions can be queued, the oldest one is evicted to queue another one
+	Capacity int `validate:"min=1"`
+
+	// TTL is how long a queued transaction waits for approval before it expires, in seconds
+	TTL int `validate:"min=1"`
+
+	// AllowUnprotectedTxs lets transactions be sent without EIP155 replay protection, e.g. signed by an
+	// external signer which doesn't support it, or by LES before EIP155 block is synced
+	AllowUnprotectedTxs bool
+
+	// RateLimit is how many transactions per second a DApp can request on average,
+	// requests over the limit are rejected
+	RateLimit float64 `validate:"min=0"`
+
+	// RateBurst is how many transactions a DApp can request at once, before the rate limit applies
+	RateBurst int `validate:"min=0"`
+}
+
+//=====================================================================================
+
+// TxWatcherConfig stores options of watching sent transactions until they are confirmed.
+type TxWatcherConfig struct {
+	// Confirmations is how many blocks, including the one it's mined in, confirm a transaction
+	Confirmations int `validate:"min=1"`
+
+	// PollInterval is how often the node is checked for new blocks and receipts, in milliseconds
+	PollInterval int `validate:"min=1"`
+}
+
+//=====================================================================================
+
+// JailFetchConfig stores limits of HTTP requests DApps make with fetch from jail cells.
+type JailFetchConfig struct {
+	// AllowedHosts are hosts every cell can fetch from, in addition to hosts allowed for the cell,
+	// e.g. "api.example.com", or "*.example.com" for its subdomains. Other hosts can't be fetched from.
+	AllowedHosts []string
+
+	// AllowInsecure lets cells fetch over plain HTTP, only HTTPS requests are allowed otherwise
+	AllowInsecure bool
+
+	// Timeout is how long a request can take, including reading the response, in seconds
+	Timeout int `validate:"min=1"`
+
+	// MaxResponseSize is how large a response body can be, in bytes, larger responses fail
+	MaxResponseSize int64 `validate:"min=1"`
+}
+
+//=====================================================================================
+
+// JailLimitsConfig stores limits of a single execution of JS code in a jail cell, e.g. of a script or
+// a callback of a timer. A cell exceeding them is halted. Zero values don't limit executions.
+type JailLimitsConfig struct {
+	// MaxExecutionTime is how long an execution can take, excluding waiting for RPC calls, in milliseconds
+	MaxExecutionTime int `validate:"min=0"`
+
+	// MaxOps is how many statements and expressions an execution can evaluate
+	MaxOps uint64
+
+	// MaxMemory is approximately how many bytes an execution can allocate
+	MaxMemory uint64
+}
+
+//=====================================================================================
+
+// MailServerConfig stores configuration of a mail server, which archives Whisper envelopes and delivers
+// them on demand to peers requesting messages sent while they were offline. It requires Whisper to be enabled.
+type MailServerConfig struct {
+	// Enabled flag specifies whether the node runs a mail server
+	Enabled bool
+
+	// DataDir is a directory envelopes are archived in
+	DataDir string
+
+	// Password is a password the symmetric key of requests is derived from, only peers knowing it are served
+	Password string
+
+	// Retention is how long envelopes are archived, in hours, zero keeps them forever
+	Retention int `validate:"min=0"`
+
+	// MinimumPoW is a PoW requests must have, zero accepts any
+	MinimumPoW float64 `validate:"min=0"`
+}
+
+//=====================================================================================
+
+// PushServerConfig stores configuration of a push server, which keeps FCM tokens of devices registered over
+// Whisper and sends push notifications to them on requests of their contacts. It requires Whisper to be enabled,
+// notifications are sent with the FCM authorization key of WhisperConfig.FirebaseConfig.
+type PushServerConfig struct {
+	// Enabled flag specifies whether the node runs a push server
+	Enabled bool
+
+	// DataDir is a directory registrations of devices are stored in
+	DataDir string
+
+	// IdentityFile is a path to the private key of the server's Whisper identity, clients send requests
+	// to its public key
+	IdentityFile string
+}
+
+// ReadIdentityFile reads and loads the private key of the push server's identity
+func (c *PushServerConfig) ReadIdentityFile() (*ecdsa.PrivateKey, error) {
+	if len(c.IdentityFile) == 0 {
+		return nil, ErrNoIdentityFileValueSet
+	}
+
+	return crypto.LoadECDSA(c.IdentityFile)
+}
+
+//=====================================================================================
+
 // NodeConfig stores configuration options for a node
 type NodeConfig struct {
 	// DevMode is true when given configuration is to be used during development.
@@ -247,6 +487,30 @@ type NodeConfig struct {
 	// Version exposes program's version. It is used in the devp2p node identifier.
 	Version string
 
+	// ListenAddr is an IP address and port of this node's p2p listener (e.g. 127.0.0.1:30303).
+	// Port 0 makes the node listen on a random available port.
+	ListenAddr string
+
+	// ListenPortRange is a range of ports (e.g. 30303-30310) the p2p listener is bound to the first available
+	// port of, so that several nodes on one host don't clash. If set, it overrides the port of ListenAddr.
+	ListenPortRange string `validate:"omitempty,portrange"`
+
+	// NAT is a port mapping mechanism which makes the node reachable from behind a router:
+	// "any", "upnp", "pmp", "pmp:<gateway IP>", "extip:<external IP>" or "none".
+	NAT string `validate:"nat"`
+
+	// DiscoveryV5 enables topic-based discovery, which LES uses to find servers
+	// and which allows to find peers registered under a topic with NodeManager.SearchTopic.
+	DiscoveryV5 bool
+
+	// PeerBlacklist is a list of peers which are never connected: enode URLs,
+	// IP addresses and IP ranges in CIDR notation (e.g. 10.0.0.0/8).
+	PeerBlacklist []string `validate:"dive,peer"`
+
+	// PeerWhitelist, if not empty, restricts connections to listed peers, in the same format as PeerBlacklist.
+	// Blacklisted peers are not connected even if they are whitelisted.
+	PeerWhitelist []string `validate:"dive,peer"`
+
 	// APIModules is a comma-separated list of API modules exposed via *any* (HTTP/WS/IPC) RPC interface.
 	APIModules string
 
@@ -296,9 +560,49 @@ type NodeConfig struct {
 	// LogToStderr defines whether logged info should also be output to os.Stderr
 	LogToStderr bool
 
+	// JailDebug enables evaluation of arbitrary JS expressions in jail cells, to diagnose misbehaving DApps.
+	// It should be turned off in production.
+	JailDebug bool
+
+	// ShutdownDrainTimeout is how long stopping node waits for queued transactions
+	// to be completed and Whisper envelopes sent by the node to be relayed to peers, in seconds
+	ShutdownDrainTimeout int
+
+	// SyncMode selects the chain service: "light" runs LES client, "fast" and "full" run a full eth node.
+	// The service is enabled with LightEthConfig, which genesis and database cache are used in all modes.
+	// Queued transactions can only be completed locally in light mode.
+	SyncMode string `validate:"eq=light|eq=fast|eq=full"`
+
 	// UpstreamConfig extra config for providing upstream infura server.
 	UpstreamConfig UpstreamRPCConfig `json:"UpstreamConfig"`
 
+	// SupervisorConfig extra configuration for restarting crashed node
+	SupervisorConfig SupervisorConfig `json:"SupervisorConfig"`
+
+	// PeerMaintenanceConfig extra configuration for keeping the node connected to peers
+	PeerMaintenanceConfig PeerMaintenanceConfig `json:"PeerMaintenanceConfig"`
+
+	// KeyStoreConfig extra configuration for encryption of account keys
+	KeyStoreConfig KeyStoreConfig `json:"KeyStoreConfig"`
+
+	// TxQueueConfig extra configuration for the transaction queue
+	TxQueueConfig TxQueueConfig `json:"TxQueueConfig"`
+
+	// TxWatcherConfig extra configuration for watching sent transactions
+	TxWatcherConfig TxWatcherConfig `json:"TxWatcherConfig"`
+
+	// JailFetchConfig extra configuration for HTTP requests made from jail cells
+	JailFetchConfig JailFetchConfig `json:"JailFetchConfig"`
+
+	// JailLimitsConfig extra configuration for limits of JS code executed in jail cells
+	JailLimitsConfig JailLimitsConfig `json:"JailLimitsConfig"`
+
+	// MailServerConfig extra configuration for archiving Whisper envelopes and serving requests of them
+	MailServerConfig MailServerConfig `json:"MailServerConfig"`
+
+	// PushServerConfig extra configuration for sending push notifications to devices registered over Whisper
+	PushServerConfig PushServerConfig `json:"PushServerConfig"`
+
 	// BootClusterConfig extra configuration for supporting cluster
 	BootClusterConfig *BootClusterConfig `json:"BootClusterConfig," validate:"structonly"`
 
@@ -320,6 +624,9 @@ func NewNodeConfig(dataDir string, networkID uint64, devMode bool) (*NodeConfig,
 		DataDir:         dataDir,
 		Name:            ClientIdentifier,
 		Version:         Version,
+		ListenAddr:      ListenAddr,
+		NAT:             NAT,
+		DiscoveryV5:     DiscoveryV5,
 		RPCEnabled:      RPCEnabledDefault,
 		HTTPHost:        HTTPHost,
 		HTTPPort:        HTTPPort,
@@ -332,6 +639,45 @@ func NewNodeConfig(dataDir string, networkID uint64, devMode bool) (*NodeConfig,
 		LogFile:         LogFile,
 		LogLevel:        LogLevel,
 		LogToStderr:     LogToStderr,
+		SupervisorConfig: SupervisorConfig{
+			MaxRestarts:    SupervisorMaxRestarts,
+			InitialBackoff: SupervisorInitialBackoff,
+			MaxBackoff:     SupervisorMaxBackoff,
+		},
+		PeerMaintenanceConfig: PeerMaintenanceConfig{
+			Enabled:         true,
+			TargetPeers:     TargetPeers,
+			CheckInterval:   PeerCheckInterval,
+			PeerlessTimeout: PeerlessTimeout,
+			KnownPeers:      KnownPeers,
+		},
+		KeyStoreConfig: KeyStoreConfig{
+			KDF: KeyStoreKDF,
+		},
+		TxQueueConfig: TxQueueConfig{
+			Capacity:  TxQueueCapacity,
+			TTL:       TxQueueTTL,
+			RateLimit: TxQueueRateLimit,
+			RateBurst: TxQueueRateBurst,
+		},
+		TxWatcherConfig: TxWatcherConfig{
+			Confirmations: TxWatcherConfirmations,
+			PollInterval:  TxWatcherPollInterval,
+		},
+		JailFetchConfig: JailFetchConfig{
+			Timeout:         JailFetchTimeout,
+			MaxResponseSize: JailFetchMaxResponseSize,
+		},
+		JailLimitsConfig: JailLimitsConfig{
+			MaxExecutionTime: JailMaxExecutionTime,
+			MaxMemory:        JailMaxMemory,
+		},
+		MailServerConfig: MailServerConfig{
+			Retention:  MailServerRetention,
+			MinimumPoW: WhisperMinimumPoW,
+		},
+		ShutdownDrainTimeout: ShutdownDrainTimeout,
+		SyncMode:             SyncMode,
 		BootClusterConfig: &BootClusterConfig{
 			Enabled:   true,
 			BootNodes: []string{},
@@ -341,10 +687,11 @@ func NewNodeConfig(dataDir string, networkID uint64, devMode bool) (*NodeConfig,
 			DatabaseCache: DatabaseCache,
 		},
 		WhisperConfig: &WhisperConfig{
-			Enabled:    true,
-			Port:       WhisperPort,
-			MinimumPoW: WhisperMinimumPoW,
-			TTL:        WhisperTTL,
+			Enabled:         true,
+			Port:            WhisperPort,
+			MinimumPoW:      WhisperMinimumPoW,
+			TTL:             WhisperTTL,
+			PeerRatePenalty: WhisperPeerRatePenalty,
 			FirebaseConfig: &FirebaseConfig{
 				NotificationTriggerURL: FirebaseNotificationTriggerURL,
 			},
@@ -417,6 +764,14 @@ func (c *NodeConfig) Validate() error {
 		return err
 	}
 
+	if c.MailServerConfig.Enabled && c.MailServerConfig.Password == "" {
+		return ErrNoMailServerPassword
+	}
+
+	if c.PushServerConfig.Enabled && c.PushServerConfig.IdentityFile == "" {
+		return ErrNoPushServerIdentity
+	}
+
 	if c.BootClusterConfig.Enabled {
 		if err := validate.Struct(c.BootClusterConfig); err != nil {
 			return err
@@ -488,22 +843,12 @@ func (c *NodeConfig) updateConfig() error {
 
 // updateGenesisConfig does necessary adjustments to config object (depending on network node will be running on)
 func (c *NodeConfig) updateGenesisConfig() error {
-	var genesis *core.Genesis
+	genesis, err := DefaultGenesisBlock(c.NetworkID)
+	if err != nil {
+		return err
+	}
 
-	switch c.NetworkID {
-	case MainNetworkID:
-		genesis = core.DefaultGenesisBlock()
-	case RopstenNetworkID:
-		genesis = core.DefaultTestnetGenesisBlock()
-	case RinkebyNetworkID:
-		genesis = core.DefaultRinkebyGenesisBlock()
-	case StatusChainNetworkID:
-		var err error
-		genesis, err = c.DefaultStatusChainGenesisBlock()
-		if err != nil {
-			return err
-		}
-	default:
+	if genesis == nil {
 		return nil
 	}
 
@@ -517,8 +862,46 @@ func (c *NodeConfig) updateGenesisConfig() error {
 	return nil
 }
 
+// DefaultGenesisBlock returns the genesis spec for a given network id.
+// Nil genesis is returned for unknown networks.
+func DefaultGenesisBlock(networkID uint64) (*core.Genesis, error) {
+	switch networkID {
+	case MainNetworkID:
+		return core.DefaultGenesisBlock(), nil
+	case RopstenNetworkID:
+		return core.DefaultTestnetGenesisBlock(), nil
+	case RinkebyNetworkID:
+		return core.DefaultRinkebyGenesisBlock(), nil
+	case StatusChainNetworkID:
+		return defaultStatusChainGenesisBlock()
+	}
+
+	return nil, nil
+}
+
+// GenesisHash returns hash of the genesis block, derived from the genesis spec
+// for a given network id. ErrUnknownGenesis is returned for unknown networks.
+func GenesisHash(networkID uint64) (gethcommon.Hash, error) {
+	genesis, err := DefaultGenesisBlock(networkID)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	if genesis == nil {
+		return gethcommon.Hash{}, ErrUnknownGenesis
+	}
+
+	block, _ := genesis.ToBlock()
+
+	return block.Hash(), nil
+}
+
 // DefaultStatusChainGenesisBlock returns the StatusChain network genesis block.
 func (c *NodeConfig) DefaultStatusChainGenesisBlock() (*core.Genesis, error) {
+	return defaultStatusChainGenesisBlock()
+}
+
+func defaultStatusChainGenesisBlock() (*core.Genesis, error) {
 	genesisJSON, err := static.Asset("config/status-chain-genesis.json")
 	if err != nil {
 		return nil, fmt.Errorf("status-chain-genesis.json could not be loaded: %s", err)
@@ -577,7 +960,15 @@ func (c *NodeConfig) updateBootClusterConfig() error {
 		Dev         subClusterConfig `json:"dev"`
 	}
 
-	chtFile, err := static.Asset("config/cht.json")
+	var (
+		chtFile []byte
+		err     error
+	)
+	if c.BootClusterConfig.ConfigFile != "" {
+		chtFile, err = ioutil.ReadFile(c.BootClusterConfig.ConfigFile)
+	} else {
+		chtFile, err = static.Asset("config/cht.json")
+	}
 	if err != nil {
 		return fmt.Errorf("cht.json could not be loaded: %s", err)
 	}
@@ -590,14 +981,17 @@ func (c *NodeConfig) updateBootClusterConfig() error {
 
 	for _, cluster := range clusters {
 		if cluster.NetworkID == int(c.NetworkID) {
-			c.BootClusterConfig.RootNumber = cluster.Prod.Number
-			c.BootClusterConfig.RootHash = cluster.Prod.Hash
-			c.BootClusterConfig.BootNodes = cluster.Prod.BootNodes
+			subCluster := cluster.Prod
 			if c.DevMode {
-				c.BootClusterConfig.RootNumber = cluster.Dev.Number
-				c.BootClusterConfig.RootHash = cluster.Dev.Hash
-				c.BootClusterConfig.BootNodes = cluster.Dev.BootNodes
+				subCluster = cluster.Dev
+			}
+
+			// checkpoint provided by the config is trusted as long as it's more recent
+			if subCluster.Number >= c.BootClusterConfig.RootNumber {
+				c.BootClusterConfig.RootNumber = subCluster.Number
+				c.BootClusterConfig.RootHash = subCluster.Hash
 			}
+			c.BootClusterConfig.BootNodes = subCluster.BootNodes
 			break
 		}
 	}
@@ -622,6 +1016,14 @@ func (c *NodeConfig) updateRelativeDirsConfig() error {
 		c.WhisperConfig.DataDir = makeSubDirPath(c.DataDir, WhisperDataDir)
 	}
 
+	if len(c.MailServerConfig.DataDir) == 0 {
+		c.MailServerConfig.DataDir = makeSubDirPath(c.DataDir, MailServerDataDir)
+	}
+
+	if len(c.PushServerConfig.DataDir) == 0 {
+		c.PushServerConfig.DataDir = makeSubDirPath(c.DataDir, PushServerDataDir)
+	}
+
 	return nil
 }
 
@@ -630,3 +1032,55 @@ func (c *NodeConfig) String() string {
 	data, _ := json.MarshalIndent(c, "", "    ")
 	return string(data)
 }
+
+// Diff returns names of fields which differ between two configs, sorted.
+// Fields of nested configs are named with a dot, e.g. "WhisperConfig.TTL".
+func (c *NodeConfig) Diff(other *NodeConfig) ([]string, error) {
+	var left, right map[string]interface{}
+	if err := reencodeJSON(c, &left); err != nil {
+		return nil, err
+	}
+	if err := reencodeJSON(other, &right); err != nil {
+		return nil, err
+	}
+
+	var fields []string
+	diffJSONObjects("", left, right, &fields)
+	sort.Strings(fields)
+
+	return fields, nil
+}
+
+// reencodeJSON converts a value to its generic JSON representation.
+func reencodeJSON(value interface{}, result *map[string]interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, result)
+}
+
+// diffJSONObjects appends names of differing keys of two JSON objects to fields.
+func diffJSONObjects(prefix string, left, right map[string]interface{}, fields *[]string) {
+	keys := make(map[string]struct{}, len(left)+len(right))
+	for key := range left {
+		keys[key] = struct{}{}
+	}
+	for key := range right {
+		keys[key] = struct{}{}
+	}
+
+	for key := range keys {
+		leftObject, leftIsObject := left[key].(map[string]interface{})
+		rightObject, rightIsObject := right[key].(map[string]interface{})
+		if leftIsObject && rightIsObject {
+			diffJSONObjects(prefix+key+".", leftObject, rightObject, fields)
+			continue
+		}
+
+		if !reflect.DeepEqual(left[key], right[key]) {
+			*fields = append(*fields, prefix+key)
+		}
+	}
+}
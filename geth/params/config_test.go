@@ -10,6 +10,7 @@ import (
 
 	"gopkg.in/go-playground/validator.v9"
 
+	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/core"
 	gethparams "github.com/ethereum/go-ethereum/params"
 	"github.com/status-im/status-go/geth/params"
@@ -266,6 +267,89 @@ var loadConfigTestCases = []struct {
 			require.Empty(t, nodeConfig.BootClusterConfig.RootNumber)
 		},
 	},
+	{
+		`default sync mode is light`,
+		`{
+			"NetworkId": 3,
+			"DataDir": "$TMPDIR"
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.NoError(t, err)
+			require.Equal(t, params.LightSyncMode, nodeConfig.SyncMode)
+		},
+	},
+	{
+		`full sync mode`,
+		`{
+			"NetworkId": 3,
+			"DataDir": "$TMPDIR",
+			"SyncMode": "full"
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.NoError(t, err)
+			require.Equal(t, params.FullSyncMode, nodeConfig.SyncMode)
+		},
+	},
+	{
+		`unsupported sync mode`,
+		`{
+			"NetworkId": 3,
+			"DataDir": "$TMPDIR",
+			"SyncMode": "archive"
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.Error(t, err)
+		},
+	},
+	{
+		`trusted CHT more recent than bundled one is kept`,
+		`{
+			"NetworkId": 3,
+			"DataDir": "$TMPDIR",
+			"BootClusterConfig": {
+				"Enabled": true,
+				"RootNumber": 1000,
+				"RootHash": "d9c1d4d9fa4ab1fd8b4b0da7b5e1e6d7f5e0c6b8f1fda1e7e0a2c9e2b3f4a5b6"
+			}
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.NoError(t, err)
+			require.Equal(t, 1000, nodeConfig.BootClusterConfig.RootNumber)
+			require.Equal(t, "d9c1d4d9fa4ab1fd8b4b0da7b5e1e6d7f5e0c6b8f1fda1e7e0a2c9e2b3f4a5b6", nodeConfig.BootClusterConfig.RootHash)
+			require.NotEmpty(t, nodeConfig.BootClusterConfig.BootNodes)
+		},
+	},
+	{
+		`trusted CHT older than bundled one is replaced`,
+		`{
+			"NetworkId": 3,
+			"DataDir": "$TMPDIR",
+			"BootClusterConfig": {
+				"Enabled": true,
+				"RootNumber": 100,
+				"RootHash": "d9c1d4d9fa4ab1fd8b4b0da7b5e1e6d7f5e0c6b8f1fda1e7e0a2c9e2b3f4a5b6"
+			}
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.NoError(t, err)
+			require.Equal(t, 478, nodeConfig.BootClusterConfig.RootNumber)
+			require.Equal(t, "77eedcf6f940940b3615da49109c1ba57b95c3fff8bcf16f20ac579c3ae24e58", nodeConfig.BootClusterConfig.RootHash)
+		},
+	},
+	{
+		`missing boot cluster config file`,
+		`{
+			"NetworkId": 3,
+			"DataDir": "$TMPDIR",
+			"BootClusterConfig": {
+				"Enabled": true,
+				"ConfigFile": "$TMPDIR/missing-cht.json"
+			}
+		}`,
+		func(t *testing.T, dataDir string, nodeConfig *params.NodeConfig, err error) {
+			require.Error(t, err)
+		},
+	},
 	{
 		`select boot cluster (Ropsten Prod)`,
 		`{
@@ -428,6 +512,41 @@ func TestLoadNodeConfig(t *testing.T) {
 	}
 }
 
+func TestBootClusterConfigFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir(os.TempDir(), "geth-config-tests")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir) // nolint: errcheck
+
+	configFile := filepath.Join(tmpDir, "cht.json")
+	err = ioutil.WriteFile(configFile, []byte(`[
+		{
+			"networkID": 3,
+			"prod": {
+				"number": 900,
+				"hash": "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0",
+				"bootnodes": ["enode://7ab298cedc4185a894d21d8a4615262ec6bdce66c9b6783878258e0d5b31013d30c9038932432f70e5b2b6a5cd323bf820554fcb22fbc7b45367889522e9c449@51.15.63.93:30303"]
+			}
+		}
+	]`), 0644)
+	require.NoError(t, err)
+
+	nodeConfig, err := params.LoadNodeConfig(`{
+		"NetworkId": 3,
+		"DataDir": "` + tmpDir + `",
+		"DevMode": false,
+		"BootClusterConfig": {
+			"Enabled": true,
+			"ConfigFile": "` + configFile + `"
+		}
+	}`)
+	require.NoError(t, err)
+	require.Equal(t, 900, nodeConfig.BootClusterConfig.RootNumber)
+	require.Equal(t, "0f1e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c4b5a69788796a5b4c3d2e1f0", nodeConfig.BootClusterConfig.RootHash)
+	require.Equal(t, []string{
+		"enode://7ab298cedc4185a894d21d8a4615262ec6bdce66c9b6783878258e0d5b31013d30c9038932432f70e5b2b6a5cd323bf820554fcb22fbc7b45367889522e9c449@51.15.63.93:30303",
+	}, nodeConfig.BootClusterConfig.BootNodes)
+}
+
 func TestConfigWriteRead(t *testing.T) {
 	configReadWrite := func(networkId uint64, refFile string) {
 		tmpDir, err := ioutil.TempDir(os.TempDir(), "geth-config-tests")
@@ -443,7 +562,7 @@ func TestConfigWriteRead(t *testing.T) {
 		loadedConfigData, err := ioutil.ReadFile(filepath.Join(nodeConfig.DataDir, "config.json"))
 		require.Nil(t, err, "cannot read configuration from disk")
 
-		refConfigData := LoadFromFile(refFile)
+		refConfigData := MustLoadFromFile(refFile)
 
 		// Ease updating new config data.
 		//ioutil.WriteFile(fmt.Sprintf("/tmp/chainId.%d.json", networkId), []byte(loadedConfigData), 0777)
@@ -509,6 +628,209 @@ func TestNodeConfigValidate(t *testing.T) {
 				"Name": "excludes",
 			},
 		},
+		{
+			Name: "Validate NAT mechanism with external IP",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"NAT": "extip:1.2.3.4"
+			}`,
+			Error:       "",
+			FieldErrors: nil,
+		},
+		{
+			Name: "Validate NAT mechanism is known",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"NAT": "stun"
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"NAT": "nat",
+			},
+		},
+		{
+			Name: "Validate NAT external IP is valid",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"NAT": "extip:1.2.3"
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"NAT": "nat",
+			},
+		},
+		{
+			Name: "Validate peer filter entries",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"PeerBlacklist": ["10.0.0.0/8", "192.168.1.1", "enode://3fa7d4b33e1a6eac0a2c2a35a6a47f5a7ae02c7e50ef5b2c21b1ed1e3b90de4a1aac5b89a55ac38ca26f1df07ae80ff9b5fbd1c4ae0b3f8ae25a2ae1c5e0ad2a@127.0.0.1:30303"],
+				"PeerWhitelist": ["fd00::/8"]
+			}`,
+			Error:       "",
+			FieldErrors: nil,
+		},
+		{
+			Name: "Validate peer filter entries are valid",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"PeerBlacklist": ["10.0.0.0/8", "10.0.0.0/33"],
+				"PeerWhitelist": ["enode://abc@127.0.0.1:30303"]
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"PeerBlacklist[1]": "peer",
+				"PeerWhitelist[0]": "peer",
+			},
+		},
+		{
+			Name: "Validate listen port range",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"ListenPortRange": "30303-30310"
+			}`,
+			Error:       "",
+			FieldErrors: nil,
+		},
+		{
+			Name: "Validate listen port range is valid",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"ListenPortRange": "30310-30303"
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"ListenPortRange": "portrange",
+			},
+		},
+		{
+			Name: "Validate keystore scrypt parameters",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"KeyStoreConfig": {"KDF": "standard", "ScryptN": 16384, "ScryptP": 2}
+			}`,
+			Error:       "",
+			FieldErrors: nil,
+		},
+		{
+			Name: "Validate keystore scrypt parameters are valid",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"KeyStoreConfig": {"KDF": "fast", "ScryptN": 1000, "ScryptP": -1}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"KDF":     "eq=light|eq=standard",
+				"ScryptN": "pow2",
+				"ScryptP": "min",
+			},
+		},
+		{
+			Name: "Validate transaction queue limits",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"TxQueueConfig": {"Capacity": 0, "TTL": -1, "RateLimit": -1, "RateBurst": -1}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"Capacity":  "min",
+				"TTL":       "min",
+				"RateLimit": "min",
+				"RateBurst": "min",
+			},
+		},
+		{
+			Name: "Validate transaction watcher options",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"TxWatcherConfig": {"Confirmations": 0, "PollInterval": 0}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"Confirmations": "min",
+				"PollInterval":  "min",
+			},
+		},
+		{
+			Name: "Validate jail fetch limits",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"JailFetchConfig": {"AllowedHosts": ["api.example.com"], "Timeout": 0, "MaxResponseSize": -1}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"Timeout":         "min",
+				"MaxResponseSize": "min",
+			},
+		},
+		{
+			Name: "Validate jail execution limits",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"JailLimitsConfig": {"MaxExecutionTime": -1}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"MaxExecutionTime": "min",
+			},
+		},
+		{
+			Name: "Validate Whisper peer rate limits",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"WhisperConfig": {"Enabled": true, "PeerEnvelopeRate": -1, "PeerByteRate": -1, "PeerRatePenalty": -1}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"PeerEnvelopeRate": "min",
+				"PeerByteRate":     "min",
+				"PeerRatePenalty":  "min",
+			},
+		},
+		{
+			Name: "Validate mail server retention",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"MailServerConfig": {"Retention": -1, "MinimumPoW": -0.5}
+			}`,
+			Error: "",
+			FieldErrors: map[string]string{
+				"Retention":  "min",
+				"MinimumPoW": "min",
+			},
+		},
+		{
+			Name: "Validate mail server password",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"MailServerConfig": {"Enabled": true}
+			}`,
+			Error: params.ErrNoMailServerPassword.Error(),
+		},
+		{
+			Name: "Validate push server identity",
+			Config: `{
+				"NetworkId": 1,
+				"DataDir": "/some/dir",
+				"PushServerConfig": {"Enabled": true}
+			}`,
+			Error: params.ErrNoPushServerIdentity.Error(),
+		},
 	}
 
 	for _, tc := range testCases {
@@ -530,3 +852,89 @@ func TestNodeConfigValidate(t *testing.T) {
 		}
 	}
 }
+
+// TestGenesisHash checks that genesis hashes are derived from genesis specs.
+func TestGenesisHash(t *testing.T) {
+	testCases := []struct {
+		networkID uint64
+		hash      string
+	}{
+		{params.MainNetworkID, gethparams.MainnetGenesisHash.Hex()},
+		{params.RopstenNetworkID, gethparams.TestnetGenesisHash.Hex()},
+		{params.RinkebyNetworkID, "0x6341fd3daf94b748c72ced5a5b26028f2474f5f00d824504e4fa37a75767e177"},
+		{params.StatusChainNetworkID, "0xe9d8920a99dc66a9557a87d51f9d14a34ec50aae04298e0f142187427d3c832e"},
+	}
+
+	for _, tc := range testCases {
+		hash, err := params.GenesisHash(tc.networkID)
+		require.NoError(t, err)
+		require.Equal(t, tc.hash, hash.Hex())
+	}
+
+	_, err := params.GenesisHash(1234)
+	require.Equal(t, params.ErrUnknownGenesis, err)
+}
+
+// TestNodeConfigDiff checks that changed fields, including nested ones, are reported.
+func TestNodeConfigDiff(t *testing.T) {
+	config, err := params.NewNodeConfig("/tmp/data", params.RopstenNetworkID, true)
+	require.NoError(t, err)
+
+	sameConfig, err := params.NewNodeConfig("/tmp/data", params.RopstenNetworkID, true)
+	require.NoError(t, err)
+
+	diff, err := config.Diff(sameConfig)
+	require.NoError(t, err)
+	require.Empty(t, diff)
+
+	newConfig, err := params.NewNodeConfig("/tmp/data", params.RopstenNetworkID, true)
+	require.NoError(t, err)
+	newConfig.LogLevel = "DEBUG"
+	newConfig.WhisperConfig.TTL = 60
+	newConfig.BootClusterConfig.BootNodes = []string{"enode://foobar@41.41.41.41:30300"}
+
+	diff, err = config.Diff(newConfig)
+	require.NoError(t, err)
+	require.Equal(t, []string{"BootClusterConfig.BootNodes", "LogLevel", "WhisperConfig.TTL"}, diff)
+}
+
+func TestKeyStoreConfigScryptParams(t *testing.T) {
+	nodeConfig, err := params.NewNodeConfig("/tmp/data", params.StatusChainNetworkID, true)
+	require.NoError(t, err)
+	n, p := nodeConfig.KeyStoreConfig.ScryptParams()
+	require.Equal(t, keystore.LightScryptN, n)
+	require.Equal(t, keystore.LightScryptP, p)
+
+	n, p = params.KeyStoreConfig{KDF: params.StandardKDF}.ScryptParams()
+	require.Equal(t, keystore.StandardScryptN, n)
+	require.Equal(t, keystore.StandardScryptP, p)
+
+	n, p = params.KeyStoreConfig{KDF: params.StandardKDF, ScryptN: 1 << 16}.ScryptParams()
+	require.Equal(t, 1<<16, n)
+	require.Equal(t, keystore.StandardScryptP, p)
+}
+
+func TestParsePortRange(t *testing.T) {
+	testCases := []struct {
+		portRange string
+		from, to  int
+		err       error
+	}{
+		{"30303-30310", 30303, 30310, nil},
+		{"30303", 30303, 30303, nil},
+		{"30303-30303", 30303, 30303, nil},
+		{"", 0, 0, params.ErrInvalidPortRange},
+		{"0-10", 0, 0, params.ErrInvalidPortRange},
+		{"30303-70000", 0, 0, params.ErrInvalidPortRange},
+		{"30310-30303", 0, 0, params.ErrInvalidPortRange},
+		{"30303-", 0, 0, params.ErrInvalidPortRange},
+		{"a-b", 0, 0, params.ErrInvalidPortRange},
+	}
+
+	for _, tc := range testCases {
+		from, to, err := params.ParsePortRange(tc.portRange)
+		require.Equal(t, tc.err, err, tc.portRange)
+		require.Equal(t, tc.from, from, tc.portRange)
+		require.Equal(t, tc.to, to, tc.portRange)
+	}
+}
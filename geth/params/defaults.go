@@ -13,6 +13,15 @@ const (
 	// IPCFile is filename of exposed IPC RPC Server
 	IPCFile = "geth.ipc"
 
+	// ListenAddr is the default p2p listening address (random port)
+	ListenAddr = ":0"
+
+	// NAT is the default port mapping mechanism, any of UPnP and NAT-PMP which is available
+	NAT = "any"
+
+	// DiscoveryV5 is the default state of topic-based discovery
+	DiscoveryV5 = true
+
 	// RPCEnabledDefault is the default state of whether the http rpc server is supposed
 	// to be started along with a node.
 	RPCEnabledDefault = false
@@ -51,6 +60,27 @@ const (
 	// DatabaseCache is memory (in MBs) allocated to internal caching (min 16MB / database forced)
 	DatabaseCache = 16
 
+	// LightSyncMode makes a node sync block headers only, over LES protocol
+	LightSyncMode = "light"
+
+	// FastSyncMode makes a full node download blocks and the latest state, without executing past transactions
+	FastSyncMode = "fast"
+
+	// FullSyncMode makes a full node download and execute all blocks
+	FullSyncMode = "full"
+
+	// SyncMode is the default chain synchronisation mode
+	SyncMode = LightSyncMode
+
+	// LightKDF encrypts account keys with scrypt parameters, which are fast enough for mobile devices
+	LightKDF = "light"
+
+	// StandardKDF encrypts account keys with go-ethereum's default scrypt parameters
+	StandardKDF = "standard"
+
+	// KeyStoreKDF is the default preset of scrypt parameters of account keys
+	KeyStoreKDF = LightKDF
+
 	// LogFile defines where to write logs to
 	LogFile = ""
 
@@ -67,6 +97,15 @@ const (
 	// WhisperDataDir is directory where Whisper data is stored, relative to DataDir
 	WhisperDataDir = "wnode"
 
+	// MailServerDataDir is directory where envelopes archived by the mail server are stored, relative to DataDir
+	MailServerDataDir = "mailserver"
+
+	// PushServerDataDir is directory where registrations of devices of the push server are stored, relative to DataDir
+	PushServerDataDir = "pushserver"
+
+	// MailServerRetention is how long the mail server archives envelopes, in hours
+	MailServerRetention = 30 * 24
+
 	// WhisperPort is Whisper node listening port
 	WhisperPort = 30379
 
@@ -76,6 +115,63 @@ const (
 	// WhisperTTL is time to live for messages, in seconds
 	WhisperTTL = 120
 
+	// WhisperPeerRatePenalty is how long envelopes of a peer over its rate limits are dropped, in seconds
+	WhisperPeerRatePenalty = 60
+
+	// ShutdownDrainTimeout is how long stopping node waits for in-flight work to be finished, in seconds
+	ShutdownDrainTimeout = 5
+
+	// TxQueueCapacity is how many transactions can wait for approval at once
+	TxQueueCapacity = 35
+
+	// TxQueueTTL is how long a transaction waits for approval, in seconds
+	TxQueueTTL = 300
+
+	// TxQueueRateLimit is how many transactions per second a DApp can request on average
+	TxQueueRateLimit = 0.2
+
+	// TxQueueRateBurst is how many transactions a DApp can request at once
+	TxQueueRateBurst = 5
+
+	// TxWatcherConfirmations is how many blocks confirm a sent transaction
+	TxWatcherConfirmations = 12
+
+	// TxWatcherPollInterval is how often sent transactions are checked, in milliseconds
+	TxWatcherPollInterval = 15000
+
+	// JailFetchTimeout is how long a request made with fetch from a jail cell can take, in seconds
+	JailFetchTimeout = 30
+
+	// JailFetchMaxResponseSize is how large a response to a request made with fetch from a jail cell can be, in bytes
+	JailFetchMaxResponseSize = 1 << 20
+
+	// JailMaxExecutionTime is how long a single execution of JS code in a jail cell can take, in milliseconds
+	JailMaxExecutionTime = 10000
+
+	// JailMaxMemory is how many bytes the heap can grow by during a single execution of JS code in a jail cell
+	JailMaxMemory = 256 << 20
+
+	// SupervisorMaxRestarts is a number of consecutive attempts to restart a crashed node
+	SupervisorMaxRestarts = 5
+
+	// SupervisorInitialBackoff is a delay before the first restart of a crashed node, in milliseconds
+	SupervisorInitialBackoff = 1000
+
+	// SupervisorMaxBackoff is a maximum delay between restarts of a crashed node, in milliseconds
+	SupervisorMaxBackoff = 60000
+
+	// TargetPeers is a number of peers the node tries to keep
+	TargetPeers = 3
+
+	// PeerCheckInterval is how often the peer count is checked, in milliseconds
+	PeerCheckInterval = 10000
+
+	// PeerlessTimeout is how long the node may have no peers before it's reported, in milliseconds
+	PeerlessTimeout = 60000
+
+	// KnownPeers is a number of the best previously connected peers dialed on start
+	KnownPeers = 5
+
 	// FirebaseNotificationTriggerURL is URL where FCM notification requests are sent to
 	FirebaseNotificationTriggerURL = "https://fcm.googleapis.com/fcm/send"
 
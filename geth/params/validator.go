@@ -1,10 +1,76 @@
 package params
 
 import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/nat"
 	"gopkg.in/go-playground/validator.v9"
 )
 
 // NewValidator returns a new validator.Validate.
 func NewValidator() *validator.Validate {
-	return validator.New()
+	validate := validator.New()
+	validate.RegisterValidation("nat", validateNAT)             // nolint: errcheck
+	validate.RegisterValidation("peer", validatePeer)           // nolint: errcheck
+	validate.RegisterValidation("portrange", validatePortRange) // nolint: errcheck
+	validate.RegisterValidation("pow2", validatePowerOfTwo)     // nolint: errcheck
+
+	return validate
+}
+
+// validateNAT checks that a field is a NAT mechanism recognized by nat.Parse.
+func validateNAT(fl validator.FieldLevel) bool {
+	_, err := nat.Parse(fl.Field().String())
+	return err == nil
+}
+
+// validatePeer checks that a field is an enode URL, an IP address or an IP range in CIDR notation.
+func validatePeer(fl validator.FieldLevel) bool {
+	peer := fl.Field().String()
+	if strings.HasPrefix(peer, "enode://") {
+		_, err := discover.ParseNode(peer)
+		return err == nil
+	}
+	if strings.Contains(peer, "/") {
+		_, _, err := net.ParseCIDR(peer)
+		return err == nil
+	}
+
+	return net.ParseIP(peer) != nil
+}
+
+// validatePortRange checks that a field is a range of ports parsed by ParsePortRange.
+func validatePortRange(fl validator.FieldLevel) bool {
+	_, _, err := ParsePortRange(fl.Field().String())
+	return err == nil
+}
+
+// validatePowerOfTwo checks that an integer field is zero or a power of 2.
+func validatePowerOfTwo(fl validator.FieldLevel) bool {
+	n := fl.Field().Int()
+	return n&(n-1) == 0
+}
+
+// ParsePortRange parses a range of ports in "from-to" form, e.g. 30303-30310.
+// A single port is a range of one port.
+func ParsePortRange(portRange string) (from, to int, err error) {
+	parts := strings.SplitN(portRange, "-", 2)
+	if from, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, ErrInvalidPortRange
+	}
+	to = from
+	if len(parts) == 2 {
+		if to, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, ErrInvalidPortRange
+		}
+	}
+
+	if from < 1 || to > 65535 || from > to {
+		return 0, 0, ErrInvalidPortRange
+	}
+
+	return from, to, nil
 }
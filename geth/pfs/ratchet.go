@@ -0,0 +1,342 @@
+package pfs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MaxSkip is how many message keys of messages which haven't arrived yet a ratchet derives at most in one step,
+// so that a malicious contact can't make the node derive an unbounded number of keys.
+const MaxSkip = 1000
+
+// maxSkipped is how many keys of skipped messages a ratchet keeps at most, keys of the oldest ones are
+// forgotten first
+const maxSkipped = 2 * MaxSkip
+
+// keyLength is the length of root, chain and message keys, in bytes
+const keyLength = 32
+
+var (
+	// ErrTooManySkipped is returned when a message is too far ahead of the last one received.
+	ErrTooManySkipped = errors.New("too many messages skipped")
+
+	// ErrDecryptionFailed is returned when a message can't be decrypted with keys of a session.
+	ErrDecryptionFailed = errors.New("message can't be decrypted")
+)
+
+var (
+	// ratchetInfo binds root and chain keys to this protocol
+	ratchetInfo = []byte("status-pfs-ratchet")
+
+	// messageInfo binds AES keys and nonces of messages to this protocol
+	messageInfo = []byte("status-pfs-message")
+)
+
+// RatchetHeader is sent with every message, so that the recipient can derive the key it's encrypted with.
+type RatchetHeader struct {
+	DH hexutil.Bytes `json:"dh"` // current ratchet public key of the sender
+	PN uint32        `json:"pn"` // number of messages in the sender's previous sending chain
+	N  uint32        `json:"n"`  // number of the message in the sender's current sending chain
+}
+
+// bytes returns an encoding of a header which is authenticated along with the message.
+func (h *RatchetHeader) bytes() []byte {
+	numbers := make([]byte, 8)
+	binary.BigEndian.PutUint32(numbers, h.PN)
+	binary.BigEndian.PutUint32(numbers[4:], h.N)
+
+	return append(append([]byte(nil), h.DH...), numbers...)
+}
+
+// ratchet is a state of the Double Ratchet of a session, serialized to be stored.
+type ratchet struct {
+	DHs hexutil.Bytes `json:"dhs"`           // ratchet private key
+	DHr hexutil.Bytes `json:"dhr,omitempty"` // ratchet public key of the contact
+	RK  hexutil.Bytes `json:"rk"`            // root key
+	CKs hexutil.Bytes `json:"cks,omitempty"` // chain key of sent messages
+	CKr hexutil.Bytes `json:"ckr,omitempty"` // chain key of received messages
+	Ns  uint32        `json:"ns"`            // number of messages sent in the sending chain
+	Nr  uint32        `json:"nr"`            // number of messages received in the receiving chain
+	PN  uint32        `json:"pn"`            // number of messages sent in the previous sending chain
+
+	// keys of messages which haven't arrived yet, by ratchet public key of the contact and number of the message
+	Skipped map[string]hexutil.Bytes `json:"skipped,omitempty"`
+	// IDs of keys of messages which haven't arrived yet, in the order they were skipped
+	SkippedIDs []string `json:"skipped_ids,omitempty"`
+}
+
+// newInitiatorRatchet returns a ratchet of the initiator of a session with a shared secret,
+// sending messages to the contact's pre-key first.
+func newInitiatorRatchet(secret []byte, preKey *ecdsa.PublicKey) (*ratchet, error) {
+	dhs, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	shared, err := dh(dhs, preKey)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ratchet{
+		DHs: crypto.FromECDSA(dhs),
+		DHr: crypto.FromECDSAPub(preKey),
+	}
+	r.RK, r.CKs = kdfRoot(secret, shared)
+
+	return r, nil
+}
+
+// newResponderRatchet returns a ratchet of the responder of a session with a shared secret, whose first
+// ratchet key is the pre-key the initiator used.
+func newResponderRatchet(secret []byte, preKey *ecdsa.PrivateKey) *ratchet {
+	return &ratchet{
+		DHs: crypto.FromECDSA(preKey),
+		RK:  secret,
+	}
+}
+
+// encrypt returns a header and a message encrypted with the next key of the sending chain,
+// authenticating associated data of the session.
+func (r *ratchet) encrypt(plainText, ad []byte) (*RatchetHeader, []byte, error) {
+	if len(r.CKs) == 0 {
+		// the responder sends once it has received a message of the initiator
+		return nil, nil, ErrNoSession
+	}
+
+	dhs, err := crypto.ToECDSA(r.DHs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var messageKey []byte
+	r.CKs, messageKey = kdfChain(r.CKs)
+	header := &RatchetHeader{DH: crypto.FromECDSAPub(&dhs.PublicKey), PN: r.PN, N: r.Ns}
+	r.Ns++
+
+	cipherText, err := seal(messageKey, plainText, append(append([]byte(nil), ad...), header.bytes()...))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, cipherText, nil
+}
+
+// decrypt returns a message decrypted with a key derived from its header, authenticating associated data of
+// the session. The ratchet is changed only if the message is decrypted.
+func (r *ratchet) decrypt(header *RatchetHeader, cipherText, ad []byte) ([]byte, error) {
+	ad = append(append([]byte(nil), ad...), header.bytes()...)
+
+	skippedID := skippedKeyID(header.DH, header.N)
+	if messageKey, ok := r.Skipped[skippedID]; ok {
+		plainText, err := open(messageKey, cipherText, ad)
+		if err != nil {
+			return nil, err
+		}
+		r.forgetSkipped(skippedID)
+
+		return plainText, nil
+	}
+
+	next := r.copy()
+	if !bytes.Equal(header.DH, next.DHr) {
+		if err := next.skip(header.PN); err != nil {
+			return nil, err
+		}
+		if err := next.step(header.DH); err != nil {
+			return nil, err
+		}
+	}
+	if err := next.skip(header.N); err != nil {
+		return nil, err
+	}
+
+	var messageKey []byte
+	next.CKr, messageKey = kdfChain(next.CKr)
+	next.Nr++
+
+	plainText, err := open(messageKey, cipherText, ad)
+	if err != nil {
+		return nil, err
+	}
+	*r = *next
+
+	return plainText, nil
+}
+
+// skip stores keys of messages of the receiving chain up to a number, which haven't arrived yet. Keys of
+// the oldest skipped messages are forgotten once there are more than maxSkipped of them.
+func (r *ratchet) skip(until uint32) error {
+	if len(r.CKr) == 0 {
+		return nil
+	}
+	if until > r.Nr+MaxSkip {
+		return ErrTooManySkipped
+	}
+
+	for r.Nr < until {
+		var messageKey []byte
+		r.CKr, messageKey = kdfChain(r.CKr)
+		id := skippedKeyID(r.DHr, r.Nr)
+		r.Skipped[id] = messageKey
+		r.SkippedIDs = append(r.SkippedIDs, id)
+		r.Nr++
+	}
+
+	for len(r.SkippedIDs) > maxSkipped {
+		delete(r.Skipped, r.SkippedIDs[0])
+		r.SkippedIDs = r.SkippedIDs[1:]
+	}
+
+	return nil
+}
+
+// forgetSkipped removes a key of a skipped message once the message arrives.
+func (r *ratchet) forgetSkipped(id string) {
+	delete(r.Skipped, id)
+	for i, skippedID := range r.SkippedIDs {
+		if skippedID == id {
+			r.SkippedIDs = append(r.SkippedIDs[:i], r.SkippedIDs[i+1:]...)
+			break
+		}
+	}
+}
+
+// step advances the ratchet with a new ratchet public key of the contact, deriving new receiving
+// and sending chains.
+func (r *ratchet) step(contactKey hexutil.Bytes) error {
+	dhr, err := toPublicKey(contactKey)
+	if err != nil {
+		return err
+	}
+	dhs, err := crypto.ToECDSA(r.DHs)
+	if err != nil {
+		return err
+	}
+
+	r.PN = r.Ns
+	r.Ns = 0
+	r.Nr = 0
+	r.DHr = contactKey
+
+	shared, err := dh(dhs, dhr)
+	if err != nil {
+		return err
+	}
+	r.RK, r.CKr = kdfRoot(r.RK, shared)
+
+	if dhs, err = crypto.GenerateKey(); err != nil {
+		return err
+	}
+	if shared, err = dh(dhs, dhr); err != nil {
+		return err
+	}
+	r.DHs = crypto.FromECDSA(dhs)
+	r.RK, r.CKs = kdfRoot(r.RK, shared)
+
+	return nil
+}
+
+// copy returns a copy of the ratchet, which can be changed without changing it.
+func (r *ratchet) copy() *ratchet {
+	c := *r
+	c.Skipped = make(map[string]hexutil.Bytes, len(r.Skipped))
+	for id, key := range r.Skipped {
+		c.Skipped[id] = key
+	}
+	c.SkippedIDs = append([]string(nil), r.SkippedIDs...)
+
+	return &c
+}
+
+// skippedKeyID identifies a key of a message by the ratchet public key and the number of the message.
+func skippedKeyID(dh []byte, n uint32) string {
+	return fmt.Sprintf("%x:%d", dh, n)
+}
+
+// kdfRoot returns a new root key and a chain key derived from a root key and the output of a Diffie-Hellman exchange.
+func kdfRoot(rootKey, shared []byte) (newRootKey, chainKey []byte) {
+	keys := hkdf(shared, rootKey, ratchetInfo, 2*keyLength)
+	return keys[:keyLength], keys[keyLength:]
+}
+
+// kdfChain returns the next chain key and a message key derived from a chain key.
+func kdfChain(chainKey []byte) (nextChainKey, messageKey []byte) {
+	return hmacSHA256(chainKey, []byte{0x02}), hmacSHA256(chainKey, []byte{0x01})
+}
+
+// seal encrypts a message with AES-GCM, with a key and a nonce derived from a message key used once.
+func seal(messageKey, plainText, ad []byte) ([]byte, error) {
+	aead, nonce, err := messageCipher(messageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nil, nonce, plainText, ad), nil
+}
+
+// open decrypts a message encrypted with seal.
+func open(messageKey, cipherText, ad []byte) ([]byte, error) {
+	aead, nonce, err := messageCipher(messageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	plainText, err := aead.Open(nil, nonce, cipherText, ad)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+
+	return plainText, nil
+}
+
+// messageCipher returns AES-GCM with a key and a nonce derived from a message key.
+func messageCipher(messageKey []byte) (cipher.AEAD, []byte, error) {
+	material := hkdf(messageKey, nil, messageInfo, keyLength+12)
+
+	block, err := aes.NewCipher(material[:keyLength])
+	if err != nil {
+		return nil, nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aead, material[keyLength:], nil
+}
+
+// hkdf derives keys of a length from a secret with HKDF-SHA256 (RFC 5869). A nil salt is a zero one.
+func hkdf(secret, salt, info []byte, length int) []byte {
+	if salt == nil {
+		salt = make([]byte, sha256.Size)
+	}
+	pseudoRandomKey := hmacSHA256(salt, secret)
+
+	var out, block []byte
+	for counter := byte(1); len(out) < length; counter++ {
+		block = hmacSHA256(pseudoRandomKey, block, info, []byte{counter})
+		out = append(out, block...)
+	}
+
+	return out[:length]
+}
+
+// hmacSHA256 returns HMAC-SHA256 of a concatenation of data with a key.
+func hmacSHA256(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, d := range data {
+		mac.Write(d) // nolint: errcheck
+	}
+
+	return mac.Sum(nil)
+}
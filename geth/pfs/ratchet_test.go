@@ -0,0 +1,163 @@
+package pfs
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+func TestX3DHAgreement(t *testing.T) {
+	alice, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bob, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bobPreKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	bundle, err := newBundle(bob, &bobPreKey.PublicKey, 1000)
+	require.NoError(t, err)
+	identityKey, preKey, err := bundle.Verify()
+	require.NoError(t, err)
+	require.Equal(t, bob.PublicKey.X, identityKey.X)
+	require.Equal(t, bobPreKey.PublicKey.X, preKey.X)
+
+	aliceSecret, ephemeralKey, err := x3dhInitiate(alice, identityKey, preKey)
+	require.NoError(t, err)
+	bobSecret, err := x3dhRespond(bob, bobPreKey, &alice.PublicKey, &ephemeralKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, aliceSecret, bobSecret)
+
+	// a bundle with a replaced pre-key or timestamp isn't valid
+	forged := *bundle
+	forged.Timestamp++
+	_, _, err = forged.Verify()
+	require.Equal(t, ErrInvalidBundle, err)
+	forged = *bundle
+	forged.SignedPreKey = crypto.FromECDSAPub(&alice.PublicKey)
+	_, _, err = forged.Verify()
+	require.Equal(t, ErrInvalidBundle, err)
+}
+
+// newRatchets returns ratchets of an initiator and a responder of a session
+func newRatchets(t *testing.T) (*ratchet, *ratchet) {
+	preKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	secret := crypto.Keccak256([]byte("shared secret"))
+
+	initiator, err := newInitiatorRatchet(secret, &preKey.PublicKey)
+	require.NoError(t, err)
+
+	return initiator, newResponderRatchet(secret, preKey)
+}
+
+func TestRatchetExchange(t *testing.T) {
+	alice, bob := newRatchets(t)
+	ad := []byte("associated data")
+
+	// the responder can't send before it receives a message
+	_, _, err := bob.encrypt([]byte("too early"), ad)
+	require.Equal(t, ErrNoSession, err)
+
+	type sent struct {
+		header     *RatchetHeader
+		cipherText []byte
+	}
+	send := func(r *ratchet, text string) sent {
+		header, cipherText, err := r.encrypt([]byte(text), ad)
+		require.NoError(t, err)
+		return sent{header, cipherText}
+	}
+	receive := func(r *ratchet, message sent) string {
+		plainText, err := r.decrypt(message.header, message.cipherText, ad)
+		require.NoError(t, err)
+		return string(plainText)
+	}
+
+	a1, a2, a3 := send(alice, "a1"), send(alice, "a2"), send(alice, "a3")
+	require.Equal(t, "a2", receive(bob, a2))
+
+	b1 := send(bob, "b1")
+	require.Equal(t, "b1", receive(alice, b1))
+	require.NotEqual(t, a1.header.DH, b1.header.DH)
+
+	// messages of previous chains are decrypted with skipped keys, once
+	a4 := send(alice, "a4")
+	require.NotEqual(t, a1.header.DH, a4.header.DH, "the ratchet steps on a reply")
+	require.Equal(t, "a4", receive(bob, a4))
+	require.Equal(t, "a3", receive(bob, a3))
+	require.Equal(t, "a1", receive(bob, a1))
+	_, err = bob.decrypt(a1.header, a1.cipherText, ad)
+	require.Equal(t, ErrDecryptionFailed, err)
+
+	// a tampered message doesn't change the ratchet
+	b2 := send(bob, "b2")
+	tampered := sent{header: &RatchetHeader{DH: b2.header.DH, PN: b2.header.PN, N: b2.header.N + 1}, cipherText: b2.cipherText}
+	_, err = alice.decrypt(tampered.header, tampered.cipherText, ad)
+	require.Equal(t, ErrDecryptionFailed, err)
+	_, err = alice.decrypt(b2.header, b2.cipherText, []byte("other data"))
+	require.Equal(t, ErrDecryptionFailed, err)
+	require.Equal(t, "b2", receive(alice, b2))
+}
+
+func TestRatchetSkipLimit(t *testing.T) {
+	alice, bob := newRatchets(t)
+	ad := []byte("associated data")
+
+	header, cipherText, err := alice.encrypt([]byte("first"), ad)
+	require.NoError(t, err)
+	_, err = bob.decrypt(header, cipherText, ad)
+	require.NoError(t, err)
+
+	for i := 0; i < MaxSkip+2; i++ {
+		header, cipherText, err = alice.encrypt([]byte("skipped"), ad)
+		require.NoError(t, err)
+	}
+	_, err = bob.decrypt(header, cipherText, ad)
+	require.Equal(t, ErrTooManySkipped, err)
+}
+
+func TestRatchetSkippedKeysEvicted(t *testing.T) {
+	alice, bob := newRatchets(t)
+	ad := []byte("associated data")
+
+	header, cipherText, err := alice.encrypt([]byte("first"), ad)
+	require.NoError(t, err)
+	_, err = bob.decrypt(header, cipherText, ad)
+	require.NoError(t, err)
+
+	// bob loses most messages of alice's chains, which are within the limit of each step
+	type sent struct {
+		header     *RatchetHeader
+		cipherText []byte
+	}
+	var lost []sent
+	for step := 0; step < 3; step++ {
+		for i := 0; i < MaxSkip-1; i++ {
+			header, cipherText, err = alice.encrypt([]byte("lost"), ad)
+			require.NoError(t, err)
+			lost = append(lost, sent{header, cipherText})
+		}
+		header, cipherText, err = alice.encrypt([]byte("received"), ad)
+		require.NoError(t, err)
+		_, err = bob.decrypt(header, cipherText, ad)
+		require.NoError(t, err)
+
+		header, cipherText, err = bob.encrypt([]byte("reply"), ad)
+		require.NoError(t, err)
+		_, err = alice.decrypt(header, cipherText, ad)
+		require.NoError(t, err)
+	}
+	require.True(t, len(lost) > maxSkipped)
+
+	// keys of the oldest lost messages are forgotten, recent ones still decrypt
+	require.Len(t, bob.Skipped, maxSkipped)
+	require.Len(t, bob.SkippedIDs, maxSkipped)
+	_, err = bob.decrypt(lost[0].header, lost[0].cipherText, ad)
+	require.Error(t, err)
+	plainText, err := bob.decrypt(lost[len(lost)-maxSkipped].header, lost[len(lost)-maxSkipped].cipherText, ad)
+	require.NoError(t, err)
+	require.Equal(t, "lost", string(plainText))
+	require.Len(t, bob.Skipped, maxSkipped-1)
+	require.Len(t, bob.SkippedIDs, maxSkipped-1)
+}
@@ -0,0 +1,472 @@
+package pfs
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+// DataDir is the directory sessions are stored in, relative to the node's instance directory
+const DataDir = "pfs"
+
+const (
+	// SignedPreKeyLifetime is how long a signed pre-key is published in bundles before a new one replaces it
+	SignedPreKeyLifetime = 7 * 24 * time.Hour
+
+	// maxPreKeys is how many signed pre-keys of an identity are kept, so that sessions established with
+	// bundles published before the last rotations can still be accepted
+	maxPreKeys = 3
+)
+
+// kinds of stored records of an identity
+const (
+	preKeysRecord = 'p'
+	sessionRecord = 's'
+)
+
+var (
+	// ErrNoSession is returned when a message is encrypted for a contact no session is established with.
+	ErrNoSession = errors.New("no session with the contact")
+
+	// ErrOwnBundle is returned when a session is established with a bundle of the identity itself.
+	ErrOwnBundle = errors.New("bundle of the identity itself")
+
+	// ErrUnknownPreKey is returned when a session is initiated with a pre-key the identity doesn't have.
+	ErrUnknownPreKey = errors.New("unknown signed pre-key")
+
+	// ErrWrongSender is returned when a message initiating a session isn't sent by the expected contact.
+	ErrWrongSender = errors.New("message is not sent by the contact")
+
+	// ErrCorruptRecord is returned when a stored record can't be decrypted.
+	ErrCorruptRecord = errors.New("stored session can't be decrypted")
+)
+
+// recordKeySalt makes keys of records differ from hashes of the same keys elsewhere
+var recordKeySalt = []byte("status-pfs-record")
+
+// encryptionKeySalt makes a record encryption key differ from any other key derived from an identity key
+var encryptionKeySalt = []byte("status-pfs-session")
+
+// Message is a message encrypted with a session, which is sent as the payload of a Whisper message.
+type Message struct {
+	X3DH       *X3DHHeader   `json:"x3dh,omitempty"` // sent by the initiator of a session until the contact replies
+	Header     RatchetHeader `json:"header"`
+	CipherText hexutil.Bytes `json:"ciphertext"`
+}
+
+// preKey is a signed pre-key of an identity
+type preKey struct {
+	Key       hexutil.Bytes `json:"key"`       // private key
+	Timestamp int64         `json:"timestamp"` // unix time the pre-key was created at
+}
+
+// session is a session of an identity with a contact
+type session struct {
+	Ratchet *ratchet      `json:"ratchet"`
+	AD      hexutil.Bytes `json:"ad"`             // identity keys of the initiator and the responder
+	Init    *X3DHHeader   `json:"init,omitempty"` // X3DH header sent until the contact replies, of the initiator
+	Ack     hexutil.Bytes `json:"ack,omitempty"`  // ephemeral key of the initiator the session was established with, of the responder
+}
+
+// Service establishes sessions of Whisper identities with their contacts and encrypts and decrypts
+// messages of them. Pre-keys and sessions are stored in LevelDB, encrypted with keys derived from identity keys.
+// It's a node service exposing the pfs namespace.
+type Service struct {
+	shh *whisper.Whisper
+	db  *leveldb.DB
+	now func() time.Time
+
+	mu sync.Mutex
+}
+
+// New returns a service of sessions of identities of a Whisper service stored in a LevelDB database at a path,
+// or in memory if the path is empty.
+func New(shh *whisper.Whisper, path string) (*Service, error) {
+	var (
+		db  *leveldb.DB
+		err error
+	)
+	if path == "" {
+		db, err = leveldb.Open(storage.NewMemStorage(), nil)
+	} else {
+		db, err = leveldb.OpenFile(path, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{shh: shh, db: db, now: time.Now}, nil
+}
+
+// Protocols implements node.Service, the service has no protocols.
+func (s *Service) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, exposing the pfs namespace.
+func (s *Service) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "pfs",
+			Version:   "1.0",
+			Service:   &PublicAPI{service: s},
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service.
+func (s *Service) Start(*p2p.Server) error {
+	return nil
+}
+
+// Stop implements node.Service, closing the database.
+func (s *Service) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Close()
+}
+
+// Bundle returns a bundle of an identity, which is an ID of a key pair in Whisper, to be published to contacts.
+// A new signed pre-key is created when the last one is older than SignedPreKeyLifetime.
+func (s *Service) Bundle(identityID string) (*Bundle, error) {
+	identityKey, err := s.shh.GetPrivateKey(identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	preKeys, err := s.preKeys(identityKey)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now().Unix()
+	if len(preKeys) == 0 || now-preKeys[0].Timestamp >= int64(SignedPreKeyLifetime/time.Second) {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		preKeys = append([]preKey{{Key: crypto.FromECDSA(key), Timestamp: now}}, preKeys...)
+		if len(preKeys) > maxPreKeys {
+			preKeys = preKeys[:maxPreKeys]
+		}
+		if err := s.put(identityKey, preKeysRecord, nil, preKeys); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := crypto.ToECDSA(preKeys[0].Key)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBundle(identityKey, &key.PublicKey, preKeys[0].Timestamp)
+}
+
+// ProcessBundle establishes a session of an identity with the contact who published a bundle, replacing
+// a previous session with the contact. Messages can be sent with it before the contact replies.
+func (s *Service) ProcessBundle(identityID string, bundle *Bundle) error {
+	identityKey, err := s.shh.GetPrivateKey(identityID)
+	if err != nil {
+		return err
+	}
+	contactKey, preKey, err := bundle.Verify()
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(bundle.IdentityKey, crypto.FromECDSAPub(&identityKey.PublicKey)) {
+		return ErrOwnBundle
+	}
+
+	secret, ephemeralKey, err := x3dhInitiate(identityKey, contactKey, preKey)
+	if err != nil {
+		return err
+	}
+	r, err := newInitiatorRatchet(secret, preKey)
+	if err != nil {
+		return err
+	}
+
+	identityPublicKey := crypto.FromECDSAPub(&identityKey.PublicKey)
+	established := &session{
+		Ratchet: r,
+		AD:      append(append([]byte(nil), identityPublicKey...), bundle.IdentityKey...),
+		Init: &X3DHHeader{
+			IdentityKey:  identityPublicKey,
+			EphemeralKey: crypto.FromECDSAPub(&ephemeralKey.PublicKey),
+			SignedPreKey: bundle.SignedPreKey,
+		},
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	log.Debug("established a session", "contact", hexutil.Encode(bundle.IdentityKey))
+
+	return s.put(identityKey, sessionRecord, bundle.IdentityKey, established)
+}
+
+// EncryptMessage encrypts a payload sent by an identity to a contact with their session, returning
+// a JSON encoded Message.
+func (s *Service) EncryptMessage(identityID string, contact, payload []byte) ([]byte, error) {
+	identityKey, err := s.shh.GetPrivateKey(identityID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current session
+	if err := s.get(identityKey, sessionRecord, contact, &current); err != nil {
+		return nil, err
+	}
+
+	header, cipherText, err := current.Ratchet.encrypt(payload, current.AD)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.put(identityKey, sessionRecord, contact, &current); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(Message{X3DH: current.Init, Header: *header, CipherText: cipherText})
+}
+
+// DecryptMessage decrypts a JSON encoded Message sent by a contact to an identity. A message initiating a new
+// session with a pre-key of the identity establishes it, replacing a previous session with the contact.
+func (s *Service) DecryptMessage(identityID string, contact, data []byte) ([]byte, error) {
+	identityKey, err := s.shh.GetPrivateKey(identityID)
+	if err != nil {
+		return nil, err
+	}
+	var message Message
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var current session
+	err = s.get(identityKey, sessionRecord, contact, &current)
+	if err != nil && err != ErrNoSession {
+		return nil, err
+	}
+
+	if message.X3DH != nil && (err == ErrNoSession || s.accepts(identityKey, &current, message.X3DH)) {
+		responded, respondErr := s.respond(identityKey, contact, message.X3DH)
+		if respondErr != nil {
+			return nil, respondErr
+		}
+		current, err = *responded, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := current.Ratchet.decrypt(&message.Header, message.CipherText, current.AD)
+	if err != nil {
+		return nil, err
+	}
+	if message.X3DH == nil {
+		// the contact has established the session, there's no need to send the X3DH header anymore
+		current.Init = nil
+	}
+	if err := s.put(identityKey, sessionRecord, contact, &current); err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// ResetSession removes a session of an identity with a contact, e.g. when the contact is removed.
+func (s *Service) ResetSession(identityID string, contact []byte) error {
+	identityKey, err := s.shh.GetPrivateKey(identityID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.db.Delete(recordKey(identityKey, sessionRecord, contact), nil)
+}
+
+// accepts reports whether a session initiated by a contact replaces the current one. When both initiated
+// sessions at once, the session of the participant with the lower identity key is kept by both.
+func (s *Service) accepts(identityKey *ecdsa.PrivateKey, current *session, header *X3DHHeader) bool {
+	if bytes.Equal(current.Ack, header.EphemeralKey) {
+		return false
+	}
+	if current.Init != nil {
+		return bytes.Compare(header.IdentityKey, crypto.FromECDSAPub(&identityKey.PublicKey)) < 0
+	}
+
+	return true
+}
+
+// respond returns a session initiated by a contact with a pre-key of the identity.
+func (s *Service) respond(identityKey *ecdsa.PrivateKey, contact []byte, header *X3DHHeader) (*session, error) {
+	if !bytes.Equal(header.IdentityKey, contact) {
+		return nil, ErrWrongSender
+	}
+	contactKey, err := toPublicKey(header.IdentityKey)
+	if err != nil {
+		return nil, err
+	}
+	ephemeralKey, err := toPublicKey(header.EphemeralKey)
+	if err != nil {
+		return nil, err
+	}
+
+	preKeys, err := s.preKeys(identityKey)
+	if err != nil {
+		return nil, err
+	}
+	var signedPreKey *ecdsa.PrivateKey
+	for _, candidate := range preKeys {
+		key, err := crypto.ToECDSA(candidate.Key)
+		if err != nil {
+			return nil, err
+		}
+		if bytes.Equal(crypto.FromECDSAPub(&key.PublicKey), header.SignedPreKey) {
+			signedPreKey = key
+			break
+		}
+	}
+	if signedPreKey == nil {
+		return nil, ErrUnknownPreKey
+	}
+
+	secret, err := x3dhRespond(identityKey, signedPreKey, contactKey, ephemeralKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session{
+		Ratchet: newResponderRatchet(secret, signedPreKey),
+		AD:      append(append([]byte(nil), header.IdentityKey...), crypto.FromECDSAPub(&identityKey.PublicKey)...),
+		Ack:     header.EphemeralKey,
+	}, nil
+}
+
+// preKeys returns signed pre-keys of an identity, the newest first.
+func (s *Service) preKeys(identityKey *ecdsa.PrivateKey) ([]preKey, error) {
+	var preKeys []preKey
+	if err := s.get(identityKey, preKeysRecord, nil, &preKeys); err != nil && err != ErrNoSession {
+		return nil, err
+	}
+
+	return preKeys, nil
+}
+
+// get reads and decrypts a record of an identity, returning ErrNoSession if there's none.
+func (s *Service) get(identityKey *ecdsa.PrivateKey, kind byte, contact []byte, record interface{}) error {
+	value, err := s.db.Get(recordKey(identityKey, kind, contact), nil)
+	if err == leveldb.ErrNotFound {
+		return ErrNoSession
+	}
+	if err != nil {
+		return err
+	}
+
+	aead, err := recordCipher(identityKey)
+	if err != nil {
+		return err
+	}
+	nonceSize := aead.NonceSize()
+	if len(value) < nonceSize {
+		return ErrCorruptRecord
+	}
+	plainText, err := aead.Open(nil, value[:nonceSize], value[nonceSize:], nil)
+	if err != nil {
+		return ErrCorruptRecord
+	}
+
+	return json.Unmarshal(plainText, record)
+}
+
+// put encrypts and writes a record of an identity.
+func (s *Service) put(identityKey *ecdsa.PrivateKey, kind byte, contact []byte, record interface{}) error {
+	plainText, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	aead, err := recordCipher(identityKey)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+
+	return s.db.Put(recordKey(identityKey, kind, contact), aead.Seal(nonce, nonce, plainText, nil), nil)
+}
+
+// recordKey returns a key of a record of an identity, which doesn't reveal the identity or the contact.
+func recordKey(identityKey *ecdsa.PrivateKey, kind byte, contact []byte) []byte {
+	return crypto.Keccak256(recordKeySalt, crypto.FromECDSA(identityKey), []byte{kind}, contact)
+}
+
+// recordCipher returns AES-GCM with a key derived from an identity key.
+func recordCipher(identityKey *ecdsa.PrivateKey) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(crypto.Keccak256(encryptionKeySalt, crypto.FromECDSA(identityKey)))
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// PublicAPI is the pfs API of the service.
+type PublicAPI struct {
+	service *Service
+}
+
+// GetBundle returns a bundle of an identity to be published, see Service.Bundle.
+func (api *PublicAPI) GetBundle(identity string) (*Bundle, error) {
+	return api.service.Bundle(identity)
+}
+
+// ProcessBundle establishes a session of an identity with a contact, see Service.ProcessBundle.
+func (api *PublicAPI) ProcessBundle(identity string, bundle *Bundle) error {
+	return api.service.ProcessBundle(identity, bundle)
+}
+
+// EncryptMessage encrypts a payload sent by an identity to a contact, see Service.EncryptMessage.
+func (api *PublicAPI) EncryptMessage(identity string, contact, payload hexutil.Bytes) (hexutil.Bytes, error) {
+	return api.service.EncryptMessage(identity, contact, payload)
+}
+
+// DecryptMessage decrypts a message sent by a contact to an identity, see Service.DecryptMessage.
+func (api *PublicAPI) DecryptMessage(identity string, contact, message hexutil.Bytes) (hexutil.Bytes, error) {
+	return api.service.DecryptMessage(identity, contact, message)
+}
+
+// ResetSession removes a session of an identity with a contact, see Service.ResetSession.
+func (api *PublicAPI) ResetSession(identity string, contact hexutil.Bytes) error {
+	return api.service.ResetSession(identity, contact)
+}
@@ -0,0 +1,141 @@
+package pfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+// participant is an identity with its own service
+type participant struct {
+	service    *Service
+	identityID string
+	publicKey  []byte
+}
+
+func newParticipant(t *testing.T, path string) *participant {
+	shh := whisper.New(nil)
+	identityID, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	key, err := shh.GetPrivateKey(identityID)
+	require.NoError(t, err)
+
+	service, err := New(shh, path)
+	require.NoError(t, err)
+
+	return &participant{service: service, identityID: identityID, publicKey: crypto.FromECDSAPub(&key.PublicKey)}
+}
+
+func (p *participant) send(t *testing.T, to *participant, text string) []byte {
+	message, err := p.service.EncryptMessage(p.identityID, to.publicKey, []byte(text))
+	require.NoError(t, err)
+	return message
+}
+
+func (p *participant) receive(t *testing.T, from *participant, message []byte) string {
+	payload, err := p.service.DecryptMessage(p.identityID, from.publicKey, message)
+	require.NoError(t, err)
+	return string(payload)
+}
+
+func TestSessionWithBundle(t *testing.T) {
+	alice := newParticipant(t, "")
+	defer alice.service.Stop() // nolint: errcheck
+	bob := newParticipant(t, "")
+	defer bob.service.Stop() // nolint: errcheck
+
+	_, err := alice.service.EncryptMessage(alice.identityID, bob.publicKey, []byte("no session"))
+	require.Equal(t, ErrNoSession, err)
+
+	bundle, err := bob.service.Bundle(bob.identityID)
+	require.NoError(t, err)
+	require.Equal(t, ErrOwnBundle, bob.service.ProcessBundle(bob.identityID, bundle))
+	require.NoError(t, alice.service.ProcessBundle(alice.identityID, bundle))
+
+	// messages sent before the contact replies initiate the session
+	first := alice.send(t, bob, "first")
+	second := alice.send(t, bob, "second")
+	require.Equal(t, "second", bob.receive(t, alice, second))
+	require.Equal(t, "first", bob.receive(t, alice, first))
+
+	// a message of the session can't be decrypted as sent by someone else
+	eve := newParticipant(t, "")
+	defer eve.service.Stop() // nolint: errcheck
+	_, err = bob.service.DecryptMessage(bob.identityID, eve.publicKey, alice.send(t, bob, "third"))
+	require.Equal(t, ErrWrongSender, err)
+
+	require.Equal(t, "reply", alice.receive(t, bob, bob.send(t, alice, "reply")))
+	require.NotContains(t, string(alice.send(t, bob, "after reply")), "x3dh")
+
+	require.NoError(t, bob.service.ResetSession(bob.identityID, alice.publicKey))
+	_, err = bob.service.EncryptMessage(bob.identityID, alice.publicKey, []byte("reset"))
+	require.Equal(t, ErrNoSession, err)
+}
+
+func TestSimultaneousSessions(t *testing.T) {
+	alice := newParticipant(t, "")
+	defer alice.service.Stop() // nolint: errcheck
+	bob := newParticipant(t, "")
+	defer bob.service.Stop() // nolint: errcheck
+
+	aliceBundle, err := alice.service.Bundle(alice.identityID)
+	require.NoError(t, err)
+	bobBundle, err := bob.service.Bundle(bob.identityID)
+	require.NoError(t, err)
+	require.NoError(t, alice.service.ProcessBundle(alice.identityID, bobBundle))
+	require.NoError(t, bob.service.ProcessBundle(bob.identityID, aliceBundle))
+
+	// both keep the session initiated by the participant with the lower identity key
+	lower, higher := alice, bob
+	if string(bob.publicKey) < string(alice.publicKey) {
+		lower, higher = bob, alice
+	}
+	fromLower := lower.send(t, higher, "from lower")
+	fromHigher := higher.send(t, lower, "from higher")
+
+	_, err = lower.service.DecryptMessage(lower.identityID, higher.publicKey, fromHigher)
+	require.Error(t, err)
+	require.Equal(t, "from lower", higher.receive(t, lower, fromLower))
+
+	require.Equal(t, "again", lower.receive(t, higher, higher.send(t, lower, "again")))
+	require.Equal(t, "and again", higher.receive(t, lower, lower.send(t, higher, "and again")))
+}
+
+func TestSessionsPersisted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	alice := newParticipant(t, "")
+	defer alice.service.Stop() // nolint: errcheck
+	bob := newParticipant(t, filepath.Join(dir, "bob"))
+
+	bundle, err := bob.service.Bundle(bob.identityID)
+	require.NoError(t, err)
+	require.NoError(t, alice.service.ProcessBundle(alice.identityID, bundle))
+	require.Equal(t, "hello", bob.receive(t, alice, alice.send(t, bob, "hello")))
+
+	shh := bob.service.shh
+	require.NoError(t, bob.service.Stop())
+	bob.service, err = New(shh, filepath.Join(dir, "bob"))
+	require.NoError(t, err)
+	defer bob.service.Stop() // nolint: errcheck
+
+	require.Equal(t, "still there", alice.receive(t, bob, bob.send(t, alice, "still there")))
+
+	// the signed pre-key is kept until it's rotated
+	again, err := bob.service.Bundle(bob.identityID)
+	require.NoError(t, err)
+	require.Equal(t, bundle.SignedPreKey, again.SignedPreKey)
+
+	bob.service.now = func() time.Time { return time.Now().Add(SignedPreKeyLifetime) }
+	rotated, err := bob.service.Bundle(bob.identityID)
+	require.NoError(t, err)
+	require.NotEqual(t, bundle.SignedPreKey, rotated.SignedPreKey)
+}
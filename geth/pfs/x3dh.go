@@ -0,0 +1,156 @@
+// Package pfs adds perfect forward secrecy to one-to-one chats on top of Whisper. Participants of a chat
+// establish a session with X3DH, from their identity keys, a signed pre-key of the contact published in
+// a bundle and an ephemeral key, and then derive a new key for every message with the Double Ratchet.
+// A compromised identity key doesn't expose messages encrypted before, and a compromised message key
+// doesn't expose any other message. See https://signal.org/docs/specifications/x3dh/ and
+// https://signal.org/docs/specifications/doubleratchet/.
+package pfs
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+var (
+	// ErrInvalidBundle is returned when a bundle isn't signed by the identity key it contains.
+	ErrInvalidBundle = errors.New("invalid signature of a bundle")
+
+	// ErrInvalidPublicKey is returned when a public key of a contact or of a bundle is malformed.
+	ErrInvalidPublicKey = errors.New("invalid public key")
+)
+
+// x3dhInfo binds secrets derived with X3DH to this protocol
+var x3dhInfo = []byte("status-pfs-x3dh")
+
+// Bundle is published by an identity, so that contacts can establish sessions with it while it's offline.
+type Bundle struct {
+	IdentityKey  hexutil.Bytes `json:"identityKey"`  // public key of the identity
+	SignedPreKey hexutil.Bytes `json:"signedPreKey"` // public pre-key of the identity
+	Signature    hexutil.Bytes `json:"signature"`    // signature of the pre-key and the timestamp by the identity key
+	Timestamp    int64         `json:"timestamp"`    // unix time the pre-key was created at, in seconds
+}
+
+// X3DHHeader is sent with messages of a session until the contact replies, so that it can establish
+// the same session.
+type X3DHHeader struct {
+	IdentityKey  hexutil.Bytes `json:"identityKey"`  // public key of the sender's identity
+	EphemeralKey hexutil.Bytes `json:"ephemeralKey"` // public key generated by the sender for the session
+	SignedPreKey hexutil.Bytes `json:"signedPreKey"` // public pre-key of the recipient's bundle the session was established with
+}
+
+// newBundle returns a bundle of a pre-key created at a time, signed by an identity key.
+func newBundle(identityKey *ecdsa.PrivateKey, preKey *ecdsa.PublicKey, timestamp int64) (*Bundle, error) {
+	bundle := &Bundle{
+		IdentityKey:  crypto.FromECDSAPub(&identityKey.PublicKey),
+		SignedPreKey: crypto.FromECDSAPub(preKey),
+		Timestamp:    timestamp,
+	}
+
+	signature, err := crypto.Sign(bundle.hash(), identityKey)
+	if err != nil {
+		return nil, err
+	}
+	bundle.Signature = signature
+
+	return bundle, nil
+}
+
+// Verify checks that a bundle is signed by its identity key, and returns the identity key and the pre-key.
+func (b *Bundle) Verify() (identityKey *ecdsa.PublicKey, preKey *ecdsa.PublicKey, err error) {
+	identityKey, err = toPublicKey(b.IdentityKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	preKey, err = toPublicKey(b.SignedPreKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signer, err := crypto.SigToPub(b.hash(), b.Signature)
+	if err != nil || !bytes.Equal(crypto.FromECDSAPub(signer), b.IdentityKey) {
+		return nil, nil, ErrInvalidBundle
+	}
+
+	return identityKey, preKey, nil
+}
+
+// hash returns a hash of the pre-key and the timestamp of a bundle, which is signed.
+func (b *Bundle) hash() []byte {
+	timestamp := make([]byte, 8)
+	binary.BigEndian.PutUint64(timestamp, uint64(b.Timestamp))
+
+	return crypto.Keccak256(b.SignedPreKey, timestamp)
+}
+
+// x3dhInitiate returns a secret shared with a contact, derived from the identity key, a pre-key of the contact
+// and an ephemeral key, which is returned to be sent to the contact.
+func x3dhInitiate(identityKey *ecdsa.PrivateKey, contact, preKey *ecdsa.PublicKey) ([]byte, *ecdsa.PrivateKey, error) {
+	ephemeralKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := x3dhSecret(
+		dhPair{identityKey, preKey},
+		dhPair{ephemeralKey, contact},
+		dhPair{ephemeralKey, preKey},
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return secret, ephemeralKey, nil
+}
+
+// x3dhRespond returns a secret shared with a contact who initiated a session with an ephemeral key
+// and a pre-key of the identity.
+func x3dhRespond(identityKey, preKey *ecdsa.PrivateKey, contact, ephemeralKey *ecdsa.PublicKey) ([]byte, error) {
+	return x3dhSecret(
+		dhPair{preKey, contact},
+		dhPair{identityKey, ephemeralKey},
+		dhPair{preKey, ephemeralKey},
+	)
+}
+
+// dhPair is a private key and a public key of the other party of a Diffie-Hellman exchange
+type dhPair struct {
+	private *ecdsa.PrivateKey
+	public  *ecdsa.PublicKey
+}
+
+// x3dhSecret returns a secret derived from outputs of Diffie-Hellman exchanges.
+func x3dhSecret(pairs ...dhPair) ([]byte, error) {
+	// the secret is preceded by bytes which aren't a valid public key, as the specification suggests
+	material := bytes.Repeat([]byte{0xff}, keyLength)
+	for _, pair := range pairs {
+		shared, err := dh(pair.private, pair.public)
+		if err != nil {
+			return nil, err
+		}
+		material = append(material, shared...)
+	}
+
+	return hkdf(material, nil, x3dhInfo, keyLength), nil
+}
+
+// dh returns the output of a Diffie-Hellman exchange of a private key with a public key.
+func dh(private *ecdsa.PrivateKey, public *ecdsa.PublicKey) ([]byte, error) {
+	return ecies.ImportECDSA(private).GenerateShared(ecies.ImportECDSAPublic(public), keyLength/2, keyLength/2)
+}
+
+// toPublicKey returns a public key, if it's valid.
+func toPublicKey(key []byte) (*ecdsa.PublicKey, error) {
+	publicKey := crypto.ToECDSAPub(key)
+	if !whisper.ValidatePublicKey(publicKey) {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return publicKey, nil
+}
@@ -0,0 +1,254 @@
+package push
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/shhlimit"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventPushRegistration is triggered when a push server responds to a registration of a device
+const EventPushRegistration = "push.registration"
+
+// RegistrationEvent is a signal sent when a push server responds to a registration of a device of an identity,
+// or to a removal of its token.
+type RegistrationEvent struct {
+	Identity string        `json:"identity"` // ID of the key pair of the identity
+	Server   hexutil.Bytes `json:"server"`
+	Type     RequestType   `json:"type"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Client sends requests of identities to push servers and signals responses to registrations with
+// EventPushRegistration. It's a node service adding push methods to the shh namespace, processing
+// responses every pollInterval.
+type Client struct {
+	shh *shhlimit.Whisper
+
+	mu       sync.Mutex
+	filters  map[string]string // IDs of filters of responses by identity
+	quit     chan struct{}
+	stopping sync.WaitGroup
+
+	signals signal.Sender // sends push notification signals, see SetSignalHandler
+}
+
+// NewClient returns a client sending requests of identities known to a Whisper service, which records them
+// as posted by the node.
+func NewClient(shh *shhlimit.Whisper) *Client {
+	return &Client{
+		shh:     shh,
+		filters: make(map[string]string),
+	}
+}
+
+// SetSignalHandler sets a handler of signals sent by the client, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (c *Client) SetSignalHandler(handler func(signal.Envelope)) {
+	c.signals.SetHandler(handler)
+}
+
+// Protocols implements node.Service, the client has no protocols.
+func (c *Client) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, adding push methods to the shh namespace.
+func (c *Client) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "shh",
+			Version:   "1.0",
+			Service:   &PublicAPI{client: c},
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service, processing responses every pollInterval.
+func (c *Client) Start(*p2p.Server) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.quit = make(chan struct{})
+	c.stopping.Add(1)
+	go c.loop(c.quit)
+
+	return nil
+}
+
+// Stop implements node.Service, removing filters of responses.
+func (c *Client) Stop() error {
+	c.mu.Lock()
+	if c.quit != nil {
+		close(c.quit)
+		c.quit = nil
+	}
+	c.mu.Unlock()
+
+	c.stopping.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for identity, filterID := range c.filters {
+		c.unsubscribe(filterID)
+		delete(c.filters, identity)
+	}
+
+	return nil
+}
+
+// Register registers the FCM token of the device with a push server for an identity, which is an ID of
+// a key pair in Whisper. The server's response is signalled with EventPushRegistration.
+func (c *Client) Register(identity string, server []byte, token string) error {
+	if token == "" {
+		return ErrInvalidToken
+	}
+
+	return c.send(identity, server, &Request{Type: RequestRegister, Token: token})
+}
+
+// Unregister removes the token of the device of an identity from a push server. The server's response is
+// signalled with EventPushRegistration.
+func (c *Client) Unregister(identity string, server []byte) error {
+	return c.send(identity, server, &Request{Type: RequestUnregister})
+}
+
+// Notify requests a push server to notify a recipient of a message sent by an identity. Nothing happens
+// if the recipient hasn't registered a device with the server.
+func (c *Client) Notify(identity string, server, recipient []byte) error {
+	if _, err := toPublicKey(recipient); err != nil {
+		return err
+	}
+
+	return c.send(identity, server, &Request{Type: RequestNotify, Recipient: recipient})
+}
+
+// PruneIdentities removes filters of responses of identities whose key pairs were removed from Whisper,
+// e.g. once their accounts are logged out.
+func (c *Client) PruneIdentities() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for identity, filterID := range c.filters {
+		if !c.shh.HasKeyPair(identity) {
+			c.unsubscribe(filterID)
+			delete(c.filters, identity)
+		}
+	}
+}
+
+// send posts a request of an identity to a push server, registering the filter of responses to the identity
+// if it isn't yet.
+func (c *Client) send(identity string, server []byte, request *Request) error {
+	serverKey, err := toPublicKey(server)
+	if err != nil {
+		return err
+	}
+	privateKey, err := c.shh.GetPrivateKey(identity)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	if _, ok := c.filters[identity]; !ok {
+		filterID, err := c.shh.Subscribe(&whisper.Filter{
+			KeyAsym: privateKey,
+			Topics:  [][]byte{Topic[:]},
+		})
+		if err != nil {
+			c.mu.Unlock()
+			return err
+		}
+		c.filters[identity] = filterID
+	}
+	c.mu.Unlock()
+
+	return post(c.shh, privateKey, serverKey, request)
+}
+
+// loop processes responses every pollInterval, until quit is closed.
+func (c *Client) loop(quit <-chan struct{}) {
+	defer c.stopping.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.poll()
+		case <-quit:
+			return
+		}
+	}
+}
+
+// poll signals responses delivered to filters of responses since the last call.
+func (c *Client) poll() {
+	var events []RegistrationEvent
+
+	c.mu.Lock()
+	for identity, filterID := range c.filters {
+		filter := c.shh.GetFilter(filterID)
+		if filter == nil {
+			continue
+		}
+		for _, message := range filter.Retrieve() {
+			var response Response
+			if message.Src == nil || json.Unmarshal(message.Payload, &response) != nil {
+				continue
+			}
+			events = append(events, RegistrationEvent{
+				Identity: identity,
+				Server:   crypto.FromECDSAPub(message.Src),
+				Type:     response.Type,
+				Error:    response.Error,
+			})
+		}
+	}
+	c.mu.Unlock()
+
+	for _, event := range events {
+		c.signals.Send(signal.Envelope{
+			Type:  EventPushRegistration,
+			Event: event,
+		})
+	}
+}
+
+// unsubscribe removes a filter, which may have been removed with shh_deleteMessageFilter already.
+func (c *Client) unsubscribe(filterID string) {
+	if err := c.shh.Unsubscribe(filterID); err != nil {
+		log.Debug("failed to remove a filter", "filterID", filterID, "err", err)
+	}
+}
+
+// PublicAPI is the shh API of the client.
+type PublicAPI struct {
+	client *Client
+}
+
+// RegisterPushToken registers the FCM token of the device with a push server, see Client.Register.
+func (api *PublicAPI) RegisterPushToken(identity string, server hexutil.Bytes, token string) error {
+	return api.client.Register(identity, server, token)
+}
+
+// UnregisterPushToken removes the token of the device from a push server, see Client.Unregister.
+func (api *PublicAPI) UnregisterPushToken(identity string, server hexutil.Bytes) error {
+	return api.client.Unregister(identity, server)
+}
+
+// RequestPush requests a push server to notify a recipient of a message, see Client.Notify.
+func (api *PublicAPI) RequestPush(identity string, server, recipient hexutil.Bytes) error {
+	return api.client.Notify(identity, server, recipient)
+}
@@ -0,0 +1,97 @@
+// Package push implements push notifications of Whisper messages, so that devices running in background learn
+// of new messages. Devices register FCM tokens of their identities with a trusted push server over Whisper,
+// and senders of messages request the server to notify recipients, without learning their tokens. Requests
+// are signed by identities and encrypted with the server's key, responses to registrations are signed by
+// the server and encrypted with keys of identities.
+package push
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/shhlimit"
+)
+
+const (
+	// requestTTL is a TTL of requests and responses, in seconds
+	requestTTL = 60 * 60
+
+	// requestWorkTime is how long a PoW of requests and responses is computed at most, in seconds
+	requestWorkTime = 1
+)
+
+var (
+	// ErrInvalidToken is returned when a device is registered without a token.
+	ErrInvalidToken = errors.New("FCM token of a device is expected")
+
+	// ErrInvalidKey is returned when a public key of a server or a recipient is invalid.
+	ErrInvalidKey = errors.New("invalid public key")
+)
+
+// Topic is the topic of requests and responses.
+var Topic = whisper.BytesToTopic(crypto.Keccak256([]byte("status-push")))
+
+// RequestType is a type of a request to a push server.
+type RequestType string
+
+// Types of requests to push servers.
+const (
+	RequestRegister   RequestType = "register"   // a device registers its token for an identity
+	RequestUnregister RequestType = "unregister" // a device removes the token of an identity
+	RequestNotify     RequestType = "notify"     // a sender requests a notification of a recipient
+)
+
+// Request is a request to a push server.
+type Request struct {
+	Type      RequestType   `json:"type"`
+	Token     string        `json:"token,omitempty"`     // FCM token of the device, for registrations
+	Recipient hexutil.Bytes `json:"recipient,omitempty"` // public key of the recipient, for notifications
+}
+
+// Response is a push server's answer to a registration, or a removal of a token.
+type Response struct {
+	Type  RequestType `json:"type"`
+	Error string      `json:"error,omitempty"`
+}
+
+// post sends a payload on Topic, signed with a key and encrypted with a public key.
+func post(shh *shhlimit.Whisper, key *ecdsa.PrivateKey, dst *ecdsa.PublicKey, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	params := &whisper.MessageParams{
+		TTL:      requestTTL,
+		Src:      key,
+		Dst:      dst,
+		Topic:    Topic,
+		WorkTime: requestWorkTime,
+		PoW:      shh.MinPow(),
+		Payload:  data,
+	}
+	message, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return err
+	}
+	envelope, err := message.Wrap(params)
+	if err != nil {
+		return err
+	}
+
+	return shh.Send(envelope)
+}
+
+// toPublicKey returns a public key of its bytes.
+func toPublicKey(key []byte) (*ecdsa.PublicKey, error) {
+	publicKey := crypto.ToECDSAPub(key)
+	if publicKey == nil || publicKey.X == nil {
+		return nil, ErrInvalidKey
+	}
+
+	return publicKey, nil
+}
@@ -0,0 +1,136 @@
+package push
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/NaySoftware/go-fcm"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/shhlimit"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+// notifier records tokens notifications are sent to
+type notifier chan string
+
+func (n notifier) Send(body string, payload fcm.NotificationPayload, tokens ...string) error {
+	for _, token := range tokens {
+		n <- token
+	}
+	return nil
+}
+
+// newIdentity adds a key pair to Whisper, returning its ID and public key
+func newIdentity(t *testing.T, shh *shhlimit.Whisper) (string, []byte) {
+	id, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	key, err := shh.GetPrivateKey(id)
+	require.NoError(t, err)
+
+	return id, crypto.FromECDSAPub(&key.PublicKey)
+}
+
+func TestPushNotifications(t *testing.T) {
+	events := make(chan RegistrationEvent, 10)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event RegistrationEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventPushRegistration {
+			events <- envelope.Event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	shh := shhlimit.New(whisper.New(nil), nil, shhlimit.NewRelay(nil, false))
+	require.NoError(t, shh.Start(nil))
+	defer shh.Stop() // nolint: errcheck
+
+	serverKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	serverPub := crypto.FromECDSAPub(&serverKey.PublicKey)
+	db, err := OpenDB("")
+	require.NoError(t, err)
+	notifications := make(notifier, 10)
+	server := NewServer(notifications)
+	server.Init(shh, serverKey, db)
+	require.NoError(t, server.Start(nil))
+	defer server.Stop() // nolint: errcheck
+
+	client := NewClient(shh)
+	require.NoError(t, client.Start(nil))
+	defer client.Stop() // nolint: errcheck
+
+	alice, alicePub := newIdentity(t, shh)
+	bob, _ := newIdentity(t, shh)
+
+	waitEvent := func() RegistrationEvent {
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("push server didn't respond")
+		}
+		return RegistrationEvent{}
+	}
+
+	require.Equal(t, ErrInvalidToken, client.Register(alice, serverPub, ""))
+	require.Equal(t, ErrInvalidKey, client.Register(alice, []byte("not a key"), "token"))
+
+	require.NoError(t, client.Register(alice, serverPub, "alice-token"))
+	require.Equal(t, RegistrationEvent{Identity: alice, Server: hexutil.Bytes(serverPub), Type: RequestRegister}, waitEvent())
+
+	// bob has alice's device notified, without learning its token
+	require.NoError(t, client.Notify(bob, serverPub, alicePub))
+	select {
+	case token := <-notifications:
+		require.Equal(t, "alice-token", token)
+	case <-time.After(10 * time.Second):
+		t.Fatal("alice's device wasn't notified")
+	}
+
+	require.NoError(t, client.Unregister(alice, serverPub))
+	require.Equal(t, RequestUnregister, waitEvent().Type)
+	_, err = db.Get(alicePub, nil)
+	require.Error(t, err)
+
+	// filters of responses are removed with key pairs
+	require.True(t, shh.DeleteKeyPair(alice))
+	client.PruneIdentities()
+	require.Len(t, client.filters, 1)
+	require.Contains(t, client.filters, bob)
+}
+
+func TestNotifyInterval(t *testing.T) {
+	db, err := OpenDB("")
+	require.NoError(t, err)
+	notifications := make(notifier, 10)
+	server := NewServer(notifications)
+	server.Init(shhlimit.New(whisper.New(nil), nil, shhlimit.NewRelay(nil, false)), nil, db)
+
+	sender, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	recipient, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	recipientPub := crypto.FromECDSAPub(&recipient.PublicKey)
+
+	// recipients without devices aren't notified
+	now := time.Now()
+	require.NoError(t, server.notify(&sender.PublicKey, recipientPub, now))
+	require.Len(t, notifications, 0)
+
+	require.NoError(t, server.register(&recipient.PublicKey, &Request{Type: RequestRegister, Token: "token"}))
+	require.NoError(t, server.notify(&sender.PublicKey, recipientPub, now))
+	require.NoError(t, server.notify(&sender.PublicKey, recipientPub, now.Add(time.Second)))
+	require.Len(t, notifications, 1)
+
+	server.poll(now.Add(notifyInterval))
+	require.NoError(t, server.notify(&sender.PublicKey, recipientPub, now.Add(notifyInterval)))
+	require.Len(t, notifications, 2)
+}
@@ -0,0 +1,248 @@
+package push
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/NaySoftware/go-fcm"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/shhlimit"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/storage"
+)
+
+const (
+	// pollInterval is how often requests delivered to the server's filter are processed
+	pollInterval = time.Second
+
+	// notifyInterval is how often a sender can have a recipient notified, further requests are dropped
+	notifyInterval = 10 * time.Second
+)
+
+// notifyKey identifies notifications of a recipient requested by a sender
+type notifyKey struct {
+	sender    string
+	recipient string
+}
+
+// Server keeps FCM tokens of devices registered by identities and sends push notifications to them when
+// senders request it. Notifications don't carry messages, nor their senders. It's a node service processing
+// requests sent to its identity every pollInterval, registrations are stored in LevelDB.
+type Server struct {
+	notifier common.Notifier
+
+	mu       sync.Mutex
+	shh      *shhlimit.Whisper
+	key      *ecdsa.PrivateKey
+	db       *leveldb.DB
+	filterID string
+	notified map[notifyKey]time.Time // when recipients were notified last
+	quit     chan struct{}
+	stopping sync.WaitGroup
+}
+
+// NewServer returns a push server sending notifications with a notifier.
+func NewServer(notifier common.Notifier) *Server {
+	return &Server{
+		notifier: notifier,
+		notified: make(map[notifyKey]time.Time),
+	}
+}
+
+// OpenDB opens a LevelDB database of registrations at a path, or in memory if the path is empty.
+func OpenDB(path string) (*leveldb.DB, error) {
+	if path == "" {
+		return leveldb.Open(storage.NewMemStorage(), nil)
+	}
+
+	return leveldb.OpenFile(path, nil)
+}
+
+// Init sets the Whisper service requests are received with, the key of the server's identity and the database
+// registrations are stored in. The server closes the database when stopped.
+func (s *Server) Init(shh *shhlimit.Whisper, key *ecdsa.PrivateKey, db *leveldb.DB) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shh = shh
+	s.key = key
+	s.db = db
+}
+
+// Protocols implements node.Service, the server has no protocols.
+func (s *Server) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, the server has no APIs.
+func (s *Server) APIs() []rpc.API {
+	return nil
+}
+
+// Start implements node.Service, registering the filter of requests and processing them every pollInterval.
+func (s *Server) Start(*p2p.Server) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	filterID, err := s.shh.Subscribe(&whisper.Filter{
+		KeyAsym: s.key,
+		Topics:  [][]byte{Topic[:]},
+	})
+	if err != nil {
+		return err
+	}
+	s.filterID = filterID
+	log.Info("Push server started", "pubkey", hexPublicKey(&s.key.PublicKey))
+
+	s.quit = make(chan struct{})
+	s.stopping.Add(1)
+	go s.loop(s.quit)
+
+	return nil
+}
+
+// Stop implements node.Service, removing the filter of requests and closing the database.
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	if s.quit != nil {
+		close(s.quit)
+		s.quit = nil
+	}
+	s.mu.Unlock()
+
+	s.stopping.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.filterID != "" {
+		if err := s.shh.Unsubscribe(s.filterID); err != nil {
+			log.Debug("failed to remove a filter", "filterID", s.filterID, "err", err)
+		}
+		s.filterID = ""
+	}
+	if s.db == nil {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+
+	return err
+}
+
+// loop processes requests every pollInterval, until quit is closed.
+func (s *Server) loop(quit <-chan struct{}) {
+	defer s.stopping.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.poll(now)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// poll processes requests delivered to the filter of requests since the last call.
+func (s *Server) poll(now time.Time) {
+	s.mu.Lock()
+	filter := s.shh.GetFilter(s.filterID)
+	for key, notified := range s.notified {
+		if now.Sub(notified) >= notifyInterval {
+			delete(s.notified, key)
+		}
+	}
+	s.mu.Unlock()
+
+	if filter == nil {
+		return
+	}
+	for _, message := range filter.Retrieve() {
+		s.process(message, now)
+	}
+}
+
+// process handles a request received at a time. Requests which aren't signed are dropped.
+func (s *Server) process(message *whisper.ReceivedMessage, now time.Time) {
+	var request Request
+	if message.Src == nil || json.Unmarshal(message.Payload, &request) != nil {
+		log.Debug("dropped an invalid push request", "hash", message.EnvelopeHash.Hex())
+		return
+	}
+
+	switch request.Type {
+	case RequestRegister, RequestUnregister:
+		err := s.register(message.Src, &request)
+		response := Response{Type: request.Type}
+		if err != nil {
+			response.Error = err.Error()
+		}
+		if err := post(s.shh, s.key, message.Src, &response); err != nil {
+			log.Warn("failed to respond to a push request", "type", request.Type, "err", err)
+		}
+	case RequestNotify:
+		if err := s.notify(message.Src, request.Recipient, now); err != nil {
+			log.Warn("failed to send a push notification", "err", err)
+		}
+	}
+}
+
+// register stores or removes the token of a device of an identity.
+func (s *Server) register(identity *ecdsa.PublicKey, request *Request) error {
+	key := crypto.FromECDSAPub(identity)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if request.Type == RequestUnregister {
+		return s.db.Delete(key, nil)
+	}
+	if request.Token == "" {
+		return ErrInvalidToken
+	}
+
+	return s.db.Put(key, []byte(request.Token), nil)
+}
+
+// notify sends a push notification to the device of a recipient, unless the sender had it notified within
+// notifyInterval. Requests of recipients without devices are ignored, so that senders can't tell whether
+// recipients are registered.
+func (s *Server) notify(sender *ecdsa.PublicKey, recipient []byte, now time.Time) error {
+	key := notifyKey{sender: hexPublicKey(sender), recipient: hexutil.Encode(recipient)}
+
+	s.mu.Lock()
+	if _, ok := s.notified[key]; ok {
+		s.mu.Unlock()
+		return nil
+	}
+	token, err := s.db.Get(recipient, nil)
+	if err == leveldb.ErrNotFound {
+		s.mu.Unlock()
+		return nil
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	s.notified[key] = now
+	s.mu.Unlock()
+
+	return s.notifier.Send("", fcm.NotificationPayload{}, string(token))
+}
+
+// hexPublicKey returns a hex encoded public key.
+func hexPublicKey(key *ecdsa.PublicKey) string {
+	return hexutil.Encode(crypto.FromECDSAPub(key))
+}
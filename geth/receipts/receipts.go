@@ -0,0 +1,401 @@
+// Package receipts implements acknowledgments of Whisper messages. Identities receiving signed one-to-one
+// messages send receipts back to senders, signed and encrypted with their keys, carrying hashes of envelopes
+// of the messages. The sender's node matches receipts to messages it sent, so that clients can tell which
+// messages reached their recipients.
+package receipts
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/shhlimit"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventMessageDelivered is triggered when a recipient acknowledges a message sent by the node
+const EventMessageDelivered = "message.delivered"
+
+const (
+	// ackInterval is how often received messages are acknowledged and receipts are matched, so that
+	// messages received meanwhile from the same sender are acknowledged with one receipt
+	ackInterval = time.Second
+
+	// pendingTimeout is how long messages sent by the node wait for receipts, recipients which were offline
+	// acknowledge messages once a mail server delivers them
+	pendingTimeout = 24 * time.Hour
+
+	// maxReceiptIDs limits how many messages a receipt acknowledges, longer lists are split
+	maxReceiptIDs = 128
+
+	// receiptTTL is a TTL of receipt envelopes, in seconds
+	receiptTTL = 60 * 60
+
+	// receiptWorkTime is how long a PoW of receipt envelopes is computed at most, in seconds
+	receiptWorkTime = 1
+)
+
+// ErrNotInitialized is returned when messages are tracked before the Whisper service is set.
+var ErrNotInitialized = errors.New("receipts service is not initialized")
+
+// ReceiptTopic is the topic of receipts. Payloads of receipts are concatenated hashes of envelopes of
+// acknowledged messages.
+var ReceiptTopic = whisper.BytesToTopic(crypto.Keccak256([]byte("status-receipts")))
+
+// DeliveredEvent is a signal sent when a recipient acknowledges a message sent by the node.
+type DeliveredEvent struct {
+	ID        string        `json:"id"` // hash of the envelope of the message, as returned by shh_post
+	Recipient hexutil.Bytes `json:"recipient"`
+}
+
+// pendingMessage is a message sent by the node which isn't acknowledged yet
+type pendingMessage struct {
+	recipient []byte
+	posted    time.Time
+}
+
+// ackKey identifies receipts an identity sends to a sender
+type ackKey struct {
+	identity string
+	sender   string
+}
+
+// Service acknowledges signed one-to-one messages received by identities and signals messages sent by the
+// node once their recipients acknowledge them with EventMessageDelivered. It's a Whisper delivery server,
+// which passes states of messages on to the delivery server it wraps, and a node service sending receipts
+// and matching them every ackInterval.
+type Service struct {
+	delivery whisper.DeliveryServer // wrapped delivery server, if any
+
+	mu       sync.Mutex
+	shh      *shhlimit.Whisper
+	pending  map[gethcommon.Hash]*pendingMessage
+	filters  map[string]string // IDs of filters of receipts by identity
+	acks     map[ackKey][]gethcommon.Hash
+	quit     chan struct{}
+	stopping sync.WaitGroup
+
+	signals signal.Sender // sends receipt signals, see SetSignalHandler
+}
+
+// New returns a service wrapping a delivery server, which may be nil.
+func New(delivery whisper.DeliveryServer) *Service {
+	return &Service{
+		delivery: delivery,
+		pending:  make(map[gethcommon.Hash]*pendingMessage),
+		filters:  make(map[string]string),
+		acks:     make(map[ackKey][]gethcommon.Hash),
+	}
+}
+
+// SetSignalHandler sets a handler of signals sent by the service, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (s *Service) SetSignalHandler(handler func(signal.Envelope)) {
+	s.signals.SetHandler(handler)
+}
+
+// Init sets the Whisper service receipts are sent with, which records them as posted by the node. States of
+// messages are passed on before it's set.
+func (s *Service) Init(shh *shhlimit.Whisper) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shh = shh
+}
+
+// Protocols implements node.Service, the service has no protocols.
+func (s *Service) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, the service has no APIs.
+func (s *Service) APIs() []rpc.API {
+	return nil
+}
+
+// Start implements node.Service, sending receipts and matching them every ackInterval.
+func (s *Service) Start(*p2p.Server) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quit = make(chan struct{})
+	s.stopping.Add(1)
+	go s.loop(s.quit)
+
+	return nil
+}
+
+// Stop implements node.Service, removing filters of receipts. Messages which aren't acknowledged yet
+// are forgotten.
+func (s *Service) Stop() error {
+	s.mu.Lock()
+	if s.quit != nil {
+		close(s.quit)
+		s.quit = nil
+	}
+	s.mu.Unlock()
+
+	s.stopping.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for identity, filterID := range s.filters {
+		s.unsubscribe(filterID)
+		delete(s.filters, identity)
+	}
+	s.pending = make(map[gethcommon.Hash]*pendingMessage)
+	s.acks = make(map[ackKey][]gethcommon.Hash)
+
+	return nil
+}
+
+// SendState implements whisper.DeliveryServer. Signed one-to-one messages posted by the node wait for
+// receipts, and those received by identities are acknowledged.
+func (s *Service) SendState(state whisper.MessageState) {
+	switch {
+	case state.Direction == gethmessage.OutgoingMessage && state.Status == gethmessage.SentStatus:
+		s.sent(&state)
+	case state.Direction == gethmessage.IncomingMessage && state.Status == gethmessage.DeliveredStatus:
+		s.received(&state.Received)
+	}
+
+	if s.delivery != nil {
+		s.delivery.SendState(state)
+	}
+}
+
+// PruneIdentities removes filters of receipts of identities whose key pairs were removed from Whisper,
+// e.g. once their accounts are logged out.
+func (s *Service) PruneIdentities() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for identity, filterID := range s.filters {
+		if s.shh == nil || !s.shh.HasKeyPair(identity) {
+			s.unsubscribe(filterID)
+			delete(s.filters, identity)
+		}
+	}
+}
+
+// sent makes a signed one-to-one message posted by the node wait for a receipt, registering a filter
+// of receipts of the identity which signed it if needed.
+func (s *Service) sent(state *whisper.MessageState) {
+	identity := state.Source.Sig
+	if identity == "" || len(state.Source.PublicKey) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.receiptFilter(identity); err != nil {
+		log.Debug("failed to register a filter of receipts", "identity", identity, "err", err)
+		return
+	}
+	s.pending[state.Envelope.Hash()] = &pendingMessage{
+		recipient: state.Source.PublicKey,
+		posted:    state.Timestamp,
+	}
+}
+
+// received queues a receipt of a signed one-to-one message received by an identity.
+func (s *Service) received(received *whisper.ReceivedMessage) {
+	if received.Src == nil || received.Dst == nil || received.Topic == ReceiptTopic {
+		return
+	}
+	sender := crypto.FromECDSAPub(received.Src)
+	recipient := crypto.FromECDSAPub(received.Dst)
+	if bytes.Equal(sender, recipient) {
+		return
+	}
+
+	key := ackKey{identity: hexutil.Encode(recipient), sender: hexutil.Encode(sender)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a message matching several filters is delivered to each of them
+	for _, hash := range s.acks[key] {
+		if hash == received.EnvelopeHash {
+			return
+		}
+	}
+	s.acks[key] = append(s.acks[key], received.EnvelopeHash)
+}
+
+// receiptFilter registers a filter of receipts sent to an identity, if it isn't yet.
+func (s *Service) receiptFilter(identity string) error {
+	if _, ok := s.filters[identity]; ok {
+		return nil
+	}
+	if s.shh == nil {
+		return ErrNotInitialized
+	}
+
+	privateKey, err := s.shh.GetPrivateKey(identity)
+	if err != nil {
+		return err
+	}
+	filterID, err := s.shh.Subscribe(&whisper.Filter{
+		KeyAsym:  privateKey,
+		Topics:   [][]byte{ReceiptTopic[:]},
+		AllowP2P: true, // receipts delivered by a mail server
+	})
+	if err != nil {
+		return err
+	}
+	s.filters[identity] = filterID
+
+	return nil
+}
+
+// loop sends receipts and matches them every ackInterval, until quit is closed.
+func (s *Service) loop(quit <-chan struct{}) {
+	defer s.stopping.Done()
+
+	ticker := time.NewTicker(ackInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.sendReceipts()
+			s.matchReceipts(now)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// sendReceipts sends queued receipts.
+func (s *Service) sendReceipts() {
+	s.mu.Lock()
+	shh, acks := s.shh, s.acks
+	s.acks = make(map[ackKey][]gethcommon.Hash)
+	s.mu.Unlock()
+
+	if shh == nil {
+		return
+	}
+
+	for key, hashes := range acks {
+		for len(hashes) > 0 {
+			n := len(hashes)
+			if n > maxReceiptIDs {
+				n = maxReceiptIDs
+			}
+			if err := sendReceipt(shh, key, hashes[:n]); err != nil {
+				log.Warn("failed to send a receipt", "identity", key.identity, "err", err)
+				break
+			}
+			hashes = hashes[n:]
+		}
+	}
+}
+
+// matchReceipts signals messages acknowledged by receipts received since the last call, and forgets
+// messages sent by the node which weren't acknowledged within pendingTimeout.
+func (s *Service) matchReceipts(now time.Time) {
+	var events []DeliveredEvent
+
+	s.mu.Lock()
+	for _, filterID := range s.filters {
+		filter := s.shh.GetFilter(filterID)
+		if filter == nil {
+			continue
+		}
+		for _, receipt := range filter.Retrieve() {
+			events = append(events, s.match(receipt)...)
+		}
+	}
+	for hash, message := range s.pending {
+		if now.Sub(message.posted) > pendingTimeout {
+			delete(s.pending, hash)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, event := range events {
+		s.signals.Send(signal.Envelope{
+			Type:  EventMessageDelivered,
+			Event: event,
+		})
+	}
+}
+
+// match returns events of messages a receipt acknowledges, which are sent to its signer.
+func (s *Service) match(receipt *whisper.ReceivedMessage) []DeliveredEvent {
+	if receipt.Src == nil || len(receipt.Payload)%gethcommon.HashLength != 0 {
+		return nil
+	}
+	signer := crypto.FromECDSAPub(receipt.Src)
+
+	var events []DeliveredEvent
+	for payload := receipt.Payload; len(payload) > 0; payload = payload[gethcommon.HashLength:] {
+		hash := gethcommon.BytesToHash(payload[:gethcommon.HashLength])
+		message, ok := s.pending[hash]
+		if !ok || !bytes.Equal(message.recipient, signer) {
+			continue
+		}
+		delete(s.pending, hash)
+		events = append(events, DeliveredEvent{ID: hash.Hex(), Recipient: signer})
+	}
+
+	return events
+}
+
+// unsubscribe removes a filter, which may have been removed with shh_deleteMessageFilter already.
+func (s *Service) unsubscribe(filterID string) {
+	if err := s.shh.Unsubscribe(filterID); err != nil {
+		log.Debug("failed to remove a filter", "filterID", filterID, "err", err)
+	}
+}
+
+// sendReceipt posts a receipt of messages an identity received from a sender, signed by the identity and
+// encrypted with the sender's key.
+func sendReceipt(shh *shhlimit.Whisper, key ackKey, hashes []gethcommon.Hash) error {
+	privateKey, err := shh.GetPrivateKey(key.identity)
+	if err != nil {
+		return err
+	}
+	sender, err := hexutil.Decode(key.sender)
+	if err != nil {
+		return err
+	}
+
+	payload := make([]byte, 0, len(hashes)*gethcommon.HashLength)
+	for _, hash := range hashes {
+		payload = append(payload, hash[:]...)
+	}
+
+	params := &whisper.MessageParams{
+		TTL:      receiptTTL,
+		Src:      privateKey,
+		Dst:      crypto.ToECDSAPub(sender),
+		Topic:    ReceiptTopic,
+		WorkTime: receiptWorkTime,
+		PoW:      shh.MinPow(),
+		Payload:  payload,
+	}
+	message, err := whisper.NewSentMessage(params)
+	if err != nil {
+		return err
+	}
+	envelope, err := message.Wrap(params)
+	if err != nil {
+		return err
+	}
+
+	return shh.Send(envelope)
+}
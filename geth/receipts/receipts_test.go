@@ -0,0 +1,169 @@
+package receipts
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/shhlimit"
+	"github.com/status-im/status-go/geth/signal"
+	"github.com/stretchr/testify/require"
+)
+
+// newIdentity adds a key pair to Whisper, returning its ID and public key
+func newIdentity(t *testing.T, shh *shhlimit.Whisper) (string, []byte) {
+	id, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	key, err := shh.GetPrivateKey(id)
+	require.NoError(t, err)
+
+	return id, crypto.FromECDSAPub(&key.PublicKey)
+}
+
+func TestMessageAcknowledged(t *testing.T) {
+	var events []DeliveredEvent
+	delivered := make(chan struct{}, 1)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string
+			Event DeliveredEvent
+		}
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventMessageDelivered {
+			events = append(events, envelope.Event)
+			delivered <- struct{}{}
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	shh := shhlimit.New(whisper.New(nil), nil, shhlimit.NewRelay(nil, false))
+	require.NoError(t, shh.Start(nil))
+	defer shh.Stop() // nolint: errcheck
+
+	service := New(nil)
+	service.Init(shh)
+	defer service.Stop() // nolint: errcheck
+
+	alice, alicePub := newIdentity(t, shh)
+	_, bobPub := newIdentity(t, shh)
+
+	// alice sends a signed message to bob, and an unsigned one
+	signed := whisper.Envelope{Expiry: 100, TTL: 10, Data: []byte("signed")}
+	service.SendState(whisper.MessageState{
+		Direction: gethmessage.OutgoingMessage,
+		Status:    gethmessage.SentStatus,
+		Source:    whisper.NewMessage{Sig: alice, PublicKey: bobPub},
+		Envelope:  signed,
+		Timestamp: time.Now(),
+	})
+	service.SendState(whisper.MessageState{
+		Direction: gethmessage.OutgoingMessage,
+		Status:    gethmessage.SentStatus,
+		Source:    whisper.NewMessage{PublicKey: bobPub},
+		Envelope:  whisper.Envelope{Expiry: 100, TTL: 10, Data: []byte("unsigned")},
+		Timestamp: time.Now(),
+	})
+	require.Len(t, service.pending, 1)
+
+	// bob receives the signed message with two filters, it's acknowledged once
+	received := whisper.ReceivedMessage{
+		Src:          crypto.ToECDSAPub(alicePub),
+		Dst:          crypto.ToECDSAPub(bobPub),
+		EnvelopeHash: signed.Hash(),
+	}
+	for i := 0; i < 2; i++ {
+		service.SendState(whisper.MessageState{
+			Direction: gethmessage.IncomingMessage,
+			Status:    gethmessage.DeliveredStatus,
+			Received:  received,
+		})
+	}
+	require.Len(t, service.acks, 1)
+	service.sendReceipts()
+	require.Empty(t, service.acks)
+
+	deadline := time.After(5 * time.Second)
+	for len(events) == 0 {
+		service.matchReceipts(time.Now())
+		select {
+		case <-delivered:
+		case <-time.After(100 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("message wasn't acknowledged")
+		}
+	}
+	require.Equal(t, []DeliveredEvent{{ID: signed.Hash().Hex(), Recipient: hexutil.Bytes(bobPub)}}, events)
+	require.Empty(t, service.pending)
+
+	// the filter of receipts is removed with alice's key pair
+	require.True(t, shh.DeleteKeyPair(alice))
+	service.PruneIdentities()
+	require.Empty(t, service.filters)
+}
+
+func TestReceiptMatching(t *testing.T) {
+	shh := shhlimit.New(whisper.New(nil), nil, shhlimit.NewRelay(nil, false))
+	service := New(nil)
+	service.Init(shh)
+
+	alice, _ := newIdentity(t, shh)
+	_, bobPub := newIdentity(t, shh)
+	_, carolPub := newIdentity(t, shh)
+
+	posted := time.Now()
+	envelope := whisper.Envelope{Expiry: 100, TTL: 10, Data: []byte("signed")}
+	service.SendState(whisper.MessageState{
+		Direction: gethmessage.OutgoingMessage,
+		Status:    gethmessage.SentStatus,
+		Source:    whisper.NewMessage{Sig: alice, PublicKey: bobPub},
+		Envelope:  envelope,
+		Timestamp: posted,
+	})
+	hash := envelope.Hash()
+
+	// a receipt signed by someone else than the recipient doesn't acknowledge the message
+	require.Empty(t, service.match(&whisper.ReceivedMessage{Src: crypto.ToECDSAPub(carolPub), Payload: hash[:]}))
+	require.Empty(t, service.match(&whisper.ReceivedMessage{Src: crypto.ToECDSAPub(bobPub), Payload: hash[1:]}))
+	require.Len(t, service.pending, 1)
+
+	// messages which aren't acknowledged in time are forgotten
+	service.matchReceipts(posted.Add(pendingTimeout + time.Second))
+	require.Empty(t, service.pending)
+}
+
+func TestReceiptsSentInLightMode(t *testing.T) {
+	relay := shhlimit.NewRelay(nil, true)
+	shh := shhlimit.New(whisper.New(nil), nil, relay)
+	require.NoError(t, shh.Start(nil))
+	defer shh.Stop() // nolint: errcheck
+
+	service := New(nil)
+	service.Init(shh)
+
+	_, alicePub := newIdentity(t, shh)
+	_, bobPub := newIdentity(t, shh)
+
+	// bob acknowledges a message of alice
+	message := whisper.Envelope{Expiry: 100, TTL: 10, Data: []byte("signed")}
+	service.SendState(whisper.MessageState{
+		Direction: gethmessage.IncomingMessage,
+		Status:    gethmessage.DeliveredStatus,
+		Received: whisper.ReceivedMessage{
+			Src:          crypto.ToECDSAPub(alicePub),
+			Dst:          crypto.ToECDSAPub(bobPub),
+			EnvelopeHash: message.Hash(),
+		},
+	})
+	require.Len(t, service.acks, 1)
+	service.sendReceipts()
+
+	// the receipt is sent to peers, though envelopes of others aren't
+	envelopes := shh.Envelopes()
+	require.Len(t, envelopes, 1)
+	require.True(t, relay.Allow(envelopes[0], uint32(time.Now().Unix())))
+	require.False(t, relay.Allow(&whisper.Envelope{Expiry: 100, TTL: 10, Data: []byte("relayed")}, uint32(time.Now().Unix())))
+}
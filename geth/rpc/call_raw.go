@@ -28,6 +28,12 @@ func (c *Client) CallRaw(body string) string {
 	return c.callRawContext(ctx, json.RawMessage(body))
 }
 
+// CallRawContext performs a JSON-RPC call with already crafted JSON-RPC body and
+// a context passed to locally registered handlers, e.g. with ID of a chat the call comes from.
+func (c *Client) CallRawContext(ctx context.Context, body string) string {
+	return c.callRawContext(ctx, json.RawMessage(body))
+}
+
 // jsonrpcMessage represents JSON-RPC request, notification, successful response or
 // error response.
 type jsonrpcMessage struct {
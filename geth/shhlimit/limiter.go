@@ -0,0 +1,137 @@
+// Package shhlimit limits how many envelopes and bytes each Whisper peer can send to the node, so that
+// a single peer can't flood it. Envelopes of a peer over its limits are dropped, and so are all its
+// envelopes for a penalty period afterwards.
+package shhlimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// burstWindow is how many seconds of traffic at its rates a peer can send at once, e.g. envelopes
+// of the pool a peer broadcasts on connect
+const burstWindow = 10
+
+// bucket is a token bucket refilled at a rate up to burstWindow seconds of it
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take refills the bucket up to a time and takes an amount of tokens, if there are enough of them.
+// A zero rate doesn't limit the amount.
+func (b *bucket) take(amount, rate float64, now time.Time) bool {
+	if rate <= 0 {
+		return true
+	}
+
+	capacity := rate * burstWindow
+	if b.updated.IsZero() {
+		b.tokens = capacity
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		if b.tokens > capacity {
+			b.tokens = capacity
+		}
+	}
+	b.updated = now
+
+	if b.tokens < amount {
+		return false
+	}
+	b.tokens -= amount
+
+	return true
+}
+
+// peerLimits are buckets and the penalty of a peer
+type peerLimits struct {
+	envelopes bucket
+	bytes     bucket
+	penalized time.Time // when the peer's penalty ends, zero if it isn't penalized
+}
+
+// Limiter limits envelopes received from each peer with token buckets of envelopes and bytes.
+type Limiter struct {
+	envelopeRate float64 // envelopes per second, zero means no limit
+	byteRate     float64 // bytes per second, zero means no limit
+	penalty      time.Duration
+
+	mu       sync.Mutex
+	peers    map[discover.NodeID]*peerLimits
+	counters common.ServiceCounters
+}
+
+// NewLimiter returns a limiter of envelopes and bytes per second each peer can send on average.
+// Zero rates don't limit peers.
+func NewLimiter(envelopeRate, byteRate float64, penalty time.Duration) *Limiter {
+	return &Limiter{
+		envelopeRate: envelopeRate,
+		byteRate:     byteRate,
+		penalty:      penalty,
+		peers:        make(map[discover.NodeID]*peerLimits),
+		counters:     make(common.ServiceCounters),
+	}
+}
+
+// Allow reports whether an envelope of a size received from a peer at a time is within its limits.
+// A peer over its limits is penalized, all its envelopes are rejected until the penalty ends.
+func (l *Limiter) Allow(peer discover.NodeID, size uint32, now time.Time) bool {
+	if l.envelopeRate <= 0 && l.byteRate <= 0 {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limits, ok := l.peers[peer]
+	if !ok {
+		limits = &peerLimits{}
+		l.peers[peer] = limits
+	}
+
+	allowed := now.After(limits.penalized) &&
+		limits.envelopes.take(1, l.envelopeRate, now) &&
+		limits.bytes.take(float64(size), l.byteRate, now)
+	if allowed {
+		return true
+	}
+
+	if !now.Before(limits.penalized) {
+		limits.penalized = now.Add(l.penalty)
+		l.counters["penalties"]++
+		log.Warn("peer exceeded envelope rate limits", "peer", peer.String(), "penalty", l.penalty)
+	}
+	l.counters["droppedEnvelopes"]++
+	l.counters["droppedBytes"] += int64(size)
+
+	return false
+}
+
+// Forget removes limits of a peer disconnected at a time, unless it's penalized, so that reconnecting
+// doesn't end the penalty.
+func (l *Limiter) Forget(peer discover.NodeID, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if limits, ok := l.peers[peer]; ok && !now.Before(limits.penalized) {
+		delete(l.peers, peer)
+	}
+}
+
+// Counters returns how many envelopes and bytes were dropped, and how many times peers were penalized.
+func (l *Limiter) Counters() common.ServiceCounters {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	counters := make(common.ServiceCounters, len(l.counters))
+	for name, value := range l.counters {
+		counters[name] = value
+	}
+
+	return counters
+}
@@ -0,0 +1,71 @@
+package shhlimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	peerA = discover.NodeID{0x0a}
+	peerB = discover.NodeID{0x0b}
+)
+
+func TestLimiterEnvelopeRate(t *testing.T) {
+	limiter := NewLimiter(1, 0, time.Minute)
+	now := time.Unix(1000, 0)
+
+	// a peer can send a burst of envelopes
+	for i := 0; i < burstWindow; i++ {
+		require.True(t, limiter.Allow(peerA, 100, now))
+	}
+	require.False(t, limiter.Allow(peerA, 100, now))
+
+	// other peers have limits of their own
+	require.True(t, limiter.Allow(peerB, 100, now))
+
+	// the penalized peer is dropped until the penalty ends, even though its bucket is refilled
+	require.False(t, limiter.Allow(peerA, 100, now.Add(30*time.Second)))
+	require.True(t, limiter.Allow(peerA, 100, now.Add(time.Minute+time.Second)))
+
+	require.Equal(t, common.ServiceCounters{
+		"penalties":        1,
+		"droppedEnvelopes": 2,
+		"droppedBytes":     200,
+	}, limiter.Counters())
+}
+
+func TestLimiterByteRate(t *testing.T) {
+	limiter := NewLimiter(0, 100, time.Minute)
+	now := time.Unix(1000, 0)
+
+	require.True(t, limiter.Allow(peerA, 600, now))
+	require.True(t, limiter.Allow(peerA, 400, now))
+	require.False(t, limiter.Allow(peerA, 1, now))
+}
+
+func TestLimiterForget(t *testing.T) {
+	limiter := NewLimiter(0.1, 0, time.Minute)
+	now := time.Unix(1000, 0)
+
+	require.True(t, limiter.Allow(peerA, 1, now))
+	require.True(t, limiter.Allow(peerB, 1, now))
+	require.False(t, limiter.Allow(peerB, 1, now))
+
+	// penalties outlast reconnections
+	limiter.Forget(peerA, now)
+	limiter.Forget(peerB, now)
+	require.True(t, limiter.Allow(peerA, 1, now))
+	require.False(t, limiter.Allow(peerB, 1, now.Add(time.Second)))
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	limiter := NewLimiter(0, 0, time.Minute)
+	for i := 0; i < 1000; i++ {
+		require.True(t, limiter.Allow(peerA, 1<<20, time.Unix(1000, 0)))
+	}
+	require.Empty(t, limiter.Counters())
+}
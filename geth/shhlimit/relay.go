@@ -0,0 +1,86 @@
+package shhlimit
+
+import (
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+// Relay decides which envelopes are sent to peers. In light mode, which is enabled in the node's config
+// or while the device is on a metered network, only envelopes posted by the node are sent, and envelopes
+// of others aren't relayed. It's a Whisper delivery server recording envelopes posted by the node, which
+// passes states of messages on to the delivery server it wraps.
+type Relay struct {
+	delivery whisper.DeliveryServer // wrapped delivery server, if any
+	light    bool                   // whether light mode is enabled in the node's config
+
+	mu      sync.Mutex
+	metered bool
+	own     map[gethcommon.Hash]uint32 // expiry of envelopes posted by the node by hash
+}
+
+// NewRelay returns a relay wrapping a delivery server, which may be nil, in light mode if light is true.
+func NewRelay(delivery whisper.DeliveryServer, light bool) *Relay {
+	return &Relay{
+		delivery: delivery,
+		light:    light,
+		own:      make(map[gethcommon.Hash]uint32),
+	}
+}
+
+// SetMetered enables light mode while the device is on a metered network, unless it's enabled in the config.
+func (r *Relay) SetMetered(metered bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metered = metered
+}
+
+// Light reports whether envelopes of others aren't relayed.
+func (r *Relay) Light() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.light || r.metered
+}
+
+// Record records an envelope posted by the node, so that it's sent to peers in light mode.
+func (r *Relay) Record(envelope *whisper.Envelope) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.own[envelope.Hash()] = envelope.Expiry
+}
+
+// SendState implements whisper.DeliveryServer, recording envelopes posted by the node.
+func (r *Relay) SendState(state whisper.MessageState) {
+	if state.Direction == gethmessage.OutgoingMessage && state.Status == gethmessage.SentStatus {
+		r.Record(&state.Envelope)
+	}
+
+	if r.delivery != nil {
+		r.delivery.SendState(state)
+	}
+}
+
+// Allow reports whether an envelope is sent to peers at a unix time, in seconds. Envelopes posted by the node
+// are forgotten once they expire.
+func (r *Relay) Allow(envelope *whisper.Envelope, now uint32) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, expiry := range r.own {
+		if expiry < now {
+			delete(r.own, hash)
+		}
+	}
+
+	if !r.light && !r.metered {
+		return true
+	}
+	_, ok := r.own[envelope.Hash()]
+
+	return ok
+}
@@ -0,0 +1,37 @@
+package shhlimit
+
+import (
+	"testing"
+
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelayLightMode(t *testing.T) {
+	relay := NewRelay(nil, false)
+
+	own := whisper.Envelope{Version: []byte{0}, Expiry: 1060, TTL: 60, Data: []byte{0x01}}
+	other := whisper.Envelope{Version: []byte{0}, Expiry: 1060, TTL: 60, Data: []byte{0x02}}
+	relay.SendState(whisper.MessageState{Direction: gethmessage.OutgoingMessage, Status: gethmessage.SentStatus, Envelope: own})
+	relay.SendState(whisper.MessageState{Direction: gethmessage.IncomingMessage, Status: gethmessage.SentStatus, Envelope: other})
+
+	require.False(t, relay.Light())
+	require.True(t, relay.Allow(&other, 1000))
+
+	// on a metered network only envelopes posted by the node are sent, until they expire
+	relay.SetMetered(true)
+	require.True(t, relay.Light())
+	require.True(t, relay.Allow(&own, 1000))
+	require.False(t, relay.Allow(&other, 1000))
+	require.False(t, relay.Allow(&own, 1061))
+
+	relay.SetMetered(false)
+	require.True(t, relay.Allow(&other, 1000))
+
+	// light mode enabled in the config isn't lifted by networks
+	relay = NewRelay(nil, true)
+	relay.SetMetered(false)
+	require.True(t, relay.Light())
+	require.False(t, relay.Allow(&other, 1000))
+}
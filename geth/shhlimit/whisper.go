@@ -0,0 +1,132 @@
+package shhlimit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/rlp"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+// messagesCode is the code of Whisper messages carrying envelopes broadcast by peers. Other messages,
+// e.g. envelopes delivered directly by a trusted mail server, aren't limited.
+const messagesCode = 1
+
+// Whisper is a Whisper service whose peers are limited by a limiter, and which sends peers envelopes
+// allowed by a relay. It's registered with the node in place of Whisper, which is available as its
+// embedded field.
+type Whisper struct {
+	*whisper.Whisper
+	limiter *Limiter
+	relay   *Relay
+	written func(peer discover.NodeID, hash gethcommon.Hash) // called with envelopes written to peers, if set
+}
+
+// New returns a Whisper service limiting its peers and relaying envelopes allowed by a relay.
+func New(shh *whisper.Whisper, limiter *Limiter, relay *Relay) *Whisper {
+	return &Whisper{Whisper: shh, limiter: limiter, relay: relay}
+}
+
+// SetWrittenHandler sets a handler called with envelopes written to peers, e.g. delivery.Tracker.Relayed.
+// It must be set before the service is started.
+func (w *Whisper) SetWrittenHandler(handler func(peer discover.NodeID, hash gethcommon.Hash)) {
+	w.written = handler
+}
+
+// Send sends an envelope created by the node, recording it with the relay. Services sending envelopes
+// other than with shh_post, which is recorded through the delivery server, must send them with it rather
+// than with the embedded Whisper, or they aren't sent to peers in light mode.
+func (w *Whisper) Send(envelope *whisper.Envelope) error {
+	w.relay.Record(envelope)
+
+	return w.Whisper.Send(envelope)
+}
+
+// Limiter returns the limiter of peers.
+func (w *Whisper) Limiter() *Limiter {
+	return w.limiter
+}
+
+// Relay returns the relay deciding which envelopes are sent to peers.
+func (w *Whisper) Relay() *Relay {
+	return w.relay
+}
+
+// Protocols implements node.Service, returning Whisper protocols which read messages of peers
+// through the limiter and send envelopes allowed by the relay.
+func (w *Whisper) Protocols() []p2p.Protocol {
+	protocols := w.Whisper.Protocols()
+	for i := range protocols {
+		run := protocols[i].Run
+		protocols[i].Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			id := peer.ID()
+			defer w.limiter.Forget(id, time.Now())
+
+			return run(peer, &limitedReadWriter{MsgReadWriter: rw, peer: id, limiter: w.limiter, relay: w.relay, written: w.written})
+		}
+	}
+
+	return protocols
+}
+
+// limitedReadWriter discards envelopes of a peer which are over its limits, and envelopes to the peer
+// which aren't relayed, reporting envelopes written to the peer
+type limitedReadWriter struct {
+	p2p.MsgReadWriter
+	peer    discover.NodeID
+	limiter *Limiter
+	relay   *Relay
+	written func(peer discover.NodeID, hash gethcommon.Hash)
+}
+
+// ReadMsg implements p2p.MsgReader, returning the next message which is within the peer's limits.
+func (rw *limitedReadWriter) ReadMsg() (p2p.Msg, error) {
+	for {
+		msg, err := rw.MsgReadWriter.ReadMsg()
+		if err != nil || msg.Code != messagesCode {
+			return msg, err
+		}
+
+		if rw.limiter.Allow(rw.peer, msg.Size, time.Now()) {
+			return msg, nil
+		}
+		if err := msg.Discard(); err != nil {
+			return msg, err
+		}
+	}
+}
+
+// WriteMsg implements p2p.MsgWriter, sending a message unless it's an envelope which isn't relayed.
+// A discarded envelope is reported as sent, so that the peer doesn't send it again. Envelopes written to
+// the peer are passed to the written handler.
+func (rw *limitedReadWriter) WriteMsg(msg p2p.Msg) error {
+	if msg.Code != messagesCode || (!rw.relay.Light() && rw.written == nil) {
+		return rw.MsgReadWriter.WriteMsg(msg)
+	}
+
+	payload, err := ioutil.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	var envelope whisper.Envelope
+	if err := rlp.DecodeBytes(payload, &envelope); err != nil {
+		return err
+	}
+	if !rw.relay.Allow(&envelope, uint32(time.Now().Unix())) {
+		return nil
+	}
+	msg.Payload = bytes.NewReader(payload)
+
+	if err := rw.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	if rw.written != nil {
+		rw.written(rw.peer, envelope.Hash())
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package shhlimit
+
+import (
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	gethmessage "github.com/ethereum/go-ethereum/common/message"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimitedReadWriter(t *testing.T) {
+	local, remote := p2p.MsgPipe()
+	defer local.Close()  // nolint: errcheck
+	defer remote.Close() // nolint: errcheck
+
+	limiter := NewLimiter(0.1, 0, time.Minute)
+	rw := &limitedReadWriter{MsgReadWriter: local, peer: peerA, limiter: limiter}
+
+	// the first envelope is within the burst, the second one is dropped, other messages aren't limited
+	go func() {
+		for _, code := range []uint64{messagesCode, messagesCode, messagesCode + 1} {
+			if err := p2p.Send(remote, code, []byte{byte(code)}); err != nil {
+				return
+			}
+		}
+	}()
+
+	msg, err := rw.ReadMsg()
+	require.NoError(t, err)
+	require.Equal(t, uint64(messagesCode), msg.Code)
+	require.NoError(t, msg.Discard())
+
+	msg, err = rw.ReadMsg()
+	require.NoError(t, err)
+	require.Equal(t, uint64(messagesCode+1), msg.Code)
+	require.NoError(t, msg.Discard())
+
+	require.Equal(t, int64(1), limiter.Counters()["droppedEnvelopes"])
+}
+
+func TestLimitedReadWriterRelaysOwnEnvelopes(t *testing.T) {
+	local, remote := p2p.MsgPipe()
+	defer local.Close()  // nolint: errcheck
+	defer remote.Close() // nolint: errcheck
+
+	relay := NewRelay(nil, true)
+	written := make(chan gethcommon.Hash, 3)
+	rw := &limitedReadWriter{MsgReadWriter: local, peer: peerA, limiter: NewLimiter(0, 0, 0), relay: relay,
+		written: func(peer discover.NodeID, hash gethcommon.Hash) {
+			require.Equal(t, peerA, peer)
+			written <- hash
+		}}
+
+	expiry := uint32(time.Now().Add(time.Minute).Unix())
+	own := &whisper.Envelope{Version: []byte{0}, Expiry: expiry, TTL: 60, Data: []byte{0x01}}
+	other := &whisper.Envelope{Version: []byte{0}, Expiry: expiry, TTL: 60, Data: []byte{0x02}}
+	relay.SendState(whisper.MessageState{Direction: gethmessage.OutgoingMessage, Status: gethmessage.SentStatus, Envelope: *own})
+
+	// envelopes of others are reported as sent, but only the node's own envelope and other messages reach the peer
+	go func() {
+		for _, msg := range []struct {
+			code uint64
+			data interface{}
+		}{{messagesCode, other}, {messagesCode, own}, {messagesCode + 1, []byte{0x03}}} {
+			if err := p2p.Send(rw, msg.code, msg.data); err != nil {
+				return
+			}
+		}
+	}()
+
+	msg, err := remote.ReadMsg()
+	require.NoError(t, err)
+	var received whisper.Envelope
+	require.NoError(t, msg.Decode(&received))
+	require.Equal(t, own.Hash(), received.Hash())
+
+	msg, err = remote.ReadMsg()
+	require.NoError(t, err)
+	require.Equal(t, uint64(messagesCode+1), msg.Code)
+	require.NoError(t, msg.Discard())
+
+	// only the envelope written to the peer is reported
+	require.Equal(t, own.Hash(), <-written)
+	require.Len(t, written, 0)
+}
+
+func TestLimitedReadWriterReportsWrittenEnvelopes(t *testing.T) {
+	local, remote := p2p.MsgPipe()
+	defer local.Close()  // nolint: errcheck
+	defer remote.Close() // nolint: errcheck
+
+	written := make(chan gethcommon.Hash, 2)
+	rw := &limitedReadWriter{MsgReadWriter: local, peer: peerA, limiter: NewLimiter(0, 0, 0), relay: NewRelay(nil, false),
+		written: func(peer discover.NodeID, hash gethcommon.Hash) {
+			written <- hash
+		}}
+
+	// envelopes are reported once the peer reads them, in full mode as well
+	envelope := &whisper.Envelope{Version: []byte{0}, Expiry: uint32(time.Now().Add(time.Minute).Unix()), TTL: 60}
+	sent := make(chan error, 1)
+	go func() {
+		sent <- p2p.Send(rw, messagesCode, envelope)
+	}()
+
+	msg, err := remote.ReadMsg()
+	require.NoError(t, err)
+	require.NoError(t, msg.Discard())
+	require.NoError(t, <-sent)
+	require.Equal(t, envelope.Hash(), <-written)
+}
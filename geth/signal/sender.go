@@ -0,0 +1,31 @@
+package signal
+
+import "sync"
+
+// Sender sends signals of a service to a handler, e.g. the signal handler of the node the service
+// runs on, so that signals of several nodes run in one process are marked with their names.
+// Signals are sent with Send until a handler is set. The zero value is ready to use.
+type Sender struct {
+	mu      sync.RWMutex
+	handler func(Envelope)
+}
+
+// SetHandler sets a handler of signals, or resets it to Send if nil.
+func (s *Sender) SetHandler(handler func(Envelope)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handler = handler
+}
+
+// Send sends a signal to the handler.
+func (s *Sender) Send(envelope Envelope) {
+	s.mu.RLock()
+	handler := s.handler
+	s.mu.RUnlock()
+
+	if handler == nil {
+		handler = Send
+	}
+	handler(envelope)
+}
@@ -13,6 +13,9 @@ import (
 )
 
 const (
+	// EventNodeStarting is triggered when underlying node is about to start
+	EventNodeStarting = "node.starting"
+
 	// EventNodeStarted is triggered when underlying node is started
 	EventNodeStarted = "node.started"
 
@@ -26,14 +29,36 @@ const (
 	// EventNodeCrashed is triggered when node crashes
 	EventNodeCrashed = "node.crashed"
 
+	// EventNodeRestarting is triggered when crashed node is going to be restarted
+	EventNodeRestarting = "node.restarting"
+
 	// EventChainDataRemoved is triggered when node's chain data is removed
 	EventChainDataRemoved = "chaindata.removed"
+
+	// EventSyncStarted is triggered when node starts synchronizing blocks
+	EventSyncStarted = "sync.started"
+
+	// EventSyncFinished is triggered when block synchronization is finished, successfully or not
+	EventSyncFinished = "sync.finished"
+
+	// EventChainHead is triggered when a new block becomes the head of the canonical chain
+	EventChainHead = "chain.head"
+
+	// EventPeersLost is triggered when node has had no peers for longer than configured timeout
+	EventPeersLost = "peers.lost"
+
+	// EventAccountDeleted is triggered when an account and its sub-accounts are deleted
+	EventAccountDeleted = "account.deleted"
 )
 
 // Envelope is a general signal sent upward from node to RN app
 type Envelope struct {
 	Type  string      `json:"type"`
 	Event interface{} `json:"event"`
+
+	// Node is a name of the node which sent the signal, if several nodes are run by a node.Registry,
+	// see node.NodeManager.SendSignal.
+	Node string `json:"node,omitempty"`
 }
 
 // NodeCrashEvent is special kind of error, used to report node crashes
@@ -41,6 +66,34 @@ type NodeCrashEvent struct {
 	Error string `json:"error"`
 }
 
+// NodeRestartingEvent reports a number of consecutive restart of a crashed node
+type NodeRestartingEvent struct {
+	Attempt int `json:"attempt"`
+}
+
+// SyncFinishedEvent reports an error if synchronization has failed
+type SyncFinishedEvent struct {
+	Error string `json:"error,omitempty"`
+}
+
+// ChainHeadEvent reports a new head of the canonical chain
+type ChainHeadEvent struct {
+	Number    uint64 `json:"number"`
+	Hash      string `json:"hash"`
+	Timestamp uint64 `json:"timestamp"`
+}
+
+// PeersLostEvent reports since when (unix time, in seconds) the node has no peers
+type PeersLostEvent struct {
+	Since int64 `json:"since"`
+}
+
+// AccountDeletedEvent reports addresses of a deleted account and its sub-accounts
+type AccountDeletedEvent struct {
+	Address     string   `json:"address"`
+	SubAccounts []string `json:"subAccounts"`
+}
+
 // NodeNotificationHandler defines a handler able to process incoming node events.
 // Events are encoded as JSON strings.
 type NodeNotificationHandler func(jsonEvent string)
@@ -0,0 +1,254 @@
+package topics
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rpc"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/log"
+)
+
+var (
+	// ErrInvalidContactKey is returned when a public key of a contact is malformed.
+	ErrInvalidContactKey = errors.New("invalid public key of a contact")
+
+	// ErrChatNotFound is returned when a chat which wasn't joined is left.
+	ErrChatNotFound = errors.New("chat not found")
+)
+
+// Chat is a one-to-one chat of a Whisper identity with a contact.
+type Chat struct {
+	Contact        hexutil.Bytes     `json:"contact"`        // public key of the contact
+	Topic          whisper.TopicType `json:"topic"`          // negotiated topic messages of the chat are sent on
+	SymKeyID       string            `json:"symKeyID"`       // ID of the symmetric key messages of the chat are encrypted with
+	FilterID       string            `json:"filterID"`       // ID of the filter receiving messages of the chat
+	DiscoveryTopic whisper.TopicType `json:"discoveryTopic"` // partitioned topic of the contact, to reach them before they join the chat
+}
+
+// chatKey identifies a chat of an identity with a contact
+type chatKey struct {
+	identity string
+	contact  string
+}
+
+// Manager registers Whisper filters of partitioned topics of identities and of negotiated topics of
+// their chats, and removes them when chats are left or the node stops. It's a node service exposing
+// shh_joinChat, shh_leaveChat and shh_discoveryFilter.
+type Manager struct {
+	shh *whisper.Whisper
+
+	mu        sync.Mutex
+	discovery map[string]string // IDs of filters of partitioned topics by identity
+	chats     map[chatKey]*Chat
+}
+
+// New returns a manager of topics of chats of identities known to a Whisper service.
+func New(shh *whisper.Whisper) *Manager {
+	return &Manager{
+		shh:       shh,
+		discovery: make(map[string]string),
+		chats:     make(map[chatKey]*Chat),
+	}
+}
+
+// Protocols implements node.Service, the manager has no protocols.
+func (m *Manager) Protocols() []p2p.Protocol {
+	return nil
+}
+
+// APIs implements node.Service, adding chat methods to the shh namespace.
+func (m *Manager) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "shh",
+			Version:   "1.0",
+			Service:   &PublicAPI{manager: m},
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service.
+func (m *Manager) Start(*p2p.Server) error {
+	return nil
+}
+
+// Stop implements node.Service, removing filters and symmetric keys of all chats.
+func (m *Manager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, chat := range m.chats {
+		m.removeChat(key, chat)
+	}
+	for identity, filterID := range m.discovery {
+		m.unsubscribe(filterID)
+		delete(m.discovery, identity)
+	}
+
+	return nil
+}
+
+// PruneIdentities removes filters and symmetric keys of chats of identities whose key pairs were removed
+// from Whisper, e.g. once their accounts are logged out.
+func (m *Manager) PruneIdentities() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, chat := range m.chats {
+		if !m.shh.HasKeyPair(key.identity) {
+			m.removeChat(key, chat)
+		}
+	}
+	for identity, filterID := range m.discovery {
+		if !m.shh.HasKeyPair(identity) {
+			m.unsubscribe(filterID)
+			delete(m.discovery, identity)
+		}
+	}
+}
+
+// DiscoveryFilter returns an ID of the filter receiving messages sent to an identity on its partitioned
+// topic, e.g. first messages of contacts, registering the filter if it isn't yet.
+func (m *Manager) DiscoveryFilter(identity string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.discoveryFilter(identity)
+}
+
+// JoinChat registers a filter of the negotiated topic of a chat of an identity with a contact, and a filter
+// of the identity's partitioned topic. A chat joined again is returned as it is.
+func (m *Manager) JoinChat(identity string, contact []byte) (*Chat, error) {
+	contactKey := crypto.ToECDSAPub(contact)
+	if !whisper.ValidatePublicKey(contactKey) {
+		return nil, ErrInvalidContactKey
+	}
+
+	privateKey, err := m.shh.GetPrivateKey(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := chatKey{identity: identity, contact: hexutil.Encode(contact)}
+	if chat, ok := m.chats[key]; ok {
+		return chat, nil
+	}
+
+	if _, err := m.discoveryFilter(identity); err != nil {
+		return nil, err
+	}
+
+	topic, symKey, err := NegotiatedTopic(privateKey, contactKey)
+	if err != nil {
+		return nil, err
+	}
+	symKeyID, err := m.shh.AddSymKeyDirect(symKey)
+	if err != nil {
+		return nil, err
+	}
+	filterID, err := m.shh.Subscribe(&whisper.Filter{
+		KeySym:   symKey,
+		Topics:   [][]byte{topic[:]},
+		AllowP2P: true, // messages of the chat delivered by a mail server
+	})
+	if err != nil {
+		m.shh.DeleteSymKey(symKeyID)
+		return nil, err
+	}
+
+	chat := &Chat{
+		Contact:        contact,
+		Topic:          topic,
+		SymKeyID:       symKeyID,
+		FilterID:       filterID,
+		DiscoveryTopic: PartitionedTopic(contactKey),
+	}
+	m.chats[key] = chat
+
+	log.Info("joined a chat", "topic", topic, "discoveryTopic", chat.DiscoveryTopic)
+
+	return chat, nil
+}
+
+// LeaveChat removes the filter and the symmetric key of a chat of an identity with a contact.
+// The filter of the identity's partitioned topic is kept, so that contacts can still reach it.
+func (m *Manager) LeaveChat(identity string, contact []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := chatKey{identity: identity, contact: hexutil.Encode(contact)}
+	chat, ok := m.chats[key]
+	if !ok {
+		return ErrChatNotFound
+	}
+	m.removeChat(key, chat)
+
+	return nil
+}
+
+// discoveryFilter returns an ID of the filter of an identity's partitioned topic, registering it if needed.
+func (m *Manager) discoveryFilter(identity string) (string, error) {
+	if filterID, ok := m.discovery[identity]; ok {
+		return filterID, nil
+	}
+
+	privateKey, err := m.shh.GetPrivateKey(identity)
+	if err != nil {
+		return "", err
+	}
+
+	topic := PartitionedTopic(&privateKey.PublicKey)
+	filterID, err := m.shh.Subscribe(&whisper.Filter{
+		KeyAsym:  privateKey,
+		Topics:   [][]byte{topic[:]},
+		AllowP2P: true,
+	})
+	if err != nil {
+		return "", err
+	}
+	m.discovery[identity] = filterID
+
+	return filterID, nil
+}
+
+// removeChat removes the filter and the symmetric key of a chat.
+func (m *Manager) removeChat(key chatKey, chat *Chat) {
+	m.unsubscribe(chat.FilterID)
+	m.shh.DeleteSymKey(chat.SymKeyID)
+	delete(m.chats, key)
+}
+
+// unsubscribe removes a filter, which may have been removed with shh_deleteMessageFilter already.
+func (m *Manager) unsubscribe(filterID string) {
+	if err := m.shh.Unsubscribe(filterID); err != nil {
+		log.Debug("failed to remove a filter", "filterID", filterID, "err", err)
+	}
+}
+
+// PublicAPI is the shh API of the manager.
+type PublicAPI struct {
+	manager *Manager
+}
+
+// JoinChat starts a chat of an identity with a contact, see Manager.JoinChat.
+func (api *PublicAPI) JoinChat(identity string, contact hexutil.Bytes) (*Chat, error) {
+	return api.manager.JoinChat(identity, contact)
+}
+
+// LeaveChat ends a chat of an identity with a contact, see Manager.LeaveChat.
+func (api *PublicAPI) LeaveChat(identity string, contact hexutil.Bytes) error {
+	return api.manager.LeaveChat(identity, contact)
+}
+
+// DiscoveryFilter returns an ID of the filter of an identity's partitioned topic, see Manager.DiscoveryFilter.
+func (api *PublicAPI) DiscoveryFilter(identity string) (string, error) {
+	return api.manager.DiscoveryFilter(identity)
+}
@@ -0,0 +1,130 @@
+package topics
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentity(t *testing.T, shh *whisper.Whisper) (string, []byte) {
+	identity, err := shh.NewKeyPair()
+	require.NoError(t, err)
+	key, err := shh.GetPrivateKey(identity)
+	require.NoError(t, err)
+
+	return identity, crypto.FromECDSAPub(&key.PublicKey)
+}
+
+func TestJoinChat(t *testing.T) {
+	shh := whisper.New(nil)
+	manager := New(shh)
+
+	alice, alicePub := newIdentity(t, shh)
+	bob, bobPub := newIdentity(t, shh)
+
+	aliceChat, err := manager.JoinChat(alice, bobPub)
+	require.NoError(t, err)
+	bobChat, err := manager.JoinChat(bob, alicePub)
+	require.NoError(t, err)
+
+	require.Equal(t, aliceChat.Topic, bobChat.Topic)
+	require.Equal(t, PartitionedTopic(crypto.ToECDSAPub(bobPub)), aliceChat.DiscoveryTopic)
+	require.NotEqual(t, aliceChat.FilterID, bobChat.FilterID)
+
+	// a chat joined again is the same chat
+	again, err := manager.JoinChat(alice, bobPub)
+	require.NoError(t, err)
+	require.Equal(t, aliceChat, again)
+
+	// messages encrypted with the chat's key on its topic are received by the contact's filter
+	symKey, err := shh.GetSymKey(aliceChat.SymKeyID)
+	require.NoError(t, err)
+	messageParams := &whisper.MessageParams{
+		TTL:      60,
+		KeySym:   symKey,
+		Topic:    aliceChat.Topic,
+		WorkTime: 1,
+		PoW:      whisper.DefaultMinimumPoW,
+		Payload:  []byte("hello"),
+	}
+	message, err := whisper.NewSentMessage(messageParams)
+	require.NoError(t, err)
+	envelope, err := message.Wrap(messageParams)
+	require.NoError(t, err)
+
+	filter := shh.GetFilter(bobChat.FilterID)
+	require.NotNil(t, filter)
+	require.True(t, filter.MatchEnvelope(envelope))
+	received := envelope.Open(filter)
+	require.NotNil(t, received)
+	require.Equal(t, []byte("hello"), received.Payload)
+
+	// identities are reachable on their partitioned topics
+	discoveryFilterID, err := manager.DiscoveryFilter(bob)
+	require.NoError(t, err)
+	discoveryFilter := shh.GetFilter(discoveryFilterID)
+	require.NotNil(t, discoveryFilter)
+	require.Equal(t, [][]byte{aliceChat.DiscoveryTopic[:]}, discoveryFilter.Topics)
+}
+
+func TestLeaveChat(t *testing.T) {
+	shh := whisper.New(nil)
+	manager := New(shh)
+
+	alice, _ := newIdentity(t, shh)
+	_, bobPub := newIdentity(t, shh)
+
+	chat, err := manager.JoinChat(alice, bobPub)
+	require.NoError(t, err)
+	discoveryFilterID, err := manager.DiscoveryFilter(alice)
+	require.NoError(t, err)
+
+	require.NoError(t, manager.LeaveChat(alice, bobPub))
+	require.Nil(t, shh.GetFilter(chat.FilterID))
+	require.False(t, shh.HasSymKey(chat.SymKeyID))
+	require.Equal(t, ErrChatNotFound, manager.LeaveChat(alice, bobPub))
+
+	// the identity stays reachable until the node stops
+	require.NotNil(t, shh.GetFilter(discoveryFilterID))
+	require.NoError(t, manager.Stop())
+	require.Nil(t, shh.GetFilter(discoveryFilterID))
+}
+
+func TestPruneIdentities(t *testing.T) {
+	shh := whisper.New(nil)
+	manager := New(shh)
+
+	alice, alicePub := newIdentity(t, shh)
+	bob, bobPub := newIdentity(t, shh)
+
+	aliceChat, err := manager.JoinChat(alice, bobPub)
+	require.NoError(t, err)
+	bobChat, err := manager.JoinChat(bob, alicePub)
+	require.NoError(t, err)
+
+	// chats of identities still known to Whisper are kept
+	manager.PruneIdentities()
+	require.NotNil(t, shh.GetFilter(aliceChat.FilterID))
+
+	require.True(t, shh.DeleteKeyPair(alice))
+	manager.PruneIdentities()
+	require.Nil(t, shh.GetFilter(aliceChat.FilterID))
+	require.False(t, shh.HasSymKey(aliceChat.SymKeyID))
+	require.Equal(t, ErrChatNotFound, manager.LeaveChat(alice, bobPub))
+	require.NotNil(t, shh.GetFilter(bobChat.FilterID))
+}
+
+func TestJoinChatInvalid(t *testing.T) {
+	shh := whisper.New(nil)
+	manager := New(shh)
+
+	alice, alicePub := newIdentity(t, shh)
+
+	_, err := manager.JoinChat(alice, []byte{0x04, 0x01})
+	require.Equal(t, ErrInvalidContactKey, err)
+
+	_, err = manager.JoinChat("unknown", alicePub)
+	require.Error(t, err)
+}
@@ -0,0 +1,47 @@
+// Package topics derives Whisper topics of one-to-one chats from keys of their participants, so that
+// messages of a chat don't share a topic with every other message. A contact is first reached on a
+// partitioned topic, one of NumPartitions derived from the contact's public key, and the chat then
+// moves to a topic negotiated by both participants with ECDH, which no one else can link to them.
+package topics
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/ecies"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+)
+
+// NumPartitions is how many partitioned topics public keys are spread over. More partitions save
+// bandwidth of peers listening on them, fewer hide which key a message on one of them is sent to.
+const NumPartitions = 5000
+
+const (
+	// discoveryTopicPrefix precedes a partition number in the name a partitioned topic is derived from
+	discoveryTopicPrefix = "contact-discovery-"
+
+	// sharedSecretLength is the length of a secret shared by participants of a chat, in bytes
+	sharedSecretLength = 32
+)
+
+// PartitionedTopic returns a topic of messages sent to a public key before a chat is negotiated.
+func PartitionedTopic(publicKey *ecdsa.PublicKey) whisper.TopicType {
+	partition := new(big.Int).Mod(publicKey.X, big.NewInt(NumPartitions))
+	return whisper.BytesToTopic(crypto.Keccak256([]byte(discoveryTopicPrefix + strconv.FormatInt(partition.Int64(), 10))))
+}
+
+// NegotiatedTopic returns a topic of a chat and a symmetric key its messages are encrypted with, both derived
+// from a secret shared by the participants with ECDH, so that either participant derives the same ones.
+func NegotiatedTopic(privateKey *ecdsa.PrivateKey, contact *ecdsa.PublicKey) (whisper.TopicType, []byte, error) {
+	secret, err := ecies.ImportECDSA(privateKey).GenerateShared(ecies.ImportECDSAPublic(contact), sharedSecretLength/2, sharedSecretLength/2)
+	if err != nil {
+		return whisper.TopicType{}, nil, err
+	}
+
+	symKey := crypto.Keccak256(secret)
+	topic := whisper.BytesToTopic(crypto.Keccak256(symKey))
+
+	return topic, symKey, nil
+}
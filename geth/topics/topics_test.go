@@ -0,0 +1,53 @@
+package topics
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPartitionedTopic(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	topic := PartitionedTopic(&key.PublicKey)
+	require.Equal(t, topic, PartitionedTopic(&key.PublicKey))
+
+	// keys are spread over a limited number of topics
+	seen := make(map[whisper.TopicType]struct{})
+	for i := 0; i < 100; i++ {
+		other, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		seen[PartitionedTopic(&other.PublicKey)] = struct{}{}
+	}
+	require.True(t, len(seen) <= NumPartitions)
+	require.True(t, len(seen) > 1)
+}
+
+func TestNegotiatedTopic(t *testing.T) {
+	alice, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	bob, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	eve, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	aliceTopic, aliceKey, err := NegotiatedTopic(alice, &bob.PublicKey)
+	require.NoError(t, err)
+	bobTopic, bobKey, err := NegotiatedTopic(bob, &alice.PublicKey)
+	require.NoError(t, err)
+
+	// both participants derive the same topic and key
+	require.Equal(t, aliceTopic, bobTopic)
+	require.Equal(t, aliceKey, bobKey)
+	require.Len(t, aliceKey, 32)
+	require.NotEqual(t, PartitionedTopic(&bob.PublicKey), aliceTopic)
+
+	// chats with other contacts have other topics
+	eveTopic, eveKey, err := NegotiatedTopic(alice, &eve.PublicKey)
+	require.NoError(t, err)
+	require.NotEqual(t, aliceTopic, eveTopic)
+	require.NotEqual(t, aliceKey, eveKey)
+}
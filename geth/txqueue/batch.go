@@ -0,0 +1,149 @@
+package txqueue
+
+import (
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventTransactionsCompleted is triggered when transactions approved together are completed
+const EventTransactionsCompleted = "transactions.completed"
+
+// CompleteTransactionsEvent is a signal with results of transactions approved together, in the order they were given
+type CompleteTransactionsEvent struct {
+	Results []CompletedTransaction `json:"results"`
+}
+
+// CompletedTransaction is a result of a transaction approved together with others
+type CompletedTransaction struct {
+	ID           string          `json:"id"`
+	Hash         gethcommon.Hash `json:"hash"`
+	ErrorMessage string          `json:"error_message,omitempty"`
+	ErrorCode    string          `json:"error_code"`
+}
+
+// CompleteTransactions sends transactions approved together, e.g. an approval of a token transfer followed by the
+// transfer, and returns their results by ID. Nothing is sent unless all transactions are queued and the password
+// unlocks all of their senders, otherwise they stay queued and the others fail with ErrQueuedTxBatchAborted.
+// Transactions are sent in the given order, and sending stops at the first one which fails, leaving the rest queued.
+// Results are signalled with a single transactions.completed signal as well.
+func (m *Manager) CompleteTransactions(ids []common.QueuedTxID, password string) map[common.QueuedTxID]common.RawCompleteTransactionResult {
+	log.Info("complete transactions", "ids", ids)
+
+	results := make(map[common.QueuedTxID]common.RawCompleteTransactionResult)
+	defer m.notifyTransactionsCompleted(ids, results)
+
+	var batch []*common.QueuedTx
+	for _, id := range ids {
+		if _, ok := results[id]; ok {
+			continue // given twice
+		}
+
+		queuedTx, err := m.txQueue.Get(id)
+		if err == nil {
+			err = m.txQueue.StartProcessing(queuedTx)
+		}
+		if err != nil {
+			log.Warn("could not complete a queued transaction", "id", id, "err", err)
+			results[id] = common.RawCompleteTransactionResult{Error: err}
+			continue
+		}
+		defer m.txQueue.StopProcessing(queuedTx)
+
+		batch = append(batch, queuedTx)
+		results[id] = common.RawCompleteTransactionResult{}
+	}
+	if len(batch) < len(results) {
+		abortTransactions(batch, results)
+		return results
+	}
+
+	for _, queuedTx := range batch {
+		if err := m.verifySender(queuedTx, password); err != nil {
+			log.Warn("could not complete a queued transaction", "id", queuedTx.ID, "err", err)
+			if err == keystore.ErrDecrypt || err == ErrInvalidCompleteTxSender {
+				m.NotifyOnQueuedTxReturn(queuedTx, err)
+			}
+			results[queuedTx.ID] = common.RawCompleteTransactionResult{Error: err}
+			abortTransactions(batch, results)
+			return results
+		}
+	}
+
+	for i, queuedTx := range batch {
+		hash, err := m.completeTransaction(queuedTx, password)
+		results[queuedTx.ID] = common.RawCompleteTransactionResult{Hash: hash, Error: err}
+		if err != nil {
+			abortTransactions(batch[i+1:], results)
+			break
+		}
+	}
+
+	return results
+}
+
+// verifySender checks that a transaction is meant for the node's network and can be completed with the password,
+// so that transactions approved together are not sent unless all of them can be.
+func (m *Manager) verifySender(queuedTx *common.QueuedTx, password string) error {
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return err
+	}
+	if err := checkChainID(queuedTx.Args, config.NetworkID); err != nil {
+		return err
+	}
+
+	if signer := m.txSigner(); signer != nil && signer.HasAccount(queuedTx.Args.From) {
+		return nil // confirmed on the signer's device
+	}
+
+	if _, err := m.accountManager.SelectedAccount(); err != nil {
+		return err
+	}
+	if _, err := m.accountManager.SessionAccount(queuedTx.Args.From); err != nil {
+		return ErrInvalidCompleteTxSender
+	}
+
+	_, err = m.accountManager.VerifyAccountPassword(config.KeyStoreDir, queuedTx.Args.From.Hex(), password)
+
+	return err
+}
+
+// abortTransactions sets ErrQueuedTxBatchAborted as a result of transactions which have no result yet.
+func abortTransactions(batch []*common.QueuedTx, results map[common.QueuedTxID]common.RawCompleteTransactionResult) {
+	for _, queuedTx := range batch {
+		if result := results[queuedTx.ID]; result.Error == nil && result.Hash == (gethcommon.Hash{}) {
+			results[queuedTx.ID] = common.RawCompleteTransactionResult{Error: ErrQueuedTxBatchAborted}
+		}
+	}
+}
+
+// notifyTransactionsCompleted sends a signal with results of transactions approved together.
+func (m *Manager) notifyTransactionsCompleted(ids []common.QueuedTxID, results map[common.QueuedTxID]common.RawCompleteTransactionResult) {
+	event := CompleteTransactionsEvent{Results: make([]CompletedTransaction, 0, len(results))}
+	notified := make(map[common.QueuedTxID]bool)
+	for _, id := range ids {
+		if notified[id] {
+			continue // given twice
+		}
+		notified[id] = true
+
+		result := results[id]
+		completed := CompletedTransaction{
+			ID:        string(id),
+			Hash:      result.Hash,
+			ErrorCode: m.sendTransactionErrorCode(result.Error),
+		}
+		if result.Error != nil {
+			completed.ErrorMessage = result.Error.Error()
+		}
+		event.Results = append(event.Results, completed)
+	}
+
+	m.signals.Send(signal.Envelope{
+		Type:  EventTransactionsCompleted,
+		Event: event,
+	})
+}
@@ -0,0 +1,59 @@
+package txqueue
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/les"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// networkChainID returns EIP155 chain ID of transactions of a network.
+func networkChainID(networkID uint64) *big.Int {
+	return new(big.Int).SetUint64(networkID)
+}
+
+// checkChainID validates a chain ID a transaction is meant for, if it's given, against the node's network,
+// so that a transaction approved for one network is never signed for another one.
+func checkChainID(args common.SendTxArgs, networkID uint64) error {
+	if args.ChainID != nil && args.ChainID.ToInt().Cmp(networkChainID(networkID)) != 0 {
+		return ErrInvalidChainID
+	}
+
+	return nil
+}
+
+// checkSignedTx validates that a signed transaction is replay protected for a chain. Transactions without
+// protection, e.g. signed by an external signer which doesn't support EIP155, pass only if they are allowed.
+func checkSignedTx(tx *types.Transaction, chainID *big.Int, allowUnprotected bool) error {
+	if !tx.Protected() {
+		if allowUnprotected {
+			return nil
+		}
+		return ErrUnprotectedTx
+	}
+	if tx.ChainId().Cmp(chainID) != 0 {
+		return ErrInvalidChainID
+	}
+
+	return nil
+}
+
+// checkLightChain validates that LES signs a transaction for the node's network with replay protection.
+// LES signs with the chain ID of its genesis, and without protection until EIP155 block is synced.
+func checkLightChain(lightEth *les.LightEthereum, args common.SendTxArgs, config *params.NodeConfig) error {
+	if err := checkChainID(args, config.NetworkID); err != nil {
+		return err
+	}
+
+	chainConfig := lightEth.ApiBackend.ChainConfig()
+	if chainConfig.ChainId == nil || chainConfig.ChainId.Cmp(networkChainID(config.NetworkID)) != 0 {
+		return ErrInvalidChainID
+	}
+	if !chainConfig.IsEIP155(lightEth.ApiBackend.CurrentBlock().Number()) && !config.TxQueueConfig.AllowUnprotectedTxs {
+		return ErrUnprotectedTx
+	}
+
+	return nil
+}
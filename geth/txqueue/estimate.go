@@ -0,0 +1,54 @@
+package txqueue
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// gasEstimateMargin is how many percent gas estimated by the node is increased by, as execution of
+// a transaction may cost more once it's mined than when it was estimated, e.g. after a state change
+const gasEstimateMargin = 20
+
+// estimateTransaction returns gas and fee of a transaction sent as it is now, with the normal gas price of
+// a suggestion, if any, for a transaction without gas price. Nil is returned if the node isn't available.
+func (m *Manager) estimateTransaction(ctx context.Context, args common.SendTxArgs, suggestion *common.GasPriceSuggestion) *common.GasEstimate {
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		return nil
+	}
+
+	estimate := &common.GasEstimate{GasPrice: args.GasPrice}
+	if estimate.GasPrice == nil && suggestion != nil {
+		estimate.GasPrice = suggestion.Normal
+	}
+	if estimate.GasPrice == nil {
+		gasPrice, err := m.gasPrice(ctx)
+		if err != nil {
+			log.Warn("failed to get gas price of a queued transaction", "err", err)
+		}
+		estimate.GasPrice = gasPrice
+	}
+
+	gas, err := m.estimateGas(ctx, client, args)
+	if err != nil {
+		estimate.Error = err.Error()
+		return estimate
+	}
+	estimate.Gas = gas
+
+	if estimate.GasPrice != nil {
+		estimate.Fee = (*hexutil.Big)(new(big.Int).Mul(gas.ToInt(), estimate.GasPrice.ToInt()))
+	}
+
+	return estimate
+}
+
+// withGasMargin returns gas increased by the safety margin of estimated gas.
+func withGasMargin(gas *hexutil.Big) *hexutil.Big {
+	increased := new(big.Int).Mul(gas.ToInt(), big.NewInt(100+gasEstimateMargin))
+	return (*hexutil.Big)(increased.Div(increased, big.NewInt(100)))
+}
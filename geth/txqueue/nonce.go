@@ -0,0 +1,114 @@
+package txqueue
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// nonceTooLowError is a part of an error returned by the node for a transaction with a nonce already used.
+const nonceTooLowError = "nonce too low"
+
+// nonceTracker assigns nonces to transactions of accounts. A pending transaction count requested from
+// the node lags behind transactions just sent to it, e.g. to a load balanced upstream node, so nonces of
+// transactions sent in quick succession are tracked locally, and assigned to one transaction at a time.
+type nonceTracker struct {
+	mu       sync.Mutex
+	accounts map[gethcommon.Address]*accountNonces
+}
+
+// accountNonces are nonces of transactions of an account sent, but maybe not counted by the node yet.
+type accountNonces struct {
+	sync.Mutex                            // serialises assignment of nonces to transactions of the account
+	txs        map[uint64]gethcommon.Hash // hashes of sent transactions by nonce
+}
+
+func newNonceTracker() *nonceTracker {
+	return &nonceTracker{
+		accounts: make(map[gethcommon.Address]*accountNonces),
+	}
+}
+
+// account returns nonces of an account, it must be locked while they are used.
+func (t *nonceTracker) account(address gethcommon.Address) *accountNonces {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nonces, ok := t.accounts[address]
+	if !ok {
+		nonces = &accountNonces{txs: make(map[uint64]gethcommon.Hash)}
+		t.accounts[address] = nonces
+	}
+
+	return nonces
+}
+
+// reset forgets transactions of an account, so that its next nonce is the pending transaction count of the node.
+func (t *nonceTracker) reset(address gethcommon.Address) {
+	nonces := t.account(address)
+	nonces.Lock()
+	defer nonces.Unlock()
+
+	nonces.txs = make(map[uint64]gethcommon.Hash)
+}
+
+// next returns a nonce of a new transaction of an account, which must be locked. It's the pending transaction
+// count of the node, unless transactions with that and following nonces were sent already. If any of them is
+// unknown to the node, e.g. it was dropped, there is a gap which is repaired by reusing its nonce, as otherwise
+// transactions after the gap would never be mined.
+func (n *accountNonces) next(ctx context.Context, client *rpc.Client, address gethcommon.Address) (uint64, error) {
+	var txCount hexutil.Uint
+	if err := client.CallContext(ctx, &txCount, "eth_getTransactionCount", address, "pending"); err != nil {
+		return 0, err
+	}
+	pending := uint64(txCount)
+
+	// transactions counted by the node don't need to be tracked anymore
+	for nonce := range n.txs {
+		if nonce < pending {
+			delete(n.txs, nonce)
+		}
+	}
+
+	nonce := pending
+	for hash, ok := n.txs[nonce]; ok; hash, ok = n.txs[nonce] {
+		if !transactionKnown(ctx, client, hash) {
+			log.Warn("nonce gap detected, reusing the nonce", "address", address.Hex(), "nonce", nonce, "dropped", hash.Hex())
+			break
+		}
+		nonce++
+	}
+
+	return nonce, nil
+}
+
+// sent records a transaction sent with a nonce.
+func (n *accountNonces) sent(nonce uint64, hash gethcommon.Hash) {
+	n.txs[nonce] = hash
+}
+
+// failed handles an error of sending a transaction with a nonce. The nonce is assigned again to the next
+// transaction, unless the node reports it's used already, in which case tracked nonces are out of date.
+func (n *accountNonces) failed(err error) {
+	if strings.Contains(err.Error(), nonceTooLowError) {
+		n.txs = make(map[uint64]gethcommon.Hash)
+	}
+}
+
+// transactionKnown reports whether the node has a transaction, pending or mined. A transaction is assumed
+// to be known if it can't be checked, so that its nonce is never reused by mistake.
+func transactionKnown(ctx context.Context, client *rpc.Client, hash gethcommon.Hash) bool {
+	var tx json.RawMessage
+	if err := client.CallContext(ctx, &tx, "eth_getTransactionByHash", hash); err != nil {
+		log.Warn("failed to check whether a transaction is known", "hash", hash.Hex(), "err", err)
+		return true
+	}
+
+	return len(tx) > 0 && string(tx) != "null"
+}
@@ -0,0 +1,152 @@
+package txqueue
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+)
+
+// QueuedTransactionsFile is a file in the node's data directory with transactions waiting for approval.
+const QueuedTransactionsFile = "queued-transactions.json"
+
+// persistedTx is a queued transaction as it's saved, without its context, which only keeps the message ID.
+type persistedTx struct {
+	ID        common.QueuedTxID     `json:"id"`
+	Args      common.SendTxArgs     `json:"args"`
+	MessageID string                `json:"message_id,omitempty"`
+	ChatID    string                `json:"chat_id,omitempty"`
+	Token     *common.TokenTransfer `json:"token,omitempty"`
+	Replaces  *gethcommon.Hash      `json:"replaces,omitempty"`
+	QueuedAt  time.Time             `json:"queued_at"`
+	Deadline  time.Time             `json:"deadline"`
+}
+
+// RestoreQueue puts transactions saved to a file back into the queue, signalling them as queued again
+// with the restored flag, and saves queued transactions to the file from now on, so that transactions
+// waiting for approval survive the application being killed. Restored transactions time out when they
+// would have timed out originally, expired ones are dropped with a failed signal. Transaction handlers
+// must be set before, or restored transactions are dropped.
+func (m *Manager) RestoreQueue(path string) error {
+	m.queueFileMu.Lock()
+	m.queueFile = path
+	m.queueFileMu.Unlock()
+
+	saved, err := loadQueuedTransactions(path)
+	if err != nil {
+		return err
+	}
+
+	for _, stored := range saved {
+		if m.txQueue.Has(stored.ID) {
+			continue // queued in this process already
+		}
+
+		ctx := context.Background()
+		if stored.MessageID != "" {
+			ctx = context.WithValue(ctx, common.MessageIDKey, stored.MessageID)
+		}
+		if stored.ChatID != "" {
+			ctx = context.WithValue(ctx, common.ChatIDKey, stored.ChatID)
+		}
+		tx := m.CreateTransaction(ctx, stored.Args)
+		tx.ID = stored.ID
+		tx.QueuedAt = stored.QueuedAt
+		tx.Deadline = stored.Deadline
+		tx.Restored = true
+		tx.Token = stored.Token
+		if stored.Replaces != nil {
+			tx.Replaces = *stored.Replaces
+		}
+
+		timeout := tx.Deadline.Sub(m.clock.Now())
+		if timeout <= 0 {
+			log.Info("restored transaction has expired", "id", tx.ID)
+			m.NotifyOnQueuedTxReturn(tx, ErrQueuedTxTimedOut)
+			continue
+		}
+
+		log.Info("restore queued transaction", "id", tx.ID, "timeout", timeout)
+		m.estimateQueuedTransaction(tx)
+		if err := m.txQueue.Enqueue(tx); err != nil {
+			return err
+		}
+		go m.waitForTransaction(tx, timeout) // nolint: errcheck
+	}
+
+	m.saveQueue()
+
+	return nil
+}
+
+// saveQueue writes queued transactions to the file set with RestoreQueue, if any. The file is replaced
+// at once, so that it's not left truncated if the application is killed while it's being written.
+// Errors are only logged, as the queue works without the file.
+func (m *Manager) saveQueue() {
+	m.queueFileMu.Lock()
+	defer m.queueFileMu.Unlock()
+
+	if m.queueFile == "" {
+		return
+	}
+
+	queued := m.txQueue.transactionsList()
+	saved := make([]persistedTx, 0, len(queued))
+	for _, tx := range queued {
+		stored := persistedTx{
+			ID:        tx.ID,
+			Args:      tx.Args,
+			MessageID: common.MessageIDFromContext(tx.Context),
+			ChatID:    tx.ChatID,
+			Token:     tx.Token,
+			QueuedAt:  tx.QueuedAt,
+			Deadline:  tx.Deadline,
+		}
+		if tx.Replaces != (gethcommon.Hash{}) {
+			replaces := tx.Replaces
+			stored.Replaces = &replaces
+		}
+		saved = append(saved, stored)
+	}
+
+	data, err := json.Marshal(saved)
+	if err == nil {
+		err = writeFileAtomically(m.queueFile, data)
+	}
+	if err != nil {
+		log.Warn("failed to save queued transactions", "file", m.queueFile, "err", err)
+	}
+}
+
+// writeFileAtomically writes data to a temporary file next to a file, and renames it to the file.
+func writeFileAtomically(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// loadQueuedTransactions reads queued transactions from a file, a missing file means there are none.
+func loadQueuedTransactions(path string) ([]persistedTx, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved []persistedTx
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
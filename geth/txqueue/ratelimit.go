@@ -0,0 +1,124 @@
+package txqueue
+
+import (
+	"sync"
+	"time"
+
+	"github.com/status-im/status-go/geth/signal"
+)
+
+// EventTransactionsThrottled is triggered when transactions requested by a DApp are rejected over its rate limit
+const EventTransactionsThrottled = "transactions.throttled"
+
+// throttleSignalWindow is how often a DApp over its rate limit is signalled at most, so that the signal
+// doesn't spam the user in place of approval prompts
+const throttleSignalWindow = time.Minute
+
+// ThrottleTransactionsEvent is a signal sent when transactions requested by a DApp are rejected over its rate limit
+type ThrottleTransactionsEvent struct {
+	ChatID    string  `json:"chat_id"`
+	RateLimit float64 `json:"rate_limit"` // transactions per second
+	RateBurst int     `json:"rate_burst"`
+}
+
+// rateBucket is a token bucket of an origin of transactions
+type rateBucket struct {
+	tokens    float64
+	updated   time.Time
+	signalled time.Time // when the origin was signalled as throttled, zero if never
+}
+
+// rateLimiter limits how many transactions each origin, e.g. a DApp, can request,
+// with a token bucket per origin refilled at the rate up to the burst.
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // tokens per second, zero means no limit
+	burst   int
+	buckets map[string]*rateBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*rateBucket)}
+}
+
+// configure sets the rate and the burst. Buckets are refilled, so that origins throttled with old limits are not.
+func (l *rateLimiter) configure(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = rate
+	l.burst = burst
+	l.buckets = make(map[string]*rateBucket)
+}
+
+// allow takes a token of an origin. It reports whether the request is allowed, and whether it's the first
+// one rejected within the signal window, so that throttling of the origin should be signalled.
+func (l *rateLimiter) allow(origin string, now time.Time) (allowed, notify bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 || l.burst <= 0 {
+		return true, false
+	}
+
+	bucket, ok := l.buckets[origin]
+	if !ok {
+		bucket = &rateBucket{tokens: float64(l.burst), updated: now}
+		l.buckets[origin] = bucket
+	}
+
+	if elapsed := now.Sub(bucket.updated); elapsed > 0 {
+		bucket.tokens += elapsed.Seconds() * l.rate
+		if bucket.tokens > float64(l.burst) {
+			bucket.tokens = float64(l.burst)
+		}
+		bucket.updated = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, false
+	}
+
+	notify = bucket.signalled.IsZero() || now.Sub(bucket.signalled) >= throttleSignalWindow
+	if notify {
+		bucket.signalled = now
+	}
+
+	return false, notify
+}
+
+// limits returns the rate and the burst.
+func (l *rateLimiter) limits() (float64, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.rate, l.burst
+}
+
+// checkRateLimit rejects a transaction requested by a DApp over its rate limit with ErrTxRateLimited.
+// Transactions which aren't requested by DApps, e.g. by the user, are not limited.
+func (m *Manager) checkRateLimit(chatID string) error {
+	if chatID == "" {
+		return nil
+	}
+
+	allowed, notify := m.rateLimiter.allow(chatID, m.clock.Now())
+	if allowed {
+		return nil
+	}
+
+	if notify {
+		rate, burst := m.rateLimiter.limits()
+		m.signals.Send(signal.Envelope{
+			Type: EventTransactionsThrottled,
+			Event: ThrottleTransactionsEvent{
+				ChatID:    chatID,
+				RateLimit: rate,
+				RateBurst: burst,
+			},
+		})
+	}
+
+	return ErrTxRateLimited
+}
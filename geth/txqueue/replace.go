@@ -0,0 +1,164 @@
+package txqueue
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+const (
+	// replacementGasPriceBump is how many percent higher gas price of a replacement transaction must be,
+	// as the node's transaction pool rejects replacements with lower gas prices
+	replacementGasPriceBump = 10
+
+	// cancelGas is gas of a transaction cancelling another one, which is a transfer of nothing to the sender
+	cancelGas = 21000
+)
+
+// ReplaceTransactionEvent is a signal sent when a pending transaction is replaced by a faster or a cancelling one
+type ReplaceTransactionEvent struct {
+	ID      string          `json:"id"`
+	OldHash gethcommon.Hash `json:"old_hash"`
+	NewHash gethcommon.Hash `json:"new_hash"`
+	Cancel  bool            `json:"cancel"`
+}
+
+// rpcTransaction is a transaction as returned by eth_getTransactionByHash
+type rpcTransaction struct {
+	From        gethcommon.Address  `json:"from"`
+	To          *gethcommon.Address `json:"to"`
+	Gas         *hexutil.Big        `json:"gas"`
+	GasPrice    *hexutil.Big        `json:"gasPrice"`
+	Value       *hexutil.Big        `json:"value"`
+	Input       hexutil.Bytes       `json:"input"`
+	Nonce       hexutil.Uint64      `json:"nonce"`
+	BlockNumber *hexutil.Big        `json:"blockNumber"`
+}
+
+// SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price, which must be
+// at least 10% higher than the original one. The replacement is queued and approved as any other transaction,
+// and the hash of the sent replacement is returned.
+func (m *Manager) SpeedUpTransaction(ctx context.Context, hash gethcommon.Hash, gasPrice *big.Int) (gethcommon.Hash, error) {
+	tx, err := m.pendingTransaction(ctx, hash)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	if gasPrice == nil || gasPrice.Cmp(minReplacementGasPrice(tx.GasPrice.ToInt())) < 0 {
+		return gethcommon.Hash{}, ErrReplacementGasPriceTooLow
+	}
+
+	nonce := tx.Nonce
+	args := common.SendTxArgs{
+		From:     tx.From,
+		To:       tx.To,
+		Gas:      tx.Gas,
+		GasPrice: (*hexutil.Big)(gasPrice),
+		Value:    tx.Value,
+		Data:     tx.Input,
+		Nonce:    &nonce,
+	}
+
+	return m.replaceTransaction(ctx, hash, args, false)
+}
+
+// CancelTransaction replaces a pending transaction with a transfer of nothing from the sender to itself,
+// paying the minimal replacement gas price or the suggested one, whichever is higher. The replacement is
+// queued and approved as any other transaction, and the hash of the sent replacement is returned.
+func (m *Manager) CancelTransaction(ctx context.Context, hash gethcommon.Hash) (gethcommon.Hash, error) {
+	tx, err := m.pendingTransaction(ctx, hash)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	priceCtx, cancel := context.WithTimeout(ctx, cancelTimeout)
+	defer cancel()
+
+	gasPrice := minReplacementGasPrice(tx.GasPrice.ToInt())
+	if suggested, err := m.gasPrice(priceCtx); err == nil && suggested.ToInt().Cmp(gasPrice) > 0 {
+		gasPrice = suggested.ToInt()
+	}
+
+	to := tx.From
+	nonce := tx.Nonce
+	args := common.SendTxArgs{
+		From:     tx.From,
+		To:       &to,
+		Gas:      (*hexutil.Big)(big.NewInt(cancelGas)),
+		GasPrice: (*hexutil.Big)(gasPrice),
+		Value:    (*hexutil.Big)(new(big.Int)),
+		Nonce:    &nonce,
+	}
+
+	return m.replaceTransaction(ctx, hash, args, true)
+}
+
+// replaceTransaction queues a replacement of a pending transaction, waits until it's sent and signals
+// that the transaction was replaced.
+func (m *Manager) replaceTransaction(ctx context.Context, hash gethcommon.Hash, args common.SendTxArgs, cancel bool) (gethcommon.Hash, error) {
+	tx := m.CreateTransaction(ctx, args)
+	tx.Replaces = hash
+
+	log.Info("replace transaction", "id", tx.ID, "hash", hash.Hex(), "cancel", cancel)
+
+	if err := m.QueueTransaction(tx); err != nil {
+		return gethcommon.Hash{}, err
+	}
+	if err := m.WaitForTransaction(tx); err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	m.signals.Send(signal.Envelope{
+		Type: EventTransactionReplaced,
+		Event: ReplaceTransactionEvent{
+			ID:      string(tx.ID),
+			OldHash: hash,
+			NewHash: tx.Hash,
+			Cancel:  cancel,
+		},
+	})
+
+	return tx.Hash, nil
+}
+
+// pendingTransaction requests a transaction from the node, which must not be mined yet.
+func (m *Manager) pendingTransaction(ctx context.Context, hash gethcommon.Hash) (*rpcTransaction, error) {
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		return nil, ErrNoRPCClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cancelTimeout)
+	defer cancel()
+
+	var raw json.RawMessage
+	if err := client.CallContext(ctx, &raw, "eth_getTransactionByHash", hash); err != nil {
+		return nil, err
+	}
+
+	var tx *rpcTransaction
+	if err := json.Unmarshal(raw, &tx); err != nil {
+		return nil, err
+	}
+	if tx == nil || tx.GasPrice == nil {
+		return nil, ErrTxNotFound
+	}
+	if tx.BlockNumber != nil {
+		return nil, ErrTxAlreadyMined
+	}
+
+	return tx, nil
+}
+
+// minReplacementGasPrice returns the lowest gas price the node accepts for a replacement of a transaction.
+func minReplacementGasPrice(gasPrice *big.Int) *big.Int {
+	price := new(big.Int).Mul(gasPrice, big.NewInt(100+replacementGasPriceBump))
+	price.Add(price, big.NewInt(99)) // round up
+	return price.Div(price, big.NewInt(100))
+}
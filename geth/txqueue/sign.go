@@ -0,0 +1,143 @@
+package txqueue
+
+import (
+	"context"
+	"math/big"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// SignTransaction signs a transaction with the key of its sender decrypted with the password, or with the signer
+// holding the sender's account, without sending it, e.g. to send it later or through another relay. Nonce, gas
+// and gas price are requested from the node unless they are given, as when a queued transaction is sent.
+// The nonce isn't reserved until the transaction is sent with BroadcastRawTransaction, so a transaction sent
+// from the account meanwhile gets the same nonce. The signed transaction and its hash are returned.
+func (m *Manager) SignTransaction(args common.SendTxArgs, password string) (hexutil.Bytes, gethcommon.Hash, error) {
+	log.Info("sign transaction", "from", args.From.Hex())
+
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return nil, gethcommon.Hash{}, err
+	}
+
+	sign, err := m.accountSignFunc(args.From, config, password)
+	if err != nil {
+		return nil, gethcommon.Hash{}, err
+	}
+
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		return nil, gethcommon.Hash{}, ErrNoRPCClient
+	}
+
+	nonces := m.nonces.account(args.From)
+	nonces.Lock()
+	defer nonces.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel()
+
+	signedTx, err := m.signTransaction(ctx, client, nonces, args, config, sign)
+	if err != nil {
+		return nil, gethcommon.Hash{}, err
+	}
+
+	raw, err := rlp.EncodeToBytes(signedTx)
+	if err != nil {
+		return nil, gethcommon.Hash{}, err
+	}
+
+	return raw, signedTx.Hash(), nil
+}
+
+// BroadcastRawTransaction sends a signed transaction, e.g. one signed with SignTransaction, to the node.
+// The transaction must be signed for the node's network, and its nonce is tracked as if it was sent from
+// the queue. A sent transaction is handed over to the watcher, if any.
+func (m *Manager) BroadcastRawTransaction(ctx context.Context, raw hexutil.Bytes) (gethcommon.Hash, error) {
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(raw, tx); err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	chainID := networkChainID(config.NetworkID)
+	if err := checkSignedTx(tx, chainID, config.TxQueueConfig.AllowUnprotectedTxs); err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	from, err := transactionSender(tx, chainID)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		return gethcommon.Hash{}, ErrNoRPCClient
+	}
+
+	log.Info("broadcast raw transaction", "from", from.Hex(), "hash", tx.Hash().Hex())
+
+	nonces := m.nonces.account(from)
+	nonces.Lock()
+	defer nonces.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, cancelTimeout)
+	defer cancel()
+
+	if err := client.CallContext(ctx, nil, "eth_sendRawTransaction", gethcommon.ToHex(raw)); err != nil {
+		nonces.failed(err)
+		return gethcommon.Hash{}, err
+	}
+	nonces.sent(tx.Nonce(), tx.Hash())
+
+	if watcher := m.getTxWatcher(); watcher != nil {
+		watcher.Watch(tx.Hash(), from)
+	}
+
+	return tx.Hash(), nil
+}
+
+// accountSignFunc returns a function signing transactions of an account with the signer set with SetTxSigner,
+// if it holds the account, or with the key of the account, which must be unlocked, decrypted with the password.
+func (m *Manager) accountSignFunc(from gethcommon.Address, config *params.NodeConfig, password string) (signFunc, error) {
+	if signer := m.txSigner(); signer != nil && signer.HasAccount(from) {
+		return func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+			return signer.SignTx(from, tx, chainID)
+		}, nil
+	}
+
+	if _, err := m.accountManager.SelectedAccount(); err != nil {
+		return nil, err
+	}
+	account, err := m.accountManager.SessionAccount(from)
+	if err != nil {
+		return nil, ErrInvalidCompleteTxSender
+	}
+	if _, err := m.accountManager.VerifyAccountPassword(config.KeyStoreDir, from.Hex(), password); err != nil {
+		return nil, err
+	}
+
+	return func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), account.AccountKey.PrivateKey)
+	}, nil
+}
+
+// transactionSender recovers the sender of a signed transaction, which is replay protected for a chain unless
+// unprotected transactions are allowed.
+func transactionSender(tx *types.Transaction, chainID *big.Int) (gethcommon.Address, error) {
+	if tx.Protected() {
+		return types.Sender(types.NewEIP155Signer(chainID), tx)
+	}
+
+	return types.Sender(types.HomesteadSigner{}, tx)
+}
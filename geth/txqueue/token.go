@@ -0,0 +1,137 @@
+package txqueue
+
+import (
+	"context"
+	"math/big"
+	"strings"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/abi"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/rpc"
+)
+
+// QueueTokenTransfer queues a transfer of an amount of ERC20 tokens, in their smallest units, from the selected
+// account to an address. Gas of the transfer is estimated, and its queued signal previews the transfer with the
+// token's symbol and the amount adjusted by the token's decimals, if the token provides them. The transfer is
+// approved as any other transaction, and the hash of the sent transaction is returned.
+func (m *Manager) QueueTokenTransfer(ctx context.Context, token, to gethcommon.Address, amount *big.Int) (gethcommon.Hash, error) {
+	if amount == nil || amount.Sign() < 0 {
+		return gethcommon.Hash{}, ErrInvalidTokenAmount
+	}
+
+	account, err := m.accountManager.SelectedAccount()
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	client := m.nodeManager.RPCClient()
+	if client == nil {
+		return gethcommon.Hash{}, ErrNoRPCClient
+	}
+
+	data, err := abi.PackTransfer(to, amount)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	args := common.SendTxArgs{
+		From:  account.Address,
+		To:    &token,
+		Value: (*hexutil.Big)(new(big.Int)),
+		Data:  data,
+	}
+
+	estimateCtx, cancel := context.WithTimeout(ctx, gasEstimationTimeout)
+	defer cancel()
+
+	// a transfer which would fail, e.g. of more tokens than the account holds, is rejected by the estimation
+	args.Gas, err = m.estimateGas(estimateCtx, client, args)
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	tx := m.CreateTransaction(ctx, args)
+	tx.Token = m.tokenTransfer(estimateCtx, client, token, to, amount)
+
+	log.Info("queue token transfer", "id", tx.ID, "token", token.Hex(), "to", to.Hex(), "amount", amount)
+
+	if err := m.QueueTransaction(tx); err != nil {
+		return gethcommon.Hash{}, err
+	}
+	if err := m.WaitForTransaction(tx); err != nil {
+		return gethcommon.Hash{}, err
+	}
+
+	return tx.Hash, nil
+}
+
+// tokenTransfer returns a preview of a transfer of tokens. Symbol and decimals are optional in ERC20,
+// so they are left out of the preview if the token doesn't provide them.
+func (m *Manager) tokenTransfer(ctx context.Context, client *rpc.Client, token, to gethcommon.Address, amount *big.Int) *common.TokenTransfer {
+	transfer := &common.TokenTransfer{
+		Token:  token,
+		To:     to,
+		Amount: (*hexutil.Big)(amount),
+	}
+
+	if output, err := callContract(ctx, client, token, abi.PackSymbol()); err == nil {
+		if symbol, err := abi.UnpackSymbol(output); err == nil {
+			transfer.Symbol = symbol
+		} else {
+			log.Warn("failed to unpack token symbol", "token", token.Hex(), "err", err)
+		}
+	}
+
+	if output, err := callContract(ctx, client, token, abi.PackDecimals()); err == nil {
+		if decimals, err := abi.UnpackDecimals(output); err == nil {
+			transfer.Decimals = &decimals
+			transfer.Formatted = formatTokenAmount(amount, decimals)
+		} else {
+			log.Warn("failed to unpack token decimals", "token", token.Hex(), "err", err)
+		}
+	}
+
+	return transfer
+}
+
+// callContract calls a constant method of a contract at the latest block.
+func callContract(ctx context.Context, client *rpc.Client, contract gethcommon.Address, data []byte) (hexutil.Bytes, error) {
+	params := struct {
+		To   gethcommon.Address `json:"to"`
+		Data hexutil.Bytes      `json:"data"`
+	}{
+		To:   contract,
+		Data: data,
+	}
+
+	var output hexutil.Bytes
+	if err := client.CallContext(ctx, &output, "eth_call", params, "latest"); err != nil {
+		log.Warn("failed to call contract", "contract", contract.Hex(), "err", err)
+		return nil, err
+	}
+
+	return output, nil
+}
+
+// formatTokenAmount formats an amount of tokens in their smallest units as a decimal number of whole tokens,
+// without trailing zeros of the fraction, e.g. 1500000000000000000 with 18 decimals as 1.5.
+func formatTokenAmount(amount *big.Int, decimals uint8) string {
+	digits := amount.String()
+	if decimals == 0 {
+		return digits
+	}
+
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	whole, fraction := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if fraction == "" {
+		return whole
+	}
+
+	return whole + "." + fraction
+}
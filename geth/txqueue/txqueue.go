@@ -34,12 +34,38 @@ var (
 	ErrQueuedTxAlreadyProcessed = errors.New("transaction has been already processed")
 	//ErrInvalidCompleteTxSender - error transaction with invalid sender
 	ErrInvalidCompleteTxSender = errors.New("transaction can only be completed by the same account which created it")
+	//ErrTxQueueDraining - error transaction queue doesn't accept new transactions
+	ErrTxQueueDraining = errors.New("transaction queue is being drained and doesn't accept new transactions")
+	//ErrQueuedTxEvicted - error transaction evicted by a newer one from the full queue
+	ErrQueuedTxEvicted = errors.New("transaction has been evicted from the full queue")
+	//ErrQueuedTxBatchAborted - error transaction not sent, as another transaction approved together with it failed
+	ErrQueuedTxBatchAborted = errors.New("transaction has not been sent, as another transaction of the batch failed")
+	//ErrWatchOnlyAccount - error transaction sent from a watch-only account
+	ErrWatchOnlyAccount = errors.New("transaction can't be sent from a watch-only account")
+	//ErrTxNotFound - error transaction to be replaced is unknown to the node
+	ErrTxNotFound = errors.New("transaction not found")
+	//ErrTxAlreadyMined - error transaction to be replaced is mined already
+	ErrTxAlreadyMined = errors.New("transaction has been already mined")
+	//ErrReplacementGasPriceTooLow - error gas price of a replacement transaction isn't high enough
+	ErrReplacementGasPriceTooLow = errors.New("gas price of a replacement transaction must be at least 10% higher")
+	//ErrInvalidChainID - error transaction is meant for, or signed for, another chain than the node's network
+	ErrInvalidChainID = errors.New("transaction chain ID doesn't match the network ID")
+	//ErrUnprotectedTx - error transaction signature isn't replay protected with EIP155
+	ErrUnprotectedTx = errors.New("transaction isn't replay protected with EIP155, and unprotected transactions aren't allowed")
+	//ErrTxRateLimited - error DApp requested more transactions than its rate limit allows
+	ErrTxRateLimited = errors.New("too many transactions requested, try again later")
+	//ErrNoRPCClient - error RPC client of the node isn't available, e.g. the node isn't running
+	ErrNoRPCClient = errors.New("RPC client is not available")
+	//ErrInvalidTokenAmount - error amount of tokens to transfer is missing or negative
+	ErrInvalidTokenAmount = errors.New("amount of tokens is missing or negative")
 )
 
 // TxQueue is capped container that holds pending transactions
 type TxQueue struct {
 	transactions  map[common.QueuedTxID]*common.QueuedTx
-	mu            sync.RWMutex // to guard transactions map
+	mu            sync.RWMutex // to guard transactions map and draining flag
+	draining      bool         // when set, new transactions are rejected
+	capacity      int          // how many transactions can be queued, applied on start
 	evictableIDs  chan common.QueuedTxID
 	enqueueTicker chan struct{}
 	incomingPool  chan *common.QueuedTx
@@ -60,6 +86,7 @@ func NewTransactionQueue() *TxQueue {
 	log.Info("initializing transaction queue")
 	return &TxQueue{
 		transactions:  make(map[common.QueuedTxID]*common.QueuedTx),
+		capacity:      DefaultTxQueueCap,
 		evictableIDs:  make(chan common.QueuedTxID, DefaultTxQueueCap), // will be used to evict in FIFO
 		enqueueTicker: make(chan struct{}),
 		incomingPool:  make(chan *common.QueuedTx, DefaultTxSendQueueCap),
@@ -70,10 +97,16 @@ func NewTransactionQueue() *TxQueue {
 func (q *TxQueue) Start() {
 	log.Info("starting transaction queue")
 
+	q.mu.Lock()
+	q.draining = false
+	q.mu.Unlock()
+
 	if q.stopped != nil {
 		return
 	}
 
+	q.resize()
+
 	q.stopped = make(chan struct{})
 	q.stoppedGroup.Add(2)
 
@@ -96,12 +129,58 @@ func (q *TxQueue) Stop() {
 	log.Info("finally stopped transaction queue")
 }
 
+// SetCapacity sets how many transactions can be queued. It's applied when the queue is started,
+// evicting the oldest transactions if more are queued already.
+func (q *TxQueue) SetCapacity(capacity int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.capacity = capacity
+}
+
+// resize makes room for as many evictable IDs as the queue's capacity, keeping IDs of queued transactions.
+// It must be called while eviction and enqueue loops are stopped.
+func (q *TxQueue) resize() {
+	q.mu.RLock()
+	capacity := q.capacity
+	q.mu.RUnlock()
+
+	if cap(q.evictableIDs) == capacity {
+		return
+	}
+
+	var ids []common.QueuedTxID
+	for len(q.evictableIDs) > 0 {
+		if id := <-q.evictableIDs; q.Has(id) {
+			ids = append(ids, id)
+		}
+	}
+	for len(ids) > capacity {
+		q.evict(ids[0])
+		ids = ids[1:]
+	}
+
+	q.evictableIDs = make(chan common.QueuedTxID, capacity)
+	for _, id := range ids {
+		q.evictableIDs <- id
+	}
+}
+
+// StopAccepting makes the queue reject new transactions until it's started again.
+// Already queued transactions can still be completed or discarded.
+func (q *TxQueue) StopAccepting() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.draining = true
+}
+
 // evictionLoop frees up queue to accommodate another transaction item
 func (q *TxQueue) evictionLoop() {
 	defer HaltOnPanic()
 	evict := func() {
-		if len(q.transactions) >= DefaultTxQueueCap { // eviction is required to accommodate another/last item
-			q.Remove(<-q.evictableIDs)
+		if q.Count() >= cap(q.evictableIDs) { // eviction is required to accommodate another/last item
+			q.evict(<-q.evictableIDs)
 		}
 	}
 
@@ -145,7 +224,7 @@ func (q *TxQueue) Reset() {
 	defer q.mu.Unlock()
 
 	q.transactions = make(map[common.QueuedTxID]*common.QueuedTx)
-	q.evictableIDs = make(chan common.QueuedTxID, DefaultTxQueueCap)
+	q.evictableIDs = make(chan common.QueuedTxID, q.capacity)
 }
 
 // EnqueueAsync enqueues incoming transaction in async manner, returns as soon as possible
@@ -159,6 +238,13 @@ func (q *TxQueue) EnqueueAsync(tx *common.QueuedTx) error {
 func (q *TxQueue) Enqueue(tx *common.QueuedTx) error {
 	log.Info(fmt.Sprintf("enqueue transaction: %s", tx.ID))
 
+	q.mu.RLock()
+	draining := q.draining
+	q.mu.RUnlock()
+	if draining {
+		return ErrTxQueueDraining
+	}
+
 	if q.txEnqueueHandler == nil { //discard, until handler is provided
 		log.Info("there is no txEnqueueHandler")
 		return nil
@@ -167,7 +253,7 @@ func (q *TxQueue) Enqueue(tx *common.QueuedTx) error {
 	log.Info("before enqueueTicker")
 	q.enqueueTicker <- struct{}{} // notify eviction loop that we are trying to insert new item
 	log.Info("before evictableIDs")
-	q.evictableIDs <- tx.ID // this will block when we hit DefaultTxQueueCap
+	q.evictableIDs <- tx.ID // this will block when we hit the capacity
 	log.Info("after evictableIDs")
 
 	q.mu.Lock()
@@ -201,6 +287,25 @@ func (q *TxQueue) Remove(id common.QueuedTxID) {
 	delete(q.transactions, id)
 }
 
+// evict removes a transaction to make room for a newer one, and lets its sender know it was evicted.
+// Transactions which are not queued anymore are ignored.
+func (q *TxQueue) evict(id common.QueuedTxID) {
+	q.mu.Lock()
+	tx, ok := q.transactions[id]
+	if ok {
+		delete(q.transactions, id)
+		tx.Err = ErrQueuedTxEvicted
+	}
+	q.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	log.Info("evict transaction from the full queue", "id", id)
+	tx.Discard <- struct{}{} // lets WaitForTransaction return
+}
+
 // StartProcessing marks a transaction as in progress. It's thread-safe and
 // prevents from processing the same transaction multiple times.
 func (q *TxQueue) StartProcessing(tx *common.QueuedTx) error {
@@ -228,6 +333,49 @@ func (q *TxQueue) StopProcessing(tx *common.QueuedTx) {
 	tx.InProgress = false
 }
 
+// transactionsList returns currently queued transactions.
+func (q *TxQueue) transactionsList() []*common.QueuedTx {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	list := make([]*common.QueuedTx, 0, len(q.transactions))
+	for _, tx := range q.transactions {
+		list = append(list, tx)
+	}
+
+	return list
+}
+
+// pendingTransactions returns currently queued transactions as they are now, as their processing state
+// changes while they are being sent.
+func (q *TxQueue) pendingTransactions() []common.PendingTransaction {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	list := make([]common.PendingTransaction, 0, len(q.transactions))
+	for _, tx := range q.transactions {
+		pending := common.PendingTransaction{
+			ID:         tx.ID,
+			Args:       tx.Args,
+			MessageID:  common.MessageIDFromContext(tx.Context),
+			ChatID:     tx.ChatID,
+			Token:      tx.Token,
+			QueuedAt:   tx.QueuedAt,
+			Deadline:   tx.Deadline,
+			InProgress: tx.InProgress,
+			Restored:   tx.Restored,
+			Estimate:   tx.Estimate,
+		}
+		if tx.Replaces != (gethcommon.Hash{}) {
+			replaces := tx.Replaces
+			pending.Replaces = &replaces
+		}
+		list = append(list, pending)
+	}
+
+	return list
+}
+
 // Count returns number of currently queued transactions
 func (q *TxQueue) Count() int {
 	q.mu.RLock()
@@ -3,6 +3,8 @@ package txqueue
 import (
 	"context"
 	"math/big"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
@@ -14,6 +16,8 @@ import (
 	"github.com/pborman/uuid"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
 	"github.com/status-im/status-go/geth/signal"
 )
 
@@ -24,6 +28,9 @@ const (
 	// EventTransactionFailed is triggered when send transaction request fails
 	EventTransactionFailed = "transaction.failed"
 
+	// EventTransactionReplaced is triggered when a pending transaction is replaced by a faster or a cancelling one
+	EventTransactionReplaced = "transaction.replaced"
+
 	// SendTxDefaultErrorCode is sent by default, when error is not nil, but type is unknown/unexpected.
 	SendTxDefaultErrorCode = SendTransactionDefaultErrorCode
 )
@@ -35,13 +42,22 @@ const (
 	SendTransactionPasswordErrorCode  = "2"
 	SendTransactionTimeoutErrorCode   = "3"
 	SendTransactionDiscardedErrorCode = "4"
+	SendTransactionWatchOnlyErrorCode = "5"
+	SendTransactionEvictedErrorCode   = "6"
+	SendTransactionAbortedErrorCode   = "7"
+	SendTransactionChainIDErrorCode   = "8"
 )
 
 var txReturnCodes = map[error]string{ // deliberately strings, in case more meaningful codes are to be returned
-	nil:                  SendTransactionNoErrorCode,
-	keystore.ErrDecrypt:  SendTransactionPasswordErrorCode,
-	ErrQueuedTxTimedOut:  SendTransactionTimeoutErrorCode,
-	ErrQueuedTxDiscarded: SendTransactionDiscardedErrorCode,
+	nil:                     SendTransactionNoErrorCode,
+	keystore.ErrDecrypt:     SendTransactionPasswordErrorCode,
+	ErrQueuedTxTimedOut:     SendTransactionTimeoutErrorCode,
+	ErrQueuedTxDiscarded:    SendTransactionDiscardedErrorCode,
+	ErrWatchOnlyAccount:     SendTransactionWatchOnlyErrorCode,
+	ErrQueuedTxEvicted:      SendTransactionEvictedErrorCode,
+	ErrQueuedTxBatchAborted: SendTransactionAbortedErrorCode,
+	ErrInvalidChainID:       SendTransactionChainIDErrorCode,
+	ErrUnprotectedTx:        SendTransactionChainIDErrorCode,
 }
 
 // Manager provides means to manage internal Status Backend (injected into LES)
@@ -49,6 +65,28 @@ type Manager struct {
 	nodeManager    common.NodeManager
 	accountManager common.AccountManager
 	txQueue        *TxQueue
+	clock          common.Clock
+	ttlMu          sync.RWMutex
+	ttl            time.Duration // how long a queued transaction waits for approval
+	nonces         *nonceTracker // assigns nonces of transactions sent with eth_sendRawTransaction
+	rateLimiter    *rateLimiter  // limits transactions requested by each DApp
+
+	signerMu sync.RWMutex
+	signer   common.TxSigner // signs transactions of accounts outside of the keystore, if set
+
+	gasPriceOracleMu sync.RWMutex
+	gasPriceOracle   common.GasPriceOracle // suggests gas prices of queued transactions, if set
+
+	txWatcherMu sync.RWMutex
+	txWatcher   common.TxWatcher // follows sent transactions, if set
+
+	callDecoderMu sync.RWMutex
+	callDecoder   common.CallDecoder // decodes contract calls of queued transactions, if set
+
+	queueFileMu sync.Mutex // serialises saving of queued transactions
+	queueFile   string     // file queued transactions are saved to, set by RestoreQueue
+
+	signals signal.Sender // sends transaction signals, see SetSignalHandler
 }
 
 // NewManager returns a new Manager.
@@ -57,9 +95,135 @@ func NewManager(nodeManager common.NodeManager, accountManager common.AccountMan
 		nodeManager:    nodeManager,
 		accountManager: accountManager,
 		txQueue:        NewTransactionQueue(),
+		clock:          common.SystemClock,
+		ttl:            DefaultTxSendCompletionTimeout * time.Second,
+		nonces:         newNonceTracker(),
+		rateLimiter:    newRateLimiter(),
 	}
 }
 
+// SetClock replaces a clock used to time out queued transactions,
+// e.g. with a suspendable clock or a fake one in tests.
+func (m *Manager) SetClock(clock common.Clock) {
+	m.clock = clock
+}
+
+// SetSignalHandler sets a handler of signals sent by the transaction queue, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (m *Manager) SetSignalHandler(handler func(signal.Envelope)) {
+	m.signals.SetHandler(handler)
+}
+
+// Configure applies limits of the queue: transactions queued after it expire after a new TTL,
+// a new capacity is applied when the queue is started, and DApps are limited to a new rate.
+// Limits which are not set are left unchanged, the rate and the burst are set together.
+func (m *Manager) Configure(config params.TxQueueConfig) {
+	if config.TTL > 0 {
+		m.ttlMu.Lock()
+		m.ttl = time.Duration(config.TTL) * time.Second
+		m.ttlMu.Unlock()
+	}
+
+	if config.Capacity > 0 {
+		m.txQueue.SetCapacity(config.Capacity)
+	}
+
+	if config.RateLimit > 0 && config.RateBurst > 0 {
+		m.rateLimiter.configure(config.RateLimit, config.RateBurst)
+	}
+}
+
+// queueTTL returns how long a queued transaction waits for approval.
+func (m *Manager) queueTTL() time.Duration {
+	m.ttlMu.RLock()
+	defer m.ttlMu.RUnlock()
+
+	return m.ttl
+}
+
+// SetTxSigner routes transactions of accounts held by a signer, e.g. a hardware wallet, to it instead
+// of the keystore. Such transactions are completed without the password and without selecting the account,
+// as they are confirmed on the device. Nil signer routes all transactions to the keystore again.
+func (m *Manager) SetTxSigner(signer common.TxSigner) {
+	m.signerMu.Lock()
+	defer m.signerMu.Unlock()
+
+	m.signer = signer
+}
+
+// txSigner returns a signer set with SetTxSigner, or nil.
+func (m *Manager) txSigner() common.TxSigner {
+	m.signerMu.RLock()
+	defer m.signerMu.RUnlock()
+
+	return m.signer
+}
+
+// SetGasPriceOracle makes gas prices suggested by an oracle part of the transaction queued signal, and makes
+// the normal one the gas price of transactions sent without it. Nil oracle turns suggestions off, and gas price
+// of transactions sent without it is requested with eth_gasPrice again.
+func (m *Manager) SetGasPriceOracle(oracle common.GasPriceOracle) {
+	m.gasPriceOracleMu.Lock()
+	defer m.gasPriceOracleMu.Unlock()
+
+	m.gasPriceOracle = oracle
+}
+
+// getGasPriceOracle returns an oracle set with SetGasPriceOracle, or nil.
+func (m *Manager) getGasPriceOracle() common.GasPriceOracle {
+	m.gasPriceOracleMu.RLock()
+	defer m.gasPriceOracleMu.RUnlock()
+
+	return m.gasPriceOracle
+}
+
+// SetTxWatcher makes a watcher follow transactions sent by the queue, including replacements,
+// until they are confirmed. Nil watcher stops handing sent transactions over.
+func (m *Manager) SetTxWatcher(watcher common.TxWatcher) {
+	m.txWatcherMu.Lock()
+	defer m.txWatcherMu.Unlock()
+
+	m.txWatcher = watcher
+}
+
+// getTxWatcher returns a watcher set with SetTxWatcher, or nil.
+func (m *Manager) getTxWatcher() common.TxWatcher {
+	m.txWatcherMu.RLock()
+	defer m.txWatcherMu.RUnlock()
+
+	return m.txWatcher
+}
+
+// SetCallDecoder makes contract calls of queued transactions decoded by a decoder, e.g. an ABI registry,
+// part of the transaction queued signal. Nil decoder turns decoding off.
+func (m *Manager) SetCallDecoder(decoder common.CallDecoder) {
+	m.callDecoderMu.Lock()
+	defer m.callDecoderMu.Unlock()
+
+	m.callDecoder = decoder
+}
+
+// decodeCall returns a contract call of transaction arguments, or nil if no decoder is set or the call is unknown.
+func (m *Manager) decodeCall(args common.SendTxArgs) *common.CallPreview {
+	m.callDecoderMu.RLock()
+	decoder := m.callDecoder
+	m.callDecoderMu.RUnlock()
+
+	if decoder == nil {
+		return nil
+	}
+
+	return decoder.DecodeCall(args.To, args.Data)
+}
+
+// ResetNonce forgets nonces of transactions of an account sent by the queue, so that nonce of its next
+// transaction is the pending transaction count reported by the node, e.g. after transactions were sent
+// from the account elsewhere.
+func (m *Manager) ResetNonce(address gethcommon.Address) {
+	log.Info("reset nonce", "address", address.Hex())
+	m.nonces.reset(address)
+}
+
 // Start starts accepting new transactions into the queue.
 func (m *Manager) Start() {
 	log.Info("start Manager")
@@ -72,6 +236,23 @@ func (m *Manager) Stop() {
 	m.txQueue.Stop()
 }
 
+// Drain stops accepting new transactions and waits until queued ones are
+// completed or discarded, or a given context is done.
+func (m *Manager) Drain(ctx context.Context) error {
+	log.Info("drain Manager")
+	m.txQueue.StopAccepting()
+
+	for m.txQueue.Count() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-m.clock.After(drainPollInterval):
+		}
+	}
+
+	return nil
+}
+
 // TransactionQueue returns a reference to the queue.
 func (m *Manager) TransactionQueue() common.TxQueue {
 	return m.txQueue
@@ -84,12 +265,15 @@ func (m *Manager) CreateTransaction(ctx context.Context, args common.SendTxArgs)
 		Hash:    gethcommon.Hash{},
 		Context: ctx,
 		Args:    args,
+		ChatID:  common.ChatIDFromContext(ctx),
 		Done:    make(chan struct{}, 1),
 		Discard: make(chan struct{}, 1),
 	}
 }
 
 // QueueTransaction puts a transaction into the queue.
+// Transactions from watch-only accounts are rejected, as they can't be signed, and so are transactions
+// of a DApp over its rate limit, without a failed signal, so that a DApp can't spam the user with them.
 func (m *Manager) QueueTransaction(tx *common.QueuedTx) error {
 	to := "<nil>"
 	if tx.Args.To != nil {
@@ -97,13 +281,48 @@ func (m *Manager) QueueTransaction(tx *common.QueuedTx) error {
 	}
 	log.Info("queue a new transaction", "id", tx.ID, "from", tx.Args.From.Hex(), "to", to)
 
-	return m.txQueue.Enqueue(tx)
+	if m.accountManager.IsWatchOnlyAccount(tx.Args.From) {
+		log.Warn("transaction from a watch-only account rejected", "id", tx.ID, "from", tx.Args.From.Hex())
+		m.NotifyOnQueuedTxReturn(tx, ErrWatchOnlyAccount)
+		return ErrWatchOnlyAccount
+	}
+
+	if err := m.checkRateLimit(tx.ChatID); err != nil {
+		log.Warn("transaction over the rate limit rejected", "id", tx.ID, "chat", tx.ChatID)
+		return err
+	}
+
+	if tx.QueuedAt.IsZero() {
+		tx.QueuedAt = m.clock.Now()
+	}
+	if tx.Deadline.IsZero() {
+		tx.Deadline = tx.QueuedAt.Add(m.queueTTL())
+	}
+	m.estimateQueuedTransaction(tx)
+	if err := m.txQueue.Enqueue(tx); err != nil {
+		return err
+	}
+	m.saveQueue()
+
+	return nil
 }
 
 // WaitForTransaction adds a transaction to the queue and blocks
 // until it's completed, discarded or times out.
 func (m *Manager) WaitForTransaction(tx *common.QueuedTx) error {
+	timeout := m.queueTTL()
+	if !tx.Deadline.IsZero() {
+		timeout = tx.Deadline.Sub(m.clock.Now())
+	}
+
+	return m.waitForTransaction(tx, timeout)
+}
+
+// waitForTransaction blocks until a queued transaction is completed, discarded or a timeout elapses,
+// and saves the queue once the transaction left it.
+func (m *Manager) waitForTransaction(tx *common.QueuedTx, timeout time.Duration) error {
 	log.Info("wait for transaction", "id", tx.ID)
+	defer m.saveQueue()
 
 	// now wait up until transaction is:
 	// - completed (via CompleteQueuedTransaction),
@@ -113,10 +332,10 @@ func (m *Manager) WaitForTransaction(tx *common.QueuedTx) error {
 	case <-tx.Done:
 		m.NotifyOnQueuedTxReturn(tx, tx.Err)
 		return tx.Err
-	case <-tx.Discard:
-		m.NotifyOnQueuedTxReturn(tx, ErrQueuedTxDiscarded)
-		return ErrQueuedTxDiscarded
-	case <-time.After(DefaultTxSendCompletionTimeout * time.Second):
+	case <-tx.Discard: // discarded or evicted
+		m.NotifyOnQueuedTxReturn(tx, tx.Err)
+		return tx.Err
+	case <-m.clock.After(timeout):
 		m.NotifyOnQueuedTxReturn(tx, ErrQueuedTxTimedOut)
 		return ErrQueuedTxTimedOut
 	}
@@ -145,15 +364,26 @@ func (m *Manager) CompleteTransaction(id common.QueuedTxID, password string) (ge
 	}
 	defer m.txQueue.StopProcessing(queuedTx)
 
-	selectedAccount, err := m.accountManager.SelectedAccount()
-	if err != nil {
+	return m.completeTransaction(queuedTx, password)
+}
+
+// completeTransaction sends a transaction marked as being processed, with the key of its sender.
+func (m *Manager) completeTransaction(queuedTx *common.QueuedTx, password string) (gethcommon.Hash, error) {
+	if signer := m.txSigner(); signer != nil && signer.HasAccount(queuedTx.Args.From) {
+		hash, err := m.completeSignerTransaction(queuedTx, signer)
+		m.transactionCompleted(queuedTx, hash, err)
+		return hash, err
+	}
+
+	if _, err := m.accountManager.SelectedAccount(); err != nil {
 		log.Warn("failed to get a selected account", "err", err)
 		return gethcommon.Hash{}, err
 	}
 
-	// make sure that only account which created the tx can complete it
-	if queuedTx.Args.From.Hex() != selectedAccount.Address.Hex() {
-		log.Warn("queued transaction does not belong to the selected account", "err", ErrInvalidCompleteTxSender)
+	// make sure that only account which created the tx can complete it, any unlocked account can be the sender
+	sessionAccount, err := m.accountManager.SessionAccount(queuedTx.Args.From)
+	if err != nil {
+		log.Warn("queued transaction does not belong to an unlocked account", "err", ErrInvalidCompleteTxSender)
 		m.NotifyOnQueuedTxReturn(queuedTx, ErrInvalidCompleteTxSender)
 		return gethcommon.Hash{}, ErrInvalidCompleteTxSender
 	}
@@ -168,9 +398,11 @@ func (m *Manager) CompleteTransaction(id common.QueuedTxID, password string) (ge
 	var hash gethcommon.Hash
 
 	if config.UpstreamConfig.Enabled {
-		hash, err = m.completeRemoteTransaction(queuedTx, password)
+		hash, err = m.completeRemoteTransaction(queuedTx, sessionAccount, password)
+	} else if config.SyncMode == params.FastSyncMode || config.SyncMode == params.FullSyncMode {
+		hash, err = m.completeFullNodeTransaction(queuedTx, sessionAccount, config, password)
 	} else {
-		hash, err = m.completeLocalTransaction(queuedTx, password)
+		hash, err = m.completeLocalTransaction(queuedTx, config, password)
 	}
 
 	// when incorrect sender tries to complete the account,
@@ -181,18 +413,38 @@ func (m *Manager) CompleteTransaction(id common.QueuedTxID, password string) (ge
 		return hash, err
 	}
 
+	m.transactionCompleted(queuedTx, hash, err)
+
+	return hash, err
+}
+
+// transactionCompleted sets a result of a transaction and lets WaitForTransaction return it.
+// A sent transaction is handed over to the watcher, if any.
+func (m *Manager) transactionCompleted(queuedTx *common.QueuedTx, hash gethcommon.Hash, err error) {
 	log.Info("finally completed transaction", "id", queuedTx.ID, "hash", hash, "err", err)
 
+	if watcher := m.getTxWatcher(); watcher != nil && err == nil {
+		watcher.Watch(hash, queuedTx.Args.From)
+	}
+
 	queuedTx.Hash = hash
 	queuedTx.Err = err
 	queuedTx.Done <- struct{}{}
-
-	return hash, err
 }
 
 const cancelTimeout = time.Minute
 
-func (m *Manager) completeLocalTransaction(queuedTx *common.QueuedTx, password string) (gethcommon.Hash, error) {
+// drainPollInterval is how often Drain checks whether the queue is empty.
+const drainPollInterval = 100 * time.Millisecond
+
+// gasPriceSuggestionTimeout limits how long the transaction queued signal waits for gas price suggestions,
+// fallback gas prices are suggested once it's over.
+const gasPriceSuggestionTimeout = 5 * time.Second
+
+// gasEstimationTimeout limits how long gas and fee of a queued transaction are estimated.
+const gasEstimationTimeout = 5 * time.Second
+
+func (m *Manager) completeLocalTransaction(queuedTx *common.QueuedTx, config *params.NodeConfig, password string) (gethcommon.Hash, error) {
 	log.Info("complete transaction using local node", "id", queuedTx.ID)
 
 	les, err := m.nodeManager.LightEthereumService()
@@ -200,13 +452,26 @@ func (m *Manager) completeLocalTransaction(queuedTx *common.QueuedTx, password s
 		return gethcommon.Hash{}, err
 	}
 
+	if err := checkLightChain(les, queuedTx.Args, config); err != nil {
+		return gethcommon.Hash{}, err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
 	defer cancel()
 
-	return les.StatusBackend.SendTransaction(ctx, status.SendTxArgs(queuedTx.Args), password)
+	args := queuedTx.Args
+	return les.StatusBackend.SendTransaction(ctx, status.SendTxArgs{
+		From:     args.From,
+		To:       args.To,
+		Gas:      args.Gas,
+		GasPrice: args.GasPrice,
+		Value:    args.Value,
+		Data:     args.Data,
+		Nonce:    args.Nonce,
+	}, password)
 }
 
-func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password string) (gethcommon.Hash, error) {
+func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, sender *common.SelectedExtKey, password string) (gethcommon.Hash, error) {
 	log.Info("complete transaction using upstream node", "id", queuedTx.ID)
 
 	var emptyHash gethcommon.Hash
@@ -216,41 +481,135 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 		return emptyHash, err
 	}
 
-	selectedAcct, err := m.accountManager.SelectedAccount()
+	_, err = m.accountManager.VerifyAccountPassword(config.KeyStoreDir, sender.Address.String(), password)
 	if err != nil {
+		log.Warn("failed to verify account", "account", sender.Address.String(), "error", err.Error())
 		return emptyHash, err
 	}
 
-	_, err = m.accountManager.VerifyAccountPassword(config.KeyStoreDir, selectedAcct.Address.String(), password)
+	return m.sendRawTransaction(queuedTx, config, func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), sender.AccountKey.PrivateKey)
+	})
+}
+
+// completeFullNodeTransaction completes a transaction using a full node, which has no LES backend signing it.
+// The transaction is signed with the key of the sender and added to the transaction pool of the eth service.
+func (m *Manager) completeFullNodeTransaction(queuedTx *common.QueuedTx, sender *common.SelectedExtKey,
+	config *params.NodeConfig, password string) (gethcommon.Hash, error) {
+	log.Info("complete transaction using full node", "id", queuedTx.ID)
+
+	ethService, err := m.nodeManager.EthereumService()
 	if err != nil {
-		log.Warn("failed to verify account", "account", selectedAcct.Address.String(), "error", err.Error())
-		return emptyHash, err
+		return gethcommon.Hash{}, err
+	}
+
+	_, err = m.accountManager.VerifyAccountPassword(config.KeyStoreDir, sender.Address.String(), password)
+	if err != nil {
+		log.Warn("failed to verify account", "account", sender.Address.String(), "error", err.Error())
+		return gethcommon.Hash{}, err
+	}
+
+	return m.sendSignedTransaction(queuedTx, config, func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		return types.SignTx(tx, types.NewEIP155Signer(chainID), sender.AccountKey.PrivateKey)
+	}, ethService.ApiBackend.SendTx)
+}
+
+// completeSignerTransaction completes a transaction of an account held by a signer set with SetTxSigner.
+func (m *Manager) completeSignerTransaction(queuedTx *common.QueuedTx, signer common.TxSigner) (gethcommon.Hash, error) {
+	log.Info("complete transaction using external signer", "id", queuedTx.ID)
+
+	config, err := m.nodeManager.NodeConfig()
+	if err != nil {
+		return gethcommon.Hash{}, err
 	}
 
-	// We need to request a new transaction nounce from upstream node.
+	return m.sendRawTransaction(queuedTx, config, func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+		return signer.SignTx(queuedTx.Args.From, tx, chainID)
+	})
+}
+
+// signFunc signs a transaction with EIP155 signer of a given chain.
+type signFunc func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+
+// submitFunc submits a signed transaction to the network.
+type submitFunc func(ctx context.Context, tx *types.Transaction) error
+
+// sendRawTransaction creates a transaction of a queued one, with the nonce assigned by the nonce tracker,
+// and gas and gas price requested from the node unless they are given, signs it with a sign function for
+// the node's network and sends it with eth_sendRawTransaction. The signed transaction must be replay
+// protected for the network.
+func (m *Manager) sendRawTransaction(queuedTx *common.QueuedTx, config *params.NodeConfig, sign signFunc) (gethcommon.Hash, error) {
+	return m.sendSignedTransaction(queuedTx, config, sign, func(ctx context.Context, tx *types.Transaction) error {
+		txBytes, err := rlp.EncodeToBytes(tx)
+		if err != nil {
+			return err
+		}
+
+		return m.nodeManager.RPCClient().CallContext(ctx, nil, "eth_sendRawTransaction", gethcommon.ToHex(txBytes))
+	})
+}
+
+// sendSignedTransaction creates and signs a transaction of a queued one like sendRawTransaction does,
+// and submits it with a submit function.
+func (m *Manager) sendSignedTransaction(queuedTx *common.QueuedTx, config *params.NodeConfig, sign signFunc,
+	submit submitFunc) (gethcommon.Hash, error) {
+	var emptyHash gethcommon.Hash
+
+	// transactions of an account are assigned nonces one at a time
+	nonces := m.nonces.account(queuedTx.Args.From)
+	nonces.Lock()
+	defer nonces.Unlock()
+
 	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
 	defer cancel()
 
-	var txCount hexutil.Uint
-	client := m.nodeManager.RPCClient()
-	err = client.CallContext(ctx, &txCount, "eth_getTransactionCount", queuedTx.Args.From, "pending")
+	signedTx, err := m.signTransaction(ctx, m.nodeManager.RPCClient(), nonces, queuedTx.Args, config, sign)
 	if err != nil {
 		return emptyHash, err
 	}
 
-	args := queuedTx.Args
+	ctx2, cancel2 := context.WithTimeout(context.Background(), cancelTimeout)
+	defer cancel2()
+
+	if err := submit(ctx2, signedTx); err != nil {
+		nonces.failed(err)
+		return emptyHash, err
+	}
+	nonces.sent(signedTx.Nonce(), signedTx.Hash())
+
+	return signedTx.Hash(), nil
+}
+
+// signTransaction creates a transaction with the nonce assigned by the nonce tracker, and gas and gas price
+// requested from the node unless they are given, and signs it with a sign function for the node's network.
+// Nonces of the sender must be locked.
+func (m *Manager) signTransaction(ctx context.Context, client *rpc.Client, nonces *accountNonces,
+	args common.SendTxArgs, config *params.NodeConfig, sign signFunc) (*types.Transaction, error) {
+	if err := checkChainID(args, config.NetworkID); err != nil {
+		return nil, err
+	}
+
+	// replacements of pending transactions reuse their nonces
+	var nonce uint64
+	if args.Nonce != nil {
+		nonce = uint64(*args.Nonce)
+	} else {
+		var err error
+		if nonce, err = nonces.next(ctx, client, args.From); err != nil {
+			return nil, err
+		}
+	}
 
 	if args.GasPrice == nil {
-		value, gasPriceErr := m.gasPrice()
+		value, gasPriceErr := m.gasPrice(ctx)
 		if gasPriceErr != nil {
-			return emptyHash, gasPriceErr
+			return nil, gasPriceErr
 		}
 
 		args.GasPrice = value
 	}
 
-	chainID := big.NewInt(int64(config.NetworkID))
-	nonce := uint64(txCount)
+	chainID := networkChainID(config.NetworkID)
 	gasPrice := (*big.Int)(args.GasPrice)
 	data := []byte(args.Data)
 	value := (*big.Int)(args.Value)
@@ -259,9 +618,9 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 		toAddr = *args.To
 	}
 
-	gas, err := m.estimateGas(args)
+	gas, err := m.estimateGas(ctx, client, args)
 	if err != nil {
-		return emptyHash, err
+		return nil, err
 	}
 
 	log.Info(
@@ -274,35 +633,23 @@ func (m *Manager) completeRemoteTransaction(queuedTx *common.QueuedTx, password
 	)
 
 	tx := types.NewTransaction(nonce, toAddr, value, (*big.Int)(gas), gasPrice, data)
-	signedTx, err := types.SignTx(tx, types.NewEIP155Signer(chainID), selectedAcct.AccountKey.PrivateKey)
-	if err != nil {
-		return emptyHash, err
-	}
-
-	txBytes, err := rlp.EncodeToBytes(signedTx)
+	signedTx, err := sign(tx, chainID)
 	if err != nil {
-		return emptyHash, err
+		return nil, err
 	}
-
-	ctx2, cancel2 := context.WithTimeout(context.Background(), cancelTimeout)
-	defer cancel2()
-
-	if err := client.CallContext(ctx2, nil, "eth_sendRawTransaction", gethcommon.ToHex(txBytes)); err != nil {
-		return emptyHash, err
+	if err := checkSignedTx(signedTx, chainID, config.TxQueueConfig.AllowUnprotectedTxs); err != nil {
+		return nil, err
 	}
 
-	return signedTx.Hash(), nil
+	return signedTx, nil
 }
 
-func (m *Manager) estimateGas(args common.SendTxArgs) (*hexutil.Big, error) {
+// estimateGas returns gas of a transaction, if it's given, or gas estimated by the node increased by a safety margin.
+func (m *Manager) estimateGas(ctx context.Context, client *rpc.Client, args common.SendTxArgs) (*hexutil.Big, error) {
 	if args.Gas != nil {
 		return args.Gas, nil
 	}
 
-	client := m.nodeManager.RPCClient()
-	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
-	defer cancel()
-
 	var gasPrice hexutil.Big
 	if args.GasPrice != nil {
 		gasPrice = *args.GasPrice
@@ -339,13 +686,16 @@ func (m *Manager) estimateGas(args common.SendTxArgs) (*hexutil.Big, error) {
 		return nil, err
 	}
 
-	return &estimatedGas, nil
+	return withGasMargin(&estimatedGas), nil
 }
 
-func (m *Manager) gasPrice() (*hexutil.Big, error) {
+// gasPrice returns the normal gas price suggested by the oracle, if it's set, or the gas price of the node.
+func (m *Manager) gasPrice(ctx context.Context) (*hexutil.Big, error) {
+	if oracle := m.getGasPriceOracle(); oracle != nil {
+		return oracle.SuggestGasPrices(ctx).Normal, nil
+	}
+
 	client := m.nodeManager.RPCClient()
-	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
-	defer cancel()
 
 	var gasPrice hexutil.Big
 	if err := client.CallContext(ctx, &gasPrice, "eth_gasPrice"); err != nil {
@@ -356,21 +706,6 @@ func (m *Manager) gasPrice() (*hexutil.Big, error) {
 	return &gasPrice, nil
 }
 
-// CompleteTransactions instructs backend to complete sending of multiple transactions
-func (m *Manager) CompleteTransactions(ids []common.QueuedTxID, password string) map[common.QueuedTxID]common.RawCompleteTransactionResult {
-	results := make(map[common.QueuedTxID]common.RawCompleteTransactionResult)
-
-	for _, txID := range ids {
-		txHash, txErr := m.CompleteTransaction(txID, password)
-		results[txID] = common.RawCompleteTransactionResult{
-			Hash:  txHash,
-			Error: txErr,
-		}
-	}
-
-	return results
-}
-
 // DiscardTransaction discards a given transaction from transaction queue
 func (m *Manager) DiscardTransaction(id common.QueuedTxID) error {
 	queuedTx, err := m.txQueue.Get(id)
@@ -404,28 +739,98 @@ func (m *Manager) DiscardTransactions(ids []common.QueuedTxID) map[common.Queued
 	return results
 }
 
+// DiscardAllTransactions discards all queued transactions, e.g. when the user logs out. Transactions being sent
+// are not discarded and fail with ErrQueuedTxInProgress. Results of all transactions are returned by ID.
+func (m *Manager) DiscardAllTransactions() map[common.QueuedTxID]common.RawDiscardTransactionResult {
+	log.Info("discard all transactions")
+
+	results := make(map[common.QueuedTxID]common.RawDiscardTransactionResult)
+	for _, queuedTx := range m.txQueue.transactionsList() {
+		// a transaction can't be discarded while it's being sent
+		if err := m.txQueue.StartProcessing(queuedTx); err != nil {
+			results[queuedTx.ID] = common.RawDiscardTransactionResult{Error: err}
+			continue
+		}
+		err := m.DiscardTransaction(queuedTx.ID)
+		m.txQueue.StopProcessing(queuedTx)
+		results[queuedTx.ID] = common.RawDiscardTransactionResult{Error: err}
+	}
+
+	return results
+}
+
+// PendingTransactions returns queued transactions waiting for approval, in the order they were queued,
+// along with gas and fee estimated when they were queued and their decoded contract calls.
+func (m *Manager) PendingTransactions() []common.PendingTransaction {
+	queued := m.txQueue.pendingTransactions()
+	sort.Slice(queued, func(i, j int) bool {
+		return queued[i].QueuedAt.Before(queued[j].QueuedAt)
+	})
+
+	for i := range queued {
+		queued[i].Call = m.decodeCall(queued[i].Args)
+	}
+
+	return queued
+}
+
 // SendTransactionEvent is a signal sent on a send transaction request
 type SendTransactionEvent struct {
-	ID        string            `json:"id"`
-	Args      common.SendTxArgs `json:"args"`
-	MessageID string            `json:"message_id"`
+	ID        string                     `json:"id"`
+	Args      common.SendTxArgs          `json:"args"`
+	MessageID string                     `json:"message_id"`
+	ChatID    string                     `json:"chat_id,omitempty"`    // ID of a chat of a DApp which requested the transaction
+	GasPrices *common.GasPriceSuggestion `json:"gas_prices,omitempty"` // suggested gas prices, if an oracle is set
+	Estimate  *common.GasEstimate        `json:"estimate,omitempty"`   // gas and fee of the transaction, if the node is available
+	Call      *common.CallPreview        `json:"call,omitempty"`       // decoded contract call, if the method is known
+	Token     *common.TokenTransfer      `json:"token,omitempty"`      // transfer of tokens, if queued with QueueTokenTransfer
+	Replaces  *gethcommon.Hash           `json:"replaces,omitempty"`   // hash of a pending transaction being replaced
+	Restored  bool                       `json:"restored,omitempty"`   // true if transaction was queued before a restart
 }
 
 // TransactionQueueHandler returns handler that processes incoming tx queue requests
 func (m *Manager) TransactionQueueHandler() func(queuedTx *common.QueuedTx) {
 	return func(queuedTx *common.QueuedTx) {
 		log.Info("calling TransactionQueueHandler")
-		signal.Send(signal.Envelope{
+
+		var replaces *gethcommon.Hash
+		if queuedTx.Replaces != (gethcommon.Hash{}) {
+			replaces = &queuedTx.Replaces
+		}
+
+		m.signals.Send(signal.Envelope{
 			Type: EventTransactionQueued,
 			Event: SendTransactionEvent{
 				ID:        string(queuedTx.ID),
 				Args:      queuedTx.Args,
 				MessageID: common.MessageIDFromContext(queuedTx.Context),
+				ChatID:    queuedTx.ChatID,
+				GasPrices: queuedTx.GasPrices,
+				Estimate:  queuedTx.Estimate,
+				Call:      m.decodeCall(queuedTx.Args),
+				Token:     queuedTx.Token,
+				Replaces:  replaces,
+				Restored:  queuedTx.Restored,
 			},
 		})
 	}
 }
 
+// estimateQueuedTransaction asks the node for gas prices and gas of a transaction before it's enqueued,
+// so that they're sent in the transaction queued signal without the queue waiting for the node.
+func (m *Manager) estimateQueuedTransaction(tx *common.QueuedTx) {
+	if oracle := m.getGasPriceOracle(); oracle != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), gasPriceSuggestionTimeout)
+		suggestion := oracle.SuggestGasPrices(ctx)
+		cancel()
+		tx.GasPrices = &suggestion
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), gasEstimationTimeout)
+	tx.Estimate = m.estimateTransaction(ctx, tx.Args, tx.GasPrices)
+	cancel()
+}
+
 // SetTransactionQueueHandler sets a handler that will be called
 // when a new transaction is enqueued.
 func (m *Manager) SetTransactionQueueHandler(fn common.EnqueuedTxHandler) {
@@ -437,6 +842,7 @@ type ReturnSendTransactionEvent struct {
 	ID           string            `json:"id"`
 	Args         common.SendTxArgs `json:"args"`
 	MessageID    string            `json:"message_id"`
+	ChatID       string            `json:"chat_id,omitempty"`
 	ErrorMessage string            `json:"error_message"`
 	ErrorCode    string            `json:"error_code"`
 }
@@ -454,12 +860,13 @@ func (m *Manager) TransactionReturnHandler() func(queuedTx *common.QueuedTx, err
 		}
 
 		// error occurred, signal up to application
-		signal.Send(signal.Envelope{
+		m.signals.Send(signal.Envelope{
 			Type: EventTransactionFailed,
 			Event: ReturnSendTransactionEvent{
 				ID:           string(queuedTx.ID),
 				Args:         queuedTx.Args,
 				MessageID:    common.MessageIDFromContext(queuedTx.Context),
+				ChatID:       queuedTx.ChatID,
 				ErrorMessage: err.Error(),
 				ErrorCode:    m.sendTransactionErrorCode(err),
 			},
@@ -2,21 +2,40 @@ package txqueue
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/suite"
 
 	"github.com/golang/mock/gomock"
 
+	"github.com/status-im/status-go/geth/abi"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
 	. "github.com/status-im/status-go/testing"
 )
 
-var errTxAssumedSent = errors.New("assume tx is done")
+var (
+	errTxAssumedSent = errors.New("assume tx is done")
+	errNoSession     = errors.New("account has no open session")
+)
 
 func TestTxQueueTestSuite(t *testing.T) {
 	suite.Run(t, new(TxQueueTestSuite))
@@ -36,6 +55,7 @@ func (s *TxQueueTestSuite) SetupTest() {
 
 	s.nodeManagerMock = common.NewMockNodeManager(s.nodeManagerMockCtrl)
 	s.accountManagerMock = common.NewMockAccountManager(s.accountManagerMockCtrl)
+	s.accountManagerMock.EXPECT().IsWatchOnlyAccount(gomock.Any()).Return(false).AnyTimes()
 }
 
 func (s *TxQueueTestSuite) TearDownTest() {
@@ -44,9 +64,13 @@ func (s *TxQueueTestSuite) TearDownTest() {
 }
 
 func (s *TxQueueTestSuite) TestCompleteTransaction() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
 	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
 		Address: common.FromAddress(TestConfig.Account1.Address),
 	}, nil)
+	s.accountManagerMock.EXPECT().SessionAccount(common.FromAddress(TestConfig.Account1.Address)).Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil)
 
 	s.nodeManagerMock.EXPECT().NodeConfig().Return(
 		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
@@ -93,10 +117,56 @@ func (s *TxQueueTestSuite) TestCompleteTransaction() {
 	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
 }
 
+func (s *TxQueueTestSuite) TestCompleteTransactionOfSession() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
+	// the sender is unlocked with a session, while another account is selected
+	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account2.Address),
+	}, nil)
+	s.accountManagerMock.EXPECT().SessionAccount(common.FromAddress(TestConfig.Account1.Address)).Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil)
+
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	)
+	s.nodeManagerMock.EXPECT().LightEthereumService().Return(nil, errTxAssumedSent)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {
+		s.Equal(tx.ID, queuedTx.ID)
+	})
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {
+		s.Equal(tx.ID, queuedTx.ID)
+		s.Equal(errTxAssumedSent, err)
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	go func() {
+		_, errCompleteTransaction := txQueueManager.CompleteTransaction(tx.ID, TestConfig.Account1.Password)
+		s.Equal(errTxAssumedSent, errCompleteTransaction)
+	}()
+
+	s.Equal(errTxAssumedSent, txQueueManager.WaitForTransaction(tx))
+	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
+}
+
 func (s *TxQueueTestSuite) TestCompleteTransactionMultipleTimes() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
 	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
 		Address: common.FromAddress(TestConfig.Account1.Address),
 	}, nil)
+	s.accountManagerMock.EXPECT().SessionAccount(common.FromAddress(TestConfig.Account1.Address)).Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil)
 
 	s.nodeManagerMock.EXPECT().NodeConfig().Return(
 		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
@@ -157,9 +227,11 @@ func (s *TxQueueTestSuite) TestCompleteTransactionMultipleTimes() {
 }
 
 func (s *TxQueueTestSuite) TestAccountMismatch() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
 	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
 		Address: common.FromAddress(TestConfig.Account2.Address),
 	}, nil)
+	s.accountManagerMock.EXPECT().SessionAccount(common.FromAddress(TestConfig.Account1.Address)).Return(nil, errNoSession)
 
 	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
 
@@ -196,9 +268,13 @@ func (s *TxQueueTestSuite) TestAccountMismatch() {
 }
 
 func (s *TxQueueTestSuite) TestInvalidPassword() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
 	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
 		Address: common.FromAddress(TestConfig.Account1.Address),
 	}, nil)
+	s.accountManagerMock.EXPECT().SessionAccount(common.FromAddress(TestConfig.Account1.Address)).Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil)
 
 	s.nodeManagerMock.EXPECT().NodeConfig().Return(
 		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
@@ -242,6 +318,7 @@ func (s *TxQueueTestSuite) TestInvalidPassword() {
 }
 
 func (s *TxQueueTestSuite) TestDiscardTransaction() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
 	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
 
 	txQueueManager.Start()
@@ -277,3 +354,1232 @@ func (s *TxQueueTestSuite) TestDiscardTransaction() {
 	// Transaction should be already removed from the queue.
 	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
 }
+
+func (s *TxQueueTestSuite) TestTransactionTimeout() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	clock := NewFakeClock(time.Now())
+	txQueueManager.SetClock(clock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {
+		s.Equal(tx.ID, queuedTx.ID)
+	})
+
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {
+		s.Equal(tx.ID, queuedTx.ID)
+		s.Equal(ErrQueuedTxTimedOut, err)
+	})
+
+	err := txQueueManager.QueueTransaction(tx)
+	s.NoError(err)
+
+	go func() {
+		clock.BlockUntil(1)
+		clock.Advance(DefaultTxSendCompletionTimeout * time.Second)
+	}()
+
+	err = txQueueManager.WaitForTransaction(tx)
+	s.Equal(ErrQueuedTxTimedOut, err)
+	// Transaction should be already removed from the queue.
+	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
+}
+
+func (s *TxQueueTestSuite) TestDrain() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	clock := NewFakeClock(time.Now())
+	txQueueManager.SetClock(clock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	txQueueManager.SetTransactionQueueHandler(func(*common.QueuedTx) {})
+	newTx := func() *common.QueuedTx {
+		return txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: common.FromAddress(TestConfig.Account1.Address),
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+	}
+
+	tx := newTx()
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	// queue isn't drained until the transaction is discarded
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Equal(context.Canceled, txQueueManager.Drain(ctx))
+	s.Equal(ErrTxQueueDraining, txQueueManager.QueueTransaction(newTx()))
+
+	drained := make(chan error)
+	go func() {
+		drained <- txQueueManager.Drain(context.Background())
+	}()
+
+	clock.BlockUntil(1)
+	s.NoError(txQueueManager.DiscardTransaction(tx.ID))
+	clock.Advance(drainPollInterval)
+	s.NoError(<-drained)
+
+	// started queue accepts transactions again
+	txQueueManager.Start()
+	s.NoError(txQueueManager.QueueTransaction(newTx()))
+}
+
+func (s *TxQueueTestSuite) TestWatchOnlyAccount() {
+	// expectations of the suite's mock allow any account
+	accountManagerMock := common.NewMockAccountManager(s.accountManagerMockCtrl)
+	accountManagerMock.EXPECT().IsWatchOnlyAccount(common.FromAddress(TestConfig.Account1.Address)).Return(true)
+
+	txQueueManager := NewManager(s.nodeManagerMock, accountManagerMock)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+
+	var errorCode string
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {
+		s.Equal(tx.ID, queuedTx.ID)
+		errorCode = txQueueManager.sendTransactionErrorCode(err)
+	})
+
+	err := txQueueManager.QueueTransaction(tx)
+	s.Equal(ErrWatchOnlyAccount, err)
+	s.Equal(SendTransactionWatchOnlyErrorCode, errorCode)
+	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
+}
+
+// keySigner signs transactions of a single account with its key.
+type keySigner struct {
+	key     *ecdsa.PrivateKey
+	chainID *big.Int
+	nonce   uint64
+	signer  types.Signer // signs with it instead of EIP155 signer of the given chain, if set
+}
+
+func (k *keySigner) HasAccount(address gethcommon.Address) bool {
+	return address == crypto.PubkeyToAddress(k.key.PublicKey)
+}
+
+func (k *keySigner) SignTx(address gethcommon.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	k.chainID = chainID
+	k.nonce = tx.Nonce()
+	if k.signer != nil {
+		return types.SignTx(tx, k.signer, k.key)
+	}
+	return types.SignTx(tx, types.NewEIP155Signer(chainID), k.key)
+}
+
+func (s *TxQueueTestSuite) TestCompleteTransactionWithSigner() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	signer := &keySigner{key: key}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	// neither the selected account nor the password are used
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	)
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_getTransactionCount", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Uint(5), nil
+	})
+	var sentTx string
+	client.RegisterHandler("eth_sendRawTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		sentTx = args[0].(string)
+		return nil, nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetTxSigner(signer)
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	gas := hexutil.Big(*big.NewInt(21000))
+	gasPrice := hexutil.Big(*big.NewInt(1))
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From:     from,
+		To:       common.ToAddress(TestConfig.Account2.Address),
+		Gas:      &gas,
+		GasPrice: &gasPrice,
+	})
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+
+	go func() {
+		_, errCompleteTransaction := txQueueManager.CompleteTransaction(tx.ID, "")
+		s.NoError(errCompleteTransaction)
+	}()
+
+	s.NoError(txQueueManager.WaitForTransaction(tx))
+	s.Equal(uint64(5), signer.nonce)
+	s.Equal(big.NewInt(params.RopstenNetworkID), signer.chainID)
+	s.NotEmpty(sentTx)
+	s.Equal(tx.Hash, crypto.Keccak256Hash(gethcommon.FromHex(sentTx)))
+}
+
+func (s *TxQueueTestSuite) TestGasPriceOracle() {
+	suggestion := common.GasPriceSuggestion{
+		Slow:   (*hexutil.Big)(big.NewInt(1)),
+		Normal: (*hexutil.Big)(big.NewInt(2)),
+		Fast:   (*hexutil.Big)(big.NewInt(3)),
+	}
+	oracle := common.NewMockGasPriceOracle(s.nodeManagerMockCtrl)
+	oracle.EXPECT().SuggestGasPrices(gomock.Any()).Return(suggestion).Times(2)
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil) // nothing is estimated
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetGasPriceOracle(oracle)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+
+	// suggestions are sent along with a queued transaction
+	var (
+		rawEvent string
+		event    struct {
+			Type  string               `json:"type"`
+			Event SendTransactionEvent `json:"event"`
+		}
+	)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		rawEvent = jsonEvent
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &event))
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	s.Equal(EventTransactionQueued, event.Type)
+	s.Equal(&suggestion, event.Event.GasPrices)
+	s.Contains(rawEvent, `"gas_prices":{"slow":"0x1","normal":"0x2","fast":"0x3"}`)
+
+	// normal gas price is used for transactions sent without gas price
+	gasPrice, err := txQueueManager.gasPrice(context.Background())
+	s.NoError(err)
+	s.Equal(suggestion.Normal, gasPrice)
+}
+
+func (s *TxQueueTestSuite) TestGasEstimate() {
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_gasPrice", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(2)), nil
+	})
+	var estimateErr error
+	client.RegisterHandler("eth_estimateGas", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(50000)), estimateErr
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+
+	var event struct {
+		Type  string               `json:"type"`
+		Event SendTransactionEvent `json:"event"`
+	}
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &event))
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	queue := func(args common.SendTxArgs) *common.GasEstimate {
+		event.Event.Estimate = nil
+		s.NoError(txQueueManager.QueueTransaction(txQueueManager.CreateTransaction(context.Background(), args)))
+		s.Equal(EventTransactionQueued, event.Type)
+		return event.Event.Estimate
+	}
+
+	// gas estimated by the node is increased by a margin, and the fee is paid at the node's gas price
+	args := common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	}
+	estimate := queue(args)
+	s.Equal(big.NewInt(60000), estimate.Gas.ToInt())
+	s.Equal(big.NewInt(2), estimate.GasPrice.ToInt())
+	s.Equal(big.NewInt(120000), estimate.Fee.ToInt())
+	s.Empty(estimate.Error)
+
+	// given gas and gas price are used as they are
+	args.Gas = (*hexutil.Big)(big.NewInt(21000))
+	args.GasPrice = (*hexutil.Big)(big.NewInt(3))
+	estimate = queue(args)
+	s.Equal(big.NewInt(21000), estimate.Gas.ToInt())
+	s.Equal(big.NewInt(63000), estimate.Fee.ToInt())
+
+	// a transaction which would fail can't be estimated
+	args.Gas = nil
+	estimateErr = errors.New("gas required exceeds allowance or always failing transaction")
+	estimate = queue(args)
+	s.Nil(estimate.Gas)
+	s.Nil(estimate.Fee)
+	s.Equal(estimateErr.Error(), estimate.Error)
+}
+
+func (s *TxQueueTestSuite) TestEstimateBeforeEnqueue() {
+	estimating := make(chan struct{})
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_gasPrice", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(2)), nil
+	})
+	client.RegisterHandler("eth_estimateGas", func(context.Context, ...interface{}) (interface{}, error) {
+		<-estimating
+		return hexutil.Big(*big.NewInt(50000)), nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+
+	tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	})
+	errc := make(chan error, 1)
+	go func() {
+		errc <- txQueueManager.QueueTransaction(tx)
+	}()
+
+	// the queue isn't held while the node estimates the transaction, which is enqueued afterwards
+	time.Sleep(100 * time.Millisecond)
+	s.Empty(txQueueManager.PendingTransactions())
+	s.False(txQueueManager.TransactionQueue().Has(tx.ID))
+
+	close(estimating)
+	s.NoError(<-errc)
+	pending := txQueueManager.PendingTransactions()
+	s.Len(pending, 1)
+	s.Equal(big.NewInt(60000), pending[0].Estimate.Gas.ToInt())
+}
+
+func (s *TxQueueTestSuite) TestCallPreview() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	decoder := common.NewMockCallDecoder(s.nodeManagerMockCtrl)
+	txQueueManager.SetCallDecoder(decoder)
+
+	var event struct {
+		Type  string               `json:"type"`
+		Event SendTransactionEvent `json:"event"`
+	}
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &event))
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	args := common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+		Data: hexutil.Bytes{0xa9, 0x05, 0x9c, 0xbb},
+	}
+	preview := &common.CallPreview{
+		Method:    "transfer",
+		Signature: "transfer(address,uint256)",
+		Args:      []common.CallArgument{{Name: "value", Type: "uint256", Value: "1000"}},
+	}
+	decoder.EXPECT().DecodeCall(args.To, []byte(args.Data)).Return(preview).Times(2)
+
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {})
+	tx := txQueueManager.CreateTransaction(context.Background(), args)
+	s.NoError(txQueueManager.QueueTransaction(tx))
+	defer txQueueManager.DiscardTransaction(tx.ID) // nolint: errcheck
+
+	s.Equal(EventTransactionQueued, event.Type)
+	s.Equal(preview, event.Event.Call)
+
+	pending := txQueueManager.PendingTransactions()
+	s.Len(pending, 1)
+	s.Equal(preview, pending[0].Call)
+
+	// calls aren't decoded without a decoder
+	txQueueManager.SetCallDecoder(nil)
+	event.Event.Call = nil
+	txQueueManager.TransactionQueueHandler()(tx)
+	s.Nil(event.Event.Call)
+}
+
+func (s *TxQueueTestSuite) TestRateLimit() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes()
+
+	clock := NewFakeClock(time.Now())
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetClock(clock)
+	txQueueManager.Configure(params.TxQueueConfig{RateLimit: 0.5, RateBurst: 2})
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {})
+
+	var throttled []ThrottleTransactionsEvent
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string                    `json:"type"`
+			Event ThrottleTransactionsEvent `json:"event"`
+		}
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &envelope))
+		s.Equal(EventTransactionsThrottled, envelope.Type)
+		throttled = append(throttled, envelope.Event)
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	args := common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	}
+	queue := func(chatID string) error {
+		ctx := context.Background()
+		if chatID != "" {
+			ctx = context.WithValue(ctx, common.ChatIDKey, chatID)
+		}
+		return txQueueManager.QueueTransaction(txQueueManager.CreateTransaction(ctx, args))
+	}
+
+	// a DApp can request the burst at once, and is signalled once as it's throttled
+	s.NoError(queue("dapp"))
+	s.NoError(queue("dapp"))
+	s.Equal(ErrTxRateLimited, queue("dapp"))
+	s.Equal(ErrTxRateLimited, queue("dapp"))
+	s.Equal([]ThrottleTransactionsEvent{{ChatID: "dapp", RateLimit: 0.5, RateBurst: 2}}, throttled)
+
+	// other DApps and transactions requested by the user are not affected
+	s.NoError(queue("other"))
+	s.NoError(queue(""))
+	s.NoError(queue(""))
+	s.NoError(queue(""))
+
+	// requests are allowed again at the rate
+	clock.Advance(2 * time.Second)
+	s.NoError(queue("dapp"))
+	s.Equal(ErrTxRateLimited, queue("dapp"))
+	s.Len(throttled, 1)
+
+	// and the DApp is signalled again after the signal window
+	clock.Advance(throttleSignalWindow)
+	s.NoError(queue("dapp"))
+	s.NoError(queue("dapp"))
+	s.Equal(ErrTxRateLimited, queue("dapp"))
+	s.Len(throttled, 2)
+}
+
+func (s *TxQueueTestSuite) TestSignAndBroadcastTransaction() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	signer := &keySigner{key: key}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_getTransactionCount", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Uint(5), nil
+	})
+	client.RegisterHandler("eth_getTransactionByHash", func(context.Context, ...interface{}) (interface{}, error) {
+		return json.RawMessage(`{}`), nil
+	})
+	var broadcast []string
+	client.RegisterHandler("eth_sendRawTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		broadcast = append(broadcast, args[0].(string))
+		return nil, nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(&params.NodeConfig{NetworkID: params.RopstenNetworkID}, nil).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetTxSigner(signer)
+	watcher := common.NewMockTxWatcher(s.nodeManagerMockCtrl)
+	txQueueManager.SetTxWatcher(watcher)
+
+	gas := hexutil.Big(*big.NewInt(21000))
+	gasPrice := hexutil.Big(*big.NewInt(1))
+	args := common.SendTxArgs{
+		From:     from,
+		To:       common.ToAddress(TestConfig.Account2.Address),
+		Gas:      &gas,
+		GasPrice: &gasPrice,
+	}
+
+	// a signed transaction isn't sent, and its nonce isn't reserved
+	raw, hash, err := txQueueManager.SignTransaction(args, "")
+	s.NoError(err)
+	s.Empty(broadcast)
+	tx := new(types.Transaction)
+	s.NoError(rlp.DecodeBytes(raw, tx))
+	s.Equal(hash, tx.Hash())
+	s.Equal(uint64(5), tx.Nonce())
+	s.Equal(big.NewInt(int64(params.RopstenNetworkID)), tx.ChainId())
+
+	_, _, err = txQueueManager.SignTransaction(args, "")
+	s.NoError(err)
+	s.Equal(uint64(5), signer.nonce)
+
+	// until it's broadcast
+	watcher.EXPECT().Watch(hash, from)
+	sentHash, err := txQueueManager.BroadcastRawTransaction(context.Background(), raw)
+	s.NoError(err)
+	s.Equal(hash, sentHash)
+	s.Equal([]string{raw.String()}, broadcast)
+
+	_, _, err = txQueueManager.SignTransaction(args, "")
+	s.NoError(err)
+	s.Equal(uint64(6), signer.nonce)
+
+	// transactions signed for another network are not broadcast
+	mainnetTx, err := types.SignTx(types.NewTransaction(6, from, big.NewInt(0), big.NewInt(21000), big.NewInt(1), nil),
+		types.NewEIP155Signer(big.NewInt(int64(params.MainNetworkID))), key)
+	s.NoError(err)
+	mainnetRaw, err := rlp.EncodeToBytes(mainnetTx)
+	s.NoError(err)
+	_, err = txQueueManager.BroadcastRawTransaction(context.Background(), mainnetRaw)
+	s.Equal(ErrInvalidChainID, err)
+
+	_, err = txQueueManager.BroadcastRawTransaction(context.Background(), hexutil.Bytes{0x01})
+	s.Error(err)
+	s.Len(broadcast, 1)
+}
+
+func (s *TxQueueTestSuite) TestQueueTokenTransfer() {
+	token := gethcommon.HexToAddress("0x744d70fdbe2ba4cf95131626614a1763df805b9e")
+	to := gethcommon.HexToAddress(TestConfig.Account2.Address)
+	amount := new(big.Int).Mul(big.NewInt(15), new(big.Int).Exp(big.NewInt(10), big.NewInt(17), nil))
+
+	symbol := make(hexutil.Bytes, 32)
+	copy(symbol, "SNT") // returned as bytes32
+	decimals := make(hexutil.Bytes, 32)
+	decimals[31] = 18
+
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_call", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		call, err := json.Marshal(args[0])
+		s.NoError(err)
+		if strings.Contains(string(call), hexutil.Encode(abi.PackSymbol())) {
+			return symbol, nil
+		}
+		return decimals, nil
+	})
+	client.RegisterHandler("eth_estimateGas", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(50000)), nil
+	})
+	client.RegisterHandler("eth_gasPrice", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(1)), nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {})
+
+	queued := make(chan SendTransactionEvent, 1)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string               `json:"type"`
+			Event SendTransactionEvent `json:"event"`
+		}
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &envelope))
+		if envelope.Type == EventTransactionQueued {
+			queued <- envelope.Event
+		}
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := txQueueManager.QueueTokenTransfer(context.Background(), token, to, amount)
+		errc <- err
+	}()
+
+	var event SendTransactionEvent
+	select {
+	case event = <-queued:
+	case <-time.After(time.Second):
+		s.FailNow("transfer wasn't queued")
+	}
+
+	data, err := abi.PackTransfer(to, amount)
+	s.NoError(err)
+	s.Equal(&token, event.Args.To)
+	s.Equal(hexutil.Bytes(data), hexutil.Bytes(event.Args.Data))
+	s.Equal(big.NewInt(60000), event.Args.Gas.ToInt())
+
+	eighteen := uint8(18)
+	s.Equal(&common.TokenTransfer{
+		Token:     token,
+		To:        to,
+		Amount:    (*hexutil.Big)(amount),
+		Symbol:    "SNT",
+		Decimals:  &eighteen,
+		Formatted: "1.5",
+	}, event.Token)
+
+	pending := txQueueManager.PendingTransactions()
+	s.Len(pending, 1)
+	s.Equal(event.Token, pending[0].Token)
+
+	s.NoError(txQueueManager.DiscardTransaction(common.QueuedTxID(event.ID)))
+	s.Equal(ErrQueuedTxDiscarded, <-errc)
+
+	_, err = txQueueManager.QueueTokenTransfer(context.Background(), token, to, big.NewInt(-1))
+	s.Equal(ErrInvalidTokenAmount, err)
+
+	s.Equal("0.000001", formatTokenAmount(big.NewInt(1000000000000), 18))
+	s.Equal("1000", formatTokenAmount(big.NewInt(1000), 0))
+	s.Equal("10", formatTokenAmount(big.NewInt(1000), 2))
+	s.Equal("0", formatTokenAmount(big.NewInt(0), 18))
+}
+
+func (s *TxQueueTestSuite) TestNonceTracking() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	signer := &keySigner{key: key}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	// the node keeps counting 5 pending transactions, as if it lagged behind
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_getTransactionCount", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Uint(5), nil
+	})
+	var sendErr error
+	client.RegisterHandler("eth_sendRawTransaction", func(context.Context, ...interface{}) (interface{}, error) {
+		return nil, sendErr
+	})
+	dropped := make(map[string]bool)
+	client.RegisterHandler("eth_getTransactionByHash", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if dropped[args[0].(gethcommon.Hash).Hex()] {
+			return nil, nil
+		}
+		return json.RawMessage(`{}`), nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	gas := hexutil.Big(*big.NewInt(21000))
+	gasPrice := hexutil.Big(*big.NewInt(1))
+	var sent int64
+	send := func() (uint64, gethcommon.Hash, error) {
+		sent++ // transactions differ in value, so that a replacement isn't the dropped transaction
+		tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From:     from,
+			To:       common.ToAddress(TestConfig.Account2.Address),
+			Gas:      &gas,
+			GasPrice: &gasPrice,
+			Value:    (*hexutil.Big)(big.NewInt(sent)),
+		})
+		hash, err := txQueueManager.sendRawTransaction(tx, &params.NodeConfig{NetworkID: params.RopstenNetworkID}, func(tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+			return signer.SignTx(from, tx, chainID)
+		})
+		return signer.nonce, hash, err
+	}
+
+	// transactions sent in quick succession get consecutive nonces
+	var hashes []gethcommon.Hash
+	for _, expected := range []uint64{5, 6, 7} {
+		nonce, hash, err := send()
+		s.NoError(err)
+		s.Equal(expected, nonce)
+		hashes = append(hashes, hash)
+	}
+
+	// nonce of a transaction unknown to the node is reused
+	dropped[hashes[1].Hex()] = true
+	nonce, _, err := send()
+	s.NoError(err)
+	s.Equal(uint64(6), nonce)
+	nonce, _, err = send()
+	s.NoError(err)
+	s.Equal(uint64(8), nonce)
+
+	// nonce of a failed transaction is reused
+	sendErr = errors.New("insufficient funds for gas * price + value")
+	_, _, err = send()
+	s.Error(err)
+	sendErr = nil
+	nonce, _, err = send()
+	s.NoError(err)
+	s.Equal(uint64(9), nonce)
+
+	// tracked nonces are forgotten on reset and when the node reports a used nonce
+	txQueueManager.ResetNonce(from)
+	nonce, _, err = send()
+	s.NoError(err)
+	s.Equal(uint64(5), nonce)
+
+	sendErr = errors.New("nonce too low")
+	_, _, err = send()
+	s.Error(err)
+	sendErr = nil
+	nonce, _, err = send()
+	s.NoError(err)
+	s.Equal(uint64(5), nonce)
+}
+
+func (s *TxQueueTestSuite) TestReplaceTransactionNodeStopped() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).Times(2)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+
+	_, err := txQueueManager.SpeedUpTransaction(context.Background(), gethcommon.HexToHash("0x01"), big.NewInt(200))
+	s.Equal(ErrNoRPCClient, err)
+	_, err = txQueueManager.CancelTransaction(context.Background(), gethcommon.HexToHash("0x01"))
+	s.Equal(ErrNoRPCClient, err)
+}
+
+func (s *TxQueueTestSuite) TestReplaceTransaction() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	signer := &keySigner{key: key}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.ToAddress(TestConfig.Account2.Address)
+
+	pending := gethcommon.HexToHash("0x01")
+	mined := gethcommon.HexToHash("0x02")
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_getTransactionByHash", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		tx := rpcTransaction{
+			From:     from,
+			To:       to,
+			Gas:      (*hexutil.Big)(big.NewInt(50000)),
+			GasPrice: (*hexutil.Big)(big.NewInt(100)),
+			Value:    (*hexutil.Big)(big.NewInt(1)),
+			Input:    hexutil.Bytes{0x01},
+			Nonce:    3,
+		}
+		switch args[0].(gethcommon.Hash) {
+		case pending:
+		case mined:
+			tx.BlockNumber = (*hexutil.Big)(big.NewInt(1))
+		default:
+			return nil, nil
+		}
+		return tx, nil
+	})
+	client.RegisterHandler("eth_gasPrice", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(120)), nil
+	})
+	var sentTx string
+	client.RegisterHandler("eth_sendRawTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		sentTx = args[0].(string)
+		return nil, nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetTxSigner(signer)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	queued := make(chan *common.QueuedTx, 1)
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {
+		queued <- queuedTx
+	})
+	var replaced ReplaceTransactionEvent
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string                  `json:"type"`
+			Event ReplaceTransactionEvent `json:"event"`
+		}
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &envelope))
+		s.Equal(EventTransactionReplaced, envelope.Type)
+		replaced = envelope.Event
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	// replacements are approved as any other transaction
+	approve := func(replace func() (gethcommon.Hash, error)) (*common.QueuedTx, gethcommon.Hash) {
+		results := make(chan gethcommon.Hash, 1)
+		go func() {
+			hash, err := replace()
+			s.NoError(err)
+			results <- hash
+		}()
+		tx := <-queued
+		s.Equal(pending, tx.Replaces)
+		_, err := txQueueManager.CompleteTransaction(tx.ID, "")
+		s.NoError(err)
+		return tx, <-results
+	}
+
+	// gas price of a faster transaction must be at least 10% higher
+	_, err = txQueueManager.SpeedUpTransaction(context.Background(), pending, big.NewInt(109))
+	s.Equal(ErrReplacementGasPriceTooLow, err)
+	_, err = txQueueManager.SpeedUpTransaction(context.Background(), mined, big.NewInt(200))
+	s.Equal(ErrTxAlreadyMined, err)
+	_, err = txQueueManager.CancelTransaction(context.Background(), gethcommon.HexToHash("0x03"))
+	s.Equal(ErrTxNotFound, err)
+
+	tx, hash := approve(func() (gethcommon.Hash, error) {
+		return txQueueManager.SpeedUpTransaction(context.Background(), pending, big.NewInt(110))
+	})
+	s.Equal(uint64(3), signer.nonce)
+	s.Equal(big.NewInt(110), tx.Args.GasPrice.ToInt())
+	s.Equal(big.NewInt(50000), tx.Args.Gas.ToInt())
+	s.Equal(big.NewInt(1), tx.Args.Value.ToInt())
+	s.Equal(to, tx.Args.To)
+	s.Equal(hexutil.Bytes{0x01}, tx.Args.Data)
+	s.Equal(hash, crypto.Keccak256Hash(gethcommon.FromHex(sentTx)))
+	s.Equal(ReplaceTransactionEvent{ID: string(tx.ID), OldHash: pending, NewHash: hash}, replaced)
+
+	// cancelling transaction pays the suggested gas price, if it's higher than the minimal one
+	tx, hash = approve(func() (gethcommon.Hash, error) {
+		return txQueueManager.CancelTransaction(context.Background(), pending)
+	})
+	s.Equal(uint64(3), signer.nonce)
+	s.Equal(big.NewInt(120), tx.Args.GasPrice.ToInt())
+	s.Equal(big.NewInt(cancelGas), tx.Args.Gas.ToInt())
+	s.Equal(0, tx.Args.Value.ToInt().Sign())
+	s.Equal(from, *tx.Args.To)
+	s.Empty(tx.Args.Data)
+	s.Equal(ReplaceTransactionEvent{ID: string(tx.ID), OldHash: pending, NewHash: hash, Cancel: true}, replaced)
+}
+
+func (s *TxQueueTestSuite) TestRestoreQueue() {
+	dir, err := ioutil.TempDir("", "txqueue")
+	s.NoError(err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+	path := filepath.Join(dir, QueuedTransactionsFile)
+	start := time.Now()
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
+
+	// transactions are saved as they are queued
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetClock(NewFakeClock(start))
+	txQueueManager.Start()
+	s.NoError(txQueueManager.RestoreQueue(path))
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	args := common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+		Data: hexutil.Bytes{0x01},
+	}
+	ctx := context.WithValue(context.Background(), common.MessageIDKey, "message")
+	ctx = context.WithValue(ctx, common.ChatIDKey, "dapp")
+	waiting := txQueueManager.CreateTransaction(ctx, args)
+	s.Equal("dapp", waiting.ChatID)
+	expired := txQueueManager.CreateTransaction(ctx, args)
+	waiting.Replaces = gethcommon.HexToHash("0x01")
+	discarded := txQueueManager.CreateTransaction(context.Background(), args)
+	expired.Deadline = start.Add(time.Minute)
+	for _, tx := range []*common.QueuedTx{waiting, discarded, expired} {
+		s.NoError(txQueueManager.QueueTransaction(tx))
+	}
+	saved, err := loadQueuedTransactions(path)
+	s.NoError(err)
+	s.Len(saved, 3)
+	txQueueManager.Stop()
+
+	// and restored with their original deadlines after a restart
+	signals := make(chan string, 10)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		signals <- jsonEvent
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	clock := NewFakeClock(start.Add(2 * time.Minute))
+	txQueueManager = NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetClock(clock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+	txQueueManager.SetTransactionReturnHandler(txQueueManager.TransactionReturnHandler())
+	s.NoError(txQueueManager.RestoreQueue(path))
+
+	queued := make(map[common.QueuedTxID]SendTransactionEvent)
+	failed := make(map[common.QueuedTxID]ReturnSendTransactionEvent)
+	for i := 0; i < 3; i++ {
+		var envelope struct {
+			Type  string          `json:"type"`
+			Event json.RawMessage `json:"event"`
+		}
+		s.NoError(json.Unmarshal([]byte(<-signals), &envelope))
+		switch envelope.Type {
+		case EventTransactionQueued:
+			var event SendTransactionEvent
+			s.NoError(json.Unmarshal(envelope.Event, &event))
+			queued[common.QueuedTxID(event.ID)] = event
+		case EventTransactionFailed:
+			var event ReturnSendTransactionEvent
+			s.NoError(json.Unmarshal(envelope.Event, &event))
+			failed[common.QueuedTxID(event.ID)] = event
+		}
+	}
+	s.Len(queued, 2)
+	s.True(queued[waiting.ID].Restored)
+	s.Equal("message", queued[waiting.ID].MessageID)
+	s.Equal("dapp", queued[waiting.ID].ChatID)
+	s.Equal(&waiting.Replaces, queued[waiting.ID].Replaces)
+	s.Equal(args, queued[waiting.ID].Args)
+	s.True(queued[discarded.ID].Restored)
+	s.Len(failed, 1)
+	s.Equal(SendTransactionTimeoutErrorCode, failed[expired.ID].ErrorCode)
+	s.Equal("dapp", failed[expired.ID].ChatID)
+	s.Empty(queued[discarded.ID].ChatID)
+	s.False(txQueueManager.TransactionQueue().Has(expired.ID))
+	clock.BlockUntil(2) // restored transactions wait for approval
+
+	// restored transactions are removed from the file once they leave the queue
+	s.NoError(txQueueManager.DiscardTransaction(discarded.ID))
+	s.Contains(<-signals, EventTransactionFailed)
+	saved = s.waitForSavedQueue(path, 1)
+	s.Equal(waiting.ID, saved[0].ID)
+
+	clock.Advance(DefaultTxSendCompletionTimeout*time.Second - 2*time.Minute)
+	s.Contains(<-signals, EventTransactionFailed)
+	s.False(txQueueManager.TransactionQueue().Has(waiting.ID))
+	s.waitForSavedQueue(path, 0)
+}
+
+// waitForSavedQueue waits until a given number of transactions is saved, as the queue is saved
+// after the failed signal of a transaction which left it is sent.
+func (s *TxQueueTestSuite) waitForSavedQueue(path string, count int) []persistedTx {
+	deadline := time.Now().Add(time.Second)
+	for {
+		saved, err := loadQueuedTransactions(path)
+		s.NoError(err)
+		if len(saved) == count || time.Now().After(deadline) {
+			s.Len(saved, count)
+			return saved
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func (s *TxQueueTestSuite) TestQueueLimits() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	start := time.Now()
+	clock := NewFakeClock(start)
+	txQueueManager.SetClock(clock)
+	txQueueManager.Configure(params.TxQueueConfig{Capacity: 2, TTL: 60})
+
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+	errorCodes := make(chan string, 3)
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {
+		errorCodes <- txQueueManager.sendTransactionErrorCode(err)
+	})
+
+	var txs []*common.QueuedTx
+	results := make([]chan error, 3)
+	for i := range results {
+		tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: common.FromAddress(TestConfig.Account1.Address),
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+		s.NoError(txQueueManager.QueueTransaction(tx))
+		s.Equal(start.Add(time.Minute), tx.Deadline)
+		txs = append(txs, tx)
+
+		results[i] = make(chan error, 1)
+		go func(tx *common.QueuedTx, result chan error) {
+			result <- txQueueManager.WaitForTransaction(tx)
+		}(tx, results[i])
+	}
+
+	// the oldest transaction is evicted to make room for newer ones
+	s.Equal(ErrQueuedTxEvicted, <-results[0])
+	s.Equal(SendTransactionEvictedErrorCode, <-errorCodes)
+	s.False(txQueueManager.TransactionQueue().Has(txs[0].ID))
+
+	// and the newest one expires after the configured TTL
+	clock.BlockUntil(3)
+	clock.Advance(time.Minute)
+	s.Equal(ErrQueuedTxTimedOut, <-results[2])
+}
+
+func (s *TxQueueTestSuite) TestCompleteTransactionsBatch() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	signer := &keySigner{key: key}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_getTransactionCount", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Uint(5), nil
+	})
+	client.RegisterHandler("eth_getTransactionByHash", func(context.Context, ...interface{}) (interface{}, error) {
+		return json.RawMessage(`{}`), nil
+	})
+	var sent []uint64
+	var sendErr error
+	client.RegisterHandler("eth_sendRawTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		if sendErr == nil {
+			sent = append(sent, signer.nonce)
+		}
+		return nil, sendErr
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetTxSigner(signer)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	var completed CompleteTransactionsEvent
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var envelope struct {
+			Type  string                    `json:"type"`
+			Event CompleteTransactionsEvent `json:"event"`
+		}
+		s.NoError(json.Unmarshal([]byte(jsonEvent), &envelope))
+		s.Equal(EventTransactionsCompleted, envelope.Type)
+		completed = envelope.Event
+	})
+	defer signal.ResetDefaultNodeNotificationHandler()
+
+	gas := hexutil.Big(*big.NewInt(21000))
+	gasPrice := hexutil.Big(*big.NewInt(1))
+	queue := func() common.QueuedTxID {
+		tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From:     from,
+			To:       common.ToAddress(TestConfig.Account2.Address),
+			Gas:      &gas,
+			GasPrice: &gasPrice,
+		})
+		s.NoError(txQueueManager.QueueTransaction(tx))
+		return tx.ID
+	}
+	approve, transfer := queue(), queue()
+
+	// nothing is sent if any of transactions can't be
+	results := txQueueManager.CompleteTransactions([]common.QueuedTxID{approve, "unknown", transfer}, "")
+	s.Equal(ErrQueuedTxBatchAborted, results[approve].Error)
+	s.Equal(ErrQueuedTxIDNotFound, results["unknown"].Error)
+	s.Equal(ErrQueuedTxBatchAborted, results[transfer].Error)
+	s.Empty(sent)
+	s.True(txQueueManager.TransactionQueue().Has(approve))
+	s.True(txQueueManager.TransactionQueue().Has(transfer))
+	s.Len(completed.Results, 3)
+	s.Equal(SendTransactionAbortedErrorCode, completed.Results[0].ErrorCode)
+	s.Equal(SendTxDefaultErrorCode, completed.Results[1].ErrorCode)
+
+	// sending stops at the first failed transaction
+	sendErr = errors.New("insufficient funds for gas * price + value")
+	results = txQueueManager.CompleteTransactions([]common.QueuedTxID{approve, transfer}, "")
+	s.Equal(sendErr, results[approve].Error)
+	s.Equal(ErrQueuedTxBatchAborted, results[transfer].Error)
+	s.True(txQueueManager.TransactionQueue().Has(transfer))
+
+	// transactions are sent in the given order
+	sendErr = nil
+	approve = queue()
+	results = txQueueManager.CompleteTransactions([]common.QueuedTxID{approve, transfer}, "")
+	s.NoError(results[approve].Error)
+	s.NoError(results[transfer].Error)
+	s.Equal([]uint64{5, 6}, sent)
+	s.Len(completed.Results, 2)
+	s.Equal(string(approve), completed.Results[0].ID)
+	s.Equal(results[approve].Hash, completed.Results[0].Hash)
+	s.Equal(SendTransactionNoErrorCode, completed.Results[0].ErrorCode)
+	s.Equal(string(transfer), completed.Results[1].ID)
+}
+
+func (s *TxQueueTestSuite) TestCompleteTransactionsBatchInvalidPassword() {
+	s.nodeManagerMock.EXPECT().RPCClient().Return(nil).AnyTimes() // nothing is estimated
+	s.accountManagerMock.EXPECT().SelectedAccount().Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil).AnyTimes()
+	s.accountManagerMock.EXPECT().SessionAccount(common.FromAddress(TestConfig.Account1.Address)).Return(&common.SelectedExtKey{
+		Address: common.FromAddress(TestConfig.Account1.Address),
+	}, nil).AnyTimes()
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	).AnyTimes()
+	s.accountManagerMock.EXPECT().VerifyAccountPassword(gomock.Any(), gomock.Any(), "invalid-password").
+		Return(nil, keystore.ErrDecrypt)
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	var ids []common.QueuedTxID
+	for i := 0; i < 2; i++ {
+		tx := txQueueManager.CreateTransaction(context.Background(), common.SendTxArgs{
+			From: common.FromAddress(TestConfig.Account1.Address),
+			To:   common.ToAddress(TestConfig.Account2.Address),
+		})
+		s.NoError(txQueueManager.QueueTransaction(tx))
+		ids = append(ids, tx.ID)
+	}
+
+	// the password is verified before anything is sent, and transactions stay queued
+	results := txQueueManager.CompleteTransactions(ids, "invalid-password")
+	s.Equal(keystore.ErrDecrypt, results[ids[0]].Error)
+	s.Equal(ErrQueuedTxBatchAborted, results[ids[1]].Error)
+	s.True(txQueueManager.TransactionQueue().Has(ids[0]))
+	s.True(txQueueManager.TransactionQueue().Has(ids[1]))
+}
+
+func (s *TxQueueTestSuite) TestChainIDValidation() {
+	key, err := crypto.GenerateKey()
+	s.NoError(err)
+	signer := &keySigner{key: key}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_getTransactionCount", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Uint(5), nil
+	})
+	client.RegisterHandler("eth_getTransactionByHash", func(context.Context, ...interface{}) (interface{}, error) {
+		return json.RawMessage(`{}`), nil
+	})
+	sent := 0
+	client.RegisterHandler("eth_sendRawTransaction", func(context.Context, ...interface{}) (interface{}, error) {
+		sent++
+		return nil, nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+	config, err := params.NewNodeConfig("/tmp", params.RopstenNetworkID, true)
+	s.NoError(err)
+	s.nodeManagerMock.EXPECT().NodeConfig().Return(config, nil).AnyTimes()
+
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetTxSigner(signer)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(func(queuedTx *common.QueuedTx) {})
+
+	gas := hexutil.Big(*big.NewInt(21000))
+	gasPrice := hexutil.Big(*big.NewInt(1))
+	complete := func(chainID int64) error {
+		args := common.SendTxArgs{
+			From:     from,
+			To:       common.ToAddress(TestConfig.Account2.Address),
+			Gas:      &gas,
+			GasPrice: &gasPrice,
+		}
+		if chainID != 0 {
+			args.ChainID = (*hexutil.Big)(big.NewInt(chainID))
+		}
+		tx := txQueueManager.CreateTransaction(context.Background(), args)
+		s.NoError(txQueueManager.QueueTransaction(tx))
+		_, err := txQueueManager.CompleteTransaction(tx.ID, "")
+		return err
+	}
+
+	// a transaction meant for another network is never signed
+	s.Equal(ErrInvalidChainID, complete(params.MainNetworkID))
+	s.Equal(0, sent)
+	s.Equal(SendTransactionChainIDErrorCode, txQueueManager.sendTransactionErrorCode(ErrInvalidChainID))
+	s.NoError(complete(params.RopstenNetworkID))
+	s.NoError(complete(0))
+	s.Equal(2, sent)
+
+	// a signer may sign without replay protection, or for another chain
+	signer.signer = types.HomesteadSigner{}
+	s.Equal(ErrUnprotectedTx, complete(0))
+	s.Equal(SendTransactionChainIDErrorCode, txQueueManager.sendTransactionErrorCode(ErrUnprotectedTx))
+	config.TxQueueConfig.AllowUnprotectedTxs = true
+	s.NoError(complete(0))
+	signer.signer = types.NewEIP155Signer(big.NewInt(params.MainNetworkID))
+	s.Equal(ErrInvalidChainID, complete(0))
+	s.Equal(3, sent)
+}
+
+func (s *TxQueueTestSuite) TestPendingTransactions() {
+	client, err := rpc.NewClient(nil, params.UpstreamRPCConfig{})
+	s.NoError(err)
+	client.RegisterHandler("eth_gasPrice", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(2)), nil
+	})
+	client.RegisterHandler("eth_estimateGas", func(context.Context, ...interface{}) (interface{}, error) {
+		return hexutil.Big(*big.NewInt(50000)), nil
+	})
+	s.nodeManagerMock.EXPECT().RPCClient().Return(client).AnyTimes()
+
+	start := time.Now()
+	clock := NewFakeClock(start)
+	txQueueManager := NewManager(s.nodeManagerMock, s.accountManagerMock)
+	txQueueManager.SetClock(clock)
+	txQueueManager.Start()
+	defer txQueueManager.Stop()
+	txQueueManager.SetTransactionQueueHandler(txQueueManager.TransactionQueueHandler())
+	txQueueManager.SetTransactionReturnHandler(func(queuedTx *common.QueuedTx, err error) {})
+
+	args := common.SendTxArgs{
+		From: common.FromAddress(TestConfig.Account1.Address),
+		To:   common.ToAddress(TestConfig.Account2.Address),
+	}
+	waitErrs := make(chan error, 2)
+	queue := func(ctx context.Context) *common.QueuedTx {
+		tx := txQueueManager.CreateTransaction(ctx, args)
+		s.NoError(txQueueManager.QueueTransaction(tx))
+		go func() {
+			waitErrs <- txQueueManager.WaitForTransaction(tx)
+		}()
+		return tx
+	}
+	first := queue(context.WithValue(context.Background(), common.MessageIDKey, "message"))
+	clock.Advance(time.Second)
+	second := queue(context.Background())
+
+	// transactions are listed in the order they were queued, with their estimates
+	pending := txQueueManager.PendingTransactions()
+	s.Len(pending, 2)
+	s.Equal(first.ID, pending[0].ID)
+	s.Equal("message", pending[0].MessageID)
+	s.True(start.Equal(pending[0].QueuedAt))
+	s.True(start.Add(DefaultTxSendCompletionTimeout * time.Second).Equal(pending[0].Deadline))
+	s.Equal(big.NewInt(60000), pending[0].Estimate.Gas.ToInt())
+	s.Equal(big.NewInt(120000), pending[0].Estimate.Fee.ToInt())
+	s.Equal(second.ID, pending[1].ID)
+	s.True(start.Add(time.Second).Equal(pending[1].QueuedAt))
+
+	// all transactions are discarded except for ones being sent
+	s.NoError(txQueueManager.txQueue.StartProcessing(second))
+	results := txQueueManager.DiscardAllTransactions()
+	s.Len(results, 2)
+	s.NoError(results[first.ID].Error)
+	s.Equal(ErrQueuedTxInProgress, results[second.ID].Error)
+	s.Equal(ErrQueuedTxDiscarded, <-waitErrs)
+
+	pending = txQueueManager.PendingTransactions()
+	s.Len(pending, 1)
+	s.Equal(second.ID, pending[0].ID)
+	s.True(pending[0].InProgress)
+}
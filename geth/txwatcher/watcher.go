@@ -0,0 +1,429 @@
+// Package txwatcher follows transactions sent by the node until they are confirmed, and signals changes of their
+// states: mined, reverted, confirmed by a number of blocks, returned to pending by a reorg, dropped or replaced.
+// Receipts are requested over RPC, so from the upstream node if it's enabled, when a new block arrives.
+package txwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/rpc"
+	"github.com/status-im/status-go/geth/signal"
+)
+
+const (
+	// EventTransactionPending is triggered when a transaction is watched, and when it's not mined anymore after a reorg
+	EventTransactionPending = "transaction.pending"
+
+	// EventTransactionMined is triggered when a transaction is mined successfully
+	EventTransactionMined = "transaction.mined"
+
+	// EventTransactionReverted is triggered when a transaction is mined, but its execution failed
+	EventTransactionReverted = "transaction.reverted"
+
+	// EventTransactionConfirmed is triggered when a mined transaction is confirmed by enough blocks
+	EventTransactionConfirmed = "transaction.confirmed"
+
+	// EventTransactionDropped is triggered when a transaction is dropped by the node, or replaced by another
+	// transaction with the same nonce
+	EventTransactionDropped = "transaction.dropped"
+)
+
+const (
+	// dropTimeout is how long a transaction may be unknown to the node before it's considered dropped
+	dropTimeout = 10 * time.Minute
+
+	// chainHeadChanSize is a size of the channel receiving new chain heads, which are received promptly
+	chainHeadChanSize = 10
+
+	// pollTimeout limits how long requests of a single check of watched transactions may take
+	pollTimeout = time.Minute
+)
+
+// TransactionEvent is a signal of a change of a state of a watched transaction
+type TransactionEvent struct {
+	Hash          gethcommon.Hash    `json:"hash"`
+	From          gethcommon.Address `json:"from"`
+	BlockNumber   *hexutil.Uint64    `json:"block_number,omitempty"`
+	BlockHash     *gethcommon.Hash   `json:"block_hash,omitempty"`
+	GasUsed       *hexutil.Big       `json:"gas_used,omitempty"`
+	Confirmations uint64             `json:"confirmations,omitempty"`
+	Reverted      bool               `json:"reverted,omitempty"`
+	Replaced      bool               `json:"replaced,omitempty"`
+	ReplacedBy    *gethcommon.Hash   `json:"replaced_by,omitempty"`
+}
+
+// rpcReceipt is a receipt as returned by eth_getTransactionReceipt
+type rpcReceipt struct {
+	BlockHash   gethcommon.Hash `json:"blockHash"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	GasUsed     *hexutil.Big    `json:"gasUsed"`
+	Status      *hexutil.Uint   `json:"status"` // missing before Byzantium
+}
+
+// reverted reports whether execution of a mined transaction failed.
+func (r *rpcReceipt) reverted() bool {
+	return r.Status != nil && *r.Status == 0
+}
+
+// rpcTransaction is a transaction as returned by eth_getTransactionByHash
+type rpcTransaction struct {
+	Nonce hexutil.Uint64 `json:"nonce"`
+}
+
+// watchedTx is a transaction followed by the watcher.
+type watchedTx struct {
+	hash    gethcommon.Hash
+	from    gethcommon.Address
+	nonce   *uint64     // known once the node returns the transaction
+	seenAt  time.Time   // when the node returned the transaction last
+	head    uint64      // block number the transaction was checked at last
+	checked bool        // true once the transaction was checked at a block
+	receipt *rpcReceipt // set while the transaction is mined
+}
+
+// Watcher follows sent transactions and signals their states. It implements common.TxWatcher.
+type Watcher struct {
+	nodeManager common.NodeManager
+	clock       common.Clock
+
+	mu            sync.Mutex
+	confirmations uint64
+	pollInterval  time.Duration
+	txs           map[gethcommon.Hash]*watchedTx
+	quit          chan struct{} // closed when the watcher is stopped, nil while it's not started
+	wg            sync.WaitGroup
+
+	signals signal.Sender // sends signals of watched transactions, see SetSignalHandler
+}
+
+// NewWatcher returns a watcher requesting receipts with the RPC client of a node.
+func NewWatcher(nodeManager common.NodeManager) *Watcher {
+	return &Watcher{
+		nodeManager:   nodeManager,
+		clock:         common.SystemClock,
+		confirmations: params.TxWatcherConfirmations,
+		pollInterval:  params.TxWatcherPollInterval * time.Millisecond,
+		txs:           make(map[gethcommon.Hash]*watchedTx),
+	}
+}
+
+// SetSignalHandler sets a handler of signals sent by the watcher, e.g. the signal handler of the node
+// it runs on, see node.NodeManager.SendSignal. Signals are sent with signal.Send by default.
+func (w *Watcher) SetSignalHandler(handler func(signal.Envelope)) {
+	w.signals.SetHandler(handler)
+}
+
+// SetClock replaces a clock used to poll the node and to drop transactions,
+// e.g. with a suspendable clock or a fake one in tests.
+func (w *Watcher) SetClock(clock common.Clock) {
+	w.clock = clock
+}
+
+// Start starts checking watched transactions with options of a config. Options which are not set are
+// left unchanged. Transactions watched before are followed further.
+func (w *Watcher) Start(config params.TxWatcherConfig) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.quit != nil {
+		return
+	}
+	if config.Confirmations > 0 {
+		w.confirmations = uint64(config.Confirmations)
+	}
+	if config.PollInterval > 0 {
+		w.pollInterval = time.Duration(config.PollInterval) * time.Millisecond
+	}
+
+	log.Info("start transaction watcher", "confirmations", w.confirmations, "interval", w.pollInterval)
+	w.quit = make(chan struct{})
+	heads := make(chan common.ChainHead, chainHeadChanSize)
+	sub := w.nodeManager.SubscribeChainHeads(heads)
+	w.wg.Add(1)
+	go w.loop(w.quit, heads, sub, w.pollInterval)
+}
+
+// Stop stops checking watched transactions and waits until a check in progress is over.
+// Watched transactions are kept until the watcher is started again.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	if w.quit == nil {
+		w.mu.Unlock()
+		return
+	}
+	log.Info("stop transaction watcher")
+	close(w.quit)
+	w.quit = nil
+	w.mu.Unlock()
+
+	w.wg.Wait()
+}
+
+// Watch starts following a transaction sent from an account, with a pending signal.
+func (w *Watcher) Watch(hash gethcommon.Hash, from gethcommon.Address) {
+	w.mu.Lock()
+	if _, ok := w.txs[hash]; ok {
+		w.mu.Unlock()
+		return
+	}
+	tx := &watchedTx{hash: hash, from: from, seenAt: w.clock.Now()}
+	w.txs[hash] = tx
+	w.mu.Unlock()
+
+	log.Info("watch transaction", "hash", hash.Hex(), "from", from.Hex())
+	w.notify(EventTransactionPending, tx, nil)
+}
+
+// loop checks watched transactions on new chain heads, and periodically as heads are not delivered
+// when upstream RPC is used, until the watcher is stopped.
+func (w *Watcher) loop(quit <-chan struct{}, heads <-chan common.ChainHead, sub event.Subscription, interval time.Duration) {
+	defer w.wg.Done()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case <-heads:
+			w.poll()
+		case <-w.clock.After(interval):
+			w.poll()
+		}
+	}
+}
+
+// poll checks watched transactions which were not checked at the current block yet. Transactions unknown to
+// the node are resolved once all transactions are checked, so that their replacements are known.
+func (w *Watcher) poll() {
+	txs := w.watchedTransactions()
+	if len(txs) == 0 {
+		return
+	}
+
+	client := w.nodeManager.RPCClient()
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pollTimeout)
+	defer cancel()
+
+	var head hexutil.Uint64
+	if err := client.CallContext(ctx, &head, "eth_blockNumber"); err != nil {
+		log.Warn("failed to get the current block of watched transactions", "err", err)
+		return
+	}
+
+	var unknown []*watchedTx
+	for _, tx := range txs {
+		if tx.checked && tx.head == uint64(head) {
+			continue // nothing has changed since
+		}
+
+		known, err := w.check(ctx, client, tx, uint64(head))
+		if err != nil {
+			log.Warn("failed to check a watched transaction", "hash", tx.hash.Hex(), "err", err)
+			continue
+		}
+		tx.head, tx.checked = uint64(head), true
+		if !known {
+			unknown = append(unknown, tx)
+		}
+	}
+
+	for _, tx := range unknown {
+		if err := w.resolveUnknown(ctx, client, tx); err != nil {
+			log.Warn("failed to check a watched transaction", "hash", tx.hash.Hex(), "err", err)
+		}
+	}
+}
+
+// check updates a state of a transaction at a block, and reports whether the node knows the transaction.
+func (w *Watcher) check(ctx context.Context, client *rpc.Client, tx *watchedTx, head uint64) (bool, error) {
+	var raw json.RawMessage
+	if err := client.CallContext(ctx, &raw, "eth_getTransactionReceipt", tx.hash); err != nil {
+		return false, err
+	}
+
+	var receipt *rpcReceipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return false, err
+	}
+
+	if receipt != nil {
+		if tx.nonce == nil {
+			// the nonce tells which transactions it replaced
+			if _, err := w.lookUp(ctx, client, tx); err != nil {
+				return false, err
+			}
+		}
+		w.mined(tx, receipt, head)
+		return true, nil
+	}
+
+	if tx.receipt != nil {
+		log.Info("watched transaction is not mined anymore", "hash", tx.hash.Hex())
+		tx.receipt = nil
+		w.notify(EventTransactionPending, tx, nil)
+	}
+
+	return w.lookUp(ctx, client, tx)
+}
+
+// mined signals a transaction mined in a new block, and confirms it once there are enough blocks on top.
+func (w *Watcher) mined(tx *watchedTx, receipt *rpcReceipt, head uint64) {
+	if tx.receipt == nil || tx.receipt.BlockHash != receipt.BlockHash {
+		tx.receipt = receipt
+		event := EventTransactionMined
+		if receipt.reverted() {
+			event = EventTransactionReverted
+		}
+		log.Info("watched transaction mined", "hash", tx.hash.Hex(), "block", uint64(receipt.BlockNumber), "reverted", receipt.reverted())
+		w.notify(event, tx, nil)
+	}
+
+	var confirmations uint64
+	if head >= uint64(receipt.BlockNumber) { // the current block may lag behind on a load balanced upstream node
+		confirmations = head - uint64(receipt.BlockNumber) + 1
+	}
+	if confirmations < w.requiredConfirmations() {
+		return
+	}
+
+	log.Info("watched transaction confirmed", "hash", tx.hash.Hex(), "confirmations", confirmations)
+	w.notify(EventTransactionConfirmed, tx, func(event *TransactionEvent) {
+		event.Confirmations = confirmations
+	})
+	w.forget(tx)
+}
+
+// lookUp requests a transaction from the node, remembering its nonce and when it was seen,
+// and reports whether the node knows it.
+func (w *Watcher) lookUp(ctx context.Context, client *rpc.Client, tx *watchedTx) (bool, error) {
+	var raw json.RawMessage
+	if err := client.CallContext(ctx, &raw, "eth_getTransactionByHash", tx.hash); err != nil {
+		return false, err
+	}
+
+	var found *rpcTransaction
+	if err := json.Unmarshal(raw, &found); err != nil {
+		return false, err
+	}
+	if found == nil {
+		return false, nil
+	}
+
+	nonce := uint64(found.Nonce)
+	tx.nonce = &nonce
+	tx.seenAt = w.clock.Now()
+
+	return true, nil
+}
+
+// resolveUnknown drops a transaction unknown to the node if its nonce is used by another mined transaction,
+// or if it's unknown for too long.
+func (w *Watcher) resolveUnknown(ctx context.Context, client *rpc.Client, tx *watchedTx) error {
+	if tx.nonce != nil {
+		var count hexutil.Uint64
+		if err := client.CallContext(ctx, &count, "eth_getTransactionCount", tx.from, "latest"); err != nil {
+			return err
+		}
+		if uint64(count) > *tx.nonce {
+			replacement := w.replacement(tx)
+			log.Info("watched transaction replaced", "hash", tx.hash.Hex(), "nonce", *tx.nonce)
+			w.notify(EventTransactionDropped, tx, func(event *TransactionEvent) {
+				event.Replaced = true
+				event.ReplacedBy = replacement
+			})
+			w.forget(tx)
+			return nil
+		}
+	}
+
+	if w.clock.Now().Sub(tx.seenAt) < dropTimeout {
+		return nil
+	}
+
+	log.Info("watched transaction dropped", "hash", tx.hash.Hex(), "since", tx.seenAt)
+	w.notify(EventTransactionDropped, tx, nil)
+	w.forget(tx)
+
+	return nil
+}
+
+// replacement returns a hash of a mined watched transaction with the same sender and nonce as a given one, if any.
+func (w *Watcher) replacement(tx *watchedTx) *gethcommon.Hash {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for hash, other := range w.txs {
+		if hash != tx.hash && other.from == tx.from && other.receipt != nil &&
+			other.nonce != nil && *other.nonce == *tx.nonce {
+			return &hash
+		}
+	}
+
+	return nil
+}
+
+// watchedTransactions returns transactions which are watched.
+func (w *Watcher) watchedTransactions() []*watchedTx {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	txs := make([]*watchedTx, 0, len(w.txs))
+	for _, tx := range w.txs {
+		txs = append(txs, tx)
+	}
+
+	return txs
+}
+
+// requiredConfirmations returns how many blocks confirm a transaction.
+func (w *Watcher) requiredConfirmations() uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.confirmations
+}
+
+// forget stops following a transaction.
+func (w *Watcher) forget(tx *watchedTx) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.txs, tx.hash)
+}
+
+// notify sends a signal of a state of a transaction, which may be extended by a function.
+func (w *Watcher) notify(eventType string, tx *watchedTx, extend func(event *TransactionEvent)) {
+	event := TransactionEvent{
+		Hash: tx.hash,
+		From: tx.from,
+	}
+	if tx.receipt != nil {
+		number := tx.receipt.BlockNumber
+		blockHash := tx.receipt.BlockHash
+		event.BlockNumber = &number
+		event.BlockHash = &blockHash
+		event.GasUsed = tx.receipt.GasUsed
+		event.Reverted = tx.receipt.reverted()
+	}
+	if extend != nil {
+		extend(&event)
+	}
+
+	w.signals.Send(signal.Envelope{
+		Type:  eventType,
+		Event: event,
+	})
+}
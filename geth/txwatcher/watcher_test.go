@@ -0,0 +1,309 @@
+package txwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/geth/signal"
+	. "github.com/status-im/status-go/testing"
+	"github.com/status-im/status-go/testing/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	sender = gethcommon.HexToAddress("0x1")
+	txA    = gethcommon.HexToHash("0xa")
+	txB    = gethcommon.HexToHash("0xb")
+)
+
+// fakeChain serves the current block, receipts and transactions known to the node.
+type fakeChain struct {
+	sync.Mutex
+	head     uint64
+	receipts map[gethcommon.Hash]map[string]interface{}
+	nonces   map[gethcommon.Hash]uint64 // nonces of transactions known to the node
+	count    uint64                     // mined transactions of the sender
+}
+
+func newFakeChain(nodeManager *mocks.NodeManager) *fakeChain {
+	c := &fakeChain{
+		receipts: make(map[gethcommon.Hash]map[string]interface{}),
+		nonces:   make(map[gethcommon.Hash]uint64),
+	}
+
+	client := nodeManager.RPCClient()
+	client.RegisterHandler("eth_blockNumber", func(context.Context, ...interface{}) (interface{}, error) {
+		c.Lock()
+		defer c.Unlock()
+		return hexutil.Uint64(c.head), nil
+	})
+	client.RegisterHandler("eth_getTransactionReceipt", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		c.Lock()
+		defer c.Unlock()
+		if receipt, ok := c.receipts[args[0].(gethcommon.Hash)]; ok {
+			return receipt, nil
+		}
+		return nil, nil
+	})
+	client.RegisterHandler("eth_getTransactionByHash", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		c.Lock()
+		defer c.Unlock()
+		if nonce, ok := c.nonces[args[0].(gethcommon.Hash)]; ok {
+			return map[string]interface{}{"nonce": hexutil.Uint64(nonce)}, nil
+		}
+		return nil, nil
+	})
+	client.RegisterHandler("eth_getTransactionCount", func(context.Context, ...interface{}) (interface{}, error) {
+		c.Lock()
+		defer c.Unlock()
+		return hexutil.Uint64(c.count), nil
+	})
+
+	return c
+}
+
+// mine puts a transaction into a block, with status 1 if it succeeds or 0 if it's reverted.
+func (c *fakeChain) mine(hash gethcommon.Hash, number uint64, blockHash gethcommon.Hash, status uint) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.receipts[hash] = map[string]interface{}{
+		"blockHash":   blockHash,
+		"blockNumber": hexutil.Uint64(number),
+		"gasUsed":     (*hexutil.Big)(hexutil.MustDecodeBig("0x5208")),
+		"status":      hexutil.Uint(status),
+	}
+}
+
+func (c *fakeChain) setHead(number uint64) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.head = number
+}
+
+type receivedSignal struct {
+	Type  string           `json:"type"`
+	Event TransactionEvent `json:"event"`
+}
+
+// captureSignals delivers signals of the watcher to a channel until the returned function is called.
+func captureSignals(t *testing.T) (<-chan receivedSignal, func()) {
+	signals := make(chan receivedSignal, 10)
+	signal.SetDefaultNodeNotificationHandler(func(jsonEvent string) {
+		var received receivedSignal
+		require.NoError(t, json.Unmarshal([]byte(jsonEvent), &received))
+		signals <- received
+	})
+
+	return signals, signal.ResetDefaultNodeNotificationHandler
+}
+
+func expectSignal(t *testing.T, signals <-chan receivedSignal, eventType string, hash gethcommon.Hash) TransactionEvent {
+	select {
+	case received := <-signals:
+		require.Equal(t, eventType, received.Type)
+		require.Equal(t, hash, received.Event.Hash)
+		return received.Event
+	case <-time.After(time.Second):
+		t.Fatalf("%s signal was not sent", eventType)
+	}
+	return TransactionEvent{}
+}
+
+func expectNoSignal(t *testing.T, signals <-chan receivedSignal) {
+	select {
+	case received := <-signals:
+		t.Fatalf("unexpected %s signal", received.Type)
+	default:
+	}
+}
+
+func newTestWatcher(confirmations uint64) (*Watcher, *fakeChain, *FakeClock) {
+	nodeManager := mocks.NewNodeManager()
+	clock := NewFakeClock(time.Now())
+
+	watcher := NewWatcher(nodeManager)
+	watcher.SetClock(clock)
+	watcher.confirmations = confirmations
+
+	return watcher, newFakeChain(nodeManager), clock
+}
+
+func TestMinedTransactionConfirmed(t *testing.T) {
+	signals, reset := captureSignals(t)
+	defer reset()
+
+	watcher, chain, _ := newTestWatcher(3)
+	chain.nonces[txA] = 5
+	chain.setHead(10)
+
+	watcher.Watch(txA, sender)
+	event := expectSignal(t, signals, EventTransactionPending, txA)
+	require.Equal(t, sender, event.From)
+	require.Nil(t, event.BlockNumber)
+
+	watcher.poll()
+	expectNoSignal(t, signals)
+
+	blockHash := gethcommon.HexToHash("0x11")
+	chain.mine(txA, 11, blockHash, 1)
+	chain.setHead(11)
+	watcher.poll()
+	event = expectSignal(t, signals, EventTransactionMined, txA)
+	require.Equal(t, hexutil.Uint64(11), *event.BlockNumber)
+	require.Equal(t, blockHash, *event.BlockHash)
+	require.Equal(t, int64(21000), event.GasUsed.ToInt().Int64())
+	require.False(t, event.Reverted)
+
+	chain.setHead(12)
+	watcher.poll()
+	expectNoSignal(t, signals)
+
+	chain.setHead(13)
+	watcher.poll()
+	event = expectSignal(t, signals, EventTransactionConfirmed, txA)
+	require.Equal(t, uint64(3), event.Confirmations)
+	require.Empty(t, watcher.watchedTransactions())
+}
+
+func TestRevertedTransaction(t *testing.T) {
+	signals, reset := captureSignals(t)
+	defer reset()
+
+	watcher, chain, _ := newTestWatcher(1)
+	watcher.Watch(txA, sender)
+	expectSignal(t, signals, EventTransactionPending, txA)
+
+	chain.nonces[txA] = 5
+	chain.mine(txA, 11, gethcommon.HexToHash("0x11"), 0)
+	chain.setHead(11)
+	watcher.poll()
+	event := expectSignal(t, signals, EventTransactionReverted, txA)
+	require.True(t, event.Reverted)
+	event = expectSignal(t, signals, EventTransactionConfirmed, txA)
+	require.True(t, event.Reverted)
+	require.Equal(t, uint64(1), event.Confirmations)
+}
+
+func TestTransactionReorganised(t *testing.T) {
+	signals, reset := captureSignals(t)
+	defer reset()
+
+	watcher, chain, _ := newTestWatcher(3)
+	chain.nonces[txA] = 5
+	watcher.Watch(txA, sender)
+	expectSignal(t, signals, EventTransactionPending, txA)
+
+	chain.mine(txA, 11, gethcommon.HexToHash("0x11"), 1)
+	chain.setHead(11)
+	watcher.poll()
+	expectSignal(t, signals, EventTransactionMined, txA)
+
+	// the block is replaced by one without the transaction
+	chain.Lock()
+	delete(chain.receipts, txA)
+	chain.Unlock()
+	chain.setHead(12)
+	watcher.poll()
+	event := expectSignal(t, signals, EventTransactionPending, txA)
+	require.Nil(t, event.BlockNumber)
+
+	otherBlock := gethcommon.HexToHash("0x13")
+	chain.mine(txA, 13, otherBlock, 1)
+	chain.setHead(13)
+	watcher.poll()
+	event = expectSignal(t, signals, EventTransactionMined, txA)
+	require.Equal(t, otherBlock, *event.BlockHash)
+}
+
+func TestTransactionReplaced(t *testing.T) {
+	signals, reset := captureSignals(t)
+	defer reset()
+
+	watcher, chain, _ := newTestWatcher(3)
+	chain.nonces[txA] = 5
+	chain.setHead(10)
+	watcher.Watch(txA, sender)
+	expectSignal(t, signals, EventTransactionPending, txA)
+	watcher.poll()
+
+	// a replacement with the same nonce is mined, and the node forgets the original transaction
+	watcher.Watch(txB, sender)
+	expectSignal(t, signals, EventTransactionPending, txB)
+	chain.Lock()
+	delete(chain.nonces, txA)
+	chain.nonces[txB] = 5
+	chain.count = 6
+	chain.Unlock()
+	chain.mine(txB, 11, gethcommon.HexToHash("0x11"), 1)
+	chain.setHead(11)
+	watcher.poll()
+
+	event := expectSignal(t, signals, EventTransactionMined, txB)
+	require.Nil(t, event.ReplacedBy)
+	event = expectSignal(t, signals, EventTransactionDropped, txA)
+	require.True(t, event.Replaced)
+	require.Equal(t, txB, *event.ReplacedBy)
+	require.Len(t, watcher.watchedTransactions(), 1)
+}
+
+func TestTransactionDropped(t *testing.T) {
+	signals, reset := captureSignals(t)
+	defer reset()
+
+	watcher, chain, clock := newTestWatcher(3)
+	chain.setHead(10)
+	watcher.Watch(txA, sender)
+	expectSignal(t, signals, EventTransactionPending, txA)
+
+	// a transaction unknown to the node may not have reached it yet
+	chain.setHead(11)
+	watcher.poll()
+	expectNoSignal(t, signals)
+
+	clock.Advance(dropTimeout)
+	chain.setHead(12)
+	watcher.poll()
+	event := expectSignal(t, signals, EventTransactionDropped, txA)
+	require.False(t, event.Replaced)
+	require.Empty(t, watcher.watchedTransactions())
+}
+
+func TestWatcherChecksNewHeads(t *testing.T) {
+	signals, reset := captureSignals(t)
+	defer reset()
+
+	nodeManager := mocks.NewNodeManager()
+	chain := newFakeChain(nodeManager)
+	clock := NewFakeClock(time.Now())
+	watcher := NewWatcher(nodeManager)
+	watcher.SetClock(clock)
+	watcher.Start(params.TxWatcherConfig{Confirmations: 2, PollInterval: 1000})
+	defer watcher.Stop()
+
+	watcher.Watch(txA, sender)
+	expectSignal(t, signals, EventTransactionPending, txA)
+
+	chain.Lock()
+	chain.nonces[txA] = 5
+	chain.Unlock()
+	chain.mine(txA, 11, gethcommon.HexToHash("0x11"), 1)
+	chain.setHead(11)
+	nodeManager.SendChainHead(common.ChainHead{Number: 11})
+	expectSignal(t, signals, EventTransactionMined, txA)
+
+	// heads aren't delivered from the upstream node, so the node is polled as well
+	chain.setHead(12)
+	clock.BlockUntil(1)
+	clock.Advance(time.Second)
+	event := expectSignal(t, signals, EventTransactionConfirmed, txA)
+	require.Equal(t, uint64(2), event.Confirmations)
+}
@@ -2,13 +2,19 @@ package main
 
 import "C"
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/NaySoftware/go-fcm"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
 	"github.com/status-im/status-go/geth/common"
 	"github.com/status-im/status-go/geth/log"
+	"github.com/status-im/status-go/geth/msgstore"
 	"github.com/status-im/status-go/geth/params"
 	"github.com/status-im/status-go/helpers/profiling"
 	"gopkg.in/go-playground/validator.v9"
@@ -99,6 +105,150 @@ func ResetChainData() *C.char {
 	return makeJSONResponse(err)
 }
 
+//AddPeer adds a static peer by its enode URL
+//export AddPeer
+func AddPeer(enode *C.char) *C.char {
+	err := statusAPI.AddPeer(C.GoString(enode))
+	return makeJSONResponse(err)
+}
+
+//RemovePeer disconnects a peer and removes it from static peers
+//export RemovePeer
+func RemovePeer(enode *C.char) *C.char {
+	err := statusAPI.RemovePeer(C.GoString(enode))
+	return makeJSONResponse(err)
+}
+
+//AddTrustedPeer adds a peer which is allowed to connect even if the peers limit is reached
+//export AddTrustedPeer
+func AddTrustedPeer(enode *C.char) *C.char {
+	err := statusAPI.AddTrustedPeer(C.GoString(enode))
+	return makeJSONResponse(err)
+}
+
+//Peers returns information about connected peers
+//export Peers
+func Peers() *C.char {
+	var out common.PeersResponse
+
+	peers, err := statusAPI.Peers()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Peers = peers
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//SetBootNodes replaces boot nodes with a JSON list of enode URLs, without restarting the node
+//export SetBootNodes
+func SetBootNodes(enodesJSON *C.char) *C.char {
+	var enodes []string
+	if err := json.Unmarshal([]byte(C.GoString(enodesJSON)), &enodes); err != nil {
+		return makeJSONResponse(err)
+	}
+
+	err := statusAPI.SetBootNodes(enodes)
+	return makeJSONResponse(err)
+}
+
+//BlacklistPeer prevents connections to a peer given by enode URL, IP address or CIDR range
+//export BlacklistPeer
+func BlacklistPeer(peer *C.char) *C.char {
+	err := statusAPI.BlacklistPeer(C.GoString(peer))
+	return makeJSONResponse(err)
+}
+
+//UnblacklistPeer removes an entry from the peer blacklist
+//export UnblacklistPeer
+func UnblacklistPeer(peer *C.char) *C.char {
+	err := statusAPI.UnblacklistPeer(C.GoString(peer))
+	return makeJSONResponse(err)
+}
+
+//SetPeerWhitelist restricts connections to a JSON list of enode URLs, IP addresses and CIDR ranges,
+//empty list allows all peers
+//export SetPeerWhitelist
+func SetPeerWhitelist(peersJSON *C.char) *C.char {
+	var peers []string
+	if err := json.Unmarshal([]byte(C.GoString(peersJSON)), &peers); err != nil {
+		return makeJSONResponse(err)
+	}
+
+	err := statusAPI.SetPeerWhitelist(peers)
+	return makeJSONResponse(err)
+}
+
+//SetNetworkCondition adapts sync and Whisper relaying to a kind of network the device is connected to,
+//one of "wifi", "cellular" and "offline"
+//export SetNetworkCondition
+func SetNetworkCondition(condition *C.char) *C.char {
+	err := statusAPI.SetNetworkCondition(common.NetworkCondition(C.GoString(condition)))
+	return makeJSONResponse(err)
+}
+
+//Suspend quiesces sync, dialing, discovery and jail timers while the application is in background
+//export Suspend
+func Suspend() {
+	statusAPI.Suspend()
+}
+
+//Resume restarts the activity stopped with Suspend
+//export Resume
+func Resume() {
+	statusAPI.Resume()
+}
+
+//RegisterTopic advertises the node under a discovery v5 topic
+//export RegisterTopic
+func RegisterTopic(topic *C.char) *C.char {
+	err := statusAPI.RegisterTopic(C.GoString(topic))
+	return makeJSONResponse(err)
+}
+
+//UnregisterTopic stops advertising the node under a discovery v5 topic
+//export UnregisterTopic
+func UnregisterTopic(topic *C.char) *C.char {
+	err := statusAPI.UnregisterTopic(C.GoString(topic))
+	return makeJSONResponse(err)
+}
+
+//SearchTopic returns enode URLs of up to limit nodes registered under a discovery v5 topic,
+//found within a timeout (in milliseconds)
+//export SearchTopic
+func SearchTopic(topic *C.char, limit C.int, timeout C.int) *C.char {
+	var out common.TopicSearchResponse
+
+	enodes, err := statusAPI.SearchTopic(C.GoString(topic), int(limit), time.Duration(timeout)*time.Millisecond)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Enodes = enodes
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//NodeStatus returns running state, peer count, sync progress and latest block of the node
+//export NodeStatus
+func NodeStatus() *C.char {
+	outBytes, _ := json.Marshal(statusAPI.NodeStatus())
+	return C.CString(string(outBytes))
+}
+
+//Metrics returns resource usage of the process and its running node,
+//with p2p traffic counted for outbound peer connections only
+//export Metrics
+func Metrics() *C.char {
+	outBytes, _ := json.Marshal(statusAPI.Metrics())
+	return C.CString(string(outBytes))
+}
+
 //CallRPC calls status node via rpc
 //export CallRPC
 func CallRPC(inputJSON *C.char) *C.char {
@@ -128,6 +278,34 @@ func CreateAccount(password *C.char) *C.char {
 	return C.CString(string(outBytes))
 }
 
+//CreateAccountWithOptions creates an account with a JSON object of mnemonic options:
+//wordCount (12, 15, 18, 21 or 24), language (index of extkeys.Languages) and BIP39 passphrase
+//export CreateAccountWithOptions
+func CreateAccountWithOptions(password, optionsJSON *C.char) *C.char {
+	var options common.MnemonicOptions
+	if err := json.Unmarshal([]byte(C.GoString(optionsJSON)), &options); err != nil {
+		outBytes, _ := json.Marshal(common.AccountInfo{Error: err.Error()})
+		return C.CString(string(outBytes))
+	}
+
+	address, pubKey, mnemonic, err := statusAPI.CreateAccountWithOptions(C.GoString(password), options)
+
+	errString := ""
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		errString = err.Error()
+	}
+
+	out := common.AccountInfo{
+		Address:  address,
+		PubKey:   pubKey,
+		Mnemonic: mnemonic,
+		Error:    errString,
+	}
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
 //CreateChildAccount creates sub-account
 //export CreateChildAccount
 func CreateChildAccount(parentAddress, password *C.char) *C.char {
@@ -169,6 +347,216 @@ func RecoverAccount(password, mnemonic *C.char) *C.char {
 	return C.CString(string(outBytes))
 }
 
+//RecoverAccountWithOptions re-creates master key of an account created with CreateAccountWithOptions,
+//given the same JSON object of mnemonic options
+//export RecoverAccountWithOptions
+func RecoverAccountWithOptions(password, mnemonic, optionsJSON *C.char) *C.char {
+	var options common.MnemonicOptions
+	if err := json.Unmarshal([]byte(C.GoString(optionsJSON)), &options); err != nil {
+		outBytes, _ := json.Marshal(common.AccountInfo{Error: err.Error()})
+		return C.CString(string(outBytes))
+	}
+
+	address, pubKey, err := statusAPI.RecoverAccountWithOptions(C.GoString(password), C.GoString(mnemonic), options)
+
+	errString := ""
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		errString = err.Error()
+	}
+
+	out := common.AccountInfo{
+		Address:  address,
+		PubKey:   pubKey,
+		Mnemonic: C.GoString(mnemonic),
+		Error:    errString,
+	}
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//DeriveAccounts derives count accounts with consecutive indexes starting from start under a BIP44 path
+//(m/44'/60'/0'/0 if empty) from a mnemonic and an optional BIP39 passphrase, and stores them encrypted with the password
+//export DeriveAccounts
+func DeriveAccounts(password, mnemonic, passphrase, path *C.char, start, count C.int) *C.char {
+	var out common.DerivedAccountsResponse
+
+	addresses, err := statusAPI.DeriveAccounts(C.GoString(password), C.GoString(mnemonic), C.GoString(passphrase), C.GoString(path), int(start), int(count))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Addresses = addresses
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//ImportPrivateKey stores a hex encoded private key (e.g. of a paper wallet) encrypted with the password
+//export ImportPrivateKey
+func ImportPrivateKey(hexKey, password *C.char) *C.char {
+	address, pubKey, err := statusAPI.ImportPrivateKey(C.GoString(hexKey), C.GoString(password))
+
+	errString := ""
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		errString = err.Error()
+	}
+
+	out := common.AccountInfo{
+		Address: address,
+		PubKey:  pubKey,
+		Error:   errString,
+	}
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//ExportAccount returns a key of an account as V3 keystore JSON, to be imported into other wallets
+//export ExportAccount
+func ExportAccount(address, password *C.char) *C.char {
+	var out common.ExportedAccountResponse
+
+	keyJSON, err := statusAPI.ExportAccount(C.GoString(address), C.GoString(password))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Keystore = keyJSON
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//ExportExtendedPublicKey returns an extended public key (xpub) of an account at a path relative to its extended key,
+//so that its addresses can be derived elsewhere without private keys
+//export ExportExtendedPublicKey
+func ExportExtendedPublicKey(address, password, path *C.char) *C.char {
+	var out common.ExportedExtendedPublicKeyResponse
+
+	xpub, err := statusAPI.ExportExtendedPublicKey(C.GoString(address), C.GoString(password), C.GoString(path))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.XPub = xpub
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//AddWatchOnlyAccount adds an account without a private key, given its address or an extended public key (xpub)
+//export AddWatchOnlyAccount
+func AddWatchOnlyAccount(addressOrXPub *C.char) *C.char {
+	address, err := statusAPI.AddWatchOnlyAccount(C.GoString(addressOrXPub))
+
+	errString := ""
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		errString = err.Error()
+	}
+
+	out := common.AccountInfo{
+		Address: address,
+		Error:   errString,
+	}
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//RemoveWatchOnlyAccount removes a watch-only account with a given address
+//export RemoveWatchOnlyAccount
+func RemoveWatchOnlyAccount(address *C.char) *C.char {
+	err := statusAPI.RemoveWatchOnlyAccount(C.GoString(address))
+	return makeJSONResponse(err)
+}
+
+//WatchOnlyAccounts returns watch-only accounts
+//export WatchOnlyAccounts
+func WatchOnlyAccounts() *C.char {
+	var out common.WatchOnlyAccountsResponse
+
+	accounts, err := statusAPI.WatchOnlyAccounts()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Accounts = accounts
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//SetAccountMetadata stores a JSON object of account metadata (name, photo path, creation time and flags),
+//encrypted with a key derived from the account's key
+//export SetAccountMetadata
+func SetAccountMetadata(address, password, metadataJSON *C.char) *C.char {
+	var metadata common.AccountMetadata
+	if err := json.Unmarshal([]byte(C.GoString(metadataJSON)), &metadata); err != nil {
+		return makeJSONResponse(err)
+	}
+
+	err := statusAPI.SetAccountMetadata(C.GoString(address), C.GoString(password), metadata)
+	return makeJSONResponse(err)
+}
+
+//AccountMetadata returns metadata of an account stored with SetAccountMetadata
+//export AccountMetadata
+func AccountMetadata(address, password *C.char) *C.char {
+	var out common.AccountMetadataResponse
+
+	metadata, err := statusAPI.AccountMetadata(C.GoString(address), C.GoString(password))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Metadata = metadata
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//DeleteAccountMetadata removes metadata of an account
+//export DeleteAccountMetadata
+func DeleteAccountMetadata(address, password *C.char) *C.char {
+	err := statusAPI.DeleteAccountMetadata(C.GoString(address), C.GoString(password))
+	return makeJSONResponse(err)
+}
+
+//ChangeAccountPassword re-encrypts key of an account and keys of its sub-accounts with a new password
+//export ChangeAccountPassword
+func ChangeAccountPassword(address, oldPassword, newPassword *C.char) *C.char {
+	err := statusAPI.ChangeAccountPassword(C.GoString(address), C.GoString(oldPassword), C.GoString(newPassword))
+	return makeJSONResponse(err)
+}
+
+//DeleteAccount removes keys of an account and its sub-accounts, and their Whisper identities
+//export DeleteAccount
+func DeleteAccount(address, password *C.char) *C.char {
+	err := statusAPI.DeleteAccount(C.GoString(address), C.GoString(password))
+	return makeJSONResponse(err)
+}
+
+//BackupKeyStore writes keystore files and account files of the running node to an archive encrypted with the password
+//export BackupKeyStore
+func BackupKeyStore(archivePath, password *C.char) *C.char {
+	err := statusAPI.BackupKeyStore(C.GoString(archivePath), C.GoString(password))
+	return makeJSONResponse(err)
+}
+
+//RestoreKeyStore unpacks an archive written by BackupKeyStore into a data directory without accounts,
+//the node is started with the data directory afterwards
+//export RestoreKeyStore
+func RestoreKeyStore(archivePath, password, dataDir *C.char) *C.char {
+	err := statusAPI.RestoreKeyStore(C.GoString(archivePath), C.GoString(password), C.GoString(dataDir))
+	return makeJSONResponse(err)
+}
+
 //VerifyAccountPassword verifies account password
 //export VerifyAccountPassword
 func VerifyAccountPassword(keyStoreDir, address, password *C.char) *C.char {
@@ -191,6 +579,48 @@ func Logout() *C.char {
 	return makeJSONResponse(err)
 }
 
+//OpenSession unlocks an account in addition to already unlocked ones, adding its key to Whisper identities
+//export OpenSession
+func OpenSession(address, password *C.char) *C.char {
+	err := statusAPI.OpenSession(C.GoString(address), C.GoString(password))
+	return makeJSONResponse(err)
+}
+
+//CloseSession locks an account unlocked with Login or OpenSession, removing its Whisper identity
+//export CloseSession
+func CloseSession(address *C.char) *C.char {
+	err := statusAPI.CloseSession(C.GoString(address))
+	return makeJSONResponse(err)
+}
+
+//Sessions returns accounts unlocked with Login or OpenSession
+//export Sessions
+func Sessions() *C.char {
+	out := common.AccountSessionsResponse{
+		Sessions: statusAPI.Sessions(),
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//WhisperIdentities returns Whisper identities of accounts unlocked with Login or OpenSession
+//export WhisperIdentities
+func WhisperIdentities() *C.char {
+	var out common.WhisperIdentitiesResponse
+
+	identities, err := statusAPI.WhisperIdentities()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Identities = identities
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
 //CompleteTransaction instructs backend to complete sending of a given transaction
 //export CompleteTransaction
 func CompleteTransaction(id, password *C.char) *C.char {
@@ -216,7 +646,7 @@ func CompleteTransaction(id, password *C.char) *C.char {
 	return C.CString(string(outBytes))
 }
 
-//CompleteTransactions instructs backend to complete sending of multiple transactions
+//CompleteTransactions sends transactions approved together, none of them unless all can be sent
 //export CompleteTransactions
 func CompleteTransactions(ids, password *C.char) *C.char {
 	out := common.CompleteTransactionsResult{}
@@ -255,6 +685,32 @@ func CompleteTransactions(ids, password *C.char) *C.char {
 	return C.CString(string(outBytes))
 }
 
+//CompleteMessage signs a message queued by personal_sign or eth_signTypedData, once the user approves it with the password
+//export CompleteMessage
+func CompleteMessage(id, password *C.char) *C.char {
+	signature, err := statusAPI.CompleteMessage(common.QueuedMessageID(C.GoString(id)), C.GoString(password))
+
+	out := common.CompleteMessageResult{
+		ID: C.GoString(id),
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Signature = signature.String()
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//DiscardMessage discards a message queued by personal_sign or eth_signTypedData
+//export DiscardMessage
+func DiscardMessage(id *C.char) *C.char {
+	err := statusAPI.DiscardMessage(common.QueuedMessageID(C.GoString(id)))
+	return makeJSONResponse(err)
+}
+
 //DiscardTransaction discards a given transaction from transaction queue
 //export DiscardTransaction
 func DiscardTransaction(id *C.char) *C.char {
@@ -279,6 +735,114 @@ func DiscardTransaction(id *C.char) *C.char {
 	return C.CString(string(outBytes))
 }
 
+//ResetNonce forgets nonces of transactions of an account sent by the transaction queue,
+//so that nonce of its next transaction is requested from the node
+//export ResetNonce
+func ResetNonce(address *C.char) *C.char {
+	err := statusAPI.ResetNonce(C.GoString(address))
+	return makeJSONResponse(err)
+}
+
+//RegisterContractABI sets a JSON ABI of a contract, so that calls of its methods are decoded
+//in the transaction queued signal
+//export RegisterContractABI
+func RegisterContractABI(address, abiJSON *C.char) *C.char {
+	err := statusAPI.RegisterContractABI(C.GoString(address), C.GoString(abiJSON))
+	return makeJSONResponse(err)
+}
+
+//SpeedUpTransaction replaces a pending transaction with the same one paying a higher gas price,
+//it returns once the replacement is approved and sent
+//export SpeedUpTransaction
+func SpeedUpTransaction(hash, gasPrice *C.char) *C.char {
+	newHash, err := statusAPI.SpeedUpTransaction(context.Background(), C.GoString(hash), C.GoString(gasPrice))
+	return makeReplaceTransactionResponse(C.GoString(hash), newHash, err)
+}
+
+//CancelTransaction replaces a pending transaction with a transfer of nothing to its sender,
+//it returns once the replacement is approved and sent
+//export CancelTransaction
+func CancelTransaction(hash *C.char) *C.char {
+	newHash, err := statusAPI.CancelTransaction(context.Background(), C.GoString(hash))
+	return makeReplaceTransactionResponse(C.GoString(hash), newHash, err)
+}
+
+func makeReplaceTransactionResponse(oldHash string, hash gethcommon.Hash, err error) *C.char {
+	out := common.ReplaceTransactionResult{
+		OldHash: oldHash,
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Hash = hash.Hex()
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//SignTransaction signs a transaction given as JSON with the key of its sender decrypted with the password,
+//without sending it
+//export SignTransaction
+func SignTransaction(argsJSON, password *C.char) *C.char {
+	var out common.SignTransactionResult
+
+	var args common.SendTxArgs
+	err := json.Unmarshal([]byte(C.GoString(argsJSON)), &args)
+	if err == nil {
+		var raw hexutil.Bytes
+		var hash gethcommon.Hash
+		raw, hash, err = statusAPI.SignTransaction(args, C.GoString(password))
+		if err == nil {
+			out.Raw = raw.String()
+			out.Hash = hash.Hex()
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//BroadcastRawTransaction sends a hex encoded signed transaction to the node
+//export BroadcastRawTransaction
+func BroadcastRawTransaction(rawTx *C.char) *C.char {
+	var out common.BroadcastTransactionResult
+
+	hash, err := statusAPI.BroadcastRawTransaction(context.Background(), C.GoString(rawTx))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Hash = hash.Hex()
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//QueueTokenTransfer queues a transfer of a hex encoded amount of ERC20 tokens from the selected account,
+//it returns once the transfer is approved and sent
+//export QueueTokenTransfer
+func QueueTokenTransfer(token, to, amount *C.char) *C.char {
+	var out common.TokenTransferResult
+
+	hash, err := statusAPI.QueueTokenTransfer(context.Background(), C.GoString(token), C.GoString(to), C.GoString(amount))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		out.Error = err.Error()
+	} else {
+		out.Hash = hash.Hex()
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
 //DiscardTransactions discards given multiple transactions from transaction queue
 //export DiscardTransactions
 func DiscardTransactions(ids *C.char) *C.char {
@@ -317,6 +881,42 @@ func DiscardTransactions(ids *C.char) *C.char {
 	return C.CString(string(outBytes))
 }
 
+//DiscardAllTransactions discards all queued transactions except for ones being sent, e.g. on logout
+//export DiscardAllTransactions
+func DiscardAllTransactions() *C.char {
+	out := common.DiscardTransactionsResult{}
+	out.Results = make(map[string]common.DiscardTransactionResult)
+
+	for txID, result := range statusAPI.DiscardAllTransactions() {
+		txResult := common.DiscardTransactionResult{
+			ID: string(txID),
+		}
+		if result.Error != nil {
+			txResult.Error = result.Error.Error()
+		}
+		out.Results[string(txID)] = txResult
+	}
+
+	outBytes, err := json.Marshal(out)
+	if err != nil {
+		log.Error("failed to marshal DiscardAllTransactions output", "error", err.Error())
+		return makeJSONResponse(err)
+	}
+
+	return C.CString(string(outBytes))
+}
+
+//PendingTransactions returns queued transactions waiting for approval, with estimated fees
+//export PendingTransactions
+func PendingTransactions() *C.char {
+	out := common.PendingTransactionsResponse{
+		Transactions: statusAPI.PendingTransactions(),
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
 //InitJail setup initial JavaScript
 //export InitJail
 func InitJail(js *C.char) {
@@ -337,6 +937,167 @@ func ExecuteJS(chatID *C.char, code *C.char) *C.char {
 	return C.CString(res)
 }
 
+//StopCell stops a jail cell and removes it
+//export StopCell
+func StopCell(chatID *C.char) *C.char {
+	err := statusAPI.StopJailCell(C.GoString(chatID))
+	return makeJSONResponse(err)
+}
+
+//RestartCell stops a jail cell and creates it again with the JavaScript code it was created with
+//export RestartCell
+func RestartCell(chatID *C.char) *C.char {
+	res := statusAPI.RestartJailCell(C.GoString(chatID))
+	return C.CString(res)
+}
+
+//InterruptCell aborts JavaScript code being executed in a jail cell, e.g. a script stuck in an infinite loop
+//export InterruptCell
+func InterruptCell(chatID *C.char) *C.char {
+	err := statusAPI.InterruptJailCell(C.GoString(chatID))
+	return makeJSONResponse(err)
+}
+
+//SnapshotCell returns a snapshot of a jail cell as JSON, to be passed to RestoreCell after the app restarts
+//export SnapshotCell
+func SnapshotCell(chatID *C.char) *C.char {
+	snapshot, err := statusAPI.SnapshotJailCell(C.GoString(chatID))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	outBytes, _ := json.Marshal(snapshot)
+	return C.CString(string(outBytes))
+}
+
+//RestoreCell creates a jail cell from a snapshot returned by SnapshotCell, running its JavaScript code again
+//export RestoreCell
+func RestoreCell(snapshot *C.char) *C.char {
+	var parsed common.JailCellSnapshot
+	if err := json.Unmarshal([]byte(C.GoString(snapshot)), &parsed); err != nil {
+		return makeJSONResponse(err)
+	}
+
+	res := statusAPI.RestoreJailCell(&parsed)
+	return C.CString(res)
+}
+
+//JailCells returns uptime, pending timers and the last error of running jail cells
+//export JailCells
+func JailCells() *C.char {
+	outBytes, _ := json.Marshal(statusAPI.JailCells())
+	return C.CString(string(outBytes))
+}
+
+//RegisterJailModule adds a CommonJS module jail cells can require, if the hex-encoded SHA-256 hash of its code is the given hash
+//export RegisterJailModule
+func RegisterJailModule(name, code, hash *C.char) *C.char {
+	err := statusAPI.RegisterJailModule(C.GoString(name), C.GoString(code), C.GoString(hash))
+	return makeJSONResponse(err)
+}
+
+//UnregisterJailModule removes a module jail cells can require
+//export UnregisterJailModule
+func UnregisterJailModule(name *C.char) {
+	statusAPI.UnregisterJailModule(C.GoString(name))
+}
+
+//SetJailConsoleCapture enables, if enabled is 1, or disables sending messages written with console of jail cells as signals
+//export SetJailConsoleCapture
+func SetJailConsoleCapture(enabled C.int) {
+	statusAPI.SetJailConsoleCapture(enabled == 1)
+}
+
+//InspectCell returns global variables, pending timers and the last error with its stack trace of a jail cell
+//export InspectCell
+func InspectCell(chatID *C.char) *C.char {
+	inspection, err := statusAPI.InspectJailCell(C.GoString(chatID))
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	outBytes, _ := json.Marshal(inspection)
+	return C.CString(string(outBytes))
+}
+
+//EvaluateInCell evaluates a JS expression in a jail cell, if JailDebug is enabled in the node's config
+//export EvaluateInCell
+func EvaluateInCell(chatID, expr *C.char) *C.char {
+	var out common.JailEvaluationResult
+
+	result, err := statusAPI.EvaluateInJailCell(C.GoString(chatID), C.GoString(expr))
+	if err != nil {
+		out.Error = err.Error()
+	} else {
+		out.Result = result
+	}
+
+	outBytes, _ := json.Marshal(out)
+	return C.CString(string(outBytes))
+}
+
+//SetJailFetchWhitelist sets hosts, given as a JSON array, a jail cell can fetch from
+//export SetJailFetchWhitelist
+func SetJailFetchWhitelist(chatID, hosts *C.char) *C.char {
+	parsedHosts, err := common.ParseJSONArray(C.GoString(hosts))
+	if err == nil {
+		statusAPI.SetJailFetchWhitelist(C.GoString(chatID), parsedHosts)
+	}
+	return makeJSONResponse(err)
+}
+
+//PurgeJailStorage removes all items from localStorage of a jail cell
+//export PurgeJailStorage
+func PurgeJailStorage(chatID *C.char) *C.char {
+	err := statusAPI.PurgeJailStorage(C.GoString(chatID))
+	return makeJSONResponse(err)
+}
+
+//OpenMessageStore starts storing Whisper messages received by an identity, which is an ID of a key pair in Whisper
+//export OpenMessageStore
+func OpenMessageStore(identity *C.char) *C.char {
+	err := statusAPI.OpenMessageStore(C.GoString(identity))
+	return makeJSONResponse(err)
+}
+
+//CloseMessageStore stops storing Whisper messages received by an identity, keeping stored ones
+//export CloseMessageStore
+func CloseMessageStore(identity *C.char) *C.char {
+	err := statusAPI.CloseMessageStore(C.GoString(identity))
+	return makeJSONResponse(err)
+}
+
+//QueryMessages returns a page of stored Whisper messages of an identity selected by a JSON encoded query
+//export QueryMessages
+func QueryMessages(identity, queryJSON *C.char) *C.char {
+	var query msgstore.Query
+	if err := json.Unmarshal([]byte(C.GoString(queryJSON)), &query); err != nil {
+		return makeJSONResponse(err)
+	}
+
+	result, err := statusAPI.QueryMessages(C.GoString(identity), query)
+	if err != nil {
+		return makeJSONResponse(err)
+	}
+
+	outBytes, _ := json.Marshal(result)
+	return C.CString(string(outBytes))
+}
+
+//DeleteMessages removes stored Whisper messages of an identity of a hex encoded topic, or all of them if it's empty
+//export DeleteMessages
+func DeleteMessages(identity, topic *C.char) *C.char {
+	var parsed whisper.TopicType
+	if hex := C.GoString(topic); hex != "" {
+		if err := parsed.UnmarshalText([]byte(hex)); err != nil {
+			return makeJSONResponse(err)
+		}
+	}
+
+	err := statusAPI.DeleteMessages(C.GoString(identity), parsed)
+	return makeJSONResponse(err)
+}
+
 //Call executes given JavaScript function
 //export Call
 func Call(chatID *C.char, path *C.char, params *C.char) *C.char {
@@ -889,10 +889,8 @@ func testCompleteMultipleQueuedTransactions(t *testing.T) bool { //nolint: gocyc
 			return
 		}
 
-		parsedIDs = append(parsedIDs, "invalid-tx-id")
-		updatedTxIDStrings, _ := json.Marshal(parsedIDs)
-
-		// complete
+		// none of transactions is sent, if any of them can't be
+		updatedTxIDStrings, _ := json.Marshal(append(parsedIDs, "invalid-tx-id"))
 		resultsString := CompleteTransactions(C.CString(string(updatedTxIDStrings)), C.CString(TestConfig.Account1.Password))
 		resultsStruct := common.CompleteTransactionsResult{}
 		if err := json.Unmarshal([]byte(C.GoString(resultsString)), &resultsStruct); err != nil {
@@ -905,16 +903,36 @@ func testCompleteMultipleQueuedTransactions(t *testing.T) bool { //nolint: gocyc
 			t.Errorf("cannot complete txs: %v", results)
 			return
 		}
+		for txID, txResult := range results {
+			if txResult.Error != txqueue.ErrQueuedTxBatchAborted.Error() && txID != "invalid-tx-id" {
+				t.Errorf("invalid error for %s", txID)
+				return
+			}
+		}
+
+		// complete
+		resultsString = CompleteTransactions(C.CString(txIDStrings), C.CString(TestConfig.Account1.Password))
+		resultsStruct = common.CompleteTransactionsResult{}
+		if err := json.Unmarshal([]byte(C.GoString(resultsString)), &resultsStruct); err != nil {
+			t.Error(err)
+			return
+		}
+		results = resultsStruct.Results
+
+		if len(results) != testTxCount {
+			t.Errorf("cannot complete txs: %v", results)
+			return
+		}
 		for txID, txResult := range results {
 			if txID != txResult.ID {
 				t.Errorf("tx id not set in result: expected id is %s", txID)
 				return
 			}
-			if txResult.Error != "" && txID != "invalid-tx-id" {
+			if txResult.Error != "" {
 				t.Errorf("invalid error for %s", txID)
 				return
 			}
-			if txResult.Hash == zeroHash && txID != "invalid-tx-id" {
+			if txResult.Hash == zeroHash {
 				t.Errorf("invalid hash (expected non empty hash): %s", txID)
 				return
 			}
@@ -1379,6 +1397,11 @@ func testExecuteJS(t *testing.T) bool {
 func startTestNode(t *testing.T) <-chan struct{} {
 	testDir := filepath.Join(TestDataDir, TestNetworkNames[GetNetworkID()])
 
+	// restore previously synced chaindata unless -freshsync is set
+	if _, err := RestoreSnapshot(GetNetworkID()); err != nil {
+		panic(err)
+	}
+
 	syncRequired := false
 	if _, err := os.Stat(testDir); os.IsNotExist(err) {
 		syncRequired = true
@@ -0,0 +1,139 @@
+package integration
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+)
+
+// FakeClock is common.Clock which time moves only when Advance is called.
+// Use it to test timeouts and timers deterministically.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to a given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel which receives the fake time once it's advanced by d.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	c.schedule(d, func(now time.Time) {
+		ch <- now
+	})
+
+	return ch
+}
+
+// AfterFunc calls f in its own goroutine once the fake time is advanced by d.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) common.Timer {
+	return c.schedule(d, func(time.Time) {
+		go f()
+	})
+}
+
+// Advance moves the fake time forward and fires expired timers in order of their deadlines.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	now := c.now
+
+	var expired, active []*fakeTimer
+	for _, t := range c.timers {
+		if !t.deadline.After(now) {
+			expired = append(expired, t)
+		} else {
+			active = append(active, t)
+		}
+	}
+	c.timers = active
+	c.mu.Unlock()
+
+	sort.SliceStable(expired, func(i, j int) bool {
+		return expired[i].deadline.Before(expired[j].deadline)
+	})
+	for _, t := range expired {
+		t.fire(now)
+	}
+}
+
+// BlockUntil blocks until at least n timers are scheduled, so that
+// Advance is not called before the code under test starts waiting.
+func (c *FakeClock) BlockUntil(n int) {
+	for {
+		c.mu.Lock()
+		scheduled := len(c.timers)
+		c.mu.Unlock()
+
+		if scheduled >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func (c *FakeClock) schedule(d time.Duration, fire func(time.Time)) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		fire:     fire,
+	}
+	c.timers = append(c.timers, t)
+
+	return t
+}
+
+// remove unschedules a timer and reports whether it was active.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	for i, active := range c.timers {
+		if active == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	fire     func(time.Time)
+}
+
+// Stop implements common.Timer.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	return t.clock.remove(t)
+}
+
+// Reset implements common.Timer.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.clock.remove(t)
+	t.deadline = t.clock.now.Add(d)
+	t.clock.timers = append(t.clock.timers, t)
+
+	return active
+}
@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	clock := NewFakeClock(start)
+	require.Equal(t, start, clock.Now())
+
+	after := clock.After(time.Minute)
+
+	var fired int32
+	done := make(chan struct{})
+	timer := clock.AfterFunc(2*time.Minute, func() {
+		atomic.AddInt32(&fired, 1)
+		close(done)
+	})
+	stopped := clock.AfterFunc(time.Second, func() {
+		atomic.AddInt32(&fired, 1)
+	})
+	require.True(t, stopped.Stop())
+	require.False(t, stopped.Stop())
+
+	clock.Advance(30 * time.Second)
+	select {
+	case <-after:
+		t.Fatal("After fired too early")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	require.Equal(t, start.Add(time.Minute), <-after)
+
+	// postpone AfterFunc by another minute
+	require.True(t, timer.Reset(2*time.Minute))
+	clock.Advance(time.Minute)
+	require.Equal(t, int32(0), atomic.LoadInt32(&fired))
+
+	clock.Advance(time.Minute)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("AfterFunc did not fire")
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&fired))
+	require.False(t, timer.Stop())
+}
@@ -0,0 +1,105 @@
+package integration
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/status-im/status-go/geth/params"
+)
+
+var (
+	reservedPortsMu sync.Mutex
+	reservedPorts   = make(map[int]bool)
+)
+
+// IsolatedTestEnv holds a data directory and ports reserved for a single test,
+// so tests run with -parallel don't collide on LevelDB locks and listeners.
+type IsolatedTestEnv struct {
+	// DataDir is a unique temporary data directory.
+	DataDir string
+
+	// ListenPort is a p2p listening port.
+	ListenPort int
+
+	// HTTPPort is an HTTP RPC port.
+	HTTPPort int
+
+	// WSPort is a WebSocket RPC port.
+	WSPort int
+}
+
+// NewIsolatedTestEnv allocates a unique data dir and free ports for a test.
+// Teardown must be called once the test is done.
+func NewIsolatedTestEnv(t *testing.T) *IsolatedTestEnv {
+	// subtest names contain slashes
+	prefix := "status-go-" + strings.Replace(t.Name(), "/", "_", -1) + "-"
+
+	dataDir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		t.Fatalf("failed to create data dir: %v", err)
+	}
+
+	env := &IsolatedTestEnv{DataDir: dataDir}
+
+	ports := make([]int, 3)
+	for i := range ports {
+		ports[i], err = reserveFreePort()
+		if err != nil {
+			env.Teardown()
+			t.Fatalf("failed to reserve port: %v", err)
+		}
+	}
+	env.ListenPort, env.HTTPPort, env.WSPort = ports[0], ports[1], ports[2]
+
+	return env
+}
+
+// Configure points a given node config to the environment's data dir and ports.
+func (e *IsolatedTestEnv) Configure(config *params.NodeConfig) {
+	config.DataDir = e.DataDir
+	config.KeyStoreDir = filepath.Join(e.DataDir, params.KeyStoreDir)
+	config.WhisperConfig.DataDir = filepath.Join(e.DataDir, params.WhisperDataDir)
+	config.ListenAddr = "127.0.0.1:" + strconv.Itoa(e.ListenPort)
+	config.HTTPPort = e.HTTPPort
+	config.WSPort = e.WSPort
+}
+
+// Teardown removes the data dir and releases reserved ports.
+func (e *IsolatedTestEnv) Teardown() {
+	os.RemoveAll(e.DataDir) // nolint: errcheck
+
+	reservedPortsMu.Lock()
+	for _, port := range []int{e.ListenPort, e.HTTPPort, e.WSPort} {
+		delete(reservedPorts, port)
+	}
+	reservedPortsMu.Unlock()
+}
+
+// reserveFreePort finds a port that is free on the host and
+// has not been handed out to another environment in this process.
+func reserveFreePort() (int, error) {
+	reservedPortsMu.Lock()
+	defer reservedPortsMu.Unlock()
+
+	for {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			return 0, err
+		}
+		port := l.Addr().(*net.TCPAddr).Port
+		if err := l.Close(); err != nil {
+			return 0, err
+		}
+
+		if !reservedPorts[port] {
+			reservedPorts[port] = true
+			return port, nil
+		}
+	}
+}
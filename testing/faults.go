@@ -0,0 +1,184 @@
+package integration
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+var (
+	// ErrPartitioned is returned when a node is dialed or written to across a partition.
+	ErrPartitioned = errors.New("peer is partitioned")
+
+	// ErrPacketLost is returned when a write is dropped by the fault injector.
+	ErrPacketLost = errors.New("packet lost")
+)
+
+// FaultInjector is p2p.NodeDialer which wraps outbound peer connections
+// of a node to inject latency, packet loss and network partitions.
+// Install it with node.NodeManager.SetDialer before the node is started.
+//
+// Only connections dialed by the node are affected, so the node under test
+// should be the one adding peers (e.g. Sender of WhisperTestPair).
+// RLPx streams are authenticated, so a lost packet can't be silently skipped:
+// the connection is closed instead, the same way a peer drops it on a MAC mismatch.
+type FaultInjector struct {
+	dialer p2p.NodeDialer
+
+	mu          sync.Mutex
+	latency     time.Duration
+	lossRate    float64
+	rand        *rand.Rand
+	partitioned map[discover.NodeID]bool
+	conns       map[*faultyConn]struct{}
+}
+
+// NewFaultInjector returns FaultInjector which does not inject any faults until configured.
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{
+		dialer:      p2p.TCPDialer{Dialer: &net.Dialer{Timeout: 15 * time.Second}},
+		rand:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		partitioned: make(map[discover.NodeID]bool),
+		conns:       make(map[*faultyConn]struct{}),
+	}
+}
+
+// SetLatency delays every read and write on peer connections by a given duration.
+func (f *FaultInjector) SetLatency(latency time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.latency = latency
+}
+
+// SetLossRate sets probability (0 to 1) of a write being lost.
+func (f *FaultInjector) SetLossRate(rate float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lossRate = rate
+}
+
+// Partition disconnects given peers and prevents them from being dialed until Heal is called.
+func (f *FaultInjector) Partition(ids ...discover.NodeID) {
+	var disconnected []*faultyConn
+
+	f.mu.Lock()
+	for _, id := range ids {
+		f.partitioned[id] = true
+	}
+	for conn := range f.conns {
+		if f.partitioned[conn.peer] {
+			disconnected = append(disconnected, conn)
+		}
+	}
+	f.mu.Unlock()
+
+	for _, conn := range disconnected {
+		conn.Close() // nolint: errcheck
+	}
+}
+
+// Heal removes all partitions. Disconnected peers must be dialed again.
+func (f *FaultInjector) Heal() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.partitioned = make(map[discover.NodeID]bool)
+}
+
+// Dial implements p2p.NodeDialer.
+func (f *FaultInjector) Dial(dest *discover.Node) (net.Conn, error) {
+	if f.isPartitioned(dest.ID) {
+		return nil, ErrPartitioned
+	}
+
+	conn, err := f.dialer.Dial(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	fc := &faultyConn{Conn: conn, injector: f, peer: dest.ID}
+
+	f.mu.Lock()
+	f.conns[fc] = struct{}{}
+	f.mu.Unlock()
+
+	return fc, nil
+}
+
+func (f *FaultInjector) isPartitioned(id discover.NodeID) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.partitioned[id]
+}
+
+// fault returns latency to inject and whether a write is lost or partitioned.
+func (f *FaultInjector) fault(peer discover.NodeID) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.partitioned[peer] {
+		return 0, ErrPartitioned
+	}
+	if f.lossRate > 0 && f.rand.Float64() < f.lossRate {
+		return 0, ErrPacketLost
+	}
+
+	return f.latency, nil
+}
+
+func (f *FaultInjector) currentLatency() time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.latency
+}
+
+func (f *FaultInjector) remove(conn *faultyConn) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.conns, conn)
+}
+
+// faultyConn is a peer connection subject to faults of its injector.
+type faultyConn struct {
+	net.Conn
+
+	injector *FaultInjector
+	peer     discover.NodeID
+}
+
+func (c *faultyConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if latency := c.injector.currentLatency(); latency > 0 {
+		time.Sleep(latency)
+	}
+
+	return n, err
+}
+
+func (c *faultyConn) Write(b []byte) (int, error) {
+	latency, err := c.injector.fault(c.peer)
+	if err != nil {
+		c.Close() // nolint: errcheck
+		return 0, err
+	}
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	return c.Conn.Write(b)
+}
+
+func (c *faultyConn) Close() error {
+	c.injector.remove(c)
+	return c.Conn.Close()
+}
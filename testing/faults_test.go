@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFaultInjector(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close() // nolint: errcheck
+
+	// echo server
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 16)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						conn.Close() // nolint: errcheck
+						return
+					}
+					conn.Write(buf[:n]) // nolint: errcheck
+				}
+			}()
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	dest := discover.NewNode(discover.NodeID{0x01}, addr.IP, 0, uint16(addr.Port))
+
+	faults := NewFaultInjector()
+	faults.SetLatency(50 * time.Millisecond)
+
+	conn, err := faults.Dial(dest)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = conn.Write([]byte("ping"))
+	require.NoError(t, err)
+	buf := make([]byte, 4)
+	_, err = conn.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, "ping", string(buf))
+	require.True(t, time.Since(start) >= 100*time.Millisecond, "latency is not injected")
+
+	// lost write breaks the connection
+	faults.SetLatency(0)
+	faults.SetLossRate(1)
+	_, err = conn.Write([]byte("ping"))
+	require.Equal(t, ErrPacketLost, err)
+	faults.SetLossRate(0)
+
+	// partition disconnects the peer and prevents dialing it
+	conn, err = faults.Dial(dest)
+	require.NoError(t, err)
+	faults.Partition(dest.ID)
+	_, err = conn.Read(buf)
+	require.Error(t, err)
+	_, err = faults.Dial(dest)
+	require.Equal(t, ErrPartitioned, err)
+
+	faults.Heal()
+	conn, err = faults.Dial(dest)
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+)
+
+const (
+	// TestAccountPassword is a password of accounts created by CreateFundedTestAccounts.
+	TestAccountPassword = "test-password"
+
+	// fundingTimeout limits time spent on funding RPC calls.
+	fundingTimeout = time.Minute
+)
+
+var (
+	// ErrFundingNotSupported is returned when accounts are funded on a network other than StatusChain.
+	ErrFundingNotSupported = errors.New("test accounts can only be funded on StatusChain")
+
+	// transferGas is gas limit of a plain value transfer.
+	transferGas = big.NewInt(21000)
+)
+
+// TestAccount is an account created by CreateFundedTestAccounts.
+type TestAccount struct {
+	Address  gethcommon.Address
+	Password string
+
+	// FundingTx is a hash of the transaction which funded the account,
+	// use WaitForReceipt to make sure the balance is available.
+	FundingTx gethcommon.Hash
+}
+
+// CreateFundedTestAccounts creates n accounts in the keystore of a running
+// StatusChain node and transfers a given amount of wei to each of them
+// from the dev account (TestConfig.Account1), which must be imported.
+func CreateFundedTestAccounts(nodeManager common.NodeManager, n int, amount *big.Int) ([]TestAccount, error) {
+	config, err := nodeManager.NodeConfig()
+	if err != nil {
+		return nil, ErrNoNodeConfig
+	}
+	if config.NetworkID != params.StatusChainNetworkID {
+		return nil, ErrFundingNotSupported
+	}
+
+	keyStore, err := nodeManager.AccountKeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	devAccount, err := keyStore.Find(accounts.Account{Address: gethcommon.HexToAddress(TestConfig.Account1.Address)})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fundingTimeout)
+	defer cancel()
+
+	client := nodeManager.RPCClient()
+
+	var txCount hexutil.Uint64
+	if err := client.CallContext(ctx, &txCount, "eth_getTransactionCount", devAccount.Address, "pending"); err != nil {
+		return nil, err
+	}
+
+	var gasPrice hexutil.Big
+	if err := client.CallContext(ctx, &gasPrice, "eth_gasPrice"); err != nil {
+		return nil, err
+	}
+
+	chainID := big.NewInt(int64(config.NetworkID))
+	testAccounts := make([]TestAccount, n)
+
+	for i := range testAccounts {
+		account, err := keyStore.NewAccount(TestAccountPassword)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := uint64(txCount) + uint64(i)
+		tx := types.NewTransaction(nonce, account.Address, amount, transferGas, (*big.Int)(&gasPrice), nil)
+		signedTx, err := keyStore.SignTxWithPassphrase(devAccount, TestConfig.Account1.Password, tx, chainID)
+		if err != nil {
+			return nil, err
+		}
+
+		txBytes, err := rlp.EncodeToBytes(signedTx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := client.CallContext(ctx, nil, "eth_sendRawTransaction", gethcommon.ToHex(txBytes)); err != nil {
+			return nil, err
+		}
+
+		testAccounts[i] = TestAccount{
+			Address:   account.Address,
+			Password:  TestAccountPassword,
+			FundingTx: signedTx.Hash(),
+		}
+	}
+
+	return testAccounts, nil
+}
@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/testing/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateFundedTestAccounts(t *testing.T) {
+	keyStoreDir, err := ioutil.TempDir("", "status-go-fixtures")
+	require.NoError(t, err)
+	defer os.RemoveAll(keyStoreDir) //nolint: errcheck
+
+	require.NoError(t, common.ImportTestAccount(keyStoreDir, "test-account1-status-chain.pk"))
+
+	nodeManager := mocks.NewNodeManager()
+	nodeManager.SetAccountKeyStore(keystore.NewKeyStore(keyStoreDir, keystore.LightScryptN, keystore.LightScryptP))
+
+	config, err := params.NewNodeConfig(keyStoreDir, params.StatusChainNetworkID, true)
+	require.NoError(t, err)
+	_, err = nodeManager.StartNode(config)
+	require.NoError(t, err)
+
+	nodeManager.SetRPCResponse("eth_getTransactionCount", hexutil.Uint64(5), nil)
+	nodeManager.SetRPCResponse("eth_gasPrice", hexutil.Big(*big.NewInt(1)), nil)
+
+	var sentTxs []*types.Transaction
+	nodeManager.RPCClient().RegisterHandler("eth_sendRawTransaction", func(ctx context.Context, args ...interface{}) (interface{}, error) {
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(hexutil.MustDecode(args[0].(string)), tx); err != nil {
+			return nil, err
+		}
+		sentTxs = append(sentTxs, tx)
+		return tx.Hash(), nil
+	})
+
+	amount := big.NewInt(1000)
+	testAccounts, err := CreateFundedTestAccounts(nodeManager, 2, amount)
+	require.NoError(t, err)
+	require.Len(t, testAccounts, 2)
+	require.Len(t, sentTxs, 2)
+
+	for i, account := range testAccounts {
+		require.Equal(t, account.Address, *sentTxs[i].To())
+		require.Equal(t, account.FundingTx, sentTxs[i].Hash())
+		require.Equal(t, uint64(5+i), sentTxs[i].Nonce())
+		require.Equal(t, amount, sentTxs[i].Value())
+	}
+}
@@ -0,0 +1,122 @@
+package mocks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/node"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeManagerLifecycle(t *testing.T) {
+	nodeManager := NewNodeManager()
+	require.False(t, nodeManager.IsNodeRunning())
+
+	_, err := nodeManager.NodeConfig()
+	require.Equal(t, node.ErrNoRunningNode, err)
+
+	_, err = nodeManager.StartNode(nil)
+	require.Equal(t, ErrNoNodeConfig, err)
+
+	events := make(chan common.NodeEvent, 3)
+	sub := nodeManager.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	config, err := params.NewNodeConfig("/tmp", params.RopstenNetworkID, true)
+	require.NoError(t, err)
+	_, err = nodeManager.StartNode(config)
+	require.NoError(t, err)
+	require.True(t, nodeManager.IsNodeRunning())
+
+	_, err = nodeManager.StartNode(config)
+	require.Equal(t, node.ErrNodeExists, err)
+
+	nc, err := nodeManager.NodeConfig()
+	require.NoError(t, err)
+	require.Equal(t, config, nc)
+
+	require.NoError(t, nodeManager.AddPeer("enode://peer@127.0.0.1:30303"))
+	require.NoError(t, nodeManager.AddTrustedPeer("enode://trusted@127.0.0.1:30304"))
+	require.Equal(t, []string{"enode://peer@127.0.0.1:30303", "enode://trusted@127.0.0.1:30304"}, nodeManager.PeerURLs())
+
+	require.NoError(t, nodeManager.RemovePeer("enode://peer@127.0.0.1:30303"))
+	peers, err := nodeManager.Peers()
+	require.NoError(t, err)
+	require.Len(t, peers, 1)
+	require.Equal(t, "enode://trusted@127.0.0.1:30304", peers[0].ID)
+
+	require.NoError(t, nodeManager.RegisterTopic("mailserver"))
+	require.True(t, nodeManager.IsTopicRegistered("mailserver"))
+	require.NoError(t, nodeManager.UnregisterTopic("mailserver"))
+	require.Equal(t, node.ErrTopicNotRegistered, nodeManager.UnregisterTopic("mailserver"))
+
+	heads := make(chan common.ChainHead, 1)
+	headsSub := nodeManager.SubscribeChainHeads(heads)
+	nodeManager.SendChainHead(common.ChainHead{Number: 1})
+	require.Equal(t, uint64(1), (<-heads).Number)
+	headsSub.Unsubscribe()
+
+	nodeManager.SetTopicNodes("mailserver", []string{"enode://a@127.0.0.1:30303", "enode://b@127.0.0.1:30303"})
+	enodes, err := nodeManager.SearchTopic("mailserver", 1, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, []string{"enode://a@127.0.0.1:30303"}, enodes)
+
+	nodeManager.SetSyncProgress(10, 20)
+	status := nodeManager.NodeStatus()
+	require.True(t, status.Running)
+	require.True(t, status.Syncing)
+	require.Equal(t, 1, status.Peers)
+	require.Equal(t, uint64(10), status.CurrentBlock)
+
+	_, err = nodeManager.LightEthereumService()
+	require.Equal(t, node.ErrInvalidLightEthereumService, err)
+
+	errLES := errors.New("LES is unavailable")
+	nodeManager.SetLightEthereumService(nil, errLES)
+	_, err = nodeManager.LightEthereumService()
+	require.Equal(t, errLES, err)
+
+	_, err = nodeManager.EthereumService()
+	require.Equal(t, node.ErrInvalidEthereumService, err)
+
+	_, err = nodeManager.StopNode()
+	require.NoError(t, err)
+	require.False(t, nodeManager.IsNodeRunning())
+	require.Equal(t, common.NodeStarting, (<-events).Type)
+	require.Equal(t, common.NodeStarted, (<-events).Type)
+	require.Equal(t, common.NodeStopped, (<-events).Type)
+	require.Empty(t, nodeManager.PeerURLs())
+	require.False(t, nodeManager.NodeStatus().Running)
+
+	_, err = nodeManager.StopNode()
+	require.Equal(t, node.ErrNoRunningNode, err)
+}
+
+func TestNodeManagerRPC(t *testing.T) {
+	nodeManager := NewNodeManager()
+	client := nodeManager.RPCClient()
+
+	var balance string
+	nodeManager.SetRPCResponse("eth_getBalance", "0x10", nil)
+	require.NoError(t, client.Call(&balance, "eth_getBalance"))
+	require.Equal(t, "0x10", balance)
+
+	errRPC := errors.New("scripted error")
+	nodeManager.SetRPCResponse("eth_getBalance", nil, errRPC)
+	require.Equal(t, errRPC, client.Call(&balance, "eth_getBalance"))
+
+	nodeManager.SetSyncProgress(5, 10)
+	require.False(t, nodeManager.IsSynced())
+	response := client.CallRaw(`{"jsonrpc":"2.0","id":1,"method":"eth_syncing","params":[]}`)
+	require.Contains(t, response, `"currentBlock":"0x5"`)
+	require.Contains(t, response, `"highestBlock":"0xa"`)
+
+	nodeManager.SetSyncProgress(10, 10)
+	require.True(t, nodeManager.IsSynced())
+	var syncing bool
+	require.NoError(t, client.Call(&syncing, "eth_syncing"))
+	require.False(t, syncing)
+}
@@ -0,0 +1,164 @@
+package integration
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// errors
+var (
+	// ErrNoRemoteURL is returned when network id has no associated url.
+	ErrNoRemoteURL = errors.New("network id requires a remote URL")
+
+	// ErrTestNetworkExists is returned when a network with the same id or name is already registered.
+	ErrTestNetworkExists = errors.New("test network is already registered")
+
+	// ErrInvalidTestNetwork is returned when a network is registered without id or name.
+	ErrInvalidTestNetwork = errors.New("test network requires a non-zero id and a name")
+)
+
+// TestNetwork describes a network tests can be run against.
+type TestNetwork struct {
+	ID          int
+	Name        string
+	URL         string // upstream RPC endpoint, empty if there is none
+	GenesisHash string // hash of the first block, derived from params genesis if empty
+}
+
+var (
+	// testNetworks holds all registered networks keyed by network ID.
+	testNetworks = map[int]TestNetwork{
+		params.MainNetworkID: {
+			ID:   params.MainNetworkID,
+			Name: "Mainnet",
+			URL:  params.MainnetEthereumNetworkURL,
+		},
+		params.RopstenNetworkID: {
+			ID:   params.RopstenNetworkID,
+			Name: "Ropsten",
+			URL:  params.RopstenEthereumNetworkURL,
+		},
+		params.RinkebyNetworkID: {
+			ID:   params.RinkebyNetworkID,
+			Name: "Rinkeby",
+			URL:  params.RinkebyEthereumNetworkURL,
+		},
+		params.StatusChainNetworkID: {
+			ID:   params.StatusChainNetworkID,
+			Name: "StatusChain",
+		},
+	}
+
+	// testNetworkAliases maps additional -network names to network IDs.
+	testNetworkAliases = map[string]int{
+		"testnet": params.RopstenNetworkID,
+	}
+
+	// TestNetworkNames network ID to name mapping
+	TestNetworkNames = makeTestNetworkNames()
+)
+
+func makeTestNetworkNames() map[int]string {
+	names := make(map[int]string, len(testNetworks))
+	for id, network := range testNetworks {
+		names[id] = network.Name
+	}
+
+	return names
+}
+
+// RegisterTestNetwork makes a custom network available to tests, selectable
+// with -network=ID or -network=NAME. It is expected to be called from init().
+func RegisterTestNetwork(id int, name, url, genesisHash string) error {
+	if id == 0 || name == "" {
+		return ErrInvalidTestNetwork
+	}
+
+	if _, ok := testNetworks[id]; ok {
+		return ErrTestNetworkExists
+	}
+
+	if _, ok := lookupTestNetwork(name); ok {
+		return ErrTestNetworkExists
+	}
+
+	testNetworks[id] = TestNetwork{
+		ID:          id,
+		Name:        name,
+		URL:         url,
+		GenesisHash: genesisHash,
+	}
+	TestNetworkNames[id] = name
+
+	// TestConfig is loaded before any custom network can be registered,
+	// so reload it if the registered network is the selected one.
+	if GetNetworkID() != id {
+		return nil
+	}
+
+	testConfig, err := common.LoadTestConfig(id)
+	if err != nil {
+		return err
+	}
+	TestConfig = testConfig
+
+	return nil
+}
+
+// lookupTestNetwork finds a registered network by its ID, name or alias.
+func lookupTestNetwork(selector string) (TestNetwork, bool) {
+	selector = strings.ToLower(selector)
+
+	if id, ok := testNetworkAliases[selector]; ok {
+		network, ok := testNetworks[id]
+		return network, ok
+	}
+
+	for _, network := range testNetworks {
+		if selector == strconv.Itoa(network.ID) || selector == strings.ToLower(network.Name) {
+			return network, true
+		}
+	}
+
+	return TestNetwork{}, false
+}
+
+// GetRemoteURLFromNetworkID returns associated network url for giving network id.
+func GetRemoteURLFromNetworkID(id int) (url string, err error) {
+	network, ok := testNetworks[id]
+	if !ok || network.URL == "" {
+		return "", ErrNoRemoteURL
+	}
+
+	return network.URL, nil
+}
+
+// GetHeadHashFromNetworkID returns the hash associated with a given network id.
+// Unless set explicitly on registration, it is derived from the genesis
+// spec the node is configured with.
+func GetHeadHashFromNetworkID(id int) string {
+	if network := testNetworks[id]; network.GenesisHash != "" {
+		return network.GenesisHash
+	}
+
+	hash, err := params.GenesisHash(uint64(id))
+	if err != nil {
+		return ""
+	}
+
+	return hash.Hex()
+}
+
+// GetNetworkID returns appropriate network id for test based on
+// default or provided -network flag.
+func GetNetworkID() int {
+	if network, ok := lookupTestNetwork(*networkSelected); ok {
+		return network.ID
+	}
+
+	return params.StatusChainNetworkID
+}
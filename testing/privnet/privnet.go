@@ -0,0 +1,430 @@
+// Package privnet runs a small private proof-of-authority (clique) network
+// in Docker: a bootnode and two miners. In-process nodes can be wired to it
+// to test peer discovery, reorgs and sync against a chain that is actually mined.
+package privnet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	gethparams "github.com/ethereum/go-ethereum/params"
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/status-im/status-go/geth/params"
+)
+
+const (
+	// GethImage is a Docker image miners are run with.
+	GethImage = "ethereum/client-go:v1.7.2"
+
+	// BootnodeImage is a Docker image the bootnode is run with.
+	BootnodeImage = "ethereum/client-go:alltools-v1.7.2"
+
+	// MinersCount is a number of miners (and clique signers) in the network.
+	MinersCount = 2
+
+	// signerPassword unlocks signer accounts of miners.
+	signerPassword = "privnet"
+
+	// pollInterval is how often network readiness is checked.
+	pollInterval = 500 * time.Millisecond
+)
+
+// errors
+var (
+	ErrDockerNotAvailable = errors.New("docker executable is not found")
+	ErrNetworkNotStarted  = errors.New("private network is not started")
+)
+
+// Miner is a mining node of the network.
+type Miner struct {
+	Container string
+	Signer    gethcommon.Address
+	NodeKey   *ecdsa.PrivateKey
+
+	// IP is an address of the miner inside of the Docker network.
+	IP string
+
+	// P2PPort and RPCPort are ports published on the host.
+	P2PPort int
+	RPCPort int
+}
+
+// Enode returns URL of the miner reachable from the host.
+func (m *Miner) Enode() string {
+	id := discover.PubkeyID(&m.NodeKey.PublicKey)
+	return discover.NewNode(id, net.ParseIP("127.0.0.1"), uint16(m.P2PPort), uint16(m.P2PPort)).String()
+}
+
+// RPCURL returns HTTP RPC endpoint of the miner reachable from the host.
+func (m *Miner) RPCURL() string {
+	return "http://127.0.0.1:" + strconv.Itoa(m.RPCPort)
+}
+
+// Network is a private PoA network run in Docker.
+type Network struct {
+	Name      string
+	NetworkID uint64
+	Subnet    string // e.g. 172.31.77.0/24, containers get addresses .2 and up
+	Genesis   *core.Genesis
+	Miners    []*Miner
+
+	dir         string
+	bootnodeKey *ecdsa.PrivateKey
+	started     bool
+}
+
+// Available returns true if Docker can be used to run a network.
+func Available() bool {
+	_, err := exec.LookPath("docker")
+	return err == nil
+}
+
+// New prepares keys, genesis and data directories of a network.
+// Name is used as a prefix of Docker network and container names.
+func New(name string, networkID uint64, subnet string) (*Network, error) {
+	dir, err := ioutil.TempDir("", "status-go-privnet-")
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Network{
+		Name:      name,
+		NetworkID: networkID,
+		Subnet:    subnet,
+		dir:       dir,
+	}
+
+	if err := n.prepare(); err != nil {
+		os.RemoveAll(dir) // nolint: errcheck
+		return nil, err
+	}
+
+	return n, nil
+}
+
+// prepare generates keys of the bootnode and miners and writes genesis.
+func (n *Network) prepare() error {
+	var err error
+	if n.bootnodeKey, err = crypto.GenerateKey(); err != nil {
+		return err
+	}
+
+	signers := make([]gethcommon.Address, MinersCount)
+	for i := range signers {
+		miner, err := n.prepareMiner(i)
+		if err != nil {
+			return err
+		}
+
+		n.Miners = append(n.Miners, miner)
+		signers[i] = miner.Signer
+	}
+
+	n.Genesis = makeCliqueGenesis(n.NetworkID, signers)
+
+	genesisJSON, err := json.Marshal(n.Genesis)
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(n.dir, "genesis.json"), genesisJSON, 0644); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(n.dir, "password"), []byte(signerPassword), 0644)
+}
+
+// prepareMiner generates node and signer keys of a miner with a given index.
+func (n *Network) prepareMiner(i int) (*Miner, error) {
+	nodeKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	signerKey, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	keyStore := keystore.NewKeyStore(filepath.Join(n.dir, n.minerName(i), "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	signer, err := keyStore.ImportECDSA(signerKey, signerPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	p2pPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	rpcPort, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Miner{
+		Container: n.minerName(i),
+		Signer:    signer.Address,
+		NodeKey:   nodeKey,
+		IP:        n.containerIP(3 + i),
+		P2PPort:   p2pPort,
+		RPCPort:   rpcPort,
+	}, nil
+}
+
+// Start creates the Docker network and runs the bootnode and miners.
+func (n *Network) Start() error {
+	if !Available() {
+		return ErrDockerNotAvailable
+	}
+
+	if err := docker("network", "create", "--subnet", n.Subnet, n.Name); err != nil {
+		return err
+	}
+	n.started = true
+
+	bootnodeIP := n.containerIP(2)
+	err := docker("run", "-d", "--name", n.bootnodeName(), "--network", n.Name, "--ip", bootnodeIP,
+		BootnodeImage, "bootnode", "--nodekeyhex", hex.EncodeToString(crypto.FromECDSA(n.bootnodeKey)), "--addr", ":30301")
+	if err != nil {
+		return err
+	}
+
+	bootnodeID := discover.PubkeyID(&n.bootnodeKey.PublicKey)
+	bootnodeEnode := discover.NewNode(bootnodeID, net.ParseIP(bootnodeIP), 30301, 30301).String()
+
+	for i, miner := range n.Miners {
+		dataDir := "/data/" + n.minerName(i)
+		gethArgs := []string{
+			"geth", "--datadir", dataDir,
+			"--networkid", strconv.FormatUint(n.NetworkID, 10),
+			"--nodekeyhex", hex.EncodeToString(crypto.FromECDSA(miner.NodeKey)),
+			"--bootnodes", bootnodeEnode,
+			"--nat", "extip:" + miner.IP,
+			"--mine", "--minerthreads", "1", "--etherbase", miner.Signer.Hex(),
+			"--unlock", miner.Signer.Hex(), "--password", "/data/password",
+			"--lightserv", "50",
+			"--rpc", "--rpcaddr", "0.0.0.0", "--rpcapi", "eth,net,web3,personal,admin,clique",
+		}
+		script := "geth --datadir " + dataDir + " init /data/genesis.json && exec " + strings.Join(gethArgs, " ")
+
+		err := docker("run", "-d", "--name", miner.Container, "--network", n.Name, "--ip", miner.IP,
+			"-v", n.dir+":/data",
+			"-p", fmt.Sprintf("%d:30303", miner.P2PPort),
+			"-p", fmt.Sprintf("%d:30303/udp", miner.P2PPort),
+			"-p", fmt.Sprintf("%d:8545", miner.RPCPort),
+			"--entrypoint", "sh", GethImage, "-c", script)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WaitReady waits until every miner is peered and the chain has advanced.
+func (n *Network) WaitReady(ctx context.Context) error {
+	if !n.started {
+		return ErrNetworkNotStarted
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if n.ready(ctx) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (n *Network) ready(ctx context.Context) bool {
+	for _, miner := range n.Miners {
+		client, err := gethrpc.DialContext(ctx, miner.RPCURL())
+		if err != nil {
+			return false
+		}
+
+		var peerCount, blockNumber hexutil.Uint64
+		err = client.CallContext(ctx, &peerCount, "net_peerCount")
+		if err == nil {
+			err = client.CallContext(ctx, &blockNumber, "eth_blockNumber")
+		}
+		client.Close()
+
+		if err != nil || peerCount == 0 || blockNumber == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Fund transfers a given amount of wei from the first miner's signer.
+func (n *Network) Fund(ctx context.Context, to gethcommon.Address, amount *big.Int) (gethcommon.Hash, error) {
+	if !n.started {
+		return gethcommon.Hash{}, ErrNetworkNotStarted
+	}
+
+	miner := n.Miners[0]
+	client, err := gethrpc.DialContext(ctx, miner.RPCURL())
+	if err != nil {
+		return gethcommon.Hash{}, err
+	}
+	defer client.Close()
+
+	tx := map[string]interface{}{
+		"from":  miner.Signer,
+		"to":    to,
+		"value": (*hexutil.Big)(amount),
+	}
+
+	var hash gethcommon.Hash
+	err = client.CallContext(ctx, &hash, "eth_sendTransaction", tx)
+
+	return hash, err
+}
+
+// Configure makes a node config join the network, with miners as boot nodes.
+func (n *Network) Configure(config *params.NodeConfig) error {
+	genesisJSON, err := json.Marshal(n.Genesis)
+	if err != nil {
+		return err
+	}
+
+	config.NetworkID = n.NetworkID
+	config.LightEthConfig.Genesis = string(genesisJSON)
+	config.UpstreamConfig.Enabled = false
+	config.BootClusterConfig.Enabled = true
+	config.BootClusterConfig.RootNumber = 0
+	config.BootClusterConfig.RootHash = ""
+	config.BootClusterConfig.BootNodes = nil
+	for _, miner := range n.Miners {
+		config.BootClusterConfig.BootNodes = append(config.BootClusterConfig.BootNodes, miner.Enode())
+	}
+
+	return nil
+}
+
+// Stop removes containers, the Docker network and generated data.
+func (n *Network) Stop() error {
+	var err error
+	if n.started {
+		containers := []string{"rm", "-f", n.bootnodeName()}
+		for _, miner := range n.Miners {
+			containers = append(containers, miner.Container)
+		}
+
+		err = docker(containers...)
+		if networkErr := docker("network", "rm", n.Name); err == nil {
+			err = networkErr
+		}
+		n.started = false
+	}
+
+	if removeErr := os.RemoveAll(n.dir); err == nil {
+		err = removeErr
+	}
+
+	return err
+}
+
+func (n *Network) bootnodeName() string {
+	return n.Name + "-bootnode"
+}
+
+func (n *Network) minerName(i int) string {
+	return fmt.Sprintf("%s-miner%d", n.Name, i+1)
+}
+
+// containerIP returns an address with a given host part within the subnet.
+func (n *Network) containerIP(host int) string {
+	ip, _, err := net.ParseCIDR(n.Subnet)
+	if err != nil {
+		return ""
+	}
+
+	ip = ip.To4()
+	return net.IPv4(ip[0], ip[1], ip[2], byte(host)).String()
+}
+
+// makeCliqueGenesis returns genesis of a clique chain with given signers,
+// each of them holding a large balance.
+func makeCliqueGenesis(networkID uint64, signers []gethcommon.Address) *core.Genesis {
+	// extra data is 32 vanity bytes, signer addresses and a 65 bytes seal
+	extraData := make([]byte, 32)
+	alloc := make(core.GenesisAlloc, len(signers))
+	balance, _ := new(big.Int).SetString("1000000000000000000000000", 10)
+
+	for _, signer := range signers {
+		extraData = append(extraData, signer.Bytes()...)
+		alloc[signer] = core.GenesisAccount{Balance: balance}
+	}
+	extraData = append(extraData, make([]byte, 65)...)
+
+	return &core.Genesis{
+		Config: &gethparams.ChainConfig{
+			ChainId:        new(big.Int).SetUint64(networkID),
+			HomesteadBlock: big.NewInt(0),
+			EIP150Block:    big.NewInt(0),
+			EIP155Block:    big.NewInt(0),
+			EIP158Block:    big.NewInt(0),
+			ByzantiumBlock: big.NewInt(0),
+			Clique: &gethparams.CliqueConfig{
+				Period: 1,
+				Epoch:  30000,
+			},
+		},
+		Timestamp:  uint64(time.Now().Unix()),
+		ExtraData:  extraData,
+		GasLimit:   6283185,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+}
+
+// docker runs docker CLI with given arguments.
+func docker(args ...string) error {
+	output, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
+// freePort returns a TCP port that is free on the host.
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close() // nolint: errcheck
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
@@ -0,0 +1,52 @@
+package privnet
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetworkConfigure(t *testing.T) {
+	network, err := New("status-go-privnet-test", 1337, "172.31.77.0/24")
+	require.NoError(t, err)
+	defer network.Stop() // nolint: errcheck
+
+	require.Len(t, network.Miners, MinersCount)
+	require.Equal(t, "172.31.77.3", network.Miners[0].IP)
+	require.Len(t, network.Genesis.ExtraData, 32+MinersCount*gethcommon.AddressLength+65)
+
+	config, err := params.NewNodeConfig("/tmp", params.StatusChainNetworkID, true)
+	require.NoError(t, err)
+	require.NoError(t, network.Configure(config))
+
+	require.Equal(t, uint64(1337), config.NetworkID)
+	require.Equal(t, []string{network.Miners[0].Enode(), network.Miners[1].Enode()}, config.BootClusterConfig.BootNodes)
+	require.Contains(t, config.LightEthConfig.Genesis, `"clique"`)
+
+	_, err = network.Fund(context.Background(), gethcommon.Address{}, big.NewInt(1))
+	require.Equal(t, ErrNetworkNotStarted, err)
+}
+
+func TestNetworkStart(t *testing.T) {
+	if !Available() {
+		t.Skip("docker is not available")
+	}
+
+	network, err := New("status-go-privnet-start", 1338, "172.31.78.0/24")
+	require.NoError(t, err)
+	defer network.Stop() // nolint: errcheck
+
+	require.NoError(t, network.Start())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	require.NoError(t, network.WaitReady(ctx))
+
+	_, err = network.Fund(ctx, gethcommon.HexToAddress("0x01"), big.NewInt(1))
+	require.NoError(t, err)
+}
@@ -0,0 +1,240 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+var (
+	recordRPC = flag.Bool("record", false, "-record to capture upstream JSON-RPC responses into golden files instead of replaying them")
+
+	// ErrNoRecordedResponse is returned when a replayed request has no recorded response.
+	ErrNoRecordedResponse = errors.New("no recorded response for JSON-RPC request")
+)
+
+// rpcExchange is a JSON-RPC request/response pair stored in a golden file.
+type rpcExchange struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// rpcMessage is a JSON-RPC request or response.
+type rpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+// RPCReplayServer is a JSON-RPC HTTP endpoint that, depending on -record flag,
+// either proxies requests to an upstream URL and records responses into
+// a golden file, or serves previously recorded responses from it.
+// Use URL as the node's upstream to make tests hermetic.
+type RPCReplayServer struct {
+	URL string
+
+	server      *httptest.Server
+	upstreamURL string
+	goldenFile  string
+	recording   bool
+
+	mu        sync.Mutex
+	exchanges []rpcExchange
+	served    map[string]int // number of times a request was replayed
+}
+
+// NewRPCReplayServer starts a record/replay server for a given upstream
+// and golden file. In replay mode the golden file must exist.
+func NewRPCReplayServer(upstreamURL, goldenFile string) (*RPCReplayServer, error) {
+	s := &RPCReplayServer{
+		upstreamURL: upstreamURL,
+		goldenFile:  goldenFile,
+		recording:   *recordRPC,
+		served:      make(map[string]int),
+	}
+
+	if !s.recording {
+		data, err := ioutil.ReadFile(goldenFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(data, &s.exchanges); err != nil {
+			return nil, err
+		}
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+
+	return s, nil
+}
+
+// Close stops the server. In record mode, recorded exchanges are saved to the golden file.
+func (s *RPCReplayServer) Close() error {
+	s.server.Close()
+
+	if !s.recording {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(s.exchanges, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.goldenFile), os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.goldenFile, data, 0644)
+}
+
+func (s *RPCReplayServer) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		requests []rpcMessage
+		isBatch  = len(bytes.TrimSpace(body)) > 0 && bytes.TrimSpace(body)[0] == '['
+	)
+	if isBatch {
+		err = json.Unmarshal(body, &requests)
+	} else {
+		requests = make([]rpcMessage, 1)
+		err = json.Unmarshal(body, &requests[0])
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var responses []rpcMessage
+	if s.recording {
+		responses, err = s.record(body, requests, isBatch)
+	} else {
+		responses, err = s.replay(requests)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	if isBatch {
+		err = encoder.Encode(responses)
+	} else {
+		err = encoder.Encode(responses[0])
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// record forwards a request body to the upstream and stores responses.
+func (s *RPCReplayServer) record(body []byte, requests []rpcMessage, isBatch bool) ([]rpcMessage, error) {
+	resp, err := http.Post(s.upstreamURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+
+	var responses []rpcMessage
+	if isBatch {
+		err = json.NewDecoder(resp.Body).Decode(&responses)
+	} else {
+		responses = make([]rpcMessage, 1)
+		err = json.NewDecoder(resp.Body).Decode(&responses[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// batch responses may come in any order
+	byID := make(map[string]rpcMessage, len(responses))
+	for _, response := range responses {
+		byID[string(response.ID)] = response
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, request := range requests {
+		response := byID[string(request.ID)]
+		s.exchanges = append(s.exchanges, rpcExchange{
+			Method: request.Method,
+			Params: request.Params,
+			Result: response.Result,
+			Error:  response.Error,
+		})
+	}
+
+	return responses, nil
+}
+
+// replay finds recorded responses for given requests. Repeated requests
+// are answered with subsequent recordings, the last one is reused afterwards.
+func (s *RPCReplayServer) replay(requests []rpcMessage) ([]rpcMessage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	responses := make([]rpcMessage, len(requests))
+	for i, request := range requests {
+		key := request.Method + string(compactJSON(request.Params))
+
+		var matches []rpcExchange
+		for _, exchange := range s.exchanges {
+			if exchange.Method+string(compactJSON(exchange.Params)) == key {
+				matches = append(matches, exchange)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, ErrNoRecordedResponse
+		}
+
+		n := s.served[key]
+		if n >= len(matches) {
+			n = len(matches) - 1
+		}
+		s.served[key]++
+
+		responses[i] = rpcMessage{
+			Version: "2.0",
+			ID:      request.ID,
+			Result:  matches[n].Result,
+			Error:   matches[n].Error,
+		}
+	}
+
+	return responses, nil
+}
+
+// compactJSON strips insignificant whitespace, so that recorded
+// and replayed params are compared regardless of formatting.
+func compactJSON(data json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return data
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gethrpc "github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCReplayServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status-go-replay")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) //nolint: errcheck
+
+	goldenFile := filepath.Join(dir, "rpc", "golden.json")
+
+	blockNumber := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request rpcMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+
+		blockNumber++
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x%x"}`, request.ID, blockNumber) // nolint: errcheck
+	}))
+
+	// replay without a golden file fails
+	_, err = NewRPCReplayServer(upstream.URL, goldenFile)
+	require.True(t, os.IsNotExist(err))
+
+	*recordRPC = true
+	server, err := NewRPCReplayServer(upstream.URL, goldenFile)
+	*recordRPC = false
+	require.NoError(t, err)
+
+	client, err := gethrpc.Dial(server.URL)
+	require.NoError(t, err)
+
+	var result string
+	require.NoError(t, client.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x1", result)
+	require.NoError(t, client.Call(&result, "eth_blockNumber"))
+	require.Equal(t, "0x2", result)
+	require.NoError(t, server.Close())
+
+	// upstream is never reached in replay mode
+	upstream.Close()
+
+	server, err = NewRPCReplayServer(upstream.URL, goldenFile)
+	require.NoError(t, err)
+	defer server.Close() // nolint: errcheck
+
+	client, err = gethrpc.Dial(server.URL)
+	require.NoError(t, err)
+
+	for _, expected := range []string{"0x1", "0x2", "0x2"} {
+		require.NoError(t, client.Call(&result, "eth_blockNumber"))
+		require.Equal(t, expected, result)
+	}
+
+	require.Error(t, client.Call(&result, "eth_gasPrice"))
+}
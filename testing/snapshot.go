@@ -0,0 +1,233 @@
+package integration
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	freshSync = flag.Bool("freshsync", false, "-freshsync to ignore chaindata snapshots and sync from scratch")
+
+	// ErrInvalidSnapshot is returned when a snapshot archive contains entries
+	// pointing outside of the test data directory.
+	ErrInvalidSnapshot = errors.New("snapshot contains invalid entries")
+
+	syncedNetworksMu sync.Mutex
+	syncedNetworks   = make(map[int]bool)
+)
+
+// SnapshotDir returns the directory where chaindata snapshots are stored.
+func SnapshotDir() string {
+	return filepath.Join(TestDataDir, "snapshots")
+}
+
+// SnapshotPath returns the snapshot archive path for a given network id.
+func SnapshotPath(networkID int) string {
+	return filepath.Join(SnapshotDir(), fmt.Sprintf("%d.tar.gz", networkID))
+}
+
+// RestoreSnapshot replaces the test data directory of a given network
+// with a previously saved snapshot. It returns false if there is no
+// snapshot or the -freshsync flag is set.
+func RestoreSnapshot(networkID int) (bool, error) {
+	if *freshSync {
+		return false, nil
+	}
+
+	name, ok := TestNetworkNames[networkID]
+	if !ok {
+		return false, ErrInvalidTestNetwork
+	}
+
+	f, err := os.Open(SnapshotPath(networkID))
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close() // nolint: errcheck
+
+	dataDir := filepath.Join(TestDataDir, name)
+	if err := os.RemoveAll(dataDir); err != nil {
+		return false, err
+	}
+
+	if err := extractArchive(f, dataDir); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SaveSnapshot archives the test data directory of a given network,
+// so that it can be restored with RestoreSnapshot on the next run.
+// The node using this directory must be stopped.
+func SaveSnapshot(networkID int) error {
+	name, ok := TestNetworkNames[networkID]
+	if !ok {
+		return ErrInvalidTestNetwork
+	}
+
+	if err := os.MkdirAll(SnapshotDir(), os.ModePerm); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so an interrupted run
+	// never leaves a truncated snapshot behind
+	path := SnapshotPath(networkID)
+	tmpPath := path + ".tmp"
+
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	err = createArchive(f, filepath.Join(TestDataDir, name))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath) // nolint: errcheck
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// SaveSyncedSnapshot saves a snapshot of a given network only if it was
+// synchronized with EnsureNodeSync since the last snapshot.
+func SaveSyncedSnapshot(networkID int) error {
+	syncedNetworksMu.Lock()
+	synced := syncedNetworks[networkID]
+	delete(syncedNetworks, networkID)
+	syncedNetworksMu.Unlock()
+
+	if !synced {
+		return nil
+	}
+
+	return SaveSnapshot(networkID)
+}
+
+// markSynced records that a given network has been synchronized.
+func markSynced(networkID int) {
+	syncedNetworksMu.Lock()
+	syncedNetworks[networkID] = true
+	syncedNetworksMu.Unlock()
+}
+
+func createArchive(w io.Writer, srcDir string) error {
+	gw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gw)
+
+	err := filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// only regular files and directories are archived, sockets
+		// and lock files of IPC endpoints are skipped
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(srcDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close() // nolint: errcheck
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	return gw.Close()
+}
+
+func extractArchive(r io.Reader, dstDir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close() // nolint: errcheck
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dstDir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dstDir)+string(os.PathSeparator)) {
+			return ErrInvalidSnapshot
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, path, os.FileMode(header.Mode)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractFile(r io.Reader, path string, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -1,25 +1,51 @@
 package integration
 
 import (
-	"bytes"
+	"context"
 	"errors"
 	"flag"
 	"fmt"
-	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/log"
 	"github.com/status-im/status-go/geth/params"
+	"github.com/status-im/status-go/static"
 )
 
 var (
 	networkSelected = flag.String("network", "statuschain", "-network=NETWORKID or -network=NETWORKNAME to select network used for tests")
+	syncTimeout     = flag.Duration("synctimeout", 50*time.Minute, "-synctimeout=DURATION to limit time spent waiting for node synchronization")
+	syncInterval    = flag.Duration("syncinterval", 1*time.Second, "-syncinterval=DURATION to set how often node synchronization status is checked")
+	syncStall       = flag.Duration("syncstalltimeout", 0, "-syncstalltimeout=DURATION to fail node synchronization which makes no progress for that long (0 disables the check)")
+	lesVersion      = flag.String("lesversion", "", "-lesversion=VERSION[,VERSION...] to restrict LES protocol versions advertised by the node (all supported versions by default)")
+	nodeURL         = flag.String("nodeurl", "", "-nodeurl=URL to run tests against an externally managed node's RPC endpoint")
 
-	// ErrNoRemoteURL is returned when network id has no associated url.
-	ErrNoRemoteURL = errors.New("network id requires a remote URL")
+	// ErrNoNodeConfig is returned when running node's configuration can't be retrieved.
+	ErrNoNodeConfig = errors.New("can't retrieve NodeConfig")
+
+	// ErrNoLightEthereumService is returned when LES service is not available.
+	ErrNoLightEthereumService = errors.New("LightEthereumService is nil")
+
+	// ErrNoEthereumService is returned when full node's eth service is not available.
+	ErrNoEthereumService = errors.New("Ethereum service is not available")
+
+	// ErrSyncTimeout is returned when node synchronization is not finished in time.
+	ErrSyncTimeout = errors.New("timeout during node synchronization")
+
+	// ErrSyncStalled is returned when the current block does not advance for too long.
+	ErrSyncStalled = errors.New("node synchronization is stalled")
+
+	// lesProtocolVersions are parsed -lesversion flag values.
+	lesProtocolVersions []uint
 
 	// TestConfig defines the default config usable at package-level.
 	TestConfig *common.TestConfig
@@ -29,14 +55,6 @@ var (
 
 	// TestDataDir is data directory used for tests
 	TestDataDir string
-
-	// TestNetworkNames network ID to name mapping
-	TestNetworkNames = map[int]string{
-		params.MainNetworkID:        "Mainnet",
-		params.RopstenNetworkID:     "Ropsten",
-		params.RinkebyNetworkID:     "Rinkeby",
-		params.StatusChainNetworkID: "StatusChain",
-	}
 )
 
 func init() {
@@ -45,8 +63,23 @@ func init() {
 		panic(err)
 	}
 
+	// testing flags must be registered before parsing, otherwise
+	// go test's own flags (e.g. -test.v) are rejected
+	testing.Init()
 	flag.Parse()
 
+	if *syncInterval <= 0 {
+		panic("-syncinterval must be positive")
+	}
+	if *syncStall < 0 {
+		panic("-syncstalltimeout must not be negative")
+	}
+
+	lesProtocolVersions, err = parseLESVersions(*lesVersion)
+	if err != nil {
+		panic(err)
+	}
+
 	// setup root directory
 	const pathSeparator = string(os.PathSeparator)
 	RootDir = filepath.Dir(pwd)
@@ -68,94 +101,263 @@ func init() {
 	}
 }
 
-// LoadFromFile is useful for loading test data, from testdata/filename into a variable
-// nolint: errcheck
+// LoadFromFile is useful for loading test data, from testdata/filename into a variable.
+// Deprecated: it returns an empty string on any error, use LoadFromFileE or MustLoadFromFile.
 func LoadFromFile(filename string) string {
-	f, err := os.Open(filename)
+	data, _ := LoadFromFileE(filename) // nolint: errcheck
+	return data
+}
+
+// LoadFromFileE loads test data from a given file.
+func LoadFromFileE(filename string) (string, error) {
+	data, err := ioutil.ReadFile(filename)
 	if err != nil {
-		return ""
+		return "", err
 	}
 
-	buf := bytes.NewBuffer(nil)
-	io.Copy(buf, f)
-	f.Close()
+	return string(data), nil
+}
 
-	return string(buf.Bytes())
+// MustLoadFromFile loads test data from a given file and panics on error.
+func MustLoadFromFile(filename string) string {
+	data, err := LoadFromFileE(filename)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}
+
+// LoadEmbeddedTestdata loads test data embedded into the static package
+// from static/testdata, so it is available in binaries built outside
+// of the repository tree. Name is relative to the testdata directory.
+func LoadEmbeddedTestdata(name string) (string, error) {
+	data, err := static.Asset(path.Join("testdata", name))
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// LESProtocolVersions returns LES protocol versions set with -lesversion flag.
+// Empty result means that all versions supported by the node are advertised.
+func LESProtocolVersions() []uint {
+	return lesProtocolVersions
+}
+
+// parseLESVersions parses a comma-separated list of LES protocol versions.
+func parseLESVersions(value string) ([]uint, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	var versions []uint
+	for _, field := range strings.Split(value, ",") {
+		version, err := strconv.ParseUint(strings.TrimSpace(field), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -lesversion value %q: %v", field, err)
+		}
+		versions = append(versions, uint(version))
+	}
+
+	return versions, nil
+}
+
+// ExternalNodeURL returns RPC endpoint of an externally managed node
+// set with -nodeurl flag, or empty string if tests manage their own node.
+func ExternalNodeURL() string {
+	return *nodeURL
+}
+
+// SyncProgress is a snapshot of node synchronization status.
+type SyncProgress struct {
+	CurrentBlock uint64
+	HighestBlock uint64
+	Peers        int
+}
+
+// SyncProgressHandler is called with synchronization status every -syncinterval.
+// Returning an error aborts waiting for synchronization with that error.
+type SyncProgressHandler func(progress SyncProgress) error
+
+// LogSyncProgress is SyncProgressHandler which logs synchronization status.
+func LogSyncProgress(progress SyncProgress) error {
+	log.Info("node synchronization", "current", progress.CurrentBlock, "highest", progress.HighestBlock, "peers", progress.Peers)
+	return nil
+}
+
+// FailOnStalledSync returns SyncProgressHandler which fails with ErrSyncStalled
+// if the current block does not advance for a given duration.
+func FailOnStalledSync(timeout time.Duration) SyncProgressHandler {
+	var (
+		lastBlock    uint64
+		lastProgress = time.Now()
+	)
+
+	return func(progress SyncProgress) error {
+		if progress.CurrentBlock != lastBlock {
+			lastBlock = progress.CurrentBlock
+			lastProgress = time.Now()
+			return nil
+		}
+
+		if time.Since(lastProgress) > timeout {
+			return ErrSyncStalled
+		}
+
+		return nil
+	}
 }
 
 // EnsureNodeSync waits until node synchronzation is done to continue
 // with tests afterwards. Panics in case of an error or a timeout.
+// The timeout is set with -synctimeout flag. Progress is logged and,
+// if -syncstalltimeout is set, a stalled synchronization fails early.
 func EnsureNodeSync(nodeManager common.NodeManager) {
+	ctx, cancel := context.WithTimeout(context.Background(), *syncTimeout)
+	defer cancel()
+
+	handler := LogSyncProgress
+	if *syncStall > 0 {
+		failOnStall := FailOnStalledSync(*syncStall)
+		handler = func(progress SyncProgress) error {
+			if err := LogSyncProgress(progress); err != nil {
+				return err
+			}
+			return failOnStall(progress)
+		}
+	}
+
+	if err := EnsureNodeSyncWithProgress(ctx, nodeManager, handler); err != nil {
+		panic(err)
+	}
+}
+
+// EnsureNodeSyncWithContext waits until node synchronization is done or
+// the context is done. Both light (LES) and full (eth) sync modes are supported.
+// ErrSyncTimeout is returned if the context deadline is exceeded,
+// and ctx.Err() if it is canceled.
+// Synchronization status is polled every -syncinterval.
+// Once synchronized, the network is eligible for SaveSyncedSnapshot.
+// An external node set with -nodeurl is considered synced.
+func EnsureNodeSyncWithContext(ctx context.Context, nodeManager common.NodeManager) error {
+	return EnsureNodeSyncWithProgress(ctx, nodeManager, nil)
+}
+
+// EnsureNodeSyncWithProgress is EnsureNodeSyncWithContext which reports
+// synchronization status to a given handler, if it is not nil.
+func EnsureNodeSyncWithProgress(ctx context.Context, nodeManager common.NodeManager, handler SyncProgressHandler) error {
 	nc, err := nodeManager.NodeConfig()
 	if err != nil {
-		panic("can't retrieve NodeConfig")
+		return ErrNoNodeConfig
 	}
 	// Don't wait for any blockchain sync for the local private chain as blocks are never mined.
 	if nc.NetworkID == params.StatusChainNetworkID {
-		return
+		return nil
+	}
+	// Chain is served by a long-lived external node, local node has nothing to sync.
+	if ExternalNodeURL() != "" {
+		return nil
 	}
 
-	les, err := nodeManager.LightEthereumService()
+	syncService, err := activeSyncService(nodeManager, nc)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	if les == nil {
-		panic("LightEthereumService is nil")
+
+	err = waitForSync(ctx, func() (SyncProgress, bool) {
+		return syncServiceProgress(nodeManager, syncService)
+	}, handler)
+	if err != nil {
+		return err
 	}
 
-	// todo(@jeka): we should extract it into config
-	timeout := time.NewTimer(50 * time.Minute)
-	defer timeout.Stop()
-	ticker := time.NewTicker(1 * time.Second)
+	markSynced(int(nc.NetworkID))
+	return nil
+}
+
+// waitForSync polls a given status function every -syncinterval until it
+// reports the node is synced, the handler fails or the context is done.
+func waitForSync(ctx context.Context, status func() (SyncProgress, bool), handler SyncProgressHandler) error {
+	ticker := time.NewTicker(*syncInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-timeout.C:
-			panic("timeout during node synchronization")
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrSyncTimeout
+			}
+			return ctx.Err()
 		case <-ticker.C:
-			downloader := les.Downloader()
-
-			if downloader != nil {
-				isSyncing := downloader.Synchronising()
-				progress := downloader.Progress()
+			progress, synced := status()
+			if synced {
+				return nil
+			}
 
-				if !isSyncing && progress.HighestBlock > 0 && progress.CurrentBlock >= progress.HighestBlock {
-					return
+			if handler != nil {
+				if err := handler(progress); err != nil {
+					return err
 				}
 			}
 		}
 	}
 }
 
-// GetRemoteURLFromNetworkID returns associated network url for giving network id.
-func GetRemoteURLFromNetworkID(id int) (url string, err error) {
-	switch id {
-	case params.MainNetworkID:
-		url = params.MainnetEthereumNetworkURL
-	case params.RinkebyNetworkID:
-		url = params.RinkebyEthereumNetworkURL
-	case params.RopstenNetworkID:
-		url = params.RopstenEthereumNetworkURL
-	default:
-		err = ErrNoRemoteURL
+// syncServiceProgress returns synchronization status of a given service
+// and whether it has finished.
+func syncServiceProgress(nodeManager common.NodeManager, syncService syncService) (SyncProgress, bool) {
+	var progress SyncProgress
+
+	if runningNode, err := nodeManager.Node(); err == nil && runningNode.Server() != nil {
+		progress.Peers = runningNode.Server().PeerCount()
 	}
 
-	return
+	downloader := syncService.Downloader()
+	if downloader == nil {
+		return progress, false
+	}
+
+	isSyncing := downloader.Synchronising()
+	downloaderProgress := downloader.Progress()
+	progress.CurrentBlock = downloaderProgress.CurrentBlock
+	progress.HighestBlock = downloaderProgress.HighestBlock
+
+	synced := !isSyncing && progress.HighestBlock > 0 && progress.CurrentBlock >= progress.HighestBlock
+	return progress, synced
 }
 
-// GetHeadHashFromNetworkID returns the hash associated with a given network id.
-func GetHeadHashFromNetworkID(id int) string {
-	switch id {
-	case params.RinkebyNetworkID:
-		return "0x6341fd3daf94b748c72ced5a5b26028f2474f5f00d824504e4fa37a75767e177"
-	case params.RopstenNetworkID:
-		return "0x41941023680923e0fe4d74a34bdac8141f2540e3ae90623718e47d66d1ca4a2d"
-	case params.StatusChainNetworkID:
-		return "0xe9d8920a99dc66a9557a87d51f9d14a34ec50aae04298e0f142187427d3c832e"
+// syncService is a chain service which synchronizes blocks with a downloader,
+// either LES or full eth.
+type syncService interface {
+	Downloader() *downloader.Downloader
+}
+
+// activeSyncService returns LES service if light sync mode is set in
+// a given config, and full node's eth service otherwise.
+func activeSyncService(nodeManager common.NodeManager, nc *params.NodeConfig) (syncService, error) {
+	if nc.SyncMode == params.LightSyncMode {
+		lesService, err := nodeManager.LightEthereumService()
+		if err != nil {
+			return nil, err
+		}
+		if lesService == nil {
+			return nil, ErrNoLightEthereumService
+		}
+
+		return lesService, nil
+	}
+
+	ethService, err := nodeManager.EthereumService()
+	if err != nil {
+		return nil, err
+	}
+	if ethService == nil {
+		return nil, ErrNoEthereumService
 	}
 
-	return ""
+	return ethService, nil
 }
 
 // GetRemoteURL returns the url associated with a given network id.
@@ -168,21 +370,6 @@ func GetHeadHash() string {
 	return GetHeadHashFromNetworkID(GetNetworkID())
 }
 
-// GetNetworkID returns appropriate network id for test based on
-// default or provided -network flag.
-func GetNetworkID() int {
-	switch strings.ToLower(*networkSelected) {
-	case fmt.Sprintf("%d", params.RinkebyNetworkID), "rinkeby":
-		return params.RinkebyNetworkID
-	case fmt.Sprintf("%d", params.RopstenNetworkID), "ropsten", "testnet":
-		return params.RopstenNetworkID
-	case fmt.Sprintf("%d", params.StatusChainNetworkID), "statuschain":
-		return params.StatusChainNetworkID
-	}
-
-	return params.StatusChainNetworkID
-}
-
 // GetAccount1PKFile returns the filename for Account1 keystore based
 // on the current network. This allows running the e2e tests on the
 // private network w/o access to the ACCOUNT_PASSWORD env variable
@@ -0,0 +1,286 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/status-im/status-go/geth/common"
+	"github.com/status-im/status-go/geth/params"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnsureNodeSyncWithContext(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	errLES := errors.New("LES is unavailable")
+	errNode := errors.New("no running node")
+
+	testCases := []struct {
+		name          string
+		prepare       func(nodeManager *common.MockNodeManager)
+		expectedError error
+	}{
+		{
+			"node config is not available",
+			func(nodeManager *common.MockNodeManager) {
+				nodeManager.EXPECT().NodeConfig().Return(nil, errors.New("no running node"))
+			},
+			ErrNoNodeConfig,
+		},
+		{
+			"private chain does not need sync",
+			func(nodeManager *common.MockNodeManager) {
+				nodeManager.EXPECT().NodeConfig().Return(
+					params.NewNodeConfig("/tmp", params.StatusChainNetworkID, true),
+				)
+			},
+			nil,
+		},
+		{
+			"LES service error is returned",
+			func(nodeManager *common.MockNodeManager) {
+				nodeManager.EXPECT().NodeConfig().Return(
+					params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+				)
+				nodeManager.EXPECT().LightEthereumService().Return(nil, errLES)
+			},
+			errLES,
+		},
+		{
+			"LES service is nil",
+			func(nodeManager *common.MockNodeManager) {
+				nodeManager.EXPECT().NodeConfig().Return(
+					params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+				)
+				nodeManager.EXPECT().LightEthereumService().Return(nil, nil)
+			},
+			ErrNoLightEthereumService,
+		},
+		{
+			"full node error is returned",
+			func(nodeManager *common.MockNodeManager) {
+				nodeConfig, _ := params.NewNodeConfig("/tmp", params.RopstenNetworkID, true)
+				nodeConfig.SyncMode = params.FullSyncMode
+				nodeManager.EXPECT().NodeConfig().Return(nodeConfig, nil)
+				nodeManager.EXPECT().EthereumService().Return(nil, errNode)
+			},
+			errNode,
+		},
+		{
+			"full node service is nil",
+			func(nodeManager *common.MockNodeManager) {
+				nodeConfig, _ := params.NewNodeConfig("/tmp", params.RopstenNetworkID, true)
+				nodeConfig.SyncMode = params.FastSyncMode
+				nodeManager.EXPECT().NodeConfig().Return(nodeConfig, nil)
+				nodeManager.EXPECT().EthereumService().Return(nil, nil)
+			},
+			ErrNoEthereumService,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Log(tc.name)
+
+		nodeManager := common.NewMockNodeManager(ctrl)
+		tc.prepare(nodeManager)
+
+		err := EnsureNodeSyncWithContext(context.Background(), nodeManager)
+		require.Equal(t, tc.expectedError, err)
+	}
+}
+
+func TestEnsureNodeSyncWithExternalNode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	*nodeURL = "http://localhost:8545"
+	defer func() { *nodeURL = "" }()
+
+	// LES service is never requested
+	nodeManager := common.NewMockNodeManager(ctrl)
+	nodeManager.EXPECT().NodeConfig().Return(
+		params.NewNodeConfig("/tmp", params.RopstenNetworkID, true),
+	)
+
+	require.NoError(t, EnsureNodeSyncWithContext(context.Background(), nodeManager))
+}
+
+func TestLoadFromFile(t *testing.T) {
+	_, err := LoadFromFileE("testdata/missing.json")
+	require.True(t, os.IsNotExist(err))
+	require.Panics(t, func() { MustLoadFromFile("testdata/missing.json") })
+
+	data, err := LoadEmbeddedTestdata("jail/status.js")
+	require.NoError(t, err)
+	require.NotEmpty(t, data)
+
+	_, err = LoadEmbeddedTestdata("jail/missing.js")
+	require.Error(t, err)
+}
+
+func TestRegisterTestNetwork(t *testing.T) {
+	const kovanNetworkID = 42
+
+	err := RegisterTestNetwork(kovanNetworkID, "Kovan", "https://kovan.example.org", "0xa3c565fc15c7478862d50ccd6561e3c06b24cc509bf388941c25ea985ce32cb9")
+	require.NoError(t, err)
+
+	require.Equal(t, "Kovan", TestNetworkNames[kovanNetworkID])
+	require.Equal(t, "0xa3c565fc15c7478862d50ccd6561e3c06b24cc509bf388941c25ea985ce32cb9", GetHeadHashFromNetworkID(kovanNetworkID))
+
+	url, err := GetRemoteURLFromNetworkID(kovanNetworkID)
+	require.NoError(t, err)
+	require.Equal(t, "https://kovan.example.org", url)
+
+	network, ok := lookupTestNetwork("kovan")
+	require.True(t, ok)
+	require.Equal(t, kovanNetworkID, network.ID)
+
+	network, ok = lookupTestNetwork("testnet")
+	require.True(t, ok)
+	require.Equal(t, params.RopstenNetworkID, network.ID)
+
+	require.Equal(t, ErrTestNetworkExists, RegisterTestNetwork(kovanNetworkID, "Other", "", ""))
+	require.Equal(t, ErrTestNetworkExists, RegisterTestNetwork(43, "KOVAN", "", ""))
+	require.Equal(t, ErrInvalidTestNetwork, RegisterTestNetwork(0, "Nameless", "", ""))
+
+	_, err = GetRemoteURLFromNetworkID(params.StatusChainNetworkID)
+	require.Equal(t, ErrNoRemoteURL, err)
+}
+
+func TestSnapshotSaveRestore(t *testing.T) {
+	oldTestDataDir := TestDataDir
+	defer func() { TestDataDir = oldTestDataDir }()
+
+	var err error
+	TestDataDir, err = ioutil.TempDir("", "status-go-snapshot")
+	require.NoError(t, err)
+	defer os.RemoveAll(TestDataDir) //nolint: errcheck
+
+	networkID := params.RopstenNetworkID
+	chainFile := filepath.Join(TestDataDir, TestNetworkNames[networkID], "ethereum", "chaindata", "000001.ldb")
+	require.NoError(t, os.MkdirAll(filepath.Dir(chainFile), os.ModePerm))
+	require.NoError(t, ioutil.WriteFile(chainFile, []byte("headers"), 0600))
+
+	// nothing to restore yet
+	restored, err := RestoreSnapshot(networkID)
+	require.NoError(t, err)
+	require.False(t, restored)
+
+	// network was not synced, so no snapshot is saved
+	require.NoError(t, SaveSyncedSnapshot(networkID))
+	_, err = os.Stat(SnapshotPath(networkID))
+	require.True(t, os.IsNotExist(err))
+
+	markSynced(networkID)
+	require.NoError(t, SaveSyncedSnapshot(networkID))
+
+	require.NoError(t, ioutil.WriteFile(chainFile, []byte("corrupted"), 0600))
+
+	restored, err = RestoreSnapshot(networkID)
+	require.NoError(t, err)
+	require.True(t, restored)
+
+	data, err := ioutil.ReadFile(chainFile)
+	require.NoError(t, err)
+	require.Equal(t, "headers", string(data))
+
+	// -freshsync bypasses snapshots
+	*freshSync = true
+	defer func() { *freshSync = false }()
+
+	restored, err = RestoreSnapshot(networkID)
+	require.NoError(t, err)
+	require.False(t, restored)
+}
+
+func TestNewIsolatedTestEnv(t *testing.T) {
+	env1 := NewIsolatedTestEnv(t)
+	env2 := NewIsolatedTestEnv(t)
+	defer env2.Teardown()
+
+	require.NotEqual(t, env1.DataDir, env2.DataDir)
+
+	ports := map[int]bool{}
+	for _, env := range []*IsolatedTestEnv{env1, env2} {
+		for _, port := range []int{env.ListenPort, env.HTTPPort, env.WSPort} {
+			require.False(t, ports[port], "port %d is allocated twice", port)
+			ports[port] = true
+		}
+	}
+
+	config, err := params.NewNodeConfig("/tmp", params.StatusChainNetworkID, true)
+	require.NoError(t, err)
+	env1.Configure(config)
+	require.Equal(t, env1.DataDir, config.DataDir)
+	require.Equal(t, filepath.Join(env1.DataDir, params.KeyStoreDir), config.KeyStoreDir)
+	require.Equal(t, env1.HTTPPort, config.HTTPPort)
+
+	env1.Teardown()
+	_, err = os.Stat(env1.DataDir)
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestWaitForSync(t *testing.T) {
+	var (
+		reported []SyncProgress
+		statuses = []SyncProgress{
+			{CurrentBlock: 1, HighestBlock: 3, Peers: 1},
+			{CurrentBlock: 2, HighestBlock: 3, Peers: 2},
+		}
+	)
+
+	status := func() (SyncProgress, bool) {
+		if len(reported) == len(statuses) {
+			return SyncProgress{CurrentBlock: 3, HighestBlock: 3}, true
+		}
+		return statuses[len(reported)], false
+	}
+	handler := func(progress SyncProgress) error {
+		reported = append(reported, progress)
+		return nil
+	}
+
+	require.NoError(t, waitForSync(context.Background(), status, handler))
+	require.Equal(t, statuses, reported)
+
+	// handler error aborts waiting
+	errAbort := errors.New("abort")
+	err := waitForSync(context.Background(), func() (SyncProgress, bool) {
+		return SyncProgress{}, false
+	}, func(SyncProgress) error {
+		return errAbort
+	})
+	require.Equal(t, errAbort, err)
+}
+
+func TestFailOnStalledSync(t *testing.T) {
+	handler := FailOnStalledSync(50 * time.Millisecond)
+
+	require.NoError(t, handler(SyncProgress{CurrentBlock: 1}))
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, ErrSyncStalled, handler(SyncProgress{CurrentBlock: 1}))
+
+	// progress resets the stall timer
+	require.NoError(t, handler(SyncProgress{CurrentBlock: 2}))
+	require.NoError(t, handler(SyncProgress{CurrentBlock: 2}))
+}
+
+func TestParseLESVersions(t *testing.T) {
+	versions, err := parseLESVersions("")
+	require.NoError(t, err)
+	require.Empty(t, versions)
+
+	versions, err = parseLESVersions("1, 2")
+	require.NoError(t, err)
+	require.Equal(t, []uint{1, 2}, versions)
+
+	_, err = parseLESVersions("les/1")
+	require.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/geth/common"
+)
+
+// waitPollInterval is how often WaitFor* helpers query the node.
+const waitPollInterval = 100 * time.Millisecond
+
+var (
+	// ErrWaitTimeout is returned when a WaitFor* helper times out.
+	ErrWaitTimeout = errors.New("timeout while waiting for the node")
+)
+
+// Block holds a subset of block fields returned by WaitForBlockHeight.
+type Block struct {
+	Number     hexutil.Uint64  `json:"number"`
+	Hash       gethcommon.Hash `json:"hash"`
+	ParentHash gethcommon.Hash `json:"parentHash"`
+	Timestamp  hexutil.Uint64  `json:"timestamp"`
+}
+
+// Receipt holds a subset of transaction receipt fields returned by WaitForReceipt.
+type Receipt struct {
+	TxHash          gethcommon.Hash     `json:"transactionHash"`
+	BlockHash       gethcommon.Hash     `json:"blockHash"`
+	BlockNumber     hexutil.Uint64      `json:"blockNumber"`
+	GasUsed         hexutil.Uint64      `json:"gasUsed"`
+	ContractAddress *gethcommon.Address `json:"contractAddress"`
+}
+
+// WaitForBlockHeight waits until the node reaches a given block height
+// and returns the block at that height.
+func WaitForBlockHeight(nodeManager common.NodeManager, height uint64, timeout time.Duration) (*Block, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	rpcClient := nodeManager.RPCClient()
+
+	err := poll(ctx, func() (bool, error) {
+		var blockNumber hexutil.Uint64
+		if err := rpcClient.CallContext(ctx, &blockNumber, "eth_blockNumber"); err != nil {
+			return false, err
+		}
+
+		return uint64(blockNumber) >= height, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var block *Block
+	if err := rpcClient.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(height), false); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+// WaitForReceipt waits until a transaction with a given hash is mined
+// and returns its receipt.
+func WaitForReceipt(nodeManager common.NodeManager, txHash gethcommon.Hash, timeout time.Duration) (*Receipt, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	rpcClient := nodeManager.RPCClient()
+
+	var receipt *Receipt
+	err := poll(ctx, func() (bool, error) {
+		// receipt is null until the transaction is mined
+		if err := rpcClient.CallContext(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+			return false, err
+		}
+
+		return receipt != nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// poll calls a given condition every waitPollInterval until it is met,
+// returns an error or the context is done.
+func poll(ctx context.Context, condition func() (bool, error)) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := condition()
+		if ctx.Err() == context.DeadlineExceeded {
+			return ErrWaitTimeout
+		}
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			if ctx.Err() == context.DeadlineExceeded {
+				return ErrWaitTimeout
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
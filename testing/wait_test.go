@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gethcommon "github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/status-im/status-go/testing/mocks"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForBlockHeight(t *testing.T) {
+	nodeManager := mocks.NewNodeManager()
+
+	var blockNumber hexutil.Uint64
+	nodeManager.RPCClient().RegisterHandler("eth_blockNumber", func(context.Context, ...interface{}) (interface{}, error) {
+		blockNumber++
+		return blockNumber, nil
+	})
+	nodeManager.SetRPCResponse("eth_getBlockByNumber", &Block{Number: 3, Hash: gethcommon.HexToHash("0x03")}, nil)
+
+	block, err := WaitForBlockHeight(nodeManager, 3, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, hexutil.Uint64(3), block.Number)
+	require.Equal(t, gethcommon.HexToHash("0x03"), block.Hash)
+
+	_, err = WaitForBlockHeight(nodeManager, 1000, 3*waitPollInterval)
+	require.Equal(t, ErrWaitTimeout, err)
+}
+
+func TestWaitForReceipt(t *testing.T) {
+	nodeManager := mocks.NewNodeManager()
+	txHash := gethcommon.HexToHash("0x01")
+
+	nodeManager.SetRPCResponse("eth_getTransactionReceipt", (*Receipt)(nil), nil)
+	_, err := WaitForReceipt(nodeManager, txHash, 3*waitPollInterval)
+	require.Equal(t, ErrWaitTimeout, err)
+
+	nodeManager.SetRPCResponse("eth_getTransactionReceipt", &Receipt{TxHash: txHash, BlockNumber: 7}, nil)
+	receipt, err := WaitForReceipt(nodeManager, txHash, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, txHash, receipt.TxHash)
+	require.Equal(t, hexutil.Uint64(7), receipt.BlockNumber)
+}
@@ -0,0 +1,246 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	whisper "github.com/ethereum/go-ethereum/whisper/whisperv5"
+	"github.com/status-im/status-go/geth/node"
+	"github.com/status-im/status-go/geth/params"
+)
+
+// whisperPeeringTimeout limits time spent waiting for nodes of a pair to connect.
+const whisperPeeringTimeout = 10 * time.Second
+
+// WhisperTestPair is a pair of connected in-process nodes, used to test
+// that Whisper messages travel from Sender to Receiver.
+// Messages are encrypted with a symmetric key shared by both nodes.
+type WhisperTestPair struct {
+	Sender   *node.NodeManager
+	Receiver *node.NodeManager
+
+	envs   []*IsolatedTestEnv
+	symKey []byte
+
+	mu       sync.Mutex
+	filters  map[whisper.TopicType]string                     // receiver filter IDs
+	received map[whisper.TopicType][]*whisper.ReceivedMessage // retrieved, but not yet expected
+}
+
+// NewWhisperTestPair starts two nodes on the StatusChain network and peers them.
+// Teardown must be called once the test is done.
+func NewWhisperTestPair(t *testing.T) *WhisperTestPair {
+	return NewWhisperTestPairWithFaults(t, nil)
+}
+
+// NewWhisperTestPairWithFaults is NewWhisperTestPair which connects Sender
+// to Receiver through a given FaultInjector, so that messages can be sent
+// under adverse network conditions. Faults may be configured at any time.
+func NewWhisperTestPairWithFaults(t *testing.T, faults *FaultInjector) *WhisperTestPair {
+	p := &WhisperTestPair{
+		filters:  make(map[whisper.TopicType]string),
+		received: make(map[whisper.TopicType][]*whisper.ReceivedMessage),
+	}
+
+	receiverEnv, senderEnv := NewIsolatedTestEnv(t), NewIsolatedTestEnv(t)
+	p.envs = []*IsolatedTestEnv{receiverEnv, senderEnv}
+
+	var err error
+	if p.Receiver, err = startWhisperNode(receiverEnv, nil); err == nil {
+		p.Sender, err = startWhisperNode(senderEnv, faults)
+	}
+	if err != nil {
+		p.Teardown()
+		t.Fatalf("failed to start node: %v", err)
+	}
+
+	if err := p.connect(receiverEnv.ListenPort); err != nil {
+		p.Teardown()
+		t.Fatalf("failed to connect nodes: %v", err)
+	}
+
+	senderWhisper, err := p.Sender.WhisperService()
+	if err != nil {
+		p.Teardown()
+		t.Fatalf("failed to get Whisper service: %v", err)
+	}
+
+	keyID, err := senderWhisper.GenerateSymKey()
+	if err == nil {
+		p.symKey, err = senderWhisper.GetSymKey(keyID)
+	}
+	if err != nil {
+		p.Teardown()
+		t.Fatalf("failed to generate symmetric key: %v", err)
+	}
+
+	return p
+}
+
+// Send posts a message with a given topic and payload from Sender.
+func (p *WhisperTestPair) Send(topic whisper.TopicType, payload []byte) error {
+	// filter must be installed before the message arrives
+	if _, err := p.receiverFilter(topic); err != nil {
+		return err
+	}
+
+	senderWhisper, err := p.Sender.WhisperService()
+	if err != nil {
+		return err
+	}
+
+	messageParams := &whisper.MessageParams{
+		KeySym:   p.symKey,
+		Topic:    topic,
+		Payload:  payload,
+		PoW:      senderWhisper.MinPow(),
+		WorkTime: 1,
+	}
+
+	message, err := whisper.NewSentMessage(messageParams)
+	if err != nil {
+		return err
+	}
+
+	envelope, err := message.Wrap(messageParams)
+	if err != nil {
+		return err
+	}
+
+	return senderWhisper.Send(envelope)
+}
+
+// Expect waits until Receiver gets a message with a given topic and payload.
+// ErrWaitTimeout is returned if the message does not arrive in time.
+func (p *WhisperTestPair) Expect(topic whisper.TopicType, payload []byte, timeout time.Duration) error {
+	filter, err := p.receiverFilter(topic)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return poll(ctx, func() (bool, error) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		messages := append(p.received[topic], filter.Retrieve()...)
+		for i, message := range messages {
+			if bytes.Equal(message.Payload, payload) {
+				p.received[topic] = append(messages[:i], messages[i+1:]...)
+				return true, nil
+			}
+		}
+		p.received[topic] = messages
+
+		return false, nil
+	})
+}
+
+// Teardown stops both nodes and removes their data.
+func (p *WhisperTestPair) Teardown() {
+	for _, nodeManager := range []*node.NodeManager{p.Sender, p.Receiver} {
+		if nodeManager == nil || !nodeManager.IsNodeRunning() {
+			continue
+		}
+
+		if nodeStopped, err := nodeManager.StopNode(); err == nil {
+			<-nodeStopped
+		}
+	}
+
+	for _, env := range p.envs {
+		env.Teardown()
+	}
+}
+
+// connect adds Receiver as a static peer of Sender and waits for the connection.
+func (p *WhisperTestPair) connect(receiverPort int) error {
+	receiverNode, err := p.Receiver.Node()
+	if err != nil {
+		return err
+	}
+
+	// advertised address may be altered by NAT, so loopback is used explicitly
+	receiverID := receiverNode.Server().Self().ID
+	receiverEnode := discover.NewNode(receiverID, net.ParseIP("127.0.0.1"), 0, uint16(receiverPort)).String()
+
+	if err := p.Sender.AddPeer(receiverEnode); err != nil {
+		return err
+	}
+
+	senderNode, err := p.Sender.Node()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), whisperPeeringTimeout)
+	defer cancel()
+
+	return poll(ctx, func() (bool, error) {
+		return senderNode.Server().PeerCount() > 0, nil
+	})
+}
+
+// receiverFilter returns Receiver's filter for a given topic, installing it if necessary.
+func (p *WhisperTestPair) receiverFilter(topic whisper.TopicType) (*whisper.Filter, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	receiverWhisper, err := p.Receiver.WhisperService()
+	if err != nil {
+		return nil, err
+	}
+
+	if filterID, ok := p.filters[topic]; ok {
+		return receiverWhisper.GetFilter(filterID), nil
+	}
+
+	filter := &whisper.Filter{
+		KeySym: p.symKey,
+		Topics: [][]byte{topic[:]},
+	}
+
+	filterID, err := receiverWhisper.Subscribe(filter)
+	if err != nil {
+		return nil, err
+	}
+	p.filters[topic] = filterID
+
+	return filter, nil
+}
+
+// startWhisperNode starts a node with Whisper only, using a given env.
+// Peer connections are subject to faults of a given injector, if it is not nil.
+func startWhisperNode(env *IsolatedTestEnv, faults *FaultInjector) (*node.NodeManager, error) {
+	config, err := params.NewNodeConfig(env.DataDir, params.StatusChainNetworkID, true)
+	if err != nil {
+		return nil, err
+	}
+	env.Configure(config)
+	config.LightEthConfig.Enabled = false
+	config.BootClusterConfig.Enabled = false
+
+	nodeManager := node.NewNodeManager()
+	if faults != nil {
+		nodeManager.SetDialer(faults)
+	}
+	nodeStarted, err := nodeManager.StartNode(config)
+	if err != nil {
+		return nil, err
+	}
+	<-nodeStarted
+
+	// nodeStarted is closed on failure as well
+	if !nodeManager.IsNodeRunning() {
+		return nil, node.ErrNoRunningNode
+	}
+
+	return nodeManager, nil
+}